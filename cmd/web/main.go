@@ -2,10 +2,12 @@ package main
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
 	"github.com/anglesson/simple-web-server/pkg/mail"
 	"github.com/anglesson/simple-web-server/pkg/storage"
@@ -45,27 +47,31 @@ func main() {
 	userRepository := repository.NewGormUserRepository(database.DB)
 	fileRepository := repository.NewGormFileRepository(database.DB)
 	purchaseRepository := repository.NewPurchaseRepository()
+	orderRepository := repository.NewGormOrderRepository(database.DB)
+	planRepository := repository.NewGormPlanRepository(database.DB)
+	usageRepository := repository.NewGormUsageRepository(database.DB)
 
 	// Services
 	commonRFService := gov.NewHubDevService()
 	userService := service.NewUserService(userRepository, encrypter)
 	sessionService := service.NewSessionService()
 	subscriptionRepository := gorm.NewSubscriptionGormRepository()
-	subscriptionService := service.NewSubscriptionService(subscriptionRepository, commonRFService)
-	stripeService := service.NewStripeService()
-	paymentGateway := service.NewStripePaymentGateway(stripeService)
+	paymentGateway := service.NewPaymentGatewayFromConfig()
+	subscriptionService := service.NewSubscriptionService(subscriptionRepository, commonRFService, paymentGateway)
 	creatorService := service.NewCreatorService(creatorRepository, commonRFService, userService, subscriptionService, paymentGateway)
-	clientService := service.NewClientService(clientRepository, creatorRepository, commonRFService)
+	planService := service.NewPlanService(planRepository, usageRepository, creatorRepository, subscriptionService, paymentGateway)
+	clientService := service.NewClientService(clientRepository, creatorRepository, commonRFService, planService)
 	s3Storage := storage.NewS3Storage()
-	fileService := service.NewFileService(fileRepository, s3Storage)
-	ebookService := service.NewEbookService(s3Storage)
+	fileService := service.NewFileService(fileRepository, s3Storage, planService)
+	ebookService := service.NewEbookService(s3Storage, planService)
 	emailService := service.NewEmailService()
+	orderService := service.NewOrderService(orderRepository)
 
 	// Handlers
 	authHandler := handler.NewAuthHandler(userService, sessionService, templateRenderer)
 	clientHandler := handler.NewClientHandler(clientService, creatorService, flashServiceFactory, templateRenderer)
 	creatorHandler := handler.NewCreatorHandler(creatorService, sessionService, templateRenderer)
-	settingsHandler := handler.NewSettingsHandler(sessionService, templateRenderer)
+	settingsHandler := handler.NewSettingsHandler(sessionService, templateRenderer, planService, creatorService)
 	fileHandler := handler.NewFileHandler(fileService, sessionService, templateRenderer, flashServiceFactory)
 	ebookHandler := handler.NewEbookHandler(ebookService, creatorService, fileService, s3Storage, flashServiceFactory, templateRenderer)
 	salesPageHandler := handler.NewSalesPageHandler(ebookService, creatorService, templateRenderer)
@@ -75,7 +81,7 @@ func main() {
 	forgetPasswordHandler := handler.NewForgetPasswordHandler(templateRenderer, userService, emailService)
 	resetPasswordHandler := handler.NewResetPasswordHandler(templateRenderer, userService)
 	sendHandler := handler.NewSendHandler(templateRenderer)
-	purchaseHandler := handler.NewPurchaseHandler(templateRenderer)
+	purchaseHandler := handler.NewPurchaseHandler(templateRenderer, creatorService, planService)
 	// Criar emailService para o StripeHandler
 	mailPort, _ := strconv.Atoi(config.AppConfig.MailPort)
 	stripeEmailService := mail.NewEmailService(mail.NewGoMailer(
@@ -83,10 +89,68 @@ func main() {
 		mailPort,
 		config.AppConfig.MailUsername,
 		config.AppConfig.MailPassword))
-	checkoutHandler := handler.NewCheckoutHandler(templateRenderer, ebookService, clientService, creatorService, commonRFService, stripeEmailService)
+	affiliateService := service.NewAffiliateService(repository.NewGormAffiliateRepository(database.DB), orderRepository, ebookService)
+	affiliateHandler := handler.NewAffiliateHandler(affiliateService, ebookService, creatorService, templateRenderer)
+	offerService := service.NewOfferService(repository.NewGormOfferRepository(database.DB), ebookService, orderService, paymentGateway)
+	offerHandler := handler.NewOfferHandler(offerService, ebookService, creatorService, templateRenderer)
+	ebookPriceService := service.NewEbookPriceService(repository.NewGormEbookPriceRepository(database.DB))
+	ebookPriceHandler := handler.NewEbookPriceHandler(ebookPriceService, ebookService, creatorService, templateRenderer)
+	recoveryService := service.NewCheckoutRecoveryService(repository.NewGormCheckoutAttemptRepository(database.DB), orderRepository, stripeEmailService,
+		config.AppConfig.CheckoutAbandonMinutes, config.AppConfig.CheckoutRecoveryHours, config.AppConfig.CheckoutRecoveryDiscount)
+	recoveryService.StartWorker(5 * time.Minute)
+	giftService := service.NewGiftService(repository.NewGormGiftRepository(database.DB), stripeEmailService)
+	giftService.StartWorker(5 * time.Minute)
+	giftHandler := handler.NewGiftHandler(giftService, commonRFService, templateRenderer)
+	licenseService := service.NewLicenseService(repository.NewGormLicenseRepository(database.DB), clientRepository, stripeEmailService)
+	licenseService.StartWorker(5 * time.Minute)
+	licenseHandler := handler.NewLicenseHandler(licenseService, ebookService, creatorService, templateRenderer)
+	membershipService := service.NewMembershipService(repository.NewGormMembershipRepository(database.DB), paymentGateway, stripeEmailService)
+	membershipService.StartWorker(5 * time.Minute)
+	membershipHandler := handler.NewMembershipHandler(membershipService, creatorService, commonRFService, templateRenderer)
+	dripService := service.NewDripService(repository.NewGormEbookFileRepository(database.DB), stripeEmailService)
+	dripService.StartWorker(5 * time.Minute)
+	dripHandler := handler.NewDripHandler(dripService, ebookService, creatorService, templateRenderer)
+
+	// Lançamentos têm hora marcada, então o agendador roda a cada minuto
+	launchService := service.NewEbookLaunchService(repository.NewGormEbookLaunchRepository(database.DB), stripeEmailService)
+	launchService.StartWorker(time.Minute)
+	launchHandler := handler.NewEbookLaunchHandler(launchService, ebookService, creatorService)
+
+	storefrontService := service.NewStorefrontService(repository.NewGormStorefrontRepository(database.DB), s3Storage)
+	storefrontHandler := handler.NewStorefrontHandler(storefrontService, creatorService, templateRenderer)
+	customDomainService := service.NewCustomDomainService(repository.NewGormCustomDomainRepository(database.DB), net.LookupTXT)
+	customDomainHandler := handler.NewCustomDomainHandler(customDomainService, creatorService, templateRenderer)
+
+	fileVersionService := service.NewFileVersionService(repository.NewGormFileVersionRepository(database.DB), fileService, s3Storage, planService, stripeEmailService)
+	fileVersionHandler := handler.NewFileVersionHandler(fileVersionService, fileService, ebookService, creatorService, templateRenderer)
+	downloadAccessService := service.NewDownloadAccessService(repository.NewGormDownloadAccessRepository(database.DB))
+	downloadAccessHandler := handler.NewDownloadAccessHandler(downloadAccessService, clientService, creatorService, templateRenderer)
+	downloadReviewService := service.NewDownloadReviewService(repository.NewGormDownloadReviewRepository(database.DB))
+	downloadReviewHandler := handler.NewDownloadReviewHandler(downloadReviewService, creatorService, templateRenderer)
+	checkoutHandler := handler.NewCheckoutHandler(templateRenderer, ebookService, clientService, creatorService, commonRFService, orderService, offerService, affiliateService, recoveryService, giftService, licenseService, paymentGateway, purchaseRepository, stripeEmailService)
+	leadService := service.NewLeadService(repository.NewGormLeadRepository(database.DB), clientRepository, stripeEmailService)
+	leadHandler := handler.NewLeadHandler(leadService, ebookService, templateRenderer)
+	cartService := service.NewCartService(ebookService)
+	cartHandler := handler.NewCartHandler(templateRenderer, cartService, creatorService, orderService, affiliateService, commonRFService)
+	bundleService := service.NewBundleService(repository.NewGormBundleRepository(database.DB), ebookService)
+	bundleHandler := handler.NewBundleHandler(bundleService, ebookService, creatorService, orderService, affiliateService, commonRFService, templateRenderer)
 	versionHandler := handler.NewVersionHandler()
+	planHandler := handler.NewPlanHandler(planService, templateRenderer)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService, planService, templateRenderer)
+
+	webhookEventRepository := repository.NewGormWebhookEventRepository(database.DB)
+	dunningService := service.NewDunningService(subscriptionService, stripeEmailService, config.AppConfig.DunningGraceDays, config.AppConfig.DunningReminderDays)
+	dunningService.StartReminderWorker(time.Hour)
 
-	stripeHandler := handler.NewStripeHandler(userRepository, subscriptionService, purchaseRepository, stripeEmailService)
+	payoutService := service.NewPayoutService(repository.NewGormPayoutRepository(database.DB), paymentGateway, config.AppConfig.PlatformFeePercent, config.AppConfig.PayoutHoldDays)
+	payoutService.StartScheduler(time.Hour)
+	payoutHandler := handler.NewPayoutHandler(payoutService, creatorService, templateRenderer)
+
+	stripeWebhookProcessor := service.NewStripeWebhookProcessor(subscriptionService, dunningService, orderService, recoveryService, membershipService, paymentGateway, purchaseRepository, stripeEmailService)
+	webhookService := service.NewWebhookService(webhookEventRepository, stripeWebhookProcessor)
+	webhookService.StartRetryWorker(time.Minute)
+
+	stripeHandler := handler.NewStripeHandler(userRepository, subscriptionService, planService, webhookService)
 
 	// Initialize rate limiters
 	authRateLimiter := middleware.NewRateLimiter(10, time.Minute)         // 10 requests per minute for auth (increased from 5)
@@ -105,6 +169,9 @@ func main() {
 	// Apply security headers to all routes
 	r.Use(middleware.SecurityHeaders)
 
+	// Domínios próprios dos criadores são roteados pelo cabeçalho Host
+	r.Use(middleware.CustomDomain(customDomainService))
+
 	fs := http.FileServer(http.Dir("web/assets"))
 	r.Get("/assets/*", func(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/assets/", fs).ServeHTTP(w, r)
@@ -131,13 +198,34 @@ func main() {
 		r.Post("/login", authHandler.LoginSubmit)
 		r.Get("/register", creatorHandler.RegisterView)
 		r.Post("/register", creatorHandler.RegisterCreatorSSR)
-		r.Get("/sales/{slug}", salesPageHandler.SalesPageView) // Página de vendas pública
+		r.With(middleware.ReferralCookie).Get("/sales/{slug}", salesPageHandler.SalesPageView) // Página de vendas pública
+		r.Get("/sales/kit/{slug}", bundleHandler.SalesPageView)
 	})
 
 	// Completely public routes (no middleware)
 	r.Get("/purchase/download/{id}", purchaseHandler.PurchaseDownloadHandler)
-	r.Get("/checkout/{id}", checkoutHandler.CheckoutView)
+	r.With(middleware.ReferralCookie).Get("/checkout/{id}", checkoutHandler.CheckoutView)
+	r.Post("/checkout/{id}/free", leadHandler.SubscribeSubmit)
+	r.Get("/lead/confirm/{token}", leadHandler.ConfirmView)
+	r.Get("/gift/{token}", giftHandler.ClaimView)
+	r.Post("/gift/{token}", giftHandler.ClaimSubmit)
+	r.Get("/license/{token}", licenseHandler.ManageView)
+	r.Post("/license/{token}/seats", licenseHandler.AssignSubmit)
+	r.Post("/license/{token}/seats/{seatID}/reassign", licenseHandler.ReassignSubmit)
+	r.Get("/m/{id}", membershipHandler.SubscribeView)
+	r.Post("/m/{id}", membershipHandler.SubscribeSubmit)
+	r.Get("/membership/success", membershipHandler.SuccessView)
+	r.Get("/membership/{token}", membershipHandler.MemberView)
+	r.Post("/membership/{token}/cancel", membershipHandler.CancelSubmit)
+	r.Get("/affiliate/{token}", affiliateHandler.DashboardView)
+	r.Get("/checkout/kit/{slug}", bundleHandler.CheckoutView)
 	r.Get("/purchase/success", checkoutHandler.PurchaseSuccessView)
+	r.Post("/purchase/upsell/{id}", checkoutHandler.AcceptUpsell)
+	r.Get("/cart", cartHandler.CartView)
+	r.Post("/cart/items/{id}", cartHandler.AddItem)
+	r.Post("/cart/items/{id}/remove", cartHandler.RemoveItem)
+	r.Get("/pricing", planHandler.PricingView)
+	r.With(middleware.ReferralCookie).Get("/c/{slug}", storefrontHandler.StorefrontView) // Vitrine pública do criador
 
 	// Version routes
 	r.Get("/version", versionHandler.VersionText)
@@ -151,6 +239,16 @@ func main() {
 		r.Post("/api/watermark", handler.WatermarkHandler)
 		r.Post("/api/validate-customer", checkoutHandler.ValidateCustomer)
 		r.Post("/api/create-ebook-checkout", checkoutHandler.CreateEbookCheckout)
+		r.Post("/api/create-cart-checkout", cartHandler.CreateCartCheckout)
+		r.Post("/api/create-bundle-checkout", bundleHandler.CreateBundleCheckout)
+	})
+
+	// API dos criadores, liberada só nos planos com acesso à API
+	r.Group(func(r chi.Router) {
+		r.Use(apiRateLimiter.RateLimitMiddleware)
+		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.PlanFeature(planService, models.FeatureAPIAccess))
+		r.Post("/api/v1/watermark", handler.CreatorWatermarkHandler)
 	})
 
 	// Private routes
@@ -162,6 +260,31 @@ func main() {
 		r.Post("/logout", authHandler.LogoutSubmit)
 		r.Get("/dashboard", dashboardHandler.DashboardView)
 		r.Get("/settings", settingsHandler.SettingsView)
+		r.Post("/settings/plan", planHandler.ChangePlanSubmit)
+		r.Post("/settings/storefront", storefrontHandler.ProfileSubmit)
+		r.Get("/settings/domain", customDomainHandler.DomainView)
+		r.Post("/settings/domain", customDomainHandler.RegisterSubmit)
+		r.Post("/settings/domain/verify", customDomainHandler.VerifySubmit)
+		r.Post("/settings/domain/remove", customDomainHandler.RemoveSubmit)
+		r.Get("/settings/subscription", subscriptionHandler.ManageView)
+		r.Post("/settings/subscription/cancel", subscriptionHandler.CancelSubmit)
+		r.Post("/settings/subscription/resume", subscriptionHandler.ResumeSubmit)
+		r.Post("/settings/subscription/payment-method", subscriptionHandler.PaymentMethodSubmit)
+
+		// Afiliados
+		r.Get("/affiliates", affiliateHandler.IndexView)
+		r.Post("/affiliates", affiliateHandler.CreateSubmit)
+		r.Post("/affiliates/rules", affiliateHandler.RulesSubmit)
+		r.Post("/affiliates/{id}/toggle", affiliateHandler.ToggleSubmit)
+		r.Get("/affiliates/commissions.csv", affiliateHandler.ExportCSV)
+
+		// Repasses
+		r.Get("/payouts", payoutHandler.StatementView)
+		r.Post("/payouts/account", payoutHandler.OnboardingSubmit)
+		r.Get("/payouts/account/refresh", payoutHandler.OnboardingRefresh)
+		r.Get("/payouts/account/return", payoutHandler.OnboardingReturn)
+		r.Post("/payouts/schedule", payoutHandler.ScheduleSubmit)
+		r.Post("/payouts/withdraw", payoutHandler.WithdrawSubmit)
 
 		// Ebook routes
 		r.Get("/ebook", ebookHandler.IndexView)
@@ -173,6 +296,31 @@ func main() {
 		r.Get("/ebook/preview/{id}", salesPageHandler.SalesPagePreviewView) // Preview da página de vendas
 		r.Get("/ebook/sales-page/{slug}", salesPageHandler.SalesPageView)   // Página de vendas (alias para preview)
 		r.Get("/ebook/{id}/image", ebookHandler.ServeEbookImage)
+		r.Get("/ebook/{id}/offers", offerHandler.IndexView)
+		r.Post("/ebook/{id}/offers", offerHandler.CreateSubmit)
+		r.Post("/ebook/{id}/offers/{offerID}/toggle", offerHandler.ToggleSubmit)
+		r.Post("/ebook/{id}/offers/{offerID}/delete", offerHandler.DeleteSubmit)
+		r.Get("/ebook/{id}/prices", ebookPriceHandler.IndexView)
+		r.Post("/ebook/{id}/prices", ebookPriceHandler.CreateSubmit)
+		r.Post("/ebook/{id}/prices/{periodID}/delete", ebookPriceHandler.DeleteSubmit)
+		r.Get("/ebook/{id}/licenses", licenseHandler.IndexView)
+		r.Get("/ebook/{id}/schedule", dripHandler.IndexView)
+		r.Post("/ebook/{id}/schedule", dripHandler.UpdateSubmit)
+		r.Post("/ebook/{id}/release", launchHandler.ReleaseSubmit)
+		r.Post("/ebook/{id}/notify-update", fileVersionHandler.NotifySubmit)
+
+		// Bundle routes
+		r.Get("/bundle", bundleHandler.IndexView)
+		r.Get("/bundle/create", bundleHandler.CreateView)
+		r.Post("/bundle/create", bundleHandler.CreateSubmit)
+		r.Get("/bundle/{id}/edit", bundleHandler.UpdateView)
+		r.Post("/bundle/{id}/edit", bundleHandler.UpdateSubmit)
+		r.Post("/bundle/{id}/delete", bundleHandler.DeleteSubmit)
+
+		// Membership routes
+		r.Get("/memberships", membershipHandler.IndexView)
+		r.Post("/memberships", membershipHandler.CreateSubmit)
+		r.Post("/memberships/{id}/toggle", membershipHandler.ToggleSubmit)
 
 		// File routes with upload rate limiting
 		r.Group(func(r chi.Router) {
@@ -182,6 +330,8 @@ func main() {
 			r.Post("/file/upload", fileHandler.FileUploadSubmit)
 			r.Post("/file/{id}/update", fileHandler.FileUpdateSubmit)
 			r.Post("/file/{id}/delete", fileHandler.FileDeleteSubmit)
+			r.Get("/file/{id}/versions", fileVersionHandler.IndexView)
+			r.Post("/file/{id}/versions", fileVersionHandler.PublishSubmit)
 		})
 
 		// Client routes
@@ -191,6 +341,14 @@ func main() {
 		r.Get("/client/update/{id}", clientHandler.UpdateView)
 		r.Post("/client/update/{id}", clientHandler.ClientUpdateSubmit)
 		r.Post("/client/import", clientHandler.ClientImportSubmit)
+		r.Get("/client/{id}/downloads", downloadAccessHandler.IndexView)
+		r.Post("/client/{id}/downloads/{purchaseID}/reset", downloadAccessHandler.ResetSubmit)
+		r.Post("/client/{id}/downloads/{purchaseID}/extend", downloadAccessHandler.ExtendSubmit)
+		r.Get("/downloads/review", downloadReviewHandler.IndexView)
+		r.Post("/downloads/review/settings", downloadReviewHandler.SettingsSubmit)
+		r.Post("/downloads/review/watermark", downloadReviewHandler.WatermarkSubmit)
+		r.Post("/downloads/review/{purchaseID}/dismiss", downloadReviewHandler.DismissSubmit)
+		r.Post("/downloads/review/{purchaseID}/suspend", downloadReviewHandler.SuspendSubmit)
 
 		// Purchase routes
 		r.Post("/purchase/ebook/{id}", purchaseHandler.PurchaseCreateHandler)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/internal/repository/gorm"
+	"github.com/anglesson/simple-web-server/internal/service"
+	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/mail"
+)
+
+// Reprocessa eventos de webhook registrados.
+//
+// Uso:
+//
+//	go run cmd/webhook-replay/main.go -event evt_123
+//	go run cmd/webhook-replay/main.go -from 2025-01-01T00:00:00Z -to 2025-01-02T00:00:00Z
+//
+// Por padrão só eventos pendentes ou com falha são reprocessados; use -force para
+// reprocessar também os já processados.
+func main() {
+	eventID := flag.String("event", "", "ID do evento a ser reprocessado")
+	from := flag.String("from", "", "início do período (RFC3339)")
+	to := flag.String("to", "", "fim do período (RFC3339), padrão: agora")
+	force := flag.Bool("force", false, "reprocessa também eventos já processados")
+	flag.Parse()
+
+	if *eventID == "" && *from == "" {
+		flag.Usage()
+		log.Fatal("informe -event ou -from")
+	}
+
+	config.LoadConfigs()
+	database.Connect()
+	defer database.Close()
+
+	mailPort, _ := strconv.Atoi(config.AppConfig.MailPort)
+	emailService := mail.NewEmailService(mail.NewGoMailer(
+		config.AppConfig.MailHost,
+		mailPort,
+		config.AppConfig.MailUsername,
+		config.AppConfig.MailPassword))
+
+	subscriptionRepository := gorm.NewSubscriptionGormRepository()
+	paymentGateway := service.NewPaymentGatewayFromConfig()
+	subscriptionService := service.NewSubscriptionService(subscriptionRepository, gov.NewHubDevService(), paymentGateway)
+	dunningService := service.NewDunningService(subscriptionService, emailService, config.AppConfig.DunningGraceDays, config.AppConfig.DunningReminderDays)
+	orderRepository := repository.NewGormOrderRepository(database.DB)
+	orderService := service.NewOrderService(orderRepository)
+	recoveryService := service.NewCheckoutRecoveryService(repository.NewGormCheckoutAttemptRepository(database.DB), orderRepository, emailService,
+		config.AppConfig.CheckoutAbandonMinutes, config.AppConfig.CheckoutRecoveryHours, config.AppConfig.CheckoutRecoveryDiscount)
+	membershipService := service.NewMembershipService(repository.NewGormMembershipRepository(database.DB), paymentGateway, emailService)
+	processor := service.NewStripeWebhookProcessor(subscriptionService, dunningService, orderService, recoveryService, membershipService, paymentGateway, repository.NewPurchaseRepository(), emailService)
+	webhookService := service.NewWebhookService(repository.NewGormWebhookEventRepository(database.DB), processor)
+
+	if *eventID != "" {
+		event, err := webhookService.ReplayEvent(*eventID, *force)
+		if err != nil {
+			log.Fatalf("Erro ao reprocessar evento: %v", err)
+		}
+		log.Printf("Evento %s reprocessado. Status: %s %s", event.EventID, event.Status, event.Error)
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		log.Fatalf("Data de início inválida: %v", err)
+	}
+
+	end := time.Now()
+	if *to != "" {
+		end, err = time.Parse(time.RFC3339, *to)
+		if err != nil {
+			log.Fatalf("Data de fim inválida: %v", err)
+		}
+	}
+
+	processed, err := webhookService.ReplayPeriod(start, end, *force)
+	if err != nil {
+		log.Fatalf("Erro ao reprocessar eventos: %v", err)
+	}
+	log.Printf("%d evento(s) reprocessado(s) com sucesso", processed)
+}
@@ -3,34 +3,51 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type AppConfiguration struct {
-	AppName               string
-	AppMode               string
-	AppKey                string
-	Host                  string
-	Port                  string
-	DatabaseURL           string
-	MailHost              string
-	MailPort              string
-	MailUsername          string
-	MailPassword          string
-	MailAuth              string
-	MailFromAddress       string
-	MailFromName          string
-	MailContactAddress    string
-	S3AccessKey           string
-	S3SecretKey           string
-	S3Region              string
-	S3BucketName          string
-	HubDesenvolvedorApi   string
-	HubDesenvolvedorToken string
-	StripeSecretKey       string
-	StripePriceID         string
-	StripeWebhookSecret   string
+	AppName                     string
+	AppMode                     string
+	AppKey                      string
+	Host                        string
+	Port                        string
+	DatabaseURL                 string
+	MailHost                    string
+	MailPort                    string
+	MailUsername                string
+	MailPassword                string
+	MailAuth                    string
+	MailFromAddress             string
+	MailFromName                string
+	MailContactAddress          string
+	S3AccessKey                 string
+	S3SecretKey                 string
+	S3Region                    string
+	S3BucketName                string
+	HubDesenvolvedorApi         string
+	HubDesenvolvedorToken       string
+	StripeSecretKey             string
+	StripePriceID               string
+	StripePriceIDYearly         string
+	StripeProPriceID            string
+	StripeProPriceIDYearly      string
+	StripeBusinessPriceID       string
+	StripeBusinessPriceIDYearly string
+	StripeWebhookSecret         string
+	DunningGraceDays            int
+	DunningReminderDays         []int
+	StripeAPIURL                string
+	PaymentGateway              string
+	PlatformFeePercent          int
+	PayoutHoldDays              int
+	CheckoutAbandonMinutes      int
+	CheckoutRecoveryHours       []int
+	CheckoutRecoveryDiscount    int
+	TrustedProxies              []string
 }
 
 func (ac *AppConfiguration) IsProduction() bool {
@@ -69,7 +86,34 @@ func LoadConfigs() {
 	AppConfig.HubDesenvolvedorToken = GetEnv("HUB_DEVSENVOLVEDOR_TOKEN", "")
 	AppConfig.StripeSecretKey = GetEnv("STRIPE_SECRET_KEY", "")
 	AppConfig.StripePriceID = GetEnv("STRIPE_PRICE_ID", "")
+	AppConfig.StripePriceIDYearly = GetEnv("STRIPE_PRICE_ID_YEARLY", "")
+	AppConfig.StripeProPriceID = GetEnv("STRIPE_PRO_PRICE_ID", "")
+	AppConfig.StripeProPriceIDYearly = GetEnv("STRIPE_PRO_PRICE_ID_YEARLY", "")
+	AppConfig.StripeBusinessPriceID = GetEnv("STRIPE_BUSINESS_PRICE_ID", "")
+	AppConfig.StripeBusinessPriceIDYearly = GetEnv("STRIPE_BUSINESS_PRICE_ID_YEARLY", "")
 	AppConfig.StripeWebhookSecret = GetEnv("STRIPE_WEBHOOK_SECRET", "")
+	AppConfig.DunningGraceDays = GetEnvInt("DUNNING_GRACE_DAYS", 7)
+	AppConfig.DunningReminderDays = GetEnvIntList("DUNNING_REMINDER_DAYS", []int{0, 3, 6})
+	AppConfig.StripeAPIURL = GetEnv("STRIPE_API_URL", "")
+	AppConfig.PaymentGateway = GetEnv("PAYMENT_GATEWAY", "stripe")
+	AppConfig.PlatformFeePercent = GetEnvInt("PLATFORM_FEE_PERCENT", 10)
+	AppConfig.PayoutHoldDays = GetEnvInt("PAYOUT_HOLD_DAYS", 7)
+	AppConfig.CheckoutAbandonMinutes = GetEnvInt("CHECKOUT_ABANDON_MINUTES", 60)
+	AppConfig.CheckoutRecoveryHours = GetEnvIntList("CHECKOUT_RECOVERY_HOURS", []int{1, 24, 72})
+	AppConfig.CheckoutRecoveryDiscount = GetEnvInt("CHECKOUT_RECOVERY_DISCOUNT_PERCENT", 0)
+	AppConfig.TrustedProxies = GetEnvList("TRUSTED_PROXIES", defaultTrustedProxies())
+}
+
+// platformProxyRanges são as faixas privadas de onde o roteador da plataforma
+// (Heroku) repassa as conexões; em produção, sem TRUSTED_PROXIES, elas são
+// confiáveis para que o IP do comprador venha do X-Forwarded-For
+var platformProxyRanges = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+func defaultTrustedProxies() []string {
+	if !AppConfig.IsProduction() {
+		return nil
+	}
+	return platformProxyRanges
 }
 
 func GetEnv(key, fallback string) string {
@@ -80,3 +124,47 @@ func GetEnv(key, fallback string) string {
 
 	return fallback
 }
+
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(GetEnv(key, strconv.Itoa(fallback)))
+	if err != nil {
+		log.Printf("Aviso: valor inválido para %s, usando %d", key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// GetEnvList lê uma lista de valores separados por vírgula
+func GetEnvList(key string, fallback []string) []string {
+	env, exists := os.LookupEnv(key)
+	if !exists || strings.TrimSpace(env) == "" {
+		return fallback
+	}
+
+	var values []string
+	for _, part := range strings.Split(env, ",") {
+		if value := strings.TrimSpace(part); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// GetEnvIntList lê uma lista de inteiros separados por vírgula
+func GetEnvIntList(key string, fallback []int) []int {
+	env, exists := os.LookupEnv(key)
+	if !exists || strings.TrimSpace(env) == "" {
+		return fallback
+	}
+
+	var values []int
+	for _, part := range strings.Split(env, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			log.Printf("Aviso: valor inválido para %s, usando padrão", key)
+			return fallback
+		}
+		values = append(values, value)
+	}
+	return values
+}
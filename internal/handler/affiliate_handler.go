@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// AffiliateHandler gerencia os afiliados do criador e o painel público de cada afiliado
+type AffiliateHandler struct {
+	affiliateService service.AffiliateService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewAffiliateHandler(
+	affiliateService service.AffiliateService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *AffiliateHandler {
+	return &AffiliateHandler{
+		affiliateService: affiliateService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView lista os afiliados com o saldo de comissões e as regras por ebook
+func (h *AffiliateHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	reports, err := h.affiliateService.ListForCreator(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao listar afiliados do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao listar afiliados", http.StatusInternalServerError)
+		return
+	}
+
+	rules, err := h.affiliateService.Rules(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar regras de comissão do criador %d: %v", creator.ID, err)
+		rules = map[uint]int{}
+	}
+
+	var ebooks []models.Ebook
+	list, err := h.ebookService.ListEbooksForUser(creator.UserID, repository.EbookQuery{})
+	if err != nil {
+		log.Printf("Erro ao listar ebooks do criador %d: %v", creator.ID, err)
+	}
+	if list != nil {
+		ebooks = *list
+	}
+
+	h.templateRenderer.View(w, r, "affiliate/index", map[string]any{
+		"Reports": reports,
+		"Ebooks":  ebooks,
+		"Rules":   rules,
+		"BaseURL": "http://" + r.Host,
+	}, "admin")
+}
+
+// CreateSubmit cadastra um afiliado e gera o código de indicação
+func (h *AffiliateHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	form := map[string]string{
+		"name":  r.FormValue("name"),
+		"email": r.FormValue("email"),
+	}
+
+	affiliate, err := h.affiliateService.Create(creator.ID, form["name"], form["email"])
+	if err != nil {
+		log.Printf("Falha ao cadastrar afiliado: %v", err)
+		redirectWithFormErrors(w, r, form, map[string]string{"affiliate": err.Error()})
+		return
+	}
+
+	cookies.NotifySuccess(w, "Afiliado cadastrado! Envie o link do painel para "+affiliate.Name+".")
+	http.Redirect(w, r, "/affiliates", http.StatusSeeOther)
+}
+
+// ToggleSubmit ativa ou desativa o afiliado; afiliados inativos não recebem novas vendas
+func (h *AffiliateHandler) ToggleSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do afiliado inválido", http.StatusBadRequest)
+		return
+	}
+
+	affiliate, err := h.affiliateService.FindForCreator(uint(id), creator.ID)
+	if errors.Is(err, models.ErrAffiliateNotFound) {
+		http.Error(w, "Afiliado não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar afiliado", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.affiliateService.Toggle(affiliate); err != nil {
+		log.Printf("Falha ao alterar afiliado %d: %v", affiliate.ID, err)
+		cookies.NotifyError(w, "Erro ao alterar afiliado")
+	} else if affiliate.Active {
+		cookies.NotifySuccess(w, "Afiliado ativado!")
+	} else {
+		cookies.NotifySuccess(w, "Afiliado desativado!")
+	}
+	http.Redirect(w, r, "/affiliates", http.StatusSeeOther)
+}
+
+// RulesSubmit salva o percentual de comissão de cada ebook
+func (h *AffiliateHandler) RulesSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	r.ParseForm()
+	percents := make(map[uint]int)
+	for key, values := range r.Form {
+		if !strings.HasPrefix(key, "percent_") || len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+			continue
+		}
+		ebookID, err := strconv.ParseUint(strings.TrimPrefix(key, "percent_"), 10, 32)
+		if err != nil {
+			continue
+		}
+		percent, err := strconv.Atoi(strings.TrimSpace(values[0]))
+		if err != nil {
+			cookies.NotifyError(w, models.ErrInvalidCommissionRate.Error())
+			http.Redirect(w, r, "/affiliates", http.StatusSeeOther)
+			return
+		}
+		percents[uint(ebookID)] = percent
+	}
+
+	if err := h.affiliateService.SaveRules(creator.ID, percents); err != nil {
+		log.Printf("Falha ao salvar regras de comissão do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, err.Error())
+	} else {
+		cookies.NotifySuccess(w, "Comissões atualizadas!")
+	}
+	http.Redirect(w, r, "/affiliates", http.StatusSeeOther)
+}
+
+// ExportCSV baixa o extrato de comissões de todos os afiliados para o pagamento
+func (h *AffiliateHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="comissoes.csv"`)
+
+	if err := h.affiliateService.ExportCommissions(creator.ID, w); err != nil {
+		log.Printf("Erro ao exportar comissões do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao exportar comissões", http.StatusInternalServerError)
+	}
+}
+
+// DashboardView exibe o painel do afiliado: links de divulgação e extrato de comissões
+func (h *AffiliateHandler) DashboardView(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := h.affiliateService.Dashboard(chi.URLParam(r, "token"))
+	if errors.Is(err, models.ErrAffiliateNotFound) {
+		http.Error(w, "Afiliado não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao montar painel do afiliado: %v", err)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "affiliate/dashboard", map[string]any{
+		"Dashboard": dashboard,
+		"BaseURL":   "http://" + r.Host,
+	}, "guest")
+}
+
+func (h *AffiliateHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
@@ -0,0 +1,401 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/checkout/session"
+)
+
+// BundleHandler gerencia os kits de ebooks: cadastro pelo criador, página de vendas e checkout
+type BundleHandler struct {
+	bundleService    service.BundleService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	orderService     service.OrderService
+	affiliateService service.AffiliateService
+	rfService        gov.ReceitaFederalService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewBundleHandler(
+	bundleService service.BundleService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	orderService service.OrderService,
+	affiliateService service.AffiliateService,
+	rfService gov.ReceitaFederalService,
+	templateRenderer template.TemplateRenderer,
+) *BundleHandler {
+	return &BundleHandler{
+		bundleService:    bundleService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		orderService:     orderService,
+		affiliateService: affiliateService,
+		rfService:        rfService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView lista os kits do criador com as vendas de cada um
+func (h *BundleHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	bundles, err := h.bundleService.ListForCreator(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao listar kits do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao listar kits", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "bundle/index", map[string]any{
+		"Bundles": bundles,
+	}, "admin")
+}
+
+// CreateView exibe o formulário de cadastro do kit
+func (h *BundleHandler) CreateView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	h.templateRenderer.View(w, r, "bundle/create", map[string]any{
+		"Action":   "/bundle/create",
+		"Ebooks":   h.creatorEbooks(creator),
+		"Selected": map[uint]bool{},
+	}, "admin")
+}
+
+// CreateSubmit cadastra o kit com os ebooks selecionados
+func (h *BundleHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	form, price, ebookIDs, errs := parseBundleForm(r)
+	if len(errs) > 0 {
+		redirectWithFormErrors(w, r, form, errs)
+		return
+	}
+
+	bundle := models.NewBundle(form.Title, form.Description, form.SalesPage, price, *creator)
+	if err := h.bundleService.Create(bundle, ebookIDs); err != nil {
+		log.Printf("Falha ao cadastrar kit: %v", err)
+		redirectWithFormErrors(w, r, form, map[string]string{"ebooks": err.Error()})
+		return
+	}
+
+	cookies.NotifySuccess(w, "Kit criado com sucesso!")
+	http.Redirect(w, r, "/bundle", http.StatusSeeOther)
+}
+
+// UpdateView exibe o formulário de edição do kit
+func (h *BundleHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	bundle := h.creatorBundle(w, r, creator)
+	if bundle == nil {
+		return
+	}
+
+	selected := make(map[uint]bool)
+	for _, ebook := range bundle.Ebooks {
+		selected[ebook.ID] = true
+	}
+
+	h.templateRenderer.View(w, r, "bundle/update", map[string]any{
+		"Action":   "/bundle/" + strconv.FormatUint(uint64(bundle.ID), 10) + "/edit",
+		"Bundle":   bundle,
+		"Ebooks":   h.creatorEbooks(creator),
+		"Selected": selected,
+		// Valores atuais do kit; em caso de erro de validação o formulário enviado os substitui
+		"Form": map[string]any{
+			"title":       bundle.Title,
+			"description": bundle.Description,
+			"sales_page":  bundle.SalesPage,
+			"value":       bundle.Price.Decimal(),
+			"currency":    string(bundle.Price.Currency),
+			"status":      bundle.Status,
+		},
+	}, "admin")
+}
+
+// UpdateSubmit atualiza os dados e os ebooks do kit
+func (h *BundleHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	bundle := h.creatorBundle(w, r, creator)
+	if bundle == nil {
+		return
+	}
+
+	form, price, ebookIDs, errs := parseBundleForm(r)
+	if len(errs) > 0 {
+		redirectWithFormErrors(w, r, form, errs)
+		return
+	}
+
+	bundle.Title = form.Title
+	bundle.Description = form.Description
+	bundle.SalesPage = form.SalesPage
+	bundle.Price = price
+	bundle.Status = form.Status
+
+	if err := h.bundleService.Update(bundle, ebookIDs); err != nil {
+		log.Printf("Falha ao atualizar kit %d: %v", bundle.ID, err)
+		redirectWithFormErrors(w, r, form, map[string]string{"ebooks": err.Error()})
+		return
+	}
+
+	cookies.NotifySuccess(w, "Kit atualizado com sucesso!")
+	http.Redirect(w, r, "/bundle", http.StatusSeeOther)
+}
+
+// DeleteSubmit remove o kit; as compras já feitas continuam válidas
+func (h *BundleHandler) DeleteSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	bundle := h.creatorBundle(w, r, creator)
+	if bundle == nil {
+		return
+	}
+
+	if err := h.bundleService.Delete(bundle); err != nil {
+		log.Printf("Falha ao excluir kit %d: %v", bundle.ID, err)
+		cookies.NotifyError(w, "Erro ao excluir kit")
+	} else {
+		cookies.NotifySuccess(w, "Kit excluído com sucesso!")
+	}
+	http.Redirect(w, r, "/bundle", http.StatusSeeOther)
+}
+
+// SalesPageView exibe a página de vendas pública do kit
+func (h *BundleHandler) SalesPageView(w http.ResponseWriter, r *http.Request) {
+	bundle, err := h.bundleService.FindAvailableBySlug(chi.URLParam(r, "slug"))
+	if err != nil {
+		http.Error(w, "Kit não encontrado", http.StatusNotFound)
+		return
+	}
+
+	if err := h.bundleService.RegisterView(bundle); err != nil {
+		log.Printf("Erro ao incrementar visualizações do kit %d: %v", bundle.ID, err)
+	}
+
+	h.templateRenderer.View(w, r, "bundle/sales_page", map[string]any{
+		"Bundle": bundle,
+	}, "guest")
+}
+
+// CheckoutView exibe o checkout do kit
+func (h *BundleHandler) CheckoutView(w http.ResponseWriter, r *http.Request) {
+	bundle, err := h.bundleService.FindAvailableBySlug(chi.URLParam(r, "slug"))
+	if err != nil {
+		http.Error(w, "Kit não encontrado", http.StatusNotFound)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "bundle/checkout", map[string]any{
+		"Bundle": bundle,
+	}, "guest")
+}
+
+// CreateBundleCheckout valida o comprador e cria a sessão de checkout do kit no Stripe
+func (h *BundleHandler) CreateBundleCheckout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	stripe.Key = config.AppConfig.StripeSecretKey
+
+	var request checkoutCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Erro ao decodificar requisição: %v", err)
+		writeCheckoutError(w, http.StatusBadRequest, "Dados inválidos")
+		return
+	}
+
+	bundle, err := h.bundleService.FindAvailableBySlug(request.BundleSlug)
+	if err != nil {
+		writeCheckoutError(w, http.StatusBadRequest, "Kit não encontrado ou indisponível")
+		return
+	}
+
+	if status, message := validateCheckoutCustomer(h.rfService, request); status != http.StatusOK {
+		writeCheckoutError(w, status, message)
+		return
+	}
+
+	client, err := createOrFindClient(request, bundle.CreatorID)
+	if err != nil {
+		log.Printf("Erro ao criar/buscar cliente: %v", err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar dados do cliente")
+		return
+	}
+
+	order, err := h.orderService.CreatePendingBundleOrder(bundle, client)
+	if err != nil {
+		log.Printf("Erro ao criar pedido do kit %d: %v", bundle.ID, err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar pagamento")
+		return
+	}
+
+	if err := h.affiliateService.AttributeOrder(order, middleware.ReferralCode(r)); err != nil {
+		log.Printf("Erro ao atribuir pedido %d ao afiliado: %v", order.ID, err)
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: stripe.String(order.Currency.Code()),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name:        stripe.String(bundle.Title),
+						Description: stripe.String(bundle.Description),
+					},
+					UnitAmount: stripe.Int64(order.Total),
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		SuccessURL:    stripe.String("http://" + r.Host + "/purchase/success?session_id={CHECKOUT_SESSION_ID}"),
+		CancelURL:     stripe.String("http://" + r.Host + "/checkout/kit/" + bundle.Slug),
+		CustomerEmail: stripe.String(request.Email),
+		Metadata: map[string]string{
+			"order_id":    strconv.FormatUint(uint64(order.ID), 10),
+			"bundle_id":   strconv.FormatUint(uint64(bundle.ID), 10),
+			"client_id":   strconv.FormatUint(uint64(client.ID), 10),
+			"creator_id":  strconv.FormatUint(uint64(bundle.CreatorID), 10),
+			"client_name": request.Name,
+			"client_cpf":  request.CPF,
+		},
+	}
+
+	checkoutSession, err := session.New(params)
+	if err != nil {
+		log.Printf("Erro ao criar sessão do Stripe: %v", err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar pagamento")
+		return
+	}
+
+	if err := h.orderService.AttachCheckoutSession(order, checkoutSession.ID); err != nil {
+		log.Printf("Erro ao vincular sessão %s ao pedido %d: %v", checkoutSession.ID, order.ID, err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"url":     checkoutSession.URL,
+	})
+}
+
+func (h *BundleHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
+
+// creatorBundle busca o kit da URL garantindo que pertence ao criador logado
+func (h *BundleHandler) creatorBundle(w http.ResponseWriter, r *http.Request, creator *models.Creator) *models.Bundle {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do kit inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	bundle, err := h.bundleService.FindByID(uint(id))
+	if errors.Is(err, service.ErrBundleNotFound) || (err == nil && bundle.CreatorID != creator.ID) {
+		http.Error(w, "Kit não encontrado", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar kit", http.StatusInternalServerError)
+		return nil
+	}
+
+	return bundle
+}
+
+func (h *BundleHandler) creatorEbooks(creator *models.Creator) []models.Ebook {
+	ebooks, err := h.ebookService.ListEbooksForUser(creator.UserID, repository.EbookQuery{})
+	if err != nil || ebooks == nil {
+		log.Printf("Erro ao listar ebooks do criador %d: %v", creator.ID, err)
+		return []models.Ebook{}
+	}
+	return *ebooks
+}
+
+// parseBundleForm lê e valida o formulário do kit
+func parseBundleForm(r *http.Request) (models.EbookRequest, money.Money, []uint, map[string]string) {
+	r.ParseForm()
+
+	form := models.EbookRequest{
+		Title:       r.FormValue("title"),
+		Description: r.FormValue("description"),
+		SalesPage:   r.FormValue("sales_page"),
+		Value:       r.FormValue("value"),
+		Currency:    formCurrency(r),
+		Status:      r.FormValue("status") != "",
+	}
+
+	errs := utils.ValidateForm(form)
+	if errs == nil {
+		errs = make(map[string]string)
+	}
+
+	price, priceErrors := parseEbookPrice(form)
+	for key, value := range priceErrors {
+		errs[key] = value
+	}
+
+	var ebookIDs []uint
+	for _, value := range r.Form["ebook_ids"] {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err == nil {
+			ebookIDs = append(ebookIDs, uint(id))
+		}
+	}
+	if len(ebookIDs) < 2 {
+		errs["ebooks"] = models.ErrBundleTooFewEbooks.Error()
+	}
+
+	return form, price, ebookIDs, errs
+}
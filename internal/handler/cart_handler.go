@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/checkout/session"
+)
+
+const cartCookieName = "cart"
+
+type CartHandler struct {
+	templateRenderer template.TemplateRenderer
+	cartService      service.CartService
+	creatorService   service.CreatorService
+	orderService     service.OrderService
+	affiliateService service.AffiliateService
+	rfService        gov.ReceitaFederalService
+}
+
+func NewCartHandler(
+	templateRenderer template.TemplateRenderer,
+	cartService service.CartService,
+	creatorService service.CreatorService,
+	orderService service.OrderService,
+	affiliateService service.AffiliateService,
+	rfService gov.ReceitaFederalService,
+) *CartHandler {
+	return &CartHandler{
+		templateRenderer: templateRenderer,
+		cartService:      cartService,
+		creatorService:   creatorService,
+		orderService:     orderService,
+		affiliateService: affiliateService,
+		rfService:        rfService,
+	}
+}
+
+// CartView exibe os ebooks do carrinho e o formulário único de dados do comprador
+func (h *CartHandler) CartView(w http.ResponseWriter, r *http.Request) {
+	cart, err := h.cartService.LoadCart(readCart(r))
+	if err != nil {
+		log.Printf("Erro ao carregar carrinho: %v", err)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+	writeCart(w, cart.EbookIDs())
+
+	data := map[string]any{
+		"Cart": cart,
+	}
+
+	if !cart.IsEmpty() {
+		creator, err := h.creatorService.FindByID(cart.CreatorID)
+		if err != nil {
+			log.Printf("Erro ao buscar criador do carrinho: %v", err)
+		}
+		data["Creator"] = creator
+	}
+
+	h.templateRenderer.View(w, r, "cart", data, "guest")
+}
+
+// AddItem inclui um ebook no carrinho
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	ebookID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return
+	}
+
+	cart, reset, err := h.cartService.AddToCart(readCart(r), uint(ebookID))
+	if errors.Is(err, models.ErrCartFixedPriceOnly) {
+		cookies.NotifyError(w, "Este ebook não pode ser comprado pelo carrinho")
+		http.Redirect(w, r, "/checkout/"+chi.URLParam(r, "id"), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao adicionar ebook %d ao carrinho: %v", ebookID, err)
+		cookies.NotifyError(w, "Ebook não disponível")
+		http.Redirect(w, r, "/cart", http.StatusSeeOther)
+		return
+	}
+
+	writeCart(w, cart.EbookIDs())
+	if reset {
+		cookies.NotifyError(w, "O carrinho aceita apenas e-books de um mesmo autor. Os itens anteriores foram removidos.")
+	} else {
+		cookies.NotifySuccess(w, "Ebook adicionado ao carrinho")
+	}
+	http.Redirect(w, r, "/cart", http.StatusSeeOther)
+}
+
+// RemoveItem retira um ebook do carrinho
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	ebookID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return
+	}
+
+	var ids []uint
+	for _, id := range readCart(r) {
+		if id != uint(ebookID) {
+			ids = append(ids, id)
+		}
+	}
+	writeCart(w, ids)
+
+	http.Redirect(w, r, "/cart", http.StatusSeeOther)
+}
+
+// CreateCartCheckout valida o comprador uma única vez e cria uma sessão de
+// checkout no Stripe com um item para cada ebook do carrinho
+func (h *CartHandler) CreateCartCheckout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	stripe.Key = config.AppConfig.StripeSecretKey
+
+	var request checkoutCustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("Erro ao decodificar requisição: %v", err)
+		writeCheckoutError(w, http.StatusBadRequest, "Dados inválidos")
+		return
+	}
+
+	if status, message := validateCheckoutCustomer(h.rfService, request); status != http.StatusOK {
+		writeCheckoutError(w, status, message)
+		return
+	}
+
+	cart, err := h.cartService.LoadCart(readCart(r))
+	if err != nil || cart.IsEmpty() {
+		writeCheckoutError(w, http.StatusBadRequest, "Seu carrinho está vazio")
+		return
+	}
+
+	creator, err := h.creatorService.FindByID(cart.CreatorID)
+	if err != nil {
+		log.Printf("Erro ao buscar criador: %v", err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro interno do servidor")
+		return
+	}
+
+	client, err := createOrFindClient(request, creator.ID)
+	if err != nil {
+		log.Printf("Erro ao criar/buscar cliente: %v", err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar dados do cliente")
+		return
+	}
+
+	order, err := h.orderService.CreatePendingCartOrder(cart, client)
+	if err != nil {
+		log.Printf("Erro ao criar pedido do carrinho: %v", err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar pagamento")
+		return
+	}
+
+	if err := h.affiliateService.AttributeOrder(order, middleware.ReferralCode(r)); err != nil {
+		log.Printf("Erro ao atribuir pedido %d ao afiliado: %v", order.ID, err)
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		Mode:          stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems:     orderLineItems(order),
+		SuccessURL:    stripe.String("http://" + r.Host + "/purchase/success?session_id={CHECKOUT_SESSION_ID}"),
+		CancelURL:     stripe.String("http://" + r.Host + "/cart"),
+		CustomerEmail: stripe.String(request.Email),
+		Metadata: map[string]string{
+			"order_id":    strconv.FormatUint(uint64(order.ID), 10),
+			"client_id":   strconv.FormatUint(uint64(client.ID), 10),
+			"creator_id":  strconv.FormatUint(uint64(creator.ID), 10),
+			"client_name": request.Name,
+			"client_cpf":  request.CPF,
+		},
+	}
+
+	checkoutSession, err := session.New(params)
+	if err != nil {
+		log.Printf("Erro ao criar sessão do Stripe: %v", err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar pagamento")
+		return
+	}
+
+	if err := h.orderService.AttachCheckoutSession(order, checkoutSession.ID); err != nil {
+		log.Printf("Erro ao vincular sessão %s ao pedido %d: %v", checkoutSession.ID, order.ID, err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"url":     checkoutSession.URL,
+	})
+}
+
+// orderLineItems monta um item da sessão do Stripe para cada item do pedido
+func orderLineItems(order *models.Order) []*stripe.CheckoutSessionLineItemParams {
+	lineItems := make([]*stripe.CheckoutSessionLineItemParams, 0, len(order.Items))
+	for _, item := range order.Items {
+		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency: stripe.String(item.Currency.Code()),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name: stripe.String(item.Title),
+				},
+				UnitAmount: stripe.Int64(item.UnitPrice),
+			},
+			Quantity: stripe.Int64(int64(item.GetQuantity())),
+		})
+	}
+	return lineItems
+}
+
+// orderDiscounts cria no gateway um cupom de uso único com o desconto do pedido
+func orderDiscounts(paymentGateway service.PaymentGateway, order *models.Order) ([]*stripe.CheckoutSessionDiscountParams, error) {
+	if order.Discount <= 0 {
+		return nil, nil
+	}
+
+	couponID, err := paymentGateway.CreateCoupon(order.Discount, order.Currency, "Desconto")
+	if err != nil {
+		return nil, err
+	}
+	return []*stripe.CheckoutSessionDiscountParams{{Coupon: stripe.String(couponID)}}, nil
+}
+
+func writeCheckoutError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"success": false,
+		"error":   message,
+	})
+}
+
+// readCart lê os IDs dos ebooks guardados no cookie do carrinho
+func readCart(r *http.Request) []uint {
+	cookie, err := r.Cookie(cartCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+
+	var ids []uint
+	for _, value := range strings.Split(cookie.Value, "-") {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+func writeCart(w http.ResponseWriter, ids []uint) {
+	if len(ids) == 0 {
+		clearCart(w)
+		return
+	}
+
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatUint(uint64(id), 10))
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     cartCookieName,
+		Value:    strings.Join(values, "-"),
+		Path:     "/",
+		MaxAge:   7 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearCart(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:   cartCookieName,
+		Value:  "",
+		Path:   "/",
+		MaxAge: -1,
+	})
+}
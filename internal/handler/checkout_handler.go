@@ -2,30 +2,68 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
 	"github.com/anglesson/simple-web-server/internal/repository/gorm"
 	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
 	"github.com/anglesson/simple-web-server/pkg/gov"
 	"github.com/anglesson/simple-web-server/pkg/mail"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/template"
 	"github.com/go-chi/chi/v5"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/checkout/session"
 )
 
+// checkoutCustomerRequest são os dados do comprador enviados pelo checkout
+type checkoutCustomerRequest struct {
+	Name        string `json:"name"`
+	CPF         string `json:"cpf"`
+	Birthdate   string `json:"birthdate"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	EbookID     string `json:"ebookId"`
+	BundleSlug  string `json:"bundleSlug"`
+	BumpOfferID string `json:"bumpOfferId"`
+	// Amount é o valor escolhido pelo comprador em ebooks "pague quanto quiser"
+	Amount string `json:"amount"`
+	// Dados do presente: quem paga informa quem recebe e, opcionalmente, a data de envio
+	Gift           bool   `json:"gift"`
+	RecipientName  string `json:"recipientName"`
+	RecipientEmail string `json:"recipientEmail"`
+	GiftMessage    string `json:"giftMessage"`
+	GiftSendAt     string `json:"giftSendAt"`
+	// Quantity acima de 1 compra licenças para equipe
+	Quantity string `json:"quantity"`
+	// RecoveryToken identifica o checkout retomado pelo e-mail de recuperação
+	RecoveryToken string `json:"recoveryToken"`
+	CSRFToken     string `json:"csrfToken"`
+}
+
 type CheckoutHandler struct {
 	templateRenderer template.TemplateRenderer
 	ebookService     service.EbookService
 	clientService    service.ClientService
 	creatorService   service.CreatorService
 	rfService        gov.ReceitaFederalService
+	orderService     service.OrderService
+	offerService     service.OfferService
+	affiliateService service.AffiliateService
+	recoveryService  service.CheckoutRecoveryService
+	giftService      service.GiftService
+	licenseService   service.LicenseService
+	paymentGateway   service.PaymentGateway
+	purchaseRepo     *repository.PurchaseRepository
 	emailService     *mail.EmailService
 }
 
@@ -35,6 +73,14 @@ func NewCheckoutHandler(
 	clientService service.ClientService,
 	creatorService service.CreatorService,
 	rfService gov.ReceitaFederalService,
+	orderService service.OrderService,
+	offerService service.OfferService,
+	affiliateService service.AffiliateService,
+	recoveryService service.CheckoutRecoveryService,
+	giftService service.GiftService,
+	licenseService service.LicenseService,
+	paymentGateway service.PaymentGateway,
+	purchaseRepo *repository.PurchaseRepository,
 	emailService *mail.EmailService,
 ) *CheckoutHandler {
 	return &CheckoutHandler{
@@ -43,6 +89,14 @@ func NewCheckoutHandler(
 		clientService:    clientService,
 		creatorService:   creatorService,
 		rfService:        rfService,
+		orderService:     orderService,
+		offerService:     offerService,
+		affiliateService: affiliateService,
+		recoveryService:  recoveryService,
+		giftService:      giftService,
+		licenseService:   licenseService,
+		paymentGateway:   paymentGateway,
+		purchaseRepo:     purchaseRepo,
 		emailService:     emailService,
 	}
 }
@@ -91,9 +145,30 @@ func (h *CheckoutHandler) CheckoutView(w http.ResponseWriter, r *http.Request) {
 	// Atualizar o ebook com os dados do criador
 	ebook.Creator = *creator
 
+	// Ebooks gratuitos não passam pelo gateway: o leitor só informa o e-mail
+	if ebook.IsFree() {
+		h.templateRenderer.View(w, r, "lead", map[string]any{"Step": "form", "Ebook": ebook}, "guest")
+		return
+	}
+
+	bump, err := h.offerService.ShowOffer(ebook.ID, models.OfferTypeBump)
+	if err != nil {
+		log.Printf("Erro ao buscar order bump do ebook %d: %v", ebook.ID, err)
+	}
+
 	// Preparar dados para o template
 	data := map[string]any{
-		"Ebook": ebook,
+		"Ebook":     ebook,
+		"Bump":      bump,
+		"Promotion": ebook.ActivePricePeriod(time.Now()),
+	}
+
+	// Link do e-mail de recuperação: retoma os dados do comprador e o cupom oferecido
+	if token := r.URL.Query().Get("recovery"); token != "" {
+		attempt, err := h.recoveryService.FindByToken(token)
+		if err == nil && attempt.EbookID == ebook.ID {
+			data["Recovery"] = attempt
+		}
 	}
 
 	h.templateRenderer.View(w, r, "checkout", data, "guest")
@@ -103,15 +178,7 @@ func (h *CheckoutHandler) CheckoutView(w http.ResponseWriter, r *http.Request) {
 func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var request struct {
-		Name      string `json:"name"`
-		CPF       string `json:"cpf"`
-		Birthdate string `json:"birthdate"`
-		Email     string `json:"email"`
-		Phone     string `json:"phone"`
-		EbookID   string `json:"ebookId"`
-		CSRFToken string `json:"csrfToken"`
-	}
+	var request checkoutCustomerRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		log.Printf("Erro ao decodificar requisição: %v", err)
@@ -123,46 +190,6 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Validar dados obrigatórios
-	if request.Name == "" || request.CPF == "" || request.Birthdate == "" || request.Email == "" || request.Phone == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "Todos os campos são obrigatórios",
-		})
-		return
-	}
-
-	// Validar CPF
-	if len(request.CPF) != 11 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "CPF inválido",
-		})
-		return
-	}
-
-	// Validar email
-	if !isValidEmail(request.Email) {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "E-mail inválido",
-		})
-		return
-	}
-
-	// Validar telefone (formato: XXXXXXXXXXX = 11 caracteres)
-	if len(request.Phone) != 11 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"error":   "Telefone inválido",
-		})
-		return
-	}
-
 	// Validar ebook
 	ebookID, err := strconv.ParseUint(request.EbookID, 10, 32)
 	if err != nil {
@@ -184,37 +211,18 @@ func (h *CheckoutHandler) ValidateCustomer(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Validar com Receita Federal
-	if h.rfService != nil {
-		response, err := h.rfService.ConsultaCPF(request.CPF, request.Birthdate)
-		if err != nil {
-			log.Printf("Erro na consulta da Receita Federal: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]any{
-				"success": false,
-				"error":   "Erro na validação dos dados. Tente novamente.",
-			})
-			return
-		}
-
-		if !response.Status {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]any{
-				"success": false,
-				"error":   "Dados não conferem com a Receita Federal",
-			})
-			return
-		}
+	if status, message := validateCheckoutCustomer(h.rfService, request); status != http.StatusOK {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
 
-		// Verificar se o nome confere
-		if !isNameSimilar(request.Name, response.Result.NomeDaPF) {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]any{
-				"success": false,
-				"error":   "Nome não confere com os dados da Receita Federal",
-			})
-			return
-		}
+	// Guardar os dados para recuperar a compra caso o pagamento não seja concluído
+	if _, err := h.recoveryService.RegisterAttempt(ebook, request.RecoveryToken, request.Name, request.Email, request.Phone); err != nil {
+		log.Printf("Erro ao registrar tentativa de checkout do ebook %d: %v", ebook.ID, err)
 	}
 
 	// Dados válidos
@@ -231,15 +239,7 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 	// Initialize Stripe
 	stripe.Key = config.AppConfig.StripeSecretKey
 
-	var request struct {
-		Name      string `json:"name"`
-		CPF       string `json:"cpf"`
-		Birthdate string `json:"birthdate"`
-		Email     string `json:"email"`
-		Phone     string `json:"phone"`
-		EbookID   string `json:"ebookId"`
-		CSRFToken string `json:"csrfToken"`
-	}
+	var request checkoutCustomerRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		log.Printf("Erro ao decodificar requisição: %v", err)
@@ -285,7 +285,7 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 	}
 
 	// Criar ou buscar cliente
-	client, err := h.createOrFindClient(request, creator.ID)
+	client, err := createOrFindClient(request, creator.ID)
 	if err != nil {
 		log.Printf("Erro ao criar/buscar cliente: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -296,26 +296,135 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// O order bump é opcional e só vale para o ebook deste checkout
+	var bump *models.Offer
+	if request.BumpOfferID != "" {
+		bumpID, err := strconv.ParseUint(request.BumpOfferID, 10, 32)
+		if err == nil {
+			bump, err = h.offerService.FindBump(uint(bumpID), ebook.ID)
+		}
+		if err != nil {
+			writeCheckoutError(w, http.StatusBadRequest, "Oferta não disponível")
+			return
+		}
+	}
+
+	// Em "pague quanto quiser", o valor vazio assume a sugestão do criador
+	var amount int64
+	if ebook.IsPayWhatYouWant() && request.Amount != "" {
+		chosen, err := money.Parse(request.Amount, ebook.Price.Currency)
+		if err != nil {
+			writeCheckoutError(w, http.StatusBadRequest, "Valor inválido")
+			return
+		}
+		amount = chosen.Amount
+	}
+
+	var gift *service.GiftInput
+	if request.Gift {
+		sendAt, err := parseGiftSendAt(request.GiftSendAt, time.Now())
+		if err != nil {
+			writeCheckoutError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		gift = &service.GiftInput{
+			RecipientName:  request.RecipientName,
+			RecipientEmail: request.RecipientEmail,
+			Message:        request.GiftMessage,
+			SendAt:         sendAt,
+		}
+	}
+
+	// Licenças para equipe são vendidas sozinhas, sem order bump nem presente
+	seats := 1
+	if request.Quantity != "" {
+		seats, err = strconv.Atoi(request.Quantity)
+		if err != nil || seats < 1 {
+			writeCheckoutError(w, http.StatusBadRequest, "Quantidade inválida")
+			return
+		}
+	}
+	if seats > 1 && (gift != nil || bump != nil) {
+		writeCheckoutError(w, http.StatusBadRequest, "Licenças para equipe não podem ser compradas como presente ou com oferta adicional")
+		return
+	}
+
+	// Registrar o pedido antes de enviar o cliente ao gateway
+	var order *models.Order
+	if seats > 1 {
+		order, err = h.orderService.CreatePendingLicenseOrder(ebook, client, amount, seats)
+	} else {
+		order, err = h.orderService.CreatePendingOrder(ebook, client, amount, bump)
+	}
+	if errors.Is(err, models.ErrLicenseSeatsRange) {
+		writeCheckoutError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, models.ErrPriceBelowMinimum) {
+		writeCheckoutError(w, http.StatusBadRequest, "O valor mínimo deste ebook é "+ebook.GetValue())
+		return
+	}
+	if errors.Is(err, models.ErrEbookIsFree) {
+		writeCheckoutError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao criar pedido: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"error":   "Erro ao processar pagamento",
+		})
+		return
+	}
+
+	if gift != nil {
+		if _, err := h.giftService.Register(order, ebook, *gift); err != nil {
+			log.Printf("Erro ao registrar presente do pedido %d: %v", order.ID, err)
+			writeCheckoutError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if order.IsLicense() {
+		if _, err := h.licenseService.Register(order); err != nil {
+			log.Printf("Erro ao registrar licenças do pedido %d: %v", order.ID, err)
+			writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar pagamento")
+			return
+		}
+	}
+
+	if err := h.affiliateService.AttributeOrder(order, middleware.ReferralCode(r)); err != nil {
+		log.Printf("Erro ao atribuir pedido %d ao afiliado: %v", order.ID, err)
+	}
+
+	attempt, err := h.recoveryService.RegisterAttempt(ebook, request.RecoveryToken, request.Name, request.Email, request.Phone)
+	if err != nil {
+		log.Printf("Erro ao registrar tentativa de checkout do pedido %d: %v", order.ID, err)
+	} else if err := h.recoveryService.AttachOrder(attempt, order); err != nil {
+		log.Printf("Erro ao vincular pedido %d à tentativa de checkout: %v", order.ID, err)
+	}
+
+	discounts, err := orderDiscounts(h.paymentGateway, order)
+	if err != nil {
+		log.Printf("Erro ao criar cupom do pedido %d: %v", order.ID, err)
+		writeCheckoutError(w, http.StatusInternalServerError, "Erro ao processar pagamento")
+		return
+	}
+
+	// O comprador volta pelo domínio próprio do criador, quando houver
+	siteURL := creator.SiteURL("http://" + r.Host)
+
 	// Criar sessão do Stripe
 	params := &stripe.CheckoutSessionParams{
-		Mode: stripe.String(string(stripe.CheckoutSessionModePayment)),
-		LineItems: []*stripe.CheckoutSessionLineItemParams{
-			{
-				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency: stripe.String("brl"),
-					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name:        stripe.String(ebook.Title),
-						Description: stripe.String(ebook.Description),
-					},
-					UnitAmount: stripe.Int64(int64(ebook.Value * 100)), // Stripe usa centavos
-				},
-				Quantity: stripe.Int64(1),
-			},
-		},
-		SuccessURL:    stripe.String("http://" + r.Host + "/purchase/success?session_id={CHECKOUT_SESSION_ID}"),
-		CancelURL:     stripe.String("http://" + r.Host + "/checkout/" + request.EbookID),
+		Mode:          stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems:     orderLineItems(order),
+		Discounts:     discounts,
+		SuccessURL:    stripe.String(siteURL + "/purchase/success?session_id={CHECKOUT_SESSION_ID}"),
+		CancelURL:     stripe.String(siteURL + "/checkout/" + request.EbookID),
 		CustomerEmail: stripe.String(request.Email),
 		Metadata: map[string]string{
+			"order_id":    strconv.FormatUint(uint64(order.ID), 10),
 			"ebook_id":    request.EbookID,
 			"client_id":   strconv.FormatUint(uint64(client.ID), 10),
 			"creator_id":  strconv.FormatUint(uint64(creator.ID), 10),
@@ -324,6 +433,14 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 		},
 	}
 
+	// Com upsell ativo, o cartão é salvo para a oferta de um clique após a compra
+	if upsell, err := h.offerService.ActiveOffer(ebook.ID, models.OfferTypeUpsell); err == nil && upsell != nil {
+		params.CustomerCreation = stripe.String(string(stripe.CheckoutSessionCustomerCreationAlways))
+		params.PaymentIntentData = &stripe.CheckoutSessionPaymentIntentDataParams{
+			SetupFutureUsage: stripe.String(string(stripe.PaymentIntentSetupFutureUsageOffSession)),
+		}
+	}
+
 	session, err := session.New(params)
 	if err != nil {
 		log.Printf("Erro ao criar sessão do Stripe: %v", err)
@@ -335,6 +452,10 @@ func (h *CheckoutHandler) CreateEbookCheckout(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if err := h.orderService.AttachCheckoutSession(order, session.ID); err != nil {
+		log.Printf("Erro ao vincular sessão %s ao pedido %d: %v", session.ID, order.ID, err)
+	}
+
 	json.NewEncoder(w).Encode(map[string]any{
 		"success": true,
 		"url":     session.URL,
@@ -368,7 +489,20 @@ func (h *CheckoutHandler) PurchaseSuccessView(w http.ResponseWriter, r *http.Req
 	clientIDStr := session.Metadata["client_id"]
 	creatorIDStr := session.Metadata["creator_id"]
 
-	if ebookIDStr == "" || clientIDStr == "" {
+	if clientIDStr == "" {
+		http.Error(w, "Dados da compra inválidos", http.StatusBadRequest)
+		return
+	}
+
+	// Pedidos do carrinho não têm um único ebook nos metadados; usa-se o primeiro item
+	order, err := h.orderService.FindByGatewaySessionID(session.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar pedido da sessão %s: %v", session.ID, err)
+	}
+	if ebookIDStr == "" && order != nil {
+		ebookIDStr = strconv.FormatUint(uint64(order.EbookID), 10)
+	}
+	if ebookIDStr == "" {
 		http.Error(w, "Dados da compra inválidos", http.StatusBadRequest)
 		return
 	}
@@ -399,46 +533,200 @@ func (h *CheckoutHandler) PurchaseSuccessView(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Criar registro de compra
-	purchase := models.NewPurchase(uint(ebookID), uint(clientID))
-	purchase.ExpiresAt = time.Now().AddDate(0, 0, 30) // 30 dias de acesso
+	// Confirmar o pedido; se o webhook já tiver confirmado, nenhuma compra nova é criada
+	input := service.ConfirmPaymentInput{
+		GatewaySessionID: session.ID,
+		AmountPaid:       session.AmountTotal,
+		Currency:         string(session.Currency),
+	}
+	if orderID, err := strconv.ParseUint(session.Metadata["order_id"], 10, 32); err == nil {
+		input.OrderID = uint(orderID)
+	}
+	if session.PaymentIntent != nil {
+		input.GatewayPaymentID = session.PaymentIntent.ID
+	}
 
-	purchaseRepo := repository.NewPurchaseRepository()
-	err = purchaseRepo.CreateManyPurchases([]*models.Purchase{purchase})
+	purchases, err := h.orderService.ConfirmPayment(input)
 	if err != nil {
-		log.Printf("Erro ao criar compra: %v", err)
+		log.Printf("Erro ao confirmar pedido: %v", err)
 		// Não retornar erro para o usuário, apenas log
 	}
 
-	log.Printf("[checkout_handler] DADOS DA COMPRA: %+v", purchase)
-	log.Printf("[checkout_handler] 📧 Enviando email para: %s", purchase.Client.Email)
+	var purchase *models.Purchase
+	var purchasesWithRelations []*models.Purchase
+	for _, created := range purchases {
+		purchaseWithRelations, err := h.purchaseRepo.FindByID(created.ID)
+		if err != nil {
+			log.Printf("Erro ao buscar compra: %v", err)
+			continue
+		}
+		purchasesWithRelations = append(purchasesWithRelations, purchaseWithRelations)
+	}
+	if len(purchasesWithRelations) > 0 {
+		purchase = purchasesWithRelations[0]
+	}
 
-	// Enviar email com link de download
-	if purchase.ID > 0 {
-		go h.emailService.SendLinkToDownload([]*models.Purchase{purchase})
+	// Enviar um único email com os links de download apenas para compras recém-criadas
+	if purchase != nil && purchase.ID > 0 {
+		log.Printf("[checkout_handler] 📧 Enviando email para: %s", purchase.Client.Email)
+		go h.emailService.SendLinkToDownload(purchasesWithRelations)
+	}
+
+	// A compra foi concluída; o carrinho pode ser esvaziado
+	clearCart(w)
+
+	// O pedido pode ter sido confirmado agora; recarrega para exibir o upsell
+	if order != nil && !order.IsPaid() {
+		if confirmed, err := h.orderService.FindByGatewaySessionID(session.ID); err == nil && confirmed != nil {
+			order = confirmed
+		}
+	}
+
+	// Nos presentes, o link de resgate segue para quem recebe (na data agendada, se houver)
+	var gift *models.Gift
+	if order != nil && order.IsGift {
+		gift, err = h.giftService.FindByOrderID(order.ID)
+		if err != nil {
+			log.Printf("Erro ao buscar presente do pedido %d: %v", order.ID, err)
+		} else if gift != nil {
+			if err := h.giftService.Deliver(gift, time.Now()); err != nil {
+				log.Printf("Erro ao enviar presente %d: %v", gift.ID, err)
+			}
+		}
+	}
+
+	// Nas licenças para equipe, quem comprou recebe o link da página de gestão
+	var license *models.License
+	if order != nil && order.IsLicense() {
+		license, err = h.licenseService.FindByOrderID(order.ID)
+		if err != nil {
+			log.Printf("Erro ao buscar licenças do pedido %d: %v", order.ID, err)
+		} else if license != nil {
+			if err := h.licenseService.Deliver(license, time.Now()); err != nil {
+				log.Printf("Erro ao enviar licenças %d: %v", license.ID, err)
+			}
+		}
+	}
+
+	upsell, err := h.offerService.ShowUpsell(order)
+	if err != nil {
+		log.Printf("Erro ao buscar upsell do pedido: %v", err)
 	}
 
 	// Preparar dados para o template
 	data := map[string]any{
 		"Ebook":         ebook,
+		"Order":         order,
 		"CustomerEmail": client.Email,
 		"CreatorEmail":  creator.Email,
 		"Purchase":      purchase,
+		"Gift":          gift,
+		"License":       license,
+		"Upsell":        upsell,
+		"SessionID":     session.ID,
 	}
 
 	h.templateRenderer.View(w, r, "purchase-success", data, "guest")
 }
 
+// AcceptUpsell compra com um clique o upsell exibido na página de sucesso, cobrando
+// o mesmo cartão do pedido original. Se o cartão não puder ser cobrado, o comprador
+// é levado ao checkout do Stripe para pagar o pedido do upsell.
+func (h *CheckoutHandler) AcceptUpsell(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.FormValue("session_id")
+	successURL := "/purchase/success?session_id=" + url.QueryEscape(sessionID)
+
+	offerID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || sessionID == "" {
+		http.Error(w, "Oferta inválida", http.StatusBadRequest)
+		return
+	}
+
+	offer, err := h.offerService.FindByID(uint(offerID))
+	if err != nil {
+		cookies.NotifyError(w, "Oferta não disponível")
+		http.Redirect(w, r, successURL, http.StatusSeeOther)
+		return
+	}
+
+	order, purchases, err := h.offerService.AcceptUpsell(offer, sessionID)
+	switch {
+	case errors.Is(err, service.ErrUpsellChargeFailed) && order != nil:
+		checkoutURL, err := h.createUpsellCheckout(r, order)
+		if err != nil {
+			log.Printf("Erro ao criar checkout do upsell %d: %v", offer.ID, err)
+			cookies.NotifyError(w, "Não foi possível processar a oferta")
+			http.Redirect(w, r, successURL, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, checkoutURL, http.StatusSeeOther)
+		return
+	case errors.Is(err, models.ErrOfferAlreadyClaimed), errors.Is(err, models.ErrOfferNotAvailable):
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, successURL, http.StatusSeeOther)
+		return
+	case err != nil:
+		log.Printf("Erro ao aceitar upsell %d: %v", offer.ID, err)
+		cookies.NotifyError(w, "Não foi possível processar a oferta")
+		http.Redirect(w, r, successURL, http.StatusSeeOther)
+		return
+	}
+
+	var purchasesWithRelations []*models.Purchase
+	for _, created := range purchases {
+		purchaseWithRelations, err := h.purchaseRepo.FindByID(created.ID)
+		if err != nil {
+			log.Printf("Erro ao buscar compra: %v", err)
+			continue
+		}
+		purchasesWithRelations = append(purchasesWithRelations, purchaseWithRelations)
+	}
+	if len(purchasesWithRelations) > 0 {
+		go h.emailService.SendLinkToDownload(purchasesWithRelations)
+	}
+
+	cookies.NotifySuccess(w, "Oferta adquirida! Enviamos o link de download para o seu e-mail.")
+	http.Redirect(w, r, successURL, http.StatusSeeOther)
+}
+
+// createUpsellCheckout cria a sessão do Stripe para o pedido do upsell, já com o
+// e-mail do comprador preenchido
+func (h *CheckoutHandler) createUpsellCheckout(r *http.Request, order *models.Order) (string, error) {
+	stripe.Key = config.AppConfig.StripeSecretKey
+
+	client := &models.Client{}
+	if err := gorm.NewClientGormRepository().FindByIDAndCreators(client, order.ClientID, ""); err != nil {
+		return "", err
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		Mode:          stripe.String(string(stripe.CheckoutSessionModePayment)),
+		LineItems:     orderLineItems(order),
+		SuccessURL:    stripe.String("http://" + r.Host + "/purchase/success?session_id={CHECKOUT_SESSION_ID}"),
+		CancelURL:     stripe.String("http://" + r.Host + "/checkout/" + strconv.FormatUint(uint64(order.EbookID), 10)),
+		CustomerEmail: stripe.String(client.Email),
+		Metadata: map[string]string{
+			"order_id":   strconv.FormatUint(uint64(order.ID), 10),
+			"ebook_id":   strconv.FormatUint(uint64(order.EbookID), 10),
+			"client_id":  strconv.FormatUint(uint64(order.ClientID), 10),
+			"creator_id": strconv.FormatUint(uint64(order.CreatorID), 10),
+		},
+	}
+
+	checkoutSession, err := session.New(params)
+	if err != nil {
+		return "", err
+	}
+
+	if err := h.orderService.AttachCheckoutSession(order, checkoutSession.ID); err != nil {
+		log.Printf("Erro ao vincular sessão %s ao pedido %d: %v", checkoutSession.ID, order.ID, err)
+	}
+
+	return checkoutSession.URL, nil
+}
+
 // createOrFindClient cria ou busca um cliente existente
-func (h *CheckoutHandler) createOrFindClient(request struct {
-	Name      string `json:"name"`
-	CPF       string `json:"cpf"`
-	Birthdate string `json:"birthdate"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone"`
-	EbookID   string `json:"ebookId"`
-	CSRFToken string `json:"csrfToken"`
-}, creatorID uint) (*models.Client, error) {
+func createOrFindClient(request checkoutCustomerRequest, creatorID uint) (*models.Client, error) {
 	clientRepo := gorm.NewClientGormRepository()
 
 	// Buscar cliente existente por email
@@ -488,6 +776,71 @@ func (h *CheckoutHandler) createOrFindClient(request struct {
 	return client, nil
 }
 
+// validateCheckoutCustomer confere os dados do comprador e, quando disponível, a
+// Receita Federal. Retorna http.StatusOK quando os dados são válidos ou o status
+// e a mensagem de erro a serem devolvidos.
+func validateCheckoutCustomer(rfService gov.ReceitaFederalService, request checkoutCustomerRequest) (int, string) {
+	// Validar dados obrigatórios
+	if request.Name == "" || request.CPF == "" || request.Birthdate == "" || request.Email == "" || request.Phone == "" {
+		return http.StatusBadRequest, "Todos os campos são obrigatórios"
+	}
+
+	// Validar CPF
+	if len(request.CPF) != 11 {
+		return http.StatusBadRequest, "CPF inválido"
+	}
+
+	// Validar email
+	if !isValidEmail(request.Email) {
+		return http.StatusBadRequest, "E-mail inválido"
+	}
+
+	// Validar telefone (formato: XXXXXXXXXXX = 11 caracteres)
+	if len(request.Phone) != 11 {
+		return http.StatusBadRequest, "Telefone inválido"
+	}
+
+	// Validar com Receita Federal
+	if rfService != nil {
+		response, err := rfService.ConsultaCPF(request.CPF, request.Birthdate)
+		if err != nil {
+			log.Printf("Erro na consulta da Receita Federal: %v", err)
+			return http.StatusInternalServerError, "Erro na validação dos dados. Tente novamente."
+		}
+
+		if !response.Status {
+			return http.StatusBadRequest, "Dados não conferem com a Receita Federal"
+		}
+
+		// Verificar se o nome confere
+		if !isNameSimilar(request.Name, response.Result.NomeDaPF) {
+			return http.StatusBadRequest, "Nome não confere com os dados da Receita Federal"
+		}
+	}
+
+	return http.StatusOK, ""
+}
+
+// parseGiftSendAt converte a data de envio do presente. Vazia ou igual a hoje, o
+// presente é enviado assim que o pagamento for confirmado.
+func parseGiftSendAt(value string, now time.Time) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	sendAt, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return nil, errors.New("data de envio do presente inválida")
+	}
+	if !sendAt.After(now) {
+		if sendAt.Year() == now.Year() && sendAt.YearDay() == now.YearDay() {
+			return nil, nil
+		}
+		return nil, models.ErrGiftSendAtInPast
+	}
+	return &sendAt, nil
+}
+
 // Funções auxiliares
 func isValidEmail(email string) bool {
 	// Implementação simples de validação de email
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+// CustomDomainHandler cuida do domínio próprio da vitrine em /settings/domain
+type CustomDomainHandler struct {
+	domainService    service.CustomDomainService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewCustomDomainHandler(
+	domainService service.CustomDomainService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *CustomDomainHandler {
+	return &CustomDomainHandler{
+		domainService:    domainService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// DomainView mostra o domínio cadastrado e o registro TXT a publicar no DNS
+func (h *CustomDomainHandler) DomainView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	domain, err := h.domainService.Domain(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar domínio do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao buscar domínio", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "settings/domain", map[string]any{
+		"Creator": creator,
+		"Domain":  domain,
+	}, "admin")
+}
+
+// RegisterSubmit cadastra o domínio, substituindo o anterior
+func (h *CustomDomainHandler) RegisterSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	_, err := h.domainService.Register(creator, r.FormValue("host"))
+	switch {
+	case errors.Is(err, models.ErrInvalidDomain), errors.Is(err, models.ErrDomainTaken),
+		errors.Is(err, service.ErrReservedDomain):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao cadastrar domínio do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, "Erro ao cadastrar domínio")
+	default:
+		cookies.NotifySuccess(w, "Domínio cadastrado! Publique o registro TXT para verificar.")
+	}
+	http.Redirect(w, r, "/settings/domain", http.StatusSeeOther)
+}
+
+// VerifySubmit consulta o DNS e ativa o domínio quando o TXT estiver publicado
+func (h *CustomDomainHandler) VerifySubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	_, err := h.domainService.Verify(creator)
+	switch {
+	case errors.Is(err, models.ErrDomainNotVerified), errors.Is(err, service.ErrDomainLookupFail),
+		errors.Is(err, service.ErrDomainNotFound), errors.Is(err, models.ErrDomainTaken):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao verificar domínio do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, "Erro ao verificar domínio")
+	default:
+		cookies.NotifySuccess(w, "Domínio verificado!")
+	}
+	http.Redirect(w, r, "/settings/domain", http.StatusSeeOther)
+}
+
+// RemoveSubmit desliga o domínio; os links voltam a usar o domínio da plataforma
+func (h *CustomDomainHandler) RemoveSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	err := h.domainService.Remove(creator)
+	switch {
+	case errors.Is(err, service.ErrDomainNotFound):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao remover domínio do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, "Erro ao remover domínio")
+	default:
+		cookies.NotifySuccess(w, "Domínio removido!")
+	}
+	http.Redirect(w, r, "/settings/domain", http.StatusSeeOther)
+}
+
+func (h *CustomDomainHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
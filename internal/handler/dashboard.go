@@ -49,6 +49,7 @@ func (h *DashboardHandler) DashboardView(w http.ResponseWriter, r *http.Request)
 	totalSendEbooks := dashRepository.GetTotalSendEbooks()
 	totalClients := dashRepository.GetTotalClients()
 	ebookStats, _ := dashRepository.GetEbookStats()
+	recoveryStats, _ := dashRepository.GetCheckoutRecoveryStats()
 
 	// Get data for charts
 	dailyPurchases, _ := dashRepository.GetDailyPurchases()
@@ -102,6 +103,7 @@ func (h *DashboardHandler) DashboardView(w http.ResponseWriter, r *http.Request)
 		"GetTotalSendEbooks":      totalSendEbooks,
 		"GetTotalClients":         totalClients,
 		"EbookStats":              ebookStats,
+		"CheckoutRecovery":        recoveryStats,
 		"DailyPurchasesJSON":      string(dailyPurchasesJSON),
 		"DailyDownloadsJSON":      string(dailyDownloadsJSON),
 		"TopEbooksJSON":           string(topEbooksJSON),
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// DownloadAccessHandler mostra os limites de download de um cliente e permite
+// ao criador zerá-los ou estendê-los
+type DownloadAccessHandler struct {
+	accessService    service.DownloadAccessService
+	clientService    service.ClientService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewDownloadAccessHandler(
+	accessService service.DownloadAccessService,
+	clientService service.ClientService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *DownloadAccessHandler {
+	return &DownloadAccessHandler{
+		accessService:    accessService,
+		clientService:    clientService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView lista as compras do cliente com downloads, validade e dispositivos
+func (h *DownloadAccessHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	creator, client := h.creatorClient(w, r)
+	if client == nil {
+		return
+	}
+
+	purchases, err := h.accessService.ListForClient(creator.ID, client.ID)
+	if err != nil {
+		log.Printf("Erro ao listar downloads do cliente %d: %v", client.ID, err)
+		http.Error(w, "Erro ao listar downloads", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "client/downloads", map[string]any{
+		"Client":    client,
+		"Purchases": purchases,
+	}, "admin")
+}
+
+// ResetSubmit zera os downloads usados e os dispositivos da compra
+func (h *DownloadAccessHandler) ResetSubmit(w http.ResponseWriter, r *http.Request) {
+	creator, client := h.creatorClient(w, r)
+	if client == nil {
+		return
+	}
+
+	_, err := h.accessService.Reset(creator.ID, purchaseIDParam(r))
+	switch {
+	case errors.Is(err, service.ErrPurchaseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Falha ao zerar downloads da compra: %v", err)
+		cookies.NotifyError(w, "Erro ao zerar downloads")
+	default:
+		cookies.NotifySuccess(w, "Downloads e dispositivos zerados!")
+	}
+	http.Redirect(w, r, clientDownloadsURL(client), http.StatusSeeOther)
+}
+
+// ExtendSubmit acrescenta dias ao link e downloads ao limite da compra
+func (h *DownloadAccessHandler) ExtendSubmit(w http.ResponseWriter, r *http.Request) {
+	creator, client := h.creatorClient(w, r)
+	if client == nil {
+		return
+	}
+
+	days, _ := strconv.Atoi(strings.TrimSpace(r.FormValue("days")))
+	downloads, _ := strconv.Atoi(strings.TrimSpace(r.FormValue("downloads")))
+
+	_, err := h.accessService.Extend(creator.ID, purchaseIDParam(r), days, downloads)
+	switch {
+	case errors.Is(err, service.ErrPurchaseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrInvalidExtension):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao estender acesso da compra: %v", err)
+		cookies.NotifyError(w, "Erro ao estender acesso")
+	default:
+		cookies.NotifySuccess(w, "Acesso estendido com sucesso!")
+	}
+	http.Redirect(w, r, clientDownloadsURL(client), http.StatusSeeOther)
+}
+
+// creatorClient busca o criador logado e o cliente da URL, garantindo o vínculo entre eles
+func (h *DownloadAccessHandler) creatorClient(w http.ResponseWriter, r *http.Request) (*models.Creator, *models.Client) {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do cliente inválido", http.StatusBadRequest)
+		return nil, nil
+	}
+
+	client, err := h.clientService.FindCreatorsClientByID(uint(id), user.Email)
+	if err != nil || client == nil {
+		http.Error(w, "Cliente não encontrado", http.StatusNotFound)
+		return nil, nil
+	}
+
+	return creator, client
+}
+
+func purchaseIDParam(r *http.Request) uint {
+	id, _ := strconv.ParseUint(chi.URLParam(r, "purchaseID"), 10, 32)
+	return uint(id)
+}
+
+func clientDownloadsURL(client *models.Client) string {
+	return "/client/" + strconv.FormatUint(uint64(client.ID), 10) + "/downloads"
+}
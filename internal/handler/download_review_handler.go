@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+// Quantos downloads de cada compra aparecem como evidência na fila
+const reviewEvidenceDownloads = 20
+
+// DownloadReviewHandler mostra as compras suspeitas de compartilhamento do link
+type DownloadReviewHandler struct {
+	reviewService    service.DownloadReviewService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewDownloadReviewHandler(
+	reviewService service.DownloadReviewService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *DownloadReviewHandler {
+	return &DownloadReviewHandler{
+		reviewService:    reviewService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// reviewItem junta a compra aos sinais que a colocaram na fila
+type reviewItem struct {
+	Purchase  *models.Purchase
+	Report    models.SharingReport
+	Downloads []models.DownloadLog
+}
+
+// IndexView lista a fila de revisão com as evidências de cada compra
+func (h *DownloadReviewHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	purchases, err := h.reviewService.Queue(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao listar fila de revisão do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao listar fila de revisão", http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]reviewItem, 0, len(purchases))
+	for _, purchase := range purchases {
+		at := *purchase.FlaggedAt
+		items = append(items, reviewItem{
+			Purchase:  purchase,
+			Report:    purchase.SharingReport(at),
+			Downloads: purchase.RecentDownloads(reviewEvidenceDownloads),
+		})
+	}
+
+	h.templateRenderer.View(w, r, "downloads/review", map[string]any{
+		"Creator": creator,
+		"Items":   items,
+	}, "admin")
+}
+
+// DismissSubmit libera os downloads da compra e a tira da fila
+func (h *DownloadReviewHandler) DismissSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	_, err := h.reviewService.Dismiss(creator.ID, purchaseIDParam(r))
+	switch {
+	case errors.Is(err, service.ErrPurchaseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Falha ao liberar compra: %v", err)
+		cookies.NotifyError(w, "Erro ao liberar compra")
+	default:
+		cookies.NotifySuccess(w, "Compra liberada e removida da fila!")
+	}
+	http.Redirect(w, r, "/downloads/review", http.StatusSeeOther)
+}
+
+// SuspendSubmit bloqueia os downloads da compra
+func (h *DownloadReviewHandler) SuspendSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	_, err := h.reviewService.Suspend(creator.ID, purchaseIDParam(r))
+	switch {
+	case errors.Is(err, service.ErrPurchaseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Falha ao suspender compra: %v", err)
+		cookies.NotifyError(w, "Erro ao suspender compra")
+	default:
+		cookies.NotifySuccess(w, "Downloads da compra suspensos!")
+	}
+	http.Redirect(w, r, "/downloads/review", http.StatusSeeOther)
+}
+
+// SettingsSubmit liga ou desliga a suspensão automática de compras suspeitas
+func (h *DownloadReviewHandler) SettingsSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	enabled := r.FormValue("auto_suspend") == "on"
+	if err := h.reviewService.SetAutoSuspend(creator.ID, enabled); err != nil {
+		log.Printf("Falha ao salvar suspensão automática: %v", err)
+		cookies.NotifyError(w, "Erro ao salvar configuração")
+	} else if enabled {
+		cookies.NotifySuccess(w, "Suspensão automática ativada!")
+	} else {
+		cookies.NotifySuccess(w, "Suspensão automática desativada!")
+	}
+	http.Redirect(w, r, "/downloads/review", http.StatusSeeOther)
+}
+
+// WatermarkSubmit grava o texto da marca d'água personalizada dos PDFs
+func (h *DownloadReviewHandler) WatermarkSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	err := h.reviewService.SetWatermarkText(creator, r.FormValue("watermark_text"))
+	switch {
+	case errors.Is(err, models.ErrWatermarkTextTooLong):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao salvar marca d'água: %v", err)
+		cookies.NotifyError(w, "Erro ao salvar configuração")
+	default:
+		cookies.NotifySuccess(w, "Marca d'água salva!")
+	}
+	http.Redirect(w, r, "/downloads/review", http.StatusSeeOther)
+}
+
+func (h *DownloadReviewHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// DripHandler gerencia o cronograma de liberação dos arquivos de cada ebook
+type DripHandler struct {
+	dripService      service.DripService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewDripHandler(
+	dripService service.DripService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *DripHandler {
+	return &DripHandler{
+		dripService:      dripService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView mostra os arquivos do ebook na ordem de entrega, com o prazo de cada um
+func (h *DripHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	files, err := h.dripService.Schedule(ebook)
+	if err != nil {
+		log.Printf("Erro ao buscar cronograma do ebook %d: %v", ebook.ID, err)
+		http.Error(w, "Erro ao buscar cronograma", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "ebook/schedule", map[string]any{
+		"Ebook":   ebook,
+		"Files":   files,
+		"MaxDays": ebook.DownloadPolicy.LastReleaseDay(),
+	}, "admin")
+}
+
+// UpdateSubmit grava a ordem e o prazo de liberação dos arquivos
+func (h *DripHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Erro ao processar formulário", http.StatusBadRequest)
+		return
+	}
+
+	entries := make([]*models.EbookFile, 0, len(ebook.Files))
+	for _, file := range ebook.Files {
+		id := strconv.FormatUint(uint64(file.ID), 10)
+		position, err := strconv.Atoi(r.FormValue("position_" + id))
+		if err != nil {
+			position = 0
+		}
+		days, err := strconv.Atoi(r.FormValue("days_" + id))
+		if err != nil {
+			cookies.NotifyError(w, "Informe o prazo de liberação de "+file.OriginalName+" em dias")
+			http.Redirect(w, r, scheduleURL(ebook), http.StatusSeeOther)
+			return
+		}
+		entries = append(entries, &models.EbookFile{FileID: file.ID, Position: position, ReleaseAfterDays: days})
+	}
+
+	err := h.dripService.UpdateSchedule(ebook, entries)
+	switch {
+	case errors.Is(err, models.ErrInvalidReleaseOffset), errors.Is(err, models.ErrFileNotInEbook),
+		errors.Is(err, models.ErrReleaseAfterExpiry):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao salvar cronograma do ebook %d: %v", ebook.ID, err)
+		cookies.NotifyError(w, "Erro ao salvar cronograma")
+	default:
+		cookies.NotifySuccess(w, "Cronograma salvo com sucesso!")
+	}
+	http.Redirect(w, r, scheduleURL(ebook), http.StatusSeeOther)
+}
+
+// creatorEbook busca o ebook da URL garantindo que pertence ao criador logado
+func (h *DripHandler) creatorEbook(w http.ResponseWriter, r *http.Request) *models.Ebook {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil
+	}
+
+	return ebook
+}
+
+func scheduleURL(ebook *models.Ebook) string {
+	return "/ebook/" + strconv.FormatUint(uint64(ebook.ID), 10) + "/schedule"
+}
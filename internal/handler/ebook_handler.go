@@ -20,6 +20,7 @@ import (
 	"github.com/anglesson/simple-web-server/internal/repository/gorm"
 	"github.com/anglesson/simple-web-server/internal/service"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/storage"
 	"github.com/anglesson/simple-web-server/pkg/template"
 	"github.com/anglesson/simple-web-server/pkg/utils"
@@ -174,30 +175,29 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 
 	errors := make(map[string]string)
 
-	// Validar arquivos selecionados
+	// Validar arquivos selecionados; na pré-venda eles podem vir depois
+	preSale := r.FormValue("pre_sale") != ""
 	selectedFiles := r.Form["selected_files"]
-	if len(selectedFiles) == 0 {
+	if len(selectedFiles) == 0 && !preSale {
 		errors["files"] = "Selecione pelo menos um arquivo para o ebook"
 	}
 
-	// Processar valor apenas se não houver erro de conversão
-	var value float64
-	valueStr := r.FormValue("value")
-	if valueStr != "" {
-		var err error
-		value, err = utils.BRLToFloat(valueStr)
-		if err != nil {
-			log.Println("Falha na conversão do valor do e-book")
-			errors["value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
-		}
-	}
-
 	form := models.EbookRequest{
-		Title:       r.FormValue("title"),
-		Description: r.FormValue("description"),
-		SalesPage:   r.FormValue("sales_page"),
-		Value:       value,
-		Status:      true,
+		Title:          r.FormValue("title"),
+		Description:    r.FormValue("description"),
+		SalesPage:      r.FormValue("sales_page"),
+		Value:          r.FormValue("value"),
+		Currency:       formCurrency(r),
+		Status:         true,
+		PricingMode:    r.FormValue("pricing_mode"),
+		SuggestedValue: r.FormValue("suggested_value"),
+		MaxDownloads:   r.FormValue("max_downloads"),
+		ExpiryDays:     r.FormValue("expiry_days"),
+		MaxDevices:     r.FormValue("max_devices"),
+		PublishAt:      r.FormValue("publish_at"),
+		UnpublishAt:    r.FormValue("unpublish_at"),
+		PreSale:        r.FormValue("pre_sale") != "",
+		Category:       strings.TrimSpace(r.FormValue("category")),
 	}
 
 	errForm := utils.ValidateForm(form)
@@ -205,6 +205,31 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 		errors[key] = value
 	}
 
+	price, priceErrors := parseEbookPrice(form)
+	for key, value := range priceErrors {
+		errors[key] = value
+	}
+
+	var pricingMode string
+	var suggestedPrice money.Money
+	if len(priceErrors) == 0 {
+		var pricingErrors map[string]string
+		pricingMode, suggestedPrice, pricingErrors = parseEbookPricing(form, price)
+		for key, value := range pricingErrors {
+			errors[key] = value
+		}
+	}
+
+	policy, policyErrors := parseDownloadPolicy(form)
+	for key, value := range policyErrors {
+		errors[key] = value
+	}
+
+	publishAt, unpublishAt, launchErrors := parseEbookLaunch(form)
+	for key, value := range launchErrors {
+		errors[key] = value
+	}
+
 	if len(errors) > 0 {
 		h.redirectWithErrors(w, r, form, errors)
 		return
@@ -231,7 +256,12 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Criar ebook
-	ebook := models.NewEbook(form.Title, form.Description, form.SalesPage, form.Value, *creator)
+	ebook := models.NewEbook(form.Title, form.Description, form.SalesPage, price, *creator)
+	ebook.SetPricing(pricingMode, price, suggestedPrice)
+	ebook.DownloadPolicy = policy
+	ebook.PreSale = form.PreSale
+	ebook.Category = form.Category
+	ebook.ScheduleLaunch(publishAt, unpublishAt, time.Now())
 
 	// Definir a URL da imagem se foi enviada
 	if imageURL != "" {
@@ -248,6 +278,10 @@ func (h *EbookHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
 
 	// Salvar ebook
 	err = h.ebookService.Create(ebook)
+	if limitErr, ok := err.(*service.PlanLimitError); ok {
+		web.RedirectBackWithErrors(w, r, limitErr.Error())
+		return
+	}
 	if err != nil {
 		log.Printf("Falha ao salvar e-book: %s", err)
 		web.RedirectBackWithErrors(w, r, "Falha ao salvar e-book")
@@ -338,23 +372,27 @@ func (h *EbookHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
 func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 	errors := make(map[string]string)
 
-	value, err := utils.BRLToFloat(r.FormValue("value"))
-	if err != nil {
-		http.Error(w, "erro na conversão", http.StatusInternalServerError)
-		return
-	}
-
 	status := false
 	if r.FormValue("status") != "" {
 		status = true
 	}
 
 	form := models.EbookRequest{
-		Title:       r.FormValue("title"),
-		Description: r.FormValue("description"),
-		SalesPage:   r.FormValue("sales_page"),
-		Value:       value,
-		Status:      status,
+		Title:          r.FormValue("title"),
+		Description:    r.FormValue("description"),
+		SalesPage:      r.FormValue("sales_page"),
+		Value:          r.FormValue("value"),
+		Currency:       formCurrency(r),
+		Status:         status,
+		PricingMode:    r.FormValue("pricing_mode"),
+		SuggestedValue: r.FormValue("suggested_value"),
+		MaxDownloads:   r.FormValue("max_downloads"),
+		ExpiryDays:     r.FormValue("expiry_days"),
+		MaxDevices:     r.FormValue("max_devices"),
+		PublishAt:      r.FormValue("publish_at"),
+		UnpublishAt:    r.FormValue("unpublish_at"),
+		PreSale:        r.FormValue("pre_sale") != "",
+		Category:       strings.TrimSpace(r.FormValue("category")),
 	}
 
 	errForm := utils.ValidateForm(form)
@@ -362,6 +400,31 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 		errors[key] = value
 	}
 
+	price, priceErrors := parseEbookPrice(form)
+	for key, value := range priceErrors {
+		errors[key] = value
+	}
+
+	var pricingMode string
+	var suggestedPrice money.Money
+	if len(priceErrors) == 0 {
+		var pricingErrors map[string]string
+		pricingMode, suggestedPrice, pricingErrors = parseEbookPricing(form, price)
+		for key, value := range pricingErrors {
+			errors[key] = value
+		}
+	}
+
+	policy, policyErrors := parseDownloadPolicy(form)
+	for key, value := range policyErrors {
+		errors[key] = value
+	}
+
+	publishAt, unpublishAt, launchErrors := parseEbookLaunch(form)
+	for key, value := range launchErrors {
+		errors[key] = value
+	}
+
 	// Validar arquivo apenas se foi enviado
 	uploadFile, uploadFileHeader, uploadErr := r.FormFile("file")
 	if uploadErr == nil && uploadFile != nil && uploadFileHeader != nil && uploadFileHeader.Filename != "" {
@@ -383,7 +446,7 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verificar se o usuário é um criador
-	_, err = h.creatorService.FindCreatorByUserID(user.ID)
+	_, err := h.creatorService.FindCreatorByUserID(user.ID)
 	if err != nil {
 		log.Printf("Falha ao buscar criador: %s", err)
 		http.Error(w, "Entre em contato", http.StatusInternalServerError)
@@ -407,9 +470,16 @@ func (h *EbookHandler) UpdateSubmit(w http.ResponseWriter, r *http.Request) {
 	// Atualizar dados do ebook
 	ebook.Title = form.Title
 	ebook.Description = form.Description
+	ebook.Category = form.Category
 	ebook.SalesPage = form.SalesPage
-	ebook.Value = form.Value
+	ebook.SetPricing(pricingMode, price, suggestedPrice)
+	ebook.DownloadPolicy = policy
 	ebook.Status = form.Status
+	ebook.ScheduleLaunch(publishAt, unpublishAt, time.Now())
+	// A pré-venda só é encerrada pelo lançamento, que entrega as compras feitas
+	if form.PreSale {
+		ebook.PreSale = true
+	}
 
 	// Processar novos arquivos selecionados
 	newFiles := r.Form["new_files"]
@@ -725,6 +795,11 @@ func (h *EbookHandler) getClientsForEbook(creator *models.Creator, ebookID uint,
 }
 
 func (h *EbookHandler) redirectWithErrors(w http.ResponseWriter, r *http.Request, form models.EbookRequest, errors map[string]string) {
+	redirectWithFormErrors(w, r, form, errors)
+}
+
+// redirectWithFormErrors devolve o formulário e os erros de validação para a mesma página
+func redirectWithFormErrors(w http.ResponseWriter, r *http.Request, form any, errors map[string]string) {
 	formJSON, _ := json.Marshal(form)
 	errorsJSON, _ := json.Marshal(errors)
 
@@ -740,3 +815,143 @@ func (h *EbookHandler) redirectWithErrors(w http.ResponseWriter, r *http.Request
 	})
 	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
 }
+
+// formCurrency retorna a moeda enviada no formulário, usando BRL como padrão
+func formCurrency(r *http.Request) string {
+	currency := strings.ToUpper(strings.TrimSpace(r.FormValue("currency")))
+	if currency == "" {
+		return string(money.BRL)
+	}
+	return currency
+}
+
+// parseEbookPrice converte o valor digitado no formulário para centavos na moeda escolhida
+func parseEbookPrice(form models.EbookRequest) (money.Money, map[string]string) {
+	errors := make(map[string]string)
+
+	currency, err := money.ParseCurrency(form.Currency)
+	if err != nil {
+		errors["currency"] = "Moeda não suportada"
+		return money.Money{}, errors
+	}
+
+	if form.PricingMode == models.PricingFree {
+		return money.New(0, currency), errors
+	}
+	if strings.TrimSpace(form.Value) == "" {
+		errors["value"] = "Preenchimento obrigatório"
+		return money.Money{}, errors
+	}
+
+	price, err := money.Parse(form.Value, currency)
+	if err != nil {
+		log.Println("Falha na conversão do valor do e-book")
+		errors["value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
+		return money.Money{}, errors
+	}
+	if price.Amount <= 0 {
+		errors["value"] = "Valor deve ser maior que zero"
+	}
+
+	return price, errors
+}
+
+// parseEbookPricing lê a modalidade de preço e o valor sugerido do formulário,
+// validando a combinação com o preço já convertido
+func parseEbookPricing(form models.EbookRequest, price money.Money) (string, money.Money, map[string]string) {
+	errors := make(map[string]string)
+
+	mode := form.PricingMode
+	if mode == "" {
+		mode = models.PricingFixed
+	}
+
+	var suggested money.Money
+	if mode == models.PricingMinimum && strings.TrimSpace(form.SuggestedValue) != "" {
+		var err error
+		suggested, err = money.Parse(form.SuggestedValue, price.Currency)
+		if err != nil {
+			errors["suggested_value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
+			return mode, suggested, errors
+		}
+	}
+
+	switch err := (&models.Ebook{}).SetPricing(mode, price, suggested); err {
+	case nil:
+	case models.ErrSuggestedBelowMinimum:
+		errors["suggested_value"] = err.Error()
+	case models.ErrInvalidEbookPrice:
+		errors["value"] = "Valor deve ser maior que zero"
+	default:
+		errors["pricing_mode"] = err.Error()
+	}
+	return mode, suggested, errors
+}
+
+// parseDownloadPolicy lê os limites de entrega do formulário. Campos vazios
+// significam sem limite.
+func parseDownloadPolicy(form models.EbookRequest) (models.DownloadPolicy, map[string]string) {
+	errors := make(map[string]string)
+	fields := map[string]string{
+		"max_downloads": form.MaxDownloads,
+		"expiry_days":   form.ExpiryDays,
+		"max_devices":   form.MaxDevices,
+	}
+
+	values := make(map[string]int, len(fields))
+	for key, raw := range fields {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			errors[key] = "Informe um número inteiro"
+			continue
+		}
+		values[key] = value
+	}
+
+	policy := models.DownloadPolicy{
+		MaxDownloads: values["max_downloads"],
+		ExpiryDays:   values["expiry_days"],
+		MaxDevices:   values["max_devices"],
+	}
+	switch err := policy.Validate(); err {
+	case nil:
+	case models.ErrInvalidMaxDownloads:
+		errors["max_downloads"] = err.Error()
+	case models.ErrInvalidExpiryDays:
+		errors["expiry_days"] = err.Error()
+	default:
+		errors["max_devices"] = err.Error()
+	}
+	return policy, errors
+}
+
+// parseEbookLaunch lê as datas de publicação e despublicação do formulário.
+// Campos vazios desligam o agendamento.
+func parseEbookLaunch(form models.EbookRequest) (*time.Time, *time.Time, map[string]string) {
+	errors := make(map[string]string)
+	dates := make(map[string]*time.Time, 2)
+	for key, raw := range map[string]string{"publish_at": form.PublishAt, "unpublish_at": form.UnpublishAt} {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		date, err := time.ParseInLocation(dateTimeLocalLayout, raw, time.Local)
+		if err != nil {
+			errors[key] = "Data inválida"
+			continue
+		}
+		dates[key] = &date
+	}
+	if len(errors) > 0 {
+		return nil, nil, errors
+	}
+
+	if err := models.ValidateLaunch(dates["publish_at"], dates["unpublish_at"], time.Now()); err != nil {
+		errors["unpublish_at"] = err.Error()
+	}
+	return dates["publish_at"], dates["unpublish_at"], errors
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/anglesson/simple-web-server/internal/repository"
 	"github.com/anglesson/simple-web-server/internal/service"
 	service_mocks "github.com/anglesson/simple-web-server/internal/service/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	template_mocks "github.com/anglesson/simple-web-server/pkg/template/mocks"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -432,7 +433,7 @@ func (suite *EbookHandlerTestSuite) TestCreateSubmit_DescriptionTooLong() {
 	// Verify form data is preserved
 	assert.Equal(suite.T(), "Valid Title", savedForm["title"])
 	assert.Equal(suite.T(), "Valid Sales Page", savedForm["sales_page"])
-	assert.Equal(suite.T(), 29.9, savedForm["value"])
+	assert.Equal(suite.T(), "29,90", savedForm["value"])
 }
 
 func (suite *EbookHandlerTestSuite) TestCreateSubmit_SimpleValidation() {
@@ -508,7 +509,7 @@ func (suite *EbookHandlerTestSuite) TestUpdateSubmit_DeactivateEbook() {
 		Title:       "Old Title",
 		Description: "Old Description",
 		SalesPage:   "Old Sales Page",
-		Value:       19.90,
+		Price:       money.New(1990, money.BRL),
 		Status:      true, // Currently active
 		CreatorID:   1,
 	}
@@ -546,7 +547,7 @@ func (suite *EbookHandlerTestSuite) TestUpdateSubmit_DeactivateEbook() {
 			assert.Equal(suite.T(), "Valid Title", ebook.Title)
 			assert.Equal(suite.T(), "Valid Description", ebook.Description)
 			assert.Equal(suite.T(), "Valid Sales Page", ebook.SalesPage)
-			assert.Equal(suite.T(), 29.90, ebook.Value)
+			assert.Equal(suite.T(), money.New(2990, money.BRL), ebook.Price)
 			assert.False(suite.T(), ebook.Status, "Ebook status should be false (deactivated)")
 			break
 		}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/go-chi/chi/v5"
+)
+
+// EbookLaunchHandler encerra a pré-venda dos ebooks do criador
+type EbookLaunchHandler struct {
+	launchService  service.EbookLaunchService
+	ebookService   service.EbookService
+	creatorService service.CreatorService
+}
+
+func NewEbookLaunchHandler(
+	launchService service.EbookLaunchService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+) *EbookLaunchHandler {
+	return &EbookLaunchHandler{
+		launchService:  launchService,
+		ebookService:   ebookService,
+		creatorService: creatorService,
+	}
+}
+
+// ReleaseSubmit lança o ebook e envia o download a quem comprou na pré-venda
+func (h *EbookLaunchHandler) ReleaseSubmit(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	delivered, err := h.launchService.Release(ebook)
+	switch {
+	case errors.Is(err, models.ErrNotInPreSale), errors.Is(err, models.ErrReleaseWithoutFiles):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao liberar a pré-venda do ebook %d: %v", ebook.ID, err)
+		cookies.NotifyError(w, "Erro ao liberar o lançamento")
+	default:
+		cookies.NotifySuccess(w, fmt.Sprintf("Lançamento liberado! Download enviado a %d compradores.", delivered))
+	}
+	http.Redirect(w, r, "/ebook/view/"+strconv.FormatUint(uint64(ebook.ID), 10), http.StatusSeeOther)
+}
+
+// creatorEbook busca o ebook da URL garantindo que pertence ao criador logado
+func (h *EbookLaunchHandler) creatorEbook(w http.ResponseWriter, r *http.Request) *models.Ebook {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil
+	}
+
+	return ebook
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// Formato do campo datetime-local dos formulários
+const dateTimeLocalLayout = "2006-01-02T15:04"
+
+// EbookPriceHandler gerencia os preços promocionais agendados pelo criador
+type EbookPriceHandler struct {
+	priceService     service.EbookPriceService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewEbookPriceHandler(
+	priceService service.EbookPriceService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *EbookPriceHandler {
+	return &EbookPriceHandler{
+		priceService:     priceService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView lista as promoções do ebook e as vendas feitas em cada preço
+func (h *EbookPriceHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	periods, err := h.priceService.ListForEbook(ebook.ID)
+	if err != nil {
+		log.Printf("Erro ao listar promoções do ebook %d: %v", ebook.ID, err)
+		http.Error(w, "Erro ao listar promoções", http.StatusInternalServerError)
+		return
+	}
+
+	sales, err := h.priceService.SalesByPrice(ebook.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar vendas por preço do ebook %d: %v", ebook.ID, err)
+	}
+
+	h.templateRenderer.View(w, r, "ebook/prices", map[string]any{
+		"Ebook":   ebook,
+		"Periods": periods,
+		"Sales":   sales,
+	}, "admin")
+}
+
+// CreateSubmit agenda um preço promocional para o ebook
+func (h *EbookPriceHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	form := map[string]string{
+		"label":     strings.TrimSpace(r.FormValue("label")),
+		"value":     r.FormValue("value"),
+		"starts_at": r.FormValue("starts_at"),
+		"ends_at":   r.FormValue("ends_at"),
+	}
+	errs := make(map[string]string)
+
+	price, err := money.Parse(form["value"], ebook.Price.Currency)
+	if err != nil || price.Amount <= 0 {
+		errs["value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
+	}
+
+	startsAt, err := time.ParseInLocation(dateTimeLocalLayout, form["starts_at"], time.Local)
+	if err != nil {
+		errs["starts_at"] = "Informe o início da promoção"
+	}
+
+	endsAt, err := time.ParseInLocation(dateTimeLocalLayout, form["ends_at"], time.Local)
+	if err != nil {
+		errs["ends_at"] = "Informe o fim da promoção"
+	}
+
+	if len(errs) > 0 {
+		redirectWithFormErrors(w, r, form, errs)
+		return
+	}
+
+	if _, err := h.priceService.Schedule(ebook, form["label"], price, startsAt, endsAt); err != nil {
+		log.Printf("Falha ao agendar promoção do ebook %d: %v", ebook.ID, err)
+		redirectWithFormErrors(w, r, form, map[string]string{"period": err.Error()})
+		return
+	}
+
+	cookies.NotifySuccess(w, "Promoção agendada com sucesso!")
+	http.Redirect(w, r, pricesURL(ebook), http.StatusSeeOther)
+}
+
+// DeleteSubmit encerra ou cancela a promoção; os pedidos feitos nela mantêm o preço pago
+func (h *EbookPriceHandler) DeleteSubmit(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "periodID"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID da promoção inválido", http.StatusBadRequest)
+		return
+	}
+
+	period, err := h.priceService.FindByID(uint(id))
+	if errors.Is(err, models.ErrPricePeriodNotFound) || (err == nil && period.EbookID != ebook.ID) {
+		http.Error(w, "Promoção não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar promoção", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.priceService.Cancel(period); err != nil {
+		log.Printf("Falha ao excluir promoção %d: %v", period.ID, err)
+		cookies.NotifyError(w, "Erro ao excluir promoção")
+	} else {
+		cookies.NotifySuccess(w, "Promoção excluída com sucesso!")
+	}
+	http.Redirect(w, r, pricesURL(ebook), http.StatusSeeOther)
+}
+
+// creatorEbook busca o ebook da URL garantindo que pertence ao criador logado
+func (h *EbookPriceHandler) creatorEbook(w http.ResponseWriter, r *http.Request) *models.Ebook {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil
+	}
+
+	return ebook
+}
+
+func pricesURL(ebook *models.Ebook) string {
+	return "/ebook/" + strconv.FormatUint(uint64(ebook.ID), 10) + "/prices"
+}
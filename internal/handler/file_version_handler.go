@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// FileVersionHandler gerencia as versões dos arquivos e o aviso aos compradores
+type FileVersionHandler struct {
+	versionService   service.FileVersionService
+	fileService      service.FileService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewFileVersionHandler(
+	versionService service.FileVersionService,
+	fileService service.FileService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *FileVersionHandler {
+	return &FileVersionHandler{
+		versionService:   versionService,
+		fileService:      fileService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView mostra a versão atual do arquivo e as versões arquivadas
+func (h *FileVersionHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	file := h.creatorFile(w, r)
+	if file == nil {
+		return
+	}
+
+	versions, err := h.versionService.Versions(file)
+	if err != nil {
+		log.Printf("Erro ao listar versões do arquivo %d: %v", file.ID, err)
+		http.Error(w, "Erro ao listar versões", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "file/versions", map[string]any{
+		"File":     file,
+		"Versions": versions,
+	}, "admin")
+}
+
+// PublishSubmit envia uma nova versão do arquivo
+func (h *FileVersionHandler) PublishSubmit(w http.ResponseWriter, r *http.Request) {
+	file := h.creatorFile(w, r)
+	if file == nil {
+		return
+	}
+
+	// Parse multipart form (máximo 50MB)
+	if err := r.ParseMultipartForm(50 << 20); err != nil {
+		http.Error(w, "Erro ao processar formulário", http.StatusBadRequest)
+		return
+	}
+
+	upload, header, err := r.FormFile("file")
+	if err != nil {
+		cookies.NotifyError(w, "Selecione o arquivo da nova versão")
+		http.Redirect(w, r, fileVersionsURL(file), http.StatusSeeOther)
+		return
+	}
+	defer upload.Close()
+
+	if err := h.versionService.Publish(file, header, r.FormValue("changelog")); err != nil {
+		log.Printf("Falha ao publicar versão do arquivo %d: %v", file.ID, err)
+		cookies.NotifyError(w, err.Error())
+	} else {
+		cookies.NotifySuccess(w, fmt.Sprintf("Versão %d publicada! Avise os compradores na página do ebook.", file.Version))
+	}
+	http.Redirect(w, r, fileVersionsURL(file), http.StatusSeeOther)
+}
+
+// NotifySubmit envia "nova versão disponível" aos compradores do ebook
+func (h *FileVersionHandler) NotifySubmit(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	sent, err := h.versionService.NotifyBuyers(ebook)
+	switch {
+	case errors.Is(err, models.ErrNoNewVersion):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao avisar compradores do ebook %d: %v", ebook.ID, err)
+		cookies.NotifyError(w, "Erro ao avisar compradores")
+	default:
+		cookies.NotifySuccess(w, fmt.Sprintf("Aviso de nova versão enviado a %d compradores.", sent))
+	}
+	http.Redirect(w, r, "/ebook/view/"+strconv.FormatUint(uint64(ebook.ID), 10), http.StatusSeeOther)
+}
+
+// creatorFile busca o arquivo da URL garantindo que pertence ao criador logado
+func (h *FileVersionHandler) creatorFile(w http.ResponseWriter, r *http.Request) *models.File {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do arquivo inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	file, err := h.fileService.GetFileByID(uint(id))
+	if err != nil || file == nil || file.CreatorID != creator.ID {
+		http.Error(w, "Arquivo não encontrado", http.StatusNotFound)
+		return nil
+	}
+
+	return file
+}
+
+// creatorEbook busca o ebook da URL garantindo que pertence ao criador logado
+func (h *FileVersionHandler) creatorEbook(w http.ResponseWriter, r *http.Request) *models.Ebook {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil
+	}
+
+	return ebook
+}
+
+func (h *FileVersionHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
+
+func fileVersionsURL(file *models.File) string {
+	return "/file/" + strconv.FormatUint(uint64(file.ID), 10) + "/versions"
+}
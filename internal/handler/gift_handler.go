@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// GiftHandler conduz o resgate dos ebooks recebidos de presente
+type GiftHandler struct {
+	giftService      service.GiftService
+	rfService        gov.ReceitaFederalService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewGiftHandler(giftService service.GiftService, rfService gov.ReceitaFederalService, templateRenderer template.TemplateRenderer) *GiftHandler {
+	return &GiftHandler{
+		giftService:      giftService,
+		rfService:        rfService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// ClaimView exibe o presente e o formulário com os dados de quem vai resgatar
+func (h *GiftHandler) ClaimView(w http.ResponseWriter, r *http.Request) {
+	gift := h.findGift(w, r)
+	if gift == nil {
+		return
+	}
+
+	step := "form"
+	if gift.IsClaimed() {
+		step = "claimed"
+	}
+
+	h.templateRenderer.View(w, r, "gift", map[string]any{
+		"Step": step,
+		"Gift": gift,
+		"Form": map[string]string{"name": gift.RecipientName, "email": gift.RecipientEmail},
+	}, "guest")
+}
+
+// ClaimSubmit valida os dados de quem recebeu o presente, como no checkout, e
+// libera o ebook em seu nome
+func (h *GiftHandler) ClaimSubmit(w http.ResponseWriter, r *http.Request) {
+	gift := h.findGift(w, r)
+	if gift == nil {
+		return
+	}
+
+	form := map[string]string{
+		"name":      r.FormValue("name"),
+		"cpf":       r.FormValue("cpf"),
+		"birthdate": r.FormValue("birthdate"),
+		"email":     r.FormValue("email"),
+		"phone":     r.FormValue("phone"),
+	}
+	request := checkoutCustomerRequest{
+		Name:      form["name"],
+		CPF:       nonDigits.ReplaceAllString(form["cpf"], ""),
+		Birthdate: form["birthdate"],
+		Email:     form["email"],
+		Phone:     nonDigits.ReplaceAllString(form["phone"], ""),
+	}
+
+	renderError := func(status int, message string) {
+		w.WriteHeader(status)
+		h.templateRenderer.View(w, r, "gift", map[string]any{
+			"Step":   "form",
+			"Gift":   gift,
+			"Form":   form,
+			"Errors": map[string]string{"gift": message},
+		}, "guest")
+	}
+
+	if status, message := validateCheckoutCustomer(h.rfService, request); status != http.StatusOK {
+		renderError(status, message)
+		return
+	}
+
+	client, err := createOrFindClient(request, gift.CreatorID)
+	if err != nil {
+		log.Printf("Erro ao criar/buscar cliente do presente %d: %v", gift.ID, err)
+		renderError(http.StatusInternalServerError, "Erro ao processar seus dados")
+		return
+	}
+
+	_, err = h.giftService.Claim(gift, client)
+	if errors.Is(err, models.ErrGiftAlreadyClaimed) || errors.Is(err, models.ErrGiftNotAvailable) {
+		renderError(http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao resgatar presente %d: %v", gift.ID, err)
+		renderError(http.StatusInternalServerError, "Erro ao resgatar o presente")
+		return
+	}
+
+	h.templateRenderer.View(w, r, "gift", map[string]any{
+		"Step":  "done",
+		"Gift":  gift,
+		"Email": client.Email,
+	}, "guest")
+}
+
+func (h *GiftHandler) findGift(w http.ResponseWriter, r *http.Request) *models.Gift {
+	gift, err := h.giftService.FindByToken(chi.URLParam(r, "token"))
+	if errors.Is(err, service.ErrGiftNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "gift", map[string]any{"Step": "invalid"}, "guest")
+		return nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar presente: %v", err)
+		http.Error(w, "Erro ao buscar presente", http.StatusInternalServerError)
+		return nil
+	}
+	return gift
+}
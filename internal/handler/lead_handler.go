@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/anglesson/simple-web-server/internal/service"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// LeadHandler entrega os ebooks gratuitos em troca do e-mail confirmado do leitor
+type LeadHandler struct {
+	leadService      service.LeadService
+	ebookService     service.EbookService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewLeadHandler(leadService service.LeadService, ebookService service.EbookService, templateRenderer template.TemplateRenderer) *LeadHandler {
+	return &LeadHandler{
+		leadService:      leadService,
+		ebookService:     ebookService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// SubscribeSubmit registra o pedido do ebook gratuito e envia o e-mail de confirmação
+func (h *LeadHandler) SubscribeSubmit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || !ebook.Status || !ebook.IsFree() {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return
+	}
+
+	form := map[string]string{
+		"name":              r.FormValue("name"),
+		"email":             r.FormValue("email"),
+		"marketing_consent": r.FormValue("marketing_consent"),
+	}
+
+	lead, err := h.leadService.Subscribe(ebook, form["name"], form["email"], form["marketing_consent"] != "")
+	if err != nil {
+		log.Printf("Falha ao registrar lead do ebook %d: %v", ebook.ID, err)
+		w.WriteHeader(http.StatusBadRequest)
+		h.templateRenderer.View(w, r, "lead", map[string]any{
+			"Step":   "form",
+			"Ebook":  ebook,
+			"Form":   form,
+			"Errors": map[string]string{"lead": err.Error()},
+		}, "guest")
+		return
+	}
+
+	h.templateRenderer.View(w, r, "lead", map[string]any{
+		"Step":  "sent",
+		"Ebook": ebook,
+		"Email": lead.Client.Email,
+	}, "guest")
+}
+
+// ConfirmView confirma o e-mail do leitor e libera o download do ebook gratuito
+func (h *LeadHandler) ConfirmView(w http.ResponseWriter, r *http.Request) {
+	lead, err := h.leadService.Confirm(chi.URLParam(r, "token"))
+	if errors.Is(err, service.ErrLeadNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "lead", map[string]any{"Step": "invalid"}, "guest")
+		return
+	}
+	if err != nil {
+		log.Printf("Falha ao confirmar lead: %v", err)
+		http.Error(w, "Erro ao confirmar e-mail", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "lead", map[string]any{
+		"Step":  "confirmed",
+		"Ebook": &lead.Ebook,
+		"Lead":  lead,
+	}, "guest")
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// Tamanho máximo da planilha de licenças enviada pelo gestor
+const maxSeatsUploadSize = 1 << 20
+
+// LicenseHandler atende o gestor das licenças para equipe, que atribui e transfere
+// as licenças, e o criador, que acompanha as entregas de cada pedido
+type LicenseHandler struct {
+	licenseService   service.LicenseService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewLicenseHandler(
+	licenseService service.LicenseService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *LicenseHandler {
+	return &LicenseHandler{
+		licenseService:   licenseService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// ManageView exibe ao gestor as licenças atribuídas e o formulário para atribuir as restantes
+func (h *LicenseHandler) ManageView(w http.ResponseWriter, r *http.Request) {
+	license := h.findLicense(w, r)
+	if license == nil {
+		return
+	}
+
+	h.templateRenderer.View(w, r, "license", map[string]any{
+		"Step":    "manage",
+		"License": license,
+	}, "guest")
+}
+
+// AssignSubmit atribui licenças às pessoas coladas no formulário ou enviadas em CSV
+func (h *LicenseHandler) AssignSubmit(w http.ResponseWriter, r *http.Request) {
+	license := h.findLicense(w, r)
+	if license == nil {
+		return
+	}
+	manageURL := licenseURL(license)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSeatsUploadSize)
+	if err := r.ParseMultipartForm(maxSeatsUploadSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		cookies.NotifyError(w, "Arquivo inválido ou maior que 1 MB")
+		http.Redirect(w, r, manageURL, http.StatusSeeOther)
+		return
+	}
+
+	// A planilha, quando enviada, tem prioridade sobre a lista colada no formulário
+	var source io.Reader = strings.NewReader(r.FormValue("seats"))
+	if file, _, err := r.FormFile("csv"); err == nil {
+		defer file.Close()
+		source = file
+	}
+
+	inputs, err := service.ParseSeatInputs(source)
+	if err == nil {
+		_, err = h.licenseService.Assign(license, inputs)
+	}
+	if err != nil {
+		log.Printf("Erro ao atribuir licenças %d: %v", license.ID, err)
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, manageURL, http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, fmt.Sprintf("%d licença(s) atribuída(s). Cada pessoa recebeu o link de download por e-mail.", len(inputs)))
+	http.Redirect(w, r, manageURL, http.StatusSeeOther)
+}
+
+// ReassignSubmit transfere uma licença que ainda não foi baixada para outra pessoa
+func (h *LicenseHandler) ReassignSubmit(w http.ResponseWriter, r *http.Request) {
+	license := h.findLicense(w, r)
+	if license == nil {
+		return
+	}
+	manageURL := licenseURL(license)
+
+	seatID, err := strconv.ParseUint(chi.URLParam(r, "seatID"), 10, 32)
+	if err != nil {
+		http.Error(w, "Licença inválida", http.StatusBadRequest)
+		return
+	}
+
+	seat, err := h.licenseService.Reassign(license, uint(seatID), service.SeatInput{
+		Name:  r.FormValue("name"),
+		Email: r.FormValue("email"),
+	})
+	if err != nil {
+		log.Printf("Erro ao transferir licença %d: %v", seatID, err)
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, manageURL, http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Licença transferida para "+seat.Email)
+	http.Redirect(w, r, manageURL, http.StatusSeeOther)
+}
+
+// IndexView lista ao criador os pedidos de licenças do ebook, cada um com as suas entregas
+func (h *LicenseHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	licenses, err := h.licenseService.ListForEbook(ebook.ID)
+	if err != nil {
+		log.Printf("Erro ao listar licenças do ebook %d: %v", ebook.ID, err)
+		http.Error(w, "Erro ao listar licenças", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "ebook/licenses", map[string]any{
+		"Ebook":    ebook,
+		"Licenses": licenses,
+	}, "admin")
+}
+
+func (h *LicenseHandler) findLicense(w http.ResponseWriter, r *http.Request) *models.License {
+	license, err := h.licenseService.FindByToken(chi.URLParam(r, "token"))
+	if errors.Is(err, service.ErrLicenseNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "license", map[string]any{"Step": "invalid"}, "guest")
+		return nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar licença: %v", err)
+		http.Error(w, "Erro ao buscar licença", http.StatusInternalServerError)
+		return nil
+	}
+	return license
+}
+
+func (h *LicenseHandler) creatorEbook(w http.ResponseWriter, r *http.Request) *models.Ebook {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil
+	}
+
+	return ebook
+}
+
+func licenseURL(license *models.License) string {
+	return "/license/" + license.Token
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/gov"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// MembershipHandler atende o criador, que cadastra as assinaturas do catálogo, e o
+// assinante, que assina, acessa os ebooks liberados e cancela a assinatura
+type MembershipHandler struct {
+	membershipService service.MembershipService
+	creatorService    service.CreatorService
+	rfService         gov.ReceitaFederalService
+	templateRenderer  template.TemplateRenderer
+}
+
+func NewMembershipHandler(
+	membershipService service.MembershipService,
+	creatorService service.CreatorService,
+	rfService gov.ReceitaFederalService,
+	templateRenderer template.TemplateRenderer,
+) *MembershipHandler {
+	return &MembershipHandler{
+		membershipService: membershipService,
+		creatorService:    creatorService,
+		rfService:         rfService,
+		templateRenderer:  templateRenderer,
+	}
+}
+
+// IndexView lista as assinaturas do criador com os assinantes em dia de cada uma
+func (h *MembershipHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	memberships, counts, err := h.membershipService.ListForCreator(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao listar assinaturas do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao listar assinaturas", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "membership/index", map[string]any{
+		"Memberships": memberships,
+		"Members":     counts,
+		"Currencies":  money.SupportedCurrencies(),
+	}, "admin")
+}
+
+// CreateSubmit cadastra uma assinatura do catálogo
+func (h *MembershipHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	price, priceErrors := parseEbookPrice(models.EbookRequest{
+		Value:    r.FormValue("value"),
+		Currency: formCurrency(r),
+	})
+	// parseEbookPrice devolve no máximo um erro: a moeda ou o valor
+	if message := priceErrors["currency"] + priceErrors["value"]; message != "" {
+		cookies.NotifyError(w, message)
+		http.Redirect(w, r, "/memberships", http.StatusSeeOther)
+		return
+	}
+
+	_, err := h.membershipService.Create(creator.ID, r.FormValue("name"), r.FormValue("description"), price, r.FormValue("interval"))
+	if err != nil {
+		log.Printf("Erro ao criar assinatura do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, "/memberships", http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Assinatura criada com sucesso!")
+	http.Redirect(w, r, "/memberships", http.StatusSeeOther)
+}
+
+// ToggleSubmit ativa ou pausa a venda da assinatura. Quem já assina continua com acesso.
+func (h *MembershipHandler) ToggleSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID da assinatura inválido", http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.membershipService.FindForCreator(uint(id), creator.ID)
+	if errors.Is(err, service.ErrMembershipNotFound) {
+		http.Error(w, "Assinatura não encontrada", http.StatusNotFound)
+		return
+	}
+	if err == nil {
+		err = h.membershipService.SetActive(membership, !membership.Active)
+	}
+	if err != nil {
+		log.Printf("Erro ao atualizar assinatura %d: %v", id, err)
+		http.Error(w, "Erro ao atualizar assinatura", http.StatusInternalServerError)
+		return
+	}
+
+	if membership.Active {
+		cookies.NotifySuccess(w, "Assinatura disponível para venda.")
+	} else {
+		cookies.NotifySuccess(w, "Venda da assinatura pausada. Os assinantes atuais continuam com acesso.")
+	}
+	http.Redirect(w, r, "/memberships", http.StatusSeeOther)
+}
+
+// SubscribeView exibe a assinatura e o formulário com os dados do assinante
+func (h *MembershipHandler) SubscribeView(w http.ResponseWriter, r *http.Request) {
+	membership := h.findAvailable(w, r)
+	if membership == nil {
+		return
+	}
+
+	h.templateRenderer.View(w, r, "membership/subscribe", map[string]any{
+		"Step":       "form",
+		"Membership": membership,
+		"Form":       map[string]string{},
+	}, "guest")
+}
+
+// SubscribeSubmit valida os dados do assinante, como no checkout, e o envia ao
+// pagamento recorrente no gateway
+func (h *MembershipHandler) SubscribeSubmit(w http.ResponseWriter, r *http.Request) {
+	membership := h.findAvailable(w, r)
+	if membership == nil {
+		return
+	}
+
+	form := map[string]string{
+		"name":      r.FormValue("name"),
+		"cpf":       r.FormValue("cpf"),
+		"birthdate": r.FormValue("birthdate"),
+		"email":     r.FormValue("email"),
+		"phone":     r.FormValue("phone"),
+	}
+	request := checkoutCustomerRequest{
+		Name:      form["name"],
+		CPF:       nonDigits.ReplaceAllString(form["cpf"], ""),
+		Birthdate: form["birthdate"],
+		Email:     form["email"],
+		Phone:     nonDigits.ReplaceAllString(form["phone"], ""),
+	}
+
+	renderError := func(status int, message string) {
+		w.WriteHeader(status)
+		h.templateRenderer.View(w, r, "membership/subscribe", map[string]any{
+			"Step":       "form",
+			"Membership": membership,
+			"Form":       form,
+			"Errors":     map[string]string{"membership": message},
+		}, "guest")
+	}
+
+	if status, message := validateCheckoutCustomer(h.rfService, request); status != http.StatusOK {
+		renderError(status, message)
+		return
+	}
+
+	client, err := createOrFindClient(request, membership.CreatorID)
+	if err != nil {
+		log.Printf("Erro ao criar/buscar cliente da assinatura %d: %v", membership.ID, err)
+		renderError(http.StatusInternalServerError, "Erro ao processar seus dados")
+		return
+	}
+
+	baseURL := "http://" + r.Host
+	checkoutURL, err := h.membershipService.Subscribe(membership, client,
+		baseURL+"/membership/success?session_id={CHECKOUT_SESSION_ID}",
+		baseURL+"/m/"+strconv.FormatUint(uint64(membership.ID), 10))
+	if err != nil {
+		log.Printf("Erro ao iniciar assinatura %d: %v", membership.ID, err)
+		renderError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	http.Redirect(w, r, checkoutURL, http.StatusSeeOther)
+}
+
+// SuccessView confirma o pagamento na volta do gateway e leva à área do assinante
+func (h *MembershipHandler) SuccessView(w http.ResponseWriter, r *http.Request) {
+	member, err := h.membershipService.ConfirmCheckout(r.URL.Query().Get("session_id"))
+	if errors.Is(err, service.ErrMemberNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "membership/subscribe", map[string]any{"Step": "invalid"}, "guest")
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao confirmar assinatura: %v", err)
+		http.Error(w, "Erro ao confirmar assinatura", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "membership/subscribe", map[string]any{
+		"Step":       "done",
+		"Membership": &member.Membership,
+		"Member":     member,
+	}, "guest")
+}
+
+// MemberView exibe ao assinante a situação da assinatura e os ebooks liberados
+func (h *MembershipHandler) MemberView(w http.ResponseWriter, r *http.Request) {
+	member, purchases := h.findMember(w, r)
+	if member == nil {
+		return
+	}
+
+	h.templateRenderer.View(w, r, "membership/member", map[string]any{
+		"Member":    member,
+		"Purchases": purchases,
+	}, "guest")
+}
+
+// CancelSubmit agenda o cancelamento da assinatura para o fim do período pago
+func (h *MembershipHandler) CancelSubmit(w http.ResponseWriter, r *http.Request) {
+	member, _ := h.findMember(w, r)
+	if member == nil {
+		return
+	}
+	memberURL := "/membership/" + member.Token
+
+	if err := h.membershipService.Cancel(member); err != nil {
+		log.Printf("Erro ao cancelar assinante %d: %v", member.ID, err)
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, memberURL, http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Cancelamento agendado. Você continua com acesso até o fim do período pago.")
+	http.Redirect(w, r, memberURL, http.StatusSeeOther)
+}
+
+func (h *MembershipHandler) findAvailable(w http.ResponseWriter, r *http.Request) *models.Membership {
+	var membership *models.Membership
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		err = models.ErrMembershipUnavailable
+	} else {
+		membership, err = h.membershipService.FindAvailable(uint(id))
+	}
+	if errors.Is(err, models.ErrMembershipUnavailable) {
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "membership/subscribe", map[string]any{"Step": "invalid"}, "guest")
+		return nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar assinatura: %v", err)
+		http.Error(w, "Erro ao buscar assinatura", http.StatusInternalServerError)
+		return nil
+	}
+	return membership
+}
+
+func (h *MembershipHandler) findMember(w http.ResponseWriter, r *http.Request) (*models.Member, []*models.Purchase) {
+	member, purchases, err := h.membershipService.FindMember(chi.URLParam(r, "token"))
+	if errors.Is(err, service.ErrMemberNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		h.templateRenderer.View(w, r, "membership/subscribe", map[string]any{"Step": "invalid"}, "guest")
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar assinante: %v", err)
+		http.Error(w, "Erro ao buscar assinatura", http.StatusInternalServerError)
+		return nil, nil
+	}
+	return member, purchases
+}
+
+func (h *MembershipHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+)
+
+// CustomDomainKey is the context key for the creator served by a custom domain
+type CustomDomainKey string
+
+const CustomDomainCreatorKey CustomDomainKey = "custom_domain_creator"
+
+// customDomainPaths são as rotas públicas que também respondem no domínio do criador
+var customDomainPaths = []string{
+	"/assets/",
+	"/c/",
+	"/sales/",
+	"/checkout/",
+	"/purchase/",
+	"/lead/",
+	"/gift/",
+	"/cart",
+	"/api/validate-customer",
+	"/api/create-ebook-checkout",
+	"/api/create-bundle-checkout",
+	"/api/create-cart-checkout",
+}
+
+// CustomDomain roteia pelo cabeçalho Host as requisições que chegam pelo
+// domínio próprio de um criador: a raiz abre a vitrine dele, as páginas
+// públicas seguem normalmente e o restante volta para o domínio da plataforma.
+func CustomDomain(domainService service.CustomDomainService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			creator, err := domainService.Resolve(r.Host)
+			if err != nil {
+				log.Printf("Erro ao resolver domínio %s: %v", r.Host, err)
+			}
+			if creator == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.URL.Path == "/" && creator.HasStorefront() {
+				r.URL.Path = creator.StorefrontURL()
+			} else if !isCustomDomainPath(r.URL.Path) {
+				target := config.AppConfig.Host + ":" + config.AppConfig.Port + r.URL.RequestURI()
+				http.Redirect(w, r, target, http.StatusFound)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), CustomDomainCreatorKey, creator)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// DomainCreator retorna o criador dono do domínio da requisição; nil no domínio da plataforma
+func DomainCreator(r *http.Request) *models.Creator {
+	creator, ok := r.Context().Value(CustomDomainCreatorKey).(*models.Creator)
+	if !ok {
+		return nil
+	}
+	return creator
+}
+
+func isCustomDomainPath(path string) bool {
+	for _, prefix := range customDomainPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
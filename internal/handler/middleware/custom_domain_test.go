@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// domainResolverStub atende só Resolve; o restante do serviço não é usado pelo middleware
+type domainResolverStub struct {
+	service.CustomDomainService
+	creators map[string]*models.Creator
+}
+
+func (s *domainResolverStub) Resolve(host string) (*models.Creator, error) {
+	return s.creators[host], nil
+}
+
+func customDomainHandler(seen *string, owner **models.Creator) http.Handler {
+	resolver := &domainResolverStub{creators: map[string]*models.Creator{
+		"loja.autor.com.br": {Name: "Ana", Slug: "ana"},
+	}}
+	return CustomDomain(resolver)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = r.URL.Path
+		*owner = DomainCreator(r)
+	}))
+}
+
+func TestCustomDomain_RootOpensStorefront(t *testing.T) {
+	var seen string
+	var owner *models.Creator
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "loja.autor.com.br"
+
+	customDomainHandler(&seen, &owner).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "/c/ana", seen)
+	assert.Equal(t, "ana", owner.Slug)
+}
+
+func TestCustomDomain_PublicPagesPassThrough(t *testing.T) {
+	var seen string
+	var owner *models.Creator
+	req := httptest.NewRequest(http.MethodGet, "/sales/meu-ebook", nil)
+	req.Host = "loja.autor.com.br"
+
+	customDomainHandler(&seen, &owner).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "/sales/meu-ebook", seen)
+	assert.NotNil(t, owner)
+}
+
+func TestCustomDomain_RedirectsPrivatePages(t *testing.T) {
+	host, port := config.AppConfig.Host, config.AppConfig.Port
+	config.AppConfig.Host, config.AppConfig.Port = "http://localhost", "8080"
+	defer func() { config.AppConfig.Host, config.AppConfig.Port = host, port }()
+
+	var seen string
+	var owner *models.Creator
+	req := httptest.NewRequest(http.MethodGet, "/dashboard?x=1", nil)
+	req.Host = "loja.autor.com.br"
+	rr := httptest.NewRecorder()
+
+	customDomainHandler(&seen, &owner).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "http://localhost:8080/dashboard?x=1", rr.Header().Get("Location"))
+	assert.Empty(t, seen)
+}
+
+func TestCustomDomain_PlatformHostUntouched(t *testing.T) {
+	var seen string
+	var owner *models.Creator
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Host = "localhost:8080"
+
+	customDomainHandler(&seen, &owner).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "/dashboard", seen)
+	assert.Nil(t, owner)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+)
+
+// PlanFeature libera as rotas apenas para usuários cujo plano inclui o recurso.
+// Deve vir depois do AuthMiddleware, que coloca o usuário no contexto.
+func PlanFeature(planService service.PlanService, feature models.PlanFeature) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(User).(*models.User)
+			if !ok || user == nil || user.ID == 0 {
+				writeJSONError(w, http.StatusUnauthorized, "Não autorizado")
+				return
+			}
+
+			plan, err := planService.PlanForUser(user.ID)
+			if err != nil {
+				log.Printf("Erro ao buscar plano do usuário %d: %v", user.ID, err)
+				writeJSONError(w, http.StatusInternalServerError, "Erro ao verificar plano")
+				return
+			}
+			if plan == nil || !plan.HasFeature(feature) {
+				writeJSONError(w, http.StatusForbidden, "Recurso não disponível no seu plano. Faça upgrade para continuar.")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// planForUserStub atende só PlanForUser; o restante do serviço não é usado pelo middleware
+type planForUserStub struct {
+	service.PlanService
+	plans map[uint]*models.Plan
+}
+
+func (s *planForUserStub) PlanForUser(userID uint) (*models.Plan, error) {
+	return s.plans[userID], nil
+}
+
+func TestPlanFeature_GatesAPIByPlan(t *testing.T) {
+	plans := &planForUserStub{plans: map[uint]*models.Plan{
+		1: {Slug: models.DefaultPlanSlug},
+		2: {Slug: "business", APIAccess: true},
+	}}
+	reached := false
+	gated := PlanFeature(plans, models.FeatureAPIAccess)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	request := func(user *models.User) *httptest.ResponseRecorder {
+		reached = false
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/watermark", nil)
+		if user != nil {
+			req = req.WithContext(context.WithValue(req.Context(), User, user))
+		}
+		rec := httptest.NewRecorder()
+		gated.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request(nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.False(t, reached)
+
+	user := &models.User{}
+	user.ID = 1
+	rec = request(user)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.False(t, reached)
+
+	user.ID = 2
+	rec = request(user)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, reached)
+}
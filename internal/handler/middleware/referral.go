@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	referralCookieName = "ref"
+	referralCookieDays = 30
+)
+
+var referralCodePattern = regexp.MustCompile(`^[A-Za-z0-9]{4,32}$`)
+
+// ReferralCookie guarda o código de afiliado recebido em ?ref= para atribuir a
+// venda quando o comprador finalizar a compra. A última indicação prevalece.
+func ReferralCookie(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := strings.TrimSpace(r.URL.Query().Get("ref"))
+		if referralCodePattern.MatchString(code) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     referralCookieName,
+				Value:    strings.ToUpper(code),
+				Path:     "/",
+				MaxAge:   referralCookieDays * 24 * 60 * 60,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReferralCode retorna o código de afiliado guardado no navegador do comprador
+func ReferralCode(r *http.Request) string {
+	cookie, err := r.Cookie(referralCookieName)
+	if err != nil || !referralCodePattern.MatchString(cookie.Value) {
+		return ""
+	}
+	return cookie.Value
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferralCookie_StoresCode(t *testing.T) {
+	handler := ReferralCookie(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/sales/meu-ebook?ref=ab12cd34", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	cookies := rr.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "AB12CD34", cookies[0].Value)
+
+	next := httptest.NewRequest(http.MethodPost, "/api/create-ebook-checkout", nil)
+	next.AddCookie(cookies[0])
+	assert.Equal(t, "AB12CD34", ReferralCode(next))
+}
+
+func TestReferralCookie_IgnoresInvalidCode(t *testing.T) {
+	handler := ReferralCookie(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/checkout/1?ref=<script>", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Empty(t, rr.Result().Cookies())
+	assert.Equal(t, "", ReferralCode(req))
+}
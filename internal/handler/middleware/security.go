@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
 )
 
 // SecurityHeaders middleware adds security headers to all responses
@@ -59,7 +63,7 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 func (rl *RateLimiter) RateLimitMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get client IP
-		clientIP := getClientIP(r)
+		clientIP := ClientIP(r)
 
 		// Check rate limit
 		if !rl.isAllowed(clientIP) {
@@ -121,26 +125,72 @@ func (rl *RateLimiter) isAllowed(clientIP string) bool {
 	return true
 }
 
-// getClientIP extracts the real client IP from the request
-func getClientIP(r *http.Request) string {
-	// Check for forwarded headers
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if commaIndex := strings.Index(ip, ","); commaIndex != -1 {
-			ip = strings.TrimSpace(ip[:commaIndex])
+// ClientIP extracts the real client IP from the request. Forwarded headers are
+// client-controlled, so they are only honored when the connection comes from
+// one of the proxies listed in TRUSTED_PROXIES.
+func ClientIP(r *http.Request) string {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+
+	trusted := trustedProxies()
+	if !isTrustedProxy(remoteIP, trusted) {
+		return remoteIP
+	}
+
+	// Walk X-Forwarded-For from the closest hop; the first address that is not
+	// one of our proxies is the client
+	if header := r.Header.Get("X-Forwarded-For"); header != "" {
+		hops := strings.Split(header, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(hops[i])
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			if !isTrustedProxy(ip, trusted) || i == 0 {
+				return ip
+			}
 		}
-		return ip
 	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
 		return ip
 	}
 
-	// Fallback to remote address
-	// Remove port if present
-	if colonIndex := strings.LastIndex(r.RemoteAddr, ":"); colonIndex != -1 {
-		return r.RemoteAddr[:colonIndex]
+	return remoteIP
+}
+
+// trustedProxies parses TRUSTED_PROXIES, accepting single IPs and CIDR ranges
+func trustedProxies() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range config.AppConfig.TrustedProxies {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil {
+				bits := 8 * len(ip.To4())
+				if bits == 0 {
+					bits = 8 * net.IPv6len
+				}
+				entry = fmt.Sprintf("%s/%d", entry, bits)
+			}
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func isTrustedProxy(ip string, trusted []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(parsed) {
+			return true
+		}
 	}
-	return r.RemoteAddr
+	return false
 }
 
 // CleanupRateLimiter periodically cleans up old rate limiting data
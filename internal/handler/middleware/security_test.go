@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
 )
 
 func TestSecurityHeaders(t *testing.T) {
@@ -117,28 +119,49 @@ func TestRateLimiterCleanup(t *testing.T) {
 	}
 }
 
-func TestGetClientIP(t *testing.T) {
+func TestClientIP(t *testing.T) {
 	tests := []struct {
-		name       string
-		headers    map[string]string
-		remoteAddr string
-		expected   string
+		name           string
+		headers        map[string]string
+		remoteAddr     string
+		trustedProxies []string
+		expected       string
 	}{
 		{
-			name: "X-Forwarded-For header",
+			name: "X-Forwarded-For header from trusted proxy",
 			headers: map[string]string{
 				"X-Forwarded-For": "192.168.1.1",
 			},
-			remoteAddr: "127.0.0.1:12345",
-			expected:   "192.168.1.1",
+			remoteAddr:     "127.0.0.1:12345",
+			trustedProxies: []string{"127.0.0.1"},
+			expected:       "192.168.1.1",
 		},
 		{
-			name: "X-Real-IP header",
+			name: "X-Forwarded-For skips trusted hops",
+			headers: map[string]string{
+				"X-Forwarded-For": "6.6.6.6, 192.168.1.1, 10.0.0.2",
+			},
+			remoteAddr:     "10.0.0.1:12345",
+			trustedProxies: []string{"10.0.0.0/8"},
+			expected:       "192.168.1.1",
+		},
+		{
+			name: "X-Real-IP header from trusted proxy",
 			headers: map[string]string{
 				"X-Real-IP": "10.0.0.1",
 			},
-			remoteAddr: "127.0.0.1:12345",
-			expected:   "10.0.0.1",
+			remoteAddr:     "127.0.0.1:12345",
+			trustedProxies: []string{"127.0.0.1"},
+			expected:       "10.0.0.1",
+		},
+		{
+			name: "Spoofed headers from untrusted client are ignored",
+			headers: map[string]string{
+				"X-Forwarded-For": "192.168.1.1",
+				"X-Real-IP":       "10.0.0.1",
+			},
+			remoteAddr: "203.0.113.7:12345",
+			expected:   "203.0.113.7",
 		},
 		{
 			name:       "No headers, use remote addr (port removed)",
@@ -148,8 +171,12 @@ func TestGetClientIP(t *testing.T) {
 		},
 	}
 
+	originalProxies := config.AppConfig.TrustedProxies
+	defer func() { config.AppConfig.TrustedProxies = originalProxies }()
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig.TrustedProxies = tt.trustedProxies
 			req := httptest.NewRequest("GET", "/", nil)
 			req.RemoteAddr = tt.remoteAddr
 
@@ -157,7 +184,7 @@ func TestGetClientIP(t *testing.T) {
 				req.Header.Set(key, value)
 			}
 
-			result := getClientIP(req)
+			result := ClientIP(req)
 			if result != tt.expected {
 				t.Errorf("Expected %s, got %s", tt.expected, result)
 			}
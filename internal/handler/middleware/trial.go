@@ -2,6 +2,9 @@ package middleware
 
 import (
 	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
 )
 
 func TrialMiddleware(next http.Handler) http.Handler {
@@ -14,8 +17,13 @@ func TrialMiddleware(next http.Handler) http.Handler {
 
 		// Skip trial check for these paths
 		excludedPaths := map[string]bool{
-			"/settings": true,
-			"/logout":   true,
+			"/settings":                             true,
+			"/settings/plan":                        true,
+			"/settings/subscription":                true,
+			"/settings/subscription/cancel":         true,
+			"/settings/subscription/resume":         true,
+			"/settings/subscription/payment-method": true,
+			"/logout":                               true,
 		}
 
 		if excludedPaths[r.URL.Path] {
@@ -23,11 +31,21 @@ func TrialMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if !user.IsInTrialPeriod() && !user.IsSubscribed() {
+		switch user.SubscriptionAccess() {
+		case models.AccessFull:
+			next.ServeHTTP(w, r)
+		case models.AccessReadOnly:
+			// Após o período de tolerância de um pagamento pendente, o criador ainda
+			// consulta seus dados, mas não pode alterá-los. Páginas de vendas e downloads
+			// dos compradores são rotas públicas e não passam por este middleware.
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cookies.NotifyError(w, "Sua conta está em modo somente leitura. Regularize o pagamento da assinatura para voltar a fazer alterações.")
+			http.Redirect(w, r, "/settings/subscription", http.StatusSeeOther)
+		default:
 			http.Redirect(w, r, "/settings", http.StatusSeeOther)
-			return
 		}
-
-		next.ServeHTTP(w, r)
 	})
 }
@@ -199,10 +199,64 @@ func (suite *TrialMiddlewareIntegrationTestSuite) TestUserRepository_LoadsSubscr
 	assert.Equal(suite.T(), user.ID, foundUser.Subscription.UserID)
 	assert.Equal(suite.T(), "test_plan", foundUser.Subscription.PlanID)
 	assert.True(suite.T(), foundUser.Subscription.IsTrialActive)
-	assert.Equal(suite.T(), "inactive", foundUser.Subscription.SubscriptionStatus)
+	assert.Equal(suite.T(), models.SubscriptionTrialing, foundUser.Subscription.SubscriptionStatus)
 
 	// Verificar que os métodos do trial funcionam
 	assert.True(suite.T(), foundUser.IsInTrialPeriod())
 	assert.False(suite.T(), foundUser.IsSubscribed())
 	assert.Greater(suite.T(), foundUser.DaysLeftInTrial(), 0)
 }
+
+func (suite *TrialMiddlewareIntegrationTestSuite) createPastDueUser(gracePeriod time.Duration) *models.User {
+	email := fmt.Sprintf("test-%d@example.com", time.Now().UnixNano())
+	user := models.NewUser("testuser", "password123", email)
+	suite.Require().NoError(suite.userRepository.Create(user))
+
+	subscription := models.NewSubscription(user.ID, "test_plan")
+	subscription.IsTrialActive = false
+	subscription.TrialEndDate = time.Now().AddDate(0, 0, -30)
+	subscription.Activate("cus_test123", "sub_test123")
+	subscription.MarkPaymentFailed(gracePeriod)
+	suite.Require().NoError(database.DB.Create(subscription).Error)
+
+	return user
+}
+
+func (suite *TrialMiddlewareIntegrationTestSuite) serveAs(user *models.User, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	ctx := context.WithValue(req.Context(), middleware.UserEmailKey, user.Email)
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	middleware.TrialMiddleware(nextHandler).ServeHTTP(w, req)
+
+	return w
+}
+
+func (suite *TrialMiddlewareIntegrationTestSuite) TestTrialMiddleware_GracePeriodKeepsFullAccess() {
+	user := suite.createPastDueUser(3 * 24 * time.Hour)
+
+	w := suite.serveAs(user, "POST", "/ebook/create")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TrialMiddlewareIntegrationTestSuite) TestTrialMiddleware_ReadOnlyAllowsReads() {
+	user := suite.createPastDueUser(-time.Hour)
+
+	w := suite.serveAs(user, "GET", "/ebook")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TrialMiddlewareIntegrationTestSuite) TestTrialMiddleware_ReadOnlyBlocksWrites() {
+	user := suite.createPastDueUser(-time.Hour)
+
+	w := suite.serveAs(user, "POST", "/ebook/create")
+
+	assert.Equal(suite.T(), http.StatusSeeOther, w.Code)
+	assert.Equal(suite.T(), "/settings/subscription", w.Header().Get("Location"))
+}
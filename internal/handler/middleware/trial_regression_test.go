@@ -65,7 +65,7 @@ func (suite *TrialRegressionTestSuite) TestTrialMiddleware_Regression_UserWithAc
 	suite.Require().NoError(err)
 
 	assert.True(suite.T(), dbSubscription.IsTrialActive)
-	assert.Equal(suite.T(), "inactive", dbSubscription.SubscriptionStatus)
+	assert.Equal(suite.T(), models.SubscriptionState("inactive"), dbSubscription.SubscriptionStatus)
 	assert.True(suite.T(), time.Now().Before(dbSubscription.TrialEndDate))
 
 	// 4. Buscar usuário via repository (simulando o que o middleware faz)
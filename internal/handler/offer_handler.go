@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// OfferHandler gerencia os order bumps e upsells que o criador configura em cada ebook
+type OfferHandler struct {
+	offerService     service.OfferService
+	ebookService     service.EbookService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewOfferHandler(
+	offerService service.OfferService,
+	ebookService service.EbookService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *OfferHandler {
+	return &OfferHandler{
+		offerService:     offerService,
+		ebookService:     ebookService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// IndexView lista as ofertas do ebook com a conversão de cada uma
+func (h *OfferHandler) IndexView(w http.ResponseWriter, r *http.Request) {
+	creator, ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	offers, err := h.offerService.ListForEbook(ebook.ID)
+	if err != nil {
+		log.Printf("Erro ao listar ofertas do ebook %d: %v", ebook.ID, err)
+		http.Error(w, "Erro ao listar ofertas", http.StatusInternalServerError)
+		return
+	}
+
+	// Apenas os outros ebooks do criador, na mesma moeda, podem ser oferecidos
+	var candidates []models.Ebook
+	ebooks, err := h.ebookService.ListEbooksForUser(creator.UserID, repository.EbookQuery{})
+	if err != nil {
+		log.Printf("Erro ao listar ebooks do criador %d: %v", creator.ID, err)
+	}
+	if ebooks != nil {
+		for _, candidate := range *ebooks {
+			if candidate.ID != ebook.ID && candidate.Price.Currency == ebook.Price.Currency {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	h.templateRenderer.View(w, r, "ebook/offers", map[string]any{
+		"Ebook":  ebook,
+		"Offers": offers,
+		"Ebooks": candidates,
+	}, "admin")
+}
+
+// CreateSubmit cadastra um order bump ou upsell para o ebook
+func (h *OfferHandler) CreateSubmit(w http.ResponseWriter, r *http.Request) {
+	_, ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	form := map[string]string{
+		"type":           r.FormValue("type"),
+		"offer_ebook_id": r.FormValue("offer_ebook_id"),
+		"value":          r.FormValue("value"),
+		"headline":       strings.TrimSpace(r.FormValue("headline")),
+	}
+	errs := make(map[string]string)
+
+	offerEbookID, err := strconv.ParseUint(form["offer_ebook_id"], 10, 32)
+	if err != nil {
+		errs["offer_ebook_id"] = "Selecione o ebook oferecido"
+	}
+
+	price, err := money.Parse(form["value"], ebook.Price.Currency)
+	if err != nil || price.Amount <= 0 {
+		errs["value"] = "Valor inválido. Use apenas números e vírgula (ex: 29,90)"
+	}
+
+	if len(errs) > 0 {
+		redirectWithFormErrors(w, r, form, errs)
+		return
+	}
+
+	if _, err := h.offerService.Create(form["type"], ebook, uint(offerEbookID), price, form["headline"]); err != nil {
+		log.Printf("Falha ao cadastrar oferta do ebook %d: %v", ebook.ID, err)
+		redirectWithFormErrors(w, r, form, map[string]string{"offer": err.Error()})
+		return
+	}
+
+	cookies.NotifySuccess(w, "Oferta criada com sucesso!")
+	http.Redirect(w, r, offersURL(ebook), http.StatusSeeOther)
+}
+
+// ToggleSubmit ativa ou pausa a oferta
+func (h *OfferHandler) ToggleSubmit(w http.ResponseWriter, r *http.Request) {
+	_, ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	offer := h.ebookOffer(w, r, ebook)
+	if offer == nil {
+		return
+	}
+
+	if err := h.offerService.Toggle(offer); err != nil {
+		log.Printf("Falha ao alterar oferta %d: %v", offer.ID, err)
+		cookies.NotifyError(w, "Erro ao alterar oferta")
+	} else if offer.Active {
+		cookies.NotifySuccess(w, "Oferta ativada!")
+	} else {
+		cookies.NotifySuccess(w, "Oferta pausada!")
+	}
+	http.Redirect(w, r, offersURL(ebook), http.StatusSeeOther)
+}
+
+// DeleteSubmit remove a oferta; os pedidos que a incluíram continuam válidos
+func (h *OfferHandler) DeleteSubmit(w http.ResponseWriter, r *http.Request) {
+	_, ebook := h.creatorEbook(w, r)
+	if ebook == nil {
+		return
+	}
+
+	offer := h.ebookOffer(w, r, ebook)
+	if offer == nil {
+		return
+	}
+
+	if err := h.offerService.Delete(offer); err != nil {
+		log.Printf("Falha ao excluir oferta %d: %v", offer.ID, err)
+		cookies.NotifyError(w, "Erro ao excluir oferta")
+	} else {
+		cookies.NotifySuccess(w, "Oferta excluída com sucesso!")
+	}
+	http.Redirect(w, r, offersURL(ebook), http.StatusSeeOther)
+}
+
+// creatorEbook busca o ebook da URL garantindo que pertence ao criador logado
+func (h *OfferHandler) creatorEbook(w http.ResponseWriter, r *http.Request) (*models.Creator, *models.Ebook) {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID do ebook inválido", http.StatusBadRequest)
+		return nil, nil
+	}
+
+	ebook, err := h.ebookService.FindByID(uint(id))
+	if err != nil || ebook == nil || ebook.CreatorID != creator.ID {
+		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
+		return nil, nil
+	}
+
+	return creator, ebook
+}
+
+func (h *OfferHandler) ebookOffer(w http.ResponseWriter, r *http.Request, ebook *models.Ebook) *models.Offer {
+	id, err := strconv.ParseUint(chi.URLParam(r, "offerID"), 10, 32)
+	if err != nil {
+		http.Error(w, "ID da oferta inválido", http.StatusBadRequest)
+		return nil
+	}
+
+	offer, err := h.offerService.FindByID(uint(id))
+	if errors.Is(err, models.ErrOfferNotAvailable) || (err == nil && offer.EbookID != ebook.ID) {
+		http.Error(w, "Oferta não encontrada", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar oferta", http.StatusInternalServerError)
+		return nil
+	}
+
+	return offer
+}
+
+func offersURL(ebook *models.Ebook) string {
+	return "/ebook/" + strconv.FormatUint(uint64(ebook.ID), 10) + "/offers"
+}
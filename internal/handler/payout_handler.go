@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+// PayoutHandler exibe o extrato do saldo do criador e gerencia a conta recebedora e os repasses
+type PayoutHandler struct {
+	payoutService    service.PayoutService
+	creatorService   service.CreatorService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewPayoutHandler(
+	payoutService service.PayoutService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *PayoutHandler {
+	return &PayoutHandler{
+		payoutService:    payoutService,
+		creatorService:   creatorService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// StatementView exibe o saldo por moeda, os repasses e os lançamentos do criador
+func (h *PayoutHandler) StatementView(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	statement, err := h.payoutService.Statement(creator.ID)
+	if err != nil {
+		log.Printf("Erro ao montar extrato do criador %d: %v", creator.ID, err)
+		http.Error(w, "Erro ao carregar extrato", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "payout/statement", map[string]any{
+		"Statement": statement,
+	}, "admin")
+}
+
+// OnboardingSubmit leva o criador ao cadastro da conta recebedora no gateway
+func (h *PayoutHandler) OnboardingSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	baseURL := "http://" + r.Host
+	url, err := h.payoutService.StartOnboarding(creator, baseURL+"/payouts/account/refresh", baseURL+"/payouts/account/return")
+	if err != nil {
+		log.Printf("Falha ao abrir cadastro da conta recebedora do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// OnboardingRefresh atende o link de renovação, usado pelo gateway quando o cadastro
+// anterior expira. Como é um GET, não abre um novo cadastro: volta ao extrato, de
+// onde o criador reenvia o formulário.
+func (h *PayoutHandler) OnboardingRefresh(w http.ResponseWriter, r *http.Request) {
+	cookies.NotifyError(w, "O link de cadastro expirou. Clique novamente para continuar o cadastro da conta recebedora.")
+	http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+}
+
+// OnboardingReturn confere no gateway se o cadastro foi concluído
+func (h *PayoutHandler) OnboardingReturn(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	account, err := h.payoutService.RefreshAccount(creator.ID)
+	switch {
+	case err != nil:
+		log.Printf("Falha ao consultar conta recebedora do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, err.Error())
+	case account.PayoutsEnabled:
+		cookies.NotifySuccess(w, "Conta recebedora ativa! Seu saldo será repassado automaticamente.")
+	default:
+		cookies.NotifyError(w, "Cadastro pendente. Conclua o envio dos dados para receber repasses.")
+	}
+	http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+}
+
+// ScheduleSubmit altera a frequência dos repasses automáticos
+func (h *PayoutHandler) ScheduleSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	if err := h.payoutService.SetSchedule(creator.ID, r.FormValue("schedule")); err != nil {
+		log.Printf("Falha ao alterar frequência de repasse do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, err.Error())
+	} else {
+		cookies.NotifySuccess(w, "Frequência de repasse atualizada!")
+	}
+	http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+}
+
+// WithdrawSubmit repassa imediatamente todo o saldo disponível
+func (h *PayoutHandler) WithdrawSubmit(w http.ResponseWriter, r *http.Request) {
+	creator := h.currentCreator(w, r)
+	if creator == nil {
+		return
+	}
+
+	payouts, err := h.payoutService.PayoutCreator(creator.ID)
+	if err != nil {
+		if !errors.Is(err, models.ErrNothingToPayout) && !errors.Is(err, models.ErrPayoutAccountNotReady) {
+			log.Printf("Falha no repasse do criador %d: %v", creator.ID, err)
+		}
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+		return
+	}
+
+	for _, payout := range payouts {
+		if payout.Status == models.PayoutStatusFailed {
+			cookies.NotifyError(w, "O gateway recusou o repasse. O saldo continua disponível.")
+			http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+			return
+		}
+	}
+
+	cookies.NotifySuccess(w, "Repasse enviado para sua conta!")
+	http.Redirect(w, r, "/payouts", http.StatusSeeOther)
+}
+
+func (h *PayoutHandler) currentCreator(w http.ResponseWriter, r *http.Request) *models.Creator {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return nil
+	}
+
+	return creator
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+type PlanHandler struct {
+	planService      service.PlanService
+	templateRenderer template.TemplateRenderer
+}
+
+func NewPlanHandler(planService service.PlanService, templateRenderer template.TemplateRenderer) *PlanHandler {
+	return &PlanHandler{
+		planService:      planService,
+		templateRenderer: templateRenderer,
+	}
+}
+
+// PricingView exibe a página pública de preços a partir do catálogo de planos
+func (h *PlanHandler) PricingView(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.planService.ListPlans()
+	if err != nil {
+		log.Printf("Erro ao listar planos: %v", err)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval != models.BillingYearly {
+		interval = models.BillingMonthly
+	}
+
+	h.templateRenderer.View(w, r, "plan/pricing", map[string]any{
+		"Plans":    plans,
+		"Interval": interval,
+	}, "guest")
+}
+
+// ChangePlanSubmit troca o plano do criador logado
+func (h *PlanHandler) ChangePlanSubmit(w http.ResponseWriter, r *http.Request) {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		cookies.NotifyError(w, "Erro ao processar formulário")
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return
+	}
+
+	interval := r.FormValue("interval")
+	if interval == "" {
+		interval = models.BillingMonthly
+	}
+
+	err := h.planService.ChangePlan(user.ID, r.FormValue("plan"), interval)
+	if err != nil {
+		log.Printf("Erro ao trocar plano do usuário %d: %v", user.ID, err)
+		cookies.NotifyError(w, err.Error())
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return
+	}
+
+	cookies.NotifySuccess(w, "Plano atualizado com sucesso!")
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
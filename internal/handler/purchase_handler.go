@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
 	"github.com/anglesson/simple-web-server/internal/handler/web"
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
@@ -21,15 +23,19 @@ import (
 
 type PurchaseHandler struct {
 	templateRenderer template.TemplateRenderer
+	creatorService   service.CreatorService
+	planLimiter      service.PlanLimiter
 }
 
-func NewPurchaseHandler(templateRenderer template.TemplateRenderer) *PurchaseHandler {
+func NewPurchaseHandler(templateRenderer template.TemplateRenderer, creatorService service.CreatorService, planLimiter service.PlanLimiter) *PurchaseHandler {
 	return &PurchaseHandler{
 		templateRenderer: templateRenderer,
+		creatorService:   creatorService,
+		planLimiter:      planLimiter,
 	}
 }
 
-func purchaseServiceFactory() *service.PurchaseService {
+func purchaseServiceFactory(planLimiter service.PlanLimiter) *service.PurchaseService {
 	mailPort, _ := strconv.Atoi(config.AppConfig.MailPort)
 	ms := mail.NewEmailService(mail.NewGoMailer(
 		config.AppConfig.MailHost,
@@ -37,7 +43,7 @@ func purchaseServiceFactory() *service.PurchaseService {
 		config.AppConfig.MailUsername,
 		config.AppConfig.MailPassword))
 	pr := repository.NewPurchaseRepository()
-	return service.NewPurchaseService(pr, ms)
+	return service.NewPurchaseService(pr, ms, planLimiter)
 }
 
 func (h *PurchaseHandler) PurchaseCreateHandler(w http.ResponseWriter, r *http.Request) {
@@ -70,7 +76,17 @@ func (h *PurchaseHandler) PurchaseCreateHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = purchaseServiceFactory().CreatePurchase(uint(ebookId), clients)
+	creator, err := h.creatorService.FindCreatorByUserID(middleware.Auth(r).ID)
+	if err != nil {
+		web.RedirectBackWithErrors(w, r, "Criador não encontrado")
+		return
+	}
+
+	err = purchaseServiceFactory(h.planLimiter).CreatePurchase(creator.ID, uint(ebookId), clients)
+	if errors.Is(err, service.ErrPlanLimitReached) {
+		web.RedirectBackWithErrors(w, r, err.Error())
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -108,7 +124,14 @@ func (h *PurchaseHandler) PurchaseDownloadHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	outputPath, err := purchaseServiceFactory().GetEbookFile(purchaseID, uint(fileID))
+	outputPath, err := purchaseServiceFactory(h.planLimiter).GetEbookFile(purchaseID, uint(fileID), models.DownloadRequest{
+		IP:        middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if errors.Is(err, models.ErrFileLocked) || errors.Is(err, models.ErrDeviceLimitReached) || errors.Is(err, models.ErrPurchaseSuspended) || errors.Is(err, models.ErrAwaitingRelease) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -140,6 +163,19 @@ func (h *PurchaseHandler) showEbookFiles(w http.ResponseWriter, r *http.Request,
 
 	log.Printf("✅ Purchase carregada: %s", purchase.Ebook.Title)
 
+	// Compras suspensas por compartilhamento do link não baixam
+	if purchase.IsSuspended() {
+		log.Printf("❌ Download suspenso para purchase ID: %d", purchaseID)
+		http.Error(w, models.ErrPurchaseSuspended.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Compras da pré-venda recebem o link no lançamento
+	if purchase.AwaitingRelease {
+		http.Error(w, models.ErrAwaitingRelease.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Verificar se o download está expirado
 	if purchase.IsExpired() {
 		log.Printf("❌ Download expirado para purchase ID: %d", purchaseID)
@@ -147,14 +183,14 @@ func (h *PurchaseHandler) showEbookFiles(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	// Verificar se o limite de downloads foi atingido
-	if !purchase.AvailableDownloads() {
+	// Verificar se o limite de downloads foi atingido; versões novas seguem liberadas
+	if !purchase.AvailableDownloads() && !purchase.HasPendingUpdates() {
 		log.Printf("❌ Limite de downloads atingido para purchase ID: %d", purchaseID)
 		h.showLimitExceededPage(w, r, purchase)
 		return
 	}
 
-	files, err := purchaseServiceFactory().GetEbookFiles(purchaseID)
+	files, err := purchaseServiceFactory(h.planLimiter).GetEbookFiles(purchaseID)
 	if err != nil {
 		log.Printf("❌ Erro ao buscar arquivos: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -166,6 +202,7 @@ func (h *PurchaseHandler) showEbookFiles(w http.ResponseWriter, r *http.Request,
 	data := map[string]interface{}{
 		"Purchase": purchase,
 		"Files":    files,
+		"Now":      time.Now(),
 		"Title":    "Download do Ebook",
 	}
 
@@ -65,7 +65,7 @@ func TestShowLimitExceededPage(t *testing.T) {
 	mockTemplateRenderer.On("ViewWithoutLayout", w, req, "ebook/download-limit-exceeded", mock.AnythingOfType("map[string]interface {}")).Return()
 
 	// Criar handler
-	handler := NewPurchaseHandler(mockTemplateRenderer)
+	handler := NewPurchaseHandler(mockTemplateRenderer, nil, nil)
 
 	// Chamar a função
 	handler.showLimitExceededPage(w, req, purchase)
@@ -107,7 +107,7 @@ func TestShowExpiredDownloadPage(t *testing.T) {
 	mockTemplateRenderer.On("ViewWithoutLayout", w, req, "ebook/download-expired", mock.AnythingOfType("map[string]interface {}")).Return()
 
 	// Criar handler
-	handler := NewPurchaseHandler(mockTemplateRenderer)
+	handler := NewPurchaseHandler(mockTemplateRenderer, nil, nil)
 
 	// Chamar a função
 	handler.showExpiredDownloadPage(w, req, purchase)
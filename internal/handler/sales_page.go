@@ -4,8 +4,10 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/service"
 	"github.com/anglesson/simple-web-server/pkg/template"
 	"github.com/go-chi/chi/v5"
@@ -43,7 +45,8 @@ func (h *SalesPageHandler) SalesPageView(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if ebook == nil {
+	// No domínio próprio de um criador, só os ebooks dele respondem
+	if owner := middleware.DomainCreator(r); ebook == nil || (owner != nil && owner.ID != ebook.CreatorID) {
 		http.Error(w, "Ebook não encontrado", http.StatusNotFound)
 		return
 	}
@@ -71,17 +74,7 @@ func (h *SalesPageHandler) SalesPageView(w http.ResponseWriter, r *http.Request)
 		log.Printf("Erro ao incrementar visualizações: %v", err)
 	}
 
-	// Calcular economia (preço original vs preço atual)
-	originalPrice := ebook.Value * 1.5 // Simular preço original 50% maior
-	savings := originalPrice - ebook.Value
-
-	// Preparar dados para o template
-	data := map[string]any{
-		"Ebook":         ebook,
-		"OriginalPrice": originalPrice,
-		"Savings":       savings,
-		"Creator":       creator,
-	}
+	data := salesPageData(ebook, creator, time.Now())
 
 	h.templateRenderer.View(w, r, "sales_page", data, "guest")
 }
@@ -125,18 +118,25 @@ func (h *SalesPageHandler) SalesPagePreviewView(w http.ResponseWriter, r *http.R
 	// Atualizar o ebook com os dados do criador
 	ebook.Creator = *creator
 
-	// Calcular economia
-	originalPrice := ebook.Value * 1.5
-	savings := originalPrice - ebook.Value
+	data := salesPageData(ebook, creator, time.Now())
+	data["IsPreview"] = true
 
-	// Preparar dados para o template
+	h.templateRenderer.View(w, r, "sales_page", data, "guest")
+}
+
+// salesPageData prepara os dados da página de vendas. Durante uma promoção, o preço
+// do ebook aparece riscado ao lado do preço promocional, com a contagem regressiva.
+func salesPageData(ebook *models.Ebook, creator *models.Creator, now time.Time) map[string]any {
 	data := map[string]any{
-		"Ebook":         ebook,
-		"OriginalPrice": originalPrice,
-		"Savings":       savings,
-		"Creator":       creator,
-		"IsPreview":     true,
+		"Ebook":   ebook,
+		"Creator": creator,
 	}
 
-	h.templateRenderer.View(w, r, "sales_page", data, "guest")
+	if promotion := ebook.ActivePricePeriod(now); promotion != nil {
+		data["Promotion"] = promotion
+		data["OriginalPrice"] = ebook.Price
+		data["Savings"] = ebook.Price.Sub(promotion.Price)
+	}
+
+	return data
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
 	"github.com/anglesson/simple-web-server/internal/service"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/anglesson/simple-web-server/pkg/template/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -200,7 +201,7 @@ func TestSalesPageView_UniqueToCreator(t *testing.T) {
 		Model:       gorm.Model{ID: 1},
 		Title:       "Ebook do Criador 1",
 		Description: "Descrição do ebook 1",
-		Value:       97.00,
+		Price:       money.New(9700, money.BRL),
 		Status:      true,
 		Slug:        "ebook-criador-1",
 		CreatorID:   creator1.ID,
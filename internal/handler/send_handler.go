@@ -57,7 +57,7 @@ func (h *SendHandler) SendViewHandler(w http.ResponseWriter, r *http.Request) {
 
 	// TODO: This should be injected as dependency
 	s3Storage := storage.NewS3Storage()
-	ebookService := service.NewEbookService(s3Storage)
+	ebookService := service.NewEbookService(s3Storage, nil)
 	ebooks, err := ebookService.ListEbooksForUser(loggedUser.ID, repository.EbookQuery{
 		Pagination: pagination,
 	})
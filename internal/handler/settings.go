@@ -12,12 +12,21 @@ import (
 type SettingsHandler struct {
 	sessionService   service.SessionService
 	templateRenderer template.TemplateRenderer
+	planService      service.PlanService
+	creatorService   service.CreatorService
 }
 
-func NewSettingsHandler(sessionService service.SessionService, templateRenderer template.TemplateRenderer) *SettingsHandler {
+func NewSettingsHandler(
+	sessionService service.SessionService,
+	templateRenderer template.TemplateRenderer,
+	planService service.PlanService,
+	creatorService service.CreatorService,
+) *SettingsHandler {
 	return &SettingsHandler{
 		sessionService:   sessionService,
 		templateRenderer: templateRenderer,
+		planService:      planService,
+		creatorService:   creatorService,
 	}
 }
 
@@ -38,8 +47,31 @@ func (h *SettingsHandler) SettingsView(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Renderizando página de configurações para o usuário: %s", user.Email)
 	log.Printf("Token CSRF: %s", user.CSRFToken)
 
-	// Passar apenas o objeto user para o template
-	h.templateRenderer.View(w, r, "settings", map[string]interface{}{
+	data := map[string]interface{}{
 		"user": user,
-	}, "admin")
+	}
+
+	// Plano atual, consumo e catálogo para troca de plano
+	plan, err := h.planService.PlanForUser(user.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar plano do usuário: %v", err)
+	}
+	data["Plan"] = plan
+
+	plans, err := h.planService.ListPlans()
+	if err != nil {
+		log.Printf("Erro ao listar planos: %v", err)
+	}
+	data["Plans"] = plans
+
+	if creator, err := h.creatorService.FindCreatorByUserID(user.ID); err == nil {
+		data["Creator"] = creator
+		usage, err := h.planService.GetUsage(creator.ID)
+		if err != nil {
+			log.Printf("Erro ao calcular uso do plano: %v", err)
+		}
+		data["Usage"] = usage
+	}
+
+	h.templateRenderer.View(w, r, "settings", data, "admin")
 }
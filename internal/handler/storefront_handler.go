@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+	"github.com/go-chi/chi/v5"
+)
+
+// StorefrontHandler exibe a vitrine pública do criador e salva o perfil editado em /settings
+type StorefrontHandler struct {
+	storefrontService service.StorefrontService
+	creatorService    service.CreatorService
+	templateRenderer  template.TemplateRenderer
+}
+
+func NewStorefrontHandler(
+	storefrontService service.StorefrontService,
+	creatorService service.CreatorService,
+	templateRenderer template.TemplateRenderer,
+) *StorefrontHandler {
+	return &StorefrontHandler{
+		storefrontService: storefrontService,
+		creatorService:    creatorService,
+		templateRenderer:  templateRenderer,
+	}
+}
+
+// StorefrontView lista os ebooks e kits à venda do criador, com busca e categorias
+func (h *StorefrontHandler) StorefrontView(w http.ResponseWriter, r *http.Request) {
+	query := models.StorefrontQuery{
+		Term:     strings.TrimSpace(r.URL.Query().Get("q")),
+		Category: strings.TrimSpace(r.URL.Query().Get("categoria")),
+	}
+
+	// No domínio próprio de um criador, só a vitrine dele responde
+	slug := chi.URLParam(r, "slug")
+	if owner := middleware.DomainCreator(r); owner != nil && !strings.EqualFold(owner.Slug, slug) {
+		http.Error(w, models.ErrStorefrontNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	storefront, err := h.storefrontService.Storefront(slug, query)
+	if errors.Is(err, models.ErrStorefrontNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Erro ao montar vitrine: %v", err)
+		http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+		return
+	}
+
+	h.templateRenderer.View(w, r, "creator/storefront", map[string]any{
+		"Storefront": storefront,
+		"Creator":    storefront.Creator,
+	}, "guest")
+}
+
+// ProfileSubmit salva o perfil público da vitrine
+func (h *StorefrontHandler) ProfileSubmit(w http.ResponseWriter, r *http.Request) {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	creator, err := h.creatorService.FindCreatorByUserID(user.ID)
+	if err != nil || creator == nil {
+		log.Printf("Criador não encontrado para o usuário %d: %v", user.ID, err)
+		http.Error(w, "Erro ao buscar criador", http.StatusInternalServerError)
+		return
+	}
+
+	// Parse multipart form (avatar de até 5MB)
+	if err := r.ParseMultipartForm(5 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "Erro ao processar formulário", http.StatusBadRequest)
+		return
+	}
+
+	profile := models.CreatorProfile{
+		Slug:         r.FormValue("slug"),
+		Bio:          r.FormValue("bio"),
+		WebsiteURL:   r.FormValue("website_url"),
+		InstagramURL: r.FormValue("instagram_url"),
+		YouTubeURL:   r.FormValue("youtube_url"),
+		TikTokURL:    r.FormValue("tiktok_url"),
+	}
+
+	var avatar *multipart.FileHeader
+	if _, header, err := r.FormFile("avatar"); err == nil && header.Filename != "" {
+		avatar = header
+	}
+
+	err = h.storefrontService.UpdateProfile(creator, profile, avatar)
+	switch {
+	case errors.Is(err, models.ErrInvalidCreatorSlug), errors.Is(err, models.ErrCreatorSlugTaken),
+		errors.Is(err, models.ErrCreatorBioTooLong), errors.Is(err, models.ErrInvalidSocialLink),
+		errors.Is(err, service.ErrInvalidAvatar):
+		cookies.NotifyError(w, err.Error())
+	case err != nil:
+		log.Printf("Falha ao salvar perfil do criador %d: %v", creator.ID, err)
+		cookies.NotifyError(w, "Erro ao salvar a vitrine")
+	default:
+		cookies.NotifySuccess(w, "Vitrine atualizada!")
+	}
+	http.Redirect(w, r, "/settings#storefront", http.StatusSeeOther)
+}
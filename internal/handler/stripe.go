@@ -2,18 +2,16 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/anglesson/simple-web-server/internal/config"
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
 	"github.com/anglesson/simple-web-server/internal/service"
-	"github.com/anglesson/simple-web-server/pkg/mail"
 	"github.com/stripe/stripe-go/v76"
 	"github.com/stripe/stripe-go/v76/checkout/session"
 	"github.com/stripe/stripe-go/v76/webhook"
@@ -22,21 +20,21 @@ import (
 type StripeHandler struct {
 	userRepository      repository.UserRepository
 	subscriptionService service.SubscriptionService
-	purchaseRepository  *repository.PurchaseRepository
-	emailService        *mail.EmailService
+	planService         service.PlanService
+	webhookService      service.WebhookService
 }
 
 func NewStripeHandler(
 	userRepository repository.UserRepository,
 	subscriptionService service.SubscriptionService,
-	purchaseRepository *repository.PurchaseRepository,
-	emailService *mail.EmailService,
+	planService service.PlanService,
+	webhookService service.WebhookService,
 ) *StripeHandler {
 	return &StripeHandler{
 		userRepository:      userRepository,
 		subscriptionService: subscriptionService,
-		purchaseRepository:  purchaseRepository,
-		emailService:        emailService,
+		planService:         planService,
+		webhookService:      webhookService,
 	}
 }
 
@@ -115,9 +113,37 @@ func (h *StripeHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// O plano escolhido só é aplicado quando o pagamento é confirmado pelo webhook
+	var selection struct {
+		Plan     string `json:"plan"`
+		Interval string `json:"interval"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&selection); err != nil && err != io.EOF {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Requisição inválida",
+		})
+		return
+	}
+
+	priceID := h.priceIDForSubscription(subscription)
+	metadata := map[string]string{}
+	if selection.Plan != "" {
+		priceID, err = h.priceIDForSelection(selection.Plan, selection.Interval)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		metadata["plan"] = selection.Plan
+		metadata["interval"] = selection.Interval
+	}
+
 	// Log Stripe configuration
 	log.Printf("Stripe Secret Key: %s", config.AppConfig.StripeSecretKey)
-	log.Printf("Stripe Price ID: %s", config.AppConfig.StripePriceID)
+	log.Printf("Stripe Price ID: %s", priceID)
 
 	if config.AppConfig.StripeSecretKey == "" {
 		log.Printf("Stripe Secret Key não configurada")
@@ -128,7 +154,7 @@ func (h *StripeHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if config.AppConfig.StripePriceID == "" {
+	if priceID == "" {
 		log.Printf("Stripe Price ID não configurado")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -142,13 +168,16 @@ func (h *StripeHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Req
 		Mode:     stripe.String(string(stripe.CheckoutSessionModeSubscription)),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
-				Price:    stripe.String(config.AppConfig.StripePriceID),
+				Price:    stripe.String(priceID),
 				Quantity: stripe.Int64(1),
 			},
 		},
 		SuccessURL: stripe.String("http://" + r.Host + "/settings?success=true"),
 		CancelURL:  stripe.String("http://" + r.Host + "/settings?canceled=true"),
 	}
+	for key, value := range metadata {
+		params.AddMetadata(key, value)
+	}
 
 	log.Printf("Criando sessão do Stripe com os parâmetros: %+v", params)
 
@@ -179,228 +208,76 @@ func (h *StripeHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Req
 	}
 }
 
-func (h *StripeHandler) HandleStripeWebhook(w http.ResponseWriter, r *http.Request) {
-	payload, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	// Configure webhook options to ignore API version mismatch
-	opts := webhook.ConstructEventOptions{
-		IgnoreAPIVersionMismatch: true,
-	}
-
-	event, err := webhook.ConstructEventWithOptions(payload, r.Header.Get("Stripe-Signature"), config.AppConfig.StripeWebhookSecret, opts)
-	if err != nil {
-		log.Printf("Error verifying webhook signature: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	// Handle the event
-	switch event.Type {
-	case "checkout.session.completed":
-		var session stripe.CheckoutSession
-		err := json.Unmarshal(event.Data.Raw, &session)
+// priceIDForSubscription retorna o preço do plano escolhido na periodicidade da
+// assinatura, usando o preço padrão da configuração quando o plano não possui um.
+func (h *StripeHandler) priceIDForSubscription(subscription *models.Subscription) string {
+	if h.planService != nil {
+		plan, err := h.planService.PlanForUser(subscription.UserID)
 		if err != nil {
-			log.Printf("Error parsing checkout session: %v", err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
+			log.Printf("Erro ao buscar plano do usuário %d: %v", subscription.UserID, err)
 		}
-
-		// Verificar se é um pagamento de ebook ou assinatura
-		if session.Mode == stripe.CheckoutSessionModePayment {
-			// É um pagamento de ebook
-			err = h.handleEbookPayment(session)
-			if err != nil {
-				log.Printf("Error handling ebook payment: %v", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+		if plan != nil {
+			if priceID := plan.PriceIDFor(subscription.BillingInterval); priceID != "" {
+				return priceID
 			}
-		} else if session.Mode == stripe.CheckoutSessionModeSubscription {
-			// É uma assinatura
-			err = h.handleSubscriptionPayment(session)
-			if err != nil {
-				log.Printf("Error handling subscription payment: %v", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		}
-
-	case "customer.subscription.updated":
-		var stripeSubscription stripe.Subscription
-		err := json.Unmarshal(event.Data.Raw, &stripeSubscription)
-		if err != nil {
-			log.Printf("Error parsing subscription: %v", err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		// Find subscription by Stripe customer ID
-		subscription, err := h.subscriptionService.FindByStripeCustomerID(stripeSubscription.Customer.ID)
-		if err != nil {
-			log.Printf("Error finding subscription: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if subscription == nil {
-			log.Printf("Subscription not found for Stripe customer ID: %s", stripeSubscription.Customer.ID)
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		// Update subscription status
-		var endDate *time.Time
-		if stripeSubscription.CurrentPeriodEnd > 0 {
-			endDate = &time.Time{}
-			*endDate = time.Unix(stripeSubscription.CurrentPeriodEnd, 0)
-		}
-		err = h.subscriptionService.UpdateSubscriptionStatus(subscription, string(stripeSubscription.Status), endDate)
-		if err != nil {
-			log.Printf("Error updating subscription: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-	case "customer.subscription.deleted":
-		var stripeSubscription stripe.Subscription
-		err := json.Unmarshal(event.Data.Raw, &stripeSubscription)
-		if err != nil {
-			log.Printf("Error parsing subscription: %v", err)
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		// Find subscription by Stripe customer ID
-		subscription, err := h.subscriptionService.FindByStripeCustomerID(stripeSubscription.Customer.ID)
-		if err != nil {
-			log.Printf("Error finding subscription: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if subscription == nil {
-			log.Printf("Subscription not found for Stripe customer ID: %s", stripeSubscription.Customer.ID)
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		// Update subscription status
-		var endDate *time.Time
-		if stripeSubscription.CurrentPeriodEnd > 0 {
-			endDate = &time.Time{}
-			*endDate = time.Unix(stripeSubscription.CurrentPeriodEnd, 0)
-		}
-		err = h.subscriptionService.UpdateSubscriptionStatus(subscription, "canceled", endDate)
-		if err != nil {
-			log.Printf("Error updating subscription: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
 		}
 	}
-
-	w.WriteHeader(http.StatusOK)
+	return config.AppConfig.StripePriceID
 }
 
-// handleEbookPayment processa pagamento de ebook
-func (h *StripeHandler) handleEbookPayment(session stripe.CheckoutSession) error {
-	// Extrair dados da sessão
-	ebookIDStr := session.Metadata["ebook_id"]
-	clientIDStr := session.Metadata["client_id"]
-
-	if ebookIDStr == "" || clientIDStr == "" {
-		return fmt.Errorf("dados da compra inválidos")
+// priceIDForSelection retorna o preço do plano escolhido no checkout
+func (h *StripeHandler) priceIDForSelection(planSlug, interval string) (string, error) {
+	if interval != models.BillingMonthly && interval != models.BillingYearly {
+		return "", errors.New("periodicidade inválida")
 	}
-
-	// Converter IDs
-	ebookID, err := strconv.ParseUint(ebookIDStr, 10, 32)
-	if err != nil {
-		return fmt.Errorf("ebook ID inválido: %v", err)
+	if h.planService == nil {
+		return "", service.ErrPlanNotFound
 	}
 
-	clientID, err := strconv.ParseUint(clientIDStr, 10, 32)
+	plan, err := h.planService.FindBySlug(planSlug)
 	if err != nil {
-		return fmt.Errorf("client ID inválido: %v", err)
+		return "", err
+	}
+	if plan == nil || !plan.Active {
+		return "", service.ErrPlanNotFound
 	}
 
-	// Criar registro de compra
-	purchase := models.NewPurchase(uint(ebookID), uint(clientID))
-	purchase.ExpiresAt = time.Now().AddDate(0, 0, 30) // 30 dias de acesso
+	priceID := plan.PriceIDFor(interval)
+	if priceID == "" {
+		return "", fmt.Errorf("o plano %s não está disponível para cobrança %s", plan.Name, interval)
+	}
+	return priceID, nil
+}
 
-	err = h.purchaseRepository.CreateManyPurchases([]*models.Purchase{purchase})
+func (h *StripeHandler) HandleStripeWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(r.Body)
 	if err != nil {
-		return fmt.Errorf("erro ao criar compra: %v", err)
+		log.Printf("Error reading request body: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	// Enviar email com link de download
-	if purchase.ID > 0 {
-		log.Printf("Purchase criado com sucesso: ID=%d, EbookID=%d, ClientID=%d", purchase.ID, purchase.EbookID, purchase.ClientID)
-
-		// Buscar purchase com relacionamentos do banco
-		log.Printf("🔍 Buscando purchase com ID=%d para carregar relacionamentos", purchase.ID)
-		log.Printf("🔍 Usando purchaseRepository: %+v", h.purchaseRepository)
-
-		// Verificar se purchaseRepository não é nil
-		if h.purchaseRepository == nil {
-			log.Printf("❌ ERRO: purchaseRepository é nil!")
-			return fmt.Errorf("purchaseRepository não inicializado")
-		}
-
-		purchaseWithRelations, err := h.purchaseRepository.FindByID(purchase.ID)
-		if err != nil {
-			log.Printf("❌ Erro ao buscar purchase com relacionamentos: %v", err)
-			return fmt.Errorf("erro ao buscar dados da compra: %v", err)
-		}
-
-		if purchaseWithRelations == nil {
-			log.Printf("❌ Purchase não encontrado após criação")
-			return fmt.Errorf("purchase não encontrado após criação")
-		}
-
-		log.Printf("✅ Purchase carregado: ID=%d, ClientID=%d",
-			purchaseWithRelations.ID, purchaseWithRelations.ClientID)
-
-		// Verificar se o cliente foi carregado
-		if purchaseWithRelations.Client.ID == 0 {
-			log.Printf("❌ Cliente não foi carregado! Client.ID=0")
-		} else {
-			log.Printf("✅ Cliente carregado: ID=%d, Name='%s', Email='%s'",
-				purchaseWithRelations.Client.ID,
-				purchaseWithRelations.Client.Name,
-				purchaseWithRelations.Client.Email)
-		}
-
-		// Verificar se o cliente tem email
-		if purchaseWithRelations.Client.Email == "" {
-			log.Printf("Cliente sem email: ClientID=%d", purchaseWithRelations.ClientID)
-			return fmt.Errorf("cliente sem email válido")
-		}
-
-		log.Printf("Enviando email para: %s", purchaseWithRelations.Client.Email)
-
-		go h.emailService.SendLinkToDownload([]*models.Purchase{purchaseWithRelations})
+	// Configure webhook options to ignore API version mismatch
+	opts := webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
 	}
 
-	return nil
-}
-
-// handleSubscriptionPayment processa pagamento de assinatura
-func (h *StripeHandler) handleSubscriptionPayment(session stripe.CheckoutSession) error {
-	// Find subscription by Stripe customer ID
-	subscription, err := h.subscriptionService.FindByStripeCustomerID(session.Customer.ID)
+	event, err := webhook.ConstructEventWithOptions(payload, r.Header.Get("Stripe-Signature"), config.AppConfig.StripeWebhookSecret, opts)
 	if err != nil {
-		return fmt.Errorf("error finding subscription: %v", err)
-	}
-	if subscription == nil {
-		return fmt.Errorf("subscription not found for Stripe customer ID: %s", session.Customer.ID)
+		log.Printf("Error verifying webhook signature: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	// Update subscription status
-	err = h.subscriptionService.ActivateSubscription(subscription, session.Customer.ID, session.Subscription.ID)
+	// O evento é registrado antes do processamento; falhas são reprocessadas pelo WebhookService
+	webhookEvent, err := h.webhookService.HandleEvent(event.ID, string(event.Type), payload)
 	if err != nil {
-		return fmt.Errorf("error updating subscription: %v", err)
+		log.Printf("Error storing webhook event %s: %v", event.ID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	return nil
+	log.Printf("Webhook event %s (%s) status: %s", webhookEvent.EventID, webhookEvent.Type, webhookEvent.Status)
+
+	w.WriteHeader(http.StatusOK)
 }
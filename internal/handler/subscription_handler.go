@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/anglesson/simple-web-server/internal/handler/middleware"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/service"
+	cookies "github.com/anglesson/simple-web-server/pkg/cookie"
+	"github.com/anglesson/simple-web-server/pkg/template"
+)
+
+type SubscriptionHandler struct {
+	subscriptionService service.SubscriptionService
+	planService         service.PlanService
+	templateRenderer    template.TemplateRenderer
+}
+
+func NewSubscriptionHandler(
+	subscriptionService service.SubscriptionService,
+	planService service.PlanService,
+	templateRenderer template.TemplateRenderer,
+) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionService: subscriptionService,
+		planService:         planService,
+		templateRenderer:    templateRenderer,
+	}
+}
+
+// ManageView exibe a assinatura do criador com as ações de autoatendimento e as faturas
+func (h *SubscriptionHandler) ManageView(w http.ResponseWriter, r *http.Request) {
+	subscription := h.currentSubscription(w, r)
+	if subscription == nil {
+		return
+	}
+
+	invoices, err := h.subscriptionService.ListInvoices(subscription)
+	if err != nil {
+		log.Printf("Erro ao listar faturas da assinatura %d: %v", subscription.ID, err)
+	}
+
+	plan, err := h.planService.PlanForUser(subscription.UserID)
+	if err != nil {
+		log.Printf("Erro ao buscar plano do usuário %d: %v", subscription.UserID, err)
+	}
+
+	events, err := h.subscriptionService.ListEvents(subscription)
+	if err != nil {
+		log.Printf("Erro ao buscar histórico da assinatura %d: %v", subscription.ID, err)
+	}
+
+	h.templateRenderer.View(w, r, "subscription/manage", map[string]any{
+		"Subscription": subscription,
+		"Plan":         plan,
+		"Invoices":     invoices,
+		"Events":       events,
+	}, "admin")
+}
+
+// CancelSubmit agenda o cancelamento da assinatura para o fim do período pago
+func (h *SubscriptionHandler) CancelSubmit(w http.ResponseWriter, r *http.Request) {
+	subscription := h.currentSubscription(w, r)
+	if subscription == nil {
+		return
+	}
+
+	if err := h.subscriptionService.ScheduleCancellation(subscription, models.UserActor(subscription.UserID)); err != nil {
+		log.Printf("Erro ao cancelar assinatura %d: %v", subscription.ID, err)
+		h.redirectToManage(w, r, err.Error(), false)
+		return
+	}
+
+	h.redirectToManage(w, r, "Assinatura cancelada. Seu acesso continua até o fim do período pago.", true)
+}
+
+// ResumeSubmit desfaz o cancelamento agendado
+func (h *SubscriptionHandler) ResumeSubmit(w http.ResponseWriter, r *http.Request) {
+	subscription := h.currentSubscription(w, r)
+	if subscription == nil {
+		return
+	}
+
+	if err := h.subscriptionService.ResumeSubscription(subscription, models.UserActor(subscription.UserID)); err != nil {
+		log.Printf("Erro ao reativar assinatura %d: %v", subscription.ID, err)
+		h.redirectToManage(w, r, err.Error(), false)
+		return
+	}
+
+	h.redirectToManage(w, r, "Assinatura reativada com sucesso!", true)
+}
+
+// PaymentMethodSubmit redireciona para o portal do gateway para atualizar o cartão
+func (h *SubscriptionHandler) PaymentMethodSubmit(w http.ResponseWriter, r *http.Request) {
+	subscription := h.currentSubscription(w, r)
+	if subscription == nil {
+		return
+	}
+
+	url, err := h.subscriptionService.CreatePortalSession(subscription, "http://"+r.Host+"/settings/subscription")
+	if err != nil {
+		log.Printf("Erro ao criar sessão do portal para a assinatura %d: %v", subscription.ID, err)
+		h.redirectToManage(w, r, err.Error(), false)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+func (h *SubscriptionHandler) currentSubscription(w http.ResponseWriter, r *http.Request) *models.Subscription {
+	user := middleware.Auth(r)
+	if user == nil || user.ID == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+
+	subscription, err := h.subscriptionService.FindByUserID(user.ID)
+	if err != nil || subscription == nil {
+		log.Printf("Assinatura não encontrada para o usuário %d: %v", user.ID, err)
+		cookies.NotifyError(w, "Assinatura não encontrada")
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return nil
+	}
+
+	return subscription
+}
+
+func (h *SubscriptionHandler) redirectToManage(w http.ResponseWriter, r *http.Request, message string, success bool) {
+	if success {
+		cookies.NotifySuccess(w, message)
+	} else {
+		cookies.NotifyError(w, message)
+	}
+	http.Redirect(w, r, "/settings/subscription", http.StatusSeeOther)
+}
@@ -17,6 +17,17 @@ func WatermarkHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	serveWatermarkedFile(w, r)
+}
+
+// CreatorWatermarkHandler atende a API dos criadores; a autenticação e o plano
+// são conferidos pelos middlewares da rota
+func CreatorWatermarkHandler(w http.ResponseWriter, r *http.Request) {
+	serveWatermarkedFile(w, r)
+}
+
+// serveWatermarkedFile devolve o PDF enviado com a marca d'água informada
+func serveWatermarkedFile(w http.ResponseWriter, r *http.Request) {
 	content := r.FormValue("content")
 	if content == "" {
 		http.Error(w, "Invalid content", http.StatusBadRequest)
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	CommissionTypeEarned   = "earned"   // comissão gerada por pedido pago
+	CommissionTypeReversed = "reversed" // estorno da comissão após reembolso
+
+	// MaxCommissionPercent limita a comissão para que o criador sempre receba parte da venda
+	MaxCommissionPercent = 90
+)
+
+var (
+	ErrAffiliateNotFound     = errors.New("afiliado não encontrado")
+	ErrInvalidCommissionRate = fmt.Errorf("a comissão deve estar entre 0 e %d%%", MaxCommissionPercent)
+)
+
+// Affiliate é um parceiro do criador que divulga os ebooks com um código de indicação
+type Affiliate struct {
+	gorm.Model
+	CreatorID uint    `json:"creator_id" gorm:"index"`
+	Creator   Creator `gorm:"foreignKey:CreatorID"`
+	Name      string  `json:"name"`
+	Email     string  `json:"email"`
+	Code      string  `json:"code" gorm:"uniqueIndex"` // código usado nos links (?ref=)
+	Token     string  `json:"-" gorm:"uniqueIndex"`    // acesso ao painel do afiliado
+	Active    bool    `json:"active" gorm:"default:true"`
+}
+
+func NewAffiliate(creatorID uint, name, email, code, token string) *Affiliate {
+	return &Affiliate{
+		CreatorID: creatorID,
+		Name:      strings.TrimSpace(name),
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		Code:      code,
+		Token:     token,
+		Active:    true,
+	}
+}
+
+// ReferralLink é o link de divulgação de uma página do site com o código do afiliado
+func (a *Affiliate) ReferralLink(baseURL, path string) string {
+	return baseURL + path + "?ref=" + a.Code
+}
+
+// CommissionRule define o percentual pago aos afiliados nas vendas de um ebook
+type CommissionRule struct {
+	gorm.Model
+	CreatorID uint `json:"creator_id" gorm:"index"`
+	EbookID   uint `json:"ebook_id" gorm:"uniqueIndex"`
+	Percent   int  `json:"percent"`
+}
+
+func NewCommissionRule(creatorID, ebookID uint, percent int) (*CommissionRule, error) {
+	if percent < 0 || percent > MaxCommissionPercent {
+		return nil, ErrInvalidCommissionRate
+	}
+	return &CommissionRule{CreatorID: creatorID, EbookID: ebookID, Percent: percent}, nil
+}
+
+// Commission é um lançamento no extrato do afiliado. Estornos têm valor negativo,
+// de modo que o saldo é a soma dos lançamentos.
+type Commission struct {
+	gorm.Model
+	AffiliateID uint           `json:"affiliate_id" gorm:"index"`
+	Affiliate   Affiliate      `gorm:"foreignKey:AffiliateID"`
+	CreatorID   uint           `json:"creator_id" gorm:"index"`
+	OrderID     uint           `json:"order_id" gorm:"index"`
+	EbookID     uint           `json:"ebook_id"`
+	Ebook       Ebook          `gorm:"foreignKey:EbookID"`
+	Type        string         `json:"type"`
+	Percent     int            `json:"percent"`
+	Amount      int64          `json:"amount"` // em centavos
+	Currency    money.Currency `json:"currency" gorm:"size:3;default:'BRL'"`
+}
+
+// NewOrderCommissions calcula as comissões do afiliado para cada item do pedido
+// que tenha regra de comissão. Itens sem regra não geram comissão.
+func NewOrderCommissions(order *Order, rules []CommissionRule) []*Commission {
+	if order.AffiliateID == nil {
+		return nil
+	}
+
+	percents := make(map[uint]int, len(rules))
+	for _, rule := range rules {
+		percents[rule.EbookID] = rule.Percent
+	}
+
+	var commissions []*Commission
+	for _, item := range order.Items {
+		percent := percents[item.EbookID]
+		amount := money.New(item.Amount(), item.Currency).Percent(int64(percent)).Amount
+		if amount <= 0 {
+			continue
+		}
+		commissions = append(commissions, &Commission{
+			AffiliateID: *order.AffiliateID,
+			CreatorID:   order.CreatorID,
+			OrderID:     order.ID,
+			EbookID:     item.EbookID,
+			Type:        CommissionTypeEarned,
+			Percent:     percent,
+			Amount:      amount,
+			Currency:    item.Currency,
+		})
+	}
+	return commissions
+}
+
+// Reversal é o lançamento que estorna a comissão
+func (c *Commission) Reversal() *Commission {
+	return &Commission{
+		AffiliateID: c.AffiliateID,
+		CreatorID:   c.CreatorID,
+		OrderID:     c.OrderID,
+		EbookID:     c.EbookID,
+		Type:        CommissionTypeReversed,
+		Percent:     c.Percent,
+		Amount:      -c.Amount,
+		Currency:    c.Currency,
+	}
+}
+
+func (c *Commission) IsReversal() bool {
+	return c.Type == CommissionTypeReversed
+}
+
+func (c *Commission) Value() money.Money {
+	return money.New(c.Amount, c.Currency)
+}
+
+func (c *Commission) GetValue() string {
+	return c.Value().Format()
+}
+
+func (c *Commission) GetTypeLabel() string {
+	if c.IsReversal() {
+		return "Estorno"
+	}
+	return "Comissão"
+}
+
+// CommissionSummary totaliza o extrato de um afiliado em uma moeda
+type CommissionSummary struct {
+	Currency money.Currency
+	Earned   int64
+	Reversed int64
+	Sales    int
+}
+
+// SummarizeCommissions agrupa os lançamentos por moeda
+func SummarizeCommissions(commissions []*Commission) []*CommissionSummary {
+	var summaries []*CommissionSummary
+	byCurrency := make(map[money.Currency]*CommissionSummary)
+	for _, commission := range commissions {
+		summary, ok := byCurrency[commission.Currency]
+		if !ok {
+			summary = &CommissionSummary{Currency: commission.Currency}
+			byCurrency[commission.Currency] = summary
+			summaries = append(summaries, summary)
+		}
+		if commission.IsReversal() {
+			summary.Reversed += -commission.Amount
+		} else {
+			summary.Earned += commission.Amount
+			summary.Sales++
+		}
+	}
+	return summaries
+}
+
+func (s *CommissionSummary) Balance() int64 {
+	return s.Earned - s.Reversed
+}
+
+func (s *CommissionSummary) GetEarned() string {
+	return money.New(s.Earned, s.Currency).Format()
+}
+
+func (s *CommissionSummary) GetReversed() string {
+	return money.New(s.Reversed, s.Currency).Format()
+}
+
+func (s *CommissionSummary) GetBalance() string {
+	return money.New(s.Balance(), s.Currency).Format()
+}
@@ -0,0 +1,61 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewOrderCommissions_UsesRulePerItem(t *testing.T) {
+	affiliateID := uint(4)
+	order := models.NewOrder(7, 9, 1, 8000, 0, money.BRL)
+	order.ID = 30
+	order.AffiliateID = &affiliateID
+	order.Items = []models.OrderItem{
+		models.NewOrderItem(&models.Ebook{Model: gorm.Model{ID: 1}, Price: money.New(4990, money.BRL)}),
+		models.NewOrderItem(&models.Ebook{Model: gorm.Model{ID: 2}, Price: money.New(3010, money.BRL)}),
+	}
+	rules := []models.CommissionRule{{EbookID: 1, Percent: 30}}
+
+	commissions := models.NewOrderCommissions(order, rules)
+
+	assert.Len(t, commissions, 1)
+	assert.Equal(t, int64(1497), commissions[0].Amount)
+	assert.Equal(t, uint(30), commissions[0].OrderID)
+	assert.Equal(t, affiliateID, commissions[0].AffiliateID)
+	assert.Equal(t, models.CommissionTypeEarned, commissions[0].Type)
+}
+
+func TestNewOrderCommissions_WithoutAffiliate(t *testing.T) {
+	order := models.NewOrder(7, 9, 1, 4990, 0, money.BRL)
+	order.Items = []models.OrderItem{models.NewOrderItem(&models.Ebook{Model: gorm.Model{ID: 1}, Price: money.New(4990, money.BRL)})}
+
+	assert.Empty(t, models.NewOrderCommissions(order, []models.CommissionRule{{EbookID: 1, Percent: 30}}))
+}
+
+func TestSummarizeCommissions_SubtractsReversals(t *testing.T) {
+	earned := &models.Commission{Type: models.CommissionTypeEarned, Amount: 1500, Currency: money.BRL}
+	other := &models.Commission{Type: models.CommissionTypeEarned, Amount: 500, Currency: money.BRL}
+
+	summaries := models.SummarizeCommissions([]*models.Commission{earned, other, earned.Reversal()})
+
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, 2, summaries[0].Sales)
+	assert.Equal(t, int64(1500), summaries[0].Reversed)
+	assert.Equal(t, "R$ 5,00", summaries[0].GetBalance())
+}
+
+func TestNewCommissionRule_Validation(t *testing.T) {
+	_, err := models.NewCommissionRule(7, 1, 95)
+	assert.ErrorIs(t, err, models.ErrInvalidCommissionRate)
+
+	_, err = models.NewCommissionRule(7, 1, -1)
+	assert.ErrorIs(t, err, models.ErrInvalidCommissionRate)
+
+	rule, err := models.NewCommissionRule(7, 1, 40)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, rule.Percent)
+}
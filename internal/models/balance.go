@@ -0,0 +1,234 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	BalanceEntrySale               = "sale"                // valor bruto da venda
+	BalanceEntryPlatformFee        = "platform_fee"        // taxa da plataforma sobre a venda
+	BalanceEntryRefund             = "refund"              // valor reembolsado ao comprador
+	BalanceEntryFeeRefund          = "fee_refund"          // taxa devolvida ao criador no reembolso
+	BalanceEntryChargeback         = "chargeback"          // valor retido pela contestação
+	BalanceEntryChargebackReversal = "chargeback_reversal" // valor devolvido ao vencer a contestação
+)
+
+const (
+	PayoutScheduleDaily   = "daily"
+	PayoutScheduleWeekly  = "weekly"
+	PayoutScheduleMonthly = "monthly"
+	PayoutScheduleManual  = "manual"
+)
+
+const (
+	PayoutStatusPending = "pending"
+	PayoutStatusPaid    = "paid"
+	PayoutStatusFailed  = "failed"
+)
+
+var (
+	ErrInvalidPayoutSchedule = errors.New("frequência de repasse inválida")
+	ErrPayoutAccountNotReady = errors.New("conclua o cadastro da conta recebedora para receber repasses")
+	ErrNothingToPayout       = errors.New("não há saldo disponível para repasse")
+)
+
+// BalanceEntry é um lançamento no saldo do criador. Débitos têm valor negativo, de
+// modo que o saldo é a soma dos lançamentos. Lançamentos só entram em um repasse
+// depois de AvailableAt e, ao entrar, ficam vinculados a ele por PayoutID.
+type BalanceEntry struct {
+	gorm.Model
+	CreatorID   uint           `json:"creator_id" gorm:"index"`
+	OrderID     uint           `json:"order_id" gorm:"index"`
+	PaymentID   uint           `json:"payment_id" gorm:"index"`
+	Type        string         `json:"type"`
+	Amount      int64          `json:"amount"` // em centavos, com sinal
+	Currency    money.Currency `json:"currency" gorm:"size:3;default:'BRL'"`
+	AvailableAt time.Time      `json:"available_at" gorm:"index"`
+	PayoutID    *uint          `json:"payout_id" gorm:"index"`
+}
+
+func (e *BalanceEntry) IsDebit() bool {
+	return e.Amount < 0
+}
+
+func (e *BalanceEntry) IsAvailable(now time.Time) bool {
+	return !e.AvailableAt.After(now)
+}
+
+func (e *BalanceEntry) Value() money.Money {
+	return money.New(e.Amount, e.Currency)
+}
+
+func (e *BalanceEntry) GetValue() string {
+	return e.Value().Format()
+}
+
+func (e *BalanceEntry) GetTypeLabel() string {
+	switch e.Type {
+	case BalanceEntrySale:
+		return "Venda"
+	case BalanceEntryPlatformFee:
+		return "Taxa da plataforma"
+	case BalanceEntryRefund:
+		return "Reembolso"
+	case BalanceEntryFeeRefund:
+		return "Devolução da taxa"
+	case BalanceEntryChargeback:
+		return "Contestação"
+	case BalanceEntryChargebackReversal:
+		return "Contestação revertida"
+	}
+	return e.Type
+}
+
+// Balance totaliza o saldo do criador em uma moeda
+type Balance struct {
+	Currency  money.Currency
+	Available int64 // liberado e ainda não repassado
+	Pending   int64 // aguardando o prazo de liberação
+}
+
+// SummarizeBalance agrupa por moeda os lançamentos que ainda não entraram em repasse
+func SummarizeBalance(entries []*BalanceEntry, now time.Time) []*Balance {
+	var balances []*Balance
+	byCurrency := make(map[money.Currency]*Balance)
+	for _, entry := range entries {
+		if entry.PayoutID != nil {
+			continue
+		}
+		balance, ok := byCurrency[entry.Currency]
+		if !ok {
+			balance = &Balance{Currency: entry.Currency}
+			byCurrency[entry.Currency] = balance
+			balances = append(balances, balance)
+		}
+		if entry.IsAvailable(now) {
+			balance.Available += entry.Amount
+		} else {
+			balance.Pending += entry.Amount
+		}
+	}
+	return balances
+}
+
+func (b *Balance) GetAvailable() string {
+	return money.New(b.Available, b.Currency).Format()
+}
+
+func (b *Balance) GetPending() string {
+	return money.New(b.Pending, b.Currency).Format()
+}
+
+// PayoutAccount é a conta recebedora do criador no gateway e a frequência dos repasses
+type PayoutAccount struct {
+	gorm.Model
+	CreatorID        uint       `json:"creator_id" gorm:"uniqueIndex"`
+	GatewayAccountID string     `json:"gateway_account_id"`
+	PayoutsEnabled   bool       `json:"payouts_enabled"`
+	Schedule         string     `json:"schedule" gorm:"default:'weekly'"`
+	LastPayoutAt     *time.Time `json:"last_payout_at"`
+}
+
+func NewPayoutAccount(creatorID uint, gatewayAccountID string) *PayoutAccount {
+	return &PayoutAccount{
+		CreatorID:        creatorID,
+		GatewayAccountID: gatewayAccountID,
+		Schedule:         PayoutScheduleWeekly,
+	}
+}
+
+func (a *PayoutAccount) SetSchedule(schedule string) error {
+	switch schedule {
+	case PayoutScheduleDaily, PayoutScheduleWeekly, PayoutScheduleMonthly, PayoutScheduleManual:
+		a.Schedule = schedule
+		return nil
+	}
+	return ErrInvalidPayoutSchedule
+}
+
+// IsDue indica se o repasse automático do período atual (dia, semana a partir de
+// segunda-feira ou mês) ainda não foi feito
+func (a *PayoutAccount) IsDue(now time.Time) bool {
+	if !a.PayoutsEnabled || a.Schedule == PayoutScheduleManual {
+		return false
+	}
+	if a.LastPayoutAt == nil {
+		return true
+	}
+
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch a.Schedule {
+	case PayoutScheduleWeekly:
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	case PayoutScheduleMonthly:
+		start = start.AddDate(0, 0, 1-start.Day())
+	}
+	return a.LastPayoutAt.Before(start)
+}
+
+func (a *PayoutAccount) GetScheduleLabel() string {
+	switch a.Schedule {
+	case PayoutScheduleDaily:
+		return "Diário"
+	case PayoutScheduleWeekly:
+		return "Semanal"
+	case PayoutScheduleMonthly:
+		return "Mensal"
+	case PayoutScheduleManual:
+		return "Manual"
+	}
+	return a.Schedule
+}
+
+// Payout é uma transferência do saldo disponível do criador para a conta recebedora
+type Payout struct {
+	gorm.Model
+	CreatorID         uint           `json:"creator_id" gorm:"index"`
+	Amount            int64          `json:"amount"` // em centavos
+	Currency          money.Currency `json:"currency" gorm:"size:3;default:'BRL'"`
+	Status            string         `json:"status" gorm:"index;default:'pending'"`
+	GatewayTransferID string         `json:"gateway_transfer_id"`
+	FailureReason     string         `json:"failure_reason"`
+	PaidAt            *time.Time     `json:"paid_at"`
+}
+
+func NewPayout(creatorID uint, amount int64, currency money.Currency) *Payout {
+	return &Payout{
+		CreatorID: creatorID,
+		Amount:    amount,
+		Currency:  currency,
+		Status:    PayoutStatusPending,
+	}
+}
+
+func (p *Payout) MarkPaid(transferID string) {
+	now := time.Now()
+	p.Status = PayoutStatusPaid
+	p.GatewayTransferID = transferID
+	p.PaidAt = &now
+}
+
+func (p *Payout) MarkFailed(reason string) {
+	p.Status = PayoutStatusFailed
+	p.FailureReason = reason
+}
+
+func (p *Payout) GetValue() string {
+	return money.New(p.Amount, p.Currency).Format()
+}
+
+func (p *Payout) GetStatusLabel() string {
+	switch p.Status {
+	case PayoutStatusPending:
+		return "Processando"
+	case PayoutStatusPaid:
+		return "Pago"
+	case PayoutStatusFailed:
+		return "Falhou"
+	}
+	return p.Status
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayment_BalanceEntries(t *testing.T) {
+	payment := NewPayment(1, "stripe", "pi_1", 10000, money.BRL)
+	payment.Order = &Order{CreatorID: 7}
+
+	payment.RecordSale(10, time.Now())
+	assert.Equal(t, int64(1000), payment.PlatformFee)
+	assert.Equal(t, int64(9000), sumEntries(payment.BalanceEntries))
+
+	// Reembolso parcial devolve 10% da taxa proporcional
+	payment.BalanceEntries = nil
+	payment.Refund(4000)
+	payment.RecordRefund(0)
+	assert.Equal(t, int64(-4000+400), sumEntries(payment.BalanceEntries))
+
+	// A contestação retém o restante e, se vencida, devolve o mesmo valor
+	payment.BalanceEntries = nil
+	payment.Dispute()
+	payment.Dispute()
+	assert.Equal(t, int64(-6000), sumEntries(payment.BalanceEntries))
+	payment.ResolveDispute(true)
+	assert.Equal(t, int64(0), sumEntries(payment.BalanceEntries))
+}
+
+func TestPayment_LostDisputeDoesNotDebitTwice(t *testing.T) {
+	payment := NewPayment(1, "stripe", "pi_1", 10000, money.BRL)
+	payment.Order = &Order{CreatorID: 7}
+
+	payment.Dispute()
+	payment.ResolveDispute(false)
+
+	assert.Equal(t, int64(-10000), sumEntries(payment.BalanceEntries))
+	assert.Equal(t, PaymentStatusRefunded, payment.Status)
+}
+
+func TestPayoutAccount_IsDue(t *testing.T) {
+	wednesday := time.Date(2026, 10, 21, 10, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	lastMonth := time.Date(2026, 9, 30, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule string
+		last     *time.Time
+		expected bool
+	}{
+		{name: "primeiro repasse", schedule: PayoutScheduleWeekly, expected: true},
+		{name: "semanal já pago nesta semana", schedule: PayoutScheduleWeekly, last: &monday, expected: false},
+		{name: "diário pago em outro dia", schedule: PayoutScheduleDaily, last: &monday, expected: true},
+		{name: "mensal já pago neste mês", schedule: PayoutScheduleMonthly, last: &monday, expected: false},
+		{name: "mensal pago no mês anterior", schedule: PayoutScheduleMonthly, last: &lastMonth, expected: true},
+		{name: "manual nunca é automático", schedule: PayoutScheduleManual, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &PayoutAccount{PayoutsEnabled: true, Schedule: tt.schedule, LastPayoutAt: tt.last}
+			assert.Equal(t, tt.expected, account.IsDue(wednesday))
+		})
+	}
+}
+
+func sumEntries(entries []*BalanceEntry) int64 {
+	var total int64
+	for _, entry := range entries {
+		total += entry.Amount
+	}
+	return total
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBundleTooFewEbooks  = errors.New("o kit precisa ter pelo menos dois e-books")
+	ErrBundleForeignEbook  = errors.New("o kit só pode ter e-books do próprio autor")
+	ErrBundleMixedCurrency = errors.New("os e-books do kit precisam estar na mesma moeda do kit")
+)
+
+// Bundle é um kit de ebooks vendido como um único produto, com preço próprio
+type Bundle struct {
+	gorm.Model
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	SalesPage   string      `json:"sales_page"` // Conteúdo da página de vendas
+	Price       money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Status      bool        `json:"status"`
+	Slug        string      `json:"slug" gorm:"uniqueIndex"` // URL amigável
+	CreatorID   uint        `json:"creator_id" gorm:"index"`
+	Creator     Creator     `gorm:"foreignKey:CreatorID"`
+	Ebooks      []*Ebook    `gorm:"many2many:bundle_ebooks;"`
+
+	// Estatísticas
+	Views int `json:"views" gorm:"default:0"`
+	Sales int `json:"sales" gorm:"default:0"`
+}
+
+func NewBundle(title, description, salesPage string, price money.Money, creator Creator) *Bundle {
+	return &Bundle{
+		Title:       title,
+		Description: description,
+		SalesPage:   salesPage,
+		Price:       price,
+		Status:      true,
+		CreatorID:   creator.ID,
+		Slug:        generateSlug(title),
+	}
+}
+
+// SetEbooks define os ebooks do kit, que devem ser do mesmo criador e da mesma moeda
+func (b *Bundle) SetEbooks(ebooks []*Ebook) error {
+	if len(ebooks) < 2 {
+		return ErrBundleTooFewEbooks
+	}
+	for _, ebook := range ebooks {
+		if ebook.CreatorID != b.CreatorID {
+			return ErrBundleForeignEbook
+		}
+		if ebook.Price.Currency != b.Price.Currency {
+			return ErrBundleMixedCurrency
+		}
+	}
+	b.Ebooks = ebooks
+	return nil
+}
+
+func (b *Bundle) HasEbook(ebookID uint) bool {
+	for _, ebook := range b.Ebooks {
+		if ebook.ID == ebookID {
+			return true
+		}
+	}
+	return false
+}
+
+// OriginalPrice é a soma dos preços dos ebooks comprados separadamente
+func (b *Bundle) OriginalPrice() money.Money {
+	total := money.New(0, b.Price.Currency)
+	for _, ebook := range b.Ebooks {
+		total = total.Add(ebook.Price)
+	}
+	return total
+}
+
+// Savings é quanto o comprador economiza levando o kit
+func (b *Bundle) Savings() money.Money {
+	savings := b.OriginalPrice().Sub(b.Price)
+	if savings.Amount < 0 {
+		return money.New(0, b.Price.Currency)
+	}
+	return savings
+}
+
+func (b *Bundle) GetValue() string {
+	return b.Price.Format()
+}
+
+func (b *Bundle) GetOriginalValue() string {
+	return b.OriginalPrice().Format()
+}
+
+func (b *Bundle) GetSavings() string {
+	return b.Savings().Format()
+}
+
+func (b *Bundle) GetEbookCount() int {
+	return len(b.Ebooks)
+}
+
+func (b *Bundle) IncrementViews() {
+	b.Views++
+}
+
+// Items divide o preço do kit entre os ebooks, proporcionalmente ao preço de cada
+// um, para que as vendas de cada ebook reflitam a receita recebida. O último item
+// recebe o arredondamento, de modo que a soma seja igual ao preço do kit.
+func (b *Bundle) Items() []OrderItem {
+	items := make([]OrderItem, 0, len(b.Ebooks))
+	original := b.OriginalPrice().Amount
+
+	var allocated int64
+	for i, ebook := range b.Ebooks {
+		item := NewOrderItem(ebook)
+		item.BundleID = &b.ID
+
+		switch {
+		case i == len(b.Ebooks)-1:
+			item.UnitPrice = b.Price.Amount - allocated
+		case original > 0:
+			item.UnitPrice = b.Price.Amount * ebook.Price.Amount / original
+		default:
+			item.UnitPrice = b.Price.Amount / int64(len(b.Ebooks))
+		}
+
+		allocated += item.UnitPrice
+		items = append(items, item)
+	}
+	return items
+}
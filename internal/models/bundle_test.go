@@ -0,0 +1,68 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newBundleWithEbooks(t *testing.T, price int64, ebookPrices ...int64) *models.Bundle {
+	bundle := models.NewBundle("Kit Completo", "Todos os ebooks", "Página", money.New(price, money.BRL), models.Creator{Model: gorm.Model{ID: 7}})
+	bundle.ID = 3
+
+	var ebooks []*models.Ebook
+	for i, amount := range ebookPrices {
+		ebooks = append(ebooks, &models.Ebook{Model: gorm.Model{ID: uint(i + 1)}, CreatorID: 7, Title: "Ebook", Price: money.New(amount, money.BRL)})
+	}
+	assert.NoError(t, bundle.SetEbooks(ebooks))
+	return bundle
+}
+
+func TestBundle_SetEbooks_Validation(t *testing.T) {
+	bundle := models.NewBundle("Kit Completo", "Todos os ebooks", "Página", money.New(5000, money.BRL), models.Creator{Model: gorm.Model{ID: 7}})
+	own := &models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 7, Price: money.New(3000, money.BRL)}
+	other := &models.Ebook{Model: gorm.Model{ID: 2}, CreatorID: 8, Price: money.New(3000, money.BRL)}
+	dollar := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 7, Price: money.New(3000, money.USD)}
+
+	assert.ErrorIs(t, bundle.SetEbooks([]*models.Ebook{own}), models.ErrBundleTooFewEbooks)
+	assert.ErrorIs(t, bundle.SetEbooks([]*models.Ebook{own, other}), models.ErrBundleForeignEbook)
+	assert.ErrorIs(t, bundle.SetEbooks([]*models.Ebook{own, dollar}), models.ErrBundleMixedCurrency)
+	assert.Equal(t, "kit-completo", bundle.Slug)
+}
+
+func TestBundle_Savings(t *testing.T) {
+	bundle := newBundleWithEbooks(t, 5000, 3000, 4000)
+
+	assert.Equal(t, "R$ 70,00", bundle.GetOriginalValue())
+	assert.Equal(t, "R$ 20,00", bundle.GetSavings())
+}
+
+func TestBundle_Items_SplitPriceProportionally(t *testing.T) {
+	bundle := newBundleWithEbooks(t, 1000, 1000, 1000, 1000)
+
+	items := bundle.Items()
+
+	assert.Len(t, items, 3)
+	var total int64
+	for _, item := range items {
+		assert.Equal(t, uint(3), *item.BundleID)
+		total += item.UnitPrice
+	}
+	assert.Equal(t, int64(333), items[0].UnitPrice)
+	assert.Equal(t, int64(334), items[2].UnitPrice)
+	assert.Equal(t, int64(1000), total)
+}
+
+func TestNewBundleOrder(t *testing.T) {
+	bundle := newBundleWithEbooks(t, 5000, 3000, 4000)
+
+	order := models.NewBundleOrder(bundle, 9)
+
+	assert.Equal(t, int64(5000), order.Total)
+	assert.Equal(t, uint(3), *order.BundleID)
+	assert.Equal(t, uint(1), order.EbookID)
+	assert.Equal(t, []uint{1, 2}, order.EbookIDs())
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+var (
+	ErrCartMixedCreators   = errors.New("o carrinho só aceita e-books do mesmo autor")
+	ErrCartMixedCurrencies = errors.New("o carrinho só aceita e-books na mesma moeda")
+	ErrCartFixedPriceOnly  = errors.New("o carrinho só aceita e-books de preço fixo")
+)
+
+// Cart reúne os ebooks de um mesmo criador comprados em um único checkout
+type Cart struct {
+	CreatorID uint
+	Ebooks    []*Ebook
+}
+
+func NewCart() *Cart {
+	return &Cart{Ebooks: []*Ebook{}}
+}
+
+// Add inclui o ebook no carrinho; ebooks repetidos são ignorados
+func (c *Cart) Add(ebook *Ebook) error {
+	if c.Contains(ebook.ID) {
+		return nil
+	}
+	if !ebook.IsFixedPrice() {
+		return ErrCartFixedPriceOnly
+	}
+	if !c.IsEmpty() {
+		if ebook.CreatorID != c.CreatorID {
+			return ErrCartMixedCreators
+		}
+		if ebook.Price.Currency != c.Currency() {
+			return ErrCartMixedCurrencies
+		}
+	}
+
+	c.CreatorID = ebook.CreatorID
+	c.Ebooks = append(c.Ebooks, ebook)
+	return nil
+}
+
+func (c *Cart) Contains(ebookID uint) bool {
+	for _, ebook := range c.Ebooks {
+		if ebook.ID == ebookID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cart) IsEmpty() bool {
+	return len(c.Ebooks) == 0
+}
+
+func (c *Cart) EbookIDs() []uint {
+	ids := make([]uint, 0, len(c.Ebooks))
+	for _, ebook := range c.Ebooks {
+		ids = append(ids, ebook.ID)
+	}
+	return ids
+}
+
+func (c *Cart) Currency() money.Currency {
+	if c.IsEmpty() {
+		return money.BRL
+	}
+	return c.Ebooks[0].Price.Currency
+}
+
+func (c *Cart) Total() money.Money {
+	total := money.New(0, c.Currency())
+	now := time.Now()
+	for _, ebook := range c.Ebooks {
+		total = total.Add(ebook.CurrentPrice(now))
+	}
+	return total
+}
+
+func (c *Cart) GetTotal() string {
+	return c.Total().Format()
+}
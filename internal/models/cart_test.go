@@ -0,0 +1,40 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCart_Add_RejectsOtherCreatorAndIgnoresDuplicates(t *testing.T) {
+	cart := models.NewCart()
+	first := &models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 7, Price: money.New(1990, money.BRL)}
+	second := &models.Ebook{Model: gorm.Model{ID: 2}, CreatorID: 7, Price: money.New(2500, money.BRL)}
+	other := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 8, Price: money.New(1000, money.BRL)}
+
+	assert.NoError(t, cart.Add(first))
+	assert.NoError(t, cart.Add(second))
+	assert.NoError(t, cart.Add(first))
+	assert.ErrorIs(t, cart.Add(other), models.ErrCartMixedCreators)
+
+	assert.Equal(t, []uint{1, 2}, cart.EbookIDs())
+	assert.Equal(t, uint(7), cart.CreatorID)
+	assert.Equal(t, int64(4490), cart.Total().Amount)
+	assert.Equal(t, "R$ 44,90", cart.GetTotal())
+}
+
+func TestNewCartOrder_CreatesOneItemPerEbook(t *testing.T) {
+	cart := models.NewCart()
+	cart.Add(&models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 7, Title: "A", Price: money.New(1000, money.BRL)})
+	cart.Add(&models.Ebook{Model: gorm.Model{ID: 2}, CreatorID: 7, Title: "B", Price: money.New(3000, money.BRL)})
+
+	order := models.NewCartOrder(cart, 5)
+
+	assert.Equal(t, int64(4000), order.Total)
+	assert.Equal(t, uint(1), order.EbookID)
+	assert.Len(t, order.Items, 2)
+	assert.Equal(t, []uint{1, 2}, order.EbookIDs())
+}
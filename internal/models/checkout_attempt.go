@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CheckoutAttemptOpen      = "open"      // comprador validou os dados e foi ao pagamento
+	CheckoutAttemptAbandoned = "abandoned" // sessão expirou ou passou do prazo sem pagamento
+	CheckoutAttemptRecovered = "recovered" // pago depois de abandonado
+	CheckoutAttemptCompleted = "completed" // pago sem precisar de recuperação
+)
+
+// CheckoutAttempt guarda os dados do comprador informados no checkout para que a
+// compra possa ser recuperada caso o pagamento não seja concluído
+type CheckoutAttempt struct {
+	gorm.Model
+	CreatorID       uint       `json:"creator_id" gorm:"index"`
+	EbookID         uint       `json:"ebook_id" gorm:"index"`
+	Ebook           Ebook      `gorm:"foreignKey:EbookID"`
+	OrderID         *uint      `json:"order_id" gorm:"index"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email" gorm:"index"`
+	Phone           string     `json:"phone"`
+	Token           string     `json:"token" gorm:"uniqueIndex"` // usado no link de recuperação
+	Status          string     `json:"status" gorm:"index;default:'open'"`
+	EmailsSent      int        `json:"emails_sent"`
+	DiscountPercent int        `json:"discount_percent"` // cupom oferecido na sequência de recuperação
+	AbandonedAt     *time.Time `json:"abandoned_at"`
+	LastEmailAt     *time.Time `json:"last_email_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+}
+
+func NewCheckoutAttempt(ebook *Ebook, name, email, phone, token string) *CheckoutAttempt {
+	return &CheckoutAttempt{
+		CreatorID: ebook.CreatorID,
+		EbookID:   ebook.ID,
+		Name:      name,
+		Email:     email,
+		Phone:     phone,
+		Token:     token,
+		Status:    CheckoutAttemptOpen,
+	}
+}
+
+// IsPending indica se o comprador ainda não pagou
+func (a *CheckoutAttempt) IsPending() bool {
+	return a.Status == CheckoutAttemptOpen || a.Status == CheckoutAttemptAbandoned
+}
+
+// Abandon marca a tentativa como abandonada; só tentativas abertas mudam de status
+func (a *CheckoutAttempt) Abandon(now time.Time) bool {
+	if a.Status != CheckoutAttemptOpen {
+		return false
+	}
+	a.Status = CheckoutAttemptAbandoned
+	a.AbandonedAt = &now
+	return true
+}
+
+// NextEmailDue indica se o próximo e-mail da sequência já deve ser enviado. Cada item
+// de scheduleHours é o tempo, em horas, contado a partir do abandono.
+func (a *CheckoutAttempt) NextEmailDue(now time.Time, scheduleHours []int) bool {
+	if a.Status != CheckoutAttemptAbandoned || a.AbandonedAt == nil || a.EmailsSent >= len(scheduleHours) {
+		return false
+	}
+	due := a.AbandonedAt.Add(time.Duration(scheduleHours[a.EmailsSent]) * time.Hour)
+	return !now.Before(due)
+}
+
+// IsLastEmail indica se o próximo e-mail é o último da sequência
+func (a *CheckoutAttempt) IsLastEmail(scheduleHours []int) bool {
+	return a.EmailsSent == len(scheduleHours)-1
+}
+
+func (a *CheckoutAttempt) RegisterEmail(now time.Time) {
+	a.EmailsSent++
+	a.LastEmailAt = &now
+}
+
+// CheckoutRecoveryStats resume a recuperação de checkouts abandonados do criador
+type CheckoutRecoveryStats struct {
+	Abandoned int64 // tentativas abandonadas, incluindo as recuperadas depois
+	Recovered int64
+}
+
+// Rate é o percentual de checkouts abandonados que foram pagos depois
+func (s CheckoutRecoveryStats) Rate() float64 {
+	if s.Abandoned == 0 {
+		return 0
+	}
+	return float64(s.Recovered*10000/s.Abandoned) / 100
+}
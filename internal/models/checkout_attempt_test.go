@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutAttempt_NextEmailDue(t *testing.T) {
+	schedule := []int{1, 24}
+	attempt := &CheckoutAttempt{Status: CheckoutAttemptOpen}
+	now := time.Now()
+
+	assert.False(t, attempt.NextEmailDue(now, schedule), "tentativa aberta não recebe e-mail")
+
+	assert.True(t, attempt.Abandon(now.Add(-2*time.Hour)))
+	assert.False(t, attempt.Abandon(now), "só tentativas abertas são abandonadas")
+	assert.True(t, attempt.NextEmailDue(now, schedule))
+	assert.False(t, attempt.IsLastEmail(schedule))
+
+	attempt.RegisterEmail(now)
+	assert.False(t, attempt.NextEmailDue(now, schedule))
+	assert.True(t, attempt.IsLastEmail(schedule))
+	assert.True(t, attempt.NextEmailDue(now.Add(23*time.Hour), schedule))
+
+	attempt.RegisterEmail(now)
+	assert.False(t, attempt.NextEmailDue(now.Add(100*time.Hour), schedule), "sequência encerrada")
+}
+
+func TestCheckoutRecoveryStats_Rate(t *testing.T) {
+	assert.Equal(t, 0.0, CheckoutRecoveryStats{}.Rate())
+	assert.Equal(t, 33.33, CheckoutRecoveryStats{Abandoned: 3, Recovered: 1}.Rate())
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -10,13 +11,18 @@ import (
 type Client struct {
 	gorm.Model
 	Name      string     `json:"name"`
-	CPF       string     `gorm:"unique" json:"cpf"`
+	CPF       string     `gorm:"uniqueIndex:idx_clients_cpf,where:cpf <> ''" json:"cpf"` // leads de ebooks gratuitos não informam CPF
 	Birthdate string     `json:"birthdate"`
 	Email     string     `json:"email"`
 	Phone     string     `json:"phone"`
 	Validated bool       `json:"validated"`
 	Creators  []*Creator `gorm:"many2many:client_creators"`
 	Purchases []*Purchase
+
+	// Consentimentos registrados na captura de leads
+	EmailConfirmedAt   *time.Time `json:"email_confirmed_at"` // confirmação do e-mail (double opt-in)
+	MarketingConsent   bool       `json:"marketing_consent"`
+	MarketingConsentAt *time.Time `json:"marketing_consent_at"`
 }
 
 func NewClient(name, cpf, birthDate, email, phone string, creator *Creator) *Client {
@@ -60,8 +66,23 @@ func (c *Client) TotalDownloadsByEbook(ebookID uint) int {
 	return count
 }
 
+// ConfirmEmail registra a confirmação do e-mail e, se autorizado, o consentimento
+// para comunicações de marketing. O consentimento dado antes não é revogado.
+func (c *Client) ConfirmEmail(marketingConsent bool, now time.Time) {
+	if c.EmailConfirmedAt == nil {
+		c.EmailConfirmedAt = &now
+	}
+	if marketingConsent && !c.MarketingConsent {
+		c.MarketingConsent = true
+		c.MarketingConsentAt = &now
+	}
+}
+
 func (c *Client) GetBirthdateBR() string {
 	partsDate := strings.Split(c.Birthdate, "-")
+	if len(partsDate) != 3 {
+		return ""
+	}
 	return fmt.Sprintf("%s/%s/%s", partsDate[2], partsDate[1], partsDate[0])
 }
 
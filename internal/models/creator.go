@@ -1,11 +1,19 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
 )
 
+// Tamanho máximo do texto da marca d'água personalizada
+const MaxWatermarkTextLength = 60
+
+var ErrWatermarkTextTooLong = fmt.Errorf("a marca d'água deve ter no máximo %d caracteres", MaxWatermarkTextLength)
+
 type Creator struct {
 	gorm.Model
 	Name      string    `json:"name"`
@@ -17,6 +25,24 @@ type Creator struct {
 	User      User      `gorm:"foreignKey:UserID"`
 	Ebooks    []Ebook
 	Clients   []*Client `gorm:"many2many:client_creators"`
+
+	// Suspende sozinho o download de compras com sinais de link compartilhado
+	AutoSuspendSharing bool `json:"auto_suspend_sharing"`
+
+	// Texto incluído na marca d'água dos PDFs, nos planos com marca d'água personalizada
+	WatermarkText string `json:"watermark_text"`
+
+	// Perfil público da vitrine em /c/{slug}
+	Slug         string `json:"slug" gorm:"uniqueIndex:idx_creators_slug,where:slug <> ''"`
+	Bio          string `json:"bio"`
+	Avatar       string `json:"avatar"`
+	WebsiteURL   string `json:"website_url"`
+	InstagramURL string `json:"instagram_url"`
+	YouTubeURL   string `json:"youtube_url" gorm:"column:youtube_url"`
+	TikTokURL    string `json:"tiktok_url" gorm:"column:tiktok_url"`
+
+	// Domínio próprio já verificado, copiado de CustomDomain para montar links
+	Domain string `json:"domain"`
 }
 
 func NewCreator(name, email, phone, cpf string, birthDate time.Time, userID uint) *Creator {
@@ -30,6 +56,17 @@ func NewCreator(name, email, phone, cpf string, birthDate time.Time, userID uint
 	}
 }
 
+// SetWatermarkText define o texto da marca d'água personalizada; vazio volta à
+// marca d'água padrão, só com os dados do comprador
+func (c *Creator) SetWatermarkText(text string) error {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > MaxWatermarkTextLength {
+		return ErrWatermarkTextTooLong
+	}
+	c.WatermarkText = text
+	return nil
+}
+
 func (c *Creator) GetEbooks() []Ebook {
 	return c.Ebooks
 }
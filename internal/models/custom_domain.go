@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DomainVerificationRecord é o subdomínio onde o criador publica o TXT de verificação
+const DomainVerificationRecord = "_verificacao"
+
+var (
+	ErrInvalidDomain     = errors.New("informe um domínio válido, como loja.seusite.com.br")
+	ErrDomainTaken       = errors.New("este domínio já está em uso")
+	ErrDomainNotVerified = errors.New("registro TXT de verificação não encontrado")
+)
+
+var domainLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// CustomDomain liga um domínio próprio à vitrine e às páginas de venda do
+// criador. O domínio só passa a responder depois de verificado por DNS, e só
+// o verificado reserva o host: cadastros pendentes não bloqueiam outros criadores.
+type CustomDomain struct {
+	gorm.Model
+	CreatorID  uint       `json:"creator_id" gorm:"uniqueIndex"`
+	Creator    Creator    `gorm:"foreignKey:CreatorID"`
+	Host       string     `json:"host" gorm:"uniqueIndex:idx_custom_domains_verified_host,where:verified_at IS NOT NULL"`
+	Token      string     `json:"-"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CheckedAt  *time.Time `json:"checked_at"`
+}
+
+func NewCustomDomain(creatorID uint, host, token string) (*CustomDomain, error) {
+	host, err := NormalizeDomain(host)
+	if err != nil {
+		return nil, err
+	}
+	return &CustomDomain{
+		CreatorID: creatorID,
+		Host:      host,
+		Token:     token,
+	}, nil
+}
+
+// NormalizeDomain aceita o domínio como o criador costuma colar (com esquema,
+// porta ou barra final) e devolve só o host em minúsculas
+func NormalizeDomain(raw string) (string, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if strings.Contains(raw, "://") {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return "", ErrInvalidDomain
+		}
+		raw = parsed.Host
+	}
+	raw = strings.SplitN(raw, "/", 2)[0]
+	if host, _, err := net.SplitHostPort(raw); err == nil {
+		raw = host
+	}
+	host := strings.TrimSuffix(raw, ".")
+
+	if len(host) > 253 || net.ParseIP(host) != nil {
+		return "", ErrInvalidDomain
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+	for _, label := range labels {
+		if !domainLabelPattern.MatchString(label) {
+			return "", ErrInvalidDomain
+		}
+	}
+	return host, nil
+}
+
+func (d *CustomDomain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// RecordName é o nome completo do registro TXT que o criador deve criar
+func (d *CustomDomain) RecordName() string {
+	return DomainVerificationRecord + "." + d.Host
+}
+
+// RecordValue é o conteúdo esperado no registro TXT
+func (d *CustomDomain) RecordValue() string {
+	return "verificacao=" + d.Token
+}
+
+// Verify confere os registros TXT encontrados no DNS e marca o domínio como
+// verificado quando algum deles traz o token
+func (d *CustomDomain) Verify(records []string, now time.Time) error {
+	d.CheckedAt = &now
+	for _, record := range records {
+		if strings.TrimSpace(record) == d.RecordValue() {
+			if d.VerifiedAt == nil {
+				d.VerifiedAt = &now
+			}
+			return nil
+		}
+	}
+	return ErrDomainNotVerified
+}
+
+// SiteURL devolve a base dos links públicos do criador: o domínio próprio
+// verificado, com o mesmo esquema da base padrão, ou a própria base padrão
+func (c *Creator) SiteURL(defaultURL string) string {
+	if c == nil || c.Domain == "" {
+		return defaultURL
+	}
+	scheme := "https"
+	if parsed, err := url.Parse(defaultURL); err == nil && parsed.Scheme != "" {
+		scheme = parsed.Scheme
+	}
+	return scheme + "://" + c.Domain
+}
@@ -0,0 +1,55 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"loja.autor.com.br", "loja.autor.com.br"},
+		{"  Loja.Autor.com.br. ", "loja.autor.com.br"},
+		{"https://loja.autor.com.br/", "loja.autor.com.br"},
+		{"loja.autor.com.br:8443/livros", "loja.autor.com.br"},
+	}
+	for _, tt := range tests {
+		host, err := models.NormalizeDomain(tt.raw)
+		assert.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.want, host)
+	}
+
+	for _, raw := range []string{"", "localhost", "192.168.0.1", "loja_autor.com", "-loja.autor.com", "loja..com"} {
+		_, err := models.NormalizeDomain(raw)
+		assert.ErrorIs(t, err, models.ErrInvalidDomain, raw)
+	}
+}
+
+func TestCustomDomain_Verify(t *testing.T) {
+	domain, err := models.NewCustomDomain(1, "https://loja.autor.com.br", "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "_verificacao.loja.autor.com.br", domain.RecordName())
+	assert.Equal(t, "verificacao=abc123", domain.RecordValue())
+
+	now := time.Now()
+	assert.ErrorIs(t, domain.Verify([]string{"v=spf1 -all", "verificacao=outro"}, now), models.ErrDomainNotVerified)
+	assert.False(t, domain.IsVerified())
+	assert.Equal(t, now, *domain.CheckedAt)
+
+	assert.NoError(t, domain.Verify([]string{"verificacao=abc123"}, now))
+	assert.True(t, domain.IsVerified())
+}
+
+func TestCreator_SiteURL(t *testing.T) {
+	creator := &models.Creator{}
+	assert.Equal(t, "http://localhost:8080", creator.SiteURL("http://localhost:8080"))
+
+	creator.Domain = "loja.autor.com.br"
+	assert.Equal(t, "http://loja.autor.com.br", creator.SiteURL("http://localhost:8080"))
+	assert.Equal(t, "https://loja.autor.com.br", creator.SiteURL("https://docffy.com.br"))
+}
@@ -8,4 +8,22 @@ type DownloadLog struct {
 	gorm.Model
 	PurchaseID uint      `json:"purchase_id"`
 	Purchase   *Purchase `gorm:"foreignKey:PurchaseID"`
+
+	FileID      uint   `json:"file_id"`
+	FileVersion int    `json:"file_version"`
+	Free        bool   `json:"free"` // atualização de versão, fora do limite de downloads
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	Bytes       int64  `json:"bytes"` // tamanho entregue, já com a marca d'água
+}
+
+// DownloadRequest identifica quem baixou o arquivo e quanto foi entregue
+type DownloadRequest struct {
+	IP        string
+	UserAgent string
+	Bytes     int64
+}
+
+func (d *DownloadLog) GetBytesFormatted() string {
+	return formatBytes(d.Bytes)
 }
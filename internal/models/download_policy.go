@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Limites aceitos na política de entrega
+const (
+	MaxPolicyDownloads  = 1000
+	MaxPolicyExpiryDays = 3650
+	MaxPolicyDevices    = 100
+)
+
+var (
+	ErrInvalidMaxDownloads = fmt.Errorf("o limite de downloads deve ficar entre 0 e %d", MaxPolicyDownloads)
+	ErrInvalidExpiryDays   = fmt.Errorf("o prazo do link deve ficar entre 0 e %d dias", MaxPolicyExpiryDays)
+	ErrInvalidMaxDevices   = fmt.Errorf("o limite de dispositivos deve ficar entre 0 e %d", MaxPolicyDevices)
+	ErrDeviceLimitReached  = errors.New("não é possível realizar o download, limite de dispositivos atingido")
+	ErrInvalidExtension    = errors.New("informe os dias ou downloads a acrescentar")
+)
+
+// DownloadPolicy define como o ebook é entregue a cada comprador. Zero em
+// qualquer campo significa sem limite.
+type DownloadPolicy struct {
+	MaxDownloads int `json:"max_downloads"`
+	ExpiryDays   int `json:"expiry_days"` // validade do link, contada da compra
+	MaxDevices   int `json:"max_devices"` // IPs distintos que podem baixar
+}
+
+func (d DownloadPolicy) Validate() error {
+	if d.MaxDownloads < 0 || d.MaxDownloads > MaxPolicyDownloads {
+		return ErrInvalidMaxDownloads
+	}
+	if d.ExpiryDays < 0 || d.ExpiryDays > MaxPolicyExpiryDays {
+		return ErrInvalidExpiryDays
+	}
+	if d.MaxDevices < 0 || d.MaxDevices > MaxPolicyDevices {
+		return ErrInvalidMaxDevices
+	}
+	return nil
+}
+
+func (d DownloadPolicy) IsUnlimited() bool {
+	return d.MaxDownloads == 0 && d.ExpiryDays == 0 && d.MaxDevices == 0
+}
+
+// Apply copia os limites para a compra. Compras de assinantes mantêm o fim do
+// período pago quando ele vem antes do prazo da política.
+func (d DownloadPolicy) Apply(purchase *Purchase, now time.Time) {
+	if d.MaxDownloads > 0 {
+		purchase.DownloadLimit = d.MaxDownloads
+	}
+	if d.ExpiryDays > 0 {
+		expiresAt := now.AddDate(0, 0, d.ExpiryDays)
+		if purchase.MemberID == nil || purchase.ExpiresAt.IsZero() || expiresAt.Before(purchase.ExpiresAt) {
+			purchase.ExpiresAt = expiresAt
+		}
+	}
+	purchase.DeviceLimit = d.MaxDevices
+}
@@ -0,0 +1,85 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadPolicy_Validate(t *testing.T) {
+	assert.NoError(t, models.DownloadPolicy{}.Validate())
+	assert.NoError(t, models.DownloadPolicy{MaxDownloads: 5, ExpiryDays: 30, MaxDevices: 2}.Validate())
+	assert.ErrorIs(t, models.DownloadPolicy{MaxDownloads: -1}.Validate(), models.ErrInvalidMaxDownloads)
+	assert.ErrorIs(t, models.DownloadPolicy{ExpiryDays: models.MaxPolicyExpiryDays + 1}.Validate(), models.ErrInvalidExpiryDays)
+	assert.ErrorIs(t, models.DownloadPolicy{MaxDevices: -2}.Validate(), models.ErrInvalidMaxDevices)
+}
+
+func TestDownloadPolicy_Apply(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	policy := models.DownloadPolicy{MaxDownloads: 5, ExpiryDays: 30, MaxDevices: 2}
+
+	purchase := models.NewPurchase(1, 2)
+	policy.Apply(purchase, now)
+	assert.Equal(t, 5, purchase.DownloadLimit)
+	assert.Equal(t, now.AddDate(0, 0, 30), purchase.ExpiresAt)
+	assert.Equal(t, 2, purchase.DeviceLimit)
+
+	// Sem limites a compra continua como criada
+	unlimited := models.NewPurchase(1, 2)
+	models.DownloadPolicy{}.Apply(unlimited, now)
+	assert.Equal(t, -1, unlimited.DownloadLimit)
+	assert.True(t, unlimited.ExpiresAt.IsZero())
+
+	// O acesso do assinante não passa do período pago
+	memberID := uint(9)
+	periodEnd := now.AddDate(0, 0, 10)
+	member := models.NewPurchase(1, 2)
+	member.MemberID = &memberID
+	member.ExpiresAt = periodEnd
+	policy.Apply(member, now)
+	assert.Equal(t, periodEnd, member.ExpiresAt)
+}
+
+func TestPurchase_AllowsDevice(t *testing.T) {
+	now := time.Now()
+	file := &models.File{Version: 1}
+	purchase := models.NewPurchase(1, 2)
+	purchase.DeviceLimit = 2
+
+	purchase.UseFileDownload(file, models.DownloadRequest{IP: "10.0.0.1"})
+	purchase.UseFileDownload(file, models.DownloadRequest{IP: "10.0.0.2"})
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, purchase.Devices())
+	assert.True(t, purchase.AllowsDevice("10.0.0.1"))
+	assert.False(t, purchase.AllowsDevice("10.0.0.3"))
+
+	for i := range purchase.Downloads {
+		purchase.Downloads[i].CreatedAt = now.Add(-time.Hour)
+	}
+	purchase.ResetAllowance(now)
+	assert.Equal(t, 0, purchase.DownloadsUsed)
+	assert.Empty(t, purchase.Devices())
+	assert.True(t, purchase.AllowsDevice("10.0.0.3"))
+}
+
+func TestPurchase_Extend(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	purchase := models.NewPurchase(1, 2)
+	purchase.DownloadLimit = 3
+	purchase.DownloadsUsed = 3
+	purchase.ExpiresAt = now.AddDate(0, 0, -2)
+
+	assert.NoError(t, purchase.Extend(7, 2, now))
+	assert.Equal(t, now.AddDate(0, 0, 7), purchase.ExpiresAt)
+	assert.Equal(t, 5, purchase.DownloadLimit)
+	assert.Equal(t, 2, purchase.RemainingDownloads())
+
+	assert.ErrorIs(t, purchase.Extend(0, 0, now), models.ErrInvalidExtension)
+	assert.ErrorIs(t, purchase.Extend(-1, 3, now), models.ErrInvalidExtension)
+
+	unlimited := models.NewPurchase(1, 2)
+	assert.NoError(t, unlimited.Extend(7, 2, now))
+	assert.True(t, unlimited.ExpiresAt.IsZero())
+	assert.Equal(t, -1, unlimited.RemainingDownloads())
+}
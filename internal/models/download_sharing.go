@@ -0,0 +1,141 @@
+package models
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"time"
+)
+
+// Janela e pontuação usadas para detectar links de download compartilhados
+const (
+	SharingWindow          = 24 * time.Hour
+	SuspiciousSharingScore = 60
+)
+
+var ErrPurchaseSuspended = errors.New("não é possível realizar o download, o acesso foi suspenso pelo criador")
+
+// SharingReport resume os downloads da compra dentro da janela de análise
+type SharingReport struct {
+	Downloads  int
+	IPs        int
+	Networks   int
+	UserAgents int
+	Score      int
+	// NetworkScore ignora o User-Agent, que qualquer um pode forjar; só ela
+	// pode suspender a compra automaticamente
+	NetworkScore int
+}
+
+func (r SharingReport) IsSuspicious() bool {
+	return r.Score >= SuspiciousSharingScore
+}
+
+// SharingReport pontua os downloads das últimas SharingWindow horas. Um leitor
+// costuma usar um ou dois aparelhos numa mesma rede; muitos IPs, redes e
+// navegadores diferentes indicam que o link foi repassado. Sem base de
+// geolocalização, redes distintas (/16 no IPv4, /32 no IPv6) servem de
+// aproximação para regiões diferentes.
+func (p *Purchase) SharingReport(now time.Time) SharingReport {
+	since := now.Add(-SharingWindow)
+	if p.ReviewedAt != nil && p.ReviewedAt.After(since) {
+		since = *p.ReviewedAt
+	}
+
+	ips := make(map[string]bool)
+	networks := make(map[string]bool)
+	agents := make(map[string]bool)
+	report := SharingReport{}
+	for _, download := range p.Downloads {
+		// Downloads ainda não gravados entram como feitos agora
+		if !download.CreatedAt.IsZero() && !download.CreatedAt.After(since) {
+			continue
+		}
+		report.Downloads++
+		if download.IP != "" {
+			ips[download.IP] = true
+			networks[ipNetwork(download.IP)] = true
+		}
+		if download.UserAgent != "" {
+			agents[download.UserAgent] = true
+		}
+	}
+	report.IPs = len(ips)
+	report.Networks = len(networks)
+	report.UserAgents = len(agents)
+
+	report.NetworkScore = 15*max(report.IPs-2, 0) +
+		20*max(report.Networks-1, 0) +
+		2*max(report.Downloads-10, 0)
+	report.Score = report.NetworkScore + 10*max(report.UserAgents-2, 0)
+	return report
+}
+
+// CheckSharing atualiza a pontuação e envia a compra para revisão quando passa do
+// limite. Com a suspensão automática ligada, os downloads param na hora, mas só
+// quando os IPs da conexão bastam para o limite: navegadores diferentes vêm de
+// um cabeçalho que o próprio visitante controla. Uma compra já na fila ainda é
+// suspensa se os IPs passarem do limite depois. Retorna true quando a compra
+// entra na fila ou é suspensa.
+func (p *Purchase) CheckSharing(autoSuspend bool, now time.Time) bool {
+	report := p.SharingReport(now)
+	p.SharingScore = max(p.SharingScore, report.Score)
+	if !report.IsSuspicious() {
+		return false
+	}
+
+	changed := false
+	if p.FlaggedAt == nil {
+		p.FlaggedAt = &now
+		changed = true
+	}
+	if autoSuspend && p.SuspendedAt == nil && report.NetworkScore >= SuspiciousSharingScore {
+		p.SuspendedAt = &now
+		changed = true
+	}
+	return changed
+}
+
+func (p *Purchase) IsSuspended() bool {
+	return p.SuspendedAt != nil
+}
+
+// Suspend bloqueia os downloads da compra
+func (p *Purchase) Suspend(now time.Time) {
+	if p.SuspendedAt == nil {
+		p.SuspendedAt = &now
+	}
+}
+
+// Dismiss tira a compra da fila e libera os downloads. Os downloads anteriores à
+// revisão deixam de contar na pontuação.
+func (p *Purchase) Dismiss(now time.Time) {
+	p.FlaggedAt = nil
+	p.SuspendedAt = nil
+	p.ReviewedAt = &now
+	p.SharingScore = 0
+}
+
+// RecentDownloads lista os últimos downloads, do mais novo para o mais antigo
+func (p *Purchase) RecentDownloads(limit int) []DownloadLog {
+	downloads := make([]DownloadLog, len(p.Downloads))
+	copy(downloads, p.Downloads)
+	sort.SliceStable(downloads, func(i, j int) bool {
+		return downloads[i].CreatedAt.After(downloads[j].CreatedAt)
+	})
+	if len(downloads) > limit {
+		downloads = downloads[:limit]
+	}
+	return downloads
+}
+
+func ipNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(32, 128)).String()
+}
@@ -0,0 +1,137 @@
+package models_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func download(ip, agent string, at time.Time) models.DownloadLog {
+	return models.DownloadLog{Model: gorm.Model{CreatedAt: at}, IP: ip, UserAgent: agent}
+}
+
+func TestPurchase_SharingReport_SingleReader(t *testing.T) {
+	now := time.Now()
+	purchase := models.NewPurchase(1, 2)
+	purchase.Downloads = []models.DownloadLog{
+		download("187.10.1.1", "Chrome", now.Add(-2*time.Hour)),
+		download("187.10.9.9", "Safari iPhone", now.Add(-time.Hour)),
+		download("187.10.1.1", "Chrome", now),
+	}
+
+	report := purchase.SharingReport(now)
+
+	assert.Equal(t, 3, report.Downloads)
+	assert.Equal(t, 2, report.IPs)
+	assert.Equal(t, 1, report.Networks)
+	assert.Equal(t, 0, report.Score)
+	assert.False(t, report.IsSuspicious())
+}
+
+func TestPurchase_SharingReport_SharedLink(t *testing.T) {
+	now := time.Now()
+	purchase := models.NewPurchase(1, 2)
+	for i := 0; i < 6; i++ {
+		purchase.Downloads = append(purchase.Downloads,
+			download(fmt.Sprintf("%d.20.0.1", 100+i), fmt.Sprintf("Agent %d", i), now.Add(-time.Duration(i)*time.Minute)))
+	}
+	// Downloads fora da janela não contam
+	purchase.Downloads = append(purchase.Downloads, download("8.8.8.8", "Old", now.Add(-48*time.Hour)))
+
+	report := purchase.SharingReport(now)
+
+	assert.Equal(t, 6, report.Downloads)
+	assert.Equal(t, 6, report.Networks)
+	assert.True(t, report.IsSuspicious())
+}
+
+func TestPurchase_CheckSharing(t *testing.T) {
+	now := time.Now()
+	shared := func() *models.Purchase {
+		purchase := models.NewPurchase(1, 2)
+		for i := 0; i < 5; i++ {
+			purchase.Downloads = append(purchase.Downloads, download(fmt.Sprintf("%d.1.1.1", 10+i), "", now))
+		}
+		return purchase
+	}
+
+	flagged := shared()
+	assert.True(t, flagged.CheckSharing(false, now))
+	assert.NotNil(t, flagged.FlaggedAt)
+	assert.False(t, flagged.IsSuspended())
+	// Já na fila, não é marcada de novo
+	assert.False(t, flagged.CheckSharing(false, now))
+
+	suspended := shared()
+	assert.True(t, suspended.CheckSharing(true, now))
+	assert.True(t, suspended.IsSuspended())
+
+	// Depois da revisão, só os downloads novos contam
+	suspended.Dismiss(now)
+	assert.False(t, suspended.IsSuspended())
+	assert.Nil(t, suspended.FlaggedAt)
+	assert.False(t, suspended.CheckSharing(true, now.Add(time.Minute)))
+}
+
+func TestPurchase_CheckSharing_ForgeableSignalsOnlyFlag(t *testing.T) {
+	now := time.Now()
+	purchase := models.NewPurchase(1, 2)
+	// Mesma rede, User-Agent trocado a cada download
+	for i := 0; i < 9; i++ {
+		purchase.Downloads = append(purchase.Downloads,
+			download(fmt.Sprintf("187.10.1.%d", 1+i%3), fmt.Sprintf("Agent %d", i), now))
+	}
+
+	report := purchase.SharingReport(now)
+	assert.True(t, report.IsSuspicious())
+	assert.Less(t, report.NetworkScore, models.SuspiciousSharingScore)
+
+	assert.True(t, purchase.CheckSharing(true, now))
+	assert.NotNil(t, purchase.FlaggedAt)
+	assert.False(t, purchase.IsSuspended())
+}
+
+func TestPurchase_CheckSharing_SuspendsFlaggedPurchaseOnceIPsCrossLimit(t *testing.T) {
+	now := time.Now()
+	purchase := models.NewPurchase(1, 2)
+	// Primeiro só os User-Agents chamam atenção: vai para a fila sem suspender
+	for i := 0; i < 9; i++ {
+		purchase.Downloads = append(purchase.Downloads,
+			download(fmt.Sprintf("187.10.1.%d", 1+i%3), fmt.Sprintf("Agent %d", i), now))
+	}
+	assert.True(t, purchase.CheckSharing(true, now))
+	flaggedAt := purchase.FlaggedAt
+	assert.False(t, purchase.IsSuspended())
+
+	// Depois o link aparece em outras redes
+	later := now.Add(time.Hour)
+	for i := 0; i < 5; i++ {
+		purchase.Downloads = append(purchase.Downloads, download(fmt.Sprintf("%d.1.1.1", 10+i), "", later))
+	}
+	assert.GreaterOrEqual(t, purchase.SharingReport(later).NetworkScore, models.SuspiciousSharingScore)
+
+	assert.True(t, purchase.CheckSharing(true, later))
+	assert.True(t, purchase.IsSuspended())
+	assert.Equal(t, flaggedAt, purchase.FlaggedAt)
+	assert.False(t, purchase.CheckSharing(true, later))
+}
+
+func TestPurchase_RecentDownloads(t *testing.T) {
+	now := time.Now()
+	purchase := models.NewPurchase(1, 2)
+	purchase.Downloads = []models.DownloadLog{
+		download("1.1.1.1", "", now.Add(-2*time.Hour)),
+		download("2.2.2.2", "", now),
+		download("3.3.3.3", "", now.Add(-time.Hour)),
+	}
+
+	recent := purchase.RecentDownloads(2)
+
+	assert.Len(t, recent, 2)
+	assert.Equal(t, "2.2.2.2", recent[0].IP)
+	assert.Equal(t, "3.3.3.3", recent[1].IP)
+}
@@ -1,25 +1,69 @@
 package models
 
 import (
+	"errors"
 	"regexp"
 	"strings"
+	"time"
 
-	"github.com/anglesson/simple-web-server/pkg/utils"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"gorm.io/gorm"
 )
 
+const (
+	PricingFixed   = "fixed"   // preço definido pelo criador
+	PricingMinimum = "minimum" // pague quanto quiser, a partir do preço mínimo
+	PricingFree    = "free"    // entregue gratuitamente em troca do e-mail
+)
+
+var (
+	ErrInvalidPricingMode    = errors.New("modalidade de preço inválida")
+	ErrInvalidEbookPrice     = errors.New("o preço deve ser maior que zero")
+	ErrSuggestedBelowMinimum = errors.New("o valor sugerido não pode ser menor que o valor mínimo")
+	ErrPriceBelowMinimum     = errors.New("o valor informado é menor que o valor mínimo")
+	ErrEbookIsFree           = errors.New("este ebook é gratuito")
+)
+
 type Ebook struct {
 	gorm.Model
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	SalesPage   string  `json:"sales_page"` // Conteúdo da página de vendas
-	Value       float64 `json:"value"`
-	Status      bool    `json:"status"`
-	Image       string  `json:"image"`
-	Slug        string  `json:"slug" gorm:"uniqueIndex"` // URL amigável
-	CreatorID   uint    `json:"creator_id"`
-	Creator     Creator `gorm:"foreignKey:CreatorID"`
-	Files       []*File `gorm:"many2many:ebook_files;"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	SalesPage   string      `json:"sales_page"` // Conteúdo da página de vendas
+	Price       money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Status      bool        `json:"status"`
+	Image       string      `json:"image"`
+	Slug        string      `json:"slug" gorm:"uniqueIndex"` // URL amigável
+	CreatorID   uint        `json:"creator_id"`
+	Creator     Creator     `gorm:"foreignKey:CreatorID"`
+	Files       []*File     `gorm:"many2many:ebook_files;"`
+
+	// Ordem e prazo de liberação de cada arquivo, gravados na mesma tabela de Files
+	FileSchedule []*EbookFile `gorm:"foreignKey:EbookID"`
+
+	// Modalidade de preço. Em "pague quanto quiser", Price é o valor mínimo e
+	// SuggestedPrice o valor sugerido ao comprador.
+	PricingMode    string      `json:"pricing_mode" gorm:"default:'fixed'"`
+	SuggestedPrice money.Money `json:"suggested_price" gorm:"embedded;embeddedPrefix:suggested_price_"`
+
+	// Preços promocionais agendados; fora deles vale Price
+	PricePeriods []*EbookPricePeriod `gorm:"foreignKey:EbookID"`
+
+	// Limites aplicados às compras no momento em que são criadas
+	DownloadPolicy DownloadPolicy `json:"download_policy" gorm:"embedded;embeddedPrefix:download_"`
+
+	// Último aviso de nova versão enviado aos compradores
+	VersionNotifiedAt *time.Time `json:"version_notified_at"`
+
+	// Lançamento agendado: o agendador liga Status em PublishAt e desliga em
+	// UnpublishAt. Em pré-venda o ebook é vendido sem arquivos e as compras são
+	// entregues quando o criador libera o lançamento.
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	PreSale     bool       `json:"pre_sale"`
+	ReleasedAt  *time.Time `json:"released_at"`
+
+	// Categoria usada para filtrar a vitrine do criador
+	Category string `json:"category"`
 
 	// Campos para SEO e marketing
 	MetaTitle       string `json:"meta_title"`
@@ -31,12 +75,13 @@ type Ebook struct {
 	Sales int `json:"sales" gorm:"default:0"`
 }
 
-func NewEbook(title, description, salesPage string, value float64, creator Creator) *Ebook {
+func NewEbook(title, description, salesPage string, price money.Money, creator Creator) *Ebook {
 	return &Ebook{
 		Title:       title,
 		Description: description,
 		SalesPage:   salesPage,
-		Value:       value,
+		Price:       price,
+		PricingMode: PricingFixed,
 		Status:      true,
 		CreatorID:   creator.ID,
 		Slug:        generateSlug(title),
@@ -44,7 +89,118 @@ func NewEbook(title, description, salesPage string, value float64, creator Creat
 }
 
 func (e *Ebook) GetValue() string {
-	return utils.FloatToBRL(e.Value)
+	return e.Price.Format()
+}
+
+// SetPricing define a modalidade de preço. No preço mínimo, a sugestão vazia assume
+// o próprio mínimo; no gratuito, os valores informados são descartados.
+func (e *Ebook) SetPricing(mode string, price, suggested money.Money) error {
+	switch mode {
+	case PricingFixed:
+		if price.Amount <= 0 {
+			return ErrInvalidEbookPrice
+		}
+		suggested = money.Money{}
+	case PricingMinimum:
+		if price.Amount <= 0 {
+			return ErrInvalidEbookPrice
+		}
+		if suggested.Amount == 0 {
+			suggested = price
+		}
+		if suggested.Currency != price.Currency || suggested.Amount < price.Amount {
+			return ErrSuggestedBelowMinimum
+		}
+	case PricingFree:
+		price = money.New(0, price.Currency)
+		suggested = money.Money{}
+	default:
+		return ErrInvalidPricingMode
+	}
+
+	e.PricingMode = mode
+	e.Price = price
+	e.SuggestedPrice = suggested
+	return nil
+}
+
+// IsFixedPrice também cobre ebooks gravados antes das modalidades de preço
+func (e *Ebook) IsFixedPrice() bool {
+	return e.PricingMode == PricingFixed || e.PricingMode == ""
+}
+
+func (e *Ebook) IsPayWhatYouWant() bool {
+	return e.PricingMode == PricingMinimum
+}
+
+func (e *Ebook) IsFree() bool {
+	return e.PricingMode == PricingFree
+}
+
+func (e *Ebook) GetSuggestedValue() string {
+	return e.SuggestedPrice.Format()
+}
+
+// ActivePricePeriod retorna a promoção em vigor no instante informado, ou nil.
+// Promoções só valem para ebooks de preço fixo.
+func (e *Ebook) ActivePricePeriod(now time.Time) *EbookPricePeriod {
+	if !e.IsFixedPrice() {
+		return nil
+	}
+	for _, period := range e.PricePeriods {
+		if period.IsActive(now) {
+			return period
+		}
+	}
+	return nil
+}
+
+// CurrentPrice é o preço cobrado no instante informado, considerando as promoções
+func (e *Ebook) CurrentPrice(now time.Time) money.Money {
+	if period := e.ActivePricePeriod(now); period != nil {
+		return period.Price
+	}
+	return e.Price
+}
+
+func (e *Ebook) GetCurrentValue() string {
+	switch {
+	case e.IsFree():
+		return "Grátis"
+	case e.IsPayWhatYouWant():
+		return "A partir de " + e.Price.Format()
+	}
+	return e.CurrentPrice(time.Now()).Format()
+}
+
+// OrderItem é o item do pedido com o preço em vigor, registrando a promoção aplicada
+func (e *Ebook) OrderItem(now time.Time) OrderItem {
+	item := NewOrderItem(e)
+	if period := e.ActivePricePeriod(now); period != nil {
+		item.UnitPrice = period.Price.Amount
+		item.PricePeriodID = &period.ID
+	}
+	return item
+}
+
+// CheckoutItem é o item do pedido do checkout do ebook. Em "pague quanto quiser" vale
+// o valor escolhido pelo comprador (a sugestão, quando zero); nos demais, o preço em vigor.
+func (e *Ebook) CheckoutItem(amount int64, now time.Time) (OrderItem, error) {
+	switch {
+	case e.IsFree():
+		return OrderItem{}, ErrEbookIsFree
+	case e.IsPayWhatYouWant():
+		if amount == 0 {
+			amount = e.SuggestedPrice.Amount
+		}
+		if amount < e.Price.Amount {
+			return OrderItem{}, ErrPriceBelowMinimum
+		}
+		item := NewOrderItem(e)
+		item.UnitPrice = amount
+		return item, nil
+	}
+	return e.OrderItem(now), nil
 }
 
 func (e *Ebook) GetLastUpdate() string {
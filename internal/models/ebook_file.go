@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Prazo máximo de liberação de um arquivo, contado da compra
+const MaxReleaseAfterDays = 365
+
+var (
+	ErrInvalidReleaseOffset = fmt.Errorf("o prazo de liberação deve ficar entre 0 e %d dias", MaxReleaseAfterDays)
+	ErrFileNotInEbook       = errors.New("arquivo não encontrado neste ebook")
+	ErrReleaseAfterExpiry   = errors.New("o prazo de liberação deve ser menor que a validade do link de download do ebook")
+	ErrFileLocked           = errors.New("este arquivo ainda não foi liberado")
+)
+
+// EbookFile é a linha da relação Ebook.Files, com a ordem do arquivo no ebook e o
+// prazo de liberação em dias contados da compra. Prazo zero libera na compra.
+type EbookFile struct {
+	EbookID          uint `json:"ebook_id" gorm:"primaryKey"`
+	FileID           uint `json:"file_id" gorm:"primaryKey"`
+	Position         int  `json:"position" gorm:"default:0"`
+	ReleaseAfterDays int  `json:"release_after_days" gorm:"default:0"`
+}
+
+func (EbookFile) TableName() string {
+	return "ebook_files"
+}
+
+func (f *EbookFile) Validate() error {
+	if f.ReleaseAfterDays < 0 || f.ReleaseAfterDays > MaxReleaseAfterDays {
+		return ErrInvalidReleaseOffset
+	}
+	return nil
+}
+
+// ValidateWithin garante que o arquivo seja liberado antes de o link de download
+// vencer; sem validade na política, qualquer prazo aceito é alcançável
+func (f *EbookFile) ValidateWithin(policy DownloadPolicy) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if f.ReleaseAfterDays > policy.LastReleaseDay() {
+		return ErrReleaseAfterExpiry
+	}
+	return nil
+}
+
+// LastReleaseDay é o maior prazo de liberação que ainda cabe na validade do link
+func (d DownloadPolicy) LastReleaseDay() int {
+	if d.ExpiryDays > 0 && d.ExpiryDays <= MaxReleaseAfterDays {
+		return d.ExpiryDays - 1
+	}
+	return MaxReleaseAfterDays
+}
+
+// ScheduledFile é um arquivo do ebook com a data em que fica disponível ao comprador
+type ScheduledFile struct {
+	*File
+	Position         int
+	ReleaseAfterDays int
+	ReleaseAt        time.Time
+}
+
+func (f *ScheduledFile) IsLocked(now time.Time) bool {
+	return now.Before(f.ReleaseAt)
+}
+
+// Countdown descreve quanto falta para o arquivo ser liberado
+func (f *ScheduledFile) Countdown(now time.Time) string {
+	left := f.ReleaseAt.Sub(now)
+	if left <= 0 {
+		return ""
+	}
+
+	days := int(left.Hours()) / 24
+	hours := int(left.Hours()) % 24
+	minutes := int(left.Minutes()) % 60
+	switch {
+	case days > 0 && hours > 0:
+		return plural(days, "dia", "dias") + " e " + plural(hours, "hora", "horas")
+	case days > 0:
+		return plural(days, "dia", "dias")
+	case hours > 0:
+		return plural(hours, "hora", "horas") + " e " + plural(minutes, "minuto", "minutos")
+	default:
+		return plural(max(minutes, 1), "minuto", "minutos")
+	}
+}
+
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+// ScheduledFiles ordena os arquivos do ebook e calcula a liberação de cada um a
+// partir da data da compra. Usa Files e FileSchedule carregados; arquivos sem
+// posição definida vêm por último, na ordem de cadastro.
+func (e *Ebook) ScheduledFiles(purchasedAt time.Time) []*ScheduledFile {
+	entries := make(map[uint]*EbookFile, len(e.FileSchedule))
+	for _, entry := range e.FileSchedule {
+		entries[entry.FileID] = entry
+	}
+
+	scheduled := make([]*ScheduledFile, 0, len(e.Files))
+	for _, file := range e.Files {
+		item := &ScheduledFile{File: file, ReleaseAt: purchasedAt}
+		if entry, ok := entries[file.ID]; ok {
+			item.Position = entry.Position
+			item.ReleaseAfterDays = entry.ReleaseAfterDays
+			item.ReleaseAt = purchasedAt.AddDate(0, 0, entry.ReleaseAfterDays)
+		}
+		scheduled = append(scheduled, item)
+	}
+
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		a, b := scheduled[i], scheduled[j]
+		if (a.Position == 0) != (b.Position == 0) {
+			return b.Position == 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+	return scheduled
+}
+
+// HasDrip indica se algum arquivo do ebook é liberado depois da compra
+func (e *Ebook) HasDrip() bool {
+	for _, entry := range e.FileSchedule {
+		if entry.ReleaseAfterDays > 0 {
+			return true
+		}
+	}
+	return false
+}
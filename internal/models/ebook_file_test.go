@@ -0,0 +1,89 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func dripEbook() *models.Ebook {
+	return &models.Ebook{
+		Model: gorm.Model{ID: 1},
+		Files: []*models.File{
+			{Model: gorm.Model{ID: 10}, OriginalName: "bonus.pdf"},
+			{Model: gorm.Model{ID: 11}, OriginalName: "modulo-2.pdf"},
+			{Model: gorm.Model{ID: 12}, OriginalName: "modulo-1.pdf"},
+		},
+		FileSchedule: []*models.EbookFile{
+			{EbookID: 1, FileID: 11, Position: 2, ReleaseAfterDays: 7},
+			{EbookID: 1, FileID: 12, Position: 1},
+		},
+	}
+}
+
+func TestEbook_ScheduledFiles_OrdersByPositionWithUnscheduledLast(t *testing.T) {
+	purchasedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	files := dripEbook().ScheduledFiles(purchasedAt)
+
+	assert.Len(t, files, 3)
+	assert.Equal(t, "modulo-1.pdf", files[0].OriginalName)
+	assert.Equal(t, purchasedAt, files[0].ReleaseAt)
+	assert.Equal(t, "modulo-2.pdf", files[1].OriginalName)
+	assert.Equal(t, purchasedAt.AddDate(0, 0, 7), files[1].ReleaseAt)
+	assert.Equal(t, "bonus.pdf", files[2].OriginalName)
+	assert.Equal(t, purchasedAt, files[2].ReleaseAt)
+}
+
+func TestEbook_HasDrip(t *testing.T) {
+	ebook := dripEbook()
+	assert.True(t, ebook.HasDrip())
+
+	ebook.FileSchedule[0].ReleaseAfterDays = 0
+	assert.False(t, ebook.HasDrip())
+}
+
+func TestScheduledFile_Countdown(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	file := &models.ScheduledFile{ReleaseAt: now.Add(49 * time.Hour)}
+
+	assert.True(t, file.IsLocked(now))
+	assert.Equal(t, "2 dias e 1 hora", file.Countdown(now))
+	assert.Equal(t, "1 hora e 30 minutos", file.Countdown(file.ReleaseAt.Add(-90*time.Minute)))
+	assert.Equal(t, "1 minuto", file.Countdown(file.ReleaseAt.Add(-10*time.Second)))
+	assert.False(t, file.IsLocked(file.ReleaseAt))
+	assert.Empty(t, file.Countdown(file.ReleaseAt))
+}
+
+func TestEbookFile_Validate(t *testing.T) {
+	assert.NoError(t, (&models.EbookFile{ReleaseAfterDays: models.MaxReleaseAfterDays}).Validate())
+	assert.ErrorIs(t, (&models.EbookFile{ReleaseAfterDays: -1}).Validate(), models.ErrInvalidReleaseOffset)
+	assert.ErrorIs(t, (&models.EbookFile{ReleaseAfterDays: models.MaxReleaseAfterDays + 1}).Validate(), models.ErrInvalidReleaseOffset)
+}
+
+func TestPurchase_FindScheduledFile(t *testing.T) {
+	purchase := &models.Purchase{Ebook: *dripEbook()}
+	purchase.CreatedAt = time.Now().AddDate(0, 0, -3)
+
+	file, err := purchase.FindScheduledFile(12, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "modulo-1.pdf", file.OriginalName)
+
+	_, err = purchase.FindScheduledFile(11, time.Now())
+	assert.ErrorIs(t, err, models.ErrFileLocked)
+
+	_, err = purchase.FindScheduledFile(99, time.Now())
+	assert.ErrorIs(t, err, models.ErrFileNotInEbook)
+}
+
+func TestPurchase_FindScheduledFile_CountsFromPreSaleRelease(t *testing.T) {
+	purchase := &models.Purchase{Ebook: *dripEbook(), AwaitingRelease: true}
+	purchase.CreatedAt = time.Now().AddDate(0, 0, -30)
+	purchase.Release(time.Now().AddDate(0, 0, -1))
+
+	_, err := purchase.FindScheduledFile(11, time.Now())
+	assert.ErrorIs(t, err, models.ErrFileLocked)
+}
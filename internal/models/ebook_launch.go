@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnpublishBeforePublish = errors.New("a despublicação deve ser depois da publicação")
+	ErrUnpublishInPast        = errors.New("a data de despublicação já passou")
+	ErrNotInPreSale           = errors.New("este ebook não está em pré-venda")
+	ErrReleaseWithoutFiles    = errors.New("anexe os arquivos do ebook antes de liberar a pré-venda")
+	ErrAwaitingRelease        = errors.New("este ebook está em pré-venda; o link de download será enviado no lançamento")
+)
+
+// ValidateLaunch confere as datas agendadas; qualquer uma pode ficar em branco
+func ValidateLaunch(publishAt, unpublishAt *time.Time, now time.Time) error {
+	if unpublishAt == nil {
+		return nil
+	}
+	if !unpublishAt.After(now) {
+		return ErrUnpublishInPast
+	}
+	if publishAt != nil && !unpublishAt.After(*publishAt) {
+		return ErrUnpublishBeforePublish
+	}
+	return nil
+}
+
+// ScheduleLaunch grava as datas de publicação e despublicação. Com a publicação
+// no futuro o ebook sai de venda até a data chegar.
+func (e *Ebook) ScheduleLaunch(publishAt, unpublishAt *time.Time, now time.Time) error {
+	if err := ValidateLaunch(publishAt, unpublishAt, now); err != nil {
+		return err
+	}
+
+	e.PublishAt = publishAt
+	e.UnpublishAt = unpublishAt
+	e.ApplySchedule(now)
+	return nil
+}
+
+// ApplySchedule liga ou desliga o ebook conforme as datas agendadas que já
+// chegaram e as descarta. Retorna true quando Status mudou.
+func (e *Ebook) ApplySchedule(now time.Time) bool {
+	status := e.Status
+	if e.PublishAt != nil {
+		if e.PublishAt.After(now) {
+			e.Status = false
+		} else {
+			e.Status = true
+			e.PublishAt = nil
+		}
+	}
+	if e.UnpublishAt != nil && !e.UnpublishAt.After(now) {
+		e.Status = false
+		e.UnpublishAt = nil
+	}
+	return status != e.Status
+}
+
+// IsScheduled indica se o ebook tem publicação agendada para o futuro
+func (e *Ebook) IsScheduled() bool {
+	return e.PublishAt != nil && !e.Status
+}
+
+// Release encerra a pré-venda. Exige arquivos, já que as compras feitas até
+// aqui recebem o link de download em seguida.
+func (e *Ebook) Release(now time.Time) error {
+	if !e.PreSale {
+		return ErrNotInPreSale
+	}
+	if len(e.Files) == 0 {
+		return ErrReleaseWithoutFiles
+	}
+	e.PreSale = false
+	e.ReleasedAt = &now
+	return nil
+}
+
+// Release libera o download de uma compra feita na pré-venda. O prazo do link e
+// o cronograma de arquivos passam a contar do lançamento, mantendo a duração que
+// o link tinha na compra.
+func (p *Purchase) Release(now time.Time) {
+	if !p.AwaitingRelease {
+		return
+	}
+	if !p.ExpiresAt.IsZero() {
+		p.ExpiresAt = now.Add(p.ExpiresAt.Sub(p.CreatedAt))
+	}
+	p.AwaitingRelease = false
+	p.ReleasedAt = &now
+}
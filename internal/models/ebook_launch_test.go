@@ -0,0 +1,76 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEbook_ScheduleLaunch(t *testing.T) {
+	now := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	publishAt := now.Add(48 * time.Hour)
+	unpublishAt := now.Add(96 * time.Hour)
+
+	ebook := &models.Ebook{Status: true}
+	assert.NoError(t, ebook.ScheduleLaunch(&publishAt, &unpublishAt, now))
+	assert.False(t, ebook.Status)
+	assert.True(t, ebook.IsScheduled())
+
+	past := now.Add(-time.Hour)
+	assert.ErrorIs(t, ebook.ScheduleLaunch(nil, &past, now), models.ErrUnpublishInPast)
+	assert.ErrorIs(t, ebook.ScheduleLaunch(&unpublishAt, &publishAt, now), models.ErrUnpublishBeforePublish)
+}
+
+func TestEbook_ApplySchedule(t *testing.T) {
+	now := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	publishAt := now.Add(-time.Minute)
+	unpublishAt := now.Add(time.Hour)
+
+	ebook := &models.Ebook{PublishAt: &publishAt, UnpublishAt: &unpublishAt}
+	assert.True(t, ebook.ApplySchedule(now))
+	assert.True(t, ebook.Status)
+	assert.Nil(t, ebook.PublishAt)
+	assert.NotNil(t, ebook.UnpublishAt)
+
+	assert.True(t, ebook.ApplySchedule(now.Add(2*time.Hour)))
+	assert.False(t, ebook.Status)
+	assert.Nil(t, ebook.UnpublishAt)
+
+	assert.False(t, ebook.ApplySchedule(now.Add(3*time.Hour)))
+}
+
+func TestEbook_Release(t *testing.T) {
+	now := time.Now()
+
+	assert.ErrorIs(t, (&models.Ebook{}).Release(now), models.ErrNotInPreSale)
+
+	ebook := &models.Ebook{PreSale: true}
+	assert.ErrorIs(t, ebook.Release(now), models.ErrReleaseWithoutFiles)
+
+	ebook.Files = []*models.File{{Name: "capitulo.pdf"}}
+	assert.NoError(t, ebook.Release(now))
+	assert.False(t, ebook.PreSale)
+	assert.Equal(t, &now, ebook.ReleasedAt)
+}
+
+func TestPurchase_Release_KeepsExpiryWindow(t *testing.T) {
+	bought := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	launch := bought.AddDate(0, 0, 20)
+
+	purchase := models.NewPurchase(1, 2)
+	purchase.CreatedAt = bought
+	purchase.ExpiresAt = bought.AddDate(0, 0, 30)
+	purchase.AwaitingRelease = true
+
+	purchase.Release(launch)
+
+	assert.False(t, purchase.AwaitingRelease)
+	assert.Equal(t, launch.AddDate(0, 0, 30), purchase.ExpiresAt)
+
+	unlimited := models.NewPurchase(1, 2)
+	unlimited.AwaitingRelease = true
+	unlimited.Release(launch)
+	assert.True(t, unlimited.ExpiresAt.IsZero())
+}
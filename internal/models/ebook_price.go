@@ -0,0 +1,118 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPricePeriodInvalidPrice  = errors.New("o preço promocional deve ser maior que zero e menor que o preço do ebook")
+	ErrPricePeriodMixedCurrency = errors.New("o preço promocional precisa estar na mesma moeda do ebook")
+	ErrPricePeriodInvalidRange  = errors.New("o fim da promoção deve ser depois do início")
+	ErrPricePeriodAlreadyEnded  = errors.New("o fim da promoção já passou")
+	ErrPricePeriodOverlap       = errors.New("já existe uma promoção do ebook nesse período")
+	ErrPricePeriodNotFound      = errors.New("promoção não encontrada")
+	ErrPricePeriodFixedOnly     = errors.New("promoções só podem ser agendadas para ebooks de preço fixo")
+)
+
+const defaultPricePeriodLabel = "Preço promocional"
+
+// EbookPricePeriod é um preço promocional do ebook válido entre StartsAt e EndsAt.
+// Fora dos períodos cadastrados vale o preço do ebook.
+type EbookPricePeriod struct {
+	gorm.Model
+	EbookID  uint        `json:"ebook_id" gorm:"index"`
+	Label    string      `json:"label"` // exibido na página de vendas, ex.: "Preço de lançamento"
+	Price    money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	StartsAt time.Time   `json:"starts_at" gorm:"index"`
+	EndsAt   time.Time   `json:"ends_at" gorm:"index"`
+}
+
+func NewEbookPricePeriod(ebook *Ebook, label string, price money.Money, startsAt, endsAt time.Time, now time.Time) (*EbookPricePeriod, error) {
+	if !ebook.IsFixedPrice() {
+		return nil, ErrPricePeriodFixedOnly
+	}
+	if price.Currency != ebook.Price.Currency {
+		return nil, ErrPricePeriodMixedCurrency
+	}
+	if price.Amount <= 0 || price.Amount >= ebook.Price.Amount {
+		return nil, ErrPricePeriodInvalidPrice
+	}
+	if !endsAt.After(startsAt) {
+		return nil, ErrPricePeriodInvalidRange
+	}
+	if !endsAt.After(now) {
+		return nil, ErrPricePeriodAlreadyEnded
+	}
+
+	period := &EbookPricePeriod{
+		EbookID:  ebook.ID,
+		Label:    strings.TrimSpace(label),
+		Price:    price,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+	}
+	if period.Label == "" {
+		period.Label = defaultPricePeriodLabel
+	}
+
+	for _, existing := range ebook.PricePeriods {
+		if period.Overlaps(existing) {
+			return nil, ErrPricePeriodOverlap
+		}
+	}
+
+	return period, nil
+}
+
+// IsActive indica se o preço promocional vale no instante informado
+func (p *EbookPricePeriod) IsActive(now time.Time) bool {
+	return !now.Before(p.StartsAt) && now.Before(p.EndsAt)
+}
+
+func (p *EbookPricePeriod) IsScheduled(now time.Time) bool {
+	return now.Before(p.StartsAt)
+}
+
+func (p *EbookPricePeriod) Overlaps(other *EbookPricePeriod) bool {
+	return p.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(p.EndsAt)
+}
+
+func (p *EbookPricePeriod) GetValue() string {
+	return p.Price.Format()
+}
+
+// GetStatusLabel descreve a situação da promoção para o criador
+func (p *EbookPricePeriod) GetStatusLabel() string {
+	now := time.Now()
+	switch {
+	case p.IsActive(now):
+		return "Em andamento"
+	case p.IsScheduled(now):
+		return "Agendada"
+	default:
+		return "Encerrada"
+	}
+}
+
+// PriceSales resume as vendas pagas do ebook a um mesmo preço
+type PriceSales struct {
+	PricePeriodID *uint
+	Label         string
+	UnitPrice     int64
+	Currency      money.Currency
+	Sales         int64
+	Revenue       int64
+}
+
+func (s PriceSales) GetUnitPrice() string {
+	return money.New(s.UnitPrice, s.Currency).Format()
+}
+
+func (s PriceSales) GetRevenue() string {
+	return money.New(s.Revenue, s.Currency).Format()
+}
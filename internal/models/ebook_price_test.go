@@ -0,0 +1,66 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewEbookPricePeriod_Validation(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, Price: money.New(4900, money.BRL)}
+	ebook.PricePeriods = []*models.EbookPricePeriod{
+		{EbookID: 1, Price: money.New(2900, money.BRL), StartsAt: now, EndsAt: now.Add(72 * time.Hour)},
+	}
+	next := now.Add(100 * time.Hour)
+
+	tests := []struct {
+		name     string
+		price    money.Money
+		startsAt time.Time
+		endsAt   time.Time
+		err      error
+	}{
+		{"preço acima do preço do ebook", money.New(5900, money.BRL), next, next.Add(time.Hour), models.ErrPricePeriodInvalidPrice},
+		{"outra moeda", money.New(900, money.USD), next, next.Add(time.Hour), models.ErrPricePeriodMixedCurrency},
+		{"fim antes do início", money.New(2900, money.BRL), next, next.Add(-time.Hour), models.ErrPricePeriodInvalidRange},
+		{"já encerrada", money.New(2900, money.BRL), now.Add(-48 * time.Hour), now.Add(-time.Hour), models.ErrPricePeriodAlreadyEnded},
+		{"sobreposta", money.New(1900, money.BRL), now.Add(48 * time.Hour), next, models.ErrPricePeriodOverlap},
+		{"válida logo após a anterior", money.New(3900, money.BRL), now.Add(72 * time.Hour), next, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, err := models.NewEbookPricePeriod(ebook, "", tt.price, tt.startsAt, tt.endsAt, now)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, "Preço promocional", period.Label)
+			}
+		})
+	}
+}
+
+func TestEbook_CurrentPrice_FollowsSchedule(t *testing.T) {
+	friday := time.Date(2026, 3, 6, 23, 59, 0, 0, time.UTC)
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, Title: "Ebook", Price: money.New(4900, money.BRL)}
+	ebook.PricePeriods = []*models.EbookPricePeriod{
+		{Model: gorm.Model{ID: 5}, EbookID: 1, Price: money.New(2900, money.BRL), StartsAt: friday.Add(-96 * time.Hour), EndsAt: friday},
+	}
+
+	assert.Equal(t, int64(4900), ebook.CurrentPrice(friday.Add(-100*time.Hour)).Amount)
+	assert.Equal(t, int64(2900), ebook.CurrentPrice(friday.Add(-time.Minute)).Amount)
+	assert.Equal(t, int64(4900), ebook.CurrentPrice(friday).Amount)
+
+	item := ebook.OrderItem(friday.Add(-time.Minute))
+	assert.Equal(t, int64(2900), item.UnitPrice)
+	assert.Equal(t, int64(4900), item.ListPrice)
+	assert.Equal(t, uint(5), *item.PricePeriodID)
+
+	item = ebook.OrderItem(friday)
+	assert.Equal(t, int64(4900), item.UnitPrice)
+	assert.Nil(t, item.PricePeriodID)
+}
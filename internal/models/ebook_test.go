@@ -4,9 +4,12 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestNewEbook(t *testing.T) {
@@ -14,7 +17,7 @@ func TestNewEbook(t *testing.T) {
 	title := "Test Ebook"
 	description := "Test description"
 	salesPage := "This is a sales page content"
-	value := 29.90
+	value := money.New(2990, money.BRL)
 	creator := models.Creator{
 		Name:  "Test Creator",
 		Email: "creator@test.com",
@@ -28,7 +31,7 @@ func TestNewEbook(t *testing.T) {
 	assert.Equal(t, title, ebook.Title)
 	assert.Equal(t, description, ebook.Description)
 	assert.Equal(t, salesPage, ebook.SalesPage)
-	assert.Equal(t, value, ebook.Value)
+	assert.Equal(t, value, ebook.Price)
 	assert.True(t, ebook.Status)
 	assert.Equal(t, creator.ID, ebook.CreatorID)
 	assert.NotEmpty(t, ebook.Slug)
@@ -143,19 +146,29 @@ func TestEbook_GetValue(t *testing.T) {
 	}{
 		{
 			name:     "Zero value",
-			ebook:    &models.Ebook{Value: 0},
+			ebook:    &models.Ebook{Price: money.New(0, money.BRL)},
 			expected: "R$ 0,00",
 		},
 		{
 			name:     "Positive value",
-			ebook:    &models.Ebook{Value: 29.90},
+			ebook:    &models.Ebook{Price: money.New(2990, money.BRL)},
 			expected: "R$ 29,90",
 		},
 		{
 			name:     "Large value",
-			ebook:    &models.Ebook{Value: 199.99},
+			ebook:    &models.Ebook{Price: money.New(19999, money.BRL)},
 			expected: "R$ 199,99",
 		},
+		{
+			name:     "Cents are not rounded",
+			ebook:    &models.Ebook{Price: money.New(1999, money.BRL)},
+			expected: "R$ 19,99",
+		},
+		{
+			name:     "USD value",
+			ebook:    &models.Ebook{Price: money.New(123456, money.USD)},
+			expected: "$1,234.56",
+		},
 	}
 
 	for _, tt := range tests {
@@ -236,6 +249,59 @@ func TestGenerateSlug(t *testing.T) {
 	}
 }
 
+func TestEbook_SetPricing(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		price     money.Money
+		suggested money.Money
+		err       error
+		expected  money.Money
+	}{
+		{"fixo", models.PricingFixed, money.New(4900, money.BRL), money.Money{}, nil, money.Money{}},
+		{"fixo sem preço", models.PricingFixed, money.New(0, money.BRL), money.Money{}, models.ErrInvalidEbookPrice, money.Money{}},
+		{"mínimo sem sugestão usa o mínimo", models.PricingMinimum, money.New(1000, money.BRL), money.Money{}, nil, money.New(1000, money.BRL)},
+		{"mínimo com sugestão", models.PricingMinimum, money.New(1000, money.BRL), money.New(2500, money.BRL), nil, money.New(2500, money.BRL)},
+		{"sugestão abaixo do mínimo", models.PricingMinimum, money.New(1000, money.BRL), money.New(500, money.BRL), models.ErrSuggestedBelowMinimum, money.Money{}},
+		{"gratuito", models.PricingFree, money.New(4900, money.BRL), money.Money{}, nil, money.Money{}},
+		{"modalidade inválida", "leilao", money.New(4900, money.BRL), money.Money{}, models.ErrInvalidPricingMode, money.Money{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ebook := &models.Ebook{}
+			err := ebook.SetPricing(tt.mode, tt.price, tt.suggested)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, tt.mode, ebook.PricingMode)
+				assert.Equal(t, tt.expected, ebook.SuggestedPrice)
+			}
+		})
+	}
+}
+
+func TestEbook_CheckoutItem(t *testing.T) {
+	now := time.Now()
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}}
+	assert.NoError(t, ebook.SetPricing(models.PricingMinimum, money.New(1000, money.BRL), money.New(2500, money.BRL)))
+
+	item, err := ebook.CheckoutItem(0, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2500), item.UnitPrice)
+
+	item, err = ebook.CheckoutItem(4000, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), item.UnitPrice)
+
+	_, err = ebook.CheckoutItem(900, now)
+	assert.ErrorIs(t, err, models.ErrPriceBelowMinimum)
+
+	assert.NoError(t, ebook.SetPricing(models.PricingFree, money.New(0, money.BRL), money.Money{}))
+	_, err = ebook.CheckoutItem(0, now)
+	assert.ErrorIs(t, err, models.ErrEbookIsFree)
+	assert.Equal(t, "Grátis", ebook.GetCurrentValue())
+}
+
 // Função auxiliar para testar generateSlug
 func generateSlug(title string) string {
 	// Copiar a implementação do modelo para teste
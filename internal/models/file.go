@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -19,6 +20,12 @@ type File struct {
 	CreatorID    uint     `json:"creator_id"`
 	Creator      Creator  `gorm:"foreignKey:CreatorID"`
 	Ebooks       []*Ebook `gorm:"many2many:ebook_files"`
+
+	// Versão atual do arquivo. As anteriores ficam arquivadas em Versions.
+	Version     int            `json:"version" gorm:"default:1"`
+	Changelog   string         `json:"changelog"`
+	VersionedAt *time.Time     `json:"versioned_at"` // publicação da versão atual, vazio na primeira
+	Versions    []*FileVersion `gorm:"foreignKey:FileID"`
 }
 
 func NewFile(name, originalName, description, fileType, s3Key, s3URL string, fileSize int64, creatorID uint) *File {
@@ -32,20 +39,25 @@ func NewFile(name, originalName, description, fileType, s3Key, s3URL string, fil
 		S3URL:        s3URL,
 		Status:       true,
 		CreatorID:    creatorID,
+		Version:      1,
 	}
 }
 
 func (f *File) GetFileSizeFormatted() string {
+	return formatBytes(f.FileSize)
+}
+
+func formatBytes(size int64) string {
 	const unit = 1024
-	if f.FileSize < unit {
-		return fmt.Sprintf("%d B", f.FileSize)
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
 	}
 	div, exp := int64(unit), 0
-	for n := f.FileSize / unit; n >= unit; n /= unit {
+	for n := size / unit; n >= unit; n /= unit {
 		div *= unit
 		exp++
 	}
-	return fmt.Sprintf("%.1f %cB", float64(f.FileSize)/float64(div), "KMGTPE"[exp])
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
 func (f *File) IsPDF() bool {
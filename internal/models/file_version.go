@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrChangelogRequired = errors.New("descreva o que mudou nesta versão")
+	ErrNoNewVersion      = errors.New("nenhum arquivo do ebook tem versão nova desde o último aviso")
+)
+
+// FileVersion guarda uma versão substituída de um arquivo. O conteúdo continua no
+// storage para consulta do criador; os compradores recebem sempre a versão atual.
+type FileVersion struct {
+	gorm.Model
+	FileID       uint   `json:"file_id" gorm:"index"`
+	Version      int    `json:"version"`
+	Name         string `json:"name"`
+	OriginalName string `json:"original_name"`
+	FileType     string `json:"file_type"`
+	FileSize     int64  `json:"file_size"`
+	S3Key        string `json:"s3_key"`
+	S3URL        string `json:"s3_url"`
+	Changelog    string `json:"changelog"`
+}
+
+func (v *FileVersion) GetFileSizeFormatted() string {
+	return formatBytes(v.FileSize)
+}
+
+// NewVersion substitui o conteúdo do arquivo pelo novo upload e devolve a versão
+// anterior para ser arquivada
+func (f *File) NewVersion(upload *File, changelog string, now time.Time) (*FileVersion, error) {
+	changelog = strings.TrimSpace(changelog)
+	if changelog == "" {
+		return nil, ErrChangelogRequired
+	}
+
+	archived := &FileVersion{
+		FileID:       f.ID,
+		Version:      f.CurrentVersion(),
+		Name:         f.Name,
+		OriginalName: f.OriginalName,
+		FileType:     f.FileType,
+		FileSize:     f.FileSize,
+		S3Key:        f.S3Key,
+		S3URL:        f.S3URL,
+		Changelog:    f.Changelog,
+	}
+
+	f.Name = upload.Name
+	f.OriginalName = upload.OriginalName
+	f.FileType = upload.FileType
+	f.FileSize = upload.FileSize
+	f.S3Key = upload.S3Key
+	f.S3URL = upload.S3URL
+	f.Version = archived.Version + 1
+	f.Changelog = changelog
+	f.VersionedAt = &now
+	return archived, nil
+}
+
+// CurrentVersion trata arquivos anteriores ao versionamento como versão 1
+func (f *File) CurrentVersion() int {
+	if f.Version < 1 {
+		return 1
+	}
+	return f.Version
+}
+
+// UpdatedFiles lista os arquivos do ebook com versão publicada depois do último
+// aviso aos compradores
+func (e *Ebook) UpdatedFiles() []*File {
+	var updated []*File
+	for _, file := range e.Files {
+		if file.VersionedAt == nil {
+			continue
+		}
+		if e.VersionNotifiedAt == nil || file.VersionedAt.After(*e.VersionNotifiedAt) {
+			updated = append(updated, file)
+		}
+	}
+	return updated
+}
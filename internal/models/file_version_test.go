@@ -0,0 +1,76 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestFile_NewVersion_ArchivesPreviousContent(t *testing.T) {
+	file := models.NewFile("livro-a1.pdf", "livro.pdf", "Livro", "pdf", "files/1/livro-a1.pdf", "https://s3/livro-a1.pdf", 100, 1)
+	file.ID = 5
+	now := time.Now()
+
+	upload := models.NewFile("livro-v2.pdf", "livro.pdf", "", "pdf", "files/1/5/livro-v2.pdf", "https://s3/livro-v2.pdf", 150, 1)
+	archived, err := file.NewVersion(upload, "  Capítulo 12 incluído ", now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), archived.FileID)
+	assert.Equal(t, 1, archived.Version)
+	assert.Equal(t, "files/1/livro-a1.pdf", archived.S3Key)
+	assert.Empty(t, archived.Changelog)
+	assert.Equal(t, 2, file.Version)
+	assert.Equal(t, "files/1/5/livro-v2.pdf", file.S3Key)
+	assert.Equal(t, int64(150), file.FileSize)
+	assert.Equal(t, "Capítulo 12 incluído", file.Changelog)
+	assert.Equal(t, &now, file.VersionedAt)
+
+	_, err = file.NewVersion(upload, " ", now)
+	assert.ErrorIs(t, err, models.ErrChangelogRequired)
+}
+
+func TestFile_CurrentVersion_DefaultsToOne(t *testing.T) {
+	assert.Equal(t, 1, (&models.File{}).CurrentVersion())
+	assert.Equal(t, 3, (&models.File{Version: 3}).CurrentVersion())
+}
+
+func TestEbook_UpdatedFiles(t *testing.T) {
+	versionedAt := time.Now().Add(-time.Hour)
+	original := &models.File{Model: gorm.Model{ID: 1}, Version: 1}
+	updated := &models.File{Model: gorm.Model{ID: 2}, Version: 2, VersionedAt: &versionedAt}
+	ebook := &models.Ebook{Files: []*models.File{original, updated}}
+
+	assert.Equal(t, []*models.File{updated}, ebook.UpdatedFiles())
+
+	notifiedAt := time.Now()
+	ebook.VersionNotifiedAt = &notifiedAt
+	assert.Empty(t, ebook.UpdatedFiles())
+}
+
+func TestPurchase_UseFileDownload_UpdateIsFree(t *testing.T) {
+	versionedAt := time.Now()
+	file := &models.File{Model: gorm.Model{ID: 2}, Version: 2, VersionedAt: &versionedAt}
+	purchase := &models.Purchase{DownloadLimit: 1, DownloadsUsed: 1, Ebook: models.Ebook{Files: []*models.File{file}}}
+	purchase.CreatedAt = versionedAt.AddDate(0, 0, -10)
+
+	assert.False(t, purchase.AvailableDownloads())
+	assert.True(t, purchase.IsUpdateDownload(file))
+	assert.True(t, purchase.HasPendingUpdates())
+
+	purchase.UseFileDownload(file, models.DownloadRequest{IP: "10.0.0.1"})
+	assert.Equal(t, 1, purchase.DownloadsUsed)
+	assert.True(t, purchase.Downloads[0].Free)
+	assert.Equal(t, 2, purchase.Downloads[0].FileVersion)
+	assert.False(t, purchase.IsUpdateDownload(file))
+	assert.False(t, purchase.HasPendingUpdates())
+
+	// Quem comprou depois da nova versão baixa normalmente
+	late := &models.Purchase{DownloadLimit: -1}
+	late.CreatedAt = versionedAt.Add(time.Hour)
+	late.UseFileDownload(file, models.DownloadRequest{IP: "10.0.0.1"})
+	assert.Equal(t, 1, late.DownloadsUsed)
+	assert.False(t, late.Downloads[0].Free)
+}
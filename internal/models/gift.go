@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGiftRecipientRequired = errors.New("informe o nome e o e-mail de quem vai receber o presente")
+	ErrGiftInvalidEmail      = errors.New("e-mail de quem vai receber o presente inválido")
+	ErrGiftSendAtInPast      = errors.New("a data de envio do presente não pode estar no passado")
+	ErrGiftAlreadyClaimed    = errors.New("este presente já foi resgatado")
+	ErrGiftNotAvailable      = errors.New("este presente não está disponível")
+)
+
+// Gift é um pedido pago por um cliente para outra pessoa. O pagador não recebe o
+// ebook: quem ganhou o presente recebe um link de resgate e, ao confirmar os
+// próprios dados, a compra é criada em seu nome (e a marca d'água também).
+type Gift struct {
+	gorm.Model
+	OrderID        uint       `json:"order_id" gorm:"uniqueIndex"`
+	Order          Order      `gorm:"foreignKey:OrderID"`
+	CreatorID      uint       `json:"creator_id" gorm:"index"`
+	EbookID        uint       `json:"ebook_id" gorm:"index"`
+	Ebook          Ebook      `gorm:"foreignKey:EbookID"`
+	SenderID       uint       `json:"sender_id" gorm:"index"` // cliente que pagou
+	Sender         Client     `gorm:"foreignKey:SenderID"`
+	RecipientName  string     `json:"recipient_name"`
+	RecipientEmail string     `json:"recipient_email"`
+	Message        string     `json:"message"`
+	SendAt         *time.Time `json:"send_at" gorm:"index"` // vazio envia assim que o pagamento é confirmado
+	Token          string     `json:"token" gorm:"uniqueIndex"`
+	SentAt         *time.Time `json:"sent_at"`
+	ClaimedAt      *time.Time `json:"claimed_at"`
+	RecipientID    *uint      `json:"recipient_id" gorm:"index"` // cliente que resgatou
+}
+
+func NewGift(order *Order, ebook *Ebook, recipientName, recipientEmail, message string, sendAt *time.Time, token string, now time.Time) (*Gift, error) {
+	recipientName = strings.TrimSpace(recipientName)
+	recipientEmail = strings.ToLower(strings.TrimSpace(recipientEmail))
+	if recipientName == "" || recipientEmail == "" {
+		return nil, ErrGiftRecipientRequired
+	}
+	if _, err := mail.ParseAddress(recipientEmail); err != nil {
+		return nil, ErrGiftInvalidEmail
+	}
+	if sendAt != nil && sendAt.Before(now) {
+		return nil, ErrGiftSendAtInPast
+	}
+
+	return &Gift{
+		OrderID:        order.ID,
+		CreatorID:      order.CreatorID,
+		EbookID:        ebook.ID,
+		Ebook:          *ebook,
+		SenderID:       order.ClientID,
+		RecipientName:  recipientName,
+		RecipientEmail: recipientEmail,
+		Message:        strings.TrimSpace(message),
+		SendAt:         sendAt,
+		Token:          token,
+	}, nil
+}
+
+// IsDue indica se o link de resgate já pode ser enviado
+func (g *Gift) IsDue(now time.Time) bool {
+	return g.SentAt == nil && (g.SendAt == nil || !now.Before(*g.SendAt))
+}
+
+func (g *Gift) MarkSent(now time.Time) {
+	g.SentAt = &now
+}
+
+func (g *Gift) IsClaimed() bool {
+	return g.ClaimedAt != nil
+}
+
+// Claim registra o resgate pelo cliente que confirmou os dados
+func (g *Gift) Claim(recipient *Client, now time.Time) error {
+	if g.IsClaimed() {
+		return ErrGiftAlreadyClaimed
+	}
+	g.RecipientID = &recipient.ID
+	g.ClaimedAt = &now
+	return nil
+}
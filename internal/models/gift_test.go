@@ -0,0 +1,71 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewGift_Validation(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	order := &models.Order{Model: gorm.Model{ID: 5}, CreatorID: 2, ClientID: 3}
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, Title: "Ebook"}
+	past := now.Add(-time.Hour)
+	future := now.Add(48 * time.Hour)
+
+	tests := []struct {
+		name   string
+		rName  string
+		rEmail string
+		sendAt *time.Time
+		err    error
+	}{
+		{"sem destinatário", "", "amiga@email.com", nil, models.ErrGiftRecipientRequired},
+		{"e-mail inválido", "Amiga", "amiga", nil, models.ErrGiftInvalidEmail},
+		{"data no passado", "Amiga", "amiga@email.com", &past, models.ErrGiftSendAtInPast},
+		{"envio imediato", "Amiga", " Amiga@Email.com ", nil, nil},
+		{"envio agendado", "Amiga", "amiga@email.com", &future, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gift, err := models.NewGift(order, ebook, tt.rName, tt.rEmail, "Feliz aniversário", tt.sendAt, "token", now)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, uint(5), gift.OrderID)
+				assert.Equal(t, uint(2), gift.CreatorID)
+				assert.Equal(t, uint(3), gift.SenderID)
+				assert.Equal(t, "amiga@email.com", gift.RecipientEmail)
+			}
+		})
+	}
+}
+
+func TestGift_IsDue(t *testing.T) {
+	now := time.Now()
+	later := now.Add(24 * time.Hour)
+
+	immediate := &models.Gift{}
+	scheduled := &models.Gift{SendAt: &later}
+
+	assert.True(t, immediate.IsDue(now))
+	assert.False(t, scheduled.IsDue(now))
+	assert.True(t, scheduled.IsDue(later))
+
+	immediate.MarkSent(now)
+	assert.False(t, immediate.IsDue(now))
+}
+
+func TestGift_Claim(t *testing.T) {
+	gift := &models.Gift{}
+	recipient := &models.Client{Model: gorm.Model{ID: 8}}
+
+	assert.NoError(t, gift.Claim(recipient, time.Now()))
+	assert.True(t, gift.IsClaimed())
+	assert.Equal(t, uint(8), *gift.RecipientID)
+
+	assert.ErrorIs(t, gift.Claim(recipient, time.Now()), models.ErrGiftAlreadyClaimed)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+const (
+	InvoiceStatusDraft         = "draft"
+	InvoiceStatusOpen          = "open"
+	InvoiceStatusPaid          = "paid"
+	InvoiceStatusUncollectible = "uncollectible"
+	InvoiceStatusVoid          = "void"
+)
+
+// Invoice representa uma fatura da assinatura emitida pelo gateway de pagamento.
+// As faturas são consultadas no gateway e não são persistidas.
+type Invoice struct {
+	ID        string
+	Number    string
+	Status    string
+	Amount    money.Money
+	CreatedAt time.Time
+	HostedURL string
+	PDFURL    string
+}
+
+func (i *Invoice) IsPaid() bool {
+	return i.Status == InvoiceStatusPaid
+}
+
+func (i *Invoice) GetAmount() string {
+	return i.Amount.Format()
+}
+
+// GetStatusLabel retorna o status da fatura para exibição
+func (i *Invoice) GetStatusLabel() string {
+	switch i.Status {
+	case InvoiceStatusPaid:
+		return "Paga"
+	case InvoiceStatusOpen:
+		return "Em aberto"
+	case InvoiceStatusDraft:
+		return "Rascunho"
+	case InvoiceStatusUncollectible:
+		return "Não cobrável"
+	case InvoiceStatusVoid:
+		return "Cancelada"
+	default:
+		return i.Status
+	}
+}
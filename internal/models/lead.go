@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrLeadAlreadyConfirmed = errors.New("pedido do ebook já confirmado")
+
+// Lead é o pedido de um ebook gratuito. O ebook só é entregue depois que o
+// comprador confirma o e-mail pelo link enviado (double opt-in).
+type Lead struct {
+	gorm.Model
+	CreatorID        uint       `json:"creator_id" gorm:"index"`
+	EbookID          uint       `json:"ebook_id" gorm:"index"`
+	Ebook            Ebook      `gorm:"foreignKey:EbookID"`
+	ClientID         uint       `json:"client_id" gorm:"index"`
+	Client           Client     `gorm:"foreignKey:ClientID"`
+	Token            string     `json:"token" gorm:"uniqueIndex"` // usado no link de confirmação
+	MarketingConsent bool       `json:"marketing_consent"`        // aceite marcado no formulário
+	ConfirmedAt      *time.Time `json:"confirmed_at"`
+	PurchaseID       *uint      `json:"purchase_id"`
+}
+
+func NewLead(ebook *Ebook, client *Client, token string, marketingConsent bool) *Lead {
+	return &Lead{
+		CreatorID:        ebook.CreatorID,
+		EbookID:          ebook.ID,
+		Ebook:            *ebook,
+		ClientID:         client.ID,
+		Client:           *client,
+		Token:            token,
+		MarketingConsent: marketingConsent,
+	}
+}
+
+func (l *Lead) IsConfirmed() bool {
+	return l.ConfirmedAt != nil
+}
+
+// Confirm registra a confirmação do e-mail no lead e no cliente. Retorna false
+// quando o lead já estava confirmado.
+func (l *Lead) Confirm(now time.Time) bool {
+	if l.IsConfirmed() {
+		return false
+	}
+	l.ConfirmedAt = &now
+	l.Client.ConfirmEmail(l.MarketingConsent, now)
+	return true
+}
@@ -0,0 +1,26 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLead_Confirm(t *testing.T) {
+	now := time.Now()
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 2}
+	client := &models.Client{Model: gorm.Model{ID: 3}, Email: "leitor@email.com"}
+	lead := models.NewLead(ebook, client, "token", true)
+
+	assert.True(t, lead.Confirm(now))
+	assert.True(t, lead.IsConfirmed())
+	assert.NotNil(t, lead.Client.EmailConfirmedAt)
+	assert.True(t, lead.Client.MarketingConsent)
+	assert.NotNil(t, lead.Client.MarketingConsentAt)
+
+	assert.False(t, lead.Confirm(now.Add(time.Hour)))
+	assert.Equal(t, now, *lead.ConfirmedAt)
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MinLicenseSeats = 2
+	MaxLicenseSeats = 500
+)
+
+var (
+	ErrLicenseSeatsRange     = fmt.Errorf("a licença para equipe deve ter entre %d e %d licenças", MinLicenseSeats, MaxLicenseSeats)
+	ErrLicenseNoSeats        = errors.New("todas as licenças já foram atribuídas")
+	ErrSeatInvalidEmail      = errors.New("e-mail inválido")
+	ErrSeatAlreadyAssigned   = errors.New("este e-mail já recebeu uma licença")
+	ErrSeatAlreadyDownloaded = errors.New("a licença não pode ser transferida depois do primeiro download")
+	ErrSeatNotFound          = errors.New("licença não encontrada")
+)
+
+// License agrupa as licenças de um pedido para equipe. Quem comprou é o gestor:
+// pela página de gestão, atribui cada licença a uma pessoa, que recebe a própria
+// compra (e a própria marca d'água).
+type License struct {
+	gorm.Model
+	OrderID     uint           `json:"order_id" gorm:"uniqueIndex"`
+	Order       Order          `gorm:"foreignKey:OrderID"`
+	CreatorID   uint           `json:"creator_id" gorm:"index"`
+	EbookID     uint           `json:"ebook_id" gorm:"index"`
+	Ebook       Ebook          `gorm:"foreignKey:EbookID"`
+	ManagerID   uint           `json:"manager_id" gorm:"index"`
+	Manager     Client         `gorm:"foreignKey:ManagerID"`
+	Seats       int            `json:"seats"`
+	Token       string         `json:"token" gorm:"uniqueIndex"` // link da página de gestão
+	SentAt      *time.Time     `json:"sent_at"`                  // envio do link de gestão ao gestor
+	Assignments []*LicenseSeat `gorm:"foreignKey:LicenseID"`
+}
+
+// LicenseSeat é uma licença atribuída a uma pessoa
+type LicenseSeat struct {
+	gorm.Model
+	LicenseID  uint     `json:"license_id" gorm:"index"`
+	Name       string   `json:"name"`
+	Email      string   `json:"email"`
+	ClientID   uint     `json:"client_id" gorm:"index"`
+	Client     Client   `gorm:"foreignKey:ClientID"`
+	PurchaseID uint     `json:"purchase_id"`
+	Purchase   Purchase `gorm:"foreignKey:PurchaseID"`
+}
+
+func NewLicense(order *Order, token string) (*License, error) {
+	if !order.IsLicense() {
+		return nil, ErrLicenseSeatsRange
+	}
+
+	return &License{
+		OrderID:   order.ID,
+		CreatorID: order.CreatorID,
+		EbookID:   order.EbookID,
+		ManagerID: order.ClientID,
+		Seats:     order.Items[0].GetQuantity(),
+		Token:     token,
+	}, nil
+}
+
+func (l *License) UsedSeats() int {
+	return len(l.Assignments)
+}
+
+func (l *License) AvailableSeats() int {
+	return l.Seats - l.UsedSeats()
+}
+
+// FindSeat busca a licença atribuída pelo ID
+func (l *License) FindSeat(id uint) *LicenseSeat {
+	for _, seat := range l.Assignments {
+		if seat.ID == id {
+			return seat
+		}
+	}
+	return nil
+}
+
+// CheckEmail valida o e-mail de quem vai receber uma licença e confere se ainda não recebeu outra
+func (l *License) CheckEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		return "", ErrSeatInvalidEmail
+	}
+	for _, seat := range l.Assignments {
+		if strings.EqualFold(seat.Email, email) {
+			return "", ErrSeatAlreadyAssigned
+		}
+	}
+	return email, nil
+}
+
+// HasDownloaded indica se a pessoa já baixou o ebook pela licença
+func (s *LicenseSeat) HasDownloaded() bool {
+	return s.Purchase.DownloadsUsed > 0
+}
+
+// CanReassign só permite transferir a licença antes do primeiro download
+func (s *LicenseSeat) CanReassign() error {
+	if s.HasDownloaded() {
+		return ErrSeatAlreadyDownloaded
+	}
+	return nil
+}
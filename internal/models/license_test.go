@@ -0,0 +1,57 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLicenseOrder(t *testing.T) {
+	item := models.OrderItem{EbookID: 3, UnitPrice: 4000, Currency: money.BRL}
+
+	order, err := models.NewLicenseOrder(item, 1, 2, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(80000), order.Subtotal)
+	assert.Equal(t, int64(80000), order.Items[0].Amount())
+	assert.True(t, order.IsLicense())
+
+	_, err = models.NewLicenseOrder(item, 1, 2, 1)
+	assert.ErrorIs(t, err, models.ErrLicenseSeatsRange)
+	_, err = models.NewLicenseOrder(item, 1, 2, models.MaxLicenseSeats+1)
+	assert.ErrorIs(t, err, models.ErrLicenseSeatsRange)
+
+	single := models.NewOrder(1, 2, 3, 4000, 0, money.BRL)
+	single.Items = []models.OrderItem{item}
+	assert.False(t, single.IsLicense())
+	assert.Equal(t, int64(4000), single.Items[0].Amount())
+}
+
+func TestLicense_Seats(t *testing.T) {
+	order, _ := models.NewLicenseOrder(models.OrderItem{EbookID: 3, UnitPrice: 4000}, 1, 2, 3)
+	order.ID = 10
+	license, err := models.NewLicense(order, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), license.ManagerID)
+	assert.Equal(t, 3, license.AvailableSeats())
+
+	seat := &models.LicenseSeat{Email: "maria@empresa.com"}
+	license.Assignments = []*models.LicenseSeat{seat}
+	assert.Equal(t, 2, license.AvailableSeats())
+
+	email, err := license.CheckEmail(" Joao@Empresa.com ")
+	assert.NoError(t, err)
+	assert.Equal(t, "joao@empresa.com", email)
+	_, err = license.CheckEmail("MARIA@empresa.com")
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyAssigned)
+	_, err = license.CheckEmail("maria")
+	assert.ErrorIs(t, err, models.ErrSeatInvalidEmail)
+
+	assert.NoError(t, seat.CanReassign())
+	seat.Purchase.DownloadsUsed = 1
+	assert.ErrorIs(t, seat.CanReassign(), models.ErrSeatAlreadyDownloaded)
+
+	_, err = models.NewLicense(models.NewOrder(1, 2, 3, 4000, 0, money.BRL), "token")
+	assert.ErrorIs(t, err, models.ErrLicenseSeatsRange)
+}
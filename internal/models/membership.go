@@ -0,0 +1,163 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	MembershipIntervalMonth = "month"
+	MembershipIntervalYear  = "year"
+)
+
+const (
+	MemberStatusPending  = "pending"  // aguardando o pagamento do checkout
+	MemberStatusActive   = "active"   // cobrança em dia, com acesso ao catálogo
+	MemberStatusPastDue  = "past_due" // cobrança recusada, acesso suspenso
+	MemberStatusCanceled = "canceled" // assinatura encerrada
+)
+
+// Tolerância após o fim do período pago, para a renovação chegar pelo webhook
+const MemberAccessGrace = 2 * 24 * time.Hour
+
+var (
+	ErrMembershipNameRequired    = errors.New("o nome da assinatura é obrigatório")
+	ErrMembershipInvalidInterval = errors.New("periodicidade da assinatura inválida")
+	ErrMembershipUnavailable     = errors.New("esta assinatura não está disponível")
+	ErrMemberNotActive           = errors.New("esta assinatura não está ativa")
+)
+
+// Membership é uma assinatura vendida pelo criador: enquanto estiver em dia, o
+// assinante tem acesso a todos os ebooks ativos do catálogo, inclusive os novos
+type Membership struct {
+	gorm.Model
+	CreatorID   uint        `json:"creator_id" gorm:"index"`
+	Creator     Creator     `gorm:"foreignKey:CreatorID"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Interval    string      `json:"interval" gorm:"default:'month'"`
+	Active      bool        `json:"active" gorm:"default:true"`
+}
+
+func NewMembership(creatorID uint, name, description string, price money.Money, interval string) (*Membership, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrMembershipNameRequired
+	}
+	if price.Amount <= 0 {
+		return nil, ErrInvalidEbookPrice
+	}
+	if interval != MembershipIntervalMonth && interval != MembershipIntervalYear {
+		return nil, ErrMembershipInvalidInterval
+	}
+
+	return &Membership{
+		CreatorID:   creatorID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Price:       price,
+		Interval:    interval,
+		Active:      true,
+	}, nil
+}
+
+func (m *Membership) GetValue() string {
+	return m.Price.Format()
+}
+
+func (m *Membership) GetIntervalLabel() string {
+	if m.Interval == MembershipIntervalYear {
+		return "por ano"
+	}
+	return "por mês"
+}
+
+// Member é a assinatura de um cliente. As compras do catálogo ficam vinculadas a
+// ela (Purchase.MemberID) e expiram junto com o período pago.
+type Member struct {
+	gorm.Model
+	MembershipID          uint       `json:"membership_id" gorm:"index"`
+	Membership            Membership `gorm:"foreignKey:MembershipID"`
+	CreatorID             uint       `json:"creator_id" gorm:"index"`
+	ClientID              uint       `json:"client_id" gorm:"index"`
+	Client                Client     `gorm:"foreignKey:ClientID"`
+	Status                string     `json:"status" gorm:"index;default:'pending'"`
+	Token                 string     `json:"token" gorm:"uniqueIndex"` // link da área do assinante
+	GatewaySessionID      string     `json:"gateway_session_id" gorm:"index"`
+	GatewaySubscriptionID string     `json:"gateway_subscription_id" gorm:"index"`
+	CurrentPeriodEnd      *time.Time `json:"current_period_end"`
+	CancelAt              *time.Time `json:"cancel_at"` // cancelamento agendado pelo assinante
+	EndedAt               *time.Time `json:"ended_at"`
+}
+
+func NewMember(membership *Membership, clientID uint, token string) *Member {
+	return &Member{
+		MembershipID: membership.ID,
+		Membership:   *membership,
+		CreatorID:    membership.CreatorID,
+		ClientID:     clientID,
+		Status:       MemberStatusPending,
+		Token:        token,
+	}
+}
+
+func (m *Member) IsActive() bool {
+	return m.Status == MemberStatusActive
+}
+
+// HasAccess indica se o assinante pode baixar os ebooks do catálogo agora
+func (m *Member) HasAccess(now time.Time) bool {
+	return m.IsActive() && now.Before(m.AccessUntil())
+}
+
+// AccessUntil é a validade das compras do catálogo: o fim do período pago mais a tolerância
+func (m *Member) AccessUntil() time.Time {
+	if m.CurrentPeriodEnd == nil {
+		return time.Time{}
+	}
+	return m.CurrentPeriodEnd.Add(MemberAccessGrace)
+}
+
+// Activate registra o pagamento do período e retorna se a assinatura acabou de ser ativada
+func (m *Member) Activate(subscriptionID string, periodEnd time.Time) bool {
+	started := m.Status == MemberStatusPending
+	if subscriptionID != "" {
+		m.GatewaySubscriptionID = subscriptionID
+	}
+	m.Status = MemberStatusActive
+	m.CurrentPeriodEnd = &periodEnd
+	m.EndedAt = nil
+	return started
+}
+
+// Suspend suspende o acesso depois de uma cobrança recusada
+func (m *Member) Suspend() {
+	m.Status = MemberStatusPastDue
+}
+
+// End encerra a assinatura
+func (m *Member) End(now time.Time) {
+	m.Status = MemberStatusCanceled
+	m.EndedAt = &now
+}
+
+func (m *Member) GetStatusLabel() string {
+	switch m.Status {
+	case MemberStatusActive:
+		if m.CancelAt != nil {
+			return "Cancelamento agendado"
+		}
+		return "Ativa"
+	case MemberStatusPastDue:
+		return "Pagamento pendente"
+	case MemberStatusCanceled:
+		return "Cancelada"
+	default:
+		return "Aguardando pagamento"
+	}
+}
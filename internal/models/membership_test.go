@@ -0,0 +1,50 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMembership(t *testing.T) {
+	membership, err := models.NewMembership(1, " Clube ", "", money.New(2990, money.BRL), models.MembershipIntervalYear)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clube", membership.Name)
+	assert.True(t, membership.Active)
+	assert.Equal(t, "por ano", membership.GetIntervalLabel())
+
+	_, err = models.NewMembership(1, "", "", money.New(2990, money.BRL), models.MembershipIntervalMonth)
+	assert.ErrorIs(t, err, models.ErrMembershipNameRequired)
+	_, err = models.NewMembership(1, "Clube", "", money.New(0, money.BRL), models.MembershipIntervalMonth)
+	assert.ErrorIs(t, err, models.ErrInvalidEbookPrice)
+	_, err = models.NewMembership(1, "Clube", "", money.New(2990, money.BRL), "week")
+	assert.ErrorIs(t, err, models.ErrMembershipInvalidInterval)
+}
+
+func TestMember_AccessFollowsPaidPeriod(t *testing.T) {
+	membership, _ := models.NewMembership(1, "Clube", "", money.New(2990, money.BRL), models.MembershipIntervalMonth)
+	member := models.NewMember(membership, 2, "token")
+	now := time.Now()
+	assert.False(t, member.HasAccess(now))
+
+	assert.True(t, member.Activate("sub_1", now.Add(-time.Hour)))
+	assert.Equal(t, "sub_1", member.GatewaySubscriptionID)
+	// Dentro da tolerância, enquanto a renovação não chega
+	assert.True(t, member.HasAccess(now))
+	assert.False(t, member.HasAccess(now.Add(models.MemberAccessGrace)))
+
+	// Renovação não é uma nova adesão
+	assert.False(t, member.Activate("", now.AddDate(0, 1, 0)))
+	assert.Equal(t, "sub_1", member.GatewaySubscriptionID)
+
+	member.Suspend()
+	assert.False(t, member.HasAccess(now))
+	assert.Equal(t, "Pagamento pendente", member.GetStatusLabel())
+
+	member.End(now)
+	assert.False(t, member.HasAccess(now))
+	assert.Equal(t, "Cancelada", member.GetStatusLabel())
+}
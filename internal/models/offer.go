@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	OfferTypeBump   = "bump"   // caixa de seleção no checkout do ebook
+	OfferTypeUpsell = "upsell" // oferta de um clique após a compra
+)
+
+var (
+	ErrInvalidOfferType    = errors.New("tipo de oferta inválido")
+	ErrOfferSameEbook      = errors.New("a oferta precisa ser de outro ebook")
+	ErrOfferForeignEbook   = errors.New("a oferta só pode ser de um ebook do próprio autor")
+	ErrOfferMixedCurrency  = errors.New("a oferta precisa estar na mesma moeda do ebook")
+	ErrOfferInvalidPrice   = errors.New("o preço da oferta deve ser maior que zero")
+	ErrOfferNotAvailable   = errors.New("oferta não disponível")
+	ErrOfferAlreadyClaimed = errors.New("você já aceitou esta oferta")
+)
+
+// Offer é uma oferta complementar exibida na compra de um ebook: como order bump
+// no checkout ou como upsell de um clique na página de sucesso
+type Offer struct {
+	gorm.Model
+	Type         string      `json:"type" gorm:"index"`
+	EbookID      uint        `json:"ebook_id" gorm:"index"` // ebook em cuja compra a oferta aparece
+	Ebook        Ebook       `gorm:"foreignKey:EbookID"`
+	OfferEbookID uint        `json:"offer_ebook_id"` // ebook oferecido
+	OfferEbook   Ebook       `gorm:"foreignKey:OfferEbookID"`
+	Headline     string      `json:"headline"`
+	Price        money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Active       bool        `json:"active" gorm:"default:true"`
+
+	// Conversão
+	Impressions int `json:"impressions" gorm:"default:0"`
+	Conversions int `json:"conversions" gorm:"default:0"`
+}
+
+func NewOffer(offerType string, ebook, offerEbook *Ebook, price money.Money, headline string) (*Offer, error) {
+	if offerType != OfferTypeBump && offerType != OfferTypeUpsell {
+		return nil, ErrInvalidOfferType
+	}
+	if ebook.ID == offerEbook.ID {
+		return nil, ErrOfferSameEbook
+	}
+	if ebook.CreatorID != offerEbook.CreatorID {
+		return nil, ErrOfferForeignEbook
+	}
+	if price.Currency != ebook.Price.Currency {
+		return nil, ErrOfferMixedCurrency
+	}
+	if price.Amount <= 0 {
+		return nil, ErrOfferInvalidPrice
+	}
+
+	if strings.TrimSpace(headline) == "" {
+		headline = "Leve também " + offerEbook.Title
+	}
+
+	return &Offer{
+		Type:         offerType,
+		EbookID:      ebook.ID,
+		OfferEbookID: offerEbook.ID,
+		OfferEbook:   *offerEbook,
+		Headline:     headline,
+		Price:        price,
+		Active:       true,
+	}, nil
+}
+
+// OrderItem é o item do pedido gerado quando a oferta é aceita
+func (o *Offer) OrderItem() OrderItem {
+	item := NewOrderItem(&o.OfferEbook)
+	item.EbookID = o.OfferEbookID
+	item.UnitPrice = o.Price.Amount
+	item.Currency = o.Price.Currency
+	item.OfferID = &o.ID
+	return item
+}
+
+func (o *Offer) IsBump() bool {
+	return o.Type == OfferTypeBump
+}
+
+func (o *Offer) IsUpsell() bool {
+	return o.Type == OfferTypeUpsell
+}
+
+// ConversionRate é o percentual de exibições que resultaram em venda paga
+func (o *Offer) ConversionRate() float64 {
+	if o.Impressions == 0 {
+		return 0
+	}
+	return float64(o.Conversions) * 100 / float64(o.Impressions)
+}
+
+func (o *Offer) GetConversionRate() string {
+	return strings.Replace(fmt.Sprintf("%.1f%%", o.ConversionRate()), ".", ",", 1)
+}
+
+func (o *Offer) GetValue() string {
+	return o.Price.Format()
+}
+
+func (o *Offer) GetTypeLabel() string {
+	if o.IsBump() {
+		return "Order bump"
+	}
+	return "Upsell"
+}
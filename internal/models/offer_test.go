@@ -0,0 +1,62 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewOffer_Validation(t *testing.T) {
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 7, Price: money.New(4990, money.BRL)}
+	other := &models.Ebook{Model: gorm.Model{ID: 2}, CreatorID: 7, Title: "Guia Prático", Price: money.New(2990, money.BRL)}
+	foreign := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 8, Price: money.New(2990, money.BRL)}
+
+	_, err := models.NewOffer("desconto", ebook, other, money.New(1990, money.BRL), "")
+	assert.ErrorIs(t, err, models.ErrInvalidOfferType)
+
+	_, err = models.NewOffer(models.OfferTypeBump, ebook, ebook, money.New(1990, money.BRL), "")
+	assert.ErrorIs(t, err, models.ErrOfferSameEbook)
+
+	_, err = models.NewOffer(models.OfferTypeBump, ebook, foreign, money.New(1990, money.BRL), "")
+	assert.ErrorIs(t, err, models.ErrOfferForeignEbook)
+
+	_, err = models.NewOffer(models.OfferTypeBump, ebook, other, money.New(1990, money.USD), "")
+	assert.ErrorIs(t, err, models.ErrOfferMixedCurrency)
+
+	_, err = models.NewOffer(models.OfferTypeBump, ebook, other, money.New(0, money.BRL), "")
+	assert.ErrorIs(t, err, models.ErrOfferInvalidPrice)
+
+	offer, err := models.NewOffer(models.OfferTypeUpsell, ebook, other, money.New(1990, money.BRL), " ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Leve também Guia Prático", offer.Headline)
+	assert.True(t, offer.IsUpsell())
+}
+
+func TestOrder_AddOffer_UsesOfferPrice(t *testing.T) {
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 7, Price: money.New(4990, money.BRL)}
+	other := &models.Ebook{Model: gorm.Model{ID: 2}, CreatorID: 7, Price: money.New(2990, money.BRL)}
+	offer, err := models.NewOffer(models.OfferTypeBump, ebook, other, money.New(1990, money.BRL), "")
+	assert.NoError(t, err)
+	offer.ID = 5
+
+	order := models.NewOrder(7, 9, ebook.ID, ebook.Price.Amount, 0, money.BRL)
+	order.Items = []models.OrderItem{models.NewOrderItem(ebook)}
+	order.AddOffer(offer)
+
+	assert.Equal(t, int64(6980), order.Total)
+	assert.Equal(t, []uint{1, 2}, order.EbookIDs())
+	assert.Equal(t, []uint{5}, order.OfferIDs())
+	assert.Equal(t, int64(1990), order.Items[1].UnitPrice)
+}
+
+func TestOffer_ConversionRate(t *testing.T) {
+	offer := &models.Offer{}
+	assert.Equal(t, "0,0%", offer.GetConversionRate())
+
+	offer.Impressions = 40
+	offer.Conversions = 5
+	assert.Equal(t, "12,5%", offer.GetConversionRate())
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	OrderStatusPending  = "pending"
+	OrderStatusPaid     = "paid"
+	OrderStatusRefunded = "refunded"
+	OrderStatusDisputed = "disputed"
+)
+
+var ErrInvalidOrderTransition = errors.New("transição de status do pedido inválida")
+
+// Order registra o que foi vendido e por quanto, independente do acesso concedido
+type Order struct {
+	gorm.Model
+	CreatorID        uint           `json:"creator_id" gorm:"index"`
+	Creator          Creator        `gorm:"foreignKey:CreatorID"`
+	ClientID         uint           `json:"client_id" gorm:"index"`
+	Client           Client         `gorm:"foreignKey:ClientID"`
+	EbookID          uint           `json:"ebook_id" gorm:"index"` // ebook principal (primeiro item)
+	Ebook            Ebook          `gorm:"foreignKey:EbookID"`
+	BundleID         *uint          `json:"bundle_id" gorm:"index"`    // preenchido quando o pedido é de um kit
+	AffiliateID      *uint          `json:"affiliate_id" gorm:"index"` // afiliado que indicou a compra
+	IsGift           bool           `json:"is_gift"`                   // as compras do presente são criadas no resgate
+	Subtotal         int64          `json:"subtotal"`                  // em centavos
+	Discount         int64          `json:"discount"`                  // em centavos
+	Total            int64          `json:"total"`                     // em centavos
+	Currency         money.Currency `json:"currency" gorm:"size:3;default:'BRL'"`
+	Status           string         `json:"status" gorm:"index;default:'pending'"`
+	Gateway          string         `json:"gateway"`
+	GatewaySessionID string         `json:"gateway_session_id" gorm:"index"`
+	PaidAt           *time.Time     `json:"paid_at"`
+	UpsellKey        string         `json:"upsell_key" gorm:"uniqueIndex:idx_orders_upsell_key,where:upsell_key <> ''"`                         // um pedido por (pedido de origem, oferta)
+	ChargeStartedAt  *time.Time     `json:"charge_started_at"`                                                                                  // cobrança do upsell no cartão salvo em andamento
+	MemberID         *uint          `json:"member_id" gorm:"index"`                                                                             // assinante do catálogo cobrado pelo pedido
+	GatewayInvoiceID string         `json:"gateway_invoice_id" gorm:"uniqueIndex:idx_orders_gateway_invoice_id,where:gateway_invoice_id <> ''"` // um pedido por fatura da assinatura
+	Items            []OrderItem
+	Payments         []Payment
+	Purchases        []Purchase
+}
+
+func NewOrder(creatorID, clientID, ebookID uint, subtotal, discount int64, currency money.Currency) *Order {
+	return &Order{
+		CreatorID: creatorID,
+		ClientID:  clientID,
+		EbookID:   ebookID,
+		Subtotal:  subtotal,
+		Discount:  discount,
+		Total:     subtotal - discount,
+		Currency:  currency,
+		Status:    OrderStatusPending,
+		Gateway:   "stripe",
+	}
+}
+
+// NewCartOrder cria um pedido com um item para cada ebook do carrinho
+func NewCartOrder(cart *Cart, clientID uint) *Order {
+	now := time.Now()
+	items := make([]OrderItem, 0, len(cart.Ebooks))
+	var subtotal int64
+	for _, ebook := range cart.Ebooks {
+		item := ebook.OrderItem(now)
+		subtotal += item.UnitPrice
+		items = append(items, item)
+	}
+
+	var ebookID uint
+	if !cart.IsEmpty() {
+		ebookID = cart.Ebooks[0].ID
+	}
+
+	order := NewOrder(cart.CreatorID, clientID, ebookID, subtotal, 0, cart.Currency())
+	order.Items = items
+	return order
+}
+
+// NewBundleOrder cria um pedido do kit com um item para cada ebook incluído
+func NewBundleOrder(bundle *Bundle, clientID uint) *Order {
+	var ebookID uint
+	if len(bundle.Ebooks) > 0 {
+		ebookID = bundle.Ebooks[0].ID
+	}
+
+	order := NewOrder(bundle.CreatorID, clientID, ebookID, bundle.Price.Amount, 0, bundle.Price.Currency)
+	order.BundleID = &bundle.ID
+	order.Items = bundle.Items()
+	return order
+}
+
+// AddOffer inclui no pedido o ebook de uma oferta aceita, somando o preço da oferta ao total
+func (o *Order) AddOffer(offer *Offer) {
+	o.Items = append(o.Items, offer.OrderItem())
+	o.Subtotal += offer.Price.Amount
+	o.Total = o.Subtotal - o.Discount
+}
+
+// ApplyDiscount define o desconto do pedido, limitado ao subtotal
+func (o *Order) ApplyDiscount(amount int64) {
+	if amount > o.Subtotal {
+		amount = o.Subtotal
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	o.Discount = amount
+	o.Total = o.Subtotal - o.Discount
+}
+
+// NewOfferOrder cria o pedido de um upsell aceito, com o ebook oferecido como único item
+func NewOfferOrder(offer *Offer, creatorID, clientID uint) *Order {
+	order := NewOrder(creatorID, clientID, offer.OfferEbookID, offer.Price.Amount, 0, offer.Price.Currency)
+	order.Items = []OrderItem{offer.OrderItem()}
+	return order
+}
+
+// UpsellKey identifica o pedido do upsell de uma oferta aceita após o pedido de
+// origem; também é a chave de idempotência da cobrança no gateway
+func UpsellKey(parentOrderID, offerID uint) string {
+	return fmt.Sprintf("upsell_%d_%d", parentOrderID, offerID)
+}
+
+// NewLicenseOrder cria o pedido de licenças para equipe: um único item do ebook
+// com a quantidade de licenças compradas
+func NewLicenseOrder(item OrderItem, creatorID, clientID uint, seats int) (*Order, error) {
+	if seats < MinLicenseSeats || seats > MaxLicenseSeats {
+		return nil, ErrLicenseSeatsRange
+	}
+
+	item.Quantity = seats
+	order := NewOrder(creatorID, clientID, item.EbookID, item.Amount(), 0, item.Currency)
+	order.Items = []OrderItem{item}
+	return order, nil
+}
+
+// IsLicense indica um pedido de licenças para equipe; as compras são criadas
+// conforme o gestor atribui as licenças
+func (o *Order) IsLicense() bool {
+	for _, item := range o.Items {
+		if item.GetQuantity() > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// NewMembershipOrder cria o pedido de um período pago da assinatura do catálogo. O
+// pedido não tem ebook: o acesso vem da renovação do assinante.
+func NewMembershipOrder(member *Member, invoiceID string, amount int64, currency money.Currency) *Order {
+	order := NewOrder(member.CreatorID, member.ClientID, 0, amount, 0, currency)
+	order.MemberID = &member.ID
+	order.GatewayInvoiceID = invoiceID
+	return order
+}
+
+// IsMembership indica o pedido de um período da assinatura do catálogo
+func (o *Order) IsMembership() bool {
+	return o.MemberID != nil
+}
+
+// OfferIDs retorna as ofertas aceitas no pedido
+func (o *Order) OfferIDs() []uint {
+	var ids []uint
+	for _, item := range o.Items {
+		if item.OfferID != nil {
+			ids = append(ids, *item.OfferID)
+		}
+	}
+	return ids
+}
+
+// EbookIDs retorna os ebooks liberados pelo pedido. Pedidos anteriores aos itens
+// têm apenas o ebook principal; os de assinatura, nenhum.
+func (o *Order) EbookIDs() []uint {
+	if o.IsMembership() {
+		return nil
+	}
+	if len(o.Items) == 0 {
+		return []uint{o.EbookID}
+	}
+	ids := make([]uint, 0, len(o.Items))
+	for _, item := range o.Items {
+		ids = append(ids, item.EbookID)
+	}
+	return ids
+}
+
+func (o *Order) IsPaid() bool {
+	return o.Status == OrderStatusPaid
+}
+
+func (o *Order) MarkPaid() error {
+	if o.Status != OrderStatusPending && o.Status != OrderStatusDisputed {
+		return ErrInvalidOrderTransition
+	}
+	now := time.Now()
+	o.Status = OrderStatusPaid
+	if o.PaidAt == nil {
+		o.PaidAt = &now
+	}
+	return nil
+}
+
+func (o *Order) MarkRefunded() error {
+	if o.Status != OrderStatusPaid && o.Status != OrderStatusDisputed {
+		return ErrInvalidOrderTransition
+	}
+	o.Status = OrderStatusRefunded
+	return nil
+}
+
+func (o *Order) MarkDisputed() error {
+	if o.Status != OrderStatusPaid {
+		return ErrInvalidOrderTransition
+	}
+	o.Status = OrderStatusDisputed
+	return nil
+}
+
+func (o *Order) TotalAmount() money.Money {
+	return money.New(o.Total, o.Currency)
+}
+
+func (o *Order) GetTotal() string {
+	return o.TotalAmount().Format()
+}
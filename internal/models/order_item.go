@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+// OrderItem registra cada ebook vendido no pedido e o preço cobrado por ele
+type OrderItem struct {
+	gorm.Model
+	OrderID   uint           `json:"order_id" gorm:"index"`
+	EbookID   uint           `json:"ebook_id" gorm:"index"`
+	Ebook     Ebook          `gorm:"foreignKey:EbookID"`
+	BundleID  *uint          `json:"bundle_id" gorm:"index"` // kit de origem, quando vendido em um kit
+	OfferID   *uint          `json:"offer_id" gorm:"index"`  // order bump ou upsell aceito
+	Title     string         `json:"title"`
+	UnitPrice int64          `json:"unit_price"` // em centavos
+	ListPrice int64          `json:"list_price"` // preço do ebook na data do pedido, em centavos
+	Currency  money.Currency `json:"currency" gorm:"size:3;default:'BRL'"`
+
+	PricePeriodID *uint `json:"price_period_id" gorm:"index"` // promoção em vigor na compra
+	Quantity      int   `json:"quantity" gorm:"default:1"`    // licenças compradas para equipe
+}
+
+func NewOrderItem(ebook *Ebook) OrderItem {
+	return OrderItem{
+		EbookID:   ebook.ID,
+		Title:     ebook.Title,
+		UnitPrice: ebook.Price.Amount,
+		ListPrice: ebook.Price.Amount,
+		Quantity:  1,
+		Currency:  ebook.Price.Currency,
+	}
+}
+
+// GetQuantity trata como 1 os itens gravados antes das licenças para equipe
+func (i *OrderItem) GetQuantity() int {
+	if i.Quantity < 1 {
+		return 1
+	}
+	return i.Quantity
+}
+
+func (i *OrderItem) GetUnitPrice() string {
+	return money.New(i.UnitPrice, i.Currency).Format()
+}
+
+// Amount é o valor cobrado pelo item, considerando a quantidade de licenças
+func (i *OrderItem) Amount() int64 {
+	return i.UnitPrice * int64(i.GetQuantity())
+}
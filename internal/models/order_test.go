@@ -0,0 +1,49 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrder_CalculatesTotal(t *testing.T) {
+	order := models.NewOrder(1, 2, 3, 5000, 1000, money.BRL)
+
+	assert.Equal(t, int64(4000), order.Total)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	assert.Equal(t, "R$ 40,00", order.GetTotal())
+}
+
+func TestOrder_StatusTransitions(t *testing.T) {
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+
+	assert.ErrorIs(t, order.MarkRefunded(), models.ErrInvalidOrderTransition)
+	assert.ErrorIs(t, order.MarkDisputed(), models.ErrInvalidOrderTransition)
+
+	assert.NoError(t, order.MarkPaid())
+	assert.True(t, order.IsPaid())
+	assert.NotNil(t, order.PaidAt)
+	assert.ErrorIs(t, order.MarkPaid(), models.ErrInvalidOrderTransition)
+
+	assert.NoError(t, order.MarkDisputed())
+	assert.Equal(t, models.OrderStatusDisputed, order.Status)
+
+	assert.NoError(t, order.MarkRefunded())
+	assert.Equal(t, models.OrderStatusRefunded, order.Status)
+}
+
+func TestPayment_PartialAndFullRefund(t *testing.T) {
+	payment := models.NewPayment(1, "stripe", "pi_1", 5000, money.BRL)
+	payment.Fee = 250
+
+	payment.Refund(2000)
+	assert.Equal(t, models.PaymentStatusPaid, payment.Status)
+	assert.False(t, payment.IsFullyRefunded())
+	assert.Equal(t, int64(2750), payment.NetAmount())
+
+	payment.Refund(5000)
+	assert.Equal(t, models.PaymentStatusRefunded, payment.Status)
+	assert.True(t, payment.IsFullyRefunded())
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+const (
+	PaymentStatusPending  = "pending"
+	PaymentStatusPaid     = "paid"
+	PaymentStatusRefunded = "refunded"
+	PaymentStatusDisputed = "disputed"
+)
+
+// Payment registra a movimentação financeira confirmada pelo gateway para um pedido
+type Payment struct {
+	gorm.Model
+	OrderID          uint           `json:"order_id" gorm:"index"`
+	Order            *Order         `gorm:"foreignKey:OrderID"`
+	Gateway          string         `json:"gateway"`
+	GatewayPaymentID string         `json:"gateway_payment_id" gorm:"index"`
+	Amount           int64          `json:"amount"`          // em centavos
+	Fee              int64          `json:"fee"`             // taxa do gateway em centavos
+	RefundedAmount   int64          `json:"refunded_amount"` // em centavos
+	PlatformFee      int64          `json:"platform_fee"`    // taxa da plataforma em centavos
+	Currency         money.Currency `json:"currency" gorm:"size:3"`
+	Status           string         `json:"status" gorm:"index;default:'pending'"`
+	PaidAt           *time.Time     `json:"paid_at"`
+	RefundedAt       *time.Time     `json:"refunded_at"`
+	// BalanceEntries são os lançamentos novos no saldo do criador, gravados junto com o pagamento
+	BalanceEntries []*BalanceEntry `gorm:"foreignKey:PaymentID"`
+}
+
+func NewPayment(orderID uint, gateway, gatewayPaymentID string, amount int64, currency money.Currency) *Payment {
+	now := time.Now()
+	return &Payment{
+		OrderID:          orderID,
+		Gateway:          gateway,
+		GatewayPaymentID: gatewayPaymentID,
+		Amount:           amount,
+		Currency:         currency,
+		Status:           PaymentStatusPaid,
+		PaidAt:           &now,
+	}
+}
+
+// NetAmount retorna o valor recebido após taxas e reembolsos
+func (p *Payment) NetAmount() int64 {
+	return p.Amount - p.Fee - p.RefundedAmount
+}
+
+// Refund registra o valor total reembolsado; reembolsos parciais mantêm o pagamento como pago
+func (p *Payment) Refund(totalRefunded int64) {
+	now := time.Now()
+	p.RefundedAmount = totalRefunded
+	p.RefundedAt = &now
+	if p.IsFullyRefunded() {
+		p.Status = PaymentStatusRefunded
+	}
+}
+
+func (p *Payment) IsFullyRefunded() bool {
+	return p.RefundedAmount >= p.Amount
+}
+
+// Dispute marca o pagamento como contestado e retém do saldo do criador o valor
+// ainda não reembolsado
+func (p *Payment) Dispute() {
+	if p.Status != PaymentStatusDisputed {
+		p.addBalanceEntry(BalanceEntryChargeback, -(p.Amount - p.RefundedAmount), time.Now())
+	}
+	p.Status = PaymentStatusDisputed
+}
+
+// ResolveDispute encerra a contestação. Ao vencer, o valor retido volta ao saldo;
+// ao perder, o pagamento passa a reembolsado sem novo débito, pois o valor já foi retido.
+func (p *Payment) ResolveDispute(won bool) {
+	if won {
+		if p.Status == PaymentStatusDisputed {
+			p.addBalanceEntry(BalanceEntryChargebackReversal, p.Amount-p.RefundedAmount, time.Now())
+		}
+		p.Status = PaymentStatusPaid
+		return
+	}
+	p.Refund(p.Amount)
+}
+
+// RecordSale lança no saldo do criador a venda e a taxa da plataforma, liberadas
+// para repasse em availableAt
+func (p *Payment) RecordSale(feePercent int, availableAt time.Time) {
+	p.PlatformFee = money.New(p.Amount, p.Currency).Percent(int64(feePercent)).Amount
+	p.addBalanceEntry(BalanceEntrySale, p.Amount, availableAt)
+	p.addBalanceEntry(BalanceEntryPlatformFee, -p.PlatformFee, availableAt)
+}
+
+// RecordRefund lança o reembolso feito desde previousRefunded e devolve ao criador
+// a parte proporcional da taxa da plataforma
+func (p *Payment) RecordRefund(previousRefunded int64) {
+	refunded := p.RefundedAmount - previousRefunded
+	if refunded <= 0 || p.Amount <= 0 {
+		return
+	}
+	now := time.Now()
+	p.addBalanceEntry(BalanceEntryRefund, -refunded, now)
+	p.addBalanceEntry(BalanceEntryFeeRefund, p.PlatformFee*refunded/p.Amount, now)
+}
+
+// addBalanceEntry só lança quando o pedido do pagamento está carregado, pois é
+// ele que identifica o criador
+func (p *Payment) addBalanceEntry(entryType string, amount int64, availableAt time.Time) {
+	if amount == 0 || p.Order == nil {
+		return
+	}
+	p.BalanceEntries = append(p.BalanceEntries, &BalanceEntry{
+		CreatorID:   p.Order.CreatorID,
+		OrderID:     p.OrderID,
+		Type:        entryType,
+		Amount:      amount,
+		Currency:    p.Currency,
+		AvailableAt: availableAt,
+	})
+}
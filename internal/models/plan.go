@@ -0,0 +1,188 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+// DefaultPlanSlug é o plano atribuído a novos criadores durante o período de teste
+const DefaultPlanSlug = "default_plan"
+
+const (
+	BillingMonthly = "monthly"
+	BillingYearly  = "yearly"
+)
+
+// PlanResource identifica um recurso limitado pelo plano
+type PlanResource string
+
+const (
+	ResourceEbooks       PlanResource = "ebooks"
+	ResourceClients      PlanResource = "clients"
+	ResourceStorage      PlanResource = "storage"
+	ResourceMonthlySends PlanResource = "monthly_sends"
+)
+
+// PlanFeature identifica um recurso liberado apenas em alguns planos
+type PlanFeature string
+
+const (
+	FeatureCustomWatermark PlanFeature = "custom_watermark"
+	FeatureAPIAccess       PlanFeature = "api_access"
+)
+
+// Unlimited indica que o recurso não possui limite no plano
+const Unlimited int64 = 0
+
+type Plan struct {
+	gorm.Model
+	Slug           string      `json:"slug" gorm:"uniqueIndex"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Position       int         `json:"position"`
+	Active         bool        `json:"active" gorm:"default:true"`
+	MonthlyPriceID string      `json:"monthly_price_id" gorm:"index"`
+	YearlyPriceID  string      `json:"yearly_price_id" gorm:"index"`
+	MonthlyPrice   money.Money `json:"monthly_price" gorm:"embedded;embeddedPrefix:monthly_price_"`
+	YearlyPrice    money.Money `json:"yearly_price" gorm:"embedded;embeddedPrefix:yearly_price_"`
+
+	// Limites (0 = ilimitado)
+	MaxEbooks       int64 `json:"max_ebooks"`
+	MaxClients      int64 `json:"max_clients"`
+	MaxStorageBytes int64 `json:"max_storage_bytes"`
+	MaxMonthlySends int64 `json:"max_monthly_sends"`
+
+	// Recursos
+	CustomWatermark bool `json:"custom_watermark"`
+	APIAccess       bool `json:"api_access"`
+}
+
+// DefaultPlans retorna o catálogo inicial de planos, sem os IDs de preço do gateway
+func DefaultPlans() []*Plan {
+	return []*Plan{
+		{
+			Slug:            DefaultPlanSlug,
+			Name:            "Essencial",
+			Description:     "Para quem está começando a vender seus e-books",
+			Position:        1,
+			Active:          true,
+			MonthlyPrice:    money.New(2990, money.BRL),
+			YearlyPrice:     money.New(29900, money.BRL),
+			MaxEbooks:       5,
+			MaxClients:      500,
+			MaxStorageBytes: 1 << 30, // 1 GB
+			MaxMonthlySends: 200,
+		},
+		{
+			Slug:            "pro",
+			Name:            "Profissional",
+			Description:     "Para criadores com catálogo e base de clientes em crescimento",
+			Position:        2,
+			Active:          true,
+			MonthlyPrice:    money.New(5990, money.BRL),
+			YearlyPrice:     money.New(59900, money.BRL),
+			MaxEbooks:       30,
+			MaxClients:      5000,
+			MaxStorageBytes: 10 << 30, // 10 GB
+			MaxMonthlySends: 2000,
+			CustomWatermark: true,
+		},
+		{
+			Slug:            "business",
+			Name:            "Empresarial",
+			Description:     "Sem limites de catálogo, clientes ou envios",
+			Position:        3,
+			Active:          true,
+			MonthlyPrice:    money.New(14990, money.BRL),
+			YearlyPrice:     money.New(149900, money.BRL),
+			MaxEbooks:       Unlimited,
+			MaxClients:      Unlimited,
+			MaxStorageBytes: 100 << 30, // 100 GB
+			MaxMonthlySends: Unlimited,
+			CustomWatermark: true,
+			APIAccess:       true,
+		},
+	}
+}
+
+// LimitFor retorna o limite do plano para o recurso informado
+func (p *Plan) LimitFor(resource PlanResource) int64 {
+	switch resource {
+	case ResourceEbooks:
+		return p.MaxEbooks
+	case ResourceClients:
+		return p.MaxClients
+	case ResourceStorage:
+		return p.MaxStorageBytes
+	case ResourceMonthlySends:
+		return p.MaxMonthlySends
+	default:
+		return Unlimited
+	}
+}
+
+// HasFeature informa se o plano libera o recurso
+func (p *Plan) HasFeature(feature PlanFeature) bool {
+	switch feature {
+	case FeatureCustomWatermark:
+		return p.CustomWatermark
+	case FeatureAPIAccess:
+		return p.APIAccess
+	default:
+		return false
+	}
+}
+
+// Allows informa se o uso atual somado ao novo consumo cabe no limite do plano
+func (p *Plan) Allows(resource PlanResource, used, amount int64) bool {
+	limit := p.LimitFor(resource)
+	if limit == Unlimited {
+		return true
+	}
+	return used+amount <= limit
+}
+
+// PriceIDFor retorna o ID do preço no gateway para a periodicidade informada
+func (p *Plan) PriceIDFor(interval string) string {
+	if interval == BillingYearly {
+		return p.YearlyPriceID
+	}
+	return p.MonthlyPriceID
+}
+
+func (p *Plan) PriceFor(interval string) money.Money {
+	if interval == BillingYearly {
+		return p.YearlyPrice
+	}
+	return p.MonthlyPrice
+}
+
+// IsUpgradeFrom indica se o plano é superior ao plano informado no catálogo
+func (p *Plan) IsUpgradeFrom(other *Plan) bool {
+	if other == nil {
+		return true
+	}
+	return p.Position > other.Position
+}
+
+// GetStorageLimit retorna o limite de armazenamento formatado para exibição
+func (p *Plan) GetStorageLimit() string {
+	if p.MaxStorageBytes == Unlimited {
+		return "Ilimitado"
+	}
+	return formatBytes(p.MaxStorageBytes)
+}
+
+// DisplayLimit retorna o limite do recurso formatado para exibição
+func (p *Plan) DisplayLimit(resource PlanResource) string {
+	limit := p.LimitFor(resource)
+	if limit == Unlimited {
+		return "Ilimitado"
+	}
+	if resource == ResourceStorage {
+		return p.GetStorageLimit()
+	}
+	return fmt.Sprintf("%d", limit)
+}
@@ -0,0 +1,39 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan_Allows(t *testing.T) {
+	plan := &models.Plan{MaxEbooks: 5, MaxClients: models.Unlimited}
+
+	assert.True(t, plan.Allows(models.ResourceEbooks, 4, 1))
+	assert.False(t, plan.Allows(models.ResourceEbooks, 5, 1))
+	assert.True(t, plan.Allows(models.ResourceClients, 100000, 1))
+}
+
+func TestPlan_PriceIDFor(t *testing.T) {
+	plan := &models.Plan{MonthlyPriceID: "price_month", YearlyPriceID: "price_year"}
+
+	assert.Equal(t, "price_month", plan.PriceIDFor(models.BillingMonthly))
+	assert.Equal(t, "price_year", plan.PriceIDFor(models.BillingYearly))
+}
+
+func TestPlan_IsUpgradeFrom(t *testing.T) {
+	plans := models.DefaultPlans()
+
+	assert.True(t, plans[1].IsUpgradeFrom(plans[0]))
+	assert.False(t, plans[0].IsUpgradeFrom(plans[1]))
+	assert.True(t, plans[0].IsUpgradeFrom(nil))
+}
+
+func TestPlan_DisplayLimit(t *testing.T) {
+	plans := models.DefaultPlans()
+
+	assert.Equal(t, "5", plans[0].DisplayLimit(models.ResourceEbooks))
+	assert.Equal(t, "1.0 GB", plans[0].DisplayLimit(models.ResourceStorage))
+	assert.Equal(t, "Ilimitado", plans[2].DisplayLimit(models.ResourceEbooks))
+}
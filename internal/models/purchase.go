@@ -12,10 +12,32 @@ type Purchase struct {
 	Ebook         Ebook     `gorm:"foreignKey:EbookID"`
 	ClientID      uint      `json:"client_id"`
 	Client        Client    `gorm:"foreignKey:ClientID"`
+	OrderID       *uint     `json:"order_id" gorm:"index"`
+	MemberID      *uint     `json:"member_id" gorm:"index"` // entregue pela assinatura do catálogo
 	ExpiresAt     time.Time `json:"expires_at"`
 	DownloadsUsed int       `json:"downloads_used"`
 	DownloadLimit int       `json:"download_limit"`
 	Downloads     []DownloadLog
+
+	// IPs distintos aceitos para download, zero sem limite. Os IPs anteriores a
+	// DevicesResetAt não contam.
+	DeviceLimit    int        `json:"device_limit"`
+	DevicesResetAt *time.Time `json:"devices_reset_at"`
+
+	// Último aviso ao comprador sobre arquivos liberados pelo cronograma do ebook
+	DripNotifiedAt *time.Time `json:"drip_notified_at"`
+
+	// Comprado em pré-venda; o link de download só é enviado no lançamento,
+	// registrado em ReleasedAt
+	AwaitingRelease bool       `json:"awaiting_release" gorm:"index"`
+	ReleasedAt      *time.Time `json:"released_at"`
+
+	// Sinais de compartilhamento do link. FlaggedAt coloca a compra na fila de
+	// revisão do criador; compras suspensas não baixam até serem liberadas.
+	SharingScore int        `json:"sharing_score"`
+	FlaggedAt    *time.Time `json:"flagged_at" gorm:"index"`
+	SuspendedAt  *time.Time `json:"suspended_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
 }
 
 func NewPurchase(ebookID, clientID uint) *Purchase {
@@ -38,6 +60,14 @@ func (p *Purchase) AvailableDownloads() bool {
 	return true
 }
 
+// RemainingDownloads retorna quantos downloads ainda cabem no limite, ou -1 sem limite
+func (p *Purchase) RemainingDownloads() int {
+	if p.DownloadLimit == -1 {
+		return -1
+	}
+	return max(p.DownloadLimit-p.DownloadsUsed, 0)
+}
+
 func (p *Purchase) IsExpired() bool {
 	if p.ExpiresAt.IsZero() {
 		return false
@@ -51,3 +81,130 @@ func (p *Purchase) UseDownload() {
 		Purchase: p,
 	})
 }
+
+// IsUpdateDownload indica se o arquivo ganhou versão nova depois da compra e o
+// comprador ainda não a baixou. Esse download não conta no limite.
+func (p *Purchase) IsUpdateDownload(file *File) bool {
+	if file.VersionedAt == nil || !file.VersionedAt.After(p.CreatedAt) {
+		return false
+	}
+	for _, download := range p.Downloads {
+		if download.FileID == file.ID && download.FileVersion == file.CurrentVersion() {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPendingUpdates indica se há versão nova de algum arquivo ainda não baixada
+func (p *Purchase) HasPendingUpdates() bool {
+	for _, file := range p.Ebook.Files {
+		if p.IsUpdateDownload(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// UseFileDownload registra o download da versão atual do arquivo, descontando do
+// limite apenas quando não é uma atualização
+func (p *Purchase) UseFileDownload(file *File, request DownloadRequest) {
+	free := p.IsUpdateDownload(file)
+	if !free {
+		p.DownloadsUsed++
+	}
+	p.Downloads = append(p.Downloads, DownloadLog{
+		Purchase:    p,
+		FileID:      file.ID,
+		FileVersion: file.CurrentVersion(),
+		Free:        free,
+		IP:          request.IP,
+		UserAgent:   request.UserAgent,
+		Bytes:       request.Bytes,
+	})
+}
+
+// Devices lista os IPs distintos que baixaram desde o último reinício
+func (p *Purchase) Devices() []string {
+	seen := make(map[string]bool)
+	var devices []string
+	for _, download := range p.Downloads {
+		if download.IP == "" || seen[download.IP] {
+			continue
+		}
+		if p.DevicesResetAt != nil && download.CreatedAt.Before(*p.DevicesResetAt) {
+			continue
+		}
+		seen[download.IP] = true
+		devices = append(devices, download.IP)
+	}
+	return devices
+}
+
+// AllowsDevice indica se o IP já é conhecido ou ainda cabe no limite de dispositivos
+func (p *Purchase) AllowsDevice(ip string) bool {
+	if p.DeviceLimit <= 0 {
+		return true
+	}
+	devices := p.Devices()
+	for _, device := range devices {
+		if device == ip {
+			return true
+		}
+	}
+	return len(devices) < p.DeviceLimit
+}
+
+// ResetAllowance zera os downloads usados e os dispositivos registrados
+func (p *Purchase) ResetAllowance(now time.Time) {
+	p.DownloadsUsed = 0
+	p.DevicesResetAt = &now
+}
+
+// Extend acrescenta dias ao link e downloads ao limite. Links sem prazo e compras
+// sem limite de downloads continuam ilimitados.
+func (p *Purchase) Extend(days, downloads int, now time.Time) error {
+	if days < 0 || downloads < 0 || days+downloads == 0 {
+		return ErrInvalidExtension
+	}
+	if days > 0 && !p.ExpiresAt.IsZero() {
+		base := p.ExpiresAt
+		if base.Before(now) {
+			base = now
+		}
+		p.ExpiresAt = base.AddDate(0, 0, days)
+	}
+	if downloads > 0 && p.DownloadLimit != -1 {
+		p.DownloadLimit += downloads
+	}
+	return nil
+}
+
+// AccessStartedAt é quando o comprador passou a ter acesso aos arquivos: a
+// compra, ou o lançamento para compras feitas na pré-venda
+func (p *Purchase) AccessStartedAt() time.Time {
+	if p.ReleasedAt != nil {
+		return *p.ReleasedAt
+	}
+	return p.CreatedAt
+}
+
+// ScheduledFiles lista os arquivos do ebook com a liberação de cada um para esta
+// compra, contando os prazos a partir de AccessStartedAt
+func (p *Purchase) ScheduledFiles() []*ScheduledFile {
+	return p.Ebook.ScheduledFiles(p.AccessStartedAt())
+}
+
+// FindScheduledFile busca o arquivo do ebook e confere se já foi liberado
+func (p *Purchase) FindScheduledFile(fileID uint, now time.Time) (*ScheduledFile, error) {
+	for _, file := range p.ScheduledFiles() {
+		if file.ID != fileID {
+			continue
+		}
+		if file.IsLocked(now) {
+			return nil, ErrFileLocked
+		}
+		return file, nil
+	}
+	return nil, ErrFileNotInEbook
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+// RecurringCheckout descreve ao gateway de pagamento a cobrança recorrente de um
+// produto do criador, como as assinaturas do catálogo
+type RecurringCheckout struct {
+	CustomerEmail string
+	Description   string
+	Price         money.Money
+	Interval      string // month ou year
+	SuccessURL    string // o gateway substitui {CHECKOUT_SESSION_ID} pelo ID da sessão
+	CancelURL     string
+	Metadata      map[string]string
+}
+
+// RecurringCheckoutResult é a situação da sessão de checkout consultada no gateway
+type RecurringCheckoutResult struct {
+	Paid           bool
+	SubscriptionID string
+	PeriodEnd      time.Time
+}
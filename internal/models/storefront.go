@@ -0,0 +1,136 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxCreatorBioLength = 600
+
+var (
+	ErrInvalidCreatorSlug = errors.New("o endereço da vitrine deve ter de 3 a 40 letras minúsculas, números ou hífens")
+	ErrCreatorSlugTaken   = errors.New("este endereço de vitrine já está em uso")
+	ErrCreatorBioTooLong  = fmt.Errorf("a bio deve ter no máximo %d caracteres", MaxCreatorBioLength)
+	ErrInvalidSocialLink  = errors.New("os links devem começar com http:// ou https://")
+	ErrStorefrontNotFound = errors.New("vitrine não encontrada")
+)
+
+var creatorSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{1,38})[a-z0-9]$`)
+
+// CreatorProfile reúne os campos da vitrine editados em /settings
+type CreatorProfile struct {
+	Slug         string
+	Bio          string
+	WebsiteURL   string
+	InstagramURL string
+	YouTubeURL   string
+	TikTokURL    string
+}
+
+// SocialLink é um link do criador exibido na vitrine
+type SocialLink struct {
+	Name string
+	Icon string
+	URL  string
+}
+
+// Storefront é a vitrine pública do criador com o catálogo filtrado
+type Storefront struct {
+	Creator    *Creator
+	Ebooks     []*Ebook
+	Bundles    []*Bundle
+	Categories []string
+	Term       string
+	Category   string
+}
+
+// StorefrontQuery filtra o catálogo da vitrine
+type StorefrontQuery struct {
+	Term     string
+	Category string
+}
+
+// UpdateProfile valida e aplica o perfil público. Sem endereço informado, a
+// vitrine usa o nome do criador.
+func (c *Creator) UpdateProfile(profile CreatorProfile) error {
+	slug := strings.TrimSpace(profile.Slug)
+	if slug == "" {
+		slug = generateSlug(c.Name)
+		if len(slug) > 40 {
+			slug = slug[:40]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+	slug = strings.ToLower(slug)
+	if !creatorSlugPattern.MatchString(slug) {
+		return ErrInvalidCreatorSlug
+	}
+
+	bio := strings.TrimSpace(profile.Bio)
+	if utf8.RuneCountInString(bio) > MaxCreatorBioLength {
+		return ErrCreatorBioTooLong
+	}
+
+	links := []*string{&profile.WebsiteURL, &profile.InstagramURL, &profile.YouTubeURL, &profile.TikTokURL}
+	for _, link := range links {
+		*link = strings.TrimSpace(*link)
+		if *link != "" && !isWebURL(*link) {
+			return ErrInvalidSocialLink
+		}
+	}
+
+	c.Slug = slug
+	c.Bio = bio
+	c.WebsiteURL = profile.WebsiteURL
+	c.InstagramURL = profile.InstagramURL
+	c.YouTubeURL = profile.YouTubeURL
+	c.TikTokURL = profile.TikTokURL
+	return nil
+}
+
+func (c *Creator) HasStorefront() bool {
+	return c.Slug != ""
+}
+
+func (c *Creator) StorefrontURL() string {
+	return "/c/" + c.Slug
+}
+
+// SocialLinks lista os links preenchidos, na ordem exibida na vitrine
+func (c *Creator) SocialLinks() []SocialLink {
+	candidates := []SocialLink{
+		{Name: "Site", Icon: "fa-solid fa-globe", URL: c.WebsiteURL},
+		{Name: "Instagram", Icon: "fa-brands fa-instagram", URL: c.InstagramURL},
+		{Name: "YouTube", Icon: "fa-brands fa-youtube", URL: c.YouTubeURL},
+		{Name: "TikTok", Icon: "fa-brands fa-tiktok", URL: c.TikTokURL},
+	}
+	var links []SocialLink
+	for _, link := range candidates {
+		if link.URL != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// Initials devolve a inicial do nome para o avatar padrão
+func (c *Creator) Initials() string {
+	name := strings.TrimSpace(c.Name)
+	if name == "" {
+		return "?"
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return strings.ToUpper(string(r))
+}
+
+func isWebURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,70 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreator_UpdateProfile(t *testing.T) {
+	creator := &models.Creator{Name: "Ana Souza"}
+
+	err := creator.UpdateProfile(models.CreatorProfile{
+		Slug:         " Ana-Escreve ",
+		Bio:          "  Escrevo sobre finanças  ",
+		InstagramURL: "https://instagram.com/ana",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ana-escreve", creator.Slug)
+	assert.Equal(t, "Escrevo sobre finanças", creator.Bio)
+	assert.True(t, creator.HasStorefront())
+	assert.Equal(t, "/c/ana-escreve", creator.StorefrontURL())
+}
+
+func TestCreator_UpdateProfile_DefaultSlugFromName(t *testing.T) {
+	creator := &models.Creator{Name: "José Antônio"}
+
+	assert.NoError(t, creator.UpdateProfile(models.CreatorProfile{}))
+	assert.Equal(t, "jose-antonio", creator.Slug)
+}
+
+func TestCreator_UpdateProfile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile models.CreatorProfile
+		want    error
+	}{
+		{"endereço curto", models.CreatorProfile{Slug: "ab"}, models.ErrInvalidCreatorSlug},
+		{"endereço com espaço", models.CreatorProfile{Slug: "ana souza"}, models.ErrInvalidCreatorSlug},
+		{"endereço terminado em hífen", models.CreatorProfile{Slug: "ana-"}, models.ErrInvalidCreatorSlug},
+		{"bio longa", models.CreatorProfile{Slug: "ana", Bio: strings.Repeat("a", models.MaxCreatorBioLength+1)}, models.ErrCreatorBioTooLong},
+		{"link sem esquema", models.CreatorProfile{Slug: "ana", WebsiteURL: "ana.com.br"}, models.ErrInvalidSocialLink},
+		{"link javascript", models.CreatorProfile{Slug: "ana", TikTokURL: "javascript:alert(1)"}, models.ErrInvalidSocialLink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := &models.Creator{Name: "Ana", Slug: "original"}
+			assert.ErrorIs(t, creator.UpdateProfile(tt.profile), tt.want)
+			assert.Equal(t, "original", creator.Slug)
+		})
+	}
+}
+
+func TestCreator_SocialLinks(t *testing.T) {
+	creator := &models.Creator{
+		Name:       "Ana",
+		WebsiteURL: "https://ana.com.br",
+		TikTokURL:  "https://tiktok.com/@ana",
+	}
+
+	links := creator.SocialLinks()
+
+	assert.Len(t, links, 2)
+	assert.Equal(t, "Site", links[0].Name)
+	assert.Equal(t, "TikTok", links[1].Name)
+	assert.Equal(t, "A", creator.Initials())
+}
@@ -1,25 +1,113 @@
 package models
 
 import (
+	"errors"
 	"math"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// SubscriptionState é o estado da assinatura na plataforma. As mudanças de estado
+// só acontecem pelas transições permitidas em subscriptionTransitions.
+type SubscriptionState string
+
+const (
+	SubscriptionTrialing SubscriptionState = "trialing"
+	SubscriptionActive   SubscriptionState = "active"
+	SubscriptionPastDue  SubscriptionState = "past_due"
+	SubscriptionCanceled SubscriptionState = "canceled"
+	SubscriptionExpired  SubscriptionState = "expired" // período de teste encerrado sem assinatura
+)
+
+var subscriptionTransitions = map[SubscriptionState][]SubscriptionState{
+	SubscriptionTrialing: {SubscriptionActive, SubscriptionExpired, SubscriptionCanceled},
+	SubscriptionExpired:  {SubscriptionActive},
+	SubscriptionActive:   {SubscriptionPastDue, SubscriptionCanceled},
+	SubscriptionPastDue:  {SubscriptionActive, SubscriptionCanceled},
+	SubscriptionCanceled: {SubscriptionActive},
+}
+
+var ErrInvalidSubscriptionTransition = errors.New("transição de status da assinatura inválida")
+
+// CanTransitionTo informa se a transição para o estado informado é permitida
+func (s SubscriptionState) CanTransitionTo(to SubscriptionState) bool {
+	for _, allowed := range subscriptionTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLabel retorna o estado para exibição
+func (s SubscriptionState) GetLabel() string {
+	switch s {
+	case SubscriptionTrialing:
+		return "Período de teste"
+	case SubscriptionActive:
+		return "Ativa"
+	case SubscriptionPastDue:
+		return "Pagamento pendente"
+	case SubscriptionCanceled:
+		return "Cancelada"
+	case SubscriptionExpired:
+		return "Teste encerrado"
+	default:
+		return string(s)
+	}
+}
+
+var ErrUnknownGatewayStatus = errors.New("status de assinatura desconhecido no gateway")
+
+// SubscriptionStateFromGateway converte os status documentados do Stripe. O Stripe
+// pausa a assinatura quando o teste termina sem meio de pagamento, o que aqui
+// equivale ao teste encerrado. ok é falso para status desconhecidos.
+func SubscriptionStateFromGateway(status string) (state SubscriptionState, ok bool) {
+	switch status {
+	case "trialing":
+		return SubscriptionTrialing, true
+	case "active":
+		return SubscriptionActive, true
+	case "past_due", "unpaid", "incomplete":
+		return SubscriptionPastDue, true
+	case "canceled", "incomplete_expired":
+		return SubscriptionCanceled, true
+	case "paused":
+		return SubscriptionExpired, true
+	default:
+		return "", false
+	}
+}
+
+// SubscriptionAccess define o que o criador pode fazer na área logada
+type SubscriptionAccess string
+
+const (
+	AccessFull     SubscriptionAccess = "full"
+	AccessReadOnly SubscriptionAccess = "read_only"
+	AccessNone     SubscriptionAccess = "none"
+)
+
 type Subscription struct {
 	gorm.Model
-	UserID               uint       `json:"user_id" gorm:"not null"`
-	User                 User       `json:"user" gorm:"foreignKey:UserID"`
-	PlanID               string     `json:"plan_id"`
-	TrialStartDate       time.Time  `json:"trial_start_date"`
-	TrialEndDate         time.Time  `json:"trial_end_date"`
-	IsTrialActive        bool       `json:"is_trial_active" gorm:"default:true"`
-	StripeCustomerID     string     `json:"stripe_customer_id"`
-	StripeSubscriptionID string     `json:"stripe_subscription_id"`
-	SubscriptionStatus   string     `json:"subscription_status" gorm:"default:'inactive'"`
-	SubscriptionEndDate  *time.Time `json:"subscription_end_date"`
-	Origin               string     `json:"origin" gorm:"default:'web'"`
+	UserID               uint              `json:"user_id" gorm:"not null"`
+	User                 User              `json:"user" gorm:"foreignKey:UserID"`
+	PlanID               string            `json:"plan_id"`
+	BillingInterval      string            `json:"billing_interval" gorm:"default:'monthly'"`
+	TrialStartDate       time.Time         `json:"trial_start_date"`
+	TrialEndDate         time.Time         `json:"trial_end_date"`
+	IsTrialActive        bool              `json:"is_trial_active" gorm:"default:true"`
+	StripeCustomerID     string            `json:"stripe_customer_id"`
+	StripeSubscriptionID string            `json:"stripe_subscription_id"`
+	SubscriptionStatus   SubscriptionState `json:"subscription_status" gorm:"default:'trialing'"`
+	SubscriptionEndDate  *time.Time        `json:"subscription_end_date"`
+	CancelAtPeriodEnd    bool              `json:"cancel_at_period_end" gorm:"default:false"`
+	CanceledAt           *time.Time        `json:"canceled_at"`
+	PaymentFailedAt      *time.Time        `json:"payment_failed_at"`
+	GracePeriodEndsAt    *time.Time        `json:"grace_period_ends_at"`
+	DunningRemindersSent int               `json:"dunning_reminders_sent" gorm:"default:0"`
+	Origin               string            `json:"origin" gorm:"default:'web'"`
 }
 
 func NewSubscription(userID uint, planID string) *Subscription {
@@ -27,20 +115,58 @@ func NewSubscription(userID uint, planID string) *Subscription {
 	trialEndDate := now.AddDate(0, 0, 7) // 7 days trial
 
 	return &Subscription{
-		UserID:         userID,
-		PlanID:         planID,
-		TrialStartDate: now,
-		TrialEndDate:   trialEndDate,
-		IsTrialActive:  true,
-		Origin:         "web",
+		UserID:             userID,
+		PlanID:             planID,
+		BillingInterval:    BillingMonthly,
+		TrialStartDate:     now,
+		TrialEndDate:       trialEndDate,
+		IsTrialActive:      true,
+		SubscriptionStatus: SubscriptionTrialing,
+		Origin:             "web",
 	}
 }
 
-func (s *Subscription) IsInTrialPeriod() bool {
-	if s.TrialEndDate.IsZero() {
-		return false
+// State retorna o estado atual considerando o tempo: um período de teste vencido
+// passa a valer como expirado mesmo antes de EndTrial ser registrado. Registros
+// antigos, com status "inactive", são interpretados pelas datas do teste.
+func (s *Subscription) State() SubscriptionState {
+	switch s.SubscriptionStatus {
+	case SubscriptionActive, SubscriptionPastDue, SubscriptionCanceled, SubscriptionExpired:
+		return s.SubscriptionStatus
+	case SubscriptionTrialing:
+		if !s.trialRunning() {
+			return SubscriptionExpired
+		}
+		return SubscriptionTrialing
+	default:
+		if s.IsTrialActive && s.trialRunning() {
+			return SubscriptionTrialing
+		}
+		return SubscriptionExpired
+	}
+}
+
+func (s *Subscription) trialRunning() bool {
+	return !s.TrialEndDate.IsZero() && time.Now().Before(s.TrialEndDate)
+}
+
+// transitionTo muda o estado validando a transição; permanecer no mesmo estado é permitido
+func (s *Subscription) transitionTo(to SubscriptionState) error {
+	from := s.State()
+	if from != to && !from.CanTransitionTo(to) {
+		return ErrInvalidSubscriptionTransition
 	}
-	return s.IsTrialActive && time.Now().Before(s.TrialEndDate)
+
+	s.SubscriptionStatus = to
+	if to != SubscriptionTrialing {
+		s.IsTrialActive = false
+	}
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Subscription) IsInTrialPeriod() bool {
+	return s.State() == SubscriptionTrialing
 }
 
 func (s *Subscription) DaysLeftInTrial() int {
@@ -55,36 +181,147 @@ func (s *Subscription) DaysLeftInTrial() int {
 }
 
 func (s *Subscription) IsSubscribed() bool {
-	if s.SubscriptionStatus == "active" {
+	switch s.State() {
+	case SubscriptionActive:
 		return true
+	case SubscriptionPastDue:
+		// Com pagamento pendente, o acesso só é mantido durante o período de tolerância
+		return s.IsInGracePeriod()
+	case SubscriptionTrialing:
+		return false
+	default:
+		// Assinaturas canceladas mantêm o acesso até o fim do período pago
+		return s.SubscriptionEndDate != nil && time.Now().Before(*s.SubscriptionEndDate)
 	}
-	if s.SubscriptionEndDate != nil && time.Now().Before(*s.SubscriptionEndDate) {
-		return true
+}
+
+// Access concentra a regra de acesso à área logada a partir do estado da assinatura
+func (s *Subscription) Access() SubscriptionAccess {
+	if s.IsInTrialPeriod() || s.IsSubscribed() {
+		return AccessFull
 	}
-	return false
+	if s.IsReadOnly() {
+		return AccessReadOnly
+	}
+	return AccessNone
+}
+
+// Activate registra a assinatura contratada no gateway e libera o acesso
+func (s *Subscription) Activate(stripeCustomerID, stripeSubscriptionID string) error {
+	if err := s.transitionTo(SubscriptionActive); err != nil {
+		return err
+	}
+	s.StripeCustomerID = stripeCustomerID
+	s.StripeSubscriptionID = stripeSubscriptionID
+	s.CancelAtPeriodEnd = false
+	s.CanceledAt = nil
+	s.clearPaymentFailure()
+	return nil
+}
+
+// AttachCustomer vincula o cliente do gateway sem alterar o estado da assinatura
+func (s *Subscription) AttachCustomer(stripeCustomerID string) {
+	s.StripeCustomerID = stripeCustomerID
+	s.UpdatedAt = time.Now()
+}
+
+func (s *Subscription) Cancel() error {
+	if err := s.transitionTo(SubscriptionCanceled); err != nil {
+		return err
+	}
+	now := time.Now()
+	if s.CanceledAt == nil {
+		s.CanceledAt = &now
+	}
+	s.CancelAtPeriodEnd = false
+	s.clearPaymentFailure()
+	return nil
+}
+
+// ScheduleCancellation encerra a renovação mantendo o acesso até o fim do período pago
+func (s *Subscription) ScheduleCancellation(periodEnd time.Time) error {
+	if s.State() != SubscriptionActive {
+		return ErrInvalidSubscriptionTransition
+	}
+	now := time.Now()
+	s.CancelAtPeriodEnd = true
+	s.CanceledAt = &now
+	if !periodEnd.IsZero() {
+		s.SubscriptionEndDate = &periodEnd
+	}
+	s.UpdatedAt = now
+	return nil
 }
 
-func (s *Subscription) CancelSubscription() {
-	s.SubscriptionStatus = "canceled"
+// Resume desfaz o cancelamento agendado, voltando a renovar a assinatura
+func (s *Subscription) Resume() error {
+	if !s.CancelAtPeriodEnd || s.State() != SubscriptionActive {
+		return ErrInvalidSubscriptionTransition
+	}
+	s.CancelAtPeriodEnd = false
+	s.CanceledAt = nil
 	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// IsCancellationScheduled indica que a assinatura segue ativa, mas não será renovada
+func (s *Subscription) IsCancellationScheduled() bool {
+	return s.CancelAtPeriodEnd && s.IsSubscribed()
 }
 
-func (s *Subscription) EndTrial() {
+// HasGatewaySubscription indica se a assinatura já foi contratada no gateway de pagamento
+func (s *Subscription) HasGatewaySubscription() bool {
+	return s.StripeSubscriptionID != ""
+}
+
+func (s *Subscription) EndTrial() error {
+	if s.State() == SubscriptionTrialing || s.State() == SubscriptionExpired {
+		if err := s.transitionTo(SubscriptionExpired); err != nil {
+			return err
+		}
+	}
 	s.IsTrialActive = false
 	s.UpdatedAt = time.Now()
+	return nil
 }
 
-func (s *Subscription) ActivateSubscription(stripeCustomerID, stripeSubscriptionID string) {
-	s.StripeCustomerID = stripeCustomerID
-	s.StripeSubscriptionID = stripeSubscriptionID
-	s.SubscriptionStatus = "active"
+// ChangePlan troca o plano e a periodicidade de cobrança da assinatura
+func (s *Subscription) ChangePlan(planID, interval string) {
+	s.PlanID = planID
+	s.BillingInterval = interval
 	s.UpdatedAt = time.Now()
 }
 
-func (s *Subscription) UpdateSubscriptionStatus(status string, endDate *time.Time) {
-	s.SubscriptionStatus = status
+// ApplyGatewayStatus sincroniza o estado com o status informado pelo gateway.
+// A entrada em pagamento pendente é controlada por MarkPaymentFailed, que define
+// o período de tolerância; aqui apenas a data do período é atualizada nesse caso.
+// Status desconhecidos retornam ErrUnknownGatewayStatus sem alterar a assinatura.
+func (s *Subscription) ApplyGatewayStatus(status string, endDate *time.Time) error {
+	to, ok := SubscriptionStateFromGateway(status)
+	if !ok {
+		return ErrUnknownGatewayStatus
+	}
+	switch to {
+	case SubscriptionPastDue:
+		// aguarda invoice.payment_failed
+	case SubscriptionActive:
+		if err := s.transitionTo(SubscriptionActive); err != nil {
+			return err
+		}
+		s.clearPaymentFailure()
+	case SubscriptionCanceled:
+		if err := s.Cancel(); err != nil {
+			return err
+		}
+	default:
+		if err := s.transitionTo(to); err != nil {
+			return err
+		}
+	}
+
 	s.SubscriptionEndDate = endDate
 	s.UpdatedAt = time.Now()
+	return nil
 }
 
 // DaysLeftInSubscription returns the number of days left in the subscription
@@ -105,10 +342,71 @@ func (s *Subscription) IsExpiringSoon() bool {
 	return daysLeft > 0 && daysLeft <= 10
 }
 
+// MarkPaymentFailed inicia o período de tolerância na primeira falha de pagamento.
+// Falhas seguintes (novas tentativas de cobrança) não estendem o prazo.
+func (s *Subscription) MarkPaymentFailed(gracePeriod time.Duration) error {
+	if err := s.transitionTo(SubscriptionPastDue); err != nil {
+		return err
+	}
+	if s.PaymentFailedAt == nil {
+		now := time.Now()
+		graceEnd := now.Add(gracePeriod)
+		s.PaymentFailedAt = &now
+		s.GracePeriodEndsAt = &graceEnd
+		s.DunningRemindersSent = 0
+	}
+	return nil
+}
+
+// MarkPaymentRecovered encerra a cobrança pendente e devolve o acesso completo
+func (s *Subscription) MarkPaymentRecovered() error {
+	if !s.IsPastDue() {
+		return ErrInvalidSubscriptionTransition
+	}
+	if err := s.transitionTo(SubscriptionActive); err != nil {
+		return err
+	}
+	s.clearPaymentFailure()
+	return nil
+}
+
+func (s *Subscription) clearPaymentFailure() {
+	s.PaymentFailedAt = nil
+	s.GracePeriodEndsAt = nil
+	s.DunningRemindersSent = 0
+}
+
+// IsPastDue indica que há um pagamento da assinatura pendente
+func (s *Subscription) IsPastDue() bool {
+	return s.State() == SubscriptionPastDue
+}
+
+func (s *Subscription) IsInGracePeriod() bool {
+	return s.IsPastDue() && s.GracePeriodEndsAt != nil && time.Now().Before(*s.GracePeriodEndsAt)
+}
+
+// IsReadOnly indica que o período de tolerância terminou sem pagamento
+func (s *Subscription) IsReadOnly() bool {
+	return s.IsPastDue() && !s.IsInGracePeriod()
+}
+
+func (s *Subscription) DaysLeftInGracePeriod() int {
+	if !s.IsInGracePeriod() {
+		return 0
+	}
+	return int(math.Ceil(time.Until(*s.GracePeriodEndsAt).Hours() / 24))
+}
+
 // GetSubscriptionStatus returns a string describing the current subscription status
 func (s *Subscription) GetSubscriptionStatus() string {
-	if s.IsInTrialPeriod() {
+	switch s.State() {
+	case SubscriptionTrialing:
 		return "trial"
+	case SubscriptionPastDue:
+		if s.IsInGracePeriod() {
+			return "grace"
+		}
+		return "read_only"
 	}
 	if s.IsSubscribed() {
 		if s.IsExpiringSoon() {
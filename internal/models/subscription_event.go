@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SubscriptionActionCreated               = "created"
+	SubscriptionActionCustomerAttached      = "customer_attached"
+	SubscriptionActionActivated             = "activated"
+	SubscriptionActionGatewaySync           = "gateway_sync"
+	SubscriptionActionCanceled              = "canceled"
+	SubscriptionActionCancellationScheduled = "cancellation_scheduled"
+	SubscriptionActionResumed               = "resumed"
+	SubscriptionActionTrialEnded            = "trial_ended"
+	SubscriptionActionPlanChanged           = "plan_changed"
+	SubscriptionActionPaymentFailed         = "payment_failed"
+	SubscriptionActionPaymentRecovered      = "payment_recovered"
+	SubscriptionActionDunningReminder       = "dunning_reminder"
+)
+
+const (
+	ActorTypeUser    = "user"
+	ActorTypeGateway = "gateway"
+	ActorTypeSystem  = "system"
+)
+
+// SubscriptionActor identifica quem ou o que provocou uma mudança na assinatura
+type SubscriptionActor struct {
+	Type string
+	ID   string
+}
+
+// UserActor representa uma ação feita pelo próprio usuário
+func UserActor(userID uint) SubscriptionActor {
+	return SubscriptionActor{Type: ActorTypeUser, ID: fmt.Sprintf("%d", userID)}
+}
+
+// GatewayActor representa um evento recebido do gateway de pagamento
+func GatewayActor(eventID string) SubscriptionActor {
+	return SubscriptionActor{Type: ActorTypeGateway, ID: eventID}
+}
+
+// SystemActor representa rotinas automáticas da plataforma
+var SystemActor = SubscriptionActor{Type: ActorTypeSystem}
+
+// SubscriptionEvent registra cada mudança feita em uma assinatura
+type SubscriptionEvent struct {
+	gorm.Model
+	SubscriptionID uint              `json:"subscription_id" gorm:"index"`
+	FromState      SubscriptionState `json:"from_state"`
+	ToState        SubscriptionState `json:"to_state"`
+	Action         string            `json:"action"`
+	ActorType      string            `json:"actor_type"`
+	ActorID        string            `json:"actor_id"`
+	Details        string            `json:"details"`
+}
+
+func NewSubscriptionEvent(subscription *Subscription, from SubscriptionState, action string, actor SubscriptionActor, details string) *SubscriptionEvent {
+	return &SubscriptionEvent{
+		SubscriptionID: subscription.ID,
+		FromState:      from,
+		ToState:        subscription.State(),
+		Action:         action,
+		ActorType:      actor.Type,
+		ActorID:        actor.ID,
+		Details:        details,
+	}
+}
+
+// IsTransition indica se o evento mudou o estado da assinatura
+func (e *SubscriptionEvent) IsTransition() bool {
+	return e.FromState != e.ToState
+}
+
+func (e *SubscriptionEvent) GetActionLabel() string {
+	switch e.Action {
+	case SubscriptionActionCreated:
+		return "Assinatura criada"
+	case SubscriptionActionCustomerAttached:
+		return "Cliente vinculado ao gateway"
+	case SubscriptionActionActivated:
+		return "Assinatura ativada"
+	case SubscriptionActionGatewaySync:
+		return "Atualização do gateway de pagamento"
+	case SubscriptionActionCanceled:
+		return "Assinatura cancelada"
+	case SubscriptionActionCancellationScheduled:
+		return "Cancelamento agendado"
+	case SubscriptionActionResumed:
+		return "Renovação reativada"
+	case SubscriptionActionTrialEnded:
+		return "Período de teste encerrado"
+	case SubscriptionActionPlanChanged:
+		return "Plano alterado"
+	case SubscriptionActionPaymentFailed:
+		return "Falha no pagamento"
+	case SubscriptionActionPaymentRecovered:
+		return "Pagamento regularizado"
+	case SubscriptionActionDunningReminder:
+		return "Lembrete de pagamento enviado"
+	default:
+		return e.Action
+	}
+}
+
+func (e *SubscriptionEvent) GetActorLabel() string {
+	switch e.ActorType {
+	case ActorTypeUser:
+		return "Você"
+	case ActorTypeGateway:
+		return "Gateway de pagamento"
+	default:
+		return "Sistema"
+	}
+}
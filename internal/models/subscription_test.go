@@ -0,0 +1,82 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscription_StateTransitions(t *testing.T) {
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	assert.Equal(t, models.SubscriptionTrialing, subscription.State())
+
+	assert.ErrorIs(t, subscription.MarkPaymentFailed(time.Hour), models.ErrInvalidSubscriptionTransition)
+	assert.ErrorIs(t, subscription.Resume(), models.ErrInvalidSubscriptionTransition)
+
+	assert.NoError(t, subscription.Activate("cus_1", "sub_1"))
+	assert.Equal(t, models.SubscriptionActive, subscription.State())
+	assert.False(t, subscription.IsTrialActive)
+
+	assert.NoError(t, subscription.MarkPaymentFailed(time.Hour))
+	assert.Equal(t, models.SubscriptionPastDue, subscription.State())
+
+	assert.NoError(t, subscription.MarkPaymentRecovered())
+	assert.NoError(t, subscription.Cancel())
+	assert.Equal(t, models.SubscriptionCanceled, subscription.State())
+	assert.ErrorIs(t, subscription.MarkPaymentFailed(time.Hour), models.ErrInvalidSubscriptionTransition)
+
+	assert.NoError(t, subscription.Activate("cus_1", "sub_2"))
+	assert.Nil(t, subscription.CanceledAt)
+}
+
+func TestSubscription_State_ExpiresTrialAndReadsLegacyStatus(t *testing.T) {
+	expired := models.NewSubscription(1, models.DefaultPlanSlug)
+	expired.TrialEndDate = time.Now().Add(-time.Hour)
+	assert.Equal(t, models.SubscriptionExpired, expired.State())
+	assert.Equal(t, models.AccessNone, expired.Access())
+
+	legacy := &models.Subscription{SubscriptionStatus: "inactive", IsTrialActive: true, TrialEndDate: time.Now().AddDate(0, 0, 3)}
+	assert.Equal(t, models.SubscriptionTrialing, legacy.State())
+	assert.Equal(t, models.AccessFull, legacy.Access())
+}
+
+func TestSubscription_Access_FollowsState(t *testing.T) {
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	subscription.Activate("cus_1", "sub_1")
+	assert.Equal(t, models.AccessFull, subscription.Access())
+
+	subscription.MarkPaymentFailed(-time.Hour)
+	assert.Equal(t, models.AccessReadOnly, subscription.Access())
+	assert.Equal(t, "read_only", subscription.GetSubscriptionStatus())
+
+	subscription.Cancel()
+	assert.Equal(t, models.AccessNone, subscription.Access())
+
+	periodEnd := time.Now().AddDate(0, 0, 5)
+	subscription.SubscriptionEndDate = &periodEnd
+	assert.Equal(t, models.AccessFull, subscription.Access())
+}
+
+func TestSubscription_ApplyGatewayStatus(t *testing.T) {
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	subscription.Activate("cus_1", "sub_1")
+
+	assert.NoError(t, subscription.ApplyGatewayStatus("unpaid", nil))
+	assert.Equal(t, models.SubscriptionActive, subscription.State())
+
+	assert.NoError(t, subscription.ApplyGatewayStatus("canceled", nil))
+	assert.Equal(t, models.SubscriptionCanceled, subscription.State())
+	assert.NotNil(t, subscription.CanceledAt)
+
+	assert.ErrorIs(t, subscription.ApplyGatewayStatus("novo_status", nil), models.ErrUnknownGatewayStatus)
+	assert.Equal(t, models.SubscriptionCanceled, subscription.State())
+}
+
+func TestSubscription_ApplyGatewayStatus_PausedTrialExpires(t *testing.T) {
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+
+	assert.NoError(t, subscription.ApplyGatewayStatus("paused", nil))
+	assert.Equal(t, models.SubscriptionExpired, subscription.State())
+}
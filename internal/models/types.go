@@ -1,11 +1,21 @@
 package models
 
 type EbookRequest struct {
-	Title       string  `validate:"required,min=5,max=120" json:"title"`
-	Description string  `validate:"required,max=120" json:"description"`
-	SalesPage   string  `validate:"required" json:"sales_page"`
-	Value       float64 `validate:"required,gt=0" json:"value"`
-	Status      bool    `json:"status"`
+	Title          string `validate:"required,min=5,max=120" json:"title"`
+	Description    string `validate:"required,max=120" json:"description"`
+	SalesPage      string `validate:"required" json:"sales_page"`
+	Value          string `json:"value"` // obrigatório, exceto para ebooks gratuitos
+	Currency       string `validate:"required,oneof=BRL USD EUR" json:"currency"`
+	Status         bool   `json:"status"`
+	PricingMode    string `validate:"omitempty,oneof=fixed minimum free" json:"pricing_mode"`
+	SuggestedValue string `json:"suggested_value"`
+	MaxDownloads   string `json:"max_downloads"`
+	ExpiryDays     string `json:"expiry_days"`
+	MaxDevices     string `json:"max_devices"`
+	PublishAt      string `json:"publish_at"`
+	UnpublishAt    string `json:"unpublish_at"`
+	PreSale        bool   `json:"pre_sale"`
+	Category       string `validate:"max=60" json:"category"`
 }
 
 type LoginForm struct {
@@ -66,6 +66,22 @@ func (u *User) IsSubscribed() bool {
 	return u.Subscription.IsSubscribed()
 }
 
+// IsReadOnly indica que o pagamento da assinatura está pendente após o período de tolerância
+func (u *User) IsReadOnly() bool {
+	if u.Subscription == nil {
+		return false
+	}
+	return u.Subscription.IsReadOnly()
+}
+
+// SubscriptionAccess retorna o nível de acesso à área logada definido pela assinatura
+func (u *User) SubscriptionAccess() SubscriptionAccess {
+	if u.Subscription == nil {
+		return AccessNone
+	}
+	return u.Subscription.Access()
+}
+
 func (u *User) HasAcceptedTerms() bool {
 	return u.TermsAcceptedAt != nil
 }
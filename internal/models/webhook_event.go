@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookEventPending    = "pending"
+	WebhookEventProcessing = "processing"
+	WebhookEventProcessed  = "processed"
+	WebhookEventFailed     = "failed"
+
+	// MaxWebhookAttempts limita quantas vezes um evento é reprocessado automaticamente
+	MaxWebhookAttempts = 6
+	webhookBaseBackoff = time.Minute
+)
+
+// ErrWebhookEventInProgress indica que outra entrega ou reprocessamento já
+// assumiu o evento
+var ErrWebhookEventInProgress = errors.New("evento de webhook já está sendo processado")
+
+// WebhookEvent armazena cada evento recebido do gateway de pagamento
+type WebhookEvent struct {
+	gorm.Model
+	EventID       string     `json:"event_id" gorm:"uniqueIndex;not null"`
+	Type          string     `json:"type" gorm:"index"`
+	Payload       string     `json:"payload" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"index;default:'pending'"`
+	Error         string     `json:"error"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	ProcessedAt   *time.Time `json:"processed_at"`
+}
+
+func NewWebhookEvent(eventID, eventType string, payload []byte) *WebhookEvent {
+	return &WebhookEvent{
+		EventID: eventID,
+		Type:    eventType,
+		Payload: string(payload),
+		Status:  WebhookEventPending,
+	}
+}
+
+func (e *WebhookEvent) IsProcessed() bool {
+	return e.Status == WebhookEventProcessed
+}
+
+// CanRetry indica se o evento falhou e ainda pode ser reprocessado automaticamente
+func (e *WebhookEvent) CanRetry() bool {
+	return e.Status == WebhookEventFailed && e.Attempts < MaxWebhookAttempts
+}
+
+func (e *WebhookEvent) MarkProcessed() {
+	now := time.Now()
+	e.Attempts++
+	e.Status = WebhookEventProcessed
+	e.Error = ""
+	e.ProcessedAt = &now
+	e.NextAttemptAt = nil
+}
+
+// MarkFailed registra a falha e agenda a próxima tentativa com backoff exponencial
+func (e *WebhookEvent) MarkFailed(err error) {
+	e.Attempts++
+	e.Status = WebhookEventFailed
+	e.Error = err.Error()
+
+	if e.Attempts >= MaxWebhookAttempts {
+		e.NextAttemptAt = nil
+		return
+	}
+
+	next := time.Now().Add(e.RetryBackoff())
+	e.NextAttemptAt = &next
+}
+
+// RetryBackoff retorna o intervalo até a próxima tentativa (1m, 2m, 4m, 8m...)
+func (e *WebhookEvent) RetryBackoff() time.Duration {
+	if e.Attempts <= 0 {
+		return webhookBaseBackoff
+	}
+	return webhookBaseBackoff * time.Duration(1<<(e.Attempts-1))
+}
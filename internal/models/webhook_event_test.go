@@ -0,0 +1,50 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookEvent_MarkFailedSchedulesRetryWithBackoff(t *testing.T) {
+	event := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+
+	event.MarkFailed(errors.New("falha"))
+
+	assert.Equal(t, models.WebhookEventFailed, event.Status)
+	assert.Equal(t, 1, event.Attempts)
+	assert.Equal(t, "falha", event.Error)
+	assert.NotNil(t, event.NextAttemptAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *event.NextAttemptAt, 2*time.Second)
+	assert.True(t, event.CanRetry())
+
+	event.MarkFailed(errors.New("falha"))
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), *event.NextAttemptAt, 2*time.Second)
+}
+
+func TestWebhookEvent_MarkFailedStopsAfterMaxAttempts(t *testing.T) {
+	event := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+
+	for i := 0; i < models.MaxWebhookAttempts; i++ {
+		event.MarkFailed(errors.New("falha"))
+	}
+
+	assert.Nil(t, event.NextAttemptAt)
+	assert.False(t, event.CanRetry())
+}
+
+func TestWebhookEvent_MarkProcessed(t *testing.T) {
+	event := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	event.MarkFailed(errors.New("falha"))
+
+	event.MarkProcessed()
+
+	assert.True(t, event.IsProcessed())
+	assert.Empty(t, event.Error)
+	assert.Nil(t, event.NextAttemptAt)
+	assert.NotNil(t, event.ProcessedAt)
+	assert.Equal(t, 2, event.Attempts)
+}
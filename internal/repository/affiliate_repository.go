@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type AffiliateRepository interface {
+	Create(affiliate *models.Affiliate) error
+	Update(affiliate *models.Affiliate) error
+	FindByID(id uint) (*models.Affiliate, error)
+	FindByCode(code string) (*models.Affiliate, error)
+	FindByToken(token string) (*models.Affiliate, error)
+	FindByCreator(creatorID uint) ([]*models.Affiliate, error)
+	FindRules(creatorID uint) ([]models.CommissionRule, error)
+	// SaveRules grava o percentual de cada ebook, substituindo as regras anteriores
+	SaveRules(creatorID uint, rules []*models.CommissionRule) error
+	FindCommissionsByAffiliate(affiliateID uint) ([]*models.Commission, error)
+	FindCommissionsByCreator(creatorID uint) ([]*models.Commission, error)
+}
+
+type GormAffiliateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAffiliateRepository(db *gorm.DB) *GormAffiliateRepository {
+	return &GormAffiliateRepository{db: db}
+}
+
+func (r *GormAffiliateRepository) Create(affiliate *models.Affiliate) error {
+	err := r.db.Omit("Creator").Create(affiliate).Error
+	if err != nil {
+		log.Printf("Erro ao criar afiliado: %v", err)
+		return errors.New("erro ao criar afiliado")
+	}
+	return nil
+}
+
+func (r *GormAffiliateRepository) Update(affiliate *models.Affiliate) error {
+	err := r.db.Omit("Creator").Save(affiliate).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar afiliado %d: %v", affiliate.ID, err)
+		return errors.New("erro ao atualizar afiliado")
+	}
+	return nil
+}
+
+func (r *GormAffiliateRepository) FindByID(id uint) (*models.Affiliate, error) {
+	return r.findOne("id = ?", id)
+}
+
+func (r *GormAffiliateRepository) FindByCode(code string) (*models.Affiliate, error) {
+	return r.findOne("code = ?", code)
+}
+
+func (r *GormAffiliateRepository) FindByToken(token string) (*models.Affiliate, error) {
+	return r.findOne("token = ?", token)
+}
+
+func (r *GormAffiliateRepository) findOne(query string, value any) (*models.Affiliate, error) {
+	var affiliate models.Affiliate
+	err := r.db.Preload("Creator").Where(query, value).First(&affiliate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar afiliado: %v", err)
+		return nil, errors.New("erro ao buscar afiliado")
+	}
+	return &affiliate, nil
+}
+
+func (r *GormAffiliateRepository) FindByCreator(creatorID uint) ([]*models.Affiliate, error) {
+	var affiliates []*models.Affiliate
+	err := r.db.Where("creator_id = ?", creatorID).Order("created_at DESC").Find(&affiliates).Error
+	if err != nil {
+		log.Printf("Erro ao listar afiliados do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar afiliados")
+	}
+	return affiliates, nil
+}
+
+func (r *GormAffiliateRepository) FindRules(creatorID uint) ([]models.CommissionRule, error) {
+	var rules []models.CommissionRule
+	err := r.db.Where("creator_id = ?", creatorID).Find(&rules).Error
+	if err != nil {
+		log.Printf("Erro ao buscar regras de comissão do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao buscar regras de comissão")
+	}
+	return rules, nil
+}
+
+func (r *GormAffiliateRepository) SaveRules(creatorID uint, rules []*models.CommissionRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("creator_id = ?", creatorID).Delete(&models.CommissionRule{}).Error; err != nil {
+			log.Printf("Erro ao remover regras de comissão do criador %d: %v", creatorID, err)
+			return errors.New("erro ao salvar regras de comissão")
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		if err := tx.Create(rules).Error; err != nil {
+			log.Printf("Erro ao salvar regras de comissão do criador %d: %v", creatorID, err)
+			return errors.New("erro ao salvar regras de comissão")
+		}
+		return nil
+	})
+}
+
+func (r *GormAffiliateRepository) FindCommissionsByAffiliate(affiliateID uint) ([]*models.Commission, error) {
+	var commissions []*models.Commission
+	err := r.db.Preload("Ebook").Where("affiliate_id = ?", affiliateID).Order("created_at DESC").Find(&commissions).Error
+	if err != nil {
+		log.Printf("Erro ao listar comissões do afiliado %d: %v", affiliateID, err)
+		return nil, errors.New("erro ao listar comissões")
+	}
+	return commissions, nil
+}
+
+func (r *GormAffiliateRepository) FindCommissionsByCreator(creatorID uint) ([]*models.Commission, error) {
+	var commissions []*models.Commission
+	err := r.db.Preload("Affiliate").Preload("Ebook").Where("creator_id = ?", creatorID).Order("created_at").Find(&commissions).Error
+	if err != nil {
+		log.Printf("Erro ao listar comissões do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar comissões")
+	}
+	return commissions, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type BundleRepository interface {
+	Create(bundle *models.Bundle) error
+	Update(bundle *models.Bundle) error
+	Delete(id uint) error
+	FindByID(id uint) (*models.Bundle, error)
+	FindBySlug(slug string) (*models.Bundle, error)
+	FindByCreator(creatorID uint) ([]*models.Bundle, error)
+}
+
+type GormBundleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormBundleRepository(db *gorm.DB) *GormBundleRepository {
+	return &GormBundleRepository{db: db}
+}
+
+func (r *GormBundleRepository) Create(bundle *models.Bundle) error {
+	err := r.db.Omit("Creator", "Ebooks.*").Create(bundle).Error
+	if err != nil {
+		log.Printf("Erro ao criar kit: %v", err)
+		return errors.New("erro ao criar kit")
+	}
+	return nil
+}
+
+// Update salva o kit e substitui a lista de ebooks incluídos
+func (r *GormBundleRepository) Update(bundle *models.Bundle) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Creator", "Ebooks").Save(bundle).Error; err != nil {
+			log.Printf("Erro ao atualizar kit %d: %v", bundle.ID, err)
+			return errors.New("erro ao atualizar kit")
+		}
+		if err := tx.Model(bundle).Association("Ebooks").Replace(bundle.Ebooks); err != nil {
+			log.Printf("Erro ao atualizar ebooks do kit %d: %v", bundle.ID, err)
+			return errors.New("erro ao atualizar kit")
+		}
+		return nil
+	})
+}
+
+func (r *GormBundleRepository) Delete(id uint) error {
+	err := r.db.Delete(&models.Bundle{}, id).Error
+	if err != nil {
+		log.Printf("Erro ao excluir kit %d: %v", id, err)
+		return errors.New("erro ao excluir kit")
+	}
+	return nil
+}
+
+func (r *GormBundleRepository) FindByID(id uint) (*models.Bundle, error) {
+	var bundle models.Bundle
+	err := r.db.Preload("Creator").Preload("Ebooks").First(&bundle, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar kit %d: %v", id, err)
+		return nil, errors.New("erro ao buscar kit")
+	}
+	return &bundle, nil
+}
+
+func (r *GormBundleRepository) FindBySlug(slug string) (*models.Bundle, error) {
+	var bundle models.Bundle
+	err := r.db.Preload("Creator").Preload("Ebooks").Where("slug = ?", slug).First(&bundle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar kit %s: %v", slug, err)
+		return nil, errors.New("erro ao buscar kit")
+	}
+	return &bundle, nil
+}
+
+func (r *GormBundleRepository) FindByCreator(creatorID uint) ([]*models.Bundle, error) {
+	var bundles []*models.Bundle
+	err := r.db.Preload("Ebooks").Where("creator_id = ?", creatorID).Order("created_at DESC").Find(&bundles).Error
+	if err != nil {
+		log.Printf("Erro ao listar kits do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar kits")
+	}
+	return bundles, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type CheckoutAttemptRepository interface {
+	Create(attempt *models.CheckoutAttempt) error
+	Update(attempt *models.CheckoutAttempt) error
+	FindByToken(token string) (*models.CheckoutAttempt, error)
+	FindByOrderID(orderID uint) (*models.CheckoutAttempt, error)
+	// FindPending busca a tentativa mais recente ainda não paga do comprador para o ebook
+	FindPending(ebookID uint, email string) (*models.CheckoutAttempt, error)
+	// FindOpenBefore lista as tentativas abertas sem atualização desde cutoff
+	FindOpenBefore(cutoff time.Time) ([]*models.CheckoutAttempt, error)
+	// FindAbandoned lista as tentativas abandonadas que ainda não receberam todos os e-mails
+	FindAbandoned(maxEmails int) ([]*models.CheckoutAttempt, error)
+}
+
+type GormCheckoutAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCheckoutAttemptRepository(db *gorm.DB) *GormCheckoutAttemptRepository {
+	return &GormCheckoutAttemptRepository{db: db}
+}
+
+func (r *GormCheckoutAttemptRepository) Create(attempt *models.CheckoutAttempt) error {
+	if err := r.db.Omit("Ebook").Create(attempt).Error; err != nil {
+		log.Printf("Erro ao registrar tentativa de checkout: %v", err)
+		return errors.New("erro ao registrar tentativa de checkout")
+	}
+	return nil
+}
+
+func (r *GormCheckoutAttemptRepository) Update(attempt *models.CheckoutAttempt) error {
+	if err := r.db.Omit("Ebook").Save(attempt).Error; err != nil {
+		log.Printf("Erro ao atualizar tentativa de checkout %d: %v", attempt.ID, err)
+		return errors.New("erro ao atualizar tentativa de checkout")
+	}
+	return nil
+}
+
+func (r *GormCheckoutAttemptRepository) FindByToken(token string) (*models.CheckoutAttempt, error) {
+	return r.findOne(r.db.Where("token = ?", token))
+}
+
+func (r *GormCheckoutAttemptRepository) FindByOrderID(orderID uint) (*models.CheckoutAttempt, error) {
+	return r.findOne(r.db.Where("order_id = ?", orderID))
+}
+
+func (r *GormCheckoutAttemptRepository) FindPending(ebookID uint, email string) (*models.CheckoutAttempt, error) {
+	return r.findOne(r.db.
+		Where("ebook_id = ? AND email = ? AND status IN ?", ebookID, email,
+			[]string{models.CheckoutAttemptOpen, models.CheckoutAttemptAbandoned}).
+		Order("created_at DESC"))
+}
+
+func (r *GormCheckoutAttemptRepository) findOne(query *gorm.DB) (*models.CheckoutAttempt, error) {
+	var attempt models.CheckoutAttempt
+	err := query.Preload("Ebook").First(&attempt).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar tentativa de checkout: %v", err)
+		return nil, errors.New("erro ao buscar tentativa de checkout")
+	}
+	return &attempt, nil
+}
+
+func (r *GormCheckoutAttemptRepository) FindOpenBefore(cutoff time.Time) ([]*models.CheckoutAttempt, error) {
+	var attempts []*models.CheckoutAttempt
+	err := r.db.Where("status = ? AND updated_at < ?", models.CheckoutAttemptOpen, cutoff).Find(&attempts).Error
+	if err != nil {
+		log.Printf("Erro ao listar checkouts em aberto: %v", err)
+		return nil, errors.New("erro ao listar checkouts em aberto")
+	}
+	return attempts, nil
+}
+
+func (r *GormCheckoutAttemptRepository) FindAbandoned(maxEmails int) ([]*models.CheckoutAttempt, error) {
+	var attempts []*models.CheckoutAttempt
+	err := r.db.Preload("Ebook").
+		Where("status = ? AND emails_sent < ?", models.CheckoutAttemptAbandoned, maxEmails).
+		Find(&attempts).Error
+	if err != nil {
+		log.Printf("Erro ao listar checkouts abandonados: %v", err)
+		return nil, errors.New("erro ao listar checkouts abandonados")
+	}
+	return attempts, nil
+}
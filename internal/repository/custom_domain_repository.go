@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// CustomDomainRepository guarda os domínios próprios dos criadores
+type CustomDomainRepository interface {
+	// FindByCreator busca o domínio do criador; nil se não houver
+	FindByCreator(creatorID uint) (*models.CustomDomain, error)
+	// FindVerifiedByHost busca o domínio verificado com o criador; nil se não houver
+	FindVerifiedByHost(host string) (*models.CustomDomain, error)
+	// HostTaken indica se outro criador já verificou o domínio
+	HostTaken(host string, creatorID uint) (bool, error)
+	// Replace troca o domínio atual do criador pelo novo, ainda sem verificação
+	Replace(domain *models.CustomDomain) error
+	// MarkVerified grava a verificação e publica o domínio no criador; devolve
+	// models.ErrDomainTaken se outro criador verificou o host antes
+	MarkVerified(domain *models.CustomDomain) error
+	UpdateCheck(domain *models.CustomDomain) error
+	// Delete remove o domínio e deixa de usá-lo nos links do criador
+	Delete(domain *models.CustomDomain) error
+}
+
+type GormCustomDomainRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCustomDomainRepository(db *gorm.DB) *GormCustomDomainRepository {
+	return &GormCustomDomainRepository{db: db}
+}
+
+func (r *GormCustomDomainRepository) FindByCreator(creatorID uint) (*models.CustomDomain, error) {
+	var domain models.CustomDomain
+	err := r.db.Where("creator_id = ?", creatorID).First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar domínio do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao buscar domínio")
+	}
+	return &domain, nil
+}
+
+func (r *GormCustomDomainRepository) FindVerifiedByHost(host string) (*models.CustomDomain, error) {
+	var domain models.CustomDomain
+	err := r.db.Preload("Creator").
+		Where("host = ? AND verified_at IS NOT NULL", host).
+		First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar domínio %s: %v", host, err)
+		return nil, errors.New("erro ao buscar domínio")
+	}
+	return &domain, nil
+}
+
+func (r *GormCustomDomainRepository) HostTaken(host string, creatorID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CustomDomain{}).
+		Where("host = ? AND creator_id <> ? AND verified_at IS NOT NULL", host, creatorID).
+		Count(&count).Error
+	if err != nil {
+		log.Printf("Erro ao verificar domínio %s: %v", host, err)
+		return false, errors.New("erro ao verificar domínio")
+	}
+	return count > 0, nil
+}
+
+func (r *GormCustomDomainRepository) Replace(domain *models.CustomDomain) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Remoção definitiva: o índice único de host e criador não enxerga soft delete
+		if err := tx.Unscoped().Where("creator_id = ?", domain.CreatorID).Delete(&models.CustomDomain{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Creator{}).Where("id = ?", domain.CreatorID).Update("domain", "").Error; err != nil {
+			return err
+		}
+		return tx.Omit("Creator").Create(domain).Error
+	})
+	if err != nil {
+		log.Printf("Erro ao cadastrar domínio %s do criador %d: %v", domain.Host, domain.CreatorID, err)
+		return errors.New("erro ao cadastrar domínio")
+	}
+	return nil
+}
+
+func (r *GormCustomDomainRepository) MarkVerified(domain *models.CustomDomain) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&models.CustomDomain{}).
+			Where("host = ? AND creator_id <> ? AND verified_at IS NOT NULL", domain.Host, domain.CreatorID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return models.ErrDomainTaken
+		}
+		if err := tx.Model(domain).Select("verified_at", "checked_at").Updates(domain).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Creator{}).Where("id = ?", domain.CreatorID).Update("domain", domain.Host).Error
+	})
+	if errors.Is(err, models.ErrDomainTaken) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Erro ao verificar domínio %s: %v", domain.Host, err)
+		return errors.New("erro ao verificar domínio")
+	}
+	return nil
+}
+
+func (r *GormCustomDomainRepository) UpdateCheck(domain *models.CustomDomain) error {
+	if err := r.db.Model(domain).Update("checked_at", domain.CheckedAt).Error; err != nil {
+		log.Printf("Erro ao atualizar verificação do domínio %s: %v", domain.Host, err)
+		return errors.New("erro ao atualizar domínio")
+	}
+	return nil
+}
+
+func (r *GormCustomDomainRepository) Delete(domain *models.CustomDomain) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(domain).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Creator{}).Where("id = ?", domain.CreatorID).Update("domain", "").Error
+	})
+	if err != nil {
+		log.Printf("Erro ao remover domínio %s: %v", domain.Host, err)
+		return errors.New("erro ao remover domínio")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomDomainRepository_Lifecycle(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.CustomDomain{})
+	repo := repository.NewGormCustomDomainRepository(db)
+
+	creator := &models.Creator{Name: "Ana", Email: "ana@email.com", Slug: "ana"}
+	other := &models.Creator{Name: "Bia", Email: "bia@email.com"}
+	db.Create(creator)
+	db.Create(other)
+
+	domain, _ := models.NewCustomDomain(creator.ID, "loja.autor.com.br", "token-1")
+	assert.NoError(t, repo.Replace(domain))
+
+	// Cadastro pendente não reserva o domínio nem responde por ele
+	taken, err := repo.HostTaken("loja.autor.com.br", other.ID)
+	assert.NoError(t, err)
+	assert.False(t, taken)
+	found, err := repo.FindVerifiedByHost("loja.autor.com.br")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	claim, _ := models.NewCustomDomain(other.ID, "loja.autor.com.br", "token-x")
+	assert.NoError(t, repo.Replace(claim))
+
+	assert.NoError(t, domain.Verify([]string{domain.RecordValue()}, time.Now()))
+	assert.NoError(t, repo.MarkVerified(domain))
+
+	taken, err = repo.HostTaken("loja.autor.com.br", other.ID)
+	assert.NoError(t, err)
+	assert.True(t, taken)
+
+	// Quem verificar depois não toma o domínio
+	assert.NoError(t, claim.Verify([]string{claim.RecordValue()}, time.Now()))
+	assert.ErrorIs(t, repo.MarkVerified(claim), models.ErrDomainTaken)
+	assert.NoError(t, repo.Delete(claim))
+
+	found, err = repo.FindVerifiedByHost("loja.autor.com.br")
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "ana", found.Creator.Slug)
+	}
+	var saved models.Creator
+	db.First(&saved, creator.ID)
+	assert.Equal(t, "loja.autor.com.br", saved.Domain)
+
+	// Trocar o domínio descarta o anterior e tira o domínio verificado dos links
+	replacement, _ := models.NewCustomDomain(creator.ID, "www.autor.com.br", "token-2")
+	assert.NoError(t, repo.Replace(replacement))
+	current, err := repo.FindByCreator(creator.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "www.autor.com.br", current.Host)
+	db.First(&saved, creator.ID)
+	assert.Empty(t, saved.Domain)
+
+	// O domínio liberado pode ser cadastrado por outro criador
+	taken, err = repo.HostTaken("loja.autor.com.br", other.ID)
+	assert.NoError(t, err)
+	assert.False(t, taken)
+	reused, _ := models.NewCustomDomain(other.ID, "loja.autor.com.br", "token-3")
+	assert.NoError(t, repo.Replace(reused))
+
+	assert.NoError(t, repo.Delete(current))
+	current, err = repo.FindByCreator(creator.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, current)
+}
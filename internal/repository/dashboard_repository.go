@@ -84,6 +84,23 @@ func (dr *DashboardRepository) GetEngagementMetric() float64 {
 	return metric
 }
 
+// GetCheckoutRecoveryStats conta os checkouts abandonados e quantos deles foram pagos depois
+func (dr *DashboardRepository) GetCheckoutRecoveryStats() (models.CheckoutRecoveryStats, error) {
+	var stats models.CheckoutRecoveryStats
+	err := database.DB.Model(&models.CheckoutAttempt{}).
+		Select("COUNT(*) AS abandoned, COALESCE(SUM(CASE WHEN checkout_attempts.status = ? THEN 1 ELSE 0 END), 0) AS recovered",
+			models.CheckoutAttemptRecovered).
+		Joins("INNER JOIN creators ON creators.id = checkout_attempts.creator_id").
+		Where("creators.user_id = ? AND checkout_attempts.abandoned_at IS NOT NULL", dr.UserID).
+		Scan(&stats).Error
+	if err != nil {
+		log.Printf("Erro ao calcular recuperação de checkouts: %v", err)
+		return stats, err
+	}
+
+	return stats, nil
+}
+
 func (dr *DashboardRepository) GetLastPurchases() []models.Purchase {
 	var purchases []models.Purchase
 
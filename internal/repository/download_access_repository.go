@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// DownloadAccessRepository consulta e ajusta os limites de download das compras
+type DownloadAccessRepository interface {
+	// FindByClient lista as compras do cliente nos ebooks do criador
+	FindByClient(creatorID, clientID uint) ([]*models.Purchase, error)
+	// FindForCreator busca a compra de um ebook do criador; nil se não existir
+	FindForCreator(purchaseID, creatorID uint) (*models.Purchase, error)
+	UpdateAllowance(purchase *models.Purchase) error
+}
+
+type GormDownloadAccessRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDownloadAccessRepository(db *gorm.DB) *GormDownloadAccessRepository {
+	return &GormDownloadAccessRepository{db: db}
+}
+
+func (r *GormDownloadAccessRepository) FindByClient(creatorID, clientID uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+	err := r.creatorPurchases(creatorID).
+		Where("purchases.client_id = ?", clientID).
+		Order("purchases.created_at DESC").
+		Find(&purchases).Error
+	if err != nil {
+		log.Printf("Erro ao listar compras do cliente %d: %v", clientID, err)
+		return nil, errors.New("erro ao listar compras do cliente")
+	}
+	return purchases, nil
+}
+
+func (r *GormDownloadAccessRepository) FindForCreator(purchaseID, creatorID uint) (*models.Purchase, error) {
+	var purchase models.Purchase
+	err := r.creatorPurchases(creatorID).Where("purchases.id = ?", purchaseID).First(&purchase).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar compra %d: %v", purchaseID, err)
+		return nil, errors.New("erro ao buscar compra")
+	}
+	return &purchase, nil
+}
+
+func (r *GormDownloadAccessRepository) UpdateAllowance(purchase *models.Purchase) error {
+	err := r.db.Model(&models.Purchase{}).
+		Where("id = ?", purchase.ID).
+		Select("downloads_used", "download_limit", "expires_at", "devices_reset_at").
+		Updates(purchase).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar limites da compra %d: %v", purchase.ID, err)
+		return errors.New("erro ao atualizar limites da compra")
+	}
+	return nil
+}
+
+func (r *GormDownloadAccessRepository) creatorPurchases(creatorID uint) *gorm.DB {
+	return r.db.Preload("Ebook").
+		Preload("Downloads").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ?", creatorID)
+}
@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadAccessRepository_FindAndUpdate(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Client{}, &models.Ebook{}, &models.Purchase{}, &models.DownloadLog{})
+	repo := repository.NewGormDownloadAccessRepository(db)
+
+	mine := &models.Ebook{Title: "Meu ebook", Slug: "meu", CreatorID: 1, Price: money.New(3000, money.BRL)}
+	other := &models.Ebook{Title: "De outro", Slug: "outro", CreatorID: 2, Price: money.New(3000, money.BRL)}
+	db.Create(mine)
+	db.Create(other)
+	client := &models.Client{Name: "Leitor", Email: "leitor@email.com", CPF: "11111111111"}
+	db.Create(client)
+	purchase := &models.Purchase{EbookID: mine.ID, ClientID: client.ID, DownloadLimit: 3, DownloadsUsed: 3}
+	foreign := &models.Purchase{EbookID: other.ID, ClientID: client.ID, DownloadLimit: -1}
+	db.Create(purchase)
+	db.Create(foreign)
+	db.Create(&models.DownloadLog{PurchaseID: purchase.ID, IP: "10.0.0.1"})
+
+	purchases, err := repo.FindByClient(1, client.ID)
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 1)
+	assert.Equal(t, "Meu ebook", purchases[0].Ebook.Title)
+	assert.Equal(t, []string{"10.0.0.1"}, purchases[0].Devices())
+
+	found, err := repo.FindForCreator(foreign.ID, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+
+	found, err = repo.FindForCreator(purchase.ID, 1)
+	assert.NoError(t, err)
+	found.ResetAllowance(time.Now())
+	assert.NoError(t, found.Extend(0, 2, time.Now()))
+	assert.NoError(t, repo.UpdateAllowance(found))
+
+	var saved models.Purchase
+	db.First(&saved, purchase.ID)
+	assert.Equal(t, 0, saved.DownloadsUsed)
+	assert.Equal(t, 5, saved.DownloadLimit)
+	assert.NotNil(t, saved.DevicesResetAt)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// DownloadReviewRepository guarda a fila de compras suspeitas de compartilhamento
+type DownloadReviewRepository interface {
+	// FindFlagged lista as compras dos ebooks do criador aguardando revisão
+	FindFlagged(creatorID uint) ([]*models.Purchase, error)
+	// FindForCreator busca a compra de um ebook do criador; nil se não existir
+	FindForCreator(purchaseID, creatorID uint) (*models.Purchase, error)
+	UpdateReview(purchase *models.Purchase) error
+	UpdateAutoSuspend(creatorID uint, enabled bool) error
+	UpdateWatermarkText(creatorID uint, text string) error
+}
+
+type GormDownloadReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDownloadReviewRepository(db *gorm.DB) *GormDownloadReviewRepository {
+	return &GormDownloadReviewRepository{db: db}
+}
+
+func (r *GormDownloadReviewRepository) FindFlagged(creatorID uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+	err := r.creatorPurchases(creatorID).
+		Where("purchases.flagged_at IS NOT NULL").
+		Order("purchases.sharing_score DESC, purchases.flagged_at DESC").
+		Find(&purchases).Error
+	if err != nil {
+		log.Printf("Erro ao listar compras suspeitas do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar compras suspeitas")
+	}
+	return purchases, nil
+}
+
+func (r *GormDownloadReviewRepository) FindForCreator(purchaseID, creatorID uint) (*models.Purchase, error) {
+	var purchase models.Purchase
+	err := r.creatorPurchases(creatorID).Where("purchases.id = ?", purchaseID).First(&purchase).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar compra %d: %v", purchaseID, err)
+		return nil, errors.New("erro ao buscar compra")
+	}
+	return &purchase, nil
+}
+
+func (r *GormDownloadReviewRepository) UpdateReview(purchase *models.Purchase) error {
+	err := r.db.Model(&models.Purchase{}).
+		Where("id = ?", purchase.ID).
+		Select("sharing_score", "flagged_at", "suspended_at", "reviewed_at").
+		Updates(purchase).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar revisão da compra %d: %v", purchase.ID, err)
+		return errors.New("erro ao atualizar revisão da compra")
+	}
+	return nil
+}
+
+func (r *GormDownloadReviewRepository) UpdateAutoSuspend(creatorID uint, enabled bool) error {
+	err := r.db.Model(&models.Creator{}).
+		Where("id = ?", creatorID).
+		Update("auto_suspend_sharing", enabled).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar suspensão automática do criador %d: %v", creatorID, err)
+		return errors.New("erro ao atualizar suspensão automática")
+	}
+	return nil
+}
+
+func (r *GormDownloadReviewRepository) UpdateWatermarkText(creatorID uint, text string) error {
+	err := r.db.Model(&models.Creator{}).
+		Where("id = ?", creatorID).
+		Update("watermark_text", text).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar marca d'água do criador %d: %v", creatorID, err)
+		return errors.New("erro ao atualizar marca d'água")
+	}
+	return nil
+}
+
+func (r *GormDownloadReviewRepository) creatorPurchases(creatorID uint) *gorm.DB {
+	return r.db.Preload("Ebook").
+		Preload("Client").
+		Preload("Downloads").
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ?", creatorID)
+}
@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadReviewRepository_Queue(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Client{}, &models.Ebook{}, &models.Purchase{}, &models.DownloadLog{})
+	repo := repository.NewGormDownloadReviewRepository(db)
+
+	creator := &models.Creator{Name: "Autora", Email: "autora@email.com"}
+	db.Create(creator)
+	ebook := &models.Ebook{Title: "Meu ebook", Slug: "meu-revisao", CreatorID: creator.ID, Price: money.New(3000, money.BRL)}
+	other := &models.Ebook{Title: "De outro", Slug: "outro-revisao", CreatorID: creator.ID + 1, Price: money.New(3000, money.BRL)}
+	db.Create(ebook)
+	db.Create(other)
+	client := &models.Client{Name: "Leitor", Email: "leitor@email.com", CPF: "11111111111"}
+	db.Create(client)
+
+	now := time.Now()
+	flagged := &models.Purchase{EbookID: ebook.ID, ClientID: client.ID, DownloadLimit: -1, SharingScore: 90, FlaggedAt: &now}
+	clean := &models.Purchase{EbookID: ebook.ID, ClientID: client.ID, DownloadLimit: -1}
+	foreign := &models.Purchase{EbookID: other.ID, ClientID: client.ID, DownloadLimit: -1, FlaggedAt: &now}
+	db.Create(flagged)
+	db.Create(clean)
+	db.Create(foreign)
+	db.Create(&models.DownloadLog{PurchaseID: flagged.ID, IP: "10.0.0.1", UserAgent: "Chrome", Bytes: 2048})
+
+	queue, err := repo.FindFlagged(creator.ID)
+	assert.NoError(t, err)
+	assert.Len(t, queue, 1)
+	assert.Equal(t, "Leitor", queue[0].Client.Name)
+	assert.Equal(t, "Chrome", queue[0].Downloads[0].UserAgent)
+	assert.Equal(t, int64(2048), queue[0].Downloads[0].Bytes)
+
+	queue[0].Dismiss(now)
+	assert.NoError(t, repo.UpdateReview(queue[0]))
+	queue, err = repo.FindFlagged(creator.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, queue)
+
+	assert.NoError(t, repo.UpdateAutoSuspend(creator.ID, true))
+	var saved models.Creator
+	db.First(&saved, creator.ID)
+	assert.True(t, saved.AutoSuspendSharing)
+}
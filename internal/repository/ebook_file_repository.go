@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// EbookFileRepository grava o cronograma de liberação dos arquivos dos ebooks
+type EbookFileRepository interface {
+	FindByEbook(ebookID uint) ([]*models.EbookFile, error)
+	// UpdateSchedule grava a ordem e o prazo de liberação dos arquivos informados
+	UpdateSchedule(entries []*models.EbookFile) error
+	// FindDripPurchases lista as compras liberadas (na compra ou no lançamento da
+	// pré-venda) desde since de ebooks com arquivos liberados depois do acesso,
+	// carregadas com o cliente e o cronograma. Pré-vendas ainda não lançadas ficam de fora.
+	FindDripPurchases(since time.Time) ([]*models.Purchase, error)
+	MarkDripNotified(purchase *models.Purchase, at time.Time) error
+}
+
+type GormEbookFileRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEbookFileRepository(db *gorm.DB) *GormEbookFileRepository {
+	return &GormEbookFileRepository{db: db}
+}
+
+func (r *GormEbookFileRepository) FindByEbook(ebookID uint) ([]*models.EbookFile, error) {
+	var entries []*models.EbookFile
+	if err := r.db.Where("ebook_id = ?", ebookID).Find(&entries).Error; err != nil {
+		log.Printf("Erro ao buscar cronograma do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao buscar cronograma do ebook")
+	}
+	return entries, nil
+}
+
+func (r *GormEbookFileRepository) UpdateSchedule(entries []*models.EbookFile) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			err := tx.Model(&models.EbookFile{}).
+				Where("ebook_id = ? AND file_id = ?", entry.EbookID, entry.FileID).
+				Updates(map[string]interface{}{
+					"position":           entry.Position,
+					"release_after_days": entry.ReleaseAfterDays,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erro ao salvar cronograma de arquivos: %v", err)
+		return errors.New("erro ao salvar cronograma do ebook")
+	}
+	return nil
+}
+
+func (r *GormEbookFileRepository) FindDripPurchases(since time.Time) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+	err := r.db.Preload("Client").
+		Preload("Ebook.Creator").
+		Preload("Ebook.Files").
+		Preload("Ebook.FileSchedule").
+		Where("ebook_id IN (?)", r.db.Model(&models.EbookFile{}).Select("ebook_id").Where("release_after_days > 0")).
+		Where("awaiting_release = ?", false).
+		Where("COALESCE(released_at, created_at) >= ?", since).
+		Find(&purchases).Error
+	if err != nil {
+		log.Printf("Erro ao listar compras com arquivos agendados: %v", err)
+		return nil, errors.New("erro ao listar compras com arquivos agendados")
+	}
+	return purchases, nil
+}
+
+func (r *GormEbookFileRepository) MarkDripNotified(purchase *models.Purchase, at time.Time) error {
+	err := r.db.Model(&models.Purchase{}).Where("id = ?", purchase.ID).Update("drip_notified_at", at).Error
+	if err != nil {
+		log.Printf("Erro ao registrar aviso de liberação da compra %d: %v", purchase.ID, err)
+		return errors.New("erro ao registrar aviso de liberação")
+	}
+	purchase.DripNotifiedAt = &at
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEbookFileRepository_ScheduleAndDripPurchases(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Client{}, &models.File{}, &models.Ebook{}, &models.EbookFile{}, &models.Purchase{})
+	repo := repository.NewGormEbookFileRepository(db)
+
+	intro := &models.File{OriginalName: "introducao.pdf", FileType: "pdf"}
+	chapter := &models.File{OriginalName: "capitulo-1.pdf", FileType: "pdf"}
+	db.Create(intro)
+	db.Create(chapter)
+	drip := &models.Ebook{Title: "Curso", Slug: "curso", CreatorID: 1, Price: money.New(3000, money.BRL), Files: []*models.File{intro, chapter}}
+	plain := &models.Ebook{Title: "Avulso", Slug: "avulso", CreatorID: 1, Price: money.New(3000, money.BRL), Files: []*models.File{intro}}
+	db.Create(drip)
+	db.Create(plain)
+
+	err := repo.UpdateSchedule([]*models.EbookFile{
+		{EbookID: drip.ID, FileID: intro.ID, Position: 1},
+		{EbookID: drip.ID, FileID: chapter.ID, Position: 2, ReleaseAfterDays: 7},
+	})
+	assert.NoError(t, err)
+
+	entries, err := repo.FindByEbook(drip.ID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	client := &models.Client{Name: "Leitor", Email: "leitor@email.com", CPF: "11111111111"}
+	db.Create(client)
+	recent := &models.Purchase{EbookID: drip.ID, ClientID: client.ID}
+	other := &models.Purchase{EbookID: plain.ID, ClientID: client.ID}
+	old := &models.Purchase{EbookID: drip.ID, ClientID: client.ID}
+	db.Create(recent)
+	db.Create(other)
+	db.Create(old)
+	db.Model(old).Update("created_at", time.Now().AddDate(-2, 0, 0))
+	// Pré-venda ainda não lançada fica de fora; a lançada conta a partir do lançamento
+	preSale := &models.Purchase{EbookID: drip.ID, ClientID: client.ID, AwaitingRelease: true}
+	db.Create(preSale)
+	released := &models.Purchase{EbookID: drip.ID, ClientID: client.ID, AwaitingRelease: true}
+	db.Create(released)
+	db.Model(released).Update("created_at", time.Now().AddDate(-2, 0, 0))
+	released.Release(time.Now())
+	db.Model(released).Select("awaiting_release", "released_at").Updates(released)
+
+	purchases, err := repo.FindDripPurchases(time.Now().AddDate(-1, 0, 0))
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 2)
+	assert.ElementsMatch(t, []uint{recent.ID, released.ID}, []uint{purchases[0].ID, purchases[1].ID})
+	if purchases[0].ID != recent.ID {
+		purchases[0], purchases[1] = purchases[1], purchases[0]
+	}
+	assert.Equal(t, "Leitor", purchases[0].Client.Name)
+	assert.True(t, purchases[0].Ebook.HasDrip())
+	assert.Len(t, purchases[0].ScheduledFiles(), 2)
+
+	at := time.Now()
+	assert.NoError(t, repo.MarkDripNotified(purchases[0], at))
+	var saved models.Purchase
+	db.First(&saved, recent.ID)
+	assert.NotNil(t, saved.DripNotifiedAt)
+}
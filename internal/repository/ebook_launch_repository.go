@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// EbookLaunchRepository guarda as datas de publicação e a liberação da pré-venda
+type EbookLaunchRepository interface {
+	// FindDue lista os ebooks com publicação ou despublicação vencida
+	FindDue(now time.Time) ([]*models.Ebook, error)
+	UpdateSchedule(ebook *models.Ebook) error
+	// FindAwaitingRelease lista as compras da pré-venda ainda sem link de download
+	FindAwaitingRelease(ebookID uint) ([]*models.Purchase, error)
+	// Release encerra a pré-venda do ebook e libera as compras em uma transação
+	Release(ebook *models.Ebook, purchases []*models.Purchase) error
+}
+
+type GormEbookLaunchRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEbookLaunchRepository(db *gorm.DB) *GormEbookLaunchRepository {
+	return &GormEbookLaunchRepository{db: db}
+}
+
+func (r *GormEbookLaunchRepository) FindDue(now time.Time) ([]*models.Ebook, error) {
+	var ebooks []*models.Ebook
+	err := r.db.Where("publish_at <= ? OR unpublish_at <= ?", now, now).Find(&ebooks).Error
+	if err != nil {
+		log.Printf("Erro ao buscar ebooks com publicação agendada: %v", err)
+		return nil, errors.New("erro ao buscar ebooks agendados")
+	}
+	return ebooks, nil
+}
+
+func (r *GormEbookLaunchRepository) UpdateSchedule(ebook *models.Ebook) error {
+	err := r.db.Model(&models.Ebook{}).
+		Where("id = ?", ebook.ID).
+		Select("status", "publish_at", "unpublish_at").
+		Updates(ebook).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar publicação do ebook %d: %v", ebook.ID, err)
+		return errors.New("erro ao atualizar publicação do ebook")
+	}
+	return nil
+}
+
+func (r *GormEbookLaunchRepository) FindAwaitingRelease(ebookID uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+	err := r.db.Preload("Client").
+		Preload("Ebook.Files").
+		Where("ebook_id = ? AND awaiting_release = ?", ebookID, true).
+		Find(&purchases).Error
+	if err != nil {
+		log.Printf("Erro ao buscar compras da pré-venda do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao buscar compras da pré-venda")
+	}
+	return purchases, nil
+}
+
+func (r *GormEbookLaunchRepository) Release(ebook *models.Ebook, purchases []*models.Purchase) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Ebook{}).
+			Where("id = ?", ebook.ID).
+			Select("pre_sale", "released_at").
+			Updates(ebook).Error
+		if err != nil {
+			return err
+		}
+
+		for _, purchase := range purchases {
+			err := tx.Model(&models.Purchase{}).
+				Where("id = ?", purchase.ID).
+				Select("awaiting_release", "released_at", "expires_at").
+				Updates(purchase).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erro ao liberar a pré-venda do ebook %d: %v", ebook.ID, err)
+		return errors.New("erro ao liberar a pré-venda")
+	}
+	return nil
+}
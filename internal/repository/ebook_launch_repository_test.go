@@ -0,0 +1,80 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEbookLaunchRepository_FindDueAndUpdate(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Ebook{})
+	repo := repository.NewGormEbookLaunchRepository(db)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	due := &models.Ebook{Title: "Lançamento", Slug: "lancamento-hoje", PublishAt: &past, Price: money.New(3000, money.BRL)}
+	later := &models.Ebook{Title: "Mais tarde", Slug: "lancamento-depois", PublishAt: &future, Price: money.New(3000, money.BRL)}
+	db.Create(due)
+	db.Create(later)
+
+	ebooks, err := repo.FindDue(now)
+	assert.NoError(t, err)
+	assert.Len(t, ebooks, 1)
+
+	ebooks[0].ApplySchedule(now)
+	assert.NoError(t, repo.UpdateSchedule(ebooks[0]))
+
+	var saved models.Ebook
+	db.First(&saved, due.ID)
+	assert.True(t, saved.Status)
+	assert.Nil(t, saved.PublishAt)
+}
+
+func TestEbookLaunchRepository_Release(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Ebook{}, &models.Client{}, &models.ClientCreator{}, &models.Purchase{}, &models.Lead{})
+	repo := repository.NewGormEbookLaunchRepository(db)
+
+	creator := &models.Creator{Name: "Autora", Email: "autora@email.com"}
+	db.Create(creator)
+	ebook := &models.Ebook{Title: "Pré-venda", Slug: "pre-venda", CreatorID: creator.ID, PricingMode: models.PricingFree, PreSale: true}
+	db.Create(ebook)
+
+	client := &models.Client{Name: "Leitora", Email: "leitora@email.com"}
+	db.Create(client)
+
+	// Compras criadas durante a pré-venda ficam aguardando o lançamento
+	leadRepo := repository.NewGormLeadRepository(db)
+	lead := models.NewLead(ebook, client, "token-pre-venda", true)
+	assert.NoError(t, leadRepo.Create(lead))
+	assert.True(t, lead.Confirm(time.Now()))
+	purchase := models.NewPurchase(ebook.ID, client.ID)
+	assert.NoError(t, leadRepo.Confirm(lead, purchase))
+	assert.True(t, purchase.AwaitingRelease)
+
+	awaiting, err := repo.FindAwaitingRelease(ebook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, awaiting, 1)
+	assert.Equal(t, "leitora@email.com", awaiting[0].Client.Email)
+
+	now := time.Now()
+	ebook.PreSale = false
+	ebook.ReleasedAt = &now
+	awaiting[0].Release(now)
+	assert.NoError(t, repo.Release(ebook, awaiting))
+
+	awaiting, err = repo.FindAwaitingRelease(ebook.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, awaiting)
+
+	var saved models.Ebook
+	db.First(&saved, ebook.ID)
+	assert.False(t, saved.PreSale)
+	assert.NotNil(t, saved.ReleasedAt)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type EbookPriceRepository interface {
+	Create(period *models.EbookPricePeriod) error
+	Delete(id uint) error
+	FindByID(id uint) (*models.EbookPricePeriod, error)
+	FindByEbook(ebookID uint) ([]*models.EbookPricePeriod, error)
+	// SalesByPrice agrupa as vendas pagas do ebook pelo preço cobrado e pela promoção
+	// em vigor na compra, inclusive de promoções já excluídas
+	SalesByPrice(ebookID uint) ([]models.PriceSales, error)
+}
+
+type GormEbookPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEbookPriceRepository(db *gorm.DB) *GormEbookPriceRepository {
+	return &GormEbookPriceRepository{db: db}
+}
+
+func (r *GormEbookPriceRepository) Create(period *models.EbookPricePeriod) error {
+	if err := r.db.Create(period).Error; err != nil {
+		log.Printf("Erro ao criar promoção do ebook %d: %v", period.EbookID, err)
+		return errors.New("erro ao criar promoção")
+	}
+	return nil
+}
+
+func (r *GormEbookPriceRepository) Delete(id uint) error {
+	if err := r.db.Delete(&models.EbookPricePeriod{}, id).Error; err != nil {
+		log.Printf("Erro ao excluir promoção %d: %v", id, err)
+		return errors.New("erro ao excluir promoção")
+	}
+	return nil
+}
+
+func (r *GormEbookPriceRepository) FindByID(id uint) (*models.EbookPricePeriod, error) {
+	var period models.EbookPricePeriod
+	err := r.db.First(&period, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar promoção %d: %v", id, err)
+		return nil, errors.New("erro ao buscar promoção")
+	}
+	return &period, nil
+}
+
+func (r *GormEbookPriceRepository) FindByEbook(ebookID uint) ([]*models.EbookPricePeriod, error) {
+	var periods []*models.EbookPricePeriod
+	err := r.db.Where("ebook_id = ?", ebookID).Order("starts_at DESC").Find(&periods).Error
+	if err != nil {
+		log.Printf("Erro ao listar promoções do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao listar promoções")
+	}
+	return periods, nil
+}
+
+func (r *GormEbookPriceRepository) SalesByPrice(ebookID uint) ([]models.PriceSales, error) {
+	var sales []models.PriceSales
+	err := r.db.Model(&models.OrderItem{}).
+		Select("order_items.price_period_id, ebook_price_periods.label, order_items.unit_price, order_items.currency, "+
+			"COUNT(*) AS sales, SUM(order_items.unit_price * order_items.quantity) AS revenue").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("LEFT JOIN ebook_price_periods ON ebook_price_periods.id = order_items.price_period_id").
+		Where("order_items.ebook_id = ? AND orders.status = ? AND order_items.bundle_id IS NULL AND order_items.offer_id IS NULL",
+			ebookID, models.OrderStatusPaid).
+		Group("order_items.price_period_id, ebook_price_periods.label, order_items.unit_price, order_items.currency").
+		Order("sales DESC").
+		Scan(&sales).Error
+	if err != nil {
+		log.Printf("Erro ao agrupar vendas do ebook %d por preço: %v", ebookID, err)
+		return nil, errors.New("erro ao buscar vendas por preço")
+	}
+	return sales, nil
+}
@@ -0,0 +1,54 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEbookPriceRepository_SalesByPrice(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Ebook{}, &models.EbookPricePeriod{}, &models.Order{}, &models.OrderItem{})
+	repo := repository.NewGormEbookPriceRepository(db)
+
+	now := time.Now()
+	ebook := &models.Ebook{Title: "Ebook", CreatorID: 7, Price: money.New(4900, money.BRL)}
+	db.Create(ebook)
+	period, err := models.NewEbookPricePeriod(ebook, "Lançamento", money.New(2900, money.BRL), now.Add(-time.Hour), now.Add(time.Hour), now)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(period))
+
+	sell := func(at time.Time, status string) {
+		ebook.PricePeriods = []*models.EbookPricePeriod{period}
+		item := ebook.OrderItem(at)
+		order := models.NewOrder(7, 9, ebook.ID, item.UnitPrice, 0, item.Currency)
+		order.Items = []models.OrderItem{item}
+		order.Status = status
+		db.Omit("Ebook").Create(order)
+	}
+	sell(now, models.OrderStatusPaid)
+	sell(now, models.OrderStatusPaid)
+	sell(now, models.OrderStatusPending)
+	sell(now.Add(2*time.Hour), models.OrderStatusPaid)
+
+	// A promoção excluída continua identificada nas vendas feitas nela
+	assert.NoError(t, repo.Delete(period.ID))
+
+	sales, err := repo.SalesByPrice(ebook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sales, 2)
+	assert.Equal(t, "Lançamento", sales[0].Label)
+	assert.Equal(t, period.ID, *sales[0].PricePeriodID)
+	assert.Equal(t, int64(2), sales[0].Sales)
+	assert.Equal(t, int64(5800), sales[0].Revenue)
+	assert.Nil(t, sales[1].PricePeriodID)
+	assert.Equal(t, int64(4900), sales[1].UnitPrice)
+
+	periods, err := repo.FindByEbook(ebook.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, periods)
+}
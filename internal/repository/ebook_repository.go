@@ -36,7 +36,7 @@ func (r *GormEbookRepository) Create(ebook *models.Ebook) error {
 
 func (r *GormEbookRepository) FindByID(id uint) (*models.Ebook, error) {
 	var ebook models.Ebook
-	err := r.db.Preload("Creator").Preload("Files").First(&ebook, id).Error
+	err := r.db.Preload("Creator").Preload("Files").Preload("PricePeriods").First(&ebook, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -62,6 +62,7 @@ func (r *GormEbookRepository) FindBySlug(slug string) (*models.Ebook, error) {
 	err := r.db.Where("slug = ?", slug).
 		Preload("Creator").
 		Preload("Files").
+		Preload("PricePeriods").
 		First(&ebook).Error
 
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
@@ -74,7 +75,7 @@ func (suite *EbookRepositoryTestSuite) TestCreate() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "test-ebook",
 		CreatorID:   suite.creator.ID,
@@ -99,7 +100,7 @@ func (suite *EbookRepositoryTestSuite) TestFindByID() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "test-ebook",
 		CreatorID:   suite.creator.ID,
@@ -122,7 +123,7 @@ func (suite *EbookRepositoryTestSuite) TestFindByCreator() {
 		Title:       "First Ebook",
 		Description: "First description",
 		SalesPage:   "First sales page",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "first-ebook",
 		CreatorID:   suite.creator.ID,
@@ -131,7 +132,7 @@ func (suite *EbookRepositoryTestSuite) TestFindByCreator() {
 		Title:       "Second Ebook",
 		Description: "Second description",
 		SalesPage:   "Second sales page",
-		Value:       39.90,
+		Price:       money.New(3990, money.BRL),
 		Status:      true,
 		Slug:        "second-ebook",
 		CreatorID:   suite.creator.ID,
@@ -155,7 +156,7 @@ func (suite *EbookRepositoryTestSuite) TestFindBySlug() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "test-ebook",
 		CreatorID:   suite.creator.ID,
@@ -178,7 +179,7 @@ func (suite *EbookRepositoryTestSuite) TestUpdate() {
 		Title:       "Original Title",
 		Description: "Original description",
 		SalesPage:   "Original sales page",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "original-ebook",
 		CreatorID:   suite.creator.ID,
@@ -206,7 +207,7 @@ func (suite *EbookRepositoryTestSuite) TestDelete() {
 		Title:       "Test Ebook",
 		Description: "Test description",
 		SalesPage:   "Sales page content",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "test-ebook",
 		CreatorID:   suite.creator.ID,
@@ -231,7 +232,7 @@ func (suite *EbookRepositoryTestSuite) TestFindAll() {
 		Title:       "First Ebook",
 		Description: "First description",
 		SalesPage:   "First sales page",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "first-ebook",
 		CreatorID:   suite.creator.ID,
@@ -240,7 +241,7 @@ func (suite *EbookRepositoryTestSuite) TestFindAll() {
 		Title:       "Second Ebook",
 		Description: "Second description",
 		SalesPage:   "Second sales page",
-		Value:       39.90,
+		Price:       money.New(3990, money.BRL),
 		Status:      true,
 		Slug:        "second-ebook",
 		CreatorID:   suite.creator.ID,
@@ -262,7 +263,7 @@ func (suite *EbookRepositoryTestSuite) TestFindActive() {
 		Title:       "Active Ebook",
 		Description: "Active description",
 		SalesPage:   "Active sales page",
-		Value:       29.90,
+		Price:       money.New(2990, money.BRL),
 		Status:      true,
 		Slug:        "active-ebook",
 		CreatorID:   suite.creator.ID,
@@ -271,7 +272,7 @@ func (suite *EbookRepositoryTestSuite) TestFindActive() {
 		Title:       "Inactive Ebook",
 		Description: "Inactive description",
 		SalesPage:   "Inactive sales page",
-		Value:       39.90,
+		Price:       money.New(3990, money.BRL),
 		Status:      false,
 		Slug:        "inactive-ebook",
 		CreatorID:   suite.creator.ID,
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileVersionRepository grava as versões dos arquivos e encontra quem deve ser
+// avisado de uma versão nova
+type FileVersionRepository interface {
+	// Publish arquiva a versão anterior e grava o arquivo com o conteúdo novo
+	Publish(file *models.File, archived *models.FileVersion) error
+	FindByFile(fileID uint) ([]*models.FileVersion, error)
+	// FindBuyers lista as compras do ebook com o cliente e os downloads carregados
+	FindBuyers(ebookID uint) ([]*models.Purchase, error)
+	MarkEbookNotified(ebook *models.Ebook, at time.Time) error
+}
+
+type GormFileVersionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormFileVersionRepository(db *gorm.DB) *GormFileVersionRepository {
+	return &GormFileVersionRepository{db: db}
+}
+
+func (r *GormFileVersionRepository) Publish(file *models.File, archived *models.FileVersion) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(archived).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(file).Error
+	})
+	if err != nil {
+		log.Printf("Erro ao publicar versão %d do arquivo %d: %v", file.Version, file.ID, err)
+		return errors.New("erro ao publicar nova versão do arquivo")
+	}
+	return nil
+}
+
+func (r *GormFileVersionRepository) FindByFile(fileID uint) ([]*models.FileVersion, error) {
+	var versions []*models.FileVersion
+	if err := r.db.Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error; err != nil {
+		log.Printf("Erro ao buscar versões do arquivo %d: %v", fileID, err)
+		return nil, errors.New("erro ao buscar versões do arquivo")
+	}
+	return versions, nil
+}
+
+func (r *GormFileVersionRepository) FindBuyers(ebookID uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+	err := r.db.Preload("Client").
+		Preload("Ebook.Creator").
+		Preload("Downloads").
+		Where("ebook_id = ?", ebookID).
+		Find(&purchases).Error
+	if err != nil {
+		log.Printf("Erro ao buscar compradores do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao buscar compradores do ebook")
+	}
+	return purchases, nil
+}
+
+func (r *GormFileVersionRepository) MarkEbookNotified(ebook *models.Ebook, at time.Time) error {
+	err := r.db.Model(&models.Ebook{}).Where("id = ?", ebook.ID).Update("version_notified_at", at).Error
+	if err != nil {
+		log.Printf("Erro ao registrar aviso de nova versão do ebook %d: %v", ebook.ID, err)
+		return errors.New("erro ao registrar aviso de nova versão")
+	}
+	ebook.VersionNotifiedAt = &at
+	return nil
+}
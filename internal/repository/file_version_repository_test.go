@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileVersionRepository_PublishAndNotify(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Client{}, &models.File{}, &models.FileVersion{},
+		&models.Ebook{}, &models.Purchase{}, &models.DownloadLog{})
+	repo := repository.NewGormFileVersionRepository(db)
+
+	file := models.NewFile("livro-a1.pdf", "livro.pdf", "Livro", "pdf", "files/1/livro-a1.pdf", "", 100, 1)
+	db.Create(file)
+	upload := models.NewFile("livro-v2.pdf", "livro.pdf", "", "pdf", "files/1/1/livro-v2.pdf", "", 150, 1)
+	archived, err := file.NewVersion(upload, "Capítulo novo", time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Publish(file, archived))
+
+	var saved models.File
+	db.First(&saved, file.ID)
+	assert.Equal(t, 2, saved.Version)
+	assert.Equal(t, "files/1/1/livro-v2.pdf", saved.S3Key)
+	versions, err := repo.FindByFile(file.ID)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+	assert.Equal(t, "files/1/livro-a1.pdf", versions[0].S3Key)
+
+	ebook := &models.Ebook{Title: "Livro", Slug: "livro", CreatorID: 1, Price: money.New(3000, money.BRL)}
+	db.Create(ebook)
+	client := &models.Client{Name: "Leitor", Email: "leitor@email.com", CPF: "11111111111"}
+	db.Create(client)
+	purchase := &models.Purchase{EbookID: ebook.ID, ClientID: client.ID}
+	db.Create(purchase)
+	db.Create(&models.DownloadLog{PurchaseID: purchase.ID, FileID: file.ID, FileVersion: 1})
+	db.Create(&models.Purchase{EbookID: ebook.ID + 1, ClientID: client.ID})
+
+	buyers, err := repo.FindBuyers(ebook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, buyers, 1)
+	assert.Equal(t, "Leitor", buyers[0].Client.Name)
+	assert.Len(t, buyers[0].Downloads, 1)
+
+	at := time.Now()
+	assert.NoError(t, repo.MarkEbookNotified(ebook, at))
+	var notified models.Ebook
+	db.First(&notified, ebook.ID)
+	assert.NotNil(t, notified.VersionNotifiedAt)
+}
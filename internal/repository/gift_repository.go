@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GiftRepository interface {
+	// Create registra o presente e marca o pedido como presente, na mesma transação
+	Create(gift *models.Gift) error
+	Update(gift *models.Gift) error
+	FindByToken(token string) (*models.Gift, error)
+	FindByOrderID(orderID uint) (*models.Gift, error)
+	// FindDue lista os presentes pagos cujo link de resgate ainda não foi enviado e
+	// cuja data de envio já chegou
+	FindDue(now time.Time) ([]*models.Gift, error)
+	// Claim grava o resgate e as compras em nome de quem recebeu o presente. As
+	// compras voltam carregadas com o cliente e o ebook para o envio do link.
+	Claim(gift *models.Gift, purchases []*models.Purchase) error
+}
+
+type GormGiftRepository struct {
+	db *gorm.DB
+}
+
+func NewGormGiftRepository(db *gorm.DB) *GormGiftRepository {
+	return &GormGiftRepository{db: db}
+}
+
+func (r *GormGiftRepository) Create(gift *models.Gift) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(gift).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Order{}).Where("id = ?", gift.OrderID).Update("is_gift", true).Error
+	})
+	if err != nil {
+		log.Printf("Erro ao registrar presente do pedido %d: %v", gift.OrderID, err)
+		return errors.New("erro ao registrar presente")
+	}
+	return nil
+}
+
+func (r *GormGiftRepository) Update(gift *models.Gift) error {
+	if err := r.db.Omit(clause.Associations).Save(gift).Error; err != nil {
+		log.Printf("Erro ao atualizar presente %d: %v", gift.ID, err)
+		return errors.New("erro ao atualizar presente")
+	}
+	return nil
+}
+
+func (r *GormGiftRepository) FindByToken(token string) (*models.Gift, error) {
+	return r.findOne(r.db.Where("token = ?", token))
+}
+
+func (r *GormGiftRepository) FindByOrderID(orderID uint) (*models.Gift, error) {
+	return r.findOne(r.db.Where("order_id = ?", orderID))
+}
+
+func (r *GormGiftRepository) findOne(query *gorm.DB) (*models.Gift, error) {
+	var gift models.Gift
+	err := r.preload(query).First(&gift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar presente: %v", err)
+		return nil, errors.New("erro ao buscar presente")
+	}
+	return &gift, nil
+}
+
+func (r *GormGiftRepository) FindDue(now time.Time) ([]*models.Gift, error) {
+	var gifts []*models.Gift
+	err := r.preload(r.db.
+		Joins("JOIN orders ON orders.id = gifts.order_id").
+		Where("orders.status = ? AND gifts.sent_at IS NULL", models.OrderStatusPaid).
+		Where("gifts.send_at IS NULL OR gifts.send_at <= ?", now)).
+		Find(&gifts).Error
+	if err != nil {
+		log.Printf("Erro ao buscar presentes para envio: %v", err)
+		return nil, errors.New("erro ao buscar presentes")
+	}
+	return gifts, nil
+}
+
+func (r *GormGiftRepository) preload(query *gorm.DB) *gorm.DB {
+	return query.Preload("Order").Preload("Order.Items").Preload("Ebook").Preload("Ebook.Creator").Preload("Sender")
+}
+
+func (r *GormGiftRepository) Claim(gift *models.Gift, purchases []*models.Purchase) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// O resgate só vale uma vez, mesmo com dois envios simultâneos do formulário
+		result := tx.Model(&models.Gift{}).
+			Where("id = ? AND claimed_at IS NULL", gift.ID).
+			Updates(map[string]any{"claimed_at": gift.ClaimedAt, "recipient_id": gift.RecipientID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrGiftAlreadyClaimed
+		}
+
+		for _, purchase := range purchases {
+			purchase.OrderID = &gift.OrderID
+		}
+		if err := applyDownloadPolicies(tx, purchases...); err != nil {
+			return err
+		}
+		return tx.Omit("Ebook", "Client").Create(purchases).Error
+	})
+	if errors.Is(err, models.ErrGiftAlreadyClaimed) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Erro ao resgatar presente %d: %v", gift.ID, err)
+		return errors.New("erro ao resgatar presente")
+	}
+
+	for _, purchase := range purchases {
+		err := r.db.Preload("Client").Preload("Ebook").Preload("Ebook.Creator").First(purchase, purchase.ID).Error
+		if err != nil {
+			log.Printf("Erro ao carregar compra %d do presente %d: %v", purchase.ID, gift.ID, err)
+			return errors.New("erro ao carregar compra do presente")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGiftRepository_FindDueAndClaim(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Ebook{}, &models.Client{}, &models.Order{}, &models.OrderItem{}, &models.Purchase{}, &models.Gift{})
+	repo := repository.NewGormGiftRepository(db)
+
+	now := time.Now()
+	ebook := &models.Ebook{Title: "Ebook", CreatorID: 1, Price: money.New(4900, money.BRL)}
+	db.Create(ebook)
+	sender := &models.Client{Name: "Quem paga", Email: "paga@email.com", CPF: "11111111111"}
+	db.Create(sender)
+
+	newGift := func(status string, sendAt *time.Time, token string) *models.Gift {
+		order := models.NewOrder(1, sender.ID, ebook.ID, 4900, 0, money.BRL)
+		order.Status = status
+		db.Create(order)
+		gift, err := models.NewGift(order, ebook, "Amiga", "amiga@email.com", "", sendAt, token, now.Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Create(gift))
+		return gift
+	}
+
+	tomorrow := now.Add(24 * time.Hour)
+	due := newGift(models.OrderStatusPaid, nil, "devido")
+	newGift(models.OrderStatusPaid, &tomorrow, "agendado")
+	newGift(models.OrderStatusPending, nil, "pendente")
+
+	var order models.Order
+	db.First(&order, due.OrderID)
+	assert.True(t, order.IsGift)
+
+	gifts, err := repo.FindDue(now)
+	assert.NoError(t, err)
+	assert.Len(t, gifts, 1)
+	assert.Equal(t, "devido", gifts[0].Token)
+	assert.Equal(t, "Quem paga", gifts[0].Sender.Name)
+
+	gifts, err = repo.FindDue(tomorrow)
+	assert.NoError(t, err)
+	assert.Len(t, gifts, 2)
+
+	found, err := repo.FindByToken("devido")
+	assert.NoError(t, err)
+	recipient := &models.Client{Name: "Amiga", Email: "amiga@email.com", CPF: "22222222222"}
+	db.Create(recipient)
+
+	assert.NoError(t, found.Claim(recipient, now))
+	purchase := models.NewPurchase(ebook.ID, recipient.ID)
+	assert.NoError(t, repo.Claim(found, []*models.Purchase{purchase}))
+	assert.Equal(t, due.OrderID, *purchase.OrderID)
+	assert.Equal(t, "Amiga", purchase.Client.Name)
+
+	// Um segundo resgate do mesmo presente não cria outra compra
+	again, _ := repo.FindByToken("devido")
+	again.ClaimedAt = nil
+	assert.NoError(t, again.Claim(recipient, now))
+	err = repo.Claim(again, []*models.Purchase{models.NewPurchase(ebook.ID, recipient.ID)})
+	assert.ErrorIs(t, err, models.ErrGiftAlreadyClaimed)
+
+	var purchases int64
+	db.Model(&models.Purchase{}).Where("order_id = ?", due.OrderID).Count(&purchases)
+	assert.Equal(t, int64(1), purchases)
+}
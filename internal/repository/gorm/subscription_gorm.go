@@ -82,3 +82,39 @@ func (sr *SubscriptionGormRepository) Save(subscription *models.Subscription) er
 	}
 	return nil
 }
+
+func (sr *SubscriptionGormRepository) SaveWithEvent(subscription *models.Subscription, event *models.SubscriptionEvent) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Save(subscription).Error; err != nil {
+			log.Printf("Erro ao salvar subscription: %s", err)
+			return errors.New("erro ao salvar subscription")
+		}
+
+		event.SubscriptionID = subscription.ID
+		if err := tx.Create(event).Error; err != nil {
+			log.Printf("Erro ao registrar evento da subscription %d: %s", subscription.ID, err)
+			return errors.New("erro ao registrar histórico da assinatura")
+		}
+		return nil
+	})
+}
+
+func (sr *SubscriptionGormRepository) FindEvents(subscriptionID uint) ([]*models.SubscriptionEvent, error) {
+	var events []*models.SubscriptionEvent
+	err := database.DB.Where("subscription_id = ?", subscriptionID).Order("created_at DESC, id DESC").Find(&events).Error
+	if err != nil {
+		log.Printf("Erro ao buscar histórico da subscription %d: %s", subscriptionID, err)
+		return nil, errors.New("erro ao buscar histórico da assinatura")
+	}
+	return events, nil
+}
+
+func (sr *SubscriptionGormRepository) FindPastDue() ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	err := database.DB.Preload("User").Where("subscription_status = ?", models.SubscriptionPastDue).Find(&subscriptions).Error
+	if err != nil {
+		log.Printf("Erro ao buscar subscriptions com pagamento pendente: %s", err)
+		return nil, errors.New("erro ao buscar subscriptions")
+	}
+	return subscriptions, nil
+}
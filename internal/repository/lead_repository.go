@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeadRepository interface {
+	Create(lead *models.Lead) error
+	Update(lead *models.Lead) error
+	FindByToken(token string) (*models.Lead, error)
+	// FindLatest busca o pedido mais recente do cliente para o ebook gratuito
+	FindLatest(ebookID, clientID uint) (*models.Lead, error)
+	// Confirm grava, na mesma transação, a confirmação do lead, os consentimentos do
+	// cliente, o vínculo do cliente com o criador e a compra que libera o download.
+	// A compra volta carregada com o cliente e o ebook para o envio do link. Se o
+	// lead já tiver sido confirmado, retorna models.ErrLeadAlreadyConfirmed.
+	Confirm(lead *models.Lead, purchase *models.Purchase) error
+}
+
+type GormLeadRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeadRepository(db *gorm.DB) *GormLeadRepository {
+	return &GormLeadRepository{db: db}
+}
+
+func (r *GormLeadRepository) Create(lead *models.Lead) error {
+	if err := r.db.Omit(clause.Associations).Create(lead).Error; err != nil {
+		log.Printf("Erro ao registrar lead do ebook %d: %v", lead.EbookID, err)
+		return errors.New("erro ao registrar pedido do ebook")
+	}
+	return nil
+}
+
+func (r *GormLeadRepository) Update(lead *models.Lead) error {
+	if err := r.db.Omit(clause.Associations).Save(lead).Error; err != nil {
+		log.Printf("Erro ao atualizar lead %d: %v", lead.ID, err)
+		return errors.New("erro ao atualizar pedido do ebook")
+	}
+	return nil
+}
+
+func (r *GormLeadRepository) FindByToken(token string) (*models.Lead, error) {
+	return r.findOne(r.db.Where("token = ?", token))
+}
+
+func (r *GormLeadRepository) FindLatest(ebookID, clientID uint) (*models.Lead, error) {
+	return r.findOne(r.db.Where("ebook_id = ? AND client_id = ?", ebookID, clientID).Order("created_at DESC"))
+}
+
+func (r *GormLeadRepository) findOne(query *gorm.DB) (*models.Lead, error) {
+	var lead models.Lead
+	err := query.Preload("Ebook").Preload("Client").First(&lead).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar lead: %v", err)
+		return nil, errors.New("erro ao buscar pedido do ebook")
+	}
+	return &lead, nil
+}
+
+func (r *GormLeadRepository) Confirm(lead *models.Lead, purchase *models.Purchase) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// A confirmação só vale uma vez, mesmo com dois cliques simultâneos no link
+		result := tx.Model(&models.Lead{}).
+			Where("id = ? AND confirmed_at IS NULL", lead.ID).
+			Update("confirmed_at", lead.ConfirmedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrLeadAlreadyConfirmed
+		}
+
+		err := tx.Model(&lead.Client).
+			Select("EmailConfirmedAt", "MarketingConsent", "MarketingConsentAt").
+			Updates(&lead.Client).Error
+		if err != nil {
+			return err
+		}
+
+		var linked int64
+		err = tx.Model(&models.ClientCreator{}).
+			Where("client_id = ? AND creator_id = ?", lead.ClientID, lead.CreatorID).
+			Count(&linked).Error
+		if err != nil {
+			return err
+		}
+		if linked == 0 {
+			link := &models.ClientCreator{ClientID: lead.ClientID, CreatorID: lead.CreatorID}
+			if err := tx.Omit(clause.Associations).Create(link).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := applyDownloadPolicies(tx, purchase); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(purchase).Error; err != nil {
+			return err
+		}
+
+		lead.PurchaseID = &purchase.ID
+		return tx.Model(&models.Lead{}).Where("id = ?", lead.ID).Update("purchase_id", purchase.ID).Error
+	})
+	if errors.Is(err, models.ErrLeadAlreadyConfirmed) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Erro ao confirmar lead %d: %v", lead.ID, err)
+		return errors.New("erro ao confirmar pedido do ebook")
+	}
+
+	err = r.db.Preload("Client").Preload("Ebook").Preload("Ebook.Creator").First(purchase, purchase.ID).Error
+	if err != nil {
+		log.Printf("Erro ao carregar compra %d do lead %d: %v", purchase.ID, lead.ID, err)
+		return errors.New("erro ao carregar compra do ebook")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeadRepository_Confirm(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Ebook{}, &models.Client{}, &models.ClientCreator{}, &models.Purchase{}, &models.Lead{})
+	repo := repository.NewGormLeadRepository(db)
+
+	creator := &models.Creator{Name: "Criador", UserID: 1}
+	db.Create(creator)
+	ebook := &models.Ebook{Title: "Ebook gratuito", CreatorID: creator.ID, PricingMode: models.PricingFree,
+		DownloadPolicy: models.DownloadPolicy{MaxDownloads: 3, ExpiryDays: 7}}
+	db.Create(ebook)
+	// Dois leads sem CPF não podem esbarrar na unicidade do CPF
+	assert.NoError(t, db.Create(&models.Client{Name: "Outro", Email: "outro@email.com"}).Error)
+	client := &models.Client{Name: "Leitor", Email: "leitor@email.com"}
+	assert.NoError(t, db.Create(client).Error)
+
+	lead := models.NewLead(ebook, client, "token", true)
+	assert.NoError(t, repo.Create(lead))
+
+	found, err := repo.FindByToken("token")
+	assert.NoError(t, err)
+	assert.True(t, found.Confirm(time.Now()))
+
+	purchase := models.NewPurchase(ebook.ID, client.ID)
+	assert.NoError(t, repo.Confirm(found, purchase))
+	assert.Equal(t, 3, purchase.DownloadLimit)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), purchase.ExpiresAt, time.Minute)
+	assert.Equal(t, "Criador", purchase.Ebook.Creator.Name)
+	assert.Equal(t, "leitor@email.com", purchase.Client.Email)
+
+	var saved models.Client
+	db.First(&saved, client.ID)
+	assert.NotNil(t, saved.EmailConfirmedAt)
+	assert.True(t, saved.MarketingConsent)
+
+	var links int64
+	db.Model(&models.ClientCreator{}).Where("client_id = ? AND creator_id = ?", client.ID, creator.ID).Count(&links)
+	assert.Equal(t, int64(1), links)
+
+	confirmed, err := repo.FindLatest(ebook.ID, client.ID)
+	assert.NoError(t, err)
+	assert.True(t, confirmed.IsConfirmed())
+	assert.Equal(t, purchase.ID, *confirmed.PurchaseID)
+
+	// Um segundo clique simultâneo no link não gera outra compra
+	again, err := repo.FindByToken("token")
+	assert.NoError(t, err)
+	again.ConfirmedAt = nil
+	assert.True(t, again.Confirm(time.Now()))
+	assert.ErrorIs(t, repo.Confirm(again, models.NewPurchase(ebook.ID, client.ID)), models.ErrLeadAlreadyConfirmed)
+
+	var purchases int64
+	db.Model(&models.Purchase{}).Where("ebook_id = ? AND client_id = ?", ebook.ID, client.ID).Count(&purchases)
+	assert.Equal(t, int64(1), purchases)
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LicenseRepository interface {
+	Create(license *models.License) error
+	Update(license *models.License) error
+	FindByToken(token string) (*models.License, error)
+	FindByOrderID(orderID uint) (*models.License, error)
+	// FindByEbook lista as licenças para equipe pagas do ebook, com as atribuições
+	FindByEbook(ebookID uint) ([]*models.License, error)
+	// FindUnsent lista as licenças pagas cujo link de gestão ainda não foi enviado ao gestor
+	FindUnsent() ([]*models.License, error)
+	// Assign grava as novas atribuições com as respectivas compras. As compras voltam
+	// carregadas com o cliente e o ebook para o envio do link.
+	Assign(license *models.License, seats []*models.LicenseSeat) error
+	// Reassign transfere a licença para outra pessoa, removendo a compra anterior
+	// desde que ela ainda não tenha sido baixada
+	Reassign(license *models.License, seat *models.LicenseSeat, purchase *models.Purchase) error
+}
+
+type GormLicenseRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLicenseRepository(db *gorm.DB) *GormLicenseRepository {
+	return &GormLicenseRepository{db: db}
+}
+
+func (r *GormLicenseRepository) Create(license *models.License) error {
+	if err := r.db.Omit(clause.Associations).Create(license).Error; err != nil {
+		log.Printf("Erro ao registrar licenças do pedido %d: %v", license.OrderID, err)
+		return errors.New("erro ao registrar licenças")
+	}
+	return nil
+}
+
+func (r *GormLicenseRepository) Update(license *models.License) error {
+	if err := r.db.Omit(clause.Associations).Save(license).Error; err != nil {
+		log.Printf("Erro ao atualizar licença %d: %v", license.ID, err)
+		return errors.New("erro ao atualizar licenças")
+	}
+	return nil
+}
+
+func (r *GormLicenseRepository) FindByToken(token string) (*models.License, error) {
+	return r.findOne(r.db.Where("token = ?", token))
+}
+
+func (r *GormLicenseRepository) FindByOrderID(orderID uint) (*models.License, error) {
+	return r.findOne(r.db.Where("order_id = ?", orderID))
+}
+
+func (r *GormLicenseRepository) findOne(query *gorm.DB) (*models.License, error) {
+	var license models.License
+	err := r.preload(query).First(&license).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar licença: %v", err)
+		return nil, errors.New("erro ao buscar licenças")
+	}
+	return &license, nil
+}
+
+func (r *GormLicenseRepository) FindByEbook(ebookID uint) ([]*models.License, error) {
+	var licenses []*models.License
+	err := r.preload(r.db.
+		Joins("JOIN orders ON orders.id = licenses.order_id").
+		Where("licenses.ebook_id = ? AND orders.status = ?", ebookID, models.OrderStatusPaid).
+		Order("licenses.created_at DESC")).
+		Find(&licenses).Error
+	if err != nil {
+		log.Printf("Erro ao listar licenças do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao listar licenças")
+	}
+	return licenses, nil
+}
+
+func (r *GormLicenseRepository) FindUnsent() ([]*models.License, error) {
+	var licenses []*models.License
+	err := r.preload(r.db.
+		Joins("JOIN orders ON orders.id = licenses.order_id").
+		Where("orders.status = ? AND licenses.sent_at IS NULL", models.OrderStatusPaid)).
+		Find(&licenses).Error
+	if err != nil {
+		log.Printf("Erro ao buscar licenças para envio: %v", err)
+		return nil, errors.New("erro ao buscar licenças")
+	}
+	return licenses, nil
+}
+
+func (r *GormLicenseRepository) preload(query *gorm.DB) *gorm.DB {
+	return query.Preload("Order").Preload("Order.Items").Preload("Ebook").Preload("Manager").
+		Preload("Assignments", func(db *gorm.DB) *gorm.DB { return db.Order("license_seats.created_at") }).
+		Preload("Assignments.Purchase")
+}
+
+func (r *GormLicenseRepository) Assign(license *models.License, seats []*models.LicenseSeat) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Dois envios simultâneos não podem passar do número de licenças compradas
+		var used int64
+		if err := tx.Model(&models.LicenseSeat{}).Where("license_id = ?", license.ID).Count(&used).Error; err != nil {
+			return err
+		}
+		if int(used)+len(seats) > license.Seats {
+			return models.ErrLicenseNoSeats
+		}
+
+		for _, seat := range seats {
+			if err := linkClientCreator(tx, seat.ClientID, license.CreatorID); err != nil {
+				return err
+			}
+			seat.Purchase.OrderID = &license.OrderID
+			if err := applyDownloadPolicies(tx, &seat.Purchase); err != nil {
+				return err
+			}
+			if err := tx.Omit("Ebook", "Client").Create(&seat.Purchase).Error; err != nil {
+				return err
+			}
+			seat.LicenseID = license.ID
+			seat.PurchaseID = seat.Purchase.ID
+			if err := tx.Omit(clause.Associations).Create(seat).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, models.ErrLicenseNoSeats) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Erro ao atribuir licenças %d: %v", license.ID, err)
+		return errors.New("erro ao atribuir licenças")
+	}
+
+	for _, seat := range seats {
+		if err := r.loadPurchase(&seat.Purchase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *GormLicenseRepository) Reassign(license *models.License, seat *models.LicenseSeat, purchase *models.Purchase) error {
+	previousID := seat.PurchaseID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// A compra anterior só é removida se ainda não houve download
+		result := tx.Where("id = ? AND downloads_used = 0", previousID).Delete(&models.Purchase{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrSeatAlreadyDownloaded
+		}
+
+		if err := linkClientCreator(tx, seat.ClientID, license.CreatorID); err != nil {
+			return err
+		}
+		purchase.OrderID = &license.OrderID
+		if err := applyDownloadPolicies(tx, purchase); err != nil {
+			return err
+		}
+		if err := tx.Omit("Ebook", "Client").Create(purchase).Error; err != nil {
+			return err
+		}
+		seat.PurchaseID = purchase.ID
+		return tx.Omit(clause.Associations).Save(seat).Error
+	})
+	if errors.Is(err, models.ErrSeatAlreadyDownloaded) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Erro ao transferir licença %d: %v", seat.ID, err)
+		return errors.New("erro ao transferir licença")
+	}
+
+	seat.Purchase = *purchase
+	return r.loadPurchase(&seat.Purchase)
+}
+
+// linkClientCreator vincula o cliente aos clientes do criador, se ainda não estiver
+func linkClientCreator(tx *gorm.DB, clientID, creatorID uint) error {
+	var linked int64
+	err := tx.Model(&models.ClientCreator{}).
+		Where("client_id = ? AND creator_id = ?", clientID, creatorID).
+		Count(&linked).Error
+	if err != nil || linked > 0 {
+		return err
+	}
+	link := &models.ClientCreator{ClientID: clientID, CreatorID: creatorID}
+	return tx.Omit(clause.Associations).Create(link).Error
+}
+
+func (r *GormLicenseRepository) loadPurchase(purchase *models.Purchase) error {
+	err := r.db.Preload("Client").Preload("Ebook").Preload("Ebook.Creator").First(purchase, purchase.ID).Error
+	if err != nil {
+		log.Printf("Erro ao carregar compra %d da licença: %v", purchase.ID, err)
+		return errors.New("erro ao carregar compra da licença")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLicenseRepository_AssignAndReassign(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Ebook{}, &models.Client{}, &models.ClientCreator{}, &models.Order{}, &models.OrderItem{},
+		&models.Purchase{}, &models.License{}, &models.LicenseSeat{})
+	repo := repository.NewGormLicenseRepository(db)
+
+	ebook := &models.Ebook{Title: "Treinamento", CreatorID: 1, Price: money.New(4000, money.BRL)}
+	db.Create(ebook)
+	manager := &models.Client{Name: "Gestora", Email: "gestora@empresa.com", CPF: "11111111111"}
+	db.Create(manager)
+
+	order, err := models.NewLicenseOrder(models.NewOrderItem(ebook), 1, manager.ID, 2)
+	assert.NoError(t, err)
+	order.Status = models.OrderStatusPaid
+	db.Create(order)
+	license, err := models.NewLicense(order, "equipe")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(license))
+
+	unsent, err := repo.FindUnsent()
+	assert.NoError(t, err)
+	assert.Len(t, unsent, 1)
+
+	maria := &models.Client{Name: "Maria", Email: "maria@empresa.com"}
+	joao := &models.Client{Name: "João", Email: "joao@empresa.com"}
+	ana := &models.Client{Name: "Ana", Email: "ana@empresa.com"}
+	db.Create(maria)
+	db.Create(joao)
+	db.Create(ana)
+	newSeat := func(client *models.Client) *models.LicenseSeat {
+		return &models.LicenseSeat{Name: client.Name, Email: client.Email, ClientID: client.ID,
+			Purchase: *models.NewPurchase(ebook.ID, client.ID)}
+	}
+
+	seats := []*models.LicenseSeat{newSeat(maria), newSeat(joao)}
+	assert.NoError(t, repo.Assign(license, seats))
+	assert.Equal(t, order.ID, *seats[0].Purchase.OrderID)
+	assert.Equal(t, "Maria", seats[0].Purchase.Client.Name)
+
+	var linked int64
+	db.Model(&models.ClientCreator{}).Where("creator_id = ?", 1).Count(&linked)
+	assert.Equal(t, int64(2), linked)
+
+	// Não é possível passar do número de licenças compradas
+	err = repo.Assign(license, []*models.LicenseSeat{newSeat(ana)})
+	assert.ErrorIs(t, err, models.ErrLicenseNoSeats)
+
+	found, err := repo.FindByToken("equipe")
+	assert.NoError(t, err)
+	assert.Len(t, found.Assignments, 2)
+	assert.Equal(t, 0, found.AvailableSeats())
+
+	// Transferência antes do download remove a compra anterior
+	seat := found.FindSeat(seats[0].ID)
+	previous := seat.PurchaseID
+	seat.Name, seat.Email, seat.ClientID = ana.Name, ana.Email, ana.ID
+	purchase := models.NewPurchase(ebook.ID, ana.ID)
+	assert.NoError(t, repo.Reassign(found, seat, purchase))
+	assert.Equal(t, "Ana", seat.Purchase.Client.Name)
+	assert.Equal(t, order.ID, *purchase.OrderID)
+	var removed int64
+	db.Model(&models.Purchase{}).Where("id = ?", previous).Count(&removed)
+	assert.Equal(t, int64(0), removed)
+
+	// Depois do primeiro download a licença fica com a pessoa
+	db.Model(&models.Purchase{}).Where("id = ?", seats[1].PurchaseID).Update("downloads_used", 1)
+	other := found.FindSeat(seats[1].ID)
+	err = repo.Reassign(found, other, models.NewPurchase(ebook.ID, maria.ID))
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyDownloaded)
+
+	licenses, err := repo.FindByEbook(ebook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, licenses, 1)
+	assert.Equal(t, "Gestora", licenses[0].Manager.Name)
+}
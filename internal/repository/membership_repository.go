@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MembershipRepository interface {
+	Create(membership *models.Membership) error
+	Update(membership *models.Membership) error
+	FindByID(id uint) (*models.Membership, error)
+	FindByCreator(creatorID uint) ([]*models.Membership, error)
+	// CountActiveMembers conta os assinantes em dia de cada assinatura do criador
+	CountActiveMembers(creatorID uint) (map[uint]int64, error)
+
+	CreateMember(member *models.Member) error
+	UpdateMember(member *models.Member) error
+	FindMemberByToken(token string) (*models.Member, error)
+	FindMemberBySession(sessionID string) (*models.Member, error)
+	FindMember(id uint) (*models.Member, error)
+	FindActiveMembers() ([]*models.Member, error)
+	// Grant renova até expiresAt as compras do assinante e cria as compras dos ebooks
+	// ativos do catálogo que ele ainda não recebeu. Retorna as compras novas, carregadas
+	// com o cliente e o ebook para o envio do link.
+	Grant(member *models.Member, expiresAt time.Time) ([]*models.Purchase, error)
+	// Revoke encerra em now o acesso às compras entregues pela assinatura
+	Revoke(member *models.Member, now time.Time) error
+	// FindMemberPurchases lista as compras entregues pela assinatura, com o ebook
+	FindMemberPurchases(memberID uint) ([]*models.Purchase, error)
+}
+
+type GormMembershipRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMembershipRepository(db *gorm.DB) *GormMembershipRepository {
+	return &GormMembershipRepository{db: db}
+}
+
+func (r *GormMembershipRepository) Create(membership *models.Membership) error {
+	if err := r.db.Omit(clause.Associations).Create(membership).Error; err != nil {
+		log.Printf("Erro ao criar assinatura do criador %d: %v", membership.CreatorID, err)
+		return errors.New("erro ao criar assinatura")
+	}
+	return nil
+}
+
+func (r *GormMembershipRepository) Update(membership *models.Membership) error {
+	if err := r.db.Omit(clause.Associations).Save(membership).Error; err != nil {
+		log.Printf("Erro ao atualizar assinatura %d: %v", membership.ID, err)
+		return errors.New("erro ao atualizar assinatura")
+	}
+	return nil
+}
+
+func (r *GormMembershipRepository) FindByID(id uint) (*models.Membership, error) {
+	var membership models.Membership
+	err := r.db.Preload("Creator").First(&membership, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar assinatura %d: %v", id, err)
+		return nil, errors.New("erro ao buscar assinatura")
+	}
+	return &membership, nil
+}
+
+func (r *GormMembershipRepository) FindByCreator(creatorID uint) ([]*models.Membership, error) {
+	var memberships []*models.Membership
+	err := r.db.Where("creator_id = ?", creatorID).Order("created_at DESC").Find(&memberships).Error
+	if err != nil {
+		log.Printf("Erro ao listar assinaturas do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar assinaturas")
+	}
+	return memberships, nil
+}
+
+func (r *GormMembershipRepository) CountActiveMembers(creatorID uint) (map[uint]int64, error) {
+	var rows []struct {
+		MembershipID uint
+		Total        int64
+	}
+	err := r.db.Model(&models.Member{}).
+		Select("membership_id, COUNT(*) AS total").
+		Where("creator_id = ? AND status = ?", creatorID, models.MemberStatusActive).
+		Group("membership_id").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Erro ao contar assinantes do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao contar assinantes")
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.MembershipID] = row.Total
+	}
+	return counts, nil
+}
+
+func (r *GormMembershipRepository) CreateMember(member *models.Member) error {
+	if err := r.db.Omit(clause.Associations).Create(member).Error; err != nil {
+		log.Printf("Erro ao registrar assinante da assinatura %d: %v", member.MembershipID, err)
+		return errors.New("erro ao registrar assinante")
+	}
+	return nil
+}
+
+func (r *GormMembershipRepository) UpdateMember(member *models.Member) error {
+	if err := r.db.Omit(clause.Associations).Save(member).Error; err != nil {
+		log.Printf("Erro ao atualizar assinante %d: %v", member.ID, err)
+		return errors.New("erro ao atualizar assinante")
+	}
+	return nil
+}
+
+func (r *GormMembershipRepository) FindMemberByToken(token string) (*models.Member, error) {
+	return r.findMember(r.db.Where("token = ?", token))
+}
+
+func (r *GormMembershipRepository) FindMemberBySession(sessionID string) (*models.Member, error) {
+	return r.findMember(r.db.Where("gateway_session_id = ?", sessionID))
+}
+
+func (r *GormMembershipRepository) FindMember(id uint) (*models.Member, error) {
+	return r.findMember(r.db.Where("id = ?", id))
+}
+
+func (r *GormMembershipRepository) findMember(query *gorm.DB) (*models.Member, error) {
+	var member models.Member
+	err := query.Preload("Membership").Preload("Membership.Creator").Preload("Client").First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar assinante: %v", err)
+		return nil, errors.New("erro ao buscar assinante")
+	}
+	return &member, nil
+}
+
+func (r *GormMembershipRepository) FindActiveMembers() ([]*models.Member, error) {
+	var members []*models.Member
+	err := r.db.Preload("Membership").Preload("Client").
+		Where("status = ?", models.MemberStatusActive).
+		Find(&members).Error
+	if err != nil {
+		log.Printf("Erro ao listar assinantes ativos: %v", err)
+		return nil, errors.New("erro ao listar assinantes")
+	}
+	return members, nil
+}
+
+func (r *GormMembershipRepository) Grant(member *models.Member, expiresAt time.Time) ([]*models.Purchase, error) {
+	var created []*models.Purchase
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Purchase{}).
+			Where("member_id = ?", member.ID).
+			Update("expires_at", expiresAt).Error
+		if err != nil {
+			return err
+		}
+
+		var ebookIDs []uint
+		err = tx.Model(&models.Ebook{}).
+			Where("creator_id = ? AND status = ?", member.CreatorID, true).
+			Where("id NOT IN (?)", tx.Model(&models.Purchase{}).Select("ebook_id").Where("member_id = ?", member.ID)).
+			Pluck("id", &ebookIDs).Error
+		if err != nil || len(ebookIDs) == 0 {
+			return err
+		}
+
+		if err := linkClientCreator(tx, member.ClientID, member.CreatorID); err != nil {
+			return err
+		}
+		for _, ebookID := range ebookIDs {
+			purchase := models.NewPurchase(ebookID, member.ClientID)
+			purchase.MemberID = &member.ID
+			purchase.ExpiresAt = expiresAt
+			created = append(created, purchase)
+		}
+		if err := applyDownloadPolicies(tx, created...); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&created).Error
+	})
+	if err != nil {
+		log.Printf("Erro ao liberar o catálogo para o assinante %d: %v", member.ID, err)
+		return nil, errors.New("erro ao liberar o catálogo da assinatura")
+	}
+
+	for _, purchase := range created {
+		err := r.db.Preload("Client").Preload("Ebook").Preload("Ebook.Creator").First(purchase, purchase.ID).Error
+		if err != nil {
+			log.Printf("Erro ao carregar compra %d do assinante %d: %v", purchase.ID, member.ID, err)
+			return nil, errors.New("erro ao carregar compras da assinatura")
+		}
+	}
+	return created, nil
+}
+
+func (r *GormMembershipRepository) Revoke(member *models.Member, now time.Time) error {
+	err := r.db.Model(&models.Purchase{}).
+		Where("member_id = ? AND expires_at > ?", member.ID, now).
+		Update("expires_at", now).Error
+	if err != nil {
+		log.Printf("Erro ao revogar o acesso do assinante %d: %v", member.ID, err)
+		return errors.New("erro ao revogar o acesso da assinatura")
+	}
+	return nil
+}
+
+func (r *GormMembershipRepository) FindMemberPurchases(memberID uint) ([]*models.Purchase, error) {
+	var purchases []*models.Purchase
+	err := r.db.Preload("Ebook").
+		Where("member_id = ?", memberID).
+		Order("created_at DESC").
+		Find(&purchases).Error
+	if err != nil {
+		log.Printf("Erro ao listar compras do assinante %d: %v", memberID, err)
+		return nil, errors.New("erro ao listar compras da assinatura")
+	}
+	return purchases, nil
+}
@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembershipRepository_GrantAndRevoke(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Ebook{}, &models.Client{}, &models.ClientCreator{},
+		&models.Purchase{}, &models.Membership{}, &models.Member{})
+	repo := repository.NewGormMembershipRepository(db)
+
+	creator := &models.Creator{Name: "Ana"}
+	db.Create(creator)
+	first := &models.Ebook{Title: "Primeiro", Slug: "primeiro", CreatorID: creator.ID, Status: true, Price: money.New(3000, money.BRL)}
+	draft := &models.Ebook{Title: "Rascunho", Slug: "rascunho", CreatorID: creator.ID, Price: money.New(3000, money.BRL)}
+	other := &models.Ebook{Title: "De outro criador", Slug: "outro", CreatorID: creator.ID + 1, Status: true, Price: money.New(3000, money.BRL)}
+	db.Create(first)
+	db.Create(draft)
+	db.Model(draft).Update("status", false)
+	db.Create(other)
+	client := &models.Client{Name: "Leitor", Email: "leitor@email.com", CPF: "11111111111"}
+	db.Create(client)
+
+	membership, err := models.NewMembership(creator.ID, "Clube", "", money.New(2990, money.BRL), models.MembershipIntervalMonth)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Create(membership))
+	member := models.NewMember(membership, client.ID, "assinante")
+	assert.NoError(t, repo.CreateMember(member))
+	member.Activate("sub_1", time.Now().AddDate(0, 1, 0))
+	assert.NoError(t, repo.UpdateMember(member))
+
+	granted, err := repo.Grant(member, member.AccessUntil())
+	assert.NoError(t, err)
+	assert.Len(t, granted, 1)
+	assert.Equal(t, "Primeiro", granted[0].Ebook.Title)
+	assert.Equal(t, "Leitor", granted[0].Client.Name)
+
+	var linked int64
+	db.Model(&models.ClientCreator{}).Where("creator_id = ? AND client_id = ?", creator.ID, client.ID).Count(&linked)
+	assert.Equal(t, int64(1), linked)
+
+	// Um ebook publicado depois da adesão é liberado uma única vez
+	second := &models.Ebook{Title: "Segundo", Slug: "segundo", CreatorID: creator.ID, Status: true, Price: money.New(3000, money.BRL)}
+	db.Create(second)
+	granted, err = repo.Grant(member, member.AccessUntil())
+	assert.NoError(t, err)
+	assert.Len(t, granted, 1)
+	assert.Equal(t, second.ID, granted[0].EbookID)
+	granted, err = repo.Grant(member, member.AccessUntil())
+	assert.NoError(t, err)
+	assert.Empty(t, granted)
+
+	counts, err := repo.CountActiveMembers(creator.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), counts[membership.ID])
+
+	now := time.Now()
+	assert.NoError(t, repo.Revoke(member, now))
+	purchases, err := repo.FindMemberPurchases(member.ID)
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 2)
+	for _, purchase := range purchases {
+		assert.True(t, purchase.IsExpired())
+	}
+
+	// A renovação devolve o acesso às compras revogadas
+	_, err = repo.Grant(member, now.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	purchases, err = repo.FindMemberPurchases(member.ID)
+	assert.NoError(t, err)
+	for _, purchase := range purchases {
+		assert.False(t, purchase.IsExpired())
+	}
+
+	found, err := repo.FindMember(member.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Clube", found.Membership.Name)
+	assert.Equal(t, "leitor@email.com", found.Client.Email)
+}
@@ -0,0 +1,81 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAffiliateRepository struct {
+	mock.Mock
+}
+
+func (m *MockAffiliateRepository) Create(affiliate *models.Affiliate) error {
+	args := m.Called(affiliate)
+	return args.Error(0)
+}
+
+func (m *MockAffiliateRepository) Update(affiliate *models.Affiliate) error {
+	args := m.Called(affiliate)
+	return args.Error(0)
+}
+
+func (m *MockAffiliateRepository) FindByID(id uint) (*models.Affiliate, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Affiliate), args.Error(1)
+}
+
+func (m *MockAffiliateRepository) FindByCode(code string) (*models.Affiliate, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Affiliate), args.Error(1)
+}
+
+func (m *MockAffiliateRepository) FindByToken(token string) (*models.Affiliate, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Affiliate), args.Error(1)
+}
+
+func (m *MockAffiliateRepository) FindByCreator(creatorID uint) ([]*models.Affiliate, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Affiliate), args.Error(1)
+}
+
+func (m *MockAffiliateRepository) FindRules(creatorID uint) ([]models.CommissionRule, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CommissionRule), args.Error(1)
+}
+
+func (m *MockAffiliateRepository) SaveRules(creatorID uint, rules []*models.CommissionRule) error {
+	args := m.Called(creatorID, rules)
+	return args.Error(0)
+}
+
+func (m *MockAffiliateRepository) FindCommissionsByAffiliate(affiliateID uint) ([]*models.Commission, error) {
+	args := m.Called(affiliateID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Commission), args.Error(1)
+}
+
+func (m *MockAffiliateRepository) FindCommissionsByCreator(creatorID uint) ([]*models.Commission, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Commission), args.Error(1)
+}
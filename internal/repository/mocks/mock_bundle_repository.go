@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBundleRepository struct {
+	mock.Mock
+}
+
+func (m *MockBundleRepository) Create(bundle *models.Bundle) error {
+	args := m.Called(bundle)
+	return args.Error(0)
+}
+
+func (m *MockBundleRepository) Update(bundle *models.Bundle) error {
+	args := m.Called(bundle)
+	return args.Error(0)
+}
+
+func (m *MockBundleRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockBundleRepository) FindByID(id uint) (*models.Bundle, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Bundle), args.Error(1)
+}
+
+func (m *MockBundleRepository) FindBySlug(slug string) (*models.Bundle, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Bundle), args.Error(1)
+}
+
+func (m *MockBundleRepository) FindByCreator(creatorID uint) ([]*models.Bundle, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Bundle), args.Error(1)
+}
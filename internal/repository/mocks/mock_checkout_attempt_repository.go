@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCheckoutAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockCheckoutAttemptRepository) Create(attempt *models.CheckoutAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockCheckoutAttemptRepository) Update(attempt *models.CheckoutAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockCheckoutAttemptRepository) FindByToken(token string) (*models.CheckoutAttempt, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CheckoutAttempt), args.Error(1)
+}
+
+func (m *MockCheckoutAttemptRepository) FindByOrderID(orderID uint) (*models.CheckoutAttempt, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CheckoutAttempt), args.Error(1)
+}
+
+func (m *MockCheckoutAttemptRepository) FindPending(ebookID uint, email string) (*models.CheckoutAttempt, error) {
+	args := m.Called(ebookID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CheckoutAttempt), args.Error(1)
+}
+
+func (m *MockCheckoutAttemptRepository) FindOpenBefore(cutoff time.Time) ([]*models.CheckoutAttempt, error) {
+	args := m.Called(cutoff)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CheckoutAttempt), args.Error(1)
+}
+
+func (m *MockCheckoutAttemptRepository) FindAbandoned(maxEmails int) ([]*models.CheckoutAttempt, error) {
+	args := m.Called(maxEmails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CheckoutAttempt), args.Error(1)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCustomDomainRepository struct {
+	mock.Mock
+}
+
+func (m *MockCustomDomainRepository) FindByCreator(creatorID uint) (*models.CustomDomain, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainRepository) FindVerifiedByHost(host string) (*models.CustomDomain, error) {
+	args := m.Called(host)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CustomDomain), args.Error(1)
+}
+
+func (m *MockCustomDomainRepository) HostTaken(host string, creatorID uint) (bool, error) {
+	args := m.Called(host, creatorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCustomDomainRepository) Replace(domain *models.CustomDomain) error {
+	args := m.Called(domain)
+	return args.Error(0)
+}
+
+func (m *MockCustomDomainRepository) MarkVerified(domain *models.CustomDomain) error {
+	args := m.Called(domain)
+	return args.Error(0)
+}
+
+func (m *MockCustomDomainRepository) UpdateCheck(domain *models.CustomDomain) error {
+	args := m.Called(domain)
+	return args.Error(0)
+}
+
+func (m *MockCustomDomainRepository) Delete(domain *models.CustomDomain) error {
+	args := m.Called(domain)
+	return args.Error(0)
+}
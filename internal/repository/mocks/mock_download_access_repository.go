@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDownloadAccessRepository struct {
+	mock.Mock
+}
+
+func (m *MockDownloadAccessRepository) FindByClient(creatorID, clientID uint) ([]*models.Purchase, error) {
+	args := m.Called(creatorID, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
+
+func (m *MockDownloadAccessRepository) FindForCreator(purchaseID, creatorID uint) (*models.Purchase, error) {
+	args := m.Called(purchaseID, creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Purchase), args.Error(1)
+}
+
+func (m *MockDownloadAccessRepository) UpdateAllowance(purchase *models.Purchase) error {
+	args := m.Called(purchase)
+	return args.Error(0)
+}
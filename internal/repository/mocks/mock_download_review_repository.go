@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDownloadReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockDownloadReviewRepository) FindFlagged(creatorID uint) ([]*models.Purchase, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
+
+func (m *MockDownloadReviewRepository) FindForCreator(purchaseID, creatorID uint) (*models.Purchase, error) {
+	args := m.Called(purchaseID, creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Purchase), args.Error(1)
+}
+
+func (m *MockDownloadReviewRepository) UpdateReview(purchase *models.Purchase) error {
+	args := m.Called(purchase)
+	return args.Error(0)
+}
+
+func (m *MockDownloadReviewRepository) UpdateAutoSuspend(creatorID uint, enabled bool) error {
+	args := m.Called(creatorID, enabled)
+	return args.Error(0)
+}
+
+func (m *MockDownloadReviewRepository) UpdateWatermarkText(creatorID uint, text string) error {
+	args := m.Called(creatorID, text)
+	return args.Error(0)
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEbookFileRepository struct {
+	mock.Mock
+}
+
+func (m *MockEbookFileRepository) FindByEbook(ebookID uint) ([]*models.EbookFile, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.EbookFile), args.Error(1)
+}
+
+func (m *MockEbookFileRepository) UpdateSchedule(entries []*models.EbookFile) error {
+	args := m.Called(entries)
+	return args.Error(0)
+}
+
+func (m *MockEbookFileRepository) FindDripPurchases(since time.Time) ([]*models.Purchase, error) {
+	args := m.Called(since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
+
+func (m *MockEbookFileRepository) MarkDripNotified(purchase *models.Purchase, at time.Time) error {
+	args := m.Called(purchase, at)
+	return args.Error(0)
+}
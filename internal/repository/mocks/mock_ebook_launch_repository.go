@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEbookLaunchRepository struct {
+	mock.Mock
+}
+
+func (m *MockEbookLaunchRepository) FindDue(now time.Time) ([]*models.Ebook, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Ebook), args.Error(1)
+}
+
+func (m *MockEbookLaunchRepository) UpdateSchedule(ebook *models.Ebook) error {
+	args := m.Called(ebook)
+	return args.Error(0)
+}
+
+func (m *MockEbookLaunchRepository) FindAwaitingRelease(ebookID uint) ([]*models.Purchase, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
+
+func (m *MockEbookLaunchRepository) Release(ebook *models.Ebook, purchases []*models.Purchase) error {
+	args := m.Called(ebook, purchases)
+	return args.Error(0)
+}
@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEbookPriceRepository struct {
+	mock.Mock
+}
+
+func (m *MockEbookPriceRepository) Create(period *models.EbookPricePeriod) error {
+	args := m.Called(period)
+	return args.Error(0)
+}
+
+func (m *MockEbookPriceRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockEbookPriceRepository) FindByID(id uint) (*models.EbookPricePeriod, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EbookPricePeriod), args.Error(1)
+}
+
+func (m *MockEbookPriceRepository) FindByEbook(ebookID uint) ([]*models.EbookPricePeriod, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.EbookPricePeriod), args.Error(1)
+}
+
+func (m *MockEbookPriceRepository) SalesByPrice(ebookID uint) ([]models.PriceSales, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PriceSales), args.Error(1)
+}
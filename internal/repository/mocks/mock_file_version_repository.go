@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFileVersionRepository struct {
+	mock.Mock
+}
+
+func (m *MockFileVersionRepository) Publish(file *models.File, archived *models.FileVersion) error {
+	args := m.Called(file, archived)
+	return args.Error(0)
+}
+
+func (m *MockFileVersionRepository) FindByFile(fileID uint) ([]*models.FileVersion, error) {
+	args := m.Called(fileID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.FileVersion), args.Error(1)
+}
+
+func (m *MockFileVersionRepository) FindBuyers(ebookID uint) ([]*models.Purchase, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
+
+func (m *MockFileVersionRepository) MarkEbookNotified(ebook *models.Ebook, at time.Time) error {
+	args := m.Called(ebook, at)
+	return args.Error(0)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGiftRepository struct {
+	mock.Mock
+}
+
+func (m *MockGiftRepository) Create(gift *models.Gift) error {
+	args := m.Called(gift)
+	return args.Error(0)
+}
+
+func (m *MockGiftRepository) Update(gift *models.Gift) error {
+	args := m.Called(gift)
+	return args.Error(0)
+}
+
+func (m *MockGiftRepository) FindByToken(token string) (*models.Gift, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Gift), args.Error(1)
+}
+
+func (m *MockGiftRepository) FindByOrderID(orderID uint) (*models.Gift, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Gift), args.Error(1)
+}
+
+func (m *MockGiftRepository) FindDue(now time.Time) ([]*models.Gift, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Gift), args.Error(1)
+}
+
+func (m *MockGiftRepository) Claim(gift *models.Gift, purchases []*models.Purchase) error {
+	args := m.Called(gift, purchases)
+	return args.Error(0)
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLeadRepository struct {
+	mock.Mock
+}
+
+func (m *MockLeadRepository) Create(lead *models.Lead) error {
+	args := m.Called(lead)
+	return args.Error(0)
+}
+
+func (m *MockLeadRepository) Update(lead *models.Lead) error {
+	args := m.Called(lead)
+	return args.Error(0)
+}
+
+func (m *MockLeadRepository) FindByToken(token string) (*models.Lead, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Lead), args.Error(1)
+}
+
+func (m *MockLeadRepository) FindLatest(ebookID, clientID uint) (*models.Lead, error) {
+	args := m.Called(ebookID, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Lead), args.Error(1)
+}
+
+func (m *MockLeadRepository) Confirm(lead *models.Lead, purchase *models.Purchase) error {
+	args := m.Called(lead, purchase)
+	return args.Error(0)
+}
@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLicenseRepository struct {
+	mock.Mock
+}
+
+func (m *MockLicenseRepository) Create(license *models.License) error {
+	args := m.Called(license)
+	return args.Error(0)
+}
+
+func (m *MockLicenseRepository) Update(license *models.License) error {
+	args := m.Called(license)
+	return args.Error(0)
+}
+
+func (m *MockLicenseRepository) FindByToken(token string) (*models.License, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.License), args.Error(1)
+}
+
+func (m *MockLicenseRepository) FindByOrderID(orderID uint) (*models.License, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.License), args.Error(1)
+}
+
+func (m *MockLicenseRepository) FindByEbook(ebookID uint) ([]*models.License, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.License), args.Error(1)
+}
+
+func (m *MockLicenseRepository) FindUnsent() ([]*models.License, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.License), args.Error(1)
+}
+
+func (m *MockLicenseRepository) Assign(license *models.License, seats []*models.LicenseSeat) error {
+	args := m.Called(license, seats)
+	return args.Error(0)
+}
+
+func (m *MockLicenseRepository) Reassign(license *models.License, seat *models.LicenseSeat, purchase *models.Purchase) error {
+	args := m.Called(license, seat, purchase)
+	return args.Error(0)
+}
@@ -0,0 +1,109 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMembershipRepository struct {
+	mock.Mock
+}
+
+func (m *MockMembershipRepository) Create(membership *models.Membership) error {
+	args := m.Called(membership)
+	return args.Error(0)
+}
+
+func (m *MockMembershipRepository) Update(membership *models.Membership) error {
+	args := m.Called(membership)
+	return args.Error(0)
+}
+
+func (m *MockMembershipRepository) FindByID(id uint) (*models.Membership, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Membership), args.Error(1)
+}
+
+func (m *MockMembershipRepository) FindByCreator(creatorID uint) ([]*models.Membership, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Membership), args.Error(1)
+}
+
+func (m *MockMembershipRepository) CountActiveMembers(creatorID uint) (map[uint]int64, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int64), args.Error(1)
+}
+
+func (m *MockMembershipRepository) CreateMember(member *models.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockMembershipRepository) UpdateMember(member *models.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockMembershipRepository) FindMemberByToken(token string) (*models.Member, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMembershipRepository) FindMemberBySession(sessionID string) (*models.Member, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMembershipRepository) FindMember(id uint) (*models.Member, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMembershipRepository) FindActiveMembers() ([]*models.Member, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Member), args.Error(1)
+}
+
+func (m *MockMembershipRepository) Grant(member *models.Member, expiresAt time.Time) ([]*models.Purchase, error) {
+	args := m.Called(member, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
+
+func (m *MockMembershipRepository) Revoke(member *models.Member, now time.Time) error {
+	args := m.Called(member, now)
+	return args.Error(0)
+}
+
+func (m *MockMembershipRepository) FindMemberPurchases(memberID uint) ([]*models.Purchase, error) {
+	args := m.Called(memberID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Purchase), args.Error(1)
+}
@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOfferRepository struct {
+	mock.Mock
+}
+
+func (m *MockOfferRepository) Create(offer *models.Offer) error {
+	args := m.Called(offer)
+	return args.Error(0)
+}
+
+func (m *MockOfferRepository) Update(offer *models.Offer) error {
+	args := m.Called(offer)
+	return args.Error(0)
+}
+
+func (m *MockOfferRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockOfferRepository) FindByID(id uint) (*models.Offer, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) FindByEbook(ebookID uint) ([]*models.Offer, error) {
+	args := m.Called(ebookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) FindActive(ebookID uint, offerType string) (*models.Offer, error) {
+	args := m.Called(ebookID, offerType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) IncrementImpressions(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockOfferRepository) HasPaidOrder(offerID, clientID uint) (bool, error) {
+	args := m.Called(offerID, clientID)
+	return args.Bool(0), args.Error(1)
+}
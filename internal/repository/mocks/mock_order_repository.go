@@ -0,0 +1,82 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) Create(order *models.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) Update(order *models.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) FindByID(id uint) (*models.Order, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindByGatewaySessionID(sessionID string) (*models.Order, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) FindPaymentByGatewayPaymentID(gatewayPaymentID string) (*models.Payment, error) {
+	args := m.Called(gatewayPaymentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockOrderRepository) ConfirmPayment(order *models.Order, payment *models.Payment, purchases []*models.Purchase) error {
+	args := m.Called(order, payment, purchases)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdatePayment(payment *models.Payment, order *models.Order) error {
+	args := m.Called(payment, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) FindByUpsellKey(key string) (*models.Order, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) CreateForInvoice(order *models.Order) (*models.Order, error) {
+	args := m.Called(order)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) ClaimCharge(orderID uint, now time.Time) (bool, error) {
+	args := m.Called(orderID, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOrderRepository) ReleaseCharge(orderID uint) error {
+	args := m.Called(orderID)
+	return args.Error(0)
+}
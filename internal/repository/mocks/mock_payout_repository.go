@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPayoutRepository struct {
+	mock.Mock
+}
+
+func (m *MockPayoutRepository) FindAccountByCreator(creatorID uint) (*models.PayoutAccount, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PayoutAccount), args.Error(1)
+}
+
+func (m *MockPayoutRepository) SaveAccount(account *models.PayoutAccount) error {
+	args := m.Called(account)
+	return args.Error(0)
+}
+
+func (m *MockPayoutRepository) FindEnabledAccounts() ([]*models.PayoutAccount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PayoutAccount), args.Error(1)
+}
+
+func (m *MockPayoutRepository) FindEntriesByCreator(creatorID uint) ([]*models.BalanceEntry, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BalanceEntry), args.Error(1)
+}
+
+func (m *MockPayoutRepository) FindPayoutsByCreator(creatorID uint) ([]*models.Payout, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Payout), args.Error(1)
+}
+
+func (m *MockPayoutRepository) CreatePayout(creatorID uint, currency money.Currency, now time.Time) (*models.Payout, error) {
+	args := m.Called(creatorID, currency, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payout), args.Error(1)
+}
+
+func (m *MockPayoutRepository) UpdatePayout(payout *models.Payout) error {
+	args := m.Called(payout)
+	return args.Error(0)
+}
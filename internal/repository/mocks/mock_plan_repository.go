@@ -0,0 +1,60 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPlanRepository struct {
+	mock.Mock
+}
+
+func (m *MockPlanRepository) FindActive() ([]*models.Plan, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Plan), args.Error(1)
+}
+
+func (m *MockPlanRepository) FindBySlug(slug string) (*models.Plan, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Plan), args.Error(1)
+}
+
+func (m *MockPlanRepository) FindByPriceID(priceID string) (*models.Plan, error) {
+	args := m.Called(priceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Plan), args.Error(1)
+}
+
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) CountEbooks(creatorID uint) (int64, error) {
+	args := m.Called(creatorID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUsageRepository) CountClients(creatorID uint) (int64, error) {
+	args := m.Called(creatorID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUsageRepository) SumStorage(creatorID uint) (int64, error) {
+	args := m.Called(creatorID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUsageRepository) CountSendsSince(creatorID uint, since time.Time) (int64, error) {
+	args := m.Called(creatorID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStorefrontRepository struct {
+	mock.Mock
+}
+
+func (m *MockStorefrontRepository) FindCreatorBySlug(slug string) (*models.Creator, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Creator), args.Error(1)
+}
+
+func (m *MockStorefrontRepository) FindEbooks(creatorID uint, query models.StorefrontQuery) ([]*models.Ebook, error) {
+	args := m.Called(creatorID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Ebook), args.Error(1)
+}
+
+func (m *MockStorefrontRepository) FindBundles(creatorID uint, term string) ([]*models.Bundle, error) {
+	args := m.Called(creatorID, term)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Bundle), args.Error(1)
+}
+
+func (m *MockStorefrontRepository) FindCategories(creatorID uint) ([]string, error) {
+	args := m.Called(creatorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockStorefrontRepository) SlugTaken(slug string, creatorID uint) (bool, error) {
+	args := m.Called(slug, creatorID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStorefrontRepository) UpdateProfile(creator *models.Creator) error {
+	args := m.Called(creator)
+	return args.Error(0)
+}
@@ -47,3 +47,24 @@ func (m *MockSubscriptionRepository) Save(subscription *models.Subscription) err
 	args := m.Called(subscription)
 	return args.Error(0)
 }
+
+func (m *MockSubscriptionRepository) SaveWithEvent(subscription *models.Subscription, event *models.SubscriptionEvent) error {
+	args := m.Called(subscription, event)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) FindEvents(subscriptionID uint) ([]*models.SubscriptionEvent, error) {
+	args := m.Called(subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SubscriptionEvent), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) FindPastDue() ([]*models.Subscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Subscription), args.Error(1)
+}
@@ -0,0 +1,51 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookEventRepository) Create(event *models.WebhookEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockWebhookEventRepository) FindByEventID(eventID string) (*models.WebhookEvent, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookEvent), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) FindDueForRetry(now time.Time) ([]*models.WebhookEvent, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookEvent), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) FindByPeriod(start, end time.Time) ([]*models.WebhookEvent, error) {
+	args := m.Called(start, end)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookEvent), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) Update(event *models.WebhookEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockWebhookEventRepository) Claim(event *models.WebhookEvent, force bool) error {
+	args := m.Called(event, force)
+	return args.Error(0)
+}
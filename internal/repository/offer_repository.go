@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type OfferRepository interface {
+	Create(offer *models.Offer) error
+	Update(offer *models.Offer) error
+	Delete(id uint) error
+	FindByID(id uint) (*models.Offer, error)
+	FindByEbook(ebookID uint) ([]*models.Offer, error)
+	// FindActive retorna a oferta ativa mais recente do tipo informado para o ebook
+	FindActive(ebookID uint, offerType string) (*models.Offer, error)
+	IncrementImpressions(id uint) error
+	// HasPaidOrder indica se o cliente já tem um pedido pago com a oferta
+	HasPaidOrder(offerID, clientID uint) (bool, error)
+}
+
+type GormOfferRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOfferRepository(db *gorm.DB) *GormOfferRepository {
+	return &GormOfferRepository{db: db}
+}
+
+func (r *GormOfferRepository) Create(offer *models.Offer) error {
+	err := r.db.Omit("Ebook", "OfferEbook").Create(offer).Error
+	if err != nil {
+		log.Printf("Erro ao criar oferta: %v", err)
+		return errors.New("erro ao criar oferta")
+	}
+	return nil
+}
+
+func (r *GormOfferRepository) Update(offer *models.Offer) error {
+	err := r.db.Omit("Ebook", "OfferEbook").Save(offer).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar oferta %d: %v", offer.ID, err)
+		return errors.New("erro ao atualizar oferta")
+	}
+	return nil
+}
+
+func (r *GormOfferRepository) Delete(id uint) error {
+	err := r.db.Delete(&models.Offer{}, id).Error
+	if err != nil {
+		log.Printf("Erro ao excluir oferta %d: %v", id, err)
+		return errors.New("erro ao excluir oferta")
+	}
+	return nil
+}
+
+func (r *GormOfferRepository) FindByID(id uint) (*models.Offer, error) {
+	var offer models.Offer
+	err := r.db.Preload("Ebook").Preload("OfferEbook").First(&offer, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar oferta %d: %v", id, err)
+		return nil, errors.New("erro ao buscar oferta")
+	}
+	return &offer, nil
+}
+
+func (r *GormOfferRepository) FindByEbook(ebookID uint) ([]*models.Offer, error) {
+	var offers []*models.Offer
+	err := r.db.Preload("OfferEbook").Where("ebook_id = ?", ebookID).Order("created_at DESC").Find(&offers).Error
+	if err != nil {
+		log.Printf("Erro ao listar ofertas do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao listar ofertas")
+	}
+	return offers, nil
+}
+
+func (r *GormOfferRepository) FindActive(ebookID uint, offerType string) (*models.Offer, error) {
+	var offer models.Offer
+	err := r.db.Preload("OfferEbook").
+		Where("ebook_id = ? AND type = ? AND active = ?", ebookID, offerType, true).
+		Order("created_at DESC").
+		First(&offer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar oferta ativa do ebook %d: %v", ebookID, err)
+		return nil, errors.New("erro ao buscar oferta")
+	}
+	return &offer, nil
+}
+
+func (r *GormOfferRepository) IncrementImpressions(id uint) error {
+	err := r.db.Model(&models.Offer{}).Where("id = ?", id).
+		UpdateColumn("impressions", gorm.Expr("impressions + ?", 1)).Error
+	if err != nil {
+		log.Printf("Erro ao registrar exibição da oferta %d: %v", id, err)
+		return errors.New("erro ao registrar exibição da oferta")
+	}
+	return nil
+}
+
+func (r *GormOfferRepository) HasPaidOrder(offerID, clientID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("order_items.offer_id = ? AND orders.client_id = ? AND orders.status = ?", offerID, clientID, models.OrderStatusPaid).
+		Count(&count).Error
+	if err != nil {
+		log.Printf("Erro ao verificar pedidos da oferta %d: %v", offerID, err)
+		return false, errors.New("erro ao verificar oferta")
+	}
+	return count > 0, nil
+}
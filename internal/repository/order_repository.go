@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrOrderAlreadyPaid = errors.New("pedido já foi pago")
+
+type OrderRepository interface {
+	Create(order *models.Order) error
+	Update(order *models.Order) error
+	FindByID(id uint) (*models.Order, error)
+	FindByGatewaySessionID(sessionID string) (*models.Order, error)
+	// FindByUpsellKey busca o pedido do upsell de um pedido de origem; nil se não existir
+	FindByUpsellKey(key string) (*models.Order, error)
+	// CreateForInvoice grava o pedido da fatura da assinatura ou retorna o já gravado para ela
+	CreateForInvoice(order *models.Order) (*models.Order, error)
+	// ClaimCharge reserva a cobrança do pedido pendente; false se outra requisição já a reservou
+	ClaimCharge(orderID uint, now time.Time) (bool, error)
+	// ReleaseCharge libera a reserva depois de uma cobrança recusada
+	ReleaseCharge(orderID uint) error
+	FindPaymentByGatewayPaymentID(gatewayPaymentID string) (*models.Payment, error)
+	ConfirmPayment(order *models.Order, payment *models.Payment, purchases []*models.Purchase) error
+	UpdatePayment(payment *models.Payment, order *models.Order) error
+}
+
+type GormOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOrderRepository(db *gorm.DB) *GormOrderRepository {
+	return &GormOrderRepository{db: db}
+}
+
+func (r *GormOrderRepository) Create(order *models.Order) error {
+	err := r.db.Create(order).Error
+	if err != nil {
+		log.Printf("Erro ao criar pedido: %v", err)
+		return errors.New("erro ao criar pedido")
+	}
+	return nil
+}
+
+func (r *GormOrderRepository) Update(order *models.Order) error {
+	err := r.db.Omit("Creator", "Client", "Ebook", "Items", "Payments", "Purchases").Save(order).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar pedido %d: %v", order.ID, err)
+		return errors.New("erro ao atualizar pedido")
+	}
+	return nil
+}
+
+func (r *GormOrderRepository) FindByID(id uint) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Items").Preload("Payments").Preload("Purchases").First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar pedido %d: %v", id, err)
+		return nil, errors.New("erro ao buscar pedido")
+	}
+	return &order, nil
+}
+
+func (r *GormOrderRepository) FindByGatewaySessionID(sessionID string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Client").Preload("Items").Preload("Payments").Preload("Purchases").Where("gateway_session_id = ?", sessionID).First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar pedido pela sessão %s: %v", sessionID, err)
+		return nil, errors.New("erro ao buscar pedido")
+	}
+	return &order, nil
+}
+
+func (r *GormOrderRepository) FindByUpsellKey(key string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Preload("Items").Where("upsell_key = ?", key).First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar pedido do upsell %s: %v", key, err)
+		return nil, errors.New("erro ao buscar pedido")
+	}
+	return &order, nil
+}
+
+// CreateForInvoice grava o pedido sem ebook, com ebook_id nulo, pois os pedidos de
+// assinatura não têm ebook. Se a fatura já tiver pedido, inclusive gravado por uma
+// entrega concorrente do mesmo evento, retorna esse pedido.
+func (r *GormOrderRepository) CreateForInvoice(order *models.Order) (*models.Order, error) {
+	existing, err := r.findByGatewayInvoiceID(order.GatewayInvoiceID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	if err := r.db.Omit("EbookID", "Creator", "Client", "Ebook").Create(order).Error; err != nil {
+		existing, findErr := r.findByGatewayInvoiceID(order.GatewayInvoiceID)
+		if findErr == nil && existing != nil {
+			return existing, nil
+		}
+		log.Printf("Erro ao criar pedido da fatura %s: %v", order.GatewayInvoiceID, err)
+		return nil, errors.New("erro ao criar pedido")
+	}
+	return order, nil
+}
+
+func (r *GormOrderRepository) findByGatewayInvoiceID(invoiceID string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Where("gateway_invoice_id = ?", invoiceID).First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar pedido da fatura %s: %v", invoiceID, err)
+		return nil, errors.New("erro ao buscar pedido")
+	}
+	return &order, nil
+}
+
+func (r *GormOrderRepository) ClaimCharge(orderID uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Order{}).
+		Where("id = ? AND status = ? AND charge_started_at IS NULL", orderID, models.OrderStatusPending).
+		Update("charge_started_at", now)
+	if result.Error != nil {
+		log.Printf("Erro ao reservar cobrança do pedido %d: %v", orderID, result.Error)
+		return false, errors.New("erro ao reservar cobrança")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *GormOrderRepository) ReleaseCharge(orderID uint) error {
+	err := r.db.Model(&models.Order{}).
+		Where("id = ? AND status = ?", orderID, models.OrderStatusPending).
+		Update("charge_started_at", nil).Error
+	if err != nil {
+		log.Printf("Erro ao liberar cobrança do pedido %d: %v", orderID, err)
+		return errors.New("erro ao liberar cobrança")
+	}
+	return nil
+}
+
+func (r *GormOrderRepository) FindPaymentByGatewayPaymentID(gatewayPaymentID string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.Preload("Order").Where("gateway_payment_id = ?", gatewayPaymentID).First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar pagamento %s: %v", gatewayPaymentID, err)
+		return nil, errors.New("erro ao buscar pagamento")
+	}
+	return &payment, nil
+}
+
+// ConfirmPayment marca o pedido como pago, registra o pagamento e cria as compras
+// que liberam o acesso na mesma transação. Retorna ErrOrderAlreadyPaid quando o
+// pedido já havia sido confirmado, evitando compras e vendas duplicadas.
+func (r *GormOrderRepository) ConfirmPayment(order *models.Order, payment *models.Payment, purchases []*models.Purchase) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Order{}).
+			Where("id = ? AND status = ?", order.ID, models.OrderStatusPending).
+			Updates(map[string]any{"status": order.Status, "paid_at": order.PaidAt})
+		if result.Error != nil {
+			log.Printf("Erro ao confirmar pedido %d: %v", order.ID, result.Error)
+			return errors.New("erro ao confirmar pedido")
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderAlreadyPaid
+		}
+
+		payment.OrderID = order.ID
+		if err := tx.Omit("Order").Create(payment).Error; err != nil {
+			log.Printf("Erro ao registrar pagamento do pedido %d: %v", order.ID, err)
+			return errors.New("erro ao registrar pagamento")
+		}
+
+		for _, purchase := range purchases {
+			purchase.OrderID = &order.ID
+		}
+		if len(purchases) > 0 {
+			if err := applyDownloadPolicies(tx, purchases...); err != nil {
+				return err
+			}
+			if err := tx.Omit("Ebook", "Client").Create(purchases).Error; err != nil {
+				log.Printf("Erro ao criar compras do pedido %d: %v", order.ID, err)
+				return errors.New("erro ao criar compra")
+			}
+		}
+
+		// Vendas contabilizadas por ebook, por oferta aceita e, nos pedidos de kit, também pelo kit
+		if ebookIDs := order.EbookIDs(); len(ebookIDs) > 0 {
+			err := tx.Model(&models.Ebook{}).Where("id IN ?", ebookIDs).
+				UpdateColumn("sales", gorm.Expr("sales + ?", 1)).Error
+			if err != nil {
+				log.Printf("Erro ao contabilizar vendas dos ebooks do pedido %d: %v", order.ID, err)
+				return errors.New("erro ao confirmar pedido")
+			}
+		}
+		if offerIDs := order.OfferIDs(); len(offerIDs) > 0 {
+			err := tx.Model(&models.Offer{}).Where("id IN ?", offerIDs).
+				UpdateColumn("conversions", gorm.Expr("conversions + ?", 1)).Error
+			if err != nil {
+				log.Printf("Erro ao contabilizar conversão das ofertas do pedido %d: %v", order.ID, err)
+				return errors.New("erro ao confirmar pedido")
+			}
+		}
+		if order.BundleID != nil {
+			err := tx.Model(&models.Bundle{}).Where("id = ?", *order.BundleID).
+				UpdateColumn("sales", gorm.Expr("sales + ?", 1)).Error
+			if err != nil {
+				log.Printf("Erro ao contabilizar venda do kit do pedido %d: %v", order.ID, err)
+				return errors.New("erro ao confirmar pedido")
+			}
+		}
+
+		if err := completeCheckoutAttempt(tx, order); err != nil {
+			return err
+		}
+
+		return createCommissions(tx, order)
+	})
+}
+
+// completeCheckoutAttempt encerra a tentativa de checkout do pedido, interrompendo a
+// sequência de recuperação. Tentativas já abandonadas contam como recuperadas.
+func completeCheckoutAttempt(tx *gorm.DB, order *models.Order) error {
+	err := tx.Model(&models.CheckoutAttempt{}).
+		Where("order_id = ? AND status IN ?", order.ID, []string{models.CheckoutAttemptOpen, models.CheckoutAttemptAbandoned}).
+		Updates(map[string]any{
+			"status": gorm.Expr("CASE WHEN status = ? THEN ? ELSE ? END",
+				models.CheckoutAttemptAbandoned, models.CheckoutAttemptRecovered, models.CheckoutAttemptCompleted),
+			"completed_at": order.PaidAt,
+		}).Error
+	if err != nil {
+		log.Printf("Erro ao encerrar tentativa de checkout do pedido %d: %v", order.ID, err)
+		return errors.New("erro ao confirmar pedido")
+	}
+	return nil
+}
+
+// createCommissions lança no extrato do afiliado as comissões do pedido indicado por ele
+func createCommissions(tx *gorm.DB, order *models.Order) error {
+	if order.AffiliateID == nil {
+		return nil
+	}
+
+	var rules []models.CommissionRule
+	if err := tx.Where("ebook_id IN ?", order.EbookIDs()).Find(&rules).Error; err != nil {
+		log.Printf("Erro ao buscar regras de comissão do pedido %d: %v", order.ID, err)
+		return errors.New("erro ao confirmar pedido")
+	}
+
+	commissions := models.NewOrderCommissions(order, rules)
+	if len(commissions) == 0 {
+		return nil
+	}
+	if err := tx.Omit("Affiliate", "Ebook").Create(commissions).Error; err != nil {
+		log.Printf("Erro ao lançar comissões do pedido %d: %v", order.ID, err)
+		return errors.New("erro ao confirmar pedido")
+	}
+	return nil
+}
+
+// reverseCommissions estorna as comissões do pedido reembolsado. Pedidos já
+// estornados não recebem um novo estorno.
+func reverseCommissions(tx *gorm.DB, orderID uint) error {
+	var commissions []*models.Commission
+	if err := tx.Where("order_id = ?", orderID).Find(&commissions).Error; err != nil {
+		log.Printf("Erro ao buscar comissões do pedido %d: %v", orderID, err)
+		return errors.New("erro ao estornar comissões")
+	}
+
+	var reversals []*models.Commission
+	for _, commission := range commissions {
+		if commission.IsReversal() {
+			return nil
+		}
+		reversals = append(reversals, commission.Reversal())
+	}
+	if len(reversals) == 0 {
+		return nil
+	}
+
+	if err := tx.Omit("Affiliate", "Ebook").Create(reversals).Error; err != nil {
+		log.Printf("Erro ao estornar comissões do pedido %d: %v", orderID, err)
+		return errors.New("erro ao estornar comissões")
+	}
+	return nil
+}
+
+func (r *GormOrderRepository) UpdatePayment(payment *models.Payment, order *models.Order) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Order").Save(payment).Error; err != nil {
+			log.Printf("Erro ao atualizar pagamento %d: %v", payment.ID, err)
+			return errors.New("erro ao atualizar pagamento")
+		}
+		if order != nil {
+			if err := tx.Omit("Creator", "Client", "Ebook", "Items", "Payments", "Purchases").Save(order).Error; err != nil {
+				log.Printf("Erro ao atualizar pedido %d: %v", order.ID, err)
+				return errors.New("erro ao atualizar pedido")
+			}
+			if order.Status == models.OrderStatusRefunded {
+				return reverseCommissions(tx, order.ID)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderRepository_CommissionLedger(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Ebook{}, &models.Bundle{}, &models.Offer{}, &models.Order{}, &models.OrderItem{},
+		&models.Payment{}, &models.Purchase{}, &models.CommissionRule{}, &models.Commission{}, &models.CheckoutAttempt{})
+	repo := repository.NewGormOrderRepository(db)
+
+	ebook := &models.Ebook{Title: "Ebook", CreatorID: 7, Price: money.New(5000, money.BRL)}
+	db.Create(ebook)
+	db.Create(&models.CommissionRule{CreatorID: 7, EbookID: ebook.ID, Percent: 20})
+
+	affiliateID := uint(3)
+	order := models.NewOrder(7, 9, ebook.ID, 5000, 0, money.BRL)
+	order.Items = []models.OrderItem{models.NewOrderItem(ebook)}
+	order.AffiliateID = &affiliateID
+	assert.NoError(t, repo.Create(order))
+
+	assert.NoError(t, order.MarkPaid())
+	payment := models.NewPayment(order.ID, "stripe", "pi_1", 5000, money.BRL)
+	assert.NoError(t, repo.ConfirmPayment(order, payment, nil))
+
+	var commissions []models.Commission
+	db.Where("order_id = ?", order.ID).Find(&commissions)
+	assert.Len(t, commissions, 1)
+	assert.Equal(t, int64(1000), commissions[0].Amount)
+
+	// Reembolso total estorna a comissão uma única vez
+	assert.NoError(t, order.MarkRefunded())
+	payment.Refund(5000)
+	assert.NoError(t, repo.UpdatePayment(payment, order))
+	assert.NoError(t, repo.UpdatePayment(payment, order))
+
+	var balance int64
+	db.Model(&models.Commission{}).Where("affiliate_id = ?", affiliateID).Select("SUM(amount)").Scan(&balance)
+	db.Where("order_id = ?", order.ID).Find(&commissions)
+	assert.Len(t, commissions, 2)
+	assert.Equal(t, int64(0), balance)
+}
+
+func TestOrderRepository_ConfirmPayment_RecoversAbandonedCheckout(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Ebook{}, &models.Bundle{}, &models.Offer{}, &models.Order{}, &models.OrderItem{},
+		&models.Payment{}, &models.Purchase{}, &models.CommissionRule{}, &models.Commission{}, &models.CheckoutAttempt{})
+	repo := repository.NewGormOrderRepository(db)
+
+	ebook := &models.Ebook{Title: "Ebook", CreatorID: 7, Price: money.New(5000, money.BRL)}
+	db.Create(ebook)
+
+	order := models.NewOrder(7, 9, ebook.ID, 5000, 0, money.BRL)
+	order.Items = []models.OrderItem{models.NewOrderItem(ebook)}
+	assert.NoError(t, repo.Create(order))
+
+	attempt := models.NewCheckoutAttempt(ebook, "Comprador", "comprador@email.com", "11999999999", "token")
+	attempt.OrderID = &order.ID
+	attempt.Abandon(time.Now())
+	db.Omit("Ebook").Create(attempt)
+
+	assert.NoError(t, order.MarkPaid())
+	assert.NoError(t, repo.ConfirmPayment(order, models.NewPayment(order.ID, "stripe", "pi_1", 5000, money.BRL), nil))
+
+	db.First(attempt, attempt.ID)
+	assert.Equal(t, models.CheckoutAttemptRecovered, attempt.Status)
+	assert.NotNil(t, attempt.CompletedAt)
+}
+
+func TestOrderRepository_UpsellChargeClaim(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Order{}, &models.OrderItem{})
+	repo := repository.NewGormOrderRepository(db)
+
+	order := models.NewOrder(7, 9, 2, 1990, 0, money.BRL)
+	order.UpsellKey = models.UpsellKey(10, 5)
+	assert.NoError(t, repo.Create(order))
+
+	// O mesmo par (pedido de origem, oferta) não gera um segundo pedido
+	duplicate := models.NewOrder(7, 9, 2, 1990, 0, money.BRL)
+	duplicate.UpsellKey = models.UpsellKey(10, 5)
+	assert.Error(t, repo.Create(duplicate))
+
+	found, err := repo.FindByUpsellKey("upsell_10_5")
+	assert.NoError(t, err)
+	assert.Equal(t, order.ID, found.ID)
+
+	claimed, err := repo.ClaimCharge(order.ID, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.ClaimCharge(order.ID, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	assert.NoError(t, repo.ReleaseCharge(order.ID))
+	claimed, err = repo.ClaimCharge(order.ID, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"gorm.io/gorm"
+)
+
+type PayoutRepository interface {
+	FindAccountByCreator(creatorID uint) (*models.PayoutAccount, error)
+	SaveAccount(account *models.PayoutAccount) error
+	FindEnabledAccounts() ([]*models.PayoutAccount, error)
+	FindEntriesByCreator(creatorID uint) ([]*models.BalanceEntry, error)
+	FindPayoutsByCreator(creatorID uint) ([]*models.Payout, error)
+	// CreatePayout soma os lançamentos da moeda liberados até now que ainda não
+	// entraram em repasse, registra o repasse com esse valor e vincula exatamente
+	// esses lançamentos a ele. Se outro repasse levar algum deles antes, nada é criado.
+	CreatePayout(creatorID uint, currency money.Currency, now time.Time) (*models.Payout, error)
+	// UpdatePayout grava o resultado da transferência; repasses que falharam devolvem
+	// os lançamentos ao saldo disponível
+	UpdatePayout(payout *models.Payout) error
+}
+
+type GormPayoutRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPayoutRepository(db *gorm.DB) *GormPayoutRepository {
+	return &GormPayoutRepository{db: db}
+}
+
+func (r *GormPayoutRepository) FindAccountByCreator(creatorID uint) (*models.PayoutAccount, error) {
+	var account models.PayoutAccount
+	err := r.db.Where("creator_id = ?", creatorID).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar conta recebedora do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao buscar conta recebedora")
+	}
+	return &account, nil
+}
+
+func (r *GormPayoutRepository) SaveAccount(account *models.PayoutAccount) error {
+	if err := r.db.Save(account).Error; err != nil {
+		log.Printf("Erro ao salvar conta recebedora do criador %d: %v", account.CreatorID, err)
+		return errors.New("erro ao salvar conta recebedora")
+	}
+	return nil
+}
+
+func (r *GormPayoutRepository) FindEnabledAccounts() ([]*models.PayoutAccount, error) {
+	var accounts []*models.PayoutAccount
+	err := r.db.Where("payouts_enabled = ?", true).Find(&accounts).Error
+	if err != nil {
+		log.Printf("Erro ao listar contas recebedoras: %v", err)
+		return nil, errors.New("erro ao listar contas recebedoras")
+	}
+	return accounts, nil
+}
+
+func (r *GormPayoutRepository) FindEntriesByCreator(creatorID uint) ([]*models.BalanceEntry, error) {
+	var entries []*models.BalanceEntry
+	err := r.db.Where("creator_id = ?", creatorID).Order("created_at DESC, id DESC").Find(&entries).Error
+	if err != nil {
+		log.Printf("Erro ao listar lançamentos do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar lançamentos")
+	}
+	return entries, nil
+}
+
+func (r *GormPayoutRepository) FindPayoutsByCreator(creatorID uint) ([]*models.Payout, error) {
+	var payouts []*models.Payout
+	err := r.db.Where("creator_id = ?", creatorID).Order("created_at DESC").Find(&payouts).Error
+	if err != nil {
+		log.Printf("Erro ao listar repasses do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar repasses")
+	}
+	return payouts, nil
+}
+
+func (r *GormPayoutRepository) CreatePayout(creatorID uint, currency money.Currency, now time.Time) (*models.Payout, error) {
+	var payout *models.Payout
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Os lançamentos são escolhidos antes: a soma e o vínculo usam os mesmos IDs,
+		// e um reembolso gravado no meio do caminho fica para o próximo repasse
+		var entries []*models.BalanceEntry
+		err := tx.Select("id", "amount").
+			Where("creator_id = ? AND currency = ? AND payout_id IS NULL AND available_at <= ?", creatorID, currency, now).
+			Find(&entries).Error
+		if err != nil {
+			log.Printf("Erro ao buscar lançamentos disponíveis do criador %d: %v", creatorID, err)
+			return errors.New("erro ao criar repasse")
+		}
+
+		var amount int64
+		ids := make([]uint, 0, len(entries))
+		for _, entry := range entries {
+			amount += entry.Amount
+			ids = append(ids, entry.ID)
+		}
+		if amount <= 0 {
+			return models.ErrNothingToPayout
+		}
+
+		payout = models.NewPayout(creatorID, amount, currency)
+		if err := tx.Create(payout).Error; err != nil {
+			log.Printf("Erro ao criar repasse do criador %d: %v", creatorID, err)
+			return errors.New("erro ao criar repasse")
+		}
+
+		result := tx.Model(&models.BalanceEntry{}).
+			Where("id IN ? AND payout_id IS NULL", ids).
+			Update("payout_id", payout.ID)
+		if result.Error != nil {
+			log.Printf("Erro ao vincular lançamentos ao repasse %d: %v", payout.ID, result.Error)
+			return errors.New("erro ao criar repasse")
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			log.Printf("Lançamentos do criador %d já vinculados a outro repasse", creatorID)
+			return models.ErrNothingToPayout
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+func (r *GormPayoutRepository) UpdatePayout(payout *models.Payout) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(payout).Error; err != nil {
+			log.Printf("Erro ao atualizar repasse %d: %v", payout.ID, err)
+			return errors.New("erro ao atualizar repasse")
+		}
+		if payout.Status != models.PayoutStatusFailed {
+			return nil
+		}
+		err := tx.Model(&models.BalanceEntry{}).Where("payout_id = ?", payout.ID).Update("payout_id", nil).Error
+		if err != nil {
+			log.Printf("Erro ao liberar lançamentos do repasse %d: %v", payout.ID, err)
+			return errors.New("erro ao atualizar repasse")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayoutRepository_BalanceLedger(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Ebook{}, &models.Bundle{}, &models.Offer{}, &models.Order{}, &models.OrderItem{},
+		&models.Payment{}, &models.Purchase{}, &models.CommissionRule{}, &models.Commission{}, &models.CheckoutAttempt{},
+		&models.BalanceEntry{}, &models.Payout{})
+	orders := repository.NewGormOrderRepository(db)
+	payouts := repository.NewGormPayoutRepository(db)
+
+	ebook := &models.Ebook{Title: "Ebook", CreatorID: 7, Price: money.New(5000, money.BRL)}
+	db.Create(ebook)
+
+	confirm := func(paymentID string, availableAt time.Time) *models.Payment {
+		order := models.NewOrder(7, 9, ebook.ID, 5000, 0, money.BRL)
+		order.Items = []models.OrderItem{models.NewOrderItem(ebook)}
+		assert.NoError(t, orders.Create(order))
+		assert.NoError(t, order.MarkPaid())
+
+		payment := models.NewPayment(order.ID, "stripe", paymentID, 5000, money.BRL)
+		payment.Order = order
+		payment.RecordSale(10, availableAt)
+		assert.NoError(t, orders.ConfirmPayment(order, payment, nil))
+		return payment
+	}
+
+	now := time.Now()
+	released := confirm("pi_1", now.Add(-time.Hour))
+	confirm("pi_2", now.Add(24*time.Hour))
+
+	// Reembolso parcial devolve a taxa proporcional ao criador
+	released.BalanceEntries = nil
+	released.Refund(1000)
+	released.RecordRefund(0)
+	assert.NoError(t, orders.UpdatePayment(released, nil))
+
+	now = time.Now()
+	entries, err := payouts.FindEntriesByCreator(7)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 6)
+
+	balances := models.SummarizeBalance(entries, now)
+	assert.Len(t, balances, 1)
+	assert.Equal(t, int64(5000-500-1000+100), balances[0].Available)
+	assert.Equal(t, int64(4500), balances[0].Pending)
+
+	payout, err := payouts.CreatePayout(7, money.BRL, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), payout.Amount)
+
+	_, err = payouts.CreatePayout(7, money.BRL, now)
+	assert.ErrorIs(t, err, models.ErrNothingToPayout)
+
+	// Transferência recusada devolve os lançamentos ao saldo disponível
+	payout.MarkFailed("recusada")
+	assert.NoError(t, payouts.UpdatePayout(payout))
+
+	retry, err := payouts.CreatePayout(7, money.BRL, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), retry.Amount)
+}
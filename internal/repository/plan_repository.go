@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type PlanRepository interface {
+	FindActive() ([]*models.Plan, error)
+	FindBySlug(slug string) (*models.Plan, error)
+	FindByPriceID(priceID string) (*models.Plan, error)
+}
+
+type GormPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPlanRepository(db *gorm.DB) *GormPlanRepository {
+	return &GormPlanRepository{db: db}
+}
+
+func (r *GormPlanRepository) FindActive() ([]*models.Plan, error) {
+	var plans []*models.Plan
+	err := r.db.Where("active = ?", true).Order("position ASC").Find(&plans).Error
+	if err != nil {
+		log.Printf("Erro ao listar planos: %v", err)
+		return nil, errors.New("erro ao listar planos")
+	}
+	return plans, nil
+}
+
+func (r *GormPlanRepository) FindBySlug(slug string) (*models.Plan, error) {
+	var plan models.Plan
+	err := r.db.Where("slug = ?", slug).First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar plano %s: %v", slug, err)
+		return nil, errors.New("erro ao buscar plano")
+	}
+	return &plan, nil
+}
+
+func (r *GormPlanRepository) FindByPriceID(priceID string) (*models.Plan, error) {
+	var plan models.Plan
+	err := r.db.Where("monthly_price_id = ? OR yearly_price_id = ?", priceID, priceID).First(&plan).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar plano pelo preço %s: %v", priceID, err)
+		return nil, errors.New("erro ao buscar plano")
+	}
+	return &plan, nil
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/pkg/database"
+	"gorm.io/gorm"
 )
 
 type PurchaseRepository struct {
@@ -16,6 +18,11 @@ func NewPurchaseRepository() *PurchaseRepository {
 }
 
 func (pr *PurchaseRepository) CreateManyPurchases(purchases []*models.Purchase) error {
+	if err := applyDownloadPolicies(database.DB, purchases...); err != nil {
+		log.Printf("[PURCHASE-REPOSITORY] POLICY ERROR: %s", err)
+		return errors.New("falha no processamento do envio")
+	}
+
 	err := database.DB.CreateInBatches(purchases, 1000).Error
 	if err != nil {
 		log.Printf("[PURCHASE-REPOSITORY] ERROR: %s", err)
@@ -73,6 +80,8 @@ func (pr *PurchaseRepository) FindByID(id uint) (*models.Purchase, error) {
 	err := database.DB.Preload("Client").
 		Preload("Ebook.Creator").
 		Preload("Ebook.Files").
+		Preload("Ebook.FileSchedule").
+		Preload("Downloads").
 		First(&purchase, id).Error
 	if err != nil {
 		log.Printf("Erro na busca da compra: %s", err)
@@ -99,3 +108,37 @@ func (pr *PurchaseRepository) Update(purchase *models.Purchase) error {
 
 	return nil
 }
+
+// applyDownloadPolicies aplica a política de entrega de cada ebook às compras
+// antes de gravá-las e marca as compras de ebooks em pré-venda. Todo caminho
+// que cria compras passa por aqui.
+func applyDownloadPolicies(tx *gorm.DB, purchases ...*models.Purchase) error {
+	ebookIDs := make([]uint, 0, len(purchases))
+	for _, purchase := range purchases {
+		ebookIDs = append(ebookIDs, purchase.EbookID)
+	}
+	if len(ebookIDs) == 0 {
+		return nil
+	}
+
+	var ebooks []*models.Ebook
+	err := tx.Select("id", "download_max_downloads", "download_expiry_days", "download_max_devices", "pre_sale").
+		Where("id IN ?", ebookIDs).
+		Find(&ebooks).Error
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uint]*models.Ebook, len(ebooks))
+	for _, ebook := range ebooks {
+		byID[ebook.ID] = ebook
+	}
+	now := time.Now()
+	for _, purchase := range purchases {
+		if ebook, ok := byID[purchase.EbookID]; ok {
+			ebook.DownloadPolicy.Apply(purchase, now)
+			purchase.AwaitingRelease = ebook.PreSale
+		}
+	}
+	return nil
+}
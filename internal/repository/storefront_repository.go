@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// StorefrontRepository consulta a vitrine pública e grava o perfil do criador
+type StorefrontRepository interface {
+	// FindCreatorBySlug busca o criador da vitrine; nil se não existir
+	FindCreatorBySlug(slug string) (*models.Creator, error)
+	// FindEbooks lista os ebooks à venda do criador que atendem ao filtro
+	FindEbooks(creatorID uint, query models.StorefrontQuery) ([]*models.Ebook, error)
+	// FindBundles lista os kits à venda do criador cujo título contém o termo
+	FindBundles(creatorID uint, term string) ([]*models.Bundle, error)
+	// FindCategories lista as categorias dos ebooks à venda do criador
+	FindCategories(creatorID uint) ([]string, error)
+	// SlugTaken indica se outro criador já usa o endereço
+	SlugTaken(slug string, creatorID uint) (bool, error)
+	UpdateProfile(creator *models.Creator) error
+}
+
+type GormStorefrontRepository struct {
+	db *gorm.DB
+}
+
+func NewGormStorefrontRepository(db *gorm.DB) *GormStorefrontRepository {
+	return &GormStorefrontRepository{db: db}
+}
+
+func (r *GormStorefrontRepository) FindCreatorBySlug(slug string) (*models.Creator, error) {
+	var creator models.Creator
+	err := r.db.Where("slug = ?", slug).First(&creator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Erro ao buscar vitrine %s: %v", slug, err)
+		return nil, errors.New("erro ao buscar vitrine")
+	}
+	return &creator, nil
+}
+
+func (r *GormStorefrontRepository) FindEbooks(creatorID uint, query models.StorefrontQuery) ([]*models.Ebook, error) {
+	db := r.db.Preload("PricePeriods").
+		Where("creator_id = ? AND status = ?", creatorID, true)
+	if term := strings.TrimSpace(query.Term); term != "" {
+		like := "%" + strings.ToLower(term) + "%"
+		db = db.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", like, like)
+	}
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+
+	var ebooks []*models.Ebook
+	if err := db.Order("sales DESC, created_at DESC").Find(&ebooks).Error; err != nil {
+		log.Printf("Erro ao listar ebooks da vitrine do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar ebooks da vitrine")
+	}
+	return ebooks, nil
+}
+
+func (r *GormStorefrontRepository) FindBundles(creatorID uint, term string) ([]*models.Bundle, error) {
+	db := r.db.Preload("Ebooks").
+		Where("creator_id = ? AND status = ?", creatorID, true)
+	if term = strings.TrimSpace(term); term != "" {
+		like := "%" + strings.ToLower(term) + "%"
+		db = db.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", like, like)
+	}
+
+	var bundles []*models.Bundle
+	if err := db.Order("created_at DESC").Find(&bundles).Error; err != nil {
+		log.Printf("Erro ao listar kits da vitrine do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar kits da vitrine")
+	}
+	return bundles, nil
+}
+
+func (r *GormStorefrontRepository) FindCategories(creatorID uint) ([]string, error) {
+	var categories []string
+	err := r.db.Model(&models.Ebook{}).
+		Where("creator_id = ? AND status = ? AND category <> ''", creatorID, true).
+		Distinct().
+		Order("category").
+		Pluck("category", &categories).Error
+	if err != nil {
+		log.Printf("Erro ao listar categorias da vitrine do criador %d: %v", creatorID, err)
+		return nil, errors.New("erro ao listar categorias")
+	}
+	return categories, nil
+}
+
+func (r *GormStorefrontRepository) SlugTaken(slug string, creatorID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Creator{}).Where("slug = ? AND id <> ?", slug, creatorID).Count(&count).Error
+	if err != nil {
+		log.Printf("Erro ao verificar endereço de vitrine %s: %v", slug, err)
+		return false, errors.New("erro ao verificar endereço da vitrine")
+	}
+	return count > 0, nil
+}
+
+func (r *GormStorefrontRepository) UpdateProfile(creator *models.Creator) error {
+	err := r.db.Model(&models.Creator{}).
+		Where("id = ?", creator.ID).
+		Select("slug", "bio", "avatar", "website_url", "instagram_url", "youtube_url", "tiktok_url").
+		Updates(creator).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar perfil do criador %d: %v", creator.ID, err)
+		return errors.New("erro ao atualizar perfil")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorefrontRepository_Catalog(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{}, &models.Ebook{}, &models.EbookPricePeriod{}, &models.Bundle{})
+	repo := repository.NewGormStorefrontRepository(db)
+
+	creator := &models.Creator{Name: "Ana", Email: "ana@email.com", Slug: "ana"}
+	other := &models.Creator{Name: "Bia", Email: "bia@email.com", Slug: "bia"}
+	db.Create(creator)
+	db.Create(other)
+
+	price := money.New(3000, money.BRL)
+	db.Create(&models.Ebook{Title: "Finanças pessoais", Slug: "vitrine-financas", Category: "Finanças", Status: true, CreatorID: creator.ID, Price: price})
+	db.Create(&models.Ebook{Title: "Bolos caseiros", Slug: "vitrine-bolos", Category: "Receitas", Status: true, CreatorID: creator.ID, Price: price})
+	db.Create(&models.Ebook{Title: "Sem categoria", Slug: "vitrine-sem-categoria", Status: true, CreatorID: creator.ID, Price: price})
+	inactive := &models.Ebook{Title: "Rascunho de finanças", Slug: "vitrine-rascunho", Category: "Rascunhos", CreatorID: creator.ID, Price: price}
+	db.Create(inactive)
+	db.Model(inactive).Update("status", false)
+	db.Create(&models.Ebook{Title: "Finanças da Bia", Slug: "vitrine-bia", Category: "Finanças", Status: true, CreatorID: other.ID, Price: price})
+	db.Create(&models.Bundle{Title: "Kit finanças", Slug: "vitrine-kit", Status: true, CreatorID: creator.ID, Price: price})
+
+	found, err := repo.FindCreatorBySlug("ana")
+	assert.NoError(t, err)
+	assert.Equal(t, creator.ID, found.ID)
+
+	missing, err := repo.FindCreatorBySlug("ninguem")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	ebooks, err := repo.FindEbooks(creator.ID, models.StorefrontQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, ebooks, 3)
+
+	ebooks, err = repo.FindEbooks(creator.ID, models.StorefrontQuery{Term: "FINANÇAS"})
+	assert.NoError(t, err)
+	if assert.Len(t, ebooks, 1) {
+		assert.Equal(t, "Finanças pessoais", ebooks[0].Title)
+	}
+
+	ebooks, err = repo.FindEbooks(creator.ID, models.StorefrontQuery{Category: "Receitas"})
+	assert.NoError(t, err)
+	if assert.Len(t, ebooks, 1) {
+		assert.Equal(t, "Bolos caseiros", ebooks[0].Title)
+	}
+
+	bundles, err := repo.FindBundles(creator.ID, "kit")
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 1)
+
+	categories, err := repo.FindCategories(creator.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Finanças", "Receitas"}, categories)
+}
+
+func TestStorefrontRepository_Profile(t *testing.T) {
+	db := testDB(t)
+	db.AutoMigrate(&models.Creator{})
+	repo := repository.NewGormStorefrontRepository(db)
+
+	creator := &models.Creator{Name: "Ana", Email: "ana@email.com", Slug: "ana"}
+	other := &models.Creator{Name: "Bia", Email: "bia@email.com"}
+	db.Create(creator)
+	db.Create(other)
+
+	taken, err := repo.SlugTaken("ana", other.ID)
+	assert.NoError(t, err)
+	assert.True(t, taken)
+
+	taken, err = repo.SlugTaken("ana", creator.ID)
+	assert.NoError(t, err)
+	assert.False(t, taken)
+
+	creator.Bio = "Escrevo sobre finanças"
+	creator.YouTubeURL = "https://youtube.com/@ana"
+	assert.NoError(t, repo.UpdateProfile(creator))
+
+	var saved models.Creator
+	db.First(&saved, creator.ID)
+	assert.Equal(t, "Escrevo sobre finanças", saved.Bio)
+	assert.Equal(t, "https://youtube.com/@ana", saved.YouTubeURL)
+}
@@ -11,4 +11,9 @@ type SubscriptionRepository interface {
 	FindByStripeSubscriptionID(subscriptionID string) (*models.Subscription, error)
 	Update(subscription *models.Subscription) error
 	Save(subscription *models.Subscription) error
+	// SaveWithEvent salva a assinatura e registra o evento no histórico na mesma transação
+	SaveWithEvent(subscription *models.Subscription, event *models.SubscriptionEvent) error
+	FindEvents(subscriptionID uint) ([]*models.SubscriptionEvent, error)
+	// FindPastDue retorna as assinaturas com pagamento pendente, com o usuário carregado
+	FindPastDue() ([]*models.Subscription, error)
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+// UsageRepository consulta o consumo dos recursos limitados pelo plano do criador
+type UsageRepository interface {
+	CountEbooks(creatorID uint) (int64, error)
+	CountClients(creatorID uint) (int64, error)
+	SumStorage(creatorID uint) (int64, error)
+	CountSendsSince(creatorID uint, since time.Time) (int64, error)
+}
+
+type GormUsageRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUsageRepository(db *gorm.DB) *GormUsageRepository {
+	return &GormUsageRepository{db: db}
+}
+
+func (r *GormUsageRepository) CountEbooks(creatorID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Ebook{}).Where("creator_id = ?", creatorID).Count(&count).Error
+	if err != nil {
+		log.Printf("Erro ao contar ebooks do criador %d: %v", creatorID, err)
+		return 0, errors.New("erro ao consultar uso do plano")
+	}
+	return count, nil
+}
+
+func (r *GormUsageRepository) CountClients(creatorID uint) (int64, error) {
+	var count int64
+	err := r.db.Table("client_creators").Where("creator_id = ?", creatorID).Count(&count).Error
+	if err != nil {
+		log.Printf("Erro ao contar clientes do criador %d: %v", creatorID, err)
+		return 0, errors.New("erro ao consultar uso do plano")
+	}
+	return count, nil
+}
+
+func (r *GormUsageRepository) SumStorage(creatorID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.File{}).
+		Select("COALESCE(SUM(file_size), 0)").
+		Where("creator_id = ?", creatorID).
+		Scan(&total).Error
+	if err != nil {
+		log.Printf("Erro ao somar armazenamento do criador %d: %v", creatorID, err)
+		return 0, errors.New("erro ao consultar uso do plano")
+	}
+	return total, nil
+}
+
+// CountSendsSince conta os envios manuais (compras sem pedido) feitos pelo criador desde a data informada
+func (r *GormUsageRepository) CountSendsSince(creatorID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Purchase{}).
+		Joins("JOIN ebooks ON ebooks.id = purchases.ebook_id").
+		Where("ebooks.creator_id = ? AND purchases.order_id IS NULL AND purchases.created_at >= ?", creatorID, since).
+		Count(&count).Error
+	if err != nil {
+		log.Printf("Erro ao contar envios do criador %d: %v", creatorID, err)
+		return 0, errors.New("erro ao consultar uso do plano")
+	}
+	return count, nil
+}
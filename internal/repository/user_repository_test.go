@@ -58,7 +58,7 @@ func (suite *UserRepositoryTestSuite) TestFindByUserEmail_LoadsSubscription() {
 	assert.Equal(suite.T(), user.ID, foundUser.Subscription.UserID)
 	assert.Equal(suite.T(), "test_plan", foundUser.Subscription.PlanID)
 	assert.True(suite.T(), foundUser.Subscription.IsTrialActive)
-	assert.Equal(suite.T(), "inactive", foundUser.Subscription.SubscriptionStatus)
+	assert.Equal(suite.T(), models.SubscriptionTrialing, foundUser.Subscription.SubscriptionStatus)
 }
 
 func (suite *UserRepositoryTestSuite) TestFindByUserEmail_UserWithoutSubscription() {
@@ -183,8 +183,7 @@ func (suite *UserRepositoryTestSuite) TestUserTrialMethods_ActiveSubscription()
 	suite.Require().NotNil(foundUser)
 	suite.Require().NotNil(foundUser.Subscription)
 
-	// Testar métodos do trial
-	assert.True(suite.T(), foundUser.IsInTrialPeriod()) // Ainda no trial
-	assert.True(suite.T(), foundUser.IsSubscribed())    // Mas também inscrito
-	assert.Greater(suite.T(), foundUser.DaysLeftInTrial(), 0)
+	// Testar métodos do trial: a assinatura ativa encerra o estado de teste
+	assert.False(suite.T(), foundUser.IsInTrialPeriod())
+	assert.True(suite.T(), foundUser.IsSubscribed())
 }
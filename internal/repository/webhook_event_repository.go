@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"gorm.io/gorm"
+)
+
+type WebhookEventRepository interface {
+	Create(event *models.WebhookEvent) error
+	FindByEventID(eventID string) (*models.WebhookEvent, error)
+	FindDueForRetry(now time.Time) ([]*models.WebhookEvent, error)
+	FindByPeriod(start, end time.Time) ([]*models.WebhookEvent, error)
+	Claim(event *models.WebhookEvent, force bool) error
+	Update(event *models.WebhookEvent) error
+}
+
+type GormWebhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebhookEventRepository(db *gorm.DB) *GormWebhookEventRepository {
+	return &GormWebhookEventRepository{db: db}
+}
+
+func (r *GormWebhookEventRepository) Create(event *models.WebhookEvent) error {
+	err := r.db.Create(event).Error
+	if err != nil {
+		log.Printf("Erro ao registrar evento de webhook %s: %v", event.EventID, err)
+		return errors.New("erro ao registrar evento de webhook")
+	}
+	return nil
+}
+
+func (r *GormWebhookEventRepository) FindByEventID(eventID string) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	err := r.db.Where("event_id = ?", eventID).First(&event).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Erro ao buscar evento de webhook %s: %v", eventID, err)
+		return nil, errors.New("erro ao buscar evento de webhook")
+	}
+	return &event, nil
+}
+
+func (r *GormWebhookEventRepository) FindDueForRetry(now time.Time) ([]*models.WebhookEvent, error) {
+	var events []*models.WebhookEvent
+	err := r.db.
+		Where("status = ? AND attempts < ? AND next_attempt_at <= ?", models.WebhookEventFailed, models.MaxWebhookAttempts, now).
+		Order("next_attempt_at ASC").
+		Find(&events).Error
+	if err != nil {
+		log.Printf("Erro ao buscar eventos de webhook para reprocessar: %v", err)
+		return nil, errors.New("erro ao buscar eventos de webhook")
+	}
+	return events, nil
+}
+
+func (r *GormWebhookEventRepository) FindByPeriod(start, end time.Time) ([]*models.WebhookEvent, error) {
+	var events []*models.WebhookEvent
+	err := r.db.
+		Where("created_at BETWEEN ? AND ?", start, end).
+		Order("created_at ASC").
+		Find(&events).Error
+	if err != nil {
+		log.Printf("Erro ao buscar eventos de webhook por período: %v", err)
+		return nil, errors.New("erro ao buscar eventos de webhook")
+	}
+	return events, nil
+}
+
+// Claim marca o evento como em processamento apenas se ele ainda estiver pendente
+// ou com falha, garantindo que entregas simultâneas não o processem duas vezes.
+// Com force, eventos já processados ou presos em processamento também são assumidos.
+func (r *GormWebhookEventRepository) Claim(event *models.WebhookEvent, force bool) error {
+	statuses := []string{models.WebhookEventPending, models.WebhookEventFailed}
+	if force {
+		statuses = append(statuses, models.WebhookEventProcessed, models.WebhookEventProcessing)
+	}
+
+	result := r.db.Model(&models.WebhookEvent{}).
+		Where("id = ? AND status IN ?", event.ID, statuses).
+		Update("status", models.WebhookEventProcessing)
+	if result.Error != nil {
+		log.Printf("Erro ao assumir evento de webhook %s: %v", event.EventID, result.Error)
+		return errors.New("erro ao atualizar evento de webhook")
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrWebhookEventInProgress
+	}
+
+	event.Status = models.WebhookEventProcessing
+	return nil
+}
+
+func (r *GormWebhookEventRepository) Update(event *models.WebhookEvent) error {
+	err := r.db.Save(event).Error
+	if err != nil {
+		log.Printf("Erro ao atualizar evento de webhook %s: %v", event.EventID, err)
+		return errors.New("erro ao atualizar evento de webhook")
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+// AffiliateService gerencia os afiliados do criador, a atribuição das vendas
+// indicadas por eles e o extrato de comissões
+type AffiliateService interface {
+	ListForCreator(creatorID uint) ([]*AffiliateReport, error)
+	FindForCreator(id, creatorID uint) (*models.Affiliate, error)
+	Create(creatorID uint, name, email string) (*models.Affiliate, error)
+	Toggle(affiliate *models.Affiliate) error
+	// Dashboard monta o painel do afiliado acessado pelo link secreto
+	Dashboard(token string) (*AffiliateDashboard, error)
+	// AttributeOrder vincula o pedido ao afiliado do código de indicação, desde que
+	// o afiliado esteja ativo e pertença ao criador do pedido
+	AttributeOrder(order *models.Order, code string) error
+	Rules(creatorID uint) (map[uint]int, error)
+	SaveRules(creatorID uint, percents map[uint]int) error
+	// ExportCommissions escreve em CSV todos os lançamentos de comissão do criador
+	ExportCommissions(creatorID uint, w io.Writer) error
+}
+
+var ErrInvalidAffiliate = errors.New("informe o nome e um e-mail válido do afiliado")
+
+// AffiliateReport é o resumo de um afiliado na lista do criador
+type AffiliateReport struct {
+	Affiliate *models.Affiliate
+	Summaries []*models.CommissionSummary
+}
+
+// AffiliateDashboard reúne o que o afiliado vê no painel
+type AffiliateDashboard struct {
+	Affiliate   *models.Affiliate
+	Ebooks      []*AffiliateEbook
+	Commissions []*models.Commission
+	Summaries   []*models.CommissionSummary
+}
+
+// AffiliateEbook é um ebook que paga comissão, com o percentual da regra
+type AffiliateEbook struct {
+	Ebook   *models.Ebook
+	Percent int
+}
+
+type affiliateServiceImpl struct {
+	affiliateRepository repository.AffiliateRepository
+	orderRepository     repository.OrderRepository
+	ebookService        EbookService
+	encrypter           utils.Encrypter
+}
+
+func NewAffiliateService(
+	affiliateRepository repository.AffiliateRepository,
+	orderRepository repository.OrderRepository,
+	ebookService EbookService,
+) AffiliateService {
+	return &affiliateServiceImpl{
+		affiliateRepository: affiliateRepository,
+		orderRepository:     orderRepository,
+		ebookService:        ebookService,
+		encrypter:           utils.NewEncrypter(),
+	}
+}
+
+func (s *affiliateServiceImpl) ListForCreator(creatorID uint) ([]*AffiliateReport, error) {
+	affiliates, err := s.affiliateRepository.FindByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	commissions, err := s.affiliateRepository.FindCommissionsByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	byAffiliate := make(map[uint][]*models.Commission)
+	for _, commission := range commissions {
+		byAffiliate[commission.AffiliateID] = append(byAffiliate[commission.AffiliateID], commission)
+	}
+
+	reports := make([]*AffiliateReport, 0, len(affiliates))
+	for _, affiliate := range affiliates {
+		reports = append(reports, &AffiliateReport{
+			Affiliate: affiliate,
+			Summaries: models.SummarizeCommissions(byAffiliate[affiliate.ID]),
+		})
+	}
+	return reports, nil
+}
+
+func (s *affiliateServiceImpl) FindForCreator(id, creatorID uint) (*models.Affiliate, error) {
+	affiliate, err := s.affiliateRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if affiliate == nil || affiliate.CreatorID != creatorID {
+		return nil, models.ErrAffiliateNotFound
+	}
+	return affiliate, nil
+}
+
+func (s *affiliateServiceImpl) Create(creatorID uint, name, email string) (*models.Affiliate, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrInvalidAffiliate
+	}
+	if _, err := mail.ParseAddress(strings.TrimSpace(email)); err != nil {
+		return nil, ErrInvalidAffiliate
+	}
+
+	code, err := newReferralCode()
+	if err != nil {
+		log.Printf("Erro ao gerar código de indicação: %v", err)
+		return nil, errors.New("erro ao criar afiliado")
+	}
+
+	affiliate := models.NewAffiliate(creatorID, name, email, code, s.encrypter.GenerateToken(24))
+	if err := s.affiliateRepository.Create(affiliate); err != nil {
+		return nil, err
+	}
+	return affiliate, nil
+}
+
+func (s *affiliateServiceImpl) Toggle(affiliate *models.Affiliate) error {
+	affiliate.Active = !affiliate.Active
+	return s.affiliateRepository.Update(affiliate)
+}
+
+func (s *affiliateServiceImpl) Dashboard(token string) (*AffiliateDashboard, error) {
+	if token == "" {
+		return nil, models.ErrAffiliateNotFound
+	}
+
+	affiliate, err := s.affiliateRepository.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if affiliate == nil {
+		return nil, models.ErrAffiliateNotFound
+	}
+
+	commissions, err := s.affiliateRepository.FindCommissionsByAffiliate(affiliate.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.affiliateRepository.FindRules(affiliate.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ebooks []*AffiliateEbook
+	for _, rule := range rules {
+		if rule.Percent == 0 {
+			continue
+		}
+		ebook, err := s.ebookService.FindByID(rule.EbookID)
+		if err != nil || ebook == nil || !ebook.Status {
+			continue
+		}
+		ebooks = append(ebooks, &AffiliateEbook{Ebook: ebook, Percent: rule.Percent})
+	}
+
+	return &AffiliateDashboard{
+		Affiliate:   affiliate,
+		Ebooks:      ebooks,
+		Commissions: commissions,
+		Summaries:   models.SummarizeCommissions(commissions),
+	}, nil
+}
+
+func (s *affiliateServiceImpl) AttributeOrder(order *models.Order, code string) error {
+	code = strings.TrimSpace(code)
+	if order == nil || code == "" {
+		return nil
+	}
+
+	affiliate, err := s.affiliateRepository.FindByCode(code)
+	if err != nil {
+		return err
+	}
+	if affiliate == nil || !affiliate.Active || affiliate.CreatorID != order.CreatorID {
+		return nil
+	}
+
+	order.AffiliateID = &affiliate.ID
+	return s.orderRepository.Update(order)
+}
+
+func (s *affiliateServiceImpl) Rules(creatorID uint) (map[uint]int, error) {
+	rules, err := s.affiliateRepository.FindRules(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	percents := make(map[uint]int, len(rules))
+	for _, rule := range rules {
+		percents[rule.EbookID] = rule.Percent
+	}
+	return percents, nil
+}
+
+func (s *affiliateServiceImpl) SaveRules(creatorID uint, percents map[uint]int) error {
+	var rules []*models.CommissionRule
+	for ebookID, percent := range percents {
+		if percent == 0 {
+			continue
+		}
+		ebook, err := s.ebookService.FindByID(ebookID)
+		if err != nil || ebook == nil || ebook.CreatorID != creatorID {
+			return errors.New("ebook não encontrado")
+		}
+		rule, err := models.NewCommissionRule(creatorID, ebookID, percent)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	return s.affiliateRepository.SaveRules(creatorID, rules)
+}
+
+func (s *affiliateServiceImpl) ExportCommissions(creatorID uint, w io.Writer) error {
+	commissions, err := s.affiliateRepository.FindCommissionsByCreator(creatorID)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"data", "afiliado", "email", "codigo", "pedido", "ebook", "tipo", "percentual", "valor", "moeda"})
+	for _, commission := range commissions {
+		writer.Write([]string{
+			commission.CreatedAt.Format("2006-01-02 15:04"),
+			commission.Affiliate.Name,
+			commission.Affiliate.Email,
+			commission.Affiliate.Code,
+			strconv.FormatUint(uint64(commission.OrderID), 10),
+			commission.Ebook.Title,
+			commission.GetTypeLabel(),
+			strconv.Itoa(commission.Percent),
+			commission.Value().Decimal(),
+			string(commission.Currency),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// newReferralCode gera um código curto e legível para os links de indicação
+func newReferralCode() (string, error) {
+	bytes := make([]byte, 4)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(bytes)), nil
+}
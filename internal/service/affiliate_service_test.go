@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestAffiliateService_AttributeOrder(t *testing.T) {
+	affiliate := &models.Affiliate{Model: gorm.Model{ID: 4}, CreatorID: 7, Code: "AB12CD34", Active: true}
+	inactive := &models.Affiliate{Model: gorm.Model{ID: 5}, CreatorID: 7, Code: "INATIVO1", Active: false}
+	foreign := &models.Affiliate{Model: gorm.Model{ID: 6}, CreatorID: 8, Code: "OUTRO123", Active: true}
+
+	tests := []struct {
+		name     string
+		code     string
+		expected *uint
+	}{
+		{name: "afiliado ativo do criador", code: "AB12CD34", expected: &affiliate.ID},
+		{name: "afiliado inativo", code: "INATIVO1"},
+		{name: "afiliado de outro criador", code: "OUTRO123"},
+		{name: "código inexistente", code: "NAOEXISTE"},
+		{name: "sem código", code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affiliateRepo := new(repoMocks.MockAffiliateRepository)
+			orderRepo := new(repoMocks.MockOrderRepository)
+			affiliateRepo.On("FindByCode", "AB12CD34").Return(affiliate, nil)
+			affiliateRepo.On("FindByCode", "INATIVO1").Return(inactive, nil)
+			affiliateRepo.On("FindByCode", "OUTRO123").Return(foreign, nil)
+			affiliateRepo.On("FindByCode", "NAOEXISTE").Return(nil, nil)
+			orderRepo.On("Update", mock.AnythingOfType("*models.Order")).Return(nil)
+
+			service := NewAffiliateService(affiliateRepo, orderRepo, nil)
+			order := models.NewOrder(7, 9, 1, 4990, 0, money.BRL)
+
+			err := service.AttributeOrder(order, tt.code)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, order.AffiliateID)
+			if tt.expected == nil {
+				orderRepo.AssertNotCalled(t, "Update", mock.Anything)
+			}
+		})
+	}
+}
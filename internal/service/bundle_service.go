@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+// BundleService gerencia os kits de ebooks vendidos como um único produto
+type BundleService interface {
+	ListForCreator(creatorID uint) ([]*models.Bundle, error)
+	FindByID(id uint) (*models.Bundle, error)
+	// FindAvailableBySlug retorna o kit ativo para a página de vendas e o checkout
+	FindAvailableBySlug(slug string) (*models.Bundle, error)
+	Create(bundle *models.Bundle, ebookIDs []uint) error
+	Update(bundle *models.Bundle, ebookIDs []uint) error
+	Delete(bundle *models.Bundle) error
+	RegisterView(bundle *models.Bundle) error
+}
+
+var ErrBundleNotFound = errors.New("kit não encontrado ou indisponível")
+
+type bundleServiceImpl struct {
+	bundleRepository repository.BundleRepository
+	ebookService     EbookService
+}
+
+func NewBundleService(bundleRepository repository.BundleRepository, ebookService EbookService) BundleService {
+	return &bundleServiceImpl{
+		bundleRepository: bundleRepository,
+		ebookService:     ebookService,
+	}
+}
+
+func (s *bundleServiceImpl) ListForCreator(creatorID uint) ([]*models.Bundle, error) {
+	return s.bundleRepository.FindByCreator(creatorID)
+}
+
+func (s *bundleServiceImpl) FindByID(id uint) (*models.Bundle, error) {
+	bundle, err := s.bundleRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, ErrBundleNotFound
+	}
+	return bundle, nil
+}
+
+func (s *bundleServiceImpl) FindAvailableBySlug(slug string) (*models.Bundle, error) {
+	bundle, err := s.bundleRepository.FindBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil || !bundle.Status {
+		return nil, ErrBundleNotFound
+	}
+
+	// Um kit com ebook desativado deixa de ser vendido até ser ajustado pelo criador
+	for _, ebook := range bundle.Ebooks {
+		if !ebook.Status {
+			return nil, ErrBundleNotFound
+		}
+	}
+
+	return bundle, nil
+}
+
+func (s *bundleServiceImpl) Create(bundle *models.Bundle, ebookIDs []uint) error {
+	if err := s.assignEbooks(bundle, ebookIDs); err != nil {
+		return err
+	}
+	return s.bundleRepository.Create(bundle)
+}
+
+func (s *bundleServiceImpl) Update(bundle *models.Bundle, ebookIDs []uint) error {
+	if err := s.assignEbooks(bundle, ebookIDs); err != nil {
+		return err
+	}
+	return s.bundleRepository.Update(bundle)
+}
+
+func (s *bundleServiceImpl) Delete(bundle *models.Bundle) error {
+	return s.bundleRepository.Delete(bundle.ID)
+}
+
+func (s *bundleServiceImpl) RegisterView(bundle *models.Bundle) error {
+	bundle.IncrementViews()
+	return s.bundleRepository.Update(bundle)
+}
+
+func (s *bundleServiceImpl) assignEbooks(bundle *models.Bundle, ebookIDs []uint) error {
+	var ebooks []*models.Ebook
+	seen := make(map[uint]bool)
+	for _, ebookID := range ebookIDs {
+		if seen[ebookID] {
+			continue
+		}
+		seen[ebookID] = true
+
+		ebook, err := s.ebookService.FindByID(ebookID)
+		if err != nil || ebook == nil {
+			return fmt.Errorf("ebook %d não encontrado", ebookID)
+		}
+		ebooks = append(ebooks, ebook)
+	}
+
+	return bundle.SetEbooks(ebooks)
+}
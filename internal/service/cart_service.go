@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+)
+
+// CartService monta o carrinho de compras a partir dos ebooks escolhidos pelo comprador
+type CartService interface {
+	// LoadCart retorna o carrinho com os ebooks disponíveis; ebooks removidos ou
+	// inativos são descartados.
+	LoadCart(ebookIDs []uint) (*models.Cart, error)
+	// AddToCart inclui o ebook no carrinho. Se o ebook for de outro criador, o
+	// carrinho é reiniciado com ele e reset retorna true.
+	AddToCart(ebookIDs []uint, ebookID uint) (cart *models.Cart, reset bool, err error)
+}
+
+var ErrEbookUnavailable = errors.New("ebook não encontrado ou indisponível")
+
+type cartServiceImpl struct {
+	ebookService EbookService
+}
+
+func NewCartService(ebookService EbookService) CartService {
+	return &cartServiceImpl{
+		ebookService: ebookService,
+	}
+}
+
+func (s *cartServiceImpl) LoadCart(ebookIDs []uint) (*models.Cart, error) {
+	cart := models.NewCart()
+	for _, ebookID := range ebookIDs {
+		ebook, err := s.findAvailable(ebookID)
+		if err != nil {
+			log.Printf("Ebook %d removido do carrinho: %v", ebookID, err)
+			continue
+		}
+		if err := cart.Add(ebook); err != nil {
+			log.Printf("Ebook %d removido do carrinho: %v", ebookID, err)
+		}
+	}
+	return cart, nil
+}
+
+func (s *cartServiceImpl) AddToCart(ebookIDs []uint, ebookID uint) (*models.Cart, bool, error) {
+	ebook, err := s.findAvailable(ebookID)
+	if err != nil {
+		return nil, false, err
+	}
+	// Valor escolhido pelo comprador e ebooks gratuitos têm checkout próprio
+	if !ebook.IsFixedPrice() {
+		return nil, false, models.ErrCartFixedPriceOnly
+	}
+
+	cart, err := s.LoadCart(ebookIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := cart.Add(ebook); err != nil {
+		cart = models.NewCart()
+		if err := cart.Add(ebook); err != nil {
+			return nil, false, err
+		}
+		return cart, true, nil
+	}
+
+	return cart, false, nil
+}
+
+func (s *cartServiceImpl) findAvailable(ebookID uint) (*models.Ebook, error) {
+	ebook, err := s.ebookService.FindByID(ebookID)
+	if err != nil || ebook == nil || !ebook.Status {
+		return nil, ErrEbookUnavailable
+	}
+	return ebook, nil
+}
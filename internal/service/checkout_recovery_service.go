@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+// CheckoutRecoveryNotifier envia ao comprador os e-mails de recuperação do checkout
+type CheckoutRecoveryNotifier interface {
+	SendCheckoutRecovery(attempt *models.CheckoutAttempt)
+}
+
+// CheckoutRecoveryService registra as tentativas de checkout e conduz a sequência de
+// e-mails enviada quando o comprador não conclui o pagamento
+type CheckoutRecoveryService interface {
+	// RegisterAttempt guarda os dados do comprador antes do pagamento. Com o token do
+	// link de recuperação, a tentativa original é retomada.
+	RegisterAttempt(ebook *models.Ebook, token, name, email, phone string) (*models.CheckoutAttempt, error)
+	// FindByToken busca a tentativa ainda não paga do link de recuperação
+	FindByToken(token string) (*models.CheckoutAttempt, error)
+	// AttachOrder vincula o pedido à tentativa e aplica o cupom de recuperação, se houver
+	AttachOrder(attempt *models.CheckoutAttempt, order *models.Order) error
+	// HandleSessionExpired marca como abandonada a tentativa da sessão expirada no gateway
+	HandleSessionExpired(sessionID string) error
+	// AbandonStale marca como abandonadas as tentativas abertas há mais tempo que o prazo
+	AbandonStale(now time.Time) (int, error)
+	SendDueEmails(now time.Time) (int, error)
+	StartWorker(interval time.Duration)
+}
+
+var ErrCheckoutAttemptNotFound = errors.New("checkout não encontrado")
+
+type checkoutRecoveryServiceImpl struct {
+	attemptRepository repository.CheckoutAttemptRepository
+	orderRepository   repository.OrderRepository
+	notifier          CheckoutRecoveryNotifier
+	encrypter         utils.Encrypter
+	abandonAfter      time.Duration
+	scheduleHours     []int
+	discountPercent   int
+}
+
+func NewCheckoutRecoveryService(
+	attemptRepository repository.CheckoutAttemptRepository,
+	orderRepository repository.OrderRepository,
+	notifier CheckoutRecoveryNotifier,
+	abandonMinutes int,
+	scheduleHours []int,
+	discountPercent int,
+) CheckoutRecoveryService {
+	return &checkoutRecoveryServiceImpl{
+		attemptRepository: attemptRepository,
+		orderRepository:   orderRepository,
+		notifier:          notifier,
+		encrypter:         utils.NewEncrypter(),
+		abandonAfter:      time.Duration(abandonMinutes) * time.Minute,
+		scheduleHours:     scheduleHours,
+		discountPercent:   discountPercent,
+	}
+}
+
+func (s *checkoutRecoveryServiceImpl) RegisterAttempt(ebook *models.Ebook, token, name, email, phone string) (*models.CheckoutAttempt, error) {
+	if ebook == nil || ebook.ID == 0 {
+		return nil, errors.New("ebook é obrigatório")
+	}
+	email = strings.TrimSpace(email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, errors.New("e-mail inválido")
+	}
+
+	var attempt *models.CheckoutAttempt
+	if token != "" {
+		found, err := s.FindByToken(token)
+		if err != nil && !errors.Is(err, ErrCheckoutAttemptNotFound) {
+			return nil, err
+		}
+		if found != nil && found.EbookID == ebook.ID {
+			attempt = found
+		}
+	}
+	if attempt == nil {
+		found, err := s.attemptRepository.FindPending(ebook.ID, email)
+		if err != nil {
+			return nil, err
+		}
+		attempt = found
+	}
+
+	if attempt == nil {
+		attempt = models.NewCheckoutAttempt(ebook, name, email, phone, s.encrypter.GenerateToken(24))
+		if err := s.attemptRepository.Create(attempt); err != nil {
+			return nil, err
+		}
+		return attempt, nil
+	}
+
+	attempt.Name = name
+	attempt.Email = email
+	attempt.Phone = phone
+	if err := s.attemptRepository.Update(attempt); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (s *checkoutRecoveryServiceImpl) FindByToken(token string) (*models.CheckoutAttempt, error) {
+	if token == "" {
+		return nil, ErrCheckoutAttemptNotFound
+	}
+
+	attempt, err := s.attemptRepository.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if attempt == nil || !attempt.IsPending() {
+		return nil, ErrCheckoutAttemptNotFound
+	}
+	return attempt, nil
+}
+
+func (s *checkoutRecoveryServiceImpl) AttachOrder(attempt *models.CheckoutAttempt, order *models.Order) error {
+	if attempt == nil || order == nil {
+		return nil
+	}
+
+	if attempt.DiscountPercent > 0 {
+		order.ApplyDiscount(money.New(order.Subtotal, order.Currency).Percent(int64(attempt.DiscountPercent)).Amount)
+		if err := s.orderRepository.Update(order); err != nil {
+			return err
+		}
+	}
+
+	attempt.OrderID = &order.ID
+	return s.attemptRepository.Update(attempt)
+}
+
+func (s *checkoutRecoveryServiceImpl) HandleSessionExpired(sessionID string) error {
+	order, err := s.orderRepository.FindByGatewaySessionID(sessionID)
+	if err != nil {
+		return err
+	}
+	if order == nil || order.IsPaid() {
+		return nil
+	}
+
+	attempt, err := s.attemptRepository.FindByOrderID(order.ID)
+	if err != nil {
+		return err
+	}
+	if attempt == nil || !attempt.Abandon(time.Now()) {
+		return nil
+	}
+	return s.attemptRepository.Update(attempt)
+}
+
+func (s *checkoutRecoveryServiceImpl) AbandonStale(now time.Time) (int, error) {
+	attempts, err := s.attemptRepository.FindOpenBefore(now.Add(-s.abandonAfter))
+	if err != nil {
+		return 0, err
+	}
+
+	abandoned := 0
+	for _, attempt := range attempts {
+		if !attempt.Abandon(now) {
+			continue
+		}
+		if err := s.attemptRepository.Update(attempt); err != nil {
+			log.Printf("Erro ao marcar checkout %d como abandonado: %v", attempt.ID, err)
+			continue
+		}
+		abandoned++
+	}
+	return abandoned, nil
+}
+
+func (s *checkoutRecoveryServiceImpl) SendDueEmails(now time.Time) (int, error) {
+	if len(s.scheduleHours) == 0 {
+		return 0, nil
+	}
+
+	attempts, err := s.attemptRepository.FindAbandoned(len(s.scheduleHours))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, attempt := range attempts {
+		if !attempt.NextEmailDue(now, s.scheduleHours) || !attempt.Ebook.Status {
+			continue
+		}
+
+		// O cupom, quando configurado, fica para o último e-mail da sequência
+		if s.discountPercent > 0 && attempt.IsLastEmail(s.scheduleHours) {
+			attempt.DiscountPercent = s.discountPercent
+		}
+
+		s.notifier.SendCheckoutRecovery(attempt)
+		attempt.RegisterEmail(now)
+		if err := s.attemptRepository.Update(attempt); err != nil {
+			log.Printf("Erro ao registrar e-mail de recuperação do checkout %d: %v", attempt.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *checkoutRecoveryServiceImpl) StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			now := time.Now()
+			if _, err := s.AbandonStale(now); err != nil {
+				log.Printf("Erro ao verificar checkouts abandonados: %v", err)
+			}
+			if _, err := s.SendDueEmails(now); err != nil {
+				log.Printf("Erro ao enviar e-mails de recuperação de checkout: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockCheckoutRecoveryNotifier struct {
+	mock.Mock
+}
+
+func (m *MockCheckoutRecoveryNotifier) SendCheckoutRecovery(attempt *models.CheckoutAttempt) {
+	m.Called(attempt)
+}
+
+func abandonedAttempt(hoursAgo int, emailsSent int) *models.CheckoutAttempt {
+	abandonedAt := time.Now().Add(-time.Duration(hoursAgo) * time.Hour)
+	return &models.CheckoutAttempt{
+		Model:       gorm.Model{ID: 1},
+		EbookID:     2,
+		Ebook:       models.Ebook{Title: "Ebook", Status: true},
+		Email:       "comprador@email.com",
+		Status:      models.CheckoutAttemptAbandoned,
+		EmailsSent:  emailsSent,
+		AbandonedAt: &abandonedAt,
+	}
+}
+
+func TestCheckoutRecoveryService_SendDueEmails(t *testing.T) {
+	tests := []struct {
+		name             string
+		attempt          *models.CheckoutAttempt
+		expectedSent     int
+		expectedDiscount int
+	}{
+		{name: "primeiro e-mail vencido", attempt: abandonedAttempt(2, 0), expectedSent: 1},
+		{name: "segundo e-mail ainda não vencido", attempt: abandonedAttempt(2, 1), expectedSent: 0},
+		{name: "último e-mail leva o cupom", attempt: abandonedAttempt(80, 2), expectedSent: 1, expectedDiscount: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attemptRepo := new(repoMocks.MockCheckoutAttemptRepository)
+			notifier := new(MockCheckoutRecoveryNotifier)
+			attemptRepo.On("FindAbandoned", 3).Return([]*models.CheckoutAttempt{tt.attempt}, nil)
+			attemptRepo.On("Update", tt.attempt).Return(nil)
+			notifier.On("SendCheckoutRecovery", tt.attempt).Return()
+
+			service := NewCheckoutRecoveryService(attemptRepo, new(repoMocks.MockOrderRepository), notifier, 60, []int{1, 24, 72}, 15)
+			sent, err := service.SendDueEmails(time.Now())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSent, sent)
+			assert.Equal(t, tt.expectedDiscount, tt.attempt.DiscountPercent)
+			notifier.AssertNumberOfCalls(t, "SendCheckoutRecovery", tt.expectedSent)
+		})
+	}
+}
+
+func TestCheckoutRecoveryService_HandleSessionExpired(t *testing.T) {
+	attemptRepo := new(repoMocks.MockCheckoutAttemptRepository)
+	orderRepo := new(repoMocks.MockOrderRepository)
+	order := &models.Order{Model: gorm.Model{ID: 5}, Status: models.OrderStatusPending}
+	attempt := &models.CheckoutAttempt{Status: models.CheckoutAttemptOpen}
+
+	orderRepo.On("FindByGatewaySessionID", "cs_1").Return(order, nil)
+	attemptRepo.On("FindByOrderID", uint(5)).Return(attempt, nil)
+	attemptRepo.On("Update", attempt).Return(nil)
+
+	service := NewCheckoutRecoveryService(attemptRepo, orderRepo, new(MockCheckoutRecoveryNotifier), 60, []int{1}, 0)
+	err := service.HandleSessionExpired("cs_1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.CheckoutAttemptAbandoned, attempt.Status)
+	assert.NotNil(t, attempt.AbandonedAt)
+}
+
+func TestCheckoutRecoveryService_AttachOrder_AppliesCoupon(t *testing.T) {
+	attemptRepo := new(repoMocks.MockCheckoutAttemptRepository)
+	orderRepo := new(repoMocks.MockOrderRepository)
+	attempt := abandonedAttempt(80, 3)
+	attempt.DiscountPercent = 15
+	order := models.NewOrder(7, 9, 2, 5000, 0, money.BRL)
+	order.ID = 5
+
+	orderRepo.On("Update", order).Return(nil)
+	attemptRepo.On("Update", attempt).Return(nil)
+
+	service := NewCheckoutRecoveryService(attemptRepo, orderRepo, new(MockCheckoutRecoveryNotifier), 60, []int{1}, 15)
+	err := service.AttachOrder(attempt, order)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(750), order.Discount)
+	assert.Equal(t, int64(4250), order.Total)
+	assert.Equal(t, uint(5), *attempt.OrderID)
+}
+
+func TestCheckoutRecoveryService_RegisterAttempt_ReusesPendingAttempt(t *testing.T) {
+	attemptRepo := new(repoMocks.MockCheckoutAttemptRepository)
+	ebook := &models.Ebook{Model: gorm.Model{ID: 2}, CreatorID: 7}
+	pending := &models.CheckoutAttempt{EbookID: 2, Email: "comprador@email.com", Status: models.CheckoutAttemptOpen}
+
+	attemptRepo.On("FindPending", uint(2), "comprador@email.com").Return(pending, nil)
+	attemptRepo.On("Update", pending).Return(nil)
+
+	service := NewCheckoutRecoveryService(attemptRepo, new(repoMocks.MockOrderRepository), new(MockCheckoutRecoveryNotifier), 60, []int{1}, 0)
+	attempt, err := service.RegisterAttempt(ebook, "", "Novo Nome", "comprador@email.com", "11999999999")
+
+	assert.NoError(t, err)
+	assert.Same(t, pending, attempt)
+	assert.Equal(t, "Novo Nome", attempt.Name)
+	attemptRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
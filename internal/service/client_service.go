@@ -49,17 +49,20 @@ type clientServiceImpl struct {
 	clientRepository      repository.ClientRepository
 	creatorRepository     repository.CreatorRepository
 	receitaFederalService gov.ReceitaFederalService
+	planLimiter           PlanLimiter
 }
 
 func NewClientService(
 	clientRepository repository.ClientRepository,
 	creatorRepository repository.CreatorRepository,
 	receitaFederalService gov.ReceitaFederalService,
+	planLimiter PlanLimiter,
 ) ClientService {
 	return &clientServiceImpl{
 		clientRepository:      clientRepository,
 		creatorRepository:     creatorRepository,
 		receitaFederalService: receitaFederalService,
+		planLimiter:           planLimiter,
 	}
 }
 
@@ -74,6 +77,10 @@ func (cs *clientServiceImpl) CreateClient(input CreateClientInput) (*CreateClien
 		return nil, err
 	}
 
+	if err := cs.checkClientLimit(creator, 1); err != nil {
+		return nil, err
+	}
+
 	clientExists, err := cs.clientRepository.FindByEmail(input.Email)
 	if err != nil {
 		return nil, err
@@ -157,6 +164,12 @@ func (cs *clientServiceImpl) Update(input UpdateClientInput) (*models.Client, er
 }
 
 func (cs *clientServiceImpl) CreateBatchClient(clients []*models.Client) error {
+	if len(clients) > 0 && len(clients[0].Creators) > 0 {
+		if err := cs.checkClientLimit(clients[0].Creators[0], int64(len(clients))); err != nil {
+			return err
+		}
+	}
+
 	err := cs.clientRepository.InsertBatch(clients)
 	if err != nil {
 		return err
@@ -186,3 +199,10 @@ func (cs *clientServiceImpl) validateReceita(client *models.Client, birthDate ti
 	client.Validated = true
 	return nil
 }
+
+func (cs *clientServiceImpl) checkClientLimit(creator *models.Creator, amount int64) error {
+	if cs.planLimiter == nil || creator == nil {
+		return nil
+	}
+	return cs.planLimiter.CheckLimit(creator.ID, models.ResourceClients, amount)
+}
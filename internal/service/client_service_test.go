@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	mocks_repo "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	mocks_service "github.com/anglesson/simple-web-server/internal/service/mocks"
 	"github.com/anglesson/simple-web-server/pkg/gov/mocks"
 
 	"github.com/anglesson/simple-web-server/internal/repository"
@@ -24,13 +25,15 @@ type ClientServiceTestSuite struct {
 	mockClientRepository  repository.ClientRepository
 	mockCreatorRepository repository.CreatorRepository
 	mockRFService         gov.ReceitaFederalService
+	mockPlanLimiter       *mocks_service.MockPlanLimiter
 }
 
 func (suite *ClientServiceTestSuite) SetupTest() {
 	suite.mockClientRepository = new(mocks_repo.MockClientRepository)
 	suite.mockCreatorRepository = new(mocks_repo.MockCreatorRepository)
 	suite.mockRFService = new(mocks.MockRFService)
-	suite.sut = service.NewClientService(suite.mockClientRepository, suite.mockCreatorRepository, suite.mockRFService)
+	suite.mockPlanLimiter = new(mocks_service.MockPlanLimiter)
+	suite.sut = service.NewClientService(suite.mockClientRepository, suite.mockCreatorRepository, suite.mockRFService, suite.mockPlanLimiter)
 }
 
 func (suite *ClientServiceTestSuite) TestCreateClient() {
@@ -73,6 +76,8 @@ func (suite *ClientServiceTestSuite) TestCreateClient() {
 		On("FindCreatorByUserEmail", creator.Email).
 		Return(creator, nil)
 
+	suite.mockPlanLimiter.On("CheckLimit", creator.ID, models.ResourceClients, int64(1)).Return(nil)
+
 	suite.mockClientRepository.(*mocks_repo.MockClientRepository).
 		On("FindByEmail", input.Email).
 		Return(nil, nil)
@@ -128,6 +133,8 @@ func (suite *ClientServiceTestSuite) TestShouldReturnErrorIfClientExists() {
 		On("FindCreatorByUserEmail", creator.Email).
 		Return(creator, nil)
 
+	suite.mockPlanLimiter.On("CheckLimit", creator.ID, models.ResourceClients, int64(1)).Return(nil)
+
 	suite.mockClientRepository.(*mocks_repo.MockClientRepository).
 		On("FindByEmail", input.Email).
 		Return(client, nil)
@@ -142,3 +149,29 @@ func (suite *ClientServiceTestSuite) TestShouldReturnErrorIfClientExists() {
 func TestClientServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(ClientServiceTestSuite))
 }
+
+func (suite *ClientServiceTestSuite) TestShouldReturnErrorWhenPlanLimitReached() {
+	creator := &models.Creator{Email: "creator@mail.com"}
+	creator.ID = 1
+
+	input := service.CreateClientInput{
+		Name:         "Name User",
+		CPF:          "058.997.950-77",
+		BirthDate:    "1990-12-12",
+		Email:        "client@mail.com",
+		Phone:        "12945678901",
+		EmailCreator: creator.Email,
+	}
+
+	suite.mockCreatorRepository.(*mocks_repo.MockCreatorRepository).
+		On("FindCreatorByUserEmail", creator.Email).
+		Return(creator, nil)
+
+	limitErr := &service.PlanLimitError{PlanName: "Essencial", Resource: models.ResourceClients, Limit: 500}
+	suite.mockPlanLimiter.On("CheckLimit", creator.ID, models.ResourceClients, int64(1)).Return(limitErr)
+
+	_, err := suite.sut.CreateClient(input)
+
+	suite.ErrorIs(err, service.ErrPlanLimitReached)
+	suite.mockClientRepository.(*mocks_repo.MockClientRepository).AssertNotCalled(suite.T(), "FindByEmail", input.Email)
+}
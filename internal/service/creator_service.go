@@ -126,14 +126,14 @@ func (cs *creatorServiceImpl) CreateCreator(input InputCreateCreator) (*models.C
 		// The customer can be created later
 	} else {
 		// Create subscription for the creator
-		subscription, err := cs.subscriptionService.CreateSubscription(user.ID, "default_plan")
+		subscription, err := cs.subscriptionService.CreateSubscription(user.ID, models.DefaultPlanSlug)
 		if err != nil {
 			log.Printf("Error creating subscription: %v", err)
 		} else {
-			// Activate subscription with customer ID
-			err = cs.subscriptionService.ActivateSubscription(subscription, customerID, "")
+			// Link the gateway customer; the subscription stays in trial until checkout
+			err = cs.subscriptionService.AttachCustomer(subscription, customerID, models.UserActor(user.ID))
 			if err != nil {
-				log.Printf("Error activating subscription: %v", err)
+				log.Printf("Error attaching customer to subscription: %v", err)
 			}
 		}
 	}
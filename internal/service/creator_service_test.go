@@ -124,7 +124,7 @@ func (suite *CreatorServiceTestSuite) setupSuccessfulMockExpectations(validatedN
 		Return(&models.Subscription{UserID: expectedUser.ID}, nil)
 
 	suite.mockSubscriptionService.(*mocksService.MockSubscriptionService).
-		On("ActivateSubscription", mock.AnythingOfType("*models.Subscription"), "cus_123", "").
+		On("AttachCustomer", mock.AnythingOfType("*models.Subscription"), "cus_123", models.UserActor(expectedUser.ID)).
 		Return(nil)
 }
 
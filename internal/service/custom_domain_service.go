@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+var (
+	ErrReservedDomain   = errors.New("este domínio pertence à plataforma")
+	ErrDomainNotFound   = errors.New("nenhum domínio cadastrado")
+	ErrDomainLookupFail = errors.New("não foi possível consultar o DNS do domínio, tente novamente em alguns minutos")
+)
+
+// TXTLookup consulta os registros TXT de um nome no DNS (net.LookupTXT em produção)
+type TXTLookup func(name string) ([]string, error)
+
+// CustomDomainService cadastra, verifica e resolve os domínios próprios dos criadores
+type CustomDomainService interface {
+	// Domain busca o domínio do criador; nil se não houver
+	Domain(creatorID uint) (*models.CustomDomain, error)
+	// Register cadastra o domínio, substituindo o anterior, e gera o token de verificação
+	Register(creator *models.Creator, host string) (*models.CustomDomain, error)
+	// Verify procura o token no TXT do domínio e ativa o domínio quando encontra
+	Verify(creator *models.Creator) (*models.CustomDomain, error)
+	Remove(creator *models.Creator) error
+	// Resolve devolve o criador dono do domínio verificado; nil para outros hosts
+	Resolve(host string) (*models.Creator, error)
+}
+
+type customDomainServiceImpl struct {
+	domainRepository repository.CustomDomainRepository
+	lookupTXT        TXTLookup
+	encrypter        utils.Encrypter
+}
+
+func NewCustomDomainService(domainRepository repository.CustomDomainRepository, lookupTXT TXTLookup) CustomDomainService {
+	return &customDomainServiceImpl{
+		domainRepository: domainRepository,
+		lookupTXT:        lookupTXT,
+		encrypter:        utils.NewEncrypter(),
+	}
+}
+
+func (s *customDomainServiceImpl) Domain(creatorID uint) (*models.CustomDomain, error) {
+	return s.domainRepository.FindByCreator(creatorID)
+}
+
+func (s *customDomainServiceImpl) Register(creator *models.Creator, host string) (*models.CustomDomain, error) {
+	domain, err := models.NewCustomDomain(creator.ID, host, s.encrypter.GenerateToken(24))
+	if err != nil {
+		return nil, err
+	}
+	if isPlatformHost(domain.Host) {
+		return nil, ErrReservedDomain
+	}
+
+	// Reenviar o mesmo domínio mantém o token já publicado no DNS
+	current, err := s.domainRepository.FindByCreator(creator.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.Host == domain.Host {
+		return current, nil
+	}
+
+	taken, err := s.domainRepository.HostTaken(domain.Host, creator.ID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, models.ErrDomainTaken
+	}
+
+	if err := s.domainRepository.Replace(domain); err != nil {
+		return nil, err
+	}
+	creator.Domain = ""
+	return domain, nil
+}
+
+func (s *customDomainServiceImpl) Verify(creator *models.Creator) (*models.CustomDomain, error) {
+	domain, err := s.domainRepository.FindByCreator(creator.ID)
+	if err != nil {
+		return nil, err
+	}
+	if domain == nil {
+		return nil, ErrDomainNotFound
+	}
+
+	records, err := s.lookupTXT(domain.RecordName())
+	if err != nil && !isDNSNotFound(err) {
+		return domain, ErrDomainLookupFail
+	}
+
+	if err := domain.Verify(records, time.Now()); err != nil {
+		if updateErr := s.domainRepository.UpdateCheck(domain); updateErr != nil {
+			return domain, updateErr
+		}
+		return domain, err
+	}
+
+	// Outro criador pode ter verificado o mesmo host enquanto este aguardava o DNS
+	taken, err := s.domainRepository.HostTaken(domain.Host, creator.ID)
+	if err != nil {
+		return domain, err
+	}
+	if taken {
+		domain.VerifiedAt = nil
+		return domain, models.ErrDomainTaken
+	}
+
+	if err := s.domainRepository.MarkVerified(domain); err != nil {
+		return domain, err
+	}
+	creator.Domain = domain.Host
+	return domain, nil
+}
+
+func (s *customDomainServiceImpl) Remove(creator *models.Creator) error {
+	domain, err := s.domainRepository.FindByCreator(creator.ID)
+	if err != nil {
+		return err
+	}
+	if domain == nil {
+		return ErrDomainNotFound
+	}
+	if err := s.domainRepository.Delete(domain); err != nil {
+		return err
+	}
+	creator.Domain = ""
+	return nil
+}
+
+func (s *customDomainServiceImpl) Resolve(host string) (*models.Creator, error) {
+	host, err := models.NormalizeDomain(host)
+	if err != nil || isPlatformHost(host) {
+		return nil, nil
+	}
+	domain, err := s.domainRepository.FindVerifiedByHost(host)
+	if err != nil || domain == nil {
+		return nil, err
+	}
+	return &domain.Creator, nil
+}
+
+// isPlatformHost indica se o host é o da própria aplicação (config HOST)
+func isPlatformHost(host string) bool {
+	parsed, err := url.Parse(config.AppConfig.Host)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Hostname(), host)
+}
+
+// isDNSNotFound trata a ausência do registro como verificação pendente, não como falha do DNS
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func staticTXT(records []string, err error) TXTLookup {
+	return func(name string) ([]string, error) {
+		return records, err
+	}
+}
+
+func TestCustomDomainService_Register(t *testing.T) {
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT(nil, nil))
+
+	creator := &models.Creator{Domain: "antigo.autor.com.br"}
+	creator.ID = 7
+	repo.On("FindByCreator", uint(7)).Return(nil, nil)
+	repo.On("HostTaken", "loja.autor.com.br", uint(7)).Return(false, nil)
+	repo.On("Replace", mock.Anything).Return(nil)
+
+	domain, err := service.Register(creator, "https://Loja.Autor.com.br/")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "loja.autor.com.br", domain.Host)
+	assert.NotEmpty(t, domain.Token)
+	assert.Empty(t, creator.Domain)
+}
+
+func TestCustomDomainService_Register_SameHostKeepsToken(t *testing.T) {
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT(nil, nil))
+
+	creator := &models.Creator{}
+	creator.ID = 7
+	current := &models.CustomDomain{CreatorID: 7, Host: "loja.autor.com.br", Token: "publicado"}
+	repo.On("FindByCreator", uint(7)).Return(current, nil)
+
+	domain, err := service.Register(creator, "loja.autor.com.br")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "publicado", domain.Token)
+	repo.AssertNotCalled(t, "Replace", mock.Anything)
+}
+
+func TestCustomDomainService_Register_Rejected(t *testing.T) {
+	host := config.AppConfig.Host
+	config.AppConfig.Host = "https://docffy.com.br"
+	defer func() { config.AppConfig.Host = host }()
+
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT(nil, nil))
+	creator := &models.Creator{}
+	creator.ID = 7
+
+	_, err := service.Register(creator, "docffy.com.br")
+	assert.ErrorIs(t, err, ErrReservedDomain)
+
+	_, err = service.Register(creator, "localhost")
+	assert.ErrorIs(t, err, models.ErrInvalidDomain)
+
+	repo.On("FindByCreator", uint(7)).Return(nil, nil)
+	repo.On("HostTaken", "loja.autor.com.br", uint(7)).Return(true, nil)
+	_, err = service.Register(creator, "loja.autor.com.br")
+	assert.ErrorIs(t, err, models.ErrDomainTaken)
+}
+
+func TestCustomDomainService_Verify(t *testing.T) {
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, func(name string) ([]string, error) {
+		assert.Equal(t, "_verificacao.loja.autor.com.br", name)
+		return []string{"verificacao=abc"}, nil
+	})
+
+	creator := &models.Creator{}
+	creator.ID = 7
+	domain := &models.CustomDomain{CreatorID: 7, Host: "loja.autor.com.br", Token: "abc"}
+	repo.On("FindByCreator", uint(7)).Return(domain, nil)
+	repo.On("HostTaken", "loja.autor.com.br", uint(7)).Return(false, nil)
+	repo.On("MarkVerified", domain).Return(nil)
+
+	_, err := service.Verify(creator)
+
+	assert.NoError(t, err)
+	assert.True(t, domain.IsVerified())
+	assert.Equal(t, "loja.autor.com.br", creator.Domain)
+}
+
+func TestCustomDomainService_Verify_HostVerifiedByAnotherCreator(t *testing.T) {
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT([]string{"verificacao=abc"}, nil))
+
+	creator := &models.Creator{}
+	creator.ID = 7
+	domain := &models.CustomDomain{CreatorID: 7, Host: "loja.autor.com.br", Token: "abc"}
+	repo.On("FindByCreator", uint(7)).Return(domain, nil)
+	repo.On("HostTaken", "loja.autor.com.br", uint(7)).Return(true, nil)
+
+	_, err := service.Verify(creator)
+
+	assert.ErrorIs(t, err, models.ErrDomainTaken)
+	assert.False(t, domain.IsVerified())
+	assert.Empty(t, creator.Domain)
+	repo.AssertNotCalled(t, "MarkVerified", mock.Anything)
+}
+
+func TestCustomDomainService_Verify_RecordMissing(t *testing.T) {
+	notFound := &net.DNSError{Err: "no such host", Name: "_verificacao.loja.autor.com.br", IsNotFound: true}
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT(nil, notFound))
+
+	creator := &models.Creator{}
+	creator.ID = 7
+	domain := &models.CustomDomain{CreatorID: 7, Host: "loja.autor.com.br", Token: "abc"}
+	repo.On("FindByCreator", uint(7)).Return(domain, nil)
+	repo.On("UpdateCheck", domain).Return(nil)
+
+	_, err := service.Verify(creator)
+
+	assert.ErrorIs(t, err, models.ErrDomainNotVerified)
+	assert.NotNil(t, domain.CheckedAt)
+	repo.AssertNotCalled(t, "MarkVerified", mock.Anything)
+}
+
+func TestCustomDomainService_Verify_LookupFailure(t *testing.T) {
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT(nil, errors.New("timeout")))
+
+	creator := &models.Creator{}
+	creator.ID = 7
+	repo.On("FindByCreator", uint(7)).Return(&models.CustomDomain{Host: "loja.autor.com.br"}, nil)
+
+	_, err := service.Verify(creator)
+
+	assert.ErrorIs(t, err, ErrDomainLookupFail)
+}
+
+func TestCustomDomainService_Resolve(t *testing.T) {
+	repo := new(repoMocks.MockCustomDomainRepository)
+	service := NewCustomDomainService(repo, staticTXT(nil, nil))
+
+	owner := models.Creator{Name: "Ana", Slug: "ana"}
+	repo.On("FindVerifiedByHost", "loja.autor.com.br").Return(&models.CustomDomain{Creator: owner}, nil)
+	repo.On("FindVerifiedByHost", "outro.com.br").Return(nil, nil)
+
+	creator, err := service.Resolve("loja.autor.com.br:443")
+	assert.NoError(t, err)
+	assert.Equal(t, "ana", creator.Slug)
+
+	creator, err = service.Resolve("outro.com.br")
+	assert.NoError(t, err)
+	assert.Nil(t, creator)
+
+	creator, err = service.Resolve("localhost:8080")
+	assert.NoError(t, err)
+	assert.Nil(t, creator)
+}
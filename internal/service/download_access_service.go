@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+var ErrPurchaseNotFound = errors.New("compra não encontrada")
+
+// DownloadAccessService permite ao criador ajustar os limites de download de cada comprador
+type DownloadAccessService interface {
+	ListForClient(creatorID, clientID uint) ([]*models.Purchase, error)
+	// Reset zera os downloads usados e os dispositivos registrados
+	Reset(creatorID, purchaseID uint) (*models.Purchase, error)
+	// Extend acrescenta dias ao link e downloads ao limite da compra
+	Extend(creatorID, purchaseID uint, days, downloads int) (*models.Purchase, error)
+}
+
+type downloadAccessServiceImpl struct {
+	accessRepository repository.DownloadAccessRepository
+}
+
+func NewDownloadAccessService(accessRepository repository.DownloadAccessRepository) DownloadAccessService {
+	return &downloadAccessServiceImpl{accessRepository: accessRepository}
+}
+
+func (s *downloadAccessServiceImpl) ListForClient(creatorID, clientID uint) ([]*models.Purchase, error) {
+	return s.accessRepository.FindByClient(creatorID, clientID)
+}
+
+func (s *downloadAccessServiceImpl) Reset(creatorID, purchaseID uint) (*models.Purchase, error) {
+	purchase, err := s.find(creatorID, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	purchase.ResetAllowance(time.Now())
+	if err := s.accessRepository.UpdateAllowance(purchase); err != nil {
+		return nil, err
+	}
+	return purchase, nil
+}
+
+func (s *downloadAccessServiceImpl) Extend(creatorID, purchaseID uint, days, downloads int) (*models.Purchase, error) {
+	purchase, err := s.find(creatorID, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := purchase.Extend(days, downloads, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.accessRepository.UpdateAllowance(purchase); err != nil {
+		return nil, err
+	}
+	return purchase, nil
+}
+
+func (s *downloadAccessServiceImpl) find(creatorID, purchaseID uint) (*models.Purchase, error) {
+	purchase, err := s.accessRepository.FindForCreator(purchaseID, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if purchase == nil {
+		return nil, ErrPurchaseNotFound
+	}
+	return purchase, nil
+}
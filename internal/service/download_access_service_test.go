@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDownloadAccessService_Reset(t *testing.T) {
+	repo := new(repoMocks.MockDownloadAccessRepository)
+	service := NewDownloadAccessService(repo)
+
+	purchase := models.NewPurchase(3, 2)
+	purchase.DownloadLimit = 3
+	purchase.DownloadsUsed = 3
+	repo.On("FindForCreator", uint(7), uint(1)).Return(purchase, nil)
+	repo.On("UpdateAllowance", purchase).Return(nil)
+
+	reset, err := service.Reset(1, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reset.DownloadsUsed)
+	assert.NotNil(t, reset.DevicesResetAt)
+	repo.AssertExpectations(t)
+}
+
+func TestDownloadAccessService_Extend(t *testing.T) {
+	repo := new(repoMocks.MockDownloadAccessRepository)
+	service := NewDownloadAccessService(repo)
+
+	purchase := models.NewPurchase(3, 2)
+	purchase.DownloadLimit = 3
+	repo.On("FindForCreator", uint(7), uint(1)).Return(purchase, nil)
+	repo.On("UpdateAllowance", purchase).Return(nil)
+
+	extended, err := service.Extend(1, 7, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, extended.DownloadLimit)
+
+	_, err = service.Extend(1, 7, 0, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidExtension)
+	repo.AssertNumberOfCalls(t, "UpdateAllowance", 1)
+}
+
+func TestDownloadAccessService_OtherCreatorsPurchase(t *testing.T) {
+	repo := new(repoMocks.MockDownloadAccessRepository)
+	service := NewDownloadAccessService(repo)
+
+	repo.On("FindForCreator", uint(7), uint(2)).Return(nil, nil)
+
+	_, err := service.Reset(2, 7)
+
+	assert.ErrorIs(t, err, ErrPurchaseNotFound)
+	repo.AssertNotCalled(t, "UpdateAllowance", mock.Anything)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+// DownloadReviewService cuida da fila de compras com sinais de link compartilhado
+type DownloadReviewService interface {
+	Queue(creatorID uint) ([]*models.Purchase, error)
+	// Dismiss libera os downloads e tira a compra da fila
+	Dismiss(creatorID, purchaseID uint) (*models.Purchase, error)
+	// Suspend bloqueia os downloads mantendo a compra na fila
+	Suspend(creatorID, purchaseID uint) (*models.Purchase, error)
+	SetAutoSuspend(creatorID uint, enabled bool) error
+	// SetWatermarkText grava o texto da marca d'água personalizada do criador
+	SetWatermarkText(creator *models.Creator, text string) error
+}
+
+type downloadReviewServiceImpl struct {
+	reviewRepository repository.DownloadReviewRepository
+}
+
+func NewDownloadReviewService(reviewRepository repository.DownloadReviewRepository) DownloadReviewService {
+	return &downloadReviewServiceImpl{reviewRepository: reviewRepository}
+}
+
+func (s *downloadReviewServiceImpl) Queue(creatorID uint) ([]*models.Purchase, error) {
+	return s.reviewRepository.FindFlagged(creatorID)
+}
+
+func (s *downloadReviewServiceImpl) Dismiss(creatorID, purchaseID uint) (*models.Purchase, error) {
+	return s.review(creatorID, purchaseID, (*models.Purchase).Dismiss)
+}
+
+func (s *downloadReviewServiceImpl) Suspend(creatorID, purchaseID uint) (*models.Purchase, error) {
+	return s.review(creatorID, purchaseID, (*models.Purchase).Suspend)
+}
+
+func (s *downloadReviewServiceImpl) SetAutoSuspend(creatorID uint, enabled bool) error {
+	return s.reviewRepository.UpdateAutoSuspend(creatorID, enabled)
+}
+
+func (s *downloadReviewServiceImpl) SetWatermarkText(creator *models.Creator, text string) error {
+	if err := creator.SetWatermarkText(text); err != nil {
+		return err
+	}
+	return s.reviewRepository.UpdateWatermarkText(creator.ID, creator.WatermarkText)
+}
+
+func (s *downloadReviewServiceImpl) review(creatorID, purchaseID uint, decide func(*models.Purchase, time.Time)) (*models.Purchase, error) {
+	purchase, err := s.reviewRepository.FindForCreator(purchaseID, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if purchase == nil {
+		return nil, ErrPurchaseNotFound
+	}
+
+	decide(purchase, time.Now())
+	if err := s.reviewRepository.UpdateReview(purchase); err != nil {
+		return nil, err
+	}
+	return purchase, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func flaggedPurchase() *models.Purchase {
+	now := time.Now()
+	purchase := models.NewPurchase(3, 2)
+	purchase.ID = 1
+	purchase.SharingScore = 80
+	purchase.FlaggedAt = &now
+	return purchase
+}
+
+func TestDownloadReviewService_Dismiss(t *testing.T) {
+	repo := new(repoMocks.MockDownloadReviewRepository)
+	service := NewDownloadReviewService(repo)
+
+	purchase := flaggedPurchase()
+	purchase.Suspend(time.Now())
+	repo.On("FindForCreator", uint(1), uint(7)).Return(purchase, nil)
+	repo.On("UpdateReview", purchase).Return(nil)
+
+	dismissed, err := service.Dismiss(7, 1)
+
+	assert.NoError(t, err)
+	assert.False(t, dismissed.IsSuspended())
+	assert.Nil(t, dismissed.FlaggedAt)
+	assert.NotNil(t, dismissed.ReviewedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestDownloadReviewService_Suspend(t *testing.T) {
+	repo := new(repoMocks.MockDownloadReviewRepository)
+	service := NewDownloadReviewService(repo)
+
+	purchase := flaggedPurchase()
+	repo.On("FindForCreator", uint(1), uint(7)).Return(purchase, nil)
+	repo.On("UpdateReview", purchase).Return(nil)
+
+	suspended, err := service.Suspend(7, 1)
+
+	assert.NoError(t, err)
+	assert.True(t, suspended.IsSuspended())
+	assert.NotNil(t, suspended.FlaggedAt)
+}
+
+func TestDownloadReviewService_OtherCreatorsPurchase(t *testing.T) {
+	repo := new(repoMocks.MockDownloadReviewRepository)
+	service := NewDownloadReviewService(repo)
+
+	repo.On("FindForCreator", uint(1), uint(8)).Return(nil, nil)
+
+	_, err := service.Suspend(8, 1)
+
+	assert.ErrorIs(t, err, ErrPurchaseNotFound)
+	repo.AssertNotCalled(t, "UpdateReview", mock.Anything)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+// DripNotifier avisa o comprador dos arquivos do ebook que acabaram de ser liberados
+type DripNotifier interface {
+	SendFilesUnlocked(purchase *models.Purchase, files []*models.ScheduledFile)
+}
+
+// DripService conduz a entrega programada dos arquivos: o criador define a ordem e
+// o prazo de liberação de cada arquivo, contado a partir da compra
+type DripService interface {
+	// Schedule lista os arquivos do ebook na ordem de entrega, com o prazo de cada um
+	Schedule(ebook *models.Ebook) ([]*models.ScheduledFile, error)
+	// UpdateSchedule grava o cronograma informado. As posições são renumeradas na
+	// ordem informada, a partir de 1. Prazos que não cabem na validade do link de
+	// download do ebook são recusados.
+	UpdateSchedule(ebook *models.Ebook, entries []*models.EbookFile) error
+	// NotifyUnlocked avisa os compradores dos arquivos liberados desde o último aviso
+	NotifyUnlocked(now time.Time) (int, error)
+	StartWorker(interval time.Duration)
+}
+
+type dripServiceImpl struct {
+	ebookFileRepository repository.EbookFileRepository
+	notifier            DripNotifier
+}
+
+func NewDripService(ebookFileRepository repository.EbookFileRepository, notifier DripNotifier) DripService {
+	return &dripServiceImpl{
+		ebookFileRepository: ebookFileRepository,
+		notifier:            notifier,
+	}
+}
+
+func (s *dripServiceImpl) Schedule(ebook *models.Ebook) ([]*models.ScheduledFile, error) {
+	entries, err := s.ebookFileRepository.FindByEbook(ebook.ID)
+	if err != nil {
+		return nil, err
+	}
+	ebook.FileSchedule = entries
+
+	// Arquivos ainda sem posição vêm por último; numera-os em sequência para o formulário
+	files := ebook.ScheduledFiles(time.Time{})
+	for i, file := range files {
+		if file.Position == 0 {
+			file.Position = i + 1
+		}
+	}
+	return files, nil
+}
+
+func (s *dripServiceImpl) UpdateSchedule(ebook *models.Ebook, entries []*models.EbookFile) error {
+	files := make(map[uint]bool, len(ebook.Files))
+	for _, file := range ebook.Files {
+		files[file.ID] = true
+	}
+
+	for _, entry := range entries {
+		if !files[entry.FileID] {
+			return models.ErrFileNotInEbook
+		}
+		if err := entry.ValidateWithin(ebook.DownloadPolicy); err != nil {
+			return err
+		}
+		entry.EbookID = ebook.ID
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if (a.Position <= 0) != (b.Position <= 0) {
+			return b.Position <= 0
+		}
+		return a.Position < b.Position
+	})
+	for i, entry := range entries {
+		entry.Position = i + 1
+	}
+
+	if err := s.ebookFileRepository.UpdateSchedule(entries); err != nil {
+		return err
+	}
+	ebook.FileSchedule = entries
+	return nil
+}
+
+func (s *dripServiceImpl) NotifyUnlocked(now time.Time) (int, error) {
+	// Compras mais antigas que o prazo máximo já tiveram todos os arquivos liberados
+	purchases, err := s.ebookFileRepository.FindDripPurchases(now.AddDate(0, 0, -models.MaxReleaseAfterDays-1))
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, purchase := range purchases {
+		if purchase.IsExpired() {
+			continue
+		}
+
+		since := purchase.AccessStartedAt()
+		if purchase.DripNotifiedAt != nil {
+			since = *purchase.DripNotifiedAt
+		}
+
+		var unlocked []*models.ScheduledFile
+		for _, file := range purchase.ScheduledFiles() {
+			if file.ReleaseAfterDays > 0 && file.ReleaseAt.After(since) && !file.IsLocked(now) {
+				unlocked = append(unlocked, file)
+			}
+		}
+		if len(unlocked) == 0 {
+			continue
+		}
+
+		if err := s.ebookFileRepository.MarkDripNotified(purchase, now); err != nil {
+			log.Printf("Erro ao registrar aviso da compra %d: %v", purchase.ID, err)
+			continue
+		}
+		s.notifier.SendFilesUnlocked(purchase, unlocked)
+		notified++
+	}
+	return notified, nil
+}
+
+func (s *dripServiceImpl) StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.NotifyUnlocked(time.Now()); err != nil {
+				log.Printf("Erro ao avisar compradores sobre arquivos liberados: %v", err)
+			}
+		}
+	}()
+}
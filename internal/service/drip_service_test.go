@@ -0,0 +1,135 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockDripNotifier struct {
+	mock.Mock
+}
+
+func (m *MockDripNotifier) SendFilesUnlocked(purchase *models.Purchase, files []*models.ScheduledFile) {
+	m.Called(purchase, files)
+}
+
+func dripServiceEbook() *models.Ebook {
+	return &models.Ebook{
+		Model: gorm.Model{ID: 3},
+		Files: []*models.File{
+			{Model: gorm.Model{ID: 20}, OriginalName: "introducao.pdf"},
+			{Model: gorm.Model{ID: 21}, OriginalName: "capitulo-1.pdf"},
+			{Model: gorm.Model{ID: 22}, OriginalName: "capitulo-2.pdf"},
+		},
+		FileSchedule: []*models.EbookFile{
+			{EbookID: 3, FileID: 20, Position: 1},
+			{EbookID: 3, FileID: 21, Position: 2, ReleaseAfterDays: 2},
+			{EbookID: 3, FileID: 22, Position: 3, ReleaseAfterDays: 10},
+		},
+	}
+}
+
+func TestDripService_UpdateSchedule_RenumbersPositions(t *testing.T) {
+	repo := new(repoMocks.MockEbookFileRepository)
+	service := NewDripService(repo, new(MockDripNotifier))
+	ebook := dripServiceEbook()
+
+	repo.On("UpdateSchedule", mock.Anything).Return(nil)
+
+	err := service.UpdateSchedule(ebook, []*models.EbookFile{
+		{FileID: 20, Position: 5},
+		{FileID: 21, Position: 0, ReleaseAfterDays: 3},
+		{FileID: 22, Position: 2, ReleaseAfterDays: 7},
+	})
+
+	assert.NoError(t, err)
+	saved := repo.Calls[0].Arguments.Get(0).([]*models.EbookFile)
+	assert.Equal(t, uint(22), saved[0].FileID)
+	assert.Equal(t, 1, saved[0].Position)
+	assert.Equal(t, uint(20), saved[1].FileID)
+	assert.Equal(t, 2, saved[1].Position)
+	assert.Equal(t, uint(21), saved[2].FileID)
+	assert.Equal(t, 3, saved[2].Position)
+	for _, entry := range saved {
+		assert.Equal(t, uint(3), entry.EbookID)
+	}
+}
+
+func TestDripService_UpdateSchedule_RejectsInvalidEntries(t *testing.T) {
+	repo := new(repoMocks.MockEbookFileRepository)
+	service := NewDripService(repo, new(MockDripNotifier))
+
+	err := service.UpdateSchedule(dripServiceEbook(), []*models.EbookFile{{FileID: 99, Position: 1}})
+	assert.ErrorIs(t, err, models.ErrFileNotInEbook)
+
+	err = service.UpdateSchedule(dripServiceEbook(), []*models.EbookFile{{FileID: 20, Position: 1, ReleaseAfterDays: -1}})
+	assert.ErrorIs(t, err, models.ErrInvalidReleaseOffset)
+
+	repo.AssertNotCalled(t, "UpdateSchedule", mock.Anything)
+}
+
+func TestDripService_UpdateSchedule_KeepsReleasesWithinLinkExpiry(t *testing.T) {
+	repo := new(repoMocks.MockEbookFileRepository)
+	service := NewDripService(repo, new(MockDripNotifier))
+	repo.On("UpdateSchedule", mock.Anything).Return(nil)
+
+	// Link válido por 30 dias: o arquivo do dia 60 nunca seria baixado
+	ebook := dripServiceEbook()
+	ebook.DownloadPolicy = models.DownloadPolicy{ExpiryDays: 30}
+	err := service.UpdateSchedule(ebook, []*models.EbookFile{{FileID: 20, Position: 1, ReleaseAfterDays: 60}})
+	assert.ErrorIs(t, err, models.ErrReleaseAfterExpiry)
+	repo.AssertNotCalled(t, "UpdateSchedule", mock.Anything)
+
+	// Sem política, a compra não vence e o arquivo do dia 60 continua alcançável
+	ebook = dripServiceEbook()
+	assert.NoError(t, service.UpdateSchedule(ebook, []*models.EbookFile{{FileID: 20, Position: 1, ReleaseAfterDays: 60}}))
+
+	purchasedAt := time.Now().AddDate(0, 0, -61)
+	purchase := models.NewPurchase(ebook.ID, 2)
+	purchase.CreatedAt = purchasedAt
+	purchase.Ebook = *ebook
+	ebook.DownloadPolicy.Apply(purchase, purchasedAt)
+	assert.False(t, purchase.IsExpired())
+	_, err = purchase.FindScheduledFile(20, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestDripService_NotifyUnlocked_SendsOnlyNewlyUnlockedFiles(t *testing.T) {
+	repo := new(repoMocks.MockEbookFileRepository)
+	notifier := new(MockDripNotifier)
+	service := NewDripService(repo, notifier)
+	now := time.Now()
+
+	purchase := &models.Purchase{Ebook: *dripServiceEbook()}
+	purchase.ID = 7
+	purchase.CreatedAt = now.AddDate(0, 0, -3)
+	alreadyNotified := &models.Purchase{Ebook: *dripServiceEbook()}
+	alreadyNotified.ID = 8
+	alreadyNotified.CreatedAt = now.AddDate(0, 0, -4)
+	notifiedAt := now.AddDate(0, 0, -1)
+	alreadyNotified.DripNotifiedAt = &notifiedAt
+	expired := &models.Purchase{Ebook: *dripServiceEbook(), ExpiresAt: now.Add(-time.Hour)}
+	expired.ID = 9
+	expired.CreatedAt = now.AddDate(0, 0, -3)
+
+	repo.On("FindDripPurchases", mock.AnythingOfType("time.Time")).
+		Return([]*models.Purchase{purchase, alreadyNotified, expired}, nil)
+	repo.On("MarkDripNotified", purchase, now).Return(nil)
+	notifier.On("SendFilesUnlocked", purchase, mock.Anything).Return()
+
+	notified, err := service.NotifyUnlocked(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, notified)
+	files := notifier.Calls[0].Arguments.Get(1).([]*models.ScheduledFile)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "capitulo-1.pdf", files[0].OriginalName)
+	repo.AssertNotCalled(t, "MarkDripNotified", alreadyNotified, now)
+	repo.AssertNotCalled(t, "MarkDripNotified", expired, now)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+)
+
+// DunningNotifier envia os lembretes de pagamento pendente ao criador
+type DunningNotifier interface {
+	SendDunningReminder(name, email string, daysLeft int, readOnly bool)
+}
+
+// DunningService controla o período de tolerância e os lembretes enviados
+// quando a cobrança da assinatura falha.
+type DunningService interface {
+	HandlePaymentFailed(customerID string, actor models.SubscriptionActor) error
+	HandleInvoicePaid(customerID string, actor models.SubscriptionActor) error
+	SendDueReminders() (int, error)
+	StartReminderWorker(interval time.Duration)
+}
+
+type dunningServiceImpl struct {
+	subscriptionService SubscriptionService
+	notifier            DunningNotifier
+	gracePeriod         time.Duration
+	reminderDays        []int
+}
+
+func NewDunningService(
+	subscriptionService SubscriptionService,
+	notifier DunningNotifier,
+	graceDays int,
+	reminderDays []int,
+) DunningService {
+	return &dunningServiceImpl{
+		subscriptionService: subscriptionService,
+		notifier:            notifier,
+		gracePeriod:         time.Duration(graceDays) * 24 * time.Hour,
+		reminderDays:        reminderDays,
+	}
+}
+
+func (s *dunningServiceImpl) HandlePaymentFailed(customerID string, actor models.SubscriptionActor) error {
+	subscription, err := s.findSubscription(customerID)
+	if err != nil || subscription == nil {
+		return err
+	}
+
+	if err := s.subscriptionService.MarkPaymentFailed(subscription, s.gracePeriod, actor); err != nil {
+		return err
+	}
+
+	log.Printf("Pagamento da assinatura %d falhou. Tolerância até %s", subscription.ID, subscription.GracePeriodEndsAt.Format("02/01/2006"))
+	return nil
+}
+
+func (s *dunningServiceImpl) HandleInvoicePaid(customerID string, actor models.SubscriptionActor) error {
+	subscription, err := s.findSubscription(customerID)
+	if err != nil || subscription == nil {
+		return err
+	}
+
+	if !subscription.IsPastDue() {
+		return nil
+	}
+
+	if err := s.subscriptionService.MarkPaymentRecovered(subscription, actor); err != nil {
+		return err
+	}
+
+	log.Printf("Pagamento da assinatura %d regularizado", subscription.ID)
+	return nil
+}
+
+// SendDueReminders envia, para cada assinatura com pagamento pendente, o lembrete
+// mais recente do cronograma que ainda não foi enviado. Retorna quantos foram enviados.
+func (s *dunningServiceImpl) SendDueReminders() (int, error) {
+	subscriptions, err := s.subscriptionService.FindPastDue()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, subscription := range subscriptions {
+		due := s.remindersDue(subscription)
+		if due <= subscription.DunningRemindersSent {
+			continue
+		}
+
+		if subscription.User.Email == "" {
+			log.Printf("Assinatura %d sem e-mail do usuário para lembrete de pagamento", subscription.ID)
+			continue
+		}
+
+		s.notifier.SendDunningReminder(subscription.User.Username, subscription.User.Email, subscription.DaysLeftInGracePeriod(), subscription.IsReadOnly())
+
+		if err := s.subscriptionService.RecordDunningReminder(subscription, due); err != nil {
+			log.Printf("Erro ao registrar lembrete da assinatura %d: %v", subscription.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (s *dunningServiceImpl) StartReminderWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.SendDueReminders(); err != nil {
+				log.Printf("Erro ao enviar lembretes de pagamento: %v", err)
+			}
+		}
+	}()
+}
+
+// remindersDue retorna quantos lembretes do cronograma já deveriam ter sido enviados
+func (s *dunningServiceImpl) remindersDue(subscription *models.Subscription) int {
+	if subscription.PaymentFailedAt == nil {
+		return 0
+	}
+
+	elapsedDays := int(time.Since(*subscription.PaymentFailedAt).Hours() / 24)
+	due := 0
+	for _, day := range s.reminderDays {
+		if day <= elapsedDays {
+			due++
+		}
+	}
+	return due
+}
+
+func (s *dunningServiceImpl) findSubscription(customerID string) (*models.Subscription, error) {
+	if customerID == "" {
+		return nil, errors.New("ID do cliente é obrigatório")
+	}
+
+	subscription, err := s.subscriptionService.FindByStripeCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		// Faturas de clientes sem assinatura na plataforma são ignoradas
+		log.Printf("Nenhuma assinatura encontrada para o cliente %s", customerID)
+		return nil, nil
+	}
+
+	return subscription, nil
+}
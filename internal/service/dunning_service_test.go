@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDunningNotifier struct {
+	mock.Mock
+}
+
+func (m *MockDunningNotifier) SendDunningReminder(name, email string, daysLeft int, readOnly bool) {
+	m.Called(name, email, daysLeft, readOnly)
+}
+
+func pastDueSubscription(failedDaysAgo int, graceDays int) *models.Subscription {
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	subscription.Activate("cus_1", "sub_1")
+	subscription.MarkPaymentFailed(time.Duration(graceDays) * 24 * time.Hour)
+
+	failedAt := time.Now().AddDate(0, 0, -failedDaysAgo)
+	graceEnd := failedAt.AddDate(0, 0, graceDays)
+	subscription.PaymentFailedAt = &failedAt
+	subscription.GracePeriodEndsAt = &graceEnd
+	subscription.User = models.User{Username: "Criador", Email: "criador@mail.com"}
+	return subscription
+}
+
+func TestDunningService_HandlePaymentFailed_StartsGracePeriod(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	subscription.Activate("cus_1", "sub_1")
+
+	mockRepo.On("FindByStripeCustomerID", "cus_1").Return(subscription, nil)
+	mockRepo.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+
+	service := NewDunningService(NewSubscriptionService(mockRepo, nil, nil), new(MockDunningNotifier), 7, []int{0, 3})
+	err := service.HandlePaymentFailed("cus_1", models.GatewayActor("evt_1"))
+
+	assert.NoError(t, err)
+	assert.True(t, subscription.IsInGracePeriod())
+	assert.True(t, subscription.IsSubscribed())
+	assert.Equal(t, "grace", subscription.GetSubscriptionStatus())
+}
+
+func TestDunningService_HandleInvoicePaid_RestoresAccess(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	subscription := pastDueSubscription(10, 7)
+
+	mockRepo.On("FindByStripeCustomerID", "cus_1").Return(subscription, nil)
+	mockRepo.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+
+	service := NewDunningService(NewSubscriptionService(mockRepo, nil, nil), new(MockDunningNotifier), 7, []int{0, 3})
+	assert.True(t, subscription.IsReadOnly())
+
+	err := service.HandleInvoicePaid("cus_1", models.GatewayActor("evt_2"))
+
+	assert.NoError(t, err)
+	assert.False(t, subscription.IsPastDue())
+	assert.True(t, subscription.IsSubscribed())
+}
+
+func TestDunningService_HandleInvoicePaid_IgnoresUnknownCustomer(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	mockRepo.On("FindByStripeCustomerID", "cus_x").Return(nil, nil)
+
+	service := NewDunningService(NewSubscriptionService(mockRepo, nil, nil), new(MockDunningNotifier), 7, []int{0})
+
+	assert.NoError(t, service.HandleInvoicePaid("cus_x", models.GatewayActor("evt_3")))
+	mockRepo.AssertNotCalled(t, "SaveWithEvent", mock.Anything, mock.Anything)
+}
+
+func TestDunningService_SendDueReminders_FollowsSchedule(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	notifier := new(MockDunningNotifier)
+
+	inGrace := pastDueSubscription(4, 7)
+	inGrace.DunningRemindersSent = 1
+	alreadyNotified := pastDueSubscription(1, 7)
+	alreadyNotified.DunningRemindersSent = 1
+	readOnly := pastDueSubscription(8, 7)
+	readOnly.DunningRemindersSent = 2
+
+	mockRepo.On("FindPastDue").Return([]*models.Subscription{inGrace, alreadyNotified, readOnly}, nil)
+	mockRepo.On("SaveWithEvent", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+	notifier.On("SendDunningReminder", "Criador", "criador@mail.com", 3, false).Return()
+	notifier.On("SendDunningReminder", "Criador", "criador@mail.com", 0, true).Return()
+
+	service := NewDunningService(NewSubscriptionService(mockRepo, nil, nil), notifier, 7, []int{0, 3, 7})
+	sent, err := service.SendDueReminders()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 2, inGrace.DunningRemindersSent)
+	assert.Equal(t, 1, alreadyNotified.DunningRemindersSent)
+	assert.Equal(t, 3, readOnly.DunningRemindersSent)
+	notifier.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+// LaunchNotifier entrega os links de download das compras da pré-venda
+type LaunchNotifier interface {
+	SendLinkToDownload(purchases []*models.Purchase)
+}
+
+// EbookLaunchService publica os ebooks agendados e encerra pré-vendas
+type EbookLaunchService interface {
+	// PublishDue liga e desliga os ebooks cujas datas agendadas chegaram
+	PublishDue(now time.Time) (int, error)
+	// Release lança o ebook em pré-venda e envia o download a quem já comprou
+	Release(ebook *models.Ebook) (int, error)
+	StartWorker(interval time.Duration)
+}
+
+type ebookLaunchServiceImpl struct {
+	launchRepository repository.EbookLaunchRepository
+	notifier         LaunchNotifier
+}
+
+func NewEbookLaunchService(launchRepository repository.EbookLaunchRepository, notifier LaunchNotifier) EbookLaunchService {
+	return &ebookLaunchServiceImpl{
+		launchRepository: launchRepository,
+		notifier:         notifier,
+	}
+}
+
+func (s *ebookLaunchServiceImpl) PublishDue(now time.Time) (int, error) {
+	ebooks, err := s.launchRepository.FindDue(now)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, ebook := range ebooks {
+		if ebook.ApplySchedule(now) {
+			changed++
+		}
+		// As datas vencidas são descartadas mesmo quando o status já era o esperado
+		if err := s.launchRepository.UpdateSchedule(ebook); err != nil {
+			log.Printf("Erro ao aplicar publicação agendada do ebook %d: %v", ebook.ID, err)
+		}
+	}
+	return changed, nil
+}
+
+func (s *ebookLaunchServiceImpl) Release(ebook *models.Ebook) (int, error) {
+	now := time.Now()
+	if err := ebook.Release(now); err != nil {
+		return 0, err
+	}
+
+	purchases, err := s.launchRepository.FindAwaitingRelease(ebook.ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, purchase := range purchases {
+		purchase.Release(now)
+	}
+
+	if err := s.launchRepository.Release(ebook, purchases); err != nil {
+		return 0, err
+	}
+
+	if len(purchases) > 0 {
+		go s.notifier.SendLinkToDownload(purchases)
+	}
+	log.Printf("Pré-venda do ebook %d liberada para %d compradores", ebook.ID, len(purchases))
+	return len(purchases), nil
+}
+
+func (s *ebookLaunchServiceImpl) StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.PublishDue(time.Now()); err != nil {
+				log.Printf("Erro ao publicar ebooks agendados: %v", err)
+			}
+		}
+	}()
+}
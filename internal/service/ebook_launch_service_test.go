@@ -0,0 +1,75 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type launchNotifierMock struct {
+	wg        sync.WaitGroup
+	delivered []*models.Purchase
+}
+
+func (n *launchNotifierMock) SendLinkToDownload(purchases []*models.Purchase) {
+	n.delivered = purchases
+	n.wg.Done()
+}
+
+func TestEbookLaunchService_PublishDue(t *testing.T) {
+	repo := new(repoMocks.MockEbookLaunchRepository)
+	service := NewEbookLaunchService(repo, &launchNotifierMock{})
+
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	scheduled := &models.Ebook{PublishAt: &due}
+	expired := &models.Ebook{Status: true, UnpublishAt: &due}
+	repo.On("FindDue", now).Return([]*models.Ebook{scheduled, expired}, nil)
+	repo.On("UpdateSchedule", mock.Anything).Return(nil)
+
+	changed, err := service.PublishDue(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	assert.True(t, scheduled.Status)
+	assert.False(t, expired.Status)
+	repo.AssertNumberOfCalls(t, "UpdateSchedule", 2)
+}
+
+func TestEbookLaunchService_Release(t *testing.T) {
+	repo := new(repoMocks.MockEbookLaunchRepository)
+	notifier := &launchNotifierMock{}
+	service := NewEbookLaunchService(repo, notifier)
+
+	ebook := &models.Ebook{PreSale: true, Files: []*models.File{{Name: "livro.pdf"}}}
+	ebook.ID = 4
+	purchase := models.NewPurchase(4, 9)
+	purchase.AwaitingRelease = true
+	repo.On("FindAwaitingRelease", uint(4)).Return([]*models.Purchase{purchase}, nil)
+	repo.On("Release", ebook, []*models.Purchase{purchase}).Return(nil)
+
+	notifier.wg.Add(1)
+	delivered, err := service.Release(ebook)
+	notifier.wg.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.False(t, ebook.PreSale)
+	assert.False(t, purchase.AwaitingRelease)
+	assert.Equal(t, []*models.Purchase{purchase}, notifier.delivered)
+}
+
+func TestEbookLaunchService_ReleaseWithoutFiles(t *testing.T) {
+	repo := new(repoMocks.MockEbookLaunchRepository)
+	service := NewEbookLaunchService(repo, &launchNotifierMock{})
+
+	_, err := service.Release(&models.Ebook{PreSale: true})
+
+	assert.ErrorIs(t, err, models.ErrReleaseWithoutFiles)
+	repo.AssertNotCalled(t, "FindAwaitingRelease", mock.Anything)
+}
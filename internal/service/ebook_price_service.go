@@ -0,0 +1,80 @@
+package service
+
+import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+// EbookPriceService gerencia os preços promocionais agendados de cada ebook
+type EbookPriceService interface {
+	ListForEbook(ebookID uint) ([]*models.EbookPricePeriod, error)
+	FindByID(id uint) (*models.EbookPricePeriod, error)
+	// Schedule agenda um preço promocional; períodos do mesmo ebook não podem se sobrepor
+	Schedule(ebook *models.Ebook, label string, price money.Money, startsAt, endsAt time.Time) (*models.EbookPricePeriod, error)
+	// Cancel remove a promoção. As vendas feitas nela continuam identificadas nos pedidos.
+	Cancel(period *models.EbookPricePeriod) error
+	// SalesByPrice mostra quantas vendas pagas o ebook teve em cada preço
+	SalesByPrice(ebookID uint) ([]models.PriceSales, error)
+}
+
+type ebookPriceServiceImpl struct {
+	priceRepository repository.EbookPriceRepository
+}
+
+func NewEbookPriceService(priceRepository repository.EbookPriceRepository) EbookPriceService {
+	return &ebookPriceServiceImpl{priceRepository: priceRepository}
+}
+
+func (s *ebookPriceServiceImpl) ListForEbook(ebookID uint) ([]*models.EbookPricePeriod, error) {
+	return s.priceRepository.FindByEbook(ebookID)
+}
+
+func (s *ebookPriceServiceImpl) FindByID(id uint) (*models.EbookPricePeriod, error) {
+	period, err := s.priceRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return nil, models.ErrPricePeriodNotFound
+	}
+	return period, nil
+}
+
+func (s *ebookPriceServiceImpl) Schedule(ebook *models.Ebook, label string, price money.Money, startsAt, endsAt time.Time) (*models.EbookPricePeriod, error) {
+	// A sobreposição é verificada contra os períodos gravados, não os carregados com o ebook
+	periods, err := s.priceRepository.FindByEbook(ebook.ID)
+	if err != nil {
+		return nil, err
+	}
+	ebook.PricePeriods = periods
+
+	period, err := models.NewEbookPricePeriod(ebook, label, price, startsAt, endsAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.priceRepository.Create(period); err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+func (s *ebookPriceServiceImpl) Cancel(period *models.EbookPricePeriod) error {
+	return s.priceRepository.Delete(period.ID)
+}
+
+func (s *ebookPriceServiceImpl) SalesByPrice(ebookID uint) ([]models.PriceSales, error) {
+	sales, err := s.priceRepository.SalesByPrice(ebookID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sales {
+		if sales[i].PricePeriodID == nil {
+			sales[i].Label = "Preço do ebook"
+		}
+	}
+	return sales, nil
+}
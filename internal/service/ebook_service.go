@@ -21,12 +21,14 @@ type EbookService interface {
 type EbookServiceImpl struct {
 	ebookRepository repository.EbookRepository
 	s3Storage       storage.S3Storage
+	planLimiter     PlanLimiter
 }
 
-func NewEbookService(s3Storage storage.S3Storage) EbookService {
+func NewEbookService(s3Storage storage.S3Storage, planLimiter PlanLimiter) EbookService {
 	return &EbookServiceImpl{
 		ebookRepository: repository.NewGormEbookRepository(database.DB),
 		s3Storage:       s3Storage,
+		planLimiter:     planLimiter,
 	}
 }
 
@@ -79,6 +81,11 @@ func (s *EbookServiceImpl) Update(ebook *models.Ebook) error {
 }
 
 func (s *EbookServiceImpl) Create(ebook *models.Ebook) error {
+	if s.planLimiter != nil {
+		if err := s.planLimiter.CheckLimit(ebook.CreatorID, models.ResourceEbooks, 1); err != nil {
+			return err
+		}
+	}
 	return s.ebookRepository.Create(ebook)
 }
 
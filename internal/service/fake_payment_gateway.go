@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+// FakeTransfer é uma transferência registrada pelo FakePaymentGateway
+type FakeTransfer struct {
+	ID        string
+	AccountID string
+	Amount    int64
+	Currency  money.Currency
+	Reference string
+}
+
+// FakePaymentGateway é um gateway em memória para desenvolvimento local e testes:
+// as contas recebedoras ficam prontas assim que o cadastro é aberto e as transferências
+// são apenas registradas. Defina FailTransfers para simular recusas do gateway e
+// Fees para informar a taxa de cada movimentação de saldo.
+type FakePaymentGateway struct {
+	FailTransfers bool
+	Fees          map[string]int64
+
+	mu        sync.Mutex
+	sequence  int
+	accounts  map[string]string
+	sessions  map[string]models.RecurringCheckout
+	charges   map[string]string
+	Transfers []FakeTransfer
+}
+
+func NewFakePaymentGateway() *FakePaymentGateway {
+	return &FakePaymentGateway{
+		Fees:     make(map[string]int64),
+		accounts: make(map[string]string),
+		sessions: make(map[string]models.RecurringCheckout),
+		charges:  make(map[string]string),
+	}
+}
+
+func (f *FakePaymentGateway) nextID(prefix string) string {
+	f.sequence++
+	return fmt.Sprintf("%s_fake_%d", prefix, f.sequence)
+}
+
+func (f *FakePaymentGateway) CreateCustomer(email, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nextID("cus"), nil
+}
+
+func (f *FakePaymentGateway) CreateSubscription(customerID, priceID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nextID("sub"), nil
+}
+
+func (f *FakePaymentGateway) CancelSubscription(subscriptionID string) error {
+	return nil
+}
+
+func (f *FakePaymentGateway) UpdateSubscriptionPrice(subscriptionID, priceID string) error {
+	return nil
+}
+
+func (f *FakePaymentGateway) ScheduleSubscriptionCancellation(subscriptionID string) (time.Time, error) {
+	return time.Now().AddDate(0, 1, 0), nil
+}
+
+func (f *FakePaymentGateway) ResumeSubscription(subscriptionID string) error {
+	return nil
+}
+
+func (f *FakePaymentGateway) ListInvoices(customerID string) ([]*models.Invoice, error) {
+	return []*models.Invoice{}, nil
+}
+
+func (f *FakePaymentGateway) CreatePortalSession(customerID, returnURL string) (string, error) {
+	return returnURL, nil
+}
+
+func (f *FakePaymentGateway) ChargeSavedPaymentMethod(paymentID string, amount int64, currency money.Currency, description, idempotencyKey string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id, ok := f.charges[idempotencyKey]; ok {
+		return id, nil
+	}
+	id := f.nextID("pi")
+	f.charges[idempotencyKey] = id
+	return id, nil
+}
+
+func (f *FakePaymentGateway) CreateConnectedAccount(email string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.nextID("acct")
+	f.accounts[id] = email
+	return id, nil
+}
+
+// CreateAccountLink devolve a própria URL de retorno, como se o cadastro tivesse sido concluído
+func (f *FakePaymentGateway) CreateAccountLink(accountID, refreshURL, returnURL string) (string, error) {
+	if _, err := f.PayoutsEnabled(accountID); err != nil {
+		return "", err
+	}
+	return returnURL, nil
+}
+
+func (f *FakePaymentGateway) PayoutsEnabled(accountID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.accounts[accountID]; !ok {
+		return false, errors.New("conta recebedora não encontrada")
+	}
+	return true, nil
+}
+
+func (f *FakePaymentGateway) Transfer(accountID string, amount int64, currency money.Currency, reference string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.accounts[accountID]; !ok {
+		return "", errors.New("conta recebedora não encontrada")
+	}
+	if f.FailTransfers {
+		return "", errors.New("transferência recusada")
+	}
+
+	transfer := FakeTransfer{
+		ID:        f.nextID("tr"),
+		AccountID: accountID,
+		Amount:    amount,
+		Currency:  currency,
+		Reference: reference,
+	}
+	f.Transfers = append(f.Transfers, transfer)
+	return transfer.ID, nil
+}
+
+// CreateRecurringCheckout devolve a URL de sucesso, como se o pagamento tivesse sido aprovado
+func (f *FakePaymentGateway) CreateRecurringCheckout(checkout models.RecurringCheckout) (string, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.nextID("cs")
+	f.sessions[id] = checkout
+	return id, strings.ReplaceAll(checkout.SuccessURL, "{CHECKOUT_SESSION_ID}", id), nil
+}
+
+func (f *FakePaymentGateway) GetRecurringCheckout(sessionID string) (*models.RecurringCheckoutResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	checkout, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, errors.New("sessão de checkout não encontrada")
+	}
+
+	periodEnd := time.Now().AddDate(0, 1, 0)
+	if checkout.Interval == models.MembershipIntervalYear {
+		periodEnd = time.Now().AddDate(1, 0, 0)
+	}
+	return &models.RecurringCheckoutResult{
+		Paid:           true,
+		SubscriptionID: "sub_" + sessionID,
+		PeriodEnd:      periodEnd,
+	}, nil
+}
+
+func (f *FakePaymentGateway) BalanceTransactionFee(balanceTransactionID string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fee, ok := f.Fees[balanceTransactionID]
+	if !ok {
+		return 0, errors.New("movimentação não encontrada")
+	}
+	return fee, nil
+}
+
+// CreateCoupon apenas gera o ID do cupom
+func (f *FakePaymentGateway) CreateCoupon(amountOff int64, currency money.Currency, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nextID("coupon"), nil
+}
@@ -30,12 +30,14 @@ type FileService interface {
 type fileService struct {
 	fileRepository repository.FileRepository
 	s3Storage      storage.S3Storage
+	planLimiter    PlanLimiter
 }
 
-func NewFileService(fileRepository repository.FileRepository, s3Storage storage.S3Storage) FileService {
+func NewFileService(fileRepository repository.FileRepository, s3Storage storage.S3Storage, planLimiter PlanLimiter) FileService {
 	return &fileService{
 		fileRepository: fileRepository,
 		s3Storage:      s3Storage,
+		planLimiter:    planLimiter,
 	}
 }
 
@@ -45,6 +47,13 @@ func (s *fileService) UploadFile(file *multipart.FileHeader, description string,
 		return nil, err
 	}
 
+	// Verificar espaço disponível no plano
+	if s.planLimiter != nil {
+		if err := s.planLimiter.CheckLimit(creatorID, models.ResourceStorage, file.Size); err != nil {
+			return nil, err
+		}
+	}
+
 	// Gerar nome único para o arquivo
 	originalName := file.Filename
 	fileExt := filepath.Ext(originalName)
@@ -107,7 +107,7 @@ func TestNewFileService(t *testing.T) {
 	mockStorage := &MockS3Storage{}
 
 	// Act
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	// Assert
 	assert.NotNil(t, fileService)
@@ -117,7 +117,7 @@ func TestFileService_GetFilesByCreator(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	creatorID := uint(1)
 	expectedFiles := []*models.File{
@@ -142,7 +142,7 @@ func TestFileService_GetFileByID(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	fileID := uint(1)
 	expectedFile := &models.File{Name: "test.pdf"}
@@ -162,7 +162,7 @@ func TestFileService_UpdateFile(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	fileID := uint(1)
 	description := "Updated description"
@@ -184,7 +184,7 @@ func TestFileService_DeleteFile(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	fileID := uint(1)
 	existingFile := &models.File{S3Key: "files/1/test.pdf"}
@@ -206,7 +206,7 @@ func TestFileService_GetFilesByType(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	creatorID := uint(1)
 	fileType := "pdf"
@@ -230,7 +230,7 @@ func TestFileService_GetActiveByCreator(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	creatorID := uint(1)
 	expectedFiles := []*models.File{
@@ -319,7 +319,7 @@ func TestFileService_getFileType(t *testing.T) {
 	// Arrange
 	mockRepo := &MockFileRepository{}
 	mockStorage := &MockS3Storage{}
-	fileService := service.NewFileService(mockRepo, mockStorage)
+	fileService := service.NewFileService(mockRepo, mockStorage, nil)
 
 	tests := []struct {
 		name     string
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/storage"
+)
+
+// FileVersionNotifier avisa os compradores de que há versão nova dos arquivos do ebook
+type FileVersionNotifier interface {
+	SendNewVersionAvailable(purchase *models.Purchase, files []*models.File)
+}
+
+// FileVersionService publica versões novas dos arquivos e avisa quem já comprou
+type FileVersionService interface {
+	// Publish envia o novo conteúdo do arquivo e arquiva a versão anterior
+	Publish(file *models.File, upload *multipart.FileHeader, changelog string) error
+	Versions(file *models.File) ([]*models.FileVersion, error)
+	// NotifyBuyers envia "nova versão disponível" a todos os compradores do ebook
+	// com os arquivos atualizados desde o último aviso
+	NotifyBuyers(ebook *models.Ebook) (int, error)
+}
+
+type fileVersionServiceImpl struct {
+	versionRepository repository.FileVersionRepository
+	fileService       FileService
+	s3Storage         storage.S3Storage
+	planLimiter       PlanLimiter
+	notifier          FileVersionNotifier
+}
+
+func NewFileVersionService(
+	versionRepository repository.FileVersionRepository,
+	fileService FileService,
+	s3Storage storage.S3Storage,
+	planLimiter PlanLimiter,
+	notifier FileVersionNotifier,
+) FileVersionService {
+	return &fileVersionServiceImpl{
+		versionRepository: versionRepository,
+		fileService:       fileService,
+		s3Storage:         s3Storage,
+		planLimiter:       planLimiter,
+		notifier:          notifier,
+	}
+}
+
+func (s *fileVersionServiceImpl) Publish(file *models.File, upload *multipart.FileHeader, changelog string) error {
+	if strings.TrimSpace(changelog) == "" {
+		return models.ErrChangelogRequired
+	}
+	if err := s.fileService.ValidateFile(upload); err != nil {
+		return err
+	}
+
+	// As versões arquivadas continuam ocupando espaço no plano
+	if s.planLimiter != nil {
+		if err := s.planLimiter.CheckLimit(file.CreatorID, models.ResourceStorage, upload.Size); err != nil {
+			return err
+		}
+	}
+
+	ext := filepath.Ext(upload.Filename)
+	version := file.CurrentVersion() + 1
+	fileName := fmt.Sprintf("%s-v%d%s", strings.TrimSuffix(upload.Filename, ext), version, ext)
+	s3Key := fmt.Sprintf("files/%d/%d/%s", file.CreatorID, file.ID, fileName)
+	s3URL, err := s.s3Storage.UploadFile(upload, s3Key)
+	if err != nil {
+		return fmt.Errorf("erro ao fazer upload para S3: %w", err)
+	}
+
+	content := models.NewFile(fileName, upload.Filename, file.Description, s.fileService.GetFileType(ext), s3Key, s3URL, upload.Size, file.CreatorID)
+	archived, err := file.NewVersion(content, changelog, time.Now())
+	if err != nil {
+		s.s3Storage.DeleteFile(s3Key)
+		return err
+	}
+
+	if err := s.versionRepository.Publish(file, archived); err != nil {
+		s.s3Storage.DeleteFile(s3Key)
+		return err
+	}
+	return nil
+}
+
+func (s *fileVersionServiceImpl) Versions(file *models.File) ([]*models.FileVersion, error) {
+	return s.versionRepository.FindByFile(file.ID)
+}
+
+func (s *fileVersionServiceImpl) NotifyBuyers(ebook *models.Ebook) (int, error) {
+	files := ebook.UpdatedFiles()
+	if len(files) == 0 {
+		return 0, models.ErrNoNewVersion
+	}
+
+	purchases, err := s.versionRepository.FindBuyers(ebook.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Cada comprador recebe só os arquivos atualizados depois da sua compra e
+	// ainda não baixados
+	recipients := make(map[*models.Purchase][]*models.File)
+	for _, purchase := range purchases {
+		if purchase.IsExpired() {
+			continue
+		}
+		for _, file := range files {
+			if purchase.IsUpdateDownload(file) {
+				recipients[purchase] = append(recipients[purchase], file)
+			}
+		}
+	}
+
+	if err := s.versionRepository.MarkEbookNotified(ebook, time.Now()); err != nil {
+		return 0, err
+	}
+
+	go func() {
+		for purchase, updated := range recipients {
+			s.notifier.SendNewVersionAvailable(purchase, updated)
+		}
+		log.Printf("Aviso de nova versão do ebook %d enviado a %d compradores", ebook.ID, len(recipients))
+	}()
+	return len(recipients), nil
+}
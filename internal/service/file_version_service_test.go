@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/internal/service"
+	serviceMocks "github.com/anglesson/simple-web-server/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockFileVersionNotifier struct {
+	mock.Mock
+}
+
+func (m *MockFileVersionNotifier) SendNewVersionAvailable(purchase *models.Purchase, files []*models.File) {
+	m.Called(purchase, files)
+}
+
+func TestFileVersionService_Publish_ArchivesPreviousVersion(t *testing.T) {
+	versionRepo := new(repoMocks.MockFileVersionRepository)
+	fileService := new(serviceMocks.MockFileService)
+	storage := new(MockS3Storage)
+	versionService := service.NewFileVersionService(versionRepo, fileService, storage, nil, new(MockFileVersionNotifier))
+
+	file := models.NewFile("livro-a1.pdf", "livro.pdf", "Livro", "pdf", "files/1/livro-a1.pdf", "", 100, 1)
+	file.ID = 5
+	upload := &multipart.FileHeader{Filename: "livro.pdf", Size: 150}
+	fileService.On("ValidateFile", upload).Return(nil)
+	fileService.On("GetFileType", ".pdf").Return("pdf")
+	storage.On("UploadFile", upload, "files/1/5/livro-v2.pdf").Return("https://s3/livro-v2.pdf", nil)
+	versionRepo.On("Publish", file, mock.AnythingOfType("*models.FileVersion")).Return(nil)
+
+	err := versionService.Publish(file, upload, "Capítulo 12 incluído")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, file.Version)
+	assert.Equal(t, "files/1/5/livro-v2.pdf", file.S3Key)
+	archived := versionRepo.Calls[0].Arguments.Get(1).(*models.FileVersion)
+	assert.Equal(t, 1, archived.Version)
+	assert.Equal(t, "files/1/livro-a1.pdf", archived.S3Key)
+}
+
+func TestFileVersionService_Publish_RequiresChangelog(t *testing.T) {
+	versionRepo := new(repoMocks.MockFileVersionRepository)
+	versionService := service.NewFileVersionService(versionRepo, new(serviceMocks.MockFileService), new(MockS3Storage), nil, new(MockFileVersionNotifier))
+
+	err := versionService.Publish(&models.File{Version: 1}, &multipart.FileHeader{Filename: "livro.pdf"}, "")
+
+	assert.ErrorIs(t, err, models.ErrChangelogRequired)
+	versionRepo.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestFileVersionService_NotifyBuyers_OnlyBuyersWithPendingUpdates(t *testing.T) {
+	versionRepo := new(repoMocks.MockFileVersionRepository)
+	notifier := new(MockFileVersionNotifier)
+	versionService := service.NewFileVersionService(versionRepo, new(serviceMocks.MockFileService), new(MockS3Storage), nil, notifier)
+
+	versionedAt := time.Now().Add(-time.Hour)
+	file := &models.File{Model: gorm.Model{ID: 2}, OriginalName: "livro.pdf", Version: 2, VersionedAt: &versionedAt}
+	ebook := &models.Ebook{Model: gorm.Model{ID: 3}, Files: []*models.File{file}}
+
+	buyer := &models.Purchase{Model: gorm.Model{ID: 1, CreatedAt: versionedAt.AddDate(0, 0, -5)}}
+	updated := &models.Purchase{Model: gorm.Model{ID: 2, CreatedAt: versionedAt.AddDate(0, 0, -5)},
+		Downloads: []models.DownloadLog{{FileID: 2, FileVersion: 2}}}
+	late := &models.Purchase{Model: gorm.Model{ID: 3, CreatedAt: versionedAt.Add(time.Minute)}}
+	expired := &models.Purchase{Model: gorm.Model{ID: 4, CreatedAt: versionedAt.AddDate(0, 0, -5)}, ExpiresAt: time.Now().Add(-time.Hour)}
+
+	versionRepo.On("FindBuyers", uint(3)).Return([]*models.Purchase{buyer, updated, late, expired}, nil)
+	versionRepo.On("MarkEbookNotified", ebook, mock.AnythingOfType("time.Time")).Return(nil)
+	notifier.On("SendNewVersionAvailable", buyer, []*models.File{file}).Return()
+
+	sent, err := versionService.NotifyBuyers(ebook)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	notifier.AssertExpectations(t)
+}
+
+func TestFileVersionService_NotifyBuyers_RequiresNewVersion(t *testing.T) {
+	versionRepo := new(repoMocks.MockFileVersionRepository)
+	versionService := service.NewFileVersionService(versionRepo, new(serviceMocks.MockFileService), new(MockS3Storage), nil, new(MockFileVersionNotifier))
+
+	_, err := versionService.NotifyBuyers(&models.Ebook{Files: []*models.File{{Version: 1}}})
+
+	assert.ErrorIs(t, err, models.ErrNoNewVersion)
+	versionRepo.AssertNotCalled(t, "FindBuyers", mock.Anything)
+}
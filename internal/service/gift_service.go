@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+// GiftNotifier envia o link de resgate a quem ganhou o presente e, depois do
+// resgate, os links de download
+type GiftNotifier interface {
+	SendGiftClaimLink(gift *models.Gift)
+	SendLinkToDownload(purchases []*models.Purchase)
+}
+
+// GiftInput são os dados do presente informados por quem paga
+type GiftInput struct {
+	RecipientName  string
+	RecipientEmail string
+	Message        string
+	SendAt         *time.Time
+}
+
+// GiftService conduz as compras feitas para presentear outra pessoa
+type GiftService interface {
+	// Register transforma o pedido pendente em presente
+	Register(order *models.Order, ebook *models.Ebook, input GiftInput) (*models.Gift, error)
+	FindByOrderID(orderID uint) (*models.Gift, error)
+	// FindByToken busca o presente do link de resgate, desde que o pedido esteja pago
+	FindByToken(token string) (*models.Gift, error)
+	// Deliver envia o link de resgate do presente quando a data de envio já chegou
+	Deliver(gift *models.Gift, now time.Time) error
+	SendDue(now time.Time) (int, error)
+	// Claim cria as compras do pedido em nome de quem recebeu o presente e envia os links de download
+	Claim(gift *models.Gift, recipient *models.Client) ([]*models.Purchase, error)
+	StartWorker(interval time.Duration)
+}
+
+var ErrGiftNotFound = errors.New("presente não encontrado")
+
+type giftServiceImpl struct {
+	giftRepository repository.GiftRepository
+	notifier       GiftNotifier
+	encrypter      utils.Encrypter
+}
+
+func NewGiftService(giftRepository repository.GiftRepository, notifier GiftNotifier) GiftService {
+	return &giftServiceImpl{
+		giftRepository: giftRepository,
+		notifier:       notifier,
+		encrypter:      utils.NewEncrypter(),
+	}
+}
+
+func (s *giftServiceImpl) Register(order *models.Order, ebook *models.Ebook, input GiftInput) (*models.Gift, error) {
+	if order == nil || order.ID == 0 || order.IsPaid() {
+		return nil, errors.New("pedido inválido para presente")
+	}
+
+	gift, err := models.NewGift(order, ebook, input.RecipientName, input.RecipientEmail, input.Message,
+		input.SendAt, s.encrypter.GenerateToken(24), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.giftRepository.Create(gift); err != nil {
+		return nil, err
+	}
+	order.IsGift = true
+	return gift, nil
+}
+
+func (s *giftServiceImpl) FindByOrderID(orderID uint) (*models.Gift, error) {
+	return s.giftRepository.FindByOrderID(orderID)
+}
+
+func (s *giftServiceImpl) FindByToken(token string) (*models.Gift, error) {
+	if token == "" {
+		return nil, ErrGiftNotFound
+	}
+
+	gift, err := s.giftRepository.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if gift == nil || !gift.Order.IsPaid() {
+		return nil, ErrGiftNotFound
+	}
+	return gift, nil
+}
+
+func (s *giftServiceImpl) Deliver(gift *models.Gift, now time.Time) error {
+	if !gift.Order.IsPaid() || !gift.IsDue(now) {
+		return nil
+	}
+
+	s.notifier.SendGiftClaimLink(gift)
+	gift.MarkSent(now)
+	return s.giftRepository.Update(gift)
+}
+
+func (s *giftServiceImpl) SendDue(now time.Time) (int, error) {
+	gifts, err := s.giftRepository.FindDue(now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, gift := range gifts {
+		if !gift.IsDue(now) {
+			continue
+		}
+		if err := s.Deliver(gift, now); err != nil {
+			log.Printf("Erro ao enviar link de resgate do presente %d: %v", gift.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *giftServiceImpl) Claim(gift *models.Gift, recipient *models.Client) ([]*models.Purchase, error) {
+	if !gift.Order.IsPaid() {
+		return nil, models.ErrGiftNotAvailable
+	}
+	if err := gift.Claim(recipient, time.Now()); err != nil {
+		return nil, err
+	}
+
+	purchases := orderPurchases(&gift.Order, recipient.ID)
+	if err := s.giftRepository.Claim(gift, purchases); err != nil {
+		return nil, err
+	}
+
+	go s.notifier.SendLinkToDownload(purchases)
+	return purchases, nil
+}
+
+func (s *giftServiceImpl) StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.SendDue(time.Now()); err != nil {
+				log.Printf("Erro ao enviar presentes agendados: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockGiftNotifier struct {
+	mock.Mock
+}
+
+func (m *MockGiftNotifier) SendGiftClaimLink(gift *models.Gift) {
+	m.Called(gift)
+}
+
+func (m *MockGiftNotifier) SendLinkToDownload(purchases []*models.Purchase) {
+	m.Called(purchases)
+}
+
+func paidGift(sendAt *time.Time) *models.Gift {
+	order := models.Order{Model: gorm.Model{ID: 10}, CreatorID: 1, ClientID: 2, EbookID: 3, Status: models.OrderStatusPaid}
+	order.Items = []models.OrderItem{{EbookID: 3}, {EbookID: 4}}
+	return &models.Gift{
+		Model:          gorm.Model{ID: 7},
+		OrderID:        10,
+		Order:          order,
+		EbookID:        3,
+		SenderID:       2,
+		RecipientName:  "Amiga",
+		RecipientEmail: "amiga@email.com",
+		SendAt:         sendAt,
+		Token:          "presente",
+	}
+}
+
+func TestGiftService_Register_MarksOrderAsGift(t *testing.T) {
+	giftRepo := new(repoMocks.MockGiftRepository)
+	service := NewGiftService(giftRepo, new(MockGiftNotifier))
+
+	order := models.NewOrder(1, 2, 3, 5000, 0, "BRL")
+	order.ID = 10
+	giftRepo.On("Create", mock.AnythingOfType("*models.Gift")).Return(nil)
+
+	gift, err := service.Register(order, &models.Ebook{Model: gorm.Model{ID: 3}}, GiftInput{
+		RecipientName:  "Amiga",
+		RecipientEmail: "amiga@email.com",
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, order.IsGift)
+	assert.Equal(t, uint(2), gift.SenderID)
+	assert.NotEmpty(t, gift.Token)
+	giftRepo.AssertExpectations(t)
+}
+
+func TestGiftService_SendDue_RespectsScheduledDate(t *testing.T) {
+	giftRepo := new(repoMocks.MockGiftRepository)
+	notifier := new(MockGiftNotifier)
+	service := NewGiftService(giftRepo, notifier)
+
+	now := time.Now()
+	tomorrow := now.Add(24 * time.Hour)
+	due := paidGift(nil)
+	scheduled := paidGift(&tomorrow)
+
+	giftRepo.On("FindDue", now).Return([]*models.Gift{due, scheduled}, nil)
+	giftRepo.On("Update", due).Return(nil)
+	notifier.On("SendGiftClaimLink", due).Return()
+
+	sent, err := service.SendDue(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.NotNil(t, due.SentAt)
+	assert.Nil(t, scheduled.SentAt)
+	notifier.AssertNotCalled(t, "SendGiftClaimLink", scheduled)
+}
+
+func TestGiftService_Claim_CreatesPurchasesForRecipient(t *testing.T) {
+	giftRepo := new(repoMocks.MockGiftRepository)
+	notifier := new(MockGiftNotifier)
+	service := NewGiftService(giftRepo, notifier)
+
+	gift := paidGift(nil)
+	recipient := &models.Client{Model: gorm.Model{ID: 8}, Name: "Amiga", CPF: "12345678901"}
+	giftRepo.On("Claim", gift, mock.AnythingOfType("[]*models.Purchase")).Return(nil)
+	notifier.On("SendLinkToDownload", mock.Anything).Return()
+
+	purchases, err := service.Claim(gift, recipient)
+
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 2)
+	for _, purchase := range purchases {
+		assert.Equal(t, uint(8), purchase.ClientID)
+	}
+	assert.Equal(t, uint(3), purchases[0].EbookID)
+	assert.Equal(t, uint(4), purchases[1].EbookID)
+	assert.True(t, gift.IsClaimed())
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGiftService_Claim_RejectsUnpaidOrClaimedGift(t *testing.T) {
+	service := NewGiftService(new(repoMocks.MockGiftRepository), new(MockGiftNotifier))
+	recipient := &models.Client{Model: gorm.Model{ID: 8}}
+
+	refunded := paidGift(nil)
+	refunded.Order.Status = models.OrderStatusRefunded
+	_, err := service.Claim(refunded, recipient)
+	assert.ErrorIs(t, err, models.ErrGiftNotAvailable)
+
+	claimed := paidGift(nil)
+	claimed.Claim(recipient, time.Now())
+	_, err = service.Claim(claimed, recipient)
+	assert.ErrorIs(t, err, models.ErrGiftAlreadyClaimed)
+}
+
+func TestGiftService_FindByToken_HidesUnpaidGift(t *testing.T) {
+	giftRepo := new(repoMocks.MockGiftRepository)
+	service := NewGiftService(giftRepo, new(MockGiftNotifier))
+
+	pending := paidGift(nil)
+	pending.Order.Status = models.OrderStatusPending
+	giftRepo.On("FindByToken", "presente").Return(pending, nil)
+
+	_, err := service.FindByToken("presente")
+	assert.ErrorIs(t, err, ErrGiftNotFound)
+}
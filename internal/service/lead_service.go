@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+// LeadNotifier envia a confirmação do e-mail e, depois dela, o link de download
+type LeadNotifier interface {
+	SendLeadConfirmation(lead *models.Lead)
+	SendLinkToDownload(purchases []*models.Purchase)
+}
+
+// LeadService entrega os ebooks gratuitos em troca do e-mail confirmado do leitor
+type LeadService interface {
+	// Subscribe registra o leitor como cliente e envia o e-mail de confirmação.
+	// Pedir de novo o mesmo ebook reenvia a confirmação pendente.
+	Subscribe(ebook *models.Ebook, name, email string, marketingConsent bool) (*models.Lead, error)
+	// Confirm confirma o e-mail e entrega o ebook. Confirmar de novo não gera outra entrega.
+	Confirm(token string) (*models.Lead, error)
+}
+
+var (
+	ErrLeadNotFound = errors.New("link de confirmação inválido")
+	ErrEbookNotFree = errors.New("este ebook não é gratuito")
+)
+
+type leadServiceImpl struct {
+	leadRepository   repository.LeadRepository
+	clientRepository repository.ClientRepository
+	notifier         LeadNotifier
+	encrypter        utils.Encrypter
+}
+
+func NewLeadService(
+	leadRepository repository.LeadRepository,
+	clientRepository repository.ClientRepository,
+	notifier LeadNotifier,
+) LeadService {
+	return &leadServiceImpl{
+		leadRepository:   leadRepository,
+		clientRepository: clientRepository,
+		notifier:         notifier,
+		encrypter:        utils.NewEncrypter(),
+	}
+}
+
+func (s *leadServiceImpl) Subscribe(ebook *models.Ebook, name, email string, marketingConsent bool) (*models.Lead, error) {
+	if ebook == nil || !ebook.Status || !ebook.IsFree() {
+		return nil, ErrEbookNotFree
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("nome é obrigatório")
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, errors.New("e-mail inválido")
+	}
+
+	client, err := s.clientRepository.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		// O vínculo com o criador só é criado quando o e-mail for confirmado
+		client = &models.Client{Name: name, Email: email}
+		if err := s.clientRepository.Save(client); err != nil {
+			return nil, err
+		}
+	}
+
+	lead, err := s.leadRepository.FindLatest(ebook.ID, client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if lead != nil && !lead.IsConfirmed() {
+		lead.MarketingConsent = lead.MarketingConsent || marketingConsent
+		if err := s.leadRepository.Update(lead); err != nil {
+			return nil, err
+		}
+	} else {
+		lead = models.NewLead(ebook, client, s.encrypter.GenerateToken(24), marketingConsent)
+		if err := s.leadRepository.Create(lead); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.notifier.SendLeadConfirmation(lead)
+	return lead, nil
+}
+
+func (s *leadServiceImpl) Confirm(token string) (*models.Lead, error) {
+	if token == "" {
+		return nil, ErrLeadNotFound
+	}
+
+	lead, err := s.leadRepository.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if lead == nil {
+		return nil, ErrLeadNotFound
+	}
+
+	if !lead.Confirm(time.Now()) {
+		return lead, nil
+	}
+
+	purchase := models.NewPurchase(lead.EbookID, lead.ClientID)
+	err = s.leadRepository.Confirm(lead, purchase)
+	if errors.Is(err, models.ErrLeadAlreadyConfirmed) {
+		// Outro clique no link confirmou primeiro e já enviou o download
+		return lead, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notifier.SendLinkToDownload([]*models.Purchase{purchase})
+	return lead, nil
+}
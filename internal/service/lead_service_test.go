@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockLeadNotifier struct {
+	mock.Mock
+}
+
+func (m *MockLeadNotifier) SendLeadConfirmation(lead *models.Lead) {
+	m.Called(lead)
+}
+
+func (m *MockLeadNotifier) SendLinkToDownload(purchases []*models.Purchase) {
+	m.Called(purchases)
+}
+
+func freeEbook() *models.Ebook {
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, CreatorID: 2, Title: "Ebook gratuito", Status: true}
+	ebook.SetPricing(models.PricingFree, money.New(0, money.BRL), money.Money{})
+	return ebook
+}
+
+func TestLeadService_Subscribe_CreatesClientAndSendsConfirmation(t *testing.T) {
+	leadRepo := new(repoMocks.MockLeadRepository)
+	clientRepo := new(repoMocks.MockClientRepository)
+	notifier := new(MockLeadNotifier)
+	service := NewLeadService(leadRepo, clientRepo, notifier)
+
+	clientRepo.On("FindByEmail", "leitor@email.com").Return(nil, nil)
+	clientRepo.On("Save", mock.MatchedBy(func(c *models.Client) bool {
+		return c.Email == "leitor@email.com" && c.CPF == ""
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Client).ID = 3
+	}).Return(nil)
+	leadRepo.On("FindLatest", uint(1), uint(3)).Return(nil, nil)
+	leadRepo.On("Create", mock.AnythingOfType("*models.Lead")).Return(nil)
+	notifier.On("SendLeadConfirmation", mock.AnythingOfType("*models.Lead")).Return()
+
+	lead, err := service.Subscribe(freeEbook(), "Leitor", " Leitor@Email.com ", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), lead.CreatorID)
+	assert.Equal(t, uint(3), lead.ClientID)
+	assert.True(t, lead.MarketingConsent)
+	assert.NotEmpty(t, lead.Token)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	clientRepo.AssertExpectations(t)
+	leadRepo.AssertExpectations(t)
+}
+
+func TestLeadService_Subscribe_ReusesPendingLead(t *testing.T) {
+	leadRepo := new(repoMocks.MockLeadRepository)
+	clientRepo := new(repoMocks.MockClientRepository)
+	notifier := new(MockLeadNotifier)
+	service := NewLeadService(leadRepo, clientRepo, notifier)
+
+	client := &models.Client{Model: gorm.Model{ID: 3}, Email: "leitor@email.com"}
+	pending := &models.Lead{Model: gorm.Model{ID: 9}, EbookID: 1, ClientID: 3, Token: "pendente"}
+	clientRepo.On("FindByEmail", "leitor@email.com").Return(client, nil)
+	leadRepo.On("FindLatest", uint(1), uint(3)).Return(pending, nil)
+	leadRepo.On("Update", pending).Return(nil)
+	notifier.On("SendLeadConfirmation", pending).Return()
+
+	lead, err := service.Subscribe(freeEbook(), "Leitor", "leitor@email.com", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pendente", lead.Token)
+	assert.True(t, lead.MarketingConsent)
+	leadRepo.AssertNotCalled(t, "Create", mock.Anything)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLeadService_Subscribe_RejectsPaidEbook(t *testing.T) {
+	service := NewLeadService(new(repoMocks.MockLeadRepository), new(repoMocks.MockClientRepository), new(MockLeadNotifier))
+
+	ebook := &models.Ebook{Model: gorm.Model{ID: 1}, Status: true, PricingMode: models.PricingFixed, Price: money.New(4900, money.BRL)}
+	_, err := service.Subscribe(ebook, "Leitor", "leitor@email.com", false)
+
+	assert.ErrorIs(t, err, ErrEbookNotFree)
+}
+
+func TestLeadService_Confirm_DeliversOnce(t *testing.T) {
+	leadRepo := new(repoMocks.MockLeadRepository)
+	notifier := new(MockLeadNotifier)
+	service := NewLeadService(leadRepo, new(repoMocks.MockClientRepository), notifier)
+
+	lead := models.NewLead(freeEbook(), &models.Client{Model: gorm.Model{ID: 3}}, "token", true)
+	leadRepo.On("FindByToken", "token").Return(lead, nil)
+	leadRepo.On("Confirm", lead, mock.MatchedBy(func(p *models.Purchase) bool {
+		return p.EbookID == 1 && p.ClientID == 3
+	})).Return(nil).Once()
+	notifier.On("SendLinkToDownload", mock.Anything).Return().Once()
+
+	confirmed, err := service.Confirm("token")
+	assert.NoError(t, err)
+	assert.True(t, confirmed.IsConfirmed())
+	assert.True(t, confirmed.Client.MarketingConsent)
+
+	_, err = service.Confirm("token")
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	leadRepo.AssertExpectations(t)
+}
+
+func TestLeadService_Confirm_ConcurrentConfirmationDoesNotResend(t *testing.T) {
+	leadRepo := new(repoMocks.MockLeadRepository)
+	notifier := new(MockLeadNotifier)
+	service := NewLeadService(leadRepo, new(repoMocks.MockClientRepository), notifier)
+
+	lead := models.NewLead(freeEbook(), &models.Client{Model: gorm.Model{ID: 3}}, "token", true)
+	leadRepo.On("FindByToken", "token").Return(lead, nil)
+	leadRepo.On("Confirm", lead, mock.Anything).Return(models.ErrLeadAlreadyConfirmed)
+
+	confirmed, err := service.Confirm("token")
+
+	assert.NoError(t, err)
+	assert.True(t, confirmed.IsConfirmed())
+	notifier.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
+}
+
+func TestLeadService_Confirm_InvalidToken(t *testing.T) {
+	leadRepo := new(repoMocks.MockLeadRepository)
+	service := NewLeadService(leadRepo, new(repoMocks.MockClientRepository), new(MockLeadNotifier))
+
+	leadRepo.On("FindByToken", "desconhecido").Return(nil, nil)
+
+	_, err := service.Confirm("desconhecido")
+	assert.ErrorIs(t, err, ErrLeadNotFound)
+}
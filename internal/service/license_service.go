@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+// LicenseNotifier envia ao gestor o link da página de gestão e, a cada licença
+// atribuída, o link de download para a pessoa
+type LicenseNotifier interface {
+	SendLicenseManagerLink(license *models.License)
+	SendLinkToDownload(purchases []*models.Purchase)
+}
+
+// SeatInput é a pessoa informada pelo gestor para receber uma licença
+type SeatInput struct {
+	Name  string
+	Email string
+}
+
+// LicenseService conduz as compras de licenças para equipe
+type LicenseService interface {
+	// Register transforma o pedido pendente de várias licenças em licença para equipe
+	Register(order *models.Order) (*models.License, error)
+	FindByOrderID(orderID uint) (*models.License, error)
+	// FindByToken busca a licença da página de gestão, desde que o pedido esteja pago
+	FindByToken(token string) (*models.License, error)
+	ListForEbook(ebookID uint) ([]*models.License, error)
+	// Deliver envia ao gestor o link da página de gestão depois do pagamento
+	Deliver(license *models.License, now time.Time) error
+	SendDue(now time.Time) (int, error)
+	// Assign cria uma compra para cada pessoa informada e envia os links de download
+	Assign(license *models.License, inputs []SeatInput) ([]*models.LicenseSeat, error)
+	// Reassign transfere uma licença ainda não baixada para outra pessoa
+	Reassign(license *models.License, seatID uint, input SeatInput) (*models.LicenseSeat, error)
+	StartWorker(interval time.Duration)
+}
+
+var ErrLicenseNotFound = errors.New("licença não encontrada")
+
+type licenseServiceImpl struct {
+	licenseRepository repository.LicenseRepository
+	clientRepository  repository.ClientRepository
+	notifier          LicenseNotifier
+	encrypter         utils.Encrypter
+}
+
+func NewLicenseService(
+	licenseRepository repository.LicenseRepository,
+	clientRepository repository.ClientRepository,
+	notifier LicenseNotifier,
+) LicenseService {
+	return &licenseServiceImpl{
+		licenseRepository: licenseRepository,
+		clientRepository:  clientRepository,
+		notifier:          notifier,
+		encrypter:         utils.NewEncrypter(),
+	}
+}
+
+func (s *licenseServiceImpl) Register(order *models.Order) (*models.License, error) {
+	if order == nil || order.ID == 0 || order.IsPaid() {
+		return nil, errors.New("pedido inválido para licença")
+	}
+
+	license, err := models.NewLicense(order, s.encrypter.GenerateToken(24))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.licenseRepository.Create(license); err != nil {
+		return nil, err
+	}
+	return license, nil
+}
+
+func (s *licenseServiceImpl) FindByOrderID(orderID uint) (*models.License, error) {
+	return s.licenseRepository.FindByOrderID(orderID)
+}
+
+func (s *licenseServiceImpl) FindByToken(token string) (*models.License, error) {
+	if token == "" {
+		return nil, ErrLicenseNotFound
+	}
+
+	license, err := s.licenseRepository.FindByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if license == nil || !license.Order.IsPaid() {
+		return nil, ErrLicenseNotFound
+	}
+	return license, nil
+}
+
+func (s *licenseServiceImpl) ListForEbook(ebookID uint) ([]*models.License, error) {
+	return s.licenseRepository.FindByEbook(ebookID)
+}
+
+func (s *licenseServiceImpl) Deliver(license *models.License, now time.Time) error {
+	if !license.Order.IsPaid() || license.SentAt != nil {
+		return nil
+	}
+
+	s.notifier.SendLicenseManagerLink(license)
+	license.SentAt = &now
+	return s.licenseRepository.Update(license)
+}
+
+func (s *licenseServiceImpl) SendDue(now time.Time) (int, error) {
+	licenses, err := s.licenseRepository.FindUnsent()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, license := range licenses {
+		if license.SentAt != nil {
+			continue
+		}
+		if err := s.Deliver(license, now); err != nil {
+			log.Printf("Erro ao enviar link de gestão da licença %d: %v", license.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *licenseServiceImpl) Assign(license *models.License, inputs []SeatInput) ([]*models.LicenseSeat, error) {
+	if !license.Order.IsPaid() {
+		return nil, ErrLicenseNotFound
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("informe ao menos um e-mail")
+	}
+	if len(inputs) > license.AvailableSeats() {
+		return nil, fmt.Errorf("%w: restam %d", models.ErrLicenseNoSeats, license.AvailableSeats())
+	}
+
+	// A lista inteira é validada antes de cadastrar qualquer cliente
+	emails := make([]string, 0, len(inputs))
+	seen := map[string]bool{}
+	for _, input := range inputs {
+		email, err := license.CheckEmail(input.Email)
+		if err == nil && seen[email] {
+			err = models.ErrSeatAlreadyAssigned
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.TrimSpace(input.Email), err)
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+
+	seats := make([]*models.LicenseSeat, 0, len(inputs))
+	for i, input := range inputs {
+		seat, err := s.newSeat(license, input.Name, emails[i])
+		if err != nil {
+			return nil, err
+		}
+		seats = append(seats, seat)
+	}
+
+	if err := s.licenseRepository.Assign(license, seats); err != nil {
+		return nil, err
+	}
+	license.Assignments = append(license.Assignments, seats...)
+
+	purchases := make([]*models.Purchase, 0, len(seats))
+	for _, seat := range seats {
+		purchases = append(purchases, &seat.Purchase)
+	}
+	go s.notifier.SendLinkToDownload(purchases)
+	return seats, nil
+}
+
+func (s *licenseServiceImpl) Reassign(license *models.License, seatID uint, input SeatInput) (*models.LicenseSeat, error) {
+	seat := license.FindSeat(seatID)
+	if seat == nil {
+		return nil, models.ErrSeatNotFound
+	}
+	if err := seat.CanReassign(); err != nil {
+		return nil, err
+	}
+	email, err := license.CheckEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := s.newSeat(license, input.Name, email)
+	if err != nil {
+		return nil, err
+	}
+	seat.Name = next.Name
+	seat.Email = next.Email
+	seat.ClientID = next.ClientID
+	if err := s.licenseRepository.Reassign(license, seat, &next.Purchase); err != nil {
+		return nil, err
+	}
+
+	go s.notifier.SendLinkToDownload([]*models.Purchase{&seat.Purchase})
+	return seat, nil
+}
+
+// newSeat prepara a atribuição para o cliente do e-mail, cadastrando-o se preciso.
+// Sem nome informado, o ebook sai com o e-mail na marca d'água.
+func (s *licenseServiceImpl) newSeat(license *models.License, name, email string) (*models.LicenseSeat, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = email
+	}
+
+	client, err := s.clientRepository.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &models.Client{Name: name, Email: email}
+		if err := s.clientRepository.Save(client); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.LicenseSeat{
+		LicenseID: license.ID,
+		Name:      name,
+		Email:     email,
+		ClientID:  client.ID,
+		Purchase:  *models.NewPurchase(license.EbookID, client.ID),
+	}, nil
+}
+
+func (s *licenseServiceImpl) StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.SendDue(time.Now()); err != nil {
+				log.Printf("Erro ao enviar links de gestão das licenças: %v", err)
+			}
+		}
+	}()
+}
+
+// ParseSeatInputs lê a lista de pessoas colada pelo gestor ou enviada em CSV: uma
+// por linha, com nome e e-mail (em qualquer ordem) ou apenas o e-mail, separados
+// por vírgula ou ponto e vírgula. O cabeçalho da planilha é ignorado.
+func ParseSeatInputs(r io.Reader) ([]SeatInput, error) {
+	reader := bufio.NewReader(r)
+	separator := ','
+	if first, _ := reader.Peek(4096); strings.Contains(string(first), ";") {
+		separator = ';'
+	}
+
+	records := csv.NewReader(reader)
+	records.Comma = separator
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+
+	var inputs []SeatInput
+	for first := true; ; first = false {
+		fields, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("linha %d: formato inválido", parseErr.Line)
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := records.FieldPos(0)
+
+		var input SeatInput
+		for _, field := range fields {
+			field = strings.TrimSpace(field)
+			if input.Email == "" && strings.Contains(field, "@") {
+				input.Email = field
+			} else if input.Name == "" {
+				input.Name = field
+			}
+		}
+		if input.Email == "" && input.Name == "" {
+			continue
+		}
+		if input.Email == "" {
+			if first {
+				continue // cabeçalho
+			}
+			return nil, fmt.Errorf("linha %d: %w", line, models.ErrSeatInvalidEmail)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockLicenseNotifier struct {
+	mock.Mock
+}
+
+func (m *MockLicenseNotifier) SendLicenseManagerLink(license *models.License) {
+	m.Called(license)
+}
+
+func (m *MockLicenseNotifier) SendLinkToDownload(purchases []*models.Purchase) {
+	m.Called(purchases)
+}
+
+func paidLicense(seats int) *models.License {
+	order := models.Order{Model: gorm.Model{ID: 10}, CreatorID: 1, ClientID: 2, EbookID: 3, Status: models.OrderStatusPaid}
+	order.Items = []models.OrderItem{{EbookID: 3, Quantity: seats}}
+	return &models.License{
+		Model:     gorm.Model{ID: 5},
+		OrderID:   10,
+		Order:     order,
+		CreatorID: 1,
+		EbookID:   3,
+		ManagerID: 2,
+		Seats:     seats,
+		Token:     "licenca",
+	}
+}
+
+func TestLicenseService_Register_CreatesLicenseForTeamOrder(t *testing.T) {
+	licenseRepo := new(repoMocks.MockLicenseRepository)
+	service := NewLicenseService(licenseRepo, new(repoMocks.MockClientRepository), new(MockLicenseNotifier))
+
+	order, err := models.NewLicenseOrder(models.OrderItem{EbookID: 3, UnitPrice: 4000, Currency: "BRL"}, 1, 2, 20)
+	assert.NoError(t, err)
+	order.ID = 10
+	licenseRepo.On("Create", mock.AnythingOfType("*models.License")).Return(nil)
+
+	license, err := service.Register(order)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, license.Seats)
+	assert.Equal(t, uint(2), license.ManagerID)
+	assert.NotEmpty(t, license.Token)
+	licenseRepo.AssertExpectations(t)
+}
+
+func TestLicenseService_Assign_CreatesPurchasePerPerson(t *testing.T) {
+	licenseRepo := new(repoMocks.MockLicenseRepository)
+	clientRepo := new(repoMocks.MockClientRepository)
+	notifier := new(MockLicenseNotifier)
+	service := NewLicenseService(licenseRepo, clientRepo, notifier)
+
+	license := paidLicense(3)
+	existing := &models.Client{Model: gorm.Model{ID: 7}, Name: "Maria", Email: "maria@empresa.com"}
+	clientRepo.On("FindByEmail", "maria@empresa.com").Return(existing, nil)
+	clientRepo.On("FindByEmail", "joao@empresa.com").Return(nil, nil)
+	clientRepo.On("Save", mock.MatchedBy(func(c *models.Client) bool {
+		return c.Email == "joao@empresa.com" && c.Name == "João"
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Client).ID = 8
+	}).Return(nil)
+	licenseRepo.On("Assign", license, mock.AnythingOfType("[]*models.LicenseSeat")).Return(nil)
+	notifier.On("SendLinkToDownload", mock.Anything).Return()
+
+	seats, err := service.Assign(license, []SeatInput{
+		{Name: "Maria", Email: "Maria@Empresa.com"},
+		{Name: "João", Email: "joao@empresa.com"},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, seats, 2)
+	assert.Equal(t, uint(7), seats[0].Purchase.ClientID)
+	assert.Equal(t, uint(8), seats[1].Purchase.ClientID)
+	assert.Equal(t, uint(3), seats[1].Purchase.EbookID)
+	assert.Equal(t, 1, license.AvailableSeats())
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLicenseService_Assign_RejectsInvalidList(t *testing.T) {
+	service := NewLicenseService(new(repoMocks.MockLicenseRepository), new(repoMocks.MockClientRepository), new(MockLicenseNotifier))
+
+	license := paidLicense(2)
+	_, err := service.Assign(license, []SeatInput{{Email: "a@empresa.com"}, {Email: "b@empresa.com"}, {Email: "c@empresa.com"}})
+	assert.ErrorIs(t, err, models.ErrLicenseNoSeats)
+
+	_, err = service.Assign(license, []SeatInput{{Email: "a@empresa.com"}, {Email: "A@empresa.com"}})
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyAssigned)
+
+	_, err = service.Assign(license, []SeatInput{{Email: "sem-arroba"}})
+	assert.ErrorIs(t, err, models.ErrSeatInvalidEmail)
+
+	unpaid := paidLicense(2)
+	unpaid.Order.Status = models.OrderStatusPending
+	_, err = service.Assign(unpaid, []SeatInput{{Email: "a@empresa.com"}})
+	assert.ErrorIs(t, err, ErrLicenseNotFound)
+}
+
+func TestLicenseService_Reassign_OnlyBeforeFirstDownload(t *testing.T) {
+	licenseRepo := new(repoMocks.MockLicenseRepository)
+	clientRepo := new(repoMocks.MockClientRepository)
+	notifier := new(MockLicenseNotifier)
+	service := NewLicenseService(licenseRepo, clientRepo, notifier)
+
+	license := paidLicense(2)
+	pending := &models.LicenseSeat{Model: gorm.Model{ID: 1}, Email: "saiu@empresa.com", PurchaseID: 20}
+	downloaded := &models.LicenseSeat{Model: gorm.Model{ID: 2}, Email: "leu@empresa.com", PurchaseID: 21}
+	downloaded.Purchase.DownloadsUsed = 1
+	license.Assignments = []*models.LicenseSeat{pending, downloaded}
+
+	_, err := service.Reassign(license, 2, SeatInput{Email: "novo@empresa.com"})
+	assert.ErrorIs(t, err, models.ErrSeatAlreadyDownloaded)
+
+	_, err = service.Reassign(license, 99, SeatInput{Email: "novo@empresa.com"})
+	assert.ErrorIs(t, err, models.ErrSeatNotFound)
+
+	novo := &models.Client{Model: gorm.Model{ID: 9}, Email: "novo@empresa.com"}
+	clientRepo.On("FindByEmail", "novo@empresa.com").Return(novo, nil)
+	licenseRepo.On("Reassign", license, pending, mock.AnythingOfType("*models.Purchase")).Return(nil)
+	notifier.On("SendLinkToDownload", mock.Anything).Return()
+
+	seat, err := service.Reassign(license, 1, SeatInput{Name: "Novo", Email: "novo@empresa.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "novo@empresa.com", seat.Email)
+	assert.Equal(t, uint(9), seat.ClientID)
+	licenseRepo.AssertExpectations(t)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLicenseService_SendDue_SendsManagerLinkOnce(t *testing.T) {
+	licenseRepo := new(repoMocks.MockLicenseRepository)
+	notifier := new(MockLicenseNotifier)
+	service := NewLicenseService(licenseRepo, new(repoMocks.MockClientRepository), notifier)
+
+	now := time.Now()
+	unsent := paidLicense(5)
+	sent := paidLicense(5)
+	sent.SentAt = &now
+	licenseRepo.On("FindUnsent").Return([]*models.License{unsent, sent}, nil)
+	licenseRepo.On("Update", unsent).Return(nil)
+	notifier.On("SendLicenseManagerLink", unsent).Return()
+
+	count, err := service.SendDue(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NotNil(t, unsent.SentAt)
+	notifier.AssertNumberOfCalls(t, "SendLicenseManagerLink", 1)
+}
+
+func TestParseSeatInputs(t *testing.T) {
+	inputs, err := ParseSeatInputs(strings.NewReader("Nome;E-mail\nMaria Silva; maria@empresa.com\n\njoao@empresa.com\nana@empresa.com;Ana\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []SeatInput{
+		{Name: "Maria Silva", Email: "maria@empresa.com"},
+		{Email: "joao@empresa.com"},
+		{Name: "Ana", Email: "ana@empresa.com"},
+	}, inputs)
+
+	_, err = ParseSeatInputs(strings.NewReader("maria@empresa.com\nsó o nome"))
+	assert.ErrorIs(t, err, models.ErrSeatInvalidEmail)
+	assert.Contains(t, err.Error(), "linha 2")
+}
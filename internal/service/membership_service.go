@@ -0,0 +1,385 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/anglesson/simple-web-server/pkg/utils"
+)
+
+// MembershipNotifier avisa o assinante do início e do fim da assinatura e envia os
+// links de download dos ebooks liberados pelo catálogo
+type MembershipNotifier interface {
+	SendMembershipWelcome(member *models.Member)
+	SendMembershipEnded(member *models.Member)
+	SendLinkToDownload(purchases []*models.Purchase)
+}
+
+// MembershipService conduz as assinaturas do catálogo vendidas pelos criadores. O
+// acesso é concedido por compras vinculadas ao assinante, renovadas a cada período
+// pago e encerradas no cancelamento ou na cobrança recusada.
+type MembershipService interface {
+	Create(creatorID uint, name, description string, price money.Money, interval string) (*models.Membership, error)
+	// ListForCreator lista as assinaturas do criador com a quantidade de assinantes em dia
+	ListForCreator(creatorID uint) ([]*models.Membership, map[uint]int64, error)
+	FindForCreator(id, creatorID uint) (*models.Membership, error)
+	SetActive(membership *models.Membership, active bool) error
+	// FindAvailable busca a assinatura oferecida na página pública
+	FindAvailable(id uint) (*models.Membership, error)
+	// Subscribe registra o assinante pendente e retorna a URL do checkout no gateway
+	Subscribe(membership *models.Membership, client *models.Client, successURL, cancelURL string) (string, error)
+	// ConfirmCheckout ativa o assinante depois do pagamento da sessão de checkout
+	ConfirmCheckout(sessionID string) (*models.Member, error)
+	// FindMember busca o assinante da área do assinante com os ebooks liberados
+	FindMember(token string) (*models.Member, []*models.Purchase, error)
+	// Cancel agenda o cancelamento: o acesso continua até o fim do período pago
+	Cancel(member *models.Member) error
+	// HandleSubscriptionUpdated aplica a situação da assinatura informada pelo gateway
+	HandleSubscriptionUpdated(memberID uint, subscriptionID, status string, periodEnd time.Time, cancelAt *time.Time) error
+	HandlePaymentFailed(memberID uint) error
+	// HandleInvoicePaid renova o assinante pelo período pago e retorna o assinante
+	// cobrado, para o registro do pagamento
+	HandleInvoicePaid(memberID uint, subscriptionID string, periodEnd time.Time) (*models.Member, error)
+	// DeliverNewEbooks libera aos assinantes em dia os ebooks publicados depois da adesão
+	DeliverNewEbooks() (int, error)
+	StartWorker(interval time.Duration)
+}
+
+// Metadado enviado ao gateway para identificar o assinante nos eventos da assinatura
+const MembershipMemberMetadata = "member_id"
+
+var (
+	ErrMembershipNotFound = errors.New("assinatura não encontrada")
+	ErrMemberNotFound     = errors.New("assinante não encontrado")
+)
+
+type membershipServiceImpl struct {
+	membershipRepository repository.MembershipRepository
+	paymentGateway       PaymentGateway
+	notifier             MembershipNotifier
+	encrypter            utils.Encrypter
+	now                  func() time.Time
+}
+
+func NewMembershipService(
+	membershipRepository repository.MembershipRepository,
+	paymentGateway PaymentGateway,
+	notifier MembershipNotifier,
+) MembershipService {
+	return &membershipServiceImpl{
+		membershipRepository: membershipRepository,
+		paymentGateway:       paymentGateway,
+		notifier:             notifier,
+		encrypter:            utils.NewEncrypter(),
+		now:                  time.Now,
+	}
+}
+
+func (s *membershipServiceImpl) Create(creatorID uint, name, description string, price money.Money, interval string) (*models.Membership, error) {
+	membership, err := models.NewMembership(creatorID, name, description, price, interval)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.membershipRepository.Create(membership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+func (s *membershipServiceImpl) ListForCreator(creatorID uint) ([]*models.Membership, map[uint]int64, error) {
+	memberships, err := s.membershipRepository.FindByCreator(creatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts, err := s.membershipRepository.CountActiveMembers(creatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return memberships, counts, nil
+}
+
+func (s *membershipServiceImpl) FindForCreator(id, creatorID uint) (*models.Membership, error) {
+	membership, err := s.membershipRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || membership.CreatorID != creatorID {
+		return nil, ErrMembershipNotFound
+	}
+	return membership, nil
+}
+
+func (s *membershipServiceImpl) SetActive(membership *models.Membership, active bool) error {
+	membership.Active = active
+	return s.membershipRepository.Update(membership)
+}
+
+func (s *membershipServiceImpl) FindAvailable(id uint) (*models.Membership, error) {
+	membership, err := s.membershipRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || !membership.Active {
+		return nil, models.ErrMembershipUnavailable
+	}
+	return membership, nil
+}
+
+func (s *membershipServiceImpl) Subscribe(membership *models.Membership, client *models.Client, successURL, cancelURL string) (string, error) {
+	if !membership.Active {
+		return "", models.ErrMembershipUnavailable
+	}
+
+	member := models.NewMember(membership, client.ID, s.encrypter.GenerateToken(24))
+	if err := s.membershipRepository.CreateMember(member); err != nil {
+		return "", err
+	}
+
+	sessionID, url, err := s.paymentGateway.CreateRecurringCheckout(models.RecurringCheckout{
+		CustomerEmail: client.Email,
+		Description:   membership.Name,
+		Price:         membership.Price,
+		Interval:      membership.Interval,
+		SuccessURL:    successURL,
+		CancelURL:     cancelURL,
+		Metadata: map[string]string{
+			MembershipMemberMetadata: strconv.FormatUint(uint64(member.ID), 10),
+		},
+	})
+	if err != nil {
+		log.Printf("Erro ao criar checkout da assinatura %d: %v", membership.ID, err)
+		return "", errors.New("erro ao iniciar o pagamento da assinatura")
+	}
+
+	member.GatewaySessionID = sessionID
+	if err := s.membershipRepository.UpdateMember(member); err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+func (s *membershipServiceImpl) ConfirmCheckout(sessionID string) (*models.Member, error) {
+	if sessionID == "" {
+		return nil, ErrMemberNotFound
+	}
+
+	member, err := s.membershipRepository.FindMemberBySession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	if member.Status != models.MemberStatusPending && member.GatewaySubscriptionID != "" {
+		return member, nil
+	}
+
+	result, err := s.paymentGateway.GetRecurringCheckout(sessionID)
+	if err != nil {
+		log.Printf("Erro ao consultar checkout %s da assinatura: %v", sessionID, err)
+		return nil, errors.New("erro ao confirmar o pagamento da assinatura")
+	}
+	if !result.Paid {
+		return member, nil
+	}
+
+	// Os webhooks da assinatura podem chegar antes da sessão e já ter ativado o
+	// assinante; falta só registrar a assinatura do gateway
+	if member.Status != models.MemberStatusPending {
+		member.GatewaySubscriptionID = result.SubscriptionID
+		if err := s.membershipRepository.UpdateMember(member); err != nil {
+			return nil, err
+		}
+		return member, nil
+	}
+
+	if err := s.activate(member, result.SubscriptionID, result.PeriodEnd); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *membershipServiceImpl) FindMember(token string) (*models.Member, []*models.Purchase, error) {
+	if token == "" {
+		return nil, nil, ErrMemberNotFound
+	}
+
+	member, err := s.membershipRepository.FindMemberByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil {
+		return nil, nil, ErrMemberNotFound
+	}
+
+	purchases, err := s.membershipRepository.FindMemberPurchases(member.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return member, purchases, nil
+}
+
+func (s *membershipServiceImpl) Cancel(member *models.Member) error {
+	if !member.IsActive() || member.GatewaySubscriptionID == "" {
+		return models.ErrMemberNotActive
+	}
+	if member.CancelAt != nil {
+		return nil
+	}
+
+	cancelAt, err := s.paymentGateway.ScheduleSubscriptionCancellation(member.GatewaySubscriptionID)
+	if err != nil {
+		log.Printf("Erro ao cancelar a assinatura do assinante %d: %v", member.ID, err)
+		return errors.New("erro ao cancelar a assinatura")
+	}
+
+	member.CancelAt = &cancelAt
+	return s.membershipRepository.UpdateMember(member)
+}
+
+func (s *membershipServiceImpl) HandleSubscriptionUpdated(memberID uint, subscriptionID, status string, periodEnd time.Time, cancelAt *time.Time) error {
+	member, err := s.findMember(memberID)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case "active", "trialing":
+		member.CancelAt = cancelAt
+		return s.activate(member, subscriptionID, periodEnd)
+	case "past_due", "unpaid", "incomplete":
+		return s.suspend(member)
+	case "canceled", "incomplete_expired":
+		return s.end(member)
+	}
+	return nil
+}
+
+func (s *membershipServiceImpl) HandlePaymentFailed(memberID uint) error {
+	member, err := s.findMember(memberID)
+	if err != nil {
+		return err
+	}
+	return s.suspend(member)
+}
+
+func (s *membershipServiceImpl) HandleInvoicePaid(memberID uint, subscriptionID string, periodEnd time.Time) (*models.Member, error) {
+	member, err := s.findMember(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Status == models.MemberStatusCanceled {
+		return member, nil
+	}
+	return member, s.activate(member, subscriptionID, periodEnd)
+}
+
+func (s *membershipServiceImpl) DeliverNewEbooks() (int, error) {
+	members, err := s.membershipRepository.FindActiveMembers()
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	now := s.now()
+	for _, member := range members {
+		if !member.HasAccess(now) {
+			continue
+		}
+		purchases, err := s.membershipRepository.Grant(member, member.AccessUntil())
+		if err != nil {
+			log.Printf("Erro ao liberar novos ebooks ao assinante %d: %v", member.ID, err)
+			continue
+		}
+		if len(purchases) > 0 {
+			s.notifier.SendLinkToDownload(purchases)
+			delivered += len(purchases)
+		}
+	}
+	return delivered, nil
+}
+
+func (s *membershipServiceImpl) StartWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.DeliverNewEbooks(); err != nil {
+				log.Printf("Erro ao liberar novos ebooks aos assinantes: %v", err)
+			}
+		}
+	}()
+}
+
+// activate registra o período pago e libera o catálogo até o fim dele. Na adesão o
+// assinante recebe as boas-vindas; nas renovações, só os links dos ebooks novos.
+func (s *membershipServiceImpl) activate(member *models.Member, subscriptionID string, periodEnd time.Time) error {
+	if periodEnd.IsZero() {
+		if member.CurrentPeriodEnd == nil {
+			return errors.New("fim do período da assinatura não informado")
+		}
+		periodEnd = *member.CurrentPeriodEnd
+	}
+
+	started := member.Activate(subscriptionID, periodEnd)
+	if err := s.membershipRepository.UpdateMember(member); err != nil {
+		return err
+	}
+
+	purchases, err := s.membershipRepository.Grant(member, member.AccessUntil())
+	if err != nil {
+		return err
+	}
+
+	if started {
+		go s.notifier.SendMembershipWelcome(member)
+	} else if len(purchases) > 0 {
+		go s.notifier.SendLinkToDownload(purchases)
+	}
+	return nil
+}
+
+func (s *membershipServiceImpl) suspend(member *models.Member) error {
+	if member.Status != models.MemberStatusActive {
+		return nil
+	}
+
+	member.Suspend()
+	if err := s.membershipRepository.UpdateMember(member); err != nil {
+		return err
+	}
+	return s.membershipRepository.Revoke(member, s.now())
+}
+
+func (s *membershipServiceImpl) end(member *models.Member) error {
+	if member.Status == models.MemberStatusCanceled {
+		return nil
+	}
+
+	now := s.now()
+	member.End(now)
+	if err := s.membershipRepository.UpdateMember(member); err != nil {
+		return err
+	}
+	if err := s.membershipRepository.Revoke(member, now); err != nil {
+		return err
+	}
+
+	go s.notifier.SendMembershipEnded(member)
+	return nil
+}
+
+func (s *membershipServiceImpl) findMember(memberID uint) (*models.Member, error) {
+	member, err := s.membershipRepository.FindMember(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockMembershipNotifier struct {
+	mock.Mock
+}
+
+func (m *MockMembershipNotifier) SendMembershipWelcome(member *models.Member) {
+	m.Called(member)
+}
+
+func (m *MockMembershipNotifier) SendMembershipEnded(member *models.Member) {
+	m.Called(member)
+}
+
+func (m *MockMembershipNotifier) SendLinkToDownload(purchases []*models.Purchase) {
+	m.Called(purchases)
+}
+
+func catalogMembership() *models.Membership {
+	membership, _ := models.NewMembership(1, "Clube do leitor", "", money.New(2990, money.BRL), models.MembershipIntervalMonth)
+	membership.ID = 4
+	return membership
+}
+
+func activeMember(periodEnd time.Time) *models.Member {
+	member := models.NewMember(catalogMembership(), 2, "assinante")
+	member.ID = 9
+	member.Activate("sub_9", periodEnd)
+	return member
+}
+
+func TestMembershipService_Subscribe_OpensRecurringCheckout(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	gateway := new(MockPaymentGateway)
+	service := NewMembershipService(membershipRepo, gateway, new(MockMembershipNotifier))
+
+	membershipRepo.On("CreateMember", mock.AnythingOfType("*models.Member")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Member).ID = 9
+	}).Return(nil)
+	gateway.On("CreateRecurringCheckout", mock.MatchedBy(func(checkout models.RecurringCheckout) bool {
+		return checkout.Interval == models.MembershipIntervalMonth &&
+			checkout.Price.Amount == 2990 &&
+			checkout.CustomerEmail == "leitor@email.com" &&
+			checkout.Metadata[MembershipMemberMetadata] == "9"
+	})).Return("cs_1", "https://checkout/cs_1", nil)
+	membershipRepo.On("UpdateMember", mock.MatchedBy(func(member *models.Member) bool {
+		return member.GatewaySessionID == "cs_1" && member.Status == models.MemberStatusPending
+	})).Return(nil)
+
+	url, err := service.Subscribe(catalogMembership(), &models.Client{Model: gorm.Model{ID: 2}, Email: "leitor@email.com"}, "ok", "cancel")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://checkout/cs_1", url)
+	membershipRepo.AssertExpectations(t)
+	gateway.AssertExpectations(t)
+}
+
+func TestMembershipService_Subscribe_RejectsPausedMembership(t *testing.T) {
+	service := NewMembershipService(new(repoMocks.MockMembershipRepository), new(MockPaymentGateway), new(MockMembershipNotifier))
+	membership := catalogMembership()
+	membership.Active = false
+
+	_, err := service.Subscribe(membership, &models.Client{}, "ok", "cancel")
+
+	assert.ErrorIs(t, err, models.ErrMembershipUnavailable)
+}
+
+func TestMembershipService_ConfirmCheckout_GrantsCatalogAndWelcomes(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	gateway := new(MockPaymentGateway)
+	notifier := new(MockMembershipNotifier)
+	service := NewMembershipService(membershipRepo, gateway, notifier)
+
+	member := models.NewMember(catalogMembership(), 2, "assinante")
+	member.ID = 9
+	periodEnd := time.Now().AddDate(0, 1, 0)
+	membershipRepo.On("FindMemberBySession", "cs_1").Return(member, nil)
+	gateway.On("GetRecurringCheckout", "cs_1").Return(&models.RecurringCheckoutResult{Paid: true, SubscriptionID: "sub_9", PeriodEnd: periodEnd}, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+	membershipRepo.On("Grant", member, periodEnd.Add(models.MemberAccessGrace)).Return([]*models.Purchase{{EbookID: 1}}, nil)
+	notifier.On("SendMembershipWelcome", member).Return()
+
+	confirmed, err := service.ConfirmCheckout("cs_1")
+
+	assert.NoError(t, err)
+	assert.True(t, confirmed.IsActive())
+	assert.Equal(t, "sub_9", confirmed.GatewaySubscriptionID)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	notifier.AssertNotCalled(t, "SendLinkToDownload", mock.Anything)
+
+	// A volta do gateway e o webhook podem confirmar a mesma sessão
+	_, err = service.ConfirmCheckout("cs_1")
+	assert.NoError(t, err)
+	gateway.AssertNumberOfCalls(t, "GetRecurringCheckout", 1)
+}
+
+func TestMembershipService_InvoicePaidBeforeCheckoutKeepsSubscriptionID(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	gateway := new(MockPaymentGateway)
+	notifier := new(MockMembershipNotifier)
+	service := NewMembershipService(membershipRepo, gateway, notifier)
+
+	member := models.NewMember(catalogMembership(), 2, "assinante")
+	member.ID = 9
+	member.GatewaySessionID = "cs_1"
+	periodEnd := time.Now().AddDate(0, 1, 0)
+	membershipRepo.On("FindMember", uint(9)).Return(member, nil)
+	membershipRepo.On("FindMemberBySession", "cs_1").Return(member, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+	membershipRepo.On("Grant", member, periodEnd.Add(models.MemberAccessGrace)).Return([]*models.Purchase{}, nil)
+	notifier.On("SendMembershipWelcome", member).Return()
+
+	// O Stripe entrega invoice.paid antes de checkout.session.completed
+	_, err := service.HandleInvoicePaid(9, "sub_9", periodEnd)
+	assert.NoError(t, err)
+	assert.True(t, member.IsActive())
+	assert.Equal(t, "sub_9", member.GatewaySubscriptionID)
+
+	_, err = service.ConfirmCheckout("cs_1")
+	assert.NoError(t, err)
+	gateway.AssertNotCalled(t, "GetRecurringCheckout", mock.Anything)
+
+	// Com a assinatura registrada, o cancelamento chega ao gateway
+	cancelAt := periodEnd
+	gateway.On("ScheduleSubscriptionCancellation", "sub_9").Return(cancelAt, nil)
+	assert.NoError(t, service.Cancel(member))
+	assert.Equal(t, cancelAt, *member.CancelAt)
+}
+
+func TestMembershipService_ConfirmCheckout_FillsMissingSubscriptionID(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	gateway := new(MockPaymentGateway)
+	service := NewMembershipService(membershipRepo, gateway, new(MockMembershipNotifier))
+
+	// Assinante ativado por um webhook que não trouxe a assinatura
+	member := activeMember(time.Now().AddDate(0, 1, 0))
+	member.GatewaySubscriptionID = ""
+	membershipRepo.On("FindMemberBySession", "cs_1").Return(member, nil)
+	gateway.On("GetRecurringCheckout", "cs_1").Return(&models.RecurringCheckoutResult{Paid: true, SubscriptionID: "sub_9"}, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+
+	confirmed, err := service.ConfirmCheckout("cs_1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sub_9", confirmed.GatewaySubscriptionID)
+	membershipRepo.AssertNotCalled(t, "Grant", mock.Anything, mock.Anything)
+}
+
+func TestMembershipService_HandleInvoicePaid_RenewsAndDeliversNewEbooks(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	notifier := new(MockMembershipNotifier)
+	service := NewMembershipService(membershipRepo, new(MockPaymentGateway), notifier)
+
+	member := activeMember(time.Now())
+	next := time.Now().AddDate(0, 1, 0)
+	created := []*models.Purchase{{EbookID: 5}}
+	membershipRepo.On("FindMember", uint(9)).Return(member, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+	membershipRepo.On("Grant", member, next.Add(models.MemberAccessGrace)).Return(created, nil)
+	notifier.On("SendLinkToDownload", created).Return()
+
+	_, err := service.HandleInvoicePaid(9, "sub_9", next)
+
+	assert.NoError(t, err)
+	assert.Equal(t, next, *member.CurrentPeriodEnd)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	notifier.AssertNotCalled(t, "SendMembershipWelcome", mock.Anything)
+}
+
+func TestMembershipService_HandlePaymentFailed_RevokesAccess(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	service := NewMembershipService(membershipRepo, new(MockPaymentGateway), new(MockMembershipNotifier))
+
+	member := activeMember(time.Now().AddDate(0, 0, 10))
+	membershipRepo.On("FindMember", uint(9)).Return(member, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+	membershipRepo.On("Revoke", member, mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := service.HandlePaymentFailed(9)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.MemberStatusPastDue, member.Status)
+	assert.False(t, member.HasAccess(time.Now()))
+	membershipRepo.AssertExpectations(t)
+}
+
+func TestMembershipService_HandleSubscriptionUpdated_CanceledEndsMembership(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	notifier := new(MockMembershipNotifier)
+	service := NewMembershipService(membershipRepo, new(MockPaymentGateway), notifier)
+
+	member := activeMember(time.Now())
+	membershipRepo.On("FindMember", uint(9)).Return(member, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+	membershipRepo.On("Revoke", member, mock.AnythingOfType("time.Time")).Return(nil)
+	notifier.On("SendMembershipEnded", member).Return()
+
+	err := service.HandleSubscriptionUpdated(9, "sub_9", "canceled", time.Time{}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.MemberStatusCanceled, member.Status)
+	assert.NotNil(t, member.EndedAt)
+	assert.Eventually(t, func() bool {
+		return len(notifier.Calls) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Eventos repetidos não reenviam o aviso
+	assert.NoError(t, service.HandleSubscriptionUpdated(9, "sub_9", "canceled", time.Time{}, nil))
+	membershipRepo.AssertNumberOfCalls(t, "Revoke", 1)
+}
+
+func TestMembershipService_Cancel_SchedulesAtPeriodEnd(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	gateway := new(MockPaymentGateway)
+	service := NewMembershipService(membershipRepo, gateway, new(MockMembershipNotifier))
+
+	periodEnd := time.Now().AddDate(0, 0, 20)
+	member := activeMember(periodEnd)
+	gateway.On("ScheduleSubscriptionCancellation", "sub_9").Return(periodEnd, nil)
+	membershipRepo.On("UpdateMember", member).Return(nil)
+
+	err := service.Cancel(member)
+
+	assert.NoError(t, err)
+	assert.Equal(t, periodEnd, *member.CancelAt)
+	assert.True(t, member.HasAccess(time.Now()))
+
+	pending := models.NewMember(catalogMembership(), 2, "pendente")
+	assert.ErrorIs(t, service.Cancel(pending), models.ErrMemberNotActive)
+}
+
+func TestMembershipService_DeliverNewEbooks_SkipsMembersWithoutAccess(t *testing.T) {
+	membershipRepo := new(repoMocks.MockMembershipRepository)
+	notifier := new(MockMembershipNotifier)
+	service := NewMembershipService(membershipRepo, new(MockPaymentGateway), notifier)
+
+	current := activeMember(time.Now().AddDate(0, 0, 10))
+	lapsed := activeMember(time.Now().AddDate(0, 0, -10))
+	lapsed.ID = 10
+	created := []*models.Purchase{{EbookID: 5}}
+	membershipRepo.On("FindActiveMembers").Return([]*models.Member{current, lapsed}, nil)
+	membershipRepo.On("Grant", current, current.AccessUntil()).Return(created, nil)
+	notifier.On("SendLinkToDownload", created).Return()
+
+	delivered, err := service.DeliverNewEbooks()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	membershipRepo.AssertNotCalled(t, "Grant", lapsed, mock.Anything)
+	notifier.AssertExpectations(t)
+}
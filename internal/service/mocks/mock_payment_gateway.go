@@ -1,6 +1,10 @@
 package mocks
 
 import (
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -22,3 +26,79 @@ func (m *MockPaymentGateway) CancelSubscription(subscriptionID string) error {
 	args := m.Called(subscriptionID)
 	return args.Error(0)
 }
+
+func (m *MockPaymentGateway) UpdateSubscriptionPrice(subscriptionID, priceID string) error {
+	args := m.Called(subscriptionID, priceID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) ScheduleSubscriptionCancellation(subscriptionID string) (time.Time, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockPaymentGateway) ResumeSubscription(subscriptionID string) error {
+	args := m.Called(subscriptionID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) ListInvoices(customerID string) ([]*models.Invoice, error) {
+	args := m.Called(customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Invoice), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreatePortalSession(customerID, returnURL string) (string, error) {
+	args := m.Called(customerID, returnURL)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) ChargeSavedPaymentMethod(paymentID string, amount int64, currency money.Currency, description, idempotencyKey string) (string, error) {
+	args := m.Called(paymentID, amount, currency, description, idempotencyKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateConnectedAccount(email string) (string, error) {
+	args := m.Called(email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateAccountLink(accountID, refreshURL, returnURL string) (string, error) {
+	args := m.Called(accountID, refreshURL, returnURL)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) PayoutsEnabled(accountID string) (bool, error) {
+	args := m.Called(accountID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) Transfer(accountID string, amount int64, currency money.Currency, reference string) (string, error) {
+	args := m.Called(accountID, amount, currency, reference)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) BalanceTransactionFee(balanceTransactionID string) (int64, error) {
+	args := m.Called(balanceTransactionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateCoupon(amountOff int64, currency money.Currency, name string) (string, error) {
+	args := m.Called(amountOff, currency, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateRecurringCheckout(checkout models.RecurringCheckout) (string, string, error) {
+	args := m.Called(checkout)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockPaymentGateway) GetRecurringCheckout(sessionID string) (*models.RecurringCheckoutResult, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecurringCheckoutResult), args.Error(1)
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPlanLimiter struct {
+	mock.Mock
+}
+
+func (m *MockPlanLimiter) CheckLimit(creatorID uint, resource models.PlanResource, amount int64) error {
+	args := m.Called(creatorID, resource, amount)
+	return args.Error(0)
+}
+
+func (m *MockPlanLimiter) HasFeature(creatorID uint, feature models.PlanFeature) (bool, error) {
+	args := m.Called(creatorID, feature)
+	return args.Bool(0), args.Error(1)
+}
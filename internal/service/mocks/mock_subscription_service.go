@@ -43,23 +43,41 @@ func (m *MockSubscriptionService) FindByStripeSubscriptionID(subscriptionID stri
 	return args.Get(0).(*models.Subscription), args.Error(1)
 }
 
-func (m *MockSubscriptionService) ActivateSubscription(subscription *models.Subscription, stripeCustomerID, stripeSubscriptionID string) error {
-	args := m.Called(subscription, stripeCustomerID, stripeSubscriptionID)
+func (m *MockSubscriptionService) FindPastDue() ([]*models.Subscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionService) AttachCustomer(subscription *models.Subscription, stripeCustomerID string, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, stripeCustomerID, actor)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) UpdateSubscriptionStatus(subscription *models.Subscription, status string, endDate *time.Time) error {
-	args := m.Called(subscription, status, endDate)
+func (m *MockSubscriptionService) ActivateSubscription(subscription *models.Subscription, stripeCustomerID, stripeSubscriptionID string, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, stripeCustomerID, stripeSubscriptionID, actor)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) CancelSubscription(subscription *models.Subscription) error {
-	args := m.Called(subscription)
+func (m *MockSubscriptionService) UpdateSubscriptionStatus(subscription *models.Subscription, status string, endDate *time.Time, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, status, endDate, actor)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) EndTrial(subscription *models.Subscription) error {
-	args := m.Called(subscription)
+func (m *MockSubscriptionService) SyncCancellation(subscription *models.Subscription, cancelAtPeriodEnd bool, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, cancelAtPeriodEnd, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) CancelSubscription(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) EndTrial(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, actor)
 	return args.Error(0)
 }
 
@@ -67,3 +85,54 @@ func (m *MockSubscriptionService) GetUserSubscriptionStatus(userID uint) (string
 	args := m.Called(userID)
 	return args.String(0), args.Int(1), args.Error(2)
 }
+
+func (m *MockSubscriptionService) ScheduleCancellation(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ResumeSubscription(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ChangePlan(subscription *models.Subscription, planID, interval string, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, planID, interval, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ListInvoices(subscription *models.Subscription) ([]*models.Invoice, error) {
+	args := m.Called(subscription)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Invoice), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CreatePortalSession(subscription *models.Subscription, returnURL string) (string, error) {
+	args := m.Called(subscription, returnURL)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSubscriptionService) MarkPaymentFailed(subscription *models.Subscription, gracePeriod time.Duration, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, gracePeriod, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) MarkPaymentRecovered(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	args := m.Called(subscription, actor)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) RecordDunningReminder(subscription *models.Subscription, remindersSent int) error {
+	args := m.Called(subscription, remindersSent)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionService) ListEvents(subscription *models.Subscription) ([]*models.SubscriptionEvent, error) {
+	args := m.Called(subscription)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SubscriptionEvent), args.Error(1)
+}
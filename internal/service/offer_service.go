@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+// OfferService gerencia os order bumps e upsells configurados para cada ebook
+type OfferService interface {
+	ListForEbook(ebookID uint) ([]*models.Offer, error)
+	FindByID(id uint) (*models.Offer, error)
+	Create(offerType string, ebook *models.Ebook, offerEbookID uint, price money.Money, headline string) (*models.Offer, error)
+	Toggle(offer *models.Offer) error
+	Delete(offer *models.Offer) error
+	// ActiveOffer retorna a oferta ativa do tipo informado para o ebook, ou nil
+	ActiveOffer(ebookID uint, offerType string) (*models.Offer, error)
+	// ShowOffer retorna a oferta ativa do tipo informado para o ebook e registra a
+	// exibição. Retorna nil quando o ebook não tem oferta ativa.
+	ShowOffer(ebookID uint, offerType string) (*models.Offer, error)
+	// ShowUpsell retorna o upsell do ebook principal do pedido pago, registrando a
+	// exibição, exceto quando o cliente já aceitou a oferta
+	ShowUpsell(order *models.Order) (*models.Offer, error)
+	// FindBump valida o order bump marcado no checkout do ebook
+	FindBump(offerID, ebookID uint) (*models.Offer, error)
+	// AcceptUpsell cobra o upsell no mesmo meio de pagamento do pedido pago na sessão
+	// informada, sem pedir os dados do comprador novamente. Quando a cobrança não é
+	// possível, retorna o pedido pendente junto com ErrUpsellChargeFailed para que o
+	// pagamento seja concluído no checkout do gateway.
+	AcceptUpsell(offer *models.Offer, sessionID string) (*models.Order, []*models.Purchase, error)
+}
+
+var ErrUpsellChargeFailed = errors.New("não foi possível cobrar o upsell no cartão salvo")
+
+type offerServiceImpl struct {
+	offerRepository repository.OfferRepository
+	ebookService    EbookService
+	orderService    OrderService
+	paymentGateway  PaymentGateway
+}
+
+func NewOfferService(
+	offerRepository repository.OfferRepository,
+	ebookService EbookService,
+	orderService OrderService,
+	paymentGateway PaymentGateway,
+) OfferService {
+	return &offerServiceImpl{
+		offerRepository: offerRepository,
+		ebookService:    ebookService,
+		orderService:    orderService,
+		paymentGateway:  paymentGateway,
+	}
+}
+
+func (s *offerServiceImpl) ListForEbook(ebookID uint) ([]*models.Offer, error) {
+	return s.offerRepository.FindByEbook(ebookID)
+}
+
+func (s *offerServiceImpl) FindByID(id uint) (*models.Offer, error) {
+	offer, err := s.offerRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil {
+		return nil, models.ErrOfferNotAvailable
+	}
+	return offer, nil
+}
+
+func (s *offerServiceImpl) Create(offerType string, ebook *models.Ebook, offerEbookID uint, price money.Money, headline string) (*models.Offer, error) {
+	offerEbook, err := s.ebookService.FindByID(offerEbookID)
+	if err != nil || offerEbook == nil {
+		return nil, fmt.Errorf("ebook %d não encontrado", offerEbookID)
+	}
+
+	offer, err := models.NewOffer(offerType, ebook, offerEbook, price, headline)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.offerRepository.Create(offer); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (s *offerServiceImpl) Toggle(offer *models.Offer) error {
+	offer.Active = !offer.Active
+	return s.offerRepository.Update(offer)
+}
+
+func (s *offerServiceImpl) Delete(offer *models.Offer) error {
+	return s.offerRepository.Delete(offer.ID)
+}
+
+func (s *offerServiceImpl) ActiveOffer(ebookID uint, offerType string) (*models.Offer, error) {
+	offer, err := s.offerRepository.FindActive(ebookID, offerType)
+	if err != nil || offer == nil {
+		return nil, err
+	}
+	// Ofertas de ebooks desativados não são exibidas
+	if !offer.OfferEbook.Status {
+		return nil, nil
+	}
+	return offer, nil
+}
+
+func (s *offerServiceImpl) ShowOffer(ebookID uint, offerType string) (*models.Offer, error) {
+	offer, err := s.ActiveOffer(ebookID, offerType)
+	if err != nil || offer == nil {
+		return nil, err
+	}
+
+	if err := s.offerRepository.IncrementImpressions(offer.ID); err != nil {
+		log.Printf("Erro ao registrar exibição da oferta %d: %v", offer.ID, err)
+	}
+	return offer, nil
+}
+
+func (s *offerServiceImpl) ShowUpsell(order *models.Order) (*models.Offer, error) {
+	if order == nil || !order.IsPaid() {
+		return nil, nil
+	}
+
+	offer, err := s.ActiveOffer(order.EbookID, models.OfferTypeUpsell)
+	if err != nil || offer == nil {
+		return nil, err
+	}
+
+	claimed, err := s.offerRepository.HasPaidOrder(offer.ID, order.ClientID)
+	if err != nil || claimed {
+		return nil, err
+	}
+
+	if err := s.offerRepository.IncrementImpressions(offer.ID); err != nil {
+		log.Printf("Erro ao registrar exibição da oferta %d: %v", offer.ID, err)
+	}
+	return offer, nil
+}
+
+func (s *offerServiceImpl) FindBump(offerID, ebookID uint) (*models.Offer, error) {
+	offer, err := s.offerRepository.FindByID(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil || !offer.Active || !offer.IsBump() || offer.EbookID != ebookID || !offer.OfferEbook.Status {
+		return nil, models.ErrOfferNotAvailable
+	}
+	return offer, nil
+}
+
+func (s *offerServiceImpl) AcceptUpsell(offer *models.Offer, sessionID string) (*models.Order, []*models.Purchase, error) {
+	if offer == nil || !offer.Active || !offer.IsUpsell() {
+		return nil, nil, models.ErrOfferNotAvailable
+	}
+
+	parent, err := s.orderService.FindByGatewaySessionID(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if parent == nil || !parent.IsPaid() || !containsEbook(parent.EbookIDs(), offer.EbookID) {
+		return nil, nil, models.ErrOfferNotAvailable
+	}
+
+	claimed, err := s.offerRepository.HasPaidOrder(offer.ID, parent.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if claimed {
+		return nil, nil, models.ErrOfferAlreadyClaimed
+	}
+
+	order, err := s.orderService.CreateUpsellOrder(offer, parent)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, nil, models.ErrOfferAlreadyClaimed
+	}
+
+	if len(parent.Payments) == 0 {
+		return order, nil, ErrUpsellChargeFailed
+	}
+
+	// Só uma requisição cobra o cartão; um clique duplo encontra a cobrança reservada
+	claimed, err = s.orderService.ClaimUpsellCharge(order)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !claimed {
+		return nil, nil, models.ErrOfferAlreadyClaimed
+	}
+
+	paymentID, err := s.paymentGateway.ChargeSavedPaymentMethod(
+		parent.Payments[0].GatewayPaymentID,
+		order.Total,
+		order.Currency,
+		offer.OfferEbook.Title,
+		order.UpsellKey,
+	)
+	if err != nil {
+		log.Printf("Erro ao cobrar upsell %d do pedido %d: %v", offer.ID, parent.ID, err)
+		if err := s.orderService.ReleaseUpsellCharge(order); err != nil {
+			log.Printf("Erro ao liberar cobrança do upsell %d do pedido %d: %v", offer.ID, parent.ID, err)
+		}
+		return order, nil, ErrUpsellChargeFailed
+	}
+
+	purchases, err := s.orderService.ConfirmPayment(ConfirmPaymentInput{
+		OrderID:          order.ID,
+		GatewayPaymentID: paymentID,
+		AmountPaid:       order.Total,
+		Currency:         string(order.Currency),
+	})
+	if err != nil {
+		return order, nil, err
+	}
+
+	return order, purchases, nil
+}
+
+func containsEbook(ebookIDs []uint, ebookID uint) bool {
+	for _, id := range ebookIDs {
+		if id == ebookID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newUpsellFixture() (*models.Offer, *models.Order) {
+	offer := &models.Offer{
+		Model:        gorm.Model{ID: 5},
+		Type:         models.OfferTypeUpsell,
+		EbookID:      1,
+		OfferEbookID: 2,
+		OfferEbook:   models.Ebook{Model: gorm.Model{ID: 2}, Title: "Guia Prático", Status: true},
+		Price:        money.New(1990, money.BRL),
+		Active:       true,
+	}
+
+	parent := models.NewOrder(7, 9, 1, 4990, 0, money.BRL)
+	parent.ID = 10
+	parent.Status = models.OrderStatusPaid
+	parent.Payments = []models.Payment{{GatewayPaymentID: "pi_original"}}
+
+	return offer, parent
+}
+
+func TestOfferService_AcceptUpsell_ChargesSavedCard(t *testing.T) {
+	offer, parent := newUpsellFixture()
+	offerRepo := new(repoMocks.MockOfferRepository)
+	orderRepo := new(repoMocks.MockOrderRepository)
+	gateway := new(MockPaymentGateway)
+
+	created := &models.Order{}
+	orderRepo.On("FindByGatewaySessionID", "cs_1").Return(parent, nil)
+	orderRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil).Run(func(args mock.Arguments) {
+		order := args.Get(0).(*models.Order)
+		order.ID = 20
+		*created = *order
+	})
+	orderRepo.On("FindByUpsellKey", "upsell_10_5").Return(nil, nil)
+	orderRepo.On("ClaimCharge", uint(20), mock.AnythingOfType("time.Time")).Return(true, nil)
+	orderRepo.On("FindByID", uint(20)).Return(created, nil)
+	orderRepo.On("ConfirmPayment", created, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("[]*models.Purchase")).Return(nil)
+	offerRepo.On("HasPaidOrder", uint(5), uint(9)).Return(false, nil)
+	gateway.On("ChargeSavedPaymentMethod", "pi_original", int64(1990), money.BRL, "Guia Prático", "upsell_10_5").Return("pi_upsell", nil)
+
+	service := NewOfferService(offerRepo, nil, NewOrderService(orderRepo), gateway)
+
+	order, purchases, err := service.AcceptUpsell(offer, "cs_1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), order.ClientID)
+	assert.Len(t, purchases, 1)
+	assert.Equal(t, uint(2), purchases[0].EbookID)
+	orderRepo.AssertExpectations(t)
+	gateway.AssertExpectations(t)
+}
+
+func TestOfferService_AcceptUpsell_RejectsClaimedOffer(t *testing.T) {
+	offer, parent := newUpsellFixture()
+	offerRepo := new(repoMocks.MockOfferRepository)
+	orderRepo := new(repoMocks.MockOrderRepository)
+
+	orderRepo.On("FindByGatewaySessionID", "cs_1").Return(parent, nil)
+	offerRepo.On("HasPaidOrder", uint(5), uint(9)).Return(true, nil)
+
+	service := NewOfferService(offerRepo, nil, NewOrderService(orderRepo), new(MockPaymentGateway))
+
+	_, _, err := service.AcceptUpsell(offer, "cs_1")
+
+	assert.ErrorIs(t, err, models.ErrOfferAlreadyClaimed)
+	orderRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestOfferService_AcceptUpsell_ReturnsPendingOrderWhenChargeFails(t *testing.T) {
+	offer, parent := newUpsellFixture()
+	offerRepo := new(repoMocks.MockOfferRepository)
+	orderRepo := new(repoMocks.MockOrderRepository)
+	gateway := new(MockPaymentGateway)
+
+	orderRepo.On("FindByGatewaySessionID", "cs_1").Return(parent, nil)
+	orderRepo.On("FindByUpsellKey", "upsell_10_5").Return(nil, nil)
+	orderRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+	orderRepo.On("ClaimCharge", uint(0), mock.AnythingOfType("time.Time")).Return(true, nil)
+	orderRepo.On("ReleaseCharge", uint(0)).Return(nil)
+	offerRepo.On("HasPaidOrder", uint(5), uint(9)).Return(false, nil)
+	gateway.On("ChargeSavedPaymentMethod", "pi_original", int64(1990), money.BRL, "Guia Prático", "upsell_10_5").Return("", errors.New("card_declined"))
+
+	service := NewOfferService(offerRepo, nil, NewOrderService(orderRepo), gateway)
+
+	order, purchases, err := service.AcceptUpsell(offer, "cs_1")
+
+	assert.ErrorIs(t, err, ErrUpsellChargeFailed)
+	assert.NotNil(t, order)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	assert.Empty(t, purchases)
+	orderRepo.AssertNotCalled(t, "ConfirmPayment", mock.Anything, mock.Anything, mock.Anything)
+	orderRepo.AssertCalled(t, "ReleaseCharge", uint(0))
+}
+
+func TestOfferService_AcceptUpsell_ReusesPendingOrderAndSkipsChargeInProgress(t *testing.T) {
+	offer, parent := newUpsellFixture()
+	offerRepo := new(repoMocks.MockOfferRepository)
+	orderRepo := new(repoMocks.MockOrderRepository)
+	gateway := new(MockPaymentGateway)
+
+	// O primeiro clique já criou o pedido e está cobrando o cartão
+	pending := models.NewOfferOrder(offer, 7, 9)
+	pending.ID = 20
+	pending.UpsellKey = "upsell_10_5"
+	orderRepo.On("FindByGatewaySessionID", "cs_1").Return(parent, nil)
+	orderRepo.On("FindByUpsellKey", "upsell_10_5").Return(pending, nil)
+	orderRepo.On("ClaimCharge", uint(20), mock.AnythingOfType("time.Time")).Return(false, nil)
+	offerRepo.On("HasPaidOrder", uint(5), uint(9)).Return(false, nil)
+
+	service := NewOfferService(offerRepo, nil, NewOrderService(orderRepo), gateway)
+
+	_, _, err := service.AcceptUpsell(offer, "cs_1")
+
+	assert.ErrorIs(t, err, models.ErrOfferAlreadyClaimed)
+	orderRepo.AssertNotCalled(t, "Create", mock.Anything)
+	gateway.AssertNotCalled(t, "ChargeSavedPaymentMethod", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
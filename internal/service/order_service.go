@@ -0,0 +1,406 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
+type OrderService interface {
+	// CreatePendingOrder registra o pedido do ebook; o order bump aceito no checkout é opcional.
+	// amount é o valor escolhido pelo comprador em "pague quanto quiser" (zero assume a
+	// sugestão) e é ignorado nas demais modalidades.
+	CreatePendingOrder(ebook *models.Ebook, client *models.Client, amount int64, bump *models.Offer) (*models.Order, error)
+	CreatePendingCartOrder(cart *models.Cart, client *models.Client) (*models.Order, error)
+	CreatePendingBundleOrder(bundle *models.Bundle, client *models.Client) (*models.Order, error)
+	// CreatePendingLicenseOrder registra o pedido de várias licenças do ebook para uma equipe
+	CreatePendingLicenseOrder(ebook *models.Ebook, client *models.Client, amount int64, seats int) (*models.Order, error)
+	CreateUpsellOrder(offer *models.Offer, parent *models.Order) (*models.Order, error)
+	// ClaimUpsellCharge reserva a cobrança do upsell; false se ela já estiver em andamento
+	ClaimUpsellCharge(order *models.Order) (bool, error)
+	ReleaseUpsellCharge(order *models.Order) error
+	FindByGatewaySessionID(sessionID string) (*models.Order, error)
+	AttachCheckoutSession(order *models.Order, sessionID string) error
+	ConfirmPayment(input ConfirmPaymentInput) ([]*models.Purchase, error)
+	// ConfirmMembershipPayment registra como pedido do criador a fatura paga de um
+	// período da assinatura do catálogo; faturas já registradas são ignoradas
+	ConfirmMembershipPayment(member *models.Member, input ConfirmPaymentInput) error
+	RegisterFee(gatewayPaymentID string, fee int64) error
+	RefundPayment(gatewayPaymentID string, totalRefunded int64) error
+	DisputePayment(gatewayPaymentID string) error
+	ResolveDispute(gatewayPaymentID string, won bool) error
+}
+
+type ConfirmPaymentInput struct {
+	OrderID          uint
+	GatewaySessionID string
+	GatewayPaymentID string
+	GatewayInvoiceID string // fatura paga da assinatura do catálogo
+	AmountPaid       int64
+	Currency         string
+}
+
+type orderServiceImpl struct {
+	orderRepository    repository.OrderRepository
+	platformFeePercent int
+	payoutHold         time.Duration
+}
+
+func NewOrderService(orderRepository repository.OrderRepository) OrderService {
+	return &orderServiceImpl{
+		orderRepository:    orderRepository,
+		platformFeePercent: config.AppConfig.PlatformFeePercent,
+		payoutHold:         time.Duration(config.AppConfig.PayoutHoldDays) * 24 * time.Hour,
+	}
+}
+
+func (s *orderServiceImpl) CreatePendingOrder(ebook *models.Ebook, client *models.Client, amount int64, bump *models.Offer) (*models.Order, error) {
+	if ebook == nil || ebook.ID == 0 {
+		return nil, errors.New("ebook é obrigatório")
+	}
+	if client == nil || client.ID == 0 {
+		return nil, errors.New("cliente é obrigatório")
+	}
+
+	// O pedido guarda o preço em vigor agora; a promoção pode terminar antes do pagamento
+	item, err := ebook.CheckoutItem(amount, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	order := models.NewOrder(ebook.CreatorID, client.ID, ebook.ID, item.UnitPrice, 0, item.Currency)
+	order.Items = []models.OrderItem{item}
+	if bump != nil {
+		order.AddOffer(bump)
+	}
+
+	if err := s.orderRepository.Create(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CreatePendingCartOrder registra um único pedido com um item por ebook do carrinho
+func (s *orderServiceImpl) CreatePendingCartOrder(cart *models.Cart, client *models.Client) (*models.Order, error) {
+	if cart == nil || cart.IsEmpty() {
+		return nil, errors.New("carrinho vazio")
+	}
+	if client == nil || client.ID == 0 {
+		return nil, errors.New("cliente é obrigatório")
+	}
+
+	order := models.NewCartOrder(cart, client.ID)
+
+	if err := s.orderRepository.Create(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CreatePendingBundleOrder registra o pedido de um kit, com um item por ebook incluído
+func (s *orderServiceImpl) CreatePendingBundleOrder(bundle *models.Bundle, client *models.Client) (*models.Order, error) {
+	if bundle == nil || bundle.ID == 0 || len(bundle.Ebooks) == 0 {
+		return nil, errors.New("kit é obrigatório")
+	}
+	if client == nil || client.ID == 0 {
+		return nil, errors.New("cliente é obrigatório")
+	}
+
+	order := models.NewBundleOrder(bundle, client.ID)
+
+	if err := s.orderRepository.Create(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *orderServiceImpl) CreatePendingLicenseOrder(ebook *models.Ebook, client *models.Client, amount int64, seats int) (*models.Order, error) {
+	if ebook == nil || ebook.ID == 0 {
+		return nil, errors.New("ebook é obrigatório")
+	}
+	if client == nil || client.ID == 0 {
+		return nil, errors.New("cliente é obrigatório")
+	}
+
+	item, err := ebook.CheckoutItem(amount, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	order, err := models.NewLicenseOrder(item, ebook.CreatorID, client.ID, seats)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.orderRepository.Create(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CreateUpsellOrder registra o pedido do upsell aceito para o mesmo cliente do
+// pedido original. Aceites repetidos da mesma oferta reaproveitam o pedido já criado.
+func (s *orderServiceImpl) CreateUpsellOrder(offer *models.Offer, parent *models.Order) (*models.Order, error) {
+	if offer == nil || offer.ID == 0 {
+		return nil, errors.New("oferta é obrigatória")
+	}
+	if parent == nil || parent.ClientID == 0 {
+		return nil, errors.New("pedido original é obrigatório")
+	}
+
+	key := models.UpsellKey(parent.ID, offer.ID)
+	existing, err := s.orderRepository.FindByUpsellKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	order := models.NewOfferOrder(offer, parent.CreatorID, parent.ClientID)
+	order.UpsellKey = key
+	// O upsell é consequência da venda indicada e também gera comissão ao afiliado
+	order.AffiliateID = parent.AffiliateID
+
+	if err := s.orderRepository.Create(order); err != nil {
+		// Outra requisição criou o mesmo pedido ao mesmo tempo; o índice único impede o segundo
+		if existing, findErr := s.orderRepository.FindByUpsellKey(key); findErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (s *orderServiceImpl) ClaimUpsellCharge(order *models.Order) (bool, error) {
+	return s.orderRepository.ClaimCharge(order.ID, time.Now())
+}
+
+func (s *orderServiceImpl) ReleaseUpsellCharge(order *models.Order) error {
+	return s.orderRepository.ReleaseCharge(order.ID)
+}
+
+func (s *orderServiceImpl) FindByGatewaySessionID(sessionID string) (*models.Order, error) {
+	if sessionID == "" {
+		return nil, errors.New("ID da sessão é obrigatório")
+	}
+
+	return s.orderRepository.FindByGatewaySessionID(sessionID)
+}
+
+func (s *orderServiceImpl) AttachCheckoutSession(order *models.Order, sessionID string) error {
+	if order == nil {
+		return errors.New("pedido é obrigatório")
+	}
+	if sessionID == "" {
+		return errors.New("ID da sessão é obrigatório")
+	}
+
+	order.GatewaySessionID = sessionID
+	return s.orderRepository.Update(order)
+}
+
+// ConfirmPayment registra o pagamento do pedido e cria uma compra para cada ebook
+// do pedido, liberando o acesso. Chamadas repetidas para o mesmo pedido não criam novas compras
+// e retornam uma lista vazia.
+func (s *orderServiceImpl) ConfirmPayment(input ConfirmPaymentInput) ([]*models.Purchase, error) {
+	order, err := s.findOrderForPayment(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.IsPaid() || order.Status == models.OrderStatusRefunded {
+		log.Printf("Pedido %d já confirmado, ignorando", order.ID)
+		return []*models.Purchase{}, nil
+	}
+
+	if err := order.MarkPaid(); err != nil {
+		return nil, err
+	}
+
+	if input.AmountPaid != order.Total {
+		log.Printf("Valor pago (%d) diferente do total do pedido %d (%d)", input.AmountPaid, order.ID, order.Total)
+	}
+
+	currency, err := money.ParseCurrency(input.Currency)
+	if err != nil {
+		currency = order.Currency
+	}
+	payment := models.NewPayment(order.ID, order.Gateway, input.GatewayPaymentID, input.AmountPaid, currency)
+	payment.Order = order
+	payment.RecordSale(s.platformFeePercent, time.Now().Add(s.payoutHold))
+
+	// Nos presentes, quem pagou não recebe o ebook: a compra sai no resgate. Nas
+	// licenças para equipe, cada pessoa atribuída pelo gestor recebe a sua. Nas
+	// assinaturas, o catálogo é liberado na renovação do assinante.
+	var purchases []*models.Purchase
+	if !order.IsGift && !order.IsLicense() && !order.IsMembership() {
+		purchases = orderPurchases(order, order.ClientID)
+	}
+
+	err = s.orderRepository.ConfirmPayment(order, payment, purchases)
+	if errors.Is(err, repository.ErrOrderAlreadyPaid) {
+		log.Printf("Pedido %d confirmado por outra requisição, ignorando", order.ID)
+		return []*models.Purchase{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return purchases, nil
+}
+
+func (s *orderServiceImpl) ConfirmMembershipPayment(member *models.Member, input ConfirmPaymentInput) error {
+	if member == nil || member.ID == 0 {
+		return errors.New("assinante é obrigatório")
+	}
+	if input.GatewayInvoiceID == "" {
+		return errors.New("ID da fatura é obrigatório")
+	}
+
+	currency, err := money.ParseCurrency(input.Currency)
+	if err != nil {
+		currency = member.Membership.Price.Currency
+	}
+	order, err := s.orderRepository.CreateForInvoice(models.NewMembershipOrder(member, input.GatewayInvoiceID, input.AmountPaid, currency))
+	if err != nil {
+		return err
+	}
+
+	input.OrderID = order.ID
+	input.GatewaySessionID = ""
+	_, err = s.ConfirmPayment(input)
+	return err
+}
+
+// orderPurchases cria as compras que liberam os ebooks do pedido para o cliente
+func orderPurchases(order *models.Order, clientID uint) []*models.Purchase {
+	var purchases []*models.Purchase
+	for _, ebookID := range order.EbookIDs() {
+		// Limites e validade vêm da política de entrega do ebook, aplicada ao gravar
+		purchases = append(purchases, models.NewPurchase(ebookID, clientID))
+	}
+	return purchases
+}
+
+func (s *orderServiceImpl) RegisterFee(gatewayPaymentID string, fee int64) error {
+	payment, err := s.findPayment(gatewayPaymentID)
+	if err != nil {
+		return err
+	}
+
+	payment.Fee = fee
+	return s.orderRepository.UpdatePayment(payment, nil)
+}
+
+func (s *orderServiceImpl) RefundPayment(gatewayPaymentID string, totalRefunded int64) error {
+	payment, err := s.findPayment(gatewayPaymentID)
+	if err != nil {
+		return err
+	}
+
+	previousRefunded := payment.RefundedAmount
+	payment.Refund(totalRefunded)
+	payment.RecordRefund(previousRefunded)
+
+	var order *models.Order
+	if payment.IsFullyRefunded() && payment.Order != nil {
+		order = payment.Order
+		if err := order.MarkRefunded(); err != nil && order.Status != models.OrderStatusRefunded {
+			return err
+		}
+	}
+
+	return s.orderRepository.UpdatePayment(payment, order)
+}
+
+func (s *orderServiceImpl) DisputePayment(gatewayPaymentID string) error {
+	payment, err := s.findPayment(gatewayPaymentID)
+	if err != nil {
+		return err
+	}
+
+	payment.Dispute()
+
+	order := payment.Order
+	if order != nil {
+		if err := order.MarkDisputed(); err != nil {
+			return err
+		}
+	}
+
+	return s.orderRepository.UpdatePayment(payment, order)
+}
+
+func (s *orderServiceImpl) ResolveDispute(gatewayPaymentID string, won bool) error {
+	payment, err := s.findPayment(gatewayPaymentID)
+	if err != nil {
+		return err
+	}
+
+	payment.ResolveDispute(won)
+
+	order := payment.Order
+	if order != nil {
+		if won {
+			err = order.MarkPaid()
+		} else {
+			err = order.MarkRefunded()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.orderRepository.UpdatePayment(payment, order)
+}
+
+func (s *orderServiceImpl) findOrderForPayment(input ConfirmPaymentInput) (*models.Order, error) {
+	var order *models.Order
+	var err error
+
+	if input.GatewaySessionID != "" {
+		order, err = s.orderRepository.FindByGatewaySessionID(input.GatewaySessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if order == nil && input.OrderID != 0 {
+		order, err = s.orderRepository.FindByID(input.OrderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if order == nil {
+		return nil, fmt.Errorf("pedido não encontrado para a sessão %s", input.GatewaySessionID)
+	}
+
+	return order, nil
+}
+
+func (s *orderServiceImpl) findPayment(gatewayPaymentID string) (*models.Payment, error) {
+	if gatewayPaymentID == "" {
+		return nil, errors.New("ID do pagamento é obrigatório")
+	}
+
+	payment, err := s.orderRepository.FindPaymentByGatewayPaymentID(gatewayPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, fmt.Errorf("pagamento %s não encontrado", gatewayPaymentID)
+	}
+
+	return payment, nil
+}
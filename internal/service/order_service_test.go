@@ -0,0 +1,355 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOrderService_CreatePendingOrder_UsesEbookPrice(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	ebook := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 1, Price: money.New(1999, money.BRL)}
+	client := &models.Client{Model: gorm.Model{ID: 2}}
+
+	order, err := service.CreatePendingOrder(ebook, client, 0, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1999), order.Total)
+	assert.Equal(t, uint(1), order.CreatorID)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_ConfirmPayment_CreatesPaymentAndPurchase(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+	order.ID = 10
+
+	mockRepo.On("FindByGatewaySessionID", "cs_1").Return(order, nil)
+	mockRepo.On("ConfirmPayment", order, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("[]*models.Purchase")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	purchases, err := service.ConfirmPayment(ConfirmPaymentInput{
+		GatewaySessionID: "cs_1",
+		GatewayPaymentID: "pi_1",
+		AmountPaid:       5000,
+		Currency:         "brl",
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 1)
+	assert.Equal(t, uint(3), purchases[0].EbookID)
+	assert.Equal(t, uint(2), purchases[0].ClientID)
+	assert.True(t, order.IsPaid())
+
+	payment := mockRepo.Calls[1].Arguments.Get(1).(*models.Payment)
+	assert.Equal(t, "pi_1", payment.GatewayPaymentID)
+	assert.Equal(t, int64(5000), payment.Amount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_ConfirmPayment_UnlimitedPolicyNeverExpires(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	db.AutoMigrate(&models.Ebook{}, &models.Bundle{}, &models.Offer{}, &models.Order{}, &models.OrderItem{}, &models.Payment{},
+		&models.Purchase{}, &models.CommissionRule{}, &models.Commission{}, &models.CheckoutAttempt{}, &models.BalanceEntry{})
+	orderRepository := repository.NewGormOrderRepository(db)
+
+	// "Sem limite" no prazo do link
+	ebook := &models.Ebook{Title: "Ebook", CreatorID: 1, Price: money.New(5000, money.BRL),
+		DownloadPolicy: models.DownloadPolicy{MaxDownloads: 3, ExpiryDays: 0}}
+	db.Create(ebook)
+	order := models.NewOrder(1, 2, ebook.ID, 5000, 0, money.BRL)
+	order.Items = []models.OrderItem{models.NewOrderItem(ebook)}
+	order.GatewaySessionID = "cs_1"
+	assert.NoError(t, orderRepository.Create(order))
+
+	purchases, err := NewOrderService(orderRepository).ConfirmPayment(ConfirmPaymentInput{
+		GatewaySessionID: "cs_1",
+		GatewayPaymentID: "pi_1",
+		AmountPaid:       5000,
+		Currency:         "brl",
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, purchases, 1) {
+		var saved models.Purchase
+		db.First(&saved, purchases[0].ID)
+		assert.True(t, saved.ExpiresAt.IsZero())
+		assert.False(t, saved.IsExpired())
+		assert.Equal(t, 3, saved.DownloadLimit)
+	}
+}
+
+func TestOrderService_ConfirmMembershipPayment_RecordsInvoiceOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	db.AutoMigrate(&models.Ebook{}, &models.Bundle{}, &models.Offer{}, &models.Order{}, &models.OrderItem{}, &models.Payment{},
+		&models.Purchase{}, &models.CommissionRule{}, &models.Commission{}, &models.CheckoutAttempt{}, &models.BalanceEntry{})
+	service := NewOrderService(repository.NewGormOrderRepository(db))
+
+	membership, _ := models.NewMembership(1, "Catálogo", "", money.New(2990, money.BRL), models.MembershipIntervalMonth)
+	member := models.NewMember(membership, 2, "assinante")
+	member.ID = 9
+	input := ConfirmPaymentInput{GatewayInvoiceID: "in_1", GatewayPaymentID: "pi_1", AmountPaid: 2990, Currency: "brl"}
+
+	assert.NoError(t, service.ConfirmMembershipPayment(member, input))
+	// O Stripe pode reenviar o mesmo invoice.paid
+	assert.NoError(t, service.ConfirmMembershipPayment(member, input))
+
+	var orders []models.Order
+	db.Find(&orders)
+	if assert.Len(t, orders, 1) {
+		assert.True(t, orders[0].IsPaid())
+		assert.Equal(t, "in_1", orders[0].GatewayInvoiceID)
+	}
+	var payments int64
+	db.Model(&models.Payment{}).Where("gateway_payment_id = ?", "pi_1").Count(&payments)
+	assert.Equal(t, int64(1), payments)
+	var purchases int64
+	db.Model(&models.Purchase{}).Count(&purchases)
+	assert.Zero(t, purchases)
+
+	var entries []models.BalanceEntry
+	db.Order("id").Find(&entries)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, models.BalanceEntrySale, entries[0].Type)
+		assert.Equal(t, int64(2990), entries[0].Amount)
+		assert.Equal(t, uint(1), entries[0].CreatorID)
+	}
+}
+
+func TestOrderService_ConfirmPayment_GiftOrderWaitsForClaim(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+	order.ID = 10
+	order.IsGift = true
+
+	mockRepo.On("FindByGatewaySessionID", "cs_1").Return(order, nil)
+	mockRepo.On("ConfirmPayment", order, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("[]*models.Purchase")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	purchases, err := service.ConfirmPayment(ConfirmPaymentInput{GatewaySessionID: "cs_1", AmountPaid: 5000, Currency: "brl"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, purchases)
+	assert.True(t, order.IsPaid())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_CreatePendingLicenseOrder_ChargesEverySeat(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+	ebook := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 1, Price: money.New(4000, money.BRL)}
+	client := &models.Client{Model: gorm.Model{ID: 2}}
+
+	order, err := service.CreatePendingLicenseOrder(ebook, client, 0, 20)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(80000), order.Total)
+	assert.Equal(t, 20, order.Items[0].Quantity)
+	assert.True(t, order.IsLicense())
+
+	_, err = service.CreatePendingLicenseOrder(ebook, client, 0, 1)
+	assert.ErrorIs(t, err, models.ErrLicenseSeatsRange)
+}
+
+func TestOrderService_ConfirmPayment_LicenseOrderWaitsForAssignment(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order, _ := models.NewLicenseOrder(models.OrderItem{EbookID: 3, UnitPrice: 4000, Currency: money.BRL}, 1, 2, 20)
+	order.ID = 10
+
+	mockRepo.On("FindByGatewaySessionID", "cs_1").Return(order, nil)
+	mockRepo.On("ConfirmPayment", order, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("[]*models.Purchase")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	purchases, err := service.ConfirmPayment(ConfirmPaymentInput{GatewaySessionID: "cs_1", AmountPaid: 80000, Currency: "brl"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, purchases)
+	assert.True(t, order.IsPaid())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_ConfirmPayment_CreatesOnePurchasePerCartItem(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	cart := models.NewCart()
+	cart.Add(&models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 1, Price: money.New(1000, money.BRL)})
+	cart.Add(&models.Ebook{Model: gorm.Model{ID: 4}, CreatorID: 1, Price: money.New(2000, money.BRL)})
+
+	order, err := service.CreatePendingCartOrder(cart, &models.Client{Model: gorm.Model{ID: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3000), order.Total)
+
+	mockRepo.On("FindByGatewaySessionID", "cs_1").Return(order, nil)
+	mockRepo.On("ConfirmPayment", order, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("[]*models.Purchase")).Return(nil)
+
+	purchases, err := service.ConfirmPayment(ConfirmPaymentInput{GatewaySessionID: "cs_1", AmountPaid: 3000, Currency: "brl"})
+
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 2)
+	assert.Equal(t, uint(3), purchases[0].EbookID)
+	assert.Equal(t, uint(4), purchases[1].EbookID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_CreatePendingBundleOrder_GrantsEachEbook(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	bundle := models.NewBundle("Kit", "Kit", "Kit", money.New(4000, money.BRL), models.Creator{Model: gorm.Model{ID: 1}})
+	bundle.ID = 5
+	bundle.SetEbooks([]*models.Ebook{
+		{Model: gorm.Model{ID: 3}, CreatorID: 1, Price: money.New(3000, money.BRL)},
+		{Model: gorm.Model{ID: 4}, CreatorID: 1, Price: money.New(3000, money.BRL)},
+	})
+
+	order, err := service.CreatePendingBundleOrder(bundle, &models.Client{Model: gorm.Model{ID: 2}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), order.Total)
+	assert.Equal(t, uint(5), *order.BundleID)
+	assert.Equal(t, []uint{3, 4}, order.EbookIDs())
+	assert.Equal(t, int64(2000), order.Items[0].UnitPrice)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_ConfirmPayment_IsIdempotent(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+	order.ID = 10
+	order.MarkPaid()
+
+	mockRepo.On("FindByGatewaySessionID", "cs_1").Return(order, nil)
+
+	service := NewOrderService(mockRepo)
+
+	purchases, err := service.ConfirmPayment(ConfirmPaymentInput{GatewaySessionID: "cs_1"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, purchases)
+	mockRepo.AssertNotCalled(t, "ConfirmPayment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderService_ConfirmPayment_ConcurrentConfirmationCreatesNothing(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+	order.ID = 10
+
+	mockRepo.On("FindByGatewaySessionID", "cs_1").Return(nil, nil)
+	mockRepo.On("FindByID", uint(10)).Return(order, nil)
+	mockRepo.On("ConfirmPayment", order, mock.Anything, mock.Anything).Return(repository.ErrOrderAlreadyPaid)
+
+	service := NewOrderService(mockRepo)
+
+	purchases, err := service.ConfirmPayment(ConfirmPaymentInput{GatewaySessionID: "cs_1", OrderID: 10})
+
+	assert.NoError(t, err)
+	assert.Empty(t, purchases)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_RefundPayment_FullRefundMarksOrderRefunded(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+	order.MarkPaid()
+	payment := models.NewPayment(10, "stripe", "pi_1", 5000, money.BRL)
+	payment.Order = order
+
+	mockRepo.On("FindPaymentByGatewayPaymentID", "pi_1").Return(payment, nil)
+	mockRepo.On("UpdatePayment", payment, order).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	err := service.RefundPayment("pi_1", 5000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusRefunded, payment.Status)
+	assert.Equal(t, models.OrderStatusRefunded, order.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_DisputeLost_RefundsOrder(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	order := models.NewOrder(1, 2, 3, 5000, 0, money.BRL)
+	order.MarkPaid()
+	payment := models.NewPayment(10, "stripe", "pi_1", 5000, money.BRL)
+	payment.Order = order
+
+	mockRepo.On("FindPaymentByGatewayPaymentID", "pi_1").Return(payment, nil)
+	mockRepo.On("UpdatePayment", payment, order).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	assert.NoError(t, service.DisputePayment("pi_1"))
+	assert.Equal(t, models.OrderStatusDisputed, order.Status)
+
+	assert.NoError(t, service.ResolveDispute("pi_1", false))
+	assert.Equal(t, models.OrderStatusRefunded, order.Status)
+	assert.Equal(t, int64(5000), payment.RefundedAmount)
+}
+
+func TestOrderService_CreatePendingOrder_AddsBump(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	ebook := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 1, Price: money.New(1999, money.BRL)}
+	client := &models.Client{Model: gorm.Model{ID: 2}}
+	bump := &models.Offer{Model: gorm.Model{ID: 8}, Type: models.OfferTypeBump, EbookID: 3, OfferEbookID: 4, Price: money.New(990, money.BRL)}
+
+	order, err := service.CreatePendingOrder(ebook, client, 0, bump)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2989), order.Total)
+	assert.Equal(t, []uint{3, 4}, order.EbookIDs())
+	assert.Equal(t, []uint{8}, order.OfferIDs())
+}
+
+func TestOrderService_CreatePendingOrder_UsesActivePromotion(t *testing.T) {
+	mockRepo := new(repoMocks.MockOrderRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Order")).Return(nil)
+
+	service := NewOrderService(mockRepo)
+
+	ebook := &models.Ebook{Model: gorm.Model{ID: 3}, CreatorID: 1, Price: money.New(4900, money.BRL)}
+	ebook.PricePeriods = []*models.EbookPricePeriod{{
+		Model:    gorm.Model{ID: 8},
+		EbookID:  3,
+		Price:    money.New(2900, money.BRL),
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(time.Hour),
+	}}
+	client := &models.Client{Model: gorm.Model{ID: 2}}
+
+	order, err := service.CreatePendingOrder(ebook, client, 0, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2900), order.Total)
+	assert.Equal(t, int64(4900), order.Items[0].ListPrice)
+	assert.Equal(t, uint(8), *order.Items[0].PricePeriodID)
+}
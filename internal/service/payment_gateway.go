@@ -1,8 +1,56 @@
 package service
 
+import (
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
+)
+
 // PaymentGateway interface for payment operations
 type PaymentGateway interface {
 	CreateCustomer(email, name string) (string, error)
 	CreateSubscription(customerID, priceID string) (string, error)
 	CancelSubscription(subscriptionID string) error
+	// UpdateSubscriptionPrice troca o preço da assinatura cobrando ou creditando a diferença proporcional
+	UpdateSubscriptionPrice(subscriptionID, priceID string) error
+	// ScheduleSubscriptionCancellation cancela a assinatura ao fim do período atual e retorna a data de término
+	ScheduleSubscriptionCancellation(subscriptionID string) (time.Time, error)
+	// ResumeSubscription desfaz um cancelamento agendado
+	ResumeSubscription(subscriptionID string) error
+	ListInvoices(customerID string) ([]*models.Invoice, error)
+	// CreatePortalSession retorna a URL do portal do gateway para o cliente atualizar o cartão
+	CreatePortalSession(customerID, returnURL string) (string, error)
+	// ChargeSavedPaymentMethod cobra novamente, sem interação do comprador, o meio de
+	// pagamento usado no pagamento informado e retorna o ID da nova cobrança. A
+	// chave de idempotência faz repetições da mesma cobrança devolverem a primeira.
+	ChargeSavedPaymentMethod(paymentID string, amount int64, currency money.Currency, description, idempotencyKey string) (string, error)
+	// CreateConnectedAccount cria a conta recebedora do criador e retorna o ID dela no gateway
+	CreateConnectedAccount(email string) (string, error)
+	// CreateAccountLink retorna a URL onde o criador cadastra os dados para receber os repasses
+	CreateAccountLink(accountID, refreshURL, returnURL string) (string, error)
+	// PayoutsEnabled indica se a conta recebedora já pode receber repasses
+	PayoutsEnabled(accountID string) (bool, error)
+	// Transfer repassa o valor do saldo da plataforma para a conta recebedora e retorna o ID da transferência
+	Transfer(accountID string, amount int64, currency money.Currency, reference string) (string, error)
+	// CreateRecurringCheckout abre o checkout de uma cobrança recorrente e retorna o ID e a URL da sessão
+	CreateRecurringCheckout(checkout models.RecurringCheckout) (string, string, error)
+	// GetRecurringCheckout consulta o pagamento e a assinatura criada pela sessão de checkout
+	GetRecurringCheckout(sessionID string) (*models.RecurringCheckoutResult, error)
+	// BalanceTransactionFee consulta a taxa cobrada pelo gateway na movimentação de saldo informada
+	BalanceTransactionFee(balanceTransactionID string) (int64, error)
+	// CreateCoupon cria um cupom de uso único com o desconto informado e retorna o ID dele
+	CreateCoupon(amountOff int64, currency money.Currency, name string) (string, error)
+}
+
+// NewPaymentGatewayFromConfig escolhe o gateway pela config PAYMENT_GATEWAY:
+// "fake" usa o gateway em memória; qualquer outro valor, o Stripe
+func NewPaymentGatewayFromConfig() PaymentGateway {
+	if config.AppConfig.PaymentGateway == "fake" {
+		log.Println("Usando gateway de pagamento em memória (PAYMENT_GATEWAY=fake)")
+		return NewFakePaymentGateway()
+	}
+	return NewStripePaymentGateway(NewStripeService())
 }
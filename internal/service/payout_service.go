@@ -0,0 +1,261 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+// PayoutService gerencia a conta recebedora do criador, o extrato do saldo e os
+// repasses do saldo disponível para o gateway
+type PayoutService interface {
+	Statement(creatorID uint) (*PayoutStatement, error)
+	// StartOnboarding cria a conta recebedora na primeira vez e retorna a URL de cadastro no gateway
+	StartOnboarding(creator *models.Creator, refreshURL, returnURL string) (string, error)
+	// RefreshAccount consulta no gateway se a conta recebedora já pode receber repasses
+	RefreshAccount(creatorID uint) (*models.PayoutAccount, error)
+	SetSchedule(creatorID uint, schedule string) error
+	// PayoutCreator transfere todo o saldo disponível do criador, um repasse por moeda
+	PayoutCreator(creatorID uint) ([]*models.Payout, error)
+	// RunScheduledPayouts repassa o saldo dos criadores cujo período de repasse chegou
+	RunScheduledPayouts(now time.Time) (int, error)
+	StartScheduler(interval time.Duration)
+}
+
+// PayoutStatement reúne o que o criador vê no extrato
+type PayoutStatement struct {
+	Account    *models.PayoutAccount
+	Balances   []*models.Balance
+	Entries    []*models.BalanceEntry
+	Payouts    []*models.Payout
+	FeePercent int
+	HoldDays   int
+}
+
+type payoutServiceImpl struct {
+	payoutRepository repository.PayoutRepository
+	gateway          PaymentGateway
+	feePercent       int
+	holdDays         int
+}
+
+func NewPayoutService(
+	payoutRepository repository.PayoutRepository,
+	gateway PaymentGateway,
+	feePercent int,
+	holdDays int,
+) PayoutService {
+	return &payoutServiceImpl{
+		payoutRepository: payoutRepository,
+		gateway:          gateway,
+		feePercent:       feePercent,
+		holdDays:         holdDays,
+	}
+}
+
+func (s *payoutServiceImpl) Statement(creatorID uint) (*PayoutStatement, error) {
+	account, err := s.payoutRepository.FindAccountByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.payoutRepository.FindEntriesByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	payouts, err := s.payoutRepository.FindPayoutsByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PayoutStatement{
+		Account:    account,
+		Balances:   models.SummarizeBalance(entries, time.Now()),
+		Entries:    entries,
+		Payouts:    payouts,
+		FeePercent: s.feePercent,
+		HoldDays:   s.holdDays,
+	}, nil
+}
+
+func (s *payoutServiceImpl) StartOnboarding(creator *models.Creator, refreshURL, returnURL string) (string, error) {
+	if creator == nil || creator.ID == 0 {
+		return "", errors.New("criador é obrigatório")
+	}
+
+	account, err := s.payoutRepository.FindAccountByCreator(creator.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if account == nil {
+		accountID, err := s.gateway.CreateConnectedAccount(creator.Email)
+		if err != nil {
+			log.Printf("Erro ao criar conta recebedora do criador %d: %v", creator.ID, err)
+			return "", errors.New("erro ao criar conta recebedora")
+		}
+		account = models.NewPayoutAccount(creator.ID, accountID)
+		if err := s.payoutRepository.SaveAccount(account); err != nil {
+			return "", err
+		}
+	}
+
+	url, err := s.gateway.CreateAccountLink(account.GatewayAccountID, refreshURL, returnURL)
+	if err != nil {
+		log.Printf("Erro ao gerar link de cadastro da conta %s: %v", account.GatewayAccountID, err)
+		return "", errors.New("erro ao abrir cadastro da conta recebedora")
+	}
+	return url, nil
+}
+
+func (s *payoutServiceImpl) RefreshAccount(creatorID uint) (*models.PayoutAccount, error) {
+	account, err := s.payoutRepository.FindAccountByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, models.ErrPayoutAccountNotReady
+	}
+
+	enabled, err := s.gateway.PayoutsEnabled(account.GatewayAccountID)
+	if err != nil {
+		log.Printf("Erro ao consultar conta recebedora %s: %v", account.GatewayAccountID, err)
+		return nil, errors.New("erro ao consultar conta recebedora")
+	}
+
+	if account.PayoutsEnabled != enabled {
+		account.PayoutsEnabled = enabled
+		if err := s.payoutRepository.SaveAccount(account); err != nil {
+			return nil, err
+		}
+	}
+	return account, nil
+}
+
+func (s *payoutServiceImpl) SetSchedule(creatorID uint, schedule string) error {
+	account, err := s.payoutRepository.FindAccountByCreator(creatorID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return models.ErrPayoutAccountNotReady
+	}
+
+	if err := account.SetSchedule(schedule); err != nil {
+		return err
+	}
+	return s.payoutRepository.SaveAccount(account)
+}
+
+func (s *payoutServiceImpl) PayoutCreator(creatorID uint) ([]*models.Payout, error) {
+	account, err := s.payoutRepository.FindAccountByCreator(creatorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.payout(account, time.Now())
+}
+
+func (s *payoutServiceImpl) RunScheduledPayouts(now time.Time) (int, error) {
+	accounts, err := s.payoutRepository.FindEnabledAccounts()
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	for _, account := range accounts {
+		if !account.IsDue(now) {
+			continue
+		}
+		payouts, err := s.payout(account, now)
+		if err != nil && !errors.Is(err, models.ErrNothingToPayout) {
+			log.Printf("Erro no repasse agendado do criador %d: %v", account.CreatorID, err)
+			continue
+		}
+		for _, payout := range payouts {
+			if payout.Status == models.PayoutStatusPaid {
+				paid++
+			}
+		}
+	}
+	return paid, nil
+}
+
+func (s *payoutServiceImpl) StartScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.RunScheduledPayouts(time.Now()); err != nil {
+				log.Printf("Erro ao processar repasses agendados: %v", err)
+			}
+		}
+	}()
+}
+
+// payout cria um repasse por moeda com saldo disponível e transfere cada um. Falhas
+// na transferência ficam registradas no repasse e o saldo volta a ficar disponível.
+func (s *payoutServiceImpl) payout(account *models.PayoutAccount, now time.Time) ([]*models.Payout, error) {
+	if account == nil || !account.PayoutsEnabled {
+		return nil, models.ErrPayoutAccountNotReady
+	}
+
+	entries, err := s.payoutRepository.FindEntriesByCreator(account.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payouts []*models.Payout
+	for _, balance := range models.SummarizeBalance(entries, now) {
+		if balance.Available <= 0 {
+			continue
+		}
+
+		payout, err := s.payoutRepository.CreatePayout(account.CreatorID, balance.Currency, now)
+		if errors.Is(err, models.ErrNothingToPayout) {
+			continue
+		}
+		if err != nil {
+			return payouts, err
+		}
+
+		transferID, err := s.gateway.Transfer(account.GatewayAccountID, payout.Amount, payout.Currency, fmt.Sprintf("payout_%d", payout.ID))
+		if err != nil {
+			log.Printf("Transferência do repasse %d recusada: %v", payout.ID, err)
+			payout.MarkFailed(err.Error())
+		} else {
+			payout.MarkPaid(transferID)
+		}
+		if err := s.payoutRepository.UpdatePayout(payout); err != nil {
+			return payouts, err
+		}
+		payouts = append(payouts, payout)
+	}
+
+	if len(payouts) == 0 {
+		return nil, models.ErrNothingToPayout
+	}
+
+	// Só um repasse pago conta para a frequência; com todas as transferências
+	// recusadas, o próximo ciclo tenta de novo
+	if !anyPayoutPaid(payouts) {
+		return payouts, nil
+	}
+	account.LastPayoutAt = &now
+	if err := s.payoutRepository.SaveAccount(account); err != nil {
+		return payouts, err
+	}
+	return payouts, nil
+}
+
+func anyPayoutPaid(payouts []*models.Payout) bool {
+	for _, payout := range payouts {
+		if payout.Status == models.PayoutStatusPaid {
+			return true
+		}
+	}
+	return false
+}
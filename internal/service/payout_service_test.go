@@ -0,0 +1,145 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newPayoutTestAccount(gateway *FakePaymentGateway) *models.PayoutAccount {
+	accountID, _ := gateway.CreateConnectedAccount("criador@email.com")
+	account := models.NewPayoutAccount(7, accountID)
+	account.PayoutsEnabled = true
+	return account
+}
+
+func TestPayoutService_PayoutCreator_TransfersAvailableBalance(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	account := newPayoutTestAccount(gateway)
+	repo := new(repoMocks.MockPayoutRepository)
+	entries := []*models.BalanceEntry{
+		{CreatorID: 7, Type: models.BalanceEntrySale, Amount: 5000, Currency: money.BRL, AvailableAt: time.Now().Add(-time.Hour)},
+		{CreatorID: 7, Type: models.BalanceEntryPlatformFee, Amount: -500, Currency: money.BRL, AvailableAt: time.Now().Add(-time.Hour)},
+	}
+	payout := &models.Payout{Model: gorm.Model{ID: 3}, CreatorID: 7, Amount: 4500, Currency: money.BRL, Status: models.PayoutStatusPending}
+
+	repo.On("FindAccountByCreator", uint(7)).Return(account, nil)
+	repo.On("FindEntriesByCreator", uint(7)).Return(entries, nil)
+	repo.On("CreatePayout", uint(7), money.BRL, mock.AnythingOfType("time.Time")).Return(payout, nil)
+	repo.On("UpdatePayout", payout).Return(nil)
+	repo.On("SaveAccount", account).Return(nil)
+
+	payouts, err := NewPayoutService(repo, gateway, 10, 7).PayoutCreator(7)
+
+	assert.NoError(t, err)
+	assert.Len(t, payouts, 1)
+	assert.Equal(t, models.PayoutStatusPaid, payout.Status)
+	assert.Len(t, gateway.Transfers, 1)
+	assert.Equal(t, payout.GatewayTransferID, gateway.Transfers[0].ID)
+	assert.Equal(t, int64(4500), gateway.Transfers[0].Amount)
+	assert.NotNil(t, account.LastPayoutAt)
+}
+
+func TestPayoutService_PayoutCreator_FailedTransfer(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	gateway.FailTransfers = true
+	account := newPayoutTestAccount(gateway)
+	repo := new(repoMocks.MockPayoutRepository)
+	entries := []*models.BalanceEntry{
+		{CreatorID: 7, Type: models.BalanceEntrySale, Amount: 5000, Currency: money.BRL, AvailableAt: time.Now().Add(-time.Hour)},
+	}
+	payout := &models.Payout{Model: gorm.Model{ID: 3}, CreatorID: 7, Amount: 5000, Currency: money.BRL, Status: models.PayoutStatusPending}
+
+	repo.On("FindAccountByCreator", uint(7)).Return(account, nil)
+	repo.On("FindEntriesByCreator", uint(7)).Return(entries, nil)
+	repo.On("CreatePayout", uint(7), money.BRL, mock.AnythingOfType("time.Time")).Return(payout, nil)
+	repo.On("UpdatePayout", payout).Return(nil)
+	repo.On("SaveAccount", account).Return(nil)
+
+	payouts, err := NewPayoutService(repo, gateway, 10, 7).PayoutCreator(7)
+
+	assert.NoError(t, err)
+	assert.Len(t, payouts, 1)
+	assert.Equal(t, models.PayoutStatusFailed, payout.Status)
+	assert.Empty(t, gateway.Transfers)
+	repo.AssertCalled(t, "UpdatePayout", payout)
+	assert.Nil(t, account.LastPayoutAt)
+	repo.AssertNotCalled(t, "SaveAccount", mock.Anything)
+}
+
+func TestPayoutService_RunScheduledPayouts_FailedTransfersKeepAccountDue(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	gateway.FailTransfers = true
+	account := newPayoutTestAccount(gateway)
+	account.Schedule = models.PayoutScheduleWeekly
+	lastWeek := time.Now().AddDate(0, 0, -8)
+	account.LastPayoutAt = &lastWeek
+	repo := new(repoMocks.MockPayoutRepository)
+	entries := []*models.BalanceEntry{
+		{CreatorID: 7, Type: models.BalanceEntrySale, Amount: 5000, Currency: money.BRL, AvailableAt: time.Now().Add(-time.Hour)},
+	}
+	payout := &models.Payout{Model: gorm.Model{ID: 3}, CreatorID: 7, Amount: 5000, Currency: money.BRL, Status: models.PayoutStatusPending}
+
+	repo.On("FindEnabledAccounts").Return([]*models.PayoutAccount{account}, nil)
+	repo.On("FindEntriesByCreator", uint(7)).Return(entries, nil)
+	repo.On("CreatePayout", uint(7), money.BRL, mock.AnythingOfType("time.Time")).Return(payout, nil)
+	repo.On("UpdatePayout", payout).Return(nil)
+
+	now := time.Now()
+	paid, err := NewPayoutService(repo, gateway, 10, 7).RunScheduledPayouts(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, paid)
+	assert.Equal(t, models.PayoutStatusFailed, payout.Status)
+	// Nenhuma transferência concluída: o próximo ciclo tenta de novo
+	assert.Equal(t, &lastWeek, account.LastPayoutAt)
+	assert.True(t, account.IsDue(now))
+	repo.AssertNotCalled(t, "SaveAccount", mock.Anything)
+}
+
+func TestPayoutService_PayoutCreator_RequiresEnabledAccount(t *testing.T) {
+	repo := new(repoMocks.MockPayoutRepository)
+	repo.On("FindAccountByCreator", uint(7)).Return(models.NewPayoutAccount(7, "acct_1"), nil)
+
+	_, err := NewPayoutService(repo, NewFakePaymentGateway(), 10, 7).PayoutCreator(7)
+
+	assert.ErrorIs(t, err, models.ErrPayoutAccountNotReady)
+	repo.AssertNotCalled(t, "CreatePayout", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPayoutService_RunScheduledPayouts_SkipsAccountsNotDue(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	account := newPayoutTestAccount(gateway)
+	today := time.Now()
+	account.LastPayoutAt = &today
+	repo := new(repoMocks.MockPayoutRepository)
+	repo.On("FindEnabledAccounts").Return([]*models.PayoutAccount{account}, nil)
+
+	paid, err := NewPayoutService(repo, gateway, 10, 7).RunScheduledPayouts(today)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, paid)
+	repo.AssertNotCalled(t, "FindEntriesByCreator", mock.Anything)
+}
+
+func TestPayoutService_StartOnboarding_CreatesAccountOnce(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	repo := new(repoMocks.MockPayoutRepository)
+	creator := &models.Creator{Model: gorm.Model{ID: 7}, Email: "criador@email.com"}
+	repo.On("FindAccountByCreator", uint(7)).Return(nil, nil).Once()
+	repo.On("SaveAccount", mock.AnythingOfType("*models.PayoutAccount")).Return(nil)
+
+	url, err := NewPayoutService(repo, gateway, 10, 7).StartOnboarding(creator, "http://app/refresh", "http://app/return")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://app/return", url)
+	saved := repo.Calls[1].Arguments.Get(0).(*models.PayoutAccount)
+	assert.Equal(t, uint(7), saved.CreatorID)
+	assert.NotEmpty(t, saved.GatewayAccountID)
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+var (
+	ErrPlanLimitReached = errors.New("limite do plano atingido")
+	ErrPlanNotFound     = errors.New("plano não encontrado")
+	// ErrPlanCheckoutRequired impede que quem ainda não paga receba um plano
+	// superior sem passar pelo checkout
+	ErrPlanCheckoutRequired = errors.New("para mudar para um plano superior, conclua a assinatura pelo botão Assinar Agora")
+)
+
+// PlanLimitError informa qual limite do plano impediu a operação
+type PlanLimitError struct {
+	PlanName string
+	Resource models.PlanResource
+	Limit    int64
+}
+
+func (e *PlanLimitError) Error() string {
+	var description string
+	switch e.Resource {
+	case models.ResourceEbooks:
+		description = fmt.Sprintf("%d e-books", e.Limit)
+	case models.ResourceClients:
+		description = fmt.Sprintf("%d clientes", e.Limit)
+	case models.ResourceStorage:
+		description = (&models.Plan{MaxStorageBytes: e.Limit}).GetStorageLimit() + " de armazenamento"
+	case models.ResourceMonthlySends:
+		description = fmt.Sprintf("%d envios por mês", e.Limit)
+	default:
+		description = string(e.Resource)
+	}
+	return fmt.Sprintf("O plano %s permite até %s. Faça upgrade do seu plano para continuar.", e.PlanName, description)
+}
+
+func (e *PlanLimitError) Is(target error) bool {
+	return target == ErrPlanLimitReached
+}
+
+// PlanLimiter verifica se o criador pode consumir mais de um recurso limitado pelo
+// plano e se o plano libera os recursos opcionais
+type PlanLimiter interface {
+	CheckLimit(creatorID uint, resource models.PlanResource, amount int64) error
+	HasFeature(creatorID uint, feature models.PlanFeature) (bool, error)
+}
+
+// PlanUsage resume o consumo atual do criador
+type PlanUsage struct {
+	Ebooks       int64
+	Clients      int64
+	StorageBytes int64
+	MonthlySends int64
+}
+
+func (u *PlanUsage) UsedFor(resource models.PlanResource) int64 {
+	switch resource {
+	case models.ResourceEbooks:
+		return u.Ebooks
+	case models.ResourceClients:
+		return u.Clients
+	case models.ResourceStorage:
+		return u.StorageBytes
+	case models.ResourceMonthlySends:
+		return u.MonthlySends
+	default:
+		return 0
+	}
+}
+
+// GetStorageUsed retorna o armazenamento utilizado formatado para exibição
+func (u *PlanUsage) GetStorageUsed() string {
+	return (&models.File{FileSize: u.StorageBytes}).GetFileSizeFormatted()
+}
+
+type PlanService interface {
+	PlanLimiter
+	ListPlans() ([]*models.Plan, error)
+	FindBySlug(slug string) (*models.Plan, error)
+	FindByPriceID(priceID string) (*models.Plan, error)
+	PlanForUser(userID uint) (*models.Plan, error)
+	GetUsage(creatorID uint) (*PlanUsage, error)
+	ChangePlan(userID uint, planSlug, interval string) error
+}
+
+type planServiceImpl struct {
+	planRepository      repository.PlanRepository
+	usageRepository     repository.UsageRepository
+	creatorRepository   repository.CreatorRepository
+	subscriptionService SubscriptionService
+	paymentGateway      PaymentGateway
+}
+
+func NewPlanService(
+	planRepository repository.PlanRepository,
+	usageRepository repository.UsageRepository,
+	creatorRepository repository.CreatorRepository,
+	subscriptionService SubscriptionService,
+	paymentGateway PaymentGateway,
+) PlanService {
+	return &planServiceImpl{
+		planRepository:      planRepository,
+		usageRepository:     usageRepository,
+		creatorRepository:   creatorRepository,
+		subscriptionService: subscriptionService,
+		paymentGateway:      paymentGateway,
+	}
+}
+
+func (s *planServiceImpl) ListPlans() ([]*models.Plan, error) {
+	return s.planRepository.FindActive()
+}
+
+func (s *planServiceImpl) FindBySlug(slug string) (*models.Plan, error) {
+	if slug == "" {
+		return nil, errors.New("plano é obrigatório")
+	}
+	return s.planRepository.FindBySlug(slug)
+}
+
+func (s *planServiceImpl) FindByPriceID(priceID string) (*models.Plan, error) {
+	if priceID == "" {
+		return nil, errors.New("ID do preço é obrigatório")
+	}
+	return s.planRepository.FindByPriceID(priceID)
+}
+
+// PlanForUser retorna o plano da assinatura do usuário, ou o plano padrão quando
+// a assinatura ainda não possui um plano válido.
+func (s *planServiceImpl) PlanForUser(userID uint) (*models.Plan, error) {
+	subscription, err := s.subscriptionService.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription != nil && subscription.PlanID != "" {
+		plan, err := s.planRepository.FindBySlug(subscription.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		if plan != nil {
+			return plan, nil
+		}
+	}
+
+	return s.planRepository.FindBySlug(models.DefaultPlanSlug)
+}
+
+func (s *planServiceImpl) GetUsage(creatorID uint) (*PlanUsage, error) {
+	var err error
+	usage := &PlanUsage{}
+
+	if usage.Ebooks, err = s.usedFor(creatorID, models.ResourceEbooks); err != nil {
+		return nil, err
+	}
+	if usage.Clients, err = s.usedFor(creatorID, models.ResourceClients); err != nil {
+		return nil, err
+	}
+	if usage.StorageBytes, err = s.usedFor(creatorID, models.ResourceStorage); err != nil {
+		return nil, err
+	}
+	if usage.MonthlySends, err = s.usedFor(creatorID, models.ResourceMonthlySends); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (s *planServiceImpl) CheckLimit(creatorID uint, resource models.PlanResource, amount int64) error {
+	creator, err := s.creatorRepository.FindByID(creatorID)
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.PlanForUser(creator.UserID)
+	if err != nil {
+		return err
+	}
+	if plan == nil || plan.LimitFor(resource) == models.Unlimited {
+		return nil
+	}
+
+	used, err := s.usedFor(creatorID, resource)
+	if err != nil {
+		return err
+	}
+
+	if !plan.Allows(resource, used, amount) {
+		return &PlanLimitError{PlanName: plan.Name, Resource: resource, Limit: plan.LimitFor(resource)}
+	}
+
+	return nil
+}
+
+func (s *planServiceImpl) HasFeature(creatorID uint, feature models.PlanFeature) (bool, error) {
+	creator, err := s.creatorRepository.FindByID(creatorID)
+	if err != nil {
+		return false, err
+	}
+	if creator == nil {
+		return false, nil
+	}
+
+	plan, err := s.PlanForUser(creator.UserID)
+	if err != nil {
+		return false, err
+	}
+	return plan != nil && plan.HasFeature(feature), nil
+}
+
+// ChangePlan troca o plano do usuário. Assinaturas ativas no gateway são
+// atualizadas com cobrança proporcional; sem elas não há o que cobrar, então
+// upgrades exigem o checkout. Downgrades só são permitidos quando o uso atual
+// cabe nos limites do novo plano.
+func (s *planServiceImpl) ChangePlan(userID uint, planSlug, interval string) error {
+	if interval != models.BillingMonthly && interval != models.BillingYearly {
+		return errors.New("periodicidade inválida")
+	}
+
+	plan, err := s.FindBySlug(planSlug)
+	if err != nil {
+		return err
+	}
+	if plan == nil || !plan.Active {
+		return ErrPlanNotFound
+	}
+
+	subscription, err := s.subscriptionService.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if subscription == nil {
+		return errors.New("assinatura não encontrada")
+	}
+
+	if subscription.PlanID == plan.Slug && subscription.BillingInterval == interval {
+		return nil
+	}
+
+	currentPlan, err := s.PlanForUser(userID)
+	if err != nil {
+		return err
+	}
+
+	subscribed := subscription.StripeSubscriptionID != "" && subscription.IsSubscribed()
+	if plan.IsUpgradeFrom(currentPlan) {
+		if !subscribed {
+			return ErrPlanCheckoutRequired
+		}
+	} else if err := s.ensureUsageFits(userID, plan); err != nil {
+		return err
+	}
+
+	if subscribed {
+		priceID := plan.PriceIDFor(interval)
+		if priceID == "" {
+			return fmt.Errorf("o plano %s não está disponível para cobrança %s", plan.Name, interval)
+		}
+		if err := s.paymentGateway.UpdateSubscriptionPrice(subscription.StripeSubscriptionID, priceID); err != nil {
+			return err
+		}
+	}
+
+	return s.subscriptionService.ChangePlan(subscription, plan.Slug, interval, models.UserActor(userID))
+}
+
+func (s *planServiceImpl) ensureUsageFits(userID uint, plan *models.Plan) error {
+	creator, err := s.creatorRepository.FindCreatorByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	usage, err := s.GetUsage(creator.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range []models.PlanResource{models.ResourceEbooks, models.ResourceClients, models.ResourceStorage} {
+		if !plan.Allows(resource, usage.UsedFor(resource), 0) {
+			return &PlanLimitError{PlanName: plan.Name, Resource: resource, Limit: plan.LimitFor(resource)}
+		}
+	}
+
+	return nil
+}
+
+func (s *planServiceImpl) usedFor(creatorID uint, resource models.PlanResource) (int64, error) {
+	switch resource {
+	case models.ResourceEbooks:
+		return s.usageRepository.CountEbooks(creatorID)
+	case models.ResourceClients:
+		return s.usageRepository.CountClients(creatorID)
+	case models.ResourceStorage:
+		return s.usageRepository.SumStorage(creatorID)
+	case models.ResourceMonthlySends:
+		now := time.Now()
+		startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return s.usageRepository.CountSendsSince(creatorID, startOfMonth)
+	default:
+		return 0, nil
+	}
+}
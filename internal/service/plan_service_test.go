@@ -0,0 +1,127 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type planServiceFixture struct {
+	planRepository         *repoMocks.MockPlanRepository
+	usageRepository        *repoMocks.MockUsageRepository
+	creatorRepository      *repoMocks.MockCreatorRepository
+	subscriptionRepository *repoMocks.MockSubscriptionRepository
+	gateway                *MockPaymentGateway
+	service                PlanService
+	plans                  []*models.Plan
+}
+
+func newPlanServiceFixture() *planServiceFixture {
+	f := &planServiceFixture{
+		planRepository:         new(repoMocks.MockPlanRepository),
+		usageRepository:        new(repoMocks.MockUsageRepository),
+		creatorRepository:      new(repoMocks.MockCreatorRepository),
+		subscriptionRepository: new(repoMocks.MockSubscriptionRepository),
+		gateway:                new(MockPaymentGateway),
+		plans:                  models.DefaultPlans(),
+	}
+	for _, plan := range f.plans {
+		plan.MonthlyPriceID = "price_" + plan.Slug
+		f.planRepository.On("FindBySlug", plan.Slug).Return(plan, nil)
+	}
+	f.service = NewPlanService(f.planRepository, f.usageRepository, f.creatorRepository, NewSubscriptionService(f.subscriptionRepository, nil, f.gateway), f.gateway)
+	return f
+}
+
+func TestPlanService_CheckLimit_ReturnsErrorWhenLimitReached(t *testing.T) {
+	f := newPlanServiceFixture()
+	creator := &models.Creator{Model: gorm.Model{ID: 1}, UserID: 2}
+	f.creatorRepository.On("FindByID", uint(1)).Return(creator, nil)
+	f.subscriptionRepository.On("FindByUserID", uint(2)).Return(models.NewSubscription(2, models.DefaultPlanSlug), nil)
+	f.usageRepository.On("CountEbooks", uint(1)).Return(int64(5), nil)
+
+	err := f.service.CheckLimit(1, models.ResourceEbooks, 1)
+
+	assert.ErrorIs(t, err, ErrPlanLimitReached)
+	assert.Contains(t, err.Error(), "5 e-books")
+}
+
+func TestPlanService_CheckLimit_UnlimitedSkipsUsage(t *testing.T) {
+	f := newPlanServiceFixture()
+	creator := &models.Creator{Model: gorm.Model{ID: 1}, UserID: 2}
+	f.creatorRepository.On("FindByID", uint(1)).Return(creator, nil)
+	f.subscriptionRepository.On("FindByUserID", uint(2)).Return(models.NewSubscription(2, "business"), nil)
+
+	err := f.service.CheckLimit(1, models.ResourceClients, 1000)
+
+	assert.NoError(t, err)
+	f.usageRepository.AssertNotCalled(t, "CountClients", uint(1))
+}
+
+func TestPlanService_ChangePlan_UpgradeUpdatesGatewayPrice(t *testing.T) {
+	f := newPlanServiceFixture()
+	subscription := models.NewSubscription(2, models.DefaultPlanSlug)
+	subscription.StripeSubscriptionID = "sub_1"
+	subscription.SubscriptionStatus = "active"
+	f.subscriptionRepository.On("FindByUserID", uint(2)).Return(subscription, nil)
+	f.subscriptionRepository.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+	f.gateway.On("UpdateSubscriptionPrice", "sub_1", "price_pro").Return(nil)
+
+	err := f.service.ChangePlan(2, "pro", models.BillingMonthly)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pro", subscription.PlanID)
+	f.gateway.AssertExpectations(t)
+}
+
+func TestPlanService_ChangePlan_DowngradeBlockedByUsage(t *testing.T) {
+	f := newPlanServiceFixture()
+	subscription := models.NewSubscription(2, "pro")
+	f.subscriptionRepository.On("FindByUserID", uint(2)).Return(subscription, nil)
+	f.creatorRepository.On("FindCreatorByUserID", uint(2)).Return(&models.Creator{Model: gorm.Model{ID: 1}}, nil)
+	f.usageRepository.On("CountEbooks", uint(1)).Return(int64(12), nil)
+	f.usageRepository.On("CountClients", uint(1)).Return(int64(10), nil)
+	f.usageRepository.On("SumStorage", uint(1)).Return(int64(0), nil)
+	f.usageRepository.On("CountSendsSince", uint(1), mock.Anything).Return(int64(0), nil)
+
+	err := f.service.ChangePlan(2, models.DefaultPlanSlug, models.BillingMonthly)
+
+	assert.ErrorIs(t, err, ErrPlanLimitReached)
+	assert.Equal(t, "pro", subscription.PlanID)
+	f.subscriptionRepository.AssertNotCalled(t, "SaveWithEvent", subscription, mock.Anything)
+}
+
+func TestPlanService_ChangePlan_TrialUpgradeRequiresCheckout(t *testing.T) {
+	f := newPlanServiceFixture()
+	subscription := models.NewSubscription(2, models.DefaultPlanSlug)
+	f.subscriptionRepository.On("FindByUserID", uint(2)).Return(subscription, nil)
+
+	err := f.service.ChangePlan(2, "business", models.BillingYearly)
+
+	assert.ErrorIs(t, err, ErrPlanCheckoutRequired)
+	assert.Equal(t, models.DefaultPlanSlug, subscription.PlanID)
+	f.subscriptionRepository.AssertNotCalled(t, "SaveWithEvent", subscription, mock.Anything)
+	f.gateway.AssertNotCalled(t, "UpdateSubscriptionPrice", mock.Anything, mock.Anything)
+}
+
+func TestPlanService_ChangePlan_TrialIntervalDoesNotCallGateway(t *testing.T) {
+	f := newPlanServiceFixture()
+	subscription := models.NewSubscription(2, models.DefaultPlanSlug)
+	f.subscriptionRepository.On("FindByUserID", uint(2)).Return(subscription, nil)
+	f.subscriptionRepository.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+	f.creatorRepository.On("FindCreatorByUserID", uint(2)).Return(&models.Creator{Model: gorm.Model{ID: 1}}, nil)
+	f.usageRepository.On("CountEbooks", uint(1)).Return(int64(1), nil)
+	f.usageRepository.On("CountClients", uint(1)).Return(int64(10), nil)
+	f.usageRepository.On("SumStorage", uint(1)).Return(int64(0), nil)
+	f.usageRepository.On("CountSendsSince", uint(1), mock.Anything).Return(int64(0), nil)
+
+	err := f.service.ChangePlan(2, models.DefaultPlanSlug, models.BillingYearly)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.BillingYearly, subscription.BillingInterval)
+	f.gateway.AssertNotCalled(t, "UpdateSubscriptionPrice", mock.Anything, mock.Anything)
+}
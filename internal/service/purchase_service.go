@@ -3,6 +3,9 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/models"
 	"github.com/anglesson/simple-web-server/internal/repository"
@@ -12,16 +15,25 @@ import (
 type PurchaseService struct {
 	purchaseRepository *repository.PurchaseRepository
 	mailService        *mail.EmailService
+	planLimiter        PlanLimiter
 }
 
-func NewPurchaseService(purchaseRepository *repository.PurchaseRepository, mailService *mail.EmailService) *PurchaseService {
+func NewPurchaseService(purchaseRepository *repository.PurchaseRepository, mailService *mail.EmailService, planLimiter PlanLimiter) *PurchaseService {
 	return &PurchaseService{
 		purchaseRepository: purchaseRepository,
 		mailService:        mailService,
+		planLimiter:        planLimiter,
 	}
 }
 
-func (ps *PurchaseService) CreatePurchase(ebookId uint, clients []uint) error {
+// CreatePurchase envia o ebook do criador para os clientes informados
+func (ps *PurchaseService) CreatePurchase(creatorID, ebookId uint, clients []uint) error {
+	if ps.planLimiter != nil {
+		if err := ps.planLimiter.CheckLimit(creatorID, models.ResourceMonthlySends, int64(len(clients))); err != nil {
+			return err
+		}
+	}
+
 	var purchases []*models.Purchase
 
 	for _, clientId := range clients {
@@ -39,54 +51,97 @@ func (ps *PurchaseService) CreatePurchase(ebookId uint, clients []uint) error {
 	return nil
 }
 
-func (ps *PurchaseService) GetEbookFile(purchaseID int, fileID uint) (string, error) {
+func (ps *PurchaseService) GetEbookFile(purchaseID int, fileID uint, request models.DownloadRequest) (string, error) {
 	purchase, err := ps.purchaseRepository.FindByID(uint(purchaseID))
 	if err != nil {
 		return "", errors.New(err.Error())
 	}
 
-	if !purchase.AvailableDownloads() {
-		return "", errors.New("não é possível realizar o download, limite de downloads atingido")
+	if purchase.IsSuspended() {
+		return "", models.ErrPurchaseSuspended
+	}
+
+	if purchase.AwaitingRelease {
+		return "", models.ErrAwaitingRelease
 	}
 
 	if purchase.IsExpired() {
 		return "", errors.New("não é possível realizar o download, o pedido está expirado")
 	}
 
-	// Buscar o arquivo específico do ebook
-	var targetFile *models.File
-	for _, file := range purchase.Ebook.Files {
-		if file.ID == fileID {
-			targetFile = file
-			break
-		}
+	// Buscar o arquivo específico do ebook, respeitando o cronograma de liberação
+	targetFile, err := purchase.FindScheduledFile(fileID, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	// Versões novas de arquivos já comprados não contam no limite
+	if !purchase.AvailableDownloads() && !purchase.IsUpdateDownload(targetFile.File) {
+		return "", errors.New("não é possível realizar o download, limite de downloads atingido")
 	}
 
-	if targetFile == nil {
-		return "", errors.New("arquivo não encontrado neste ebook")
+	if !purchase.AllowsDevice(request.IP) {
+		return "", models.ErrDeviceLimitReached
 	}
 
 	// Aplicar marca d'água no arquivo
-	watermarkText := fmt.Sprintf("%s - %s - %s", purchase.Client.Name, purchase.Client.CPF, purchase.Client.Email)
-	outputFilePath, err := ApplyWatermark(targetFile.S3Key, watermarkText)
+	outputFilePath, err := ApplyWatermark(targetFile.S3Key, ps.watermarkText(purchase))
 	if err != nil {
 		return "", err
 	}
 
-	purchase.UseDownload()
+	if info, err := os.Stat(outputFilePath); err == nil {
+		request.Bytes = info.Size()
+	}
+	purchase.UseFileDownload(targetFile.File, request)
+
+	// Links repassados vão para a fila de revisão do criador
+	if purchase.CheckSharing(purchase.Ebook.Creator.AutoSuspendSharing, time.Now()) {
+		log.Printf("Compra %d marcada para revisão (suspensa: %t): pontuação de compartilhamento %d",
+			purchase.ID, purchase.IsSuspended(), purchase.SharingScore)
+	}
 	ps.purchaseRepository.Update(purchase)
 
 	return outputFilePath, nil
 }
 
-// GetEbookFiles retorna todos os arquivos do ebook para um cliente
-func (ps *PurchaseService) GetEbookFiles(purchaseID int) ([]*models.File, error) {
+// watermarkText identifica o comprador na marca d'água. O texto personalizado do
+// criador só entra quando o plano dele libera a marca d'água personalizada.
+func (ps *PurchaseService) watermarkText(purchase *models.Purchase) string {
+	text := fmt.Sprintf("%s - %s - %s", purchase.Client.Name, purchase.Client.CPF, purchase.Client.Email)
+
+	creator := purchase.Ebook.Creator
+	if creator.WatermarkText == "" || ps.planLimiter == nil {
+		return text
+	}
+	allowed, err := ps.planLimiter.HasFeature(creator.ID, models.FeatureCustomWatermark)
+	if err != nil {
+		log.Printf("Erro ao verificar marca d'água personalizada do criador %d: %v", creator.ID, err)
+		return text
+	}
+	if !allowed {
+		return text
+	}
+	return creator.WatermarkText + " - " + text
+}
+
+// GetEbookFiles retorna todos os arquivos do ebook para um cliente, na ordem do
+// cronograma e com a data de liberação de cada um
+func (ps *PurchaseService) GetEbookFiles(purchaseID int) ([]*models.ScheduledFile, error) {
 	purchase, err := ps.purchaseRepository.FindByID(uint(purchaseID))
 	if err != nil {
 		return nil, errors.New(err.Error())
 	}
 
-	if !purchase.AvailableDownloads() {
+	if purchase.IsSuspended() {
+		return nil, models.ErrPurchaseSuspended
+	}
+
+	if purchase.AwaitingRelease {
+		return nil, models.ErrAwaitingRelease
+	}
+
+	if !purchase.AvailableDownloads() && !purchase.HasPendingUpdates() {
 		return nil, errors.New("não é possível realizar o download, limite de downloads atingido")
 	}
 
@@ -98,5 +153,5 @@ func (ps *PurchaseService) GetEbookFiles(purchaseID int) ([]*models.File, error)
 		return nil, errors.New("nenhum arquivo encontrado neste ebook")
 	}
 
-	return purchase.Ebook.Files, nil
+	return purchase.ScheduledFiles(), nil
 }
@@ -148,3 +148,30 @@ func TestPurchaseValidationLogic(t *testing.T) {
 	assert.Equal(t, 5, purchaseValid.DownloadLimit)
 	assert.True(t, purchaseValid.ExpiresAt.After(time.Now()))
 }
+
+// featureLimiterStub libera apenas os recursos informados
+type featureLimiterStub struct {
+	features map[models.PlanFeature]bool
+}
+
+func (s featureLimiterStub) CheckLimit(creatorID uint, resource models.PlanResource, amount int64) error {
+	return nil
+}
+
+func (s featureLimiterStub) HasFeature(creatorID uint, feature models.PlanFeature) (bool, error) {
+	return s.features[feature], nil
+}
+
+func TestPurchaseService_WatermarkText_CustomTextFollowsPlan(t *testing.T) {
+	purchase := &models.Purchase{
+		Client: models.Client{Name: "Maria", CPF: "123.456.789-00", Email: "maria@email.com"},
+		Ebook:  models.Ebook{Creator: models.Creator{Model: gorm.Model{ID: 1}, WatermarkText: "© Editora Ana"}},
+	}
+
+	// Plano sem o recurso: o texto gravado pelo criador é ignorado
+	essential := NewPurchaseService(nil, nil, featureLimiterStub{})
+	assert.Equal(t, "Maria - 123.456.789-00 - maria@email.com", essential.watermarkText(purchase))
+
+	pro := NewPurchaseService(nil, nil, featureLimiterStub{features: map[models.PlanFeature]bool{models.FeatureCustomWatermark: true}})
+	assert.Equal(t, "© Editora Ana - Maria - 123.456.789-00 - maria@email.com", pro.watermarkText(purchase))
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/storage"
+)
+
+var ErrInvalidAvatar = errors.New("o avatar deve ser uma imagem JPEG, PNG ou WebP")
+
+// avatarExtensions são os formatos aceitos para o avatar, pelo tipo detectado no conteúdo
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// StorefrontService monta a vitrine pública do criador e cuida do seu perfil
+type StorefrontService interface {
+	// Storefront busca a vitrine pelo endereço com o catálogo filtrado
+	Storefront(slug string, query models.StorefrontQuery) (*models.Storefront, error)
+	// UpdateProfile grava o perfil público; avatar é opcional
+	UpdateProfile(creator *models.Creator, profile models.CreatorProfile, avatar *multipart.FileHeader) error
+}
+
+type storefrontServiceImpl struct {
+	storefrontRepository repository.StorefrontRepository
+	s3Storage            storage.S3Storage
+}
+
+func NewStorefrontService(storefrontRepository repository.StorefrontRepository, s3Storage storage.S3Storage) StorefrontService {
+	return &storefrontServiceImpl{
+		storefrontRepository: storefrontRepository,
+		s3Storage:            s3Storage,
+	}
+}
+
+func (s *storefrontServiceImpl) Storefront(slug string, query models.StorefrontQuery) (*models.Storefront, error) {
+	creator, err := s.storefrontRepository.FindCreatorBySlug(strings.ToLower(slug))
+	if err != nil {
+		return nil, err
+	}
+	if creator == nil {
+		return nil, models.ErrStorefrontNotFound
+	}
+
+	ebooks, err := s.storefrontRepository.FindEbooks(creator.ID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Kits não têm categoria e somem quando o visitante filtra por uma
+	var bundles []*models.Bundle
+	if query.Category == "" {
+		bundles, err = s.storefrontRepository.FindBundles(creator.ID, query.Term)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	categories, err := s.storefrontRepository.FindCategories(creator.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Storefront{
+		Creator:    creator,
+		Ebooks:     ebooks,
+		Bundles:    bundles,
+		Categories: categories,
+		Term:       query.Term,
+		Category:   query.Category,
+	}, nil
+}
+
+func (s *storefrontServiceImpl) UpdateProfile(creator *models.Creator, profile models.CreatorProfile, avatar *multipart.FileHeader) error {
+	if err := creator.UpdateProfile(profile); err != nil {
+		return err
+	}
+
+	taken, err := s.storefrontRepository.SlugTaken(creator.Slug, creator.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return models.ErrCreatorSlugTaken
+	}
+
+	if avatar != nil {
+		ext, err := avatarExtension(avatar)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("avatars/%d-%d%s", creator.ID, time.Now().Unix(), ext)
+		avatarURL, err := s.s3Storage.UploadFile(avatar, key)
+		if err != nil {
+			return fmt.Errorf("erro ao fazer upload do avatar: %w", err)
+		}
+		creator.Avatar = avatarURL
+	}
+
+	return s.storefrontRepository.UpdateProfile(creator)
+}
+
+// avatarExtension identifica o formato pelo conteúdo do arquivo; o Content-Type
+// e o nome enviados pelo navegador não são confiáveis
+func avatarExtension(avatar *multipart.FileHeader) (string, error) {
+	file, err := avatar.Open()
+	if err != nil {
+		return "", fmt.Errorf("erro ao abrir avatar: %w", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", ErrInvalidAvatar
+	}
+
+	ext, ok := avatarExtensions[http.DetectContentType(head[:n])]
+	if !ok {
+		return "", ErrInvalidAvatar
+	}
+	return ext, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStorefrontService_Storefront(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	service := NewStorefrontService(repo, nil)
+
+	creator := &models.Creator{Name: "Ana", Slug: "ana"}
+	creator.ID = 3
+	query := models.StorefrontQuery{Term: "finanças"}
+	repo.On("FindCreatorBySlug", "ana").Return(creator, nil)
+	repo.On("FindEbooks", uint(3), query).Return([]*models.Ebook{{Title: "Finanças"}}, nil)
+	repo.On("FindBundles", uint(3), "finanças").Return([]*models.Bundle{{Title: "Kit"}}, nil)
+	repo.On("FindCategories", uint(3)).Return([]string{"Finanças"}, nil)
+
+	storefront, err := service.Storefront("Ana", query)
+
+	assert.NoError(t, err)
+	assert.Equal(t, creator, storefront.Creator)
+	assert.Len(t, storefront.Ebooks, 1)
+	assert.Len(t, storefront.Bundles, 1)
+	assert.Equal(t, "finanças", storefront.Term)
+}
+
+func TestStorefrontService_Storefront_CategorySkipsBundles(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	service := NewStorefrontService(repo, nil)
+
+	creator := &models.Creator{Slug: "ana"}
+	creator.ID = 3
+	query := models.StorefrontQuery{Category: "Receitas"}
+	repo.On("FindCreatorBySlug", "ana").Return(creator, nil)
+	repo.On("FindEbooks", uint(3), query).Return([]*models.Ebook{}, nil)
+	repo.On("FindCategories", uint(3)).Return([]string{"Receitas"}, nil)
+
+	storefront, err := service.Storefront("ana", query)
+
+	assert.NoError(t, err)
+	assert.Empty(t, storefront.Bundles)
+	repo.AssertNotCalled(t, "FindBundles", mock.Anything, mock.Anything)
+}
+
+func TestStorefrontService_Storefront_NotFound(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	service := NewStorefrontService(repo, nil)
+	repo.On("FindCreatorBySlug", "ninguem").Return(nil, nil)
+
+	_, err := service.Storefront("ninguem", models.StorefrontQuery{})
+
+	assert.ErrorIs(t, err, models.ErrStorefrontNotFound)
+}
+
+func TestStorefrontService_UpdateProfile(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	service := NewStorefrontService(repo, nil)
+
+	creator := &models.Creator{Name: "Ana"}
+	creator.ID = 3
+	repo.On("SlugTaken", "ana", uint(3)).Return(false, nil)
+	repo.On("UpdateProfile", creator).Return(nil)
+
+	err := service.UpdateProfile(creator, models.CreatorProfile{Slug: "ana", Bio: "Olá"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Olá", creator.Bio)
+	repo.AssertExpectations(t)
+}
+
+func TestStorefrontService_UpdateProfile_SlugTaken(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	service := NewStorefrontService(repo, nil)
+
+	creator := &models.Creator{Name: "Ana"}
+	creator.ID = 3
+	repo.On("SlugTaken", "ana", uint(3)).Return(true, nil)
+
+	err := service.UpdateProfile(creator, models.CreatorProfile{Slug: "ana"}, nil)
+
+	assert.ErrorIs(t, err, models.ErrCreatorSlugTaken)
+	repo.AssertNotCalled(t, "UpdateProfile", mock.Anything)
+}
+
+type stubAvatarStorage struct {
+	keys []string
+}
+
+func (s *stubAvatarStorage) UploadFile(file *multipart.FileHeader, key string) (string, error) {
+	s.keys = append(s.keys, key)
+	return "https://cdn/" + key, nil
+}
+func (s *stubAvatarStorage) DeleteFile(key string) error            { return nil }
+func (s *stubAvatarStorage) GenerateDownloadLink(key string) string { return key }
+func (s *stubAvatarStorage) GenerateDownloadLinkWithExpiration(key string, expirationSeconds int) string {
+	return key
+}
+
+// avatarUpload monta o arquivo como chega do formulário, com nome e Content-Type do navegador
+func avatarUpload(t *testing.T, filename, contentType string, content []byte) *multipart.FileHeader {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	assert.NoError(t, err)
+	part.Write(content)
+	writer.Close()
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(1 << 20)
+	assert.NoError(t, err)
+	return form.File["avatar"][0]
+}
+
+func TestStorefrontService_UpdateProfile_AvatarTypeFromContent(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	storage := &stubAvatarStorage{}
+	service := NewStorefrontService(repo, storage)
+
+	creator := &models.Creator{Name: "Ana"}
+	creator.ID = 3
+	repo.On("SlugTaken", "ana", uint(3)).Return(false, nil)
+	repo.On("UpdateProfile", creator).Return(nil)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	err := service.UpdateProfile(creator, models.CreatorProfile{Slug: "ana"}, avatarUpload(t, "foto.html", "text/html", png))
+
+	assert.NoError(t, err)
+	if assert.Len(t, storage.keys, 1) {
+		assert.True(t, strings.HasSuffix(storage.keys[0], ".png"))
+	}
+	assert.Equal(t, "https://cdn/"+storage.keys[0], creator.Avatar)
+}
+
+func TestStorefrontService_UpdateProfile_RejectsDisguisedAvatar(t *testing.T) {
+	repo := new(repoMocks.MockStorefrontRepository)
+	storage := &stubAvatarStorage{}
+	service := NewStorefrontService(repo, storage)
+
+	creator := &models.Creator{Name: "Ana"}
+	creator.ID = 3
+	repo.On("SlugTaken", "ana", uint(3)).Return(false, nil)
+
+	html := []byte("<html><script>alert(1)</script></html>")
+	err := service.UpdateProfile(creator, models.CreatorProfile{Slug: "ana"}, avatarUpload(t, "foto.png", "image/png", html))
+	assert.ErrorIs(t, err, ErrInvalidAvatar)
+
+	gif := []byte("GIF89a\x01\x00\x01\x00")
+	err = service.UpdateProfile(creator, models.CreatorProfile{Slug: "ana"}, avatarUpload(t, "foto.gif", "image/gif", gif))
+	assert.ErrorIs(t, err, ErrInvalidAvatar)
+
+	assert.Empty(t, storage.keys)
+	repo.AssertNotCalled(t, "UpdateProfile", mock.Anything)
+}
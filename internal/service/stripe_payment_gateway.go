@@ -3,6 +3,10 @@ package service
 import (
 	"errors"
 	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
 )
 
 type StripePaymentGateway struct {
@@ -64,3 +68,149 @@ func (spg *StripePaymentGateway) CancelSubscription(subscriptionID string) error
 
 	return nil
 }
+
+func (spg *StripePaymentGateway) UpdateSubscriptionPrice(subscriptionID, priceID string) error {
+	if subscriptionID == "" {
+		return errors.New("ID da assinatura é obrigatório")
+	}
+	if priceID == "" {
+		return errors.New("ID do preço é obrigatório")
+	}
+
+	err := spg.stripeService.UpdateSubscriptionPrice(subscriptionID, priceID)
+	if err != nil {
+		log.Printf("Error updating subscription price in Stripe: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (spg *StripePaymentGateway) ScheduleSubscriptionCancellation(subscriptionID string) (time.Time, error) {
+	if subscriptionID == "" {
+		return time.Time{}, errors.New("ID da assinatura é obrigatório")
+	}
+
+	periodEnd, err := spg.stripeService.SetCancelAtPeriodEnd(subscriptionID, true)
+	if err != nil {
+		log.Printf("Error scheduling subscription cancellation in Stripe: %v", err)
+		return time.Time{}, err
+	}
+
+	return periodEnd, nil
+}
+
+func (spg *StripePaymentGateway) ResumeSubscription(subscriptionID string) error {
+	if subscriptionID == "" {
+		return errors.New("ID da assinatura é obrigatório")
+	}
+
+	_, err := spg.stripeService.SetCancelAtPeriodEnd(subscriptionID, false)
+	if err != nil {
+		log.Printf("Error resuming subscription in Stripe: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (spg *StripePaymentGateway) ListInvoices(customerID string) ([]*models.Invoice, error) {
+	if customerID == "" {
+		return nil, errors.New("ID do cliente é obrigatório")
+	}
+
+	return spg.stripeService.ListInvoices(customerID)
+}
+
+func (spg *StripePaymentGateway) CreatePortalSession(customerID, returnURL string) (string, error) {
+	if customerID == "" {
+		return "", errors.New("ID do cliente é obrigatório")
+	}
+
+	return spg.stripeService.CreatePortalSession(customerID, returnURL)
+}
+
+func (spg *StripePaymentGateway) ChargeSavedPaymentMethod(paymentID string, amount int64, currency money.Currency, description, idempotencyKey string) (string, error) {
+	if paymentID == "" {
+		return "", errors.New("ID do pagamento é obrigatório")
+	}
+	if amount <= 0 {
+		return "", errors.New("valor da cobrança deve ser maior que zero")
+	}
+
+	if idempotencyKey == "" {
+		return "", errors.New("chave de idempotência é obrigatória")
+	}
+
+	return spg.stripeService.ChargeSavedPaymentMethod(paymentID, amount, currency, description, idempotencyKey)
+}
+
+func (spg *StripePaymentGateway) CreateConnectedAccount(email string) (string, error) {
+	if email == "" {
+		return "", errors.New("e-mail é obrigatório")
+	}
+
+	return spg.stripeService.CreateConnectedAccount(email)
+}
+
+func (spg *StripePaymentGateway) CreateAccountLink(accountID, refreshURL, returnURL string) (string, error) {
+	if accountID == "" {
+		return "", errors.New("ID da conta recebedora é obrigatório")
+	}
+
+	return spg.stripeService.CreateAccountLink(accountID, refreshURL, returnURL)
+}
+
+func (spg *StripePaymentGateway) PayoutsEnabled(accountID string) (bool, error) {
+	if accountID == "" {
+		return false, errors.New("ID da conta recebedora é obrigatório")
+	}
+
+	return spg.stripeService.PayoutsEnabled(accountID)
+}
+
+func (spg *StripePaymentGateway) Transfer(accountID string, amount int64, currency money.Currency, reference string) (string, error) {
+	if accountID == "" {
+		return "", errors.New("ID da conta recebedora é obrigatório")
+	}
+	if amount <= 0 {
+		return "", errors.New("valor do repasse deve ser maior que zero")
+	}
+
+	return spg.stripeService.Transfer(accountID, amount, currency, reference)
+}
+
+func (spg *StripePaymentGateway) CreateRecurringCheckout(checkout models.RecurringCheckout) (string, string, error) {
+	if checkout.Price.Amount <= 0 {
+		return "", "", errors.New("valor da cobrança deve ser maior que zero")
+	}
+	if checkout.SuccessURL == "" || checkout.CancelURL == "" {
+		return "", "", errors.New("URLs de retorno são obrigatórias")
+	}
+
+	return spg.stripeService.CreateRecurringCheckout(checkout)
+}
+
+func (spg *StripePaymentGateway) GetRecurringCheckout(sessionID string) (*models.RecurringCheckoutResult, error) {
+	if sessionID == "" {
+		return nil, errors.New("ID da sessão é obrigatório")
+	}
+
+	return spg.stripeService.GetRecurringCheckout(sessionID)
+}
+
+func (spg *StripePaymentGateway) BalanceTransactionFee(balanceTransactionID string) (int64, error) {
+	if balanceTransactionID == "" {
+		return 0, errors.New("ID da movimentação é obrigatório")
+	}
+
+	return spg.stripeService.BalanceTransactionFee(balanceTransactionID)
+}
+
+func (spg *StripePaymentGateway) CreateCoupon(amountOff int64, currency money.Currency, name string) (string, error) {
+	if amountOff <= 0 {
+		return "", errors.New("valor do desconto deve ser maior que zero")
+	}
+
+	return spg.stripeService.CreateCoupon(amountOff, currency, name)
+}
@@ -1,12 +1,27 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/config"
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/account"
+	"github.com/stripe/stripe-go/v76/accountlink"
+	"github.com/stripe/stripe-go/v76/balancetransaction"
+	portalsession "github.com/stripe/stripe-go/v76/billingportal/session"
+	checkoutsession "github.com/stripe/stripe-go/v76/checkout/session"
+	"github.com/stripe/stripe-go/v76/coupon"
 	"github.com/stripe/stripe-go/v76/customer"
+	"github.com/stripe/stripe-go/v76/invoice"
+	"github.com/stripe/stripe-go/v76/paymentintent"
 	"github.com/stripe/stripe-go/v76/subscription"
+	"github.com/stripe/stripe-go/v76/transfer"
 )
 
 type StripeService struct {
@@ -15,6 +30,12 @@ type StripeService struct {
 
 func NewStripeService() *StripeService {
 	stripe.Key = config.AppConfig.StripeSecretKey
+	if config.AppConfig.StripeAPIURL != "" {
+		// Aponta o SDK para um servidor local, como o stripe-mock, nos testes de integração
+		stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+			URL: stripe.String(config.AppConfig.StripeAPIURL),
+		}))
+	}
 	return &StripeService{
 		apiKey: config.AppConfig.StripeSecretKey,
 	}
@@ -63,3 +84,294 @@ func (s *StripeService) CancelSubscription(subscriptionID string) error {
 
 	return nil
 }
+
+// UpdateSubscriptionPrice substitui o item da assinatura pelo novo preço, gerando cobrança proporcional
+func (s *StripeService) UpdateSubscriptionPrice(subscriptionID string, priceID string) error {
+	current, err := subscription.Get(subscriptionID, nil)
+	if err != nil {
+		log.Printf("Error retrieving subscription: %v", err)
+		return err
+	}
+	if current.Items == nil || len(current.Items.Data) == 0 {
+		return errors.New("assinatura sem itens para atualizar")
+	}
+
+	params := &stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{
+				ID:    stripe.String(current.Items.Data[0].ID),
+				Price: stripe.String(priceID),
+			},
+		},
+		ProrationBehavior: stripe.String("create_prorations"),
+	}
+
+	_, err = subscription.Update(subscriptionID, params)
+	if err != nil {
+		log.Printf("Error updating subscription price: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetCancelAtPeriodEnd agenda ou desfaz o cancelamento da assinatura ao fim do período atual
+func (s *StripeService) SetCancelAtPeriodEnd(subscriptionID string, cancel bool) (time.Time, error) {
+	params := &stripe.SubscriptionParams{
+		CancelAtPeriodEnd: stripe.Bool(cancel),
+	}
+
+	updated, err := subscription.Update(subscriptionID, params)
+	if err != nil {
+		log.Printf("Error updating subscription cancellation: %v", err)
+		return time.Time{}, err
+	}
+
+	return time.Unix(updated.CurrentPeriodEnd, 0), nil
+}
+
+func (s *StripeService) ListInvoices(customerID string) ([]*models.Invoice, error) {
+	params := &stripe.InvoiceListParams{
+		Customer: stripe.String(customerID),
+	}
+	params.Limit = stripe.Int64(24)
+
+	var invoices []*models.Invoice
+	i := invoice.List(params)
+	for i.Next() {
+		inv := i.Invoice()
+		currency, err := money.ParseCurrency(strings.ToUpper(string(inv.Currency)))
+		if err != nil {
+			currency = money.BRL
+		}
+		amount := inv.AmountPaid
+		if inv.Status != stripe.InvoiceStatusPaid {
+			amount = inv.AmountDue
+		}
+		invoices = append(invoices, &models.Invoice{
+			ID:        inv.ID,
+			Number:    inv.Number,
+			Status:    string(inv.Status),
+			Amount:    money.New(amount, currency),
+			CreatedAt: time.Unix(inv.Created, 0),
+			HostedURL: inv.HostedInvoiceURL,
+			PDFURL:    inv.InvoicePDF,
+		})
+	}
+	if err := i.Err(); err != nil {
+		log.Printf("Error listing invoices: %v", err)
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+func (s *StripeService) CreatePortalSession(customerID, returnURL string) (string, error) {
+	params := &stripe.BillingPortalSessionParams{
+		Customer:  stripe.String(customerID),
+		ReturnURL: stripe.String(returnURL),
+		FlowData: &stripe.BillingPortalSessionFlowDataParams{
+			Type: stripe.String("payment_method_update"),
+		},
+	}
+
+	portal, err := portalsession.New(params)
+	if err != nil {
+		log.Printf("Error creating billing portal session: %v", err)
+		return "", err
+	}
+
+	return portal.URL, nil
+}
+
+var ErrSavedPaymentMethodUnavailable = errors.New("meio de pagamento salvo não disponível")
+
+// ChargeSavedPaymentMethod cria uma cobrança fora da sessão usando o cliente e o
+// cartão do pagamento original, que precisa ter sido salvo para uso futuro
+func (s *StripeService) ChargeSavedPaymentMethod(paymentIntentID string, amount int64, currency money.Currency, description, idempotencyKey string) (string, error) {
+	original, err := paymentintent.Get(paymentIntentID, nil)
+	if err != nil {
+		log.Printf("Error fetching payment intent %s: %v", paymentIntentID, err)
+		return "", err
+	}
+	if original.Customer == nil || original.PaymentMethod == nil {
+		return "", ErrSavedPaymentMethodUnavailable
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(amount),
+		Currency:      stripe.String(currency.Code()),
+		Customer:      stripe.String(original.Customer.ID),
+		PaymentMethod: stripe.String(original.PaymentMethod.ID),
+		Description:   stripe.String(description),
+		OffSession:    stripe.Bool(true),
+		Confirm:       stripe.Bool(true),
+	}
+	// Cliques repetidos reenviam a mesma chave e recebem a cobrança original
+	params.SetIdempotencyKey(idempotencyKey)
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		log.Printf("Error charging saved payment method: %v", err)
+		return "", err
+	}
+	if pi.Status != stripe.PaymentIntentStatusSucceeded {
+		return "", fmt.Errorf("cobrança não concluída: %s", pi.Status)
+	}
+
+	return pi.ID, nil
+}
+
+// CreateConnectedAccount cria uma conta Express para o criador receber os repasses
+func (s *StripeService) CreateConnectedAccount(email string) (string, error) {
+	params := &stripe.AccountParams{
+		Type:  stripe.String(string(stripe.AccountTypeExpress)),
+		Email: stripe.String(email),
+		Capabilities: &stripe.AccountCapabilitiesParams{
+			Transfers: &stripe.AccountCapabilitiesTransfersParams{Requested: stripe.Bool(true)},
+		},
+	}
+
+	acct, err := account.New(params)
+	if err != nil {
+		log.Printf("Error creating connected account: %v", err)
+		return "", err
+	}
+
+	return acct.ID, nil
+}
+
+func (s *StripeService) CreateAccountLink(accountID, refreshURL, returnURL string) (string, error) {
+	params := &stripe.AccountLinkParams{
+		Account:    stripe.String(accountID),
+		RefreshURL: stripe.String(refreshURL),
+		ReturnURL:  stripe.String(returnURL),
+		Type:       stripe.String("account_onboarding"),
+	}
+
+	link, err := accountlink.New(params)
+	if err != nil {
+		log.Printf("Error creating account link for %s: %v", accountID, err)
+		return "", err
+	}
+
+	return link.URL, nil
+}
+
+func (s *StripeService) PayoutsEnabled(accountID string) (bool, error) {
+	acct, err := account.GetByID(accountID, nil)
+	if err != nil {
+		log.Printf("Error fetching connected account %s: %v", accountID, err)
+		return false, err
+	}
+
+	return acct.PayoutsEnabled, nil
+}
+
+// Transfer move o valor do saldo da plataforma para a conta conectada; a referência
+// agrupa a transferência com o repasse registrado no sistema
+func (s *StripeService) Transfer(accountID string, amount int64, currency money.Currency, reference string) (string, error) {
+	params := &stripe.TransferParams{
+		Amount:        stripe.Int64(amount),
+		Currency:      stripe.String(currency.Code()),
+		Destination:   stripe.String(accountID),
+		TransferGroup: stripe.String(reference),
+	}
+
+	tr, err := transfer.New(params)
+	if err != nil {
+		log.Printf("Error creating transfer to %s: %v", accountID, err)
+		return "", err
+	}
+
+	return tr.ID, nil
+}
+
+func (s *StripeService) BalanceTransactionFee(balanceTransactionID string) (int64, error) {
+	bt, err := balancetransaction.Get(balanceTransactionID, nil)
+	if err != nil {
+		log.Printf("Error fetching balance transaction %s: %v", balanceTransactionID, err)
+		return 0, err
+	}
+
+	return bt.Fee, nil
+}
+
+func (s *StripeService) CreateCoupon(amountOff int64, currency money.Currency, name string) (string, error) {
+	params := &stripe.CouponParams{
+		AmountOff:      stripe.Int64(amountOff),
+		Currency:       stripe.String(currency.Code()),
+		Duration:       stripe.String(string(stripe.CouponDurationOnce)),
+		MaxRedemptions: stripe.Int64(1),
+		Name:           stripe.String(name),
+	}
+
+	c, err := coupon.New(params)
+	if err != nil {
+		log.Printf("Error creating coupon: %v", err)
+		return "", err
+	}
+
+	return c.ID, nil
+}
+
+// CreateRecurringCheckout cria uma sessão de checkout no modo assinatura com o preço
+// informado na própria sessão, sem cadastrar produtos no Stripe. Os metadados são
+// gravados também na assinatura, para identificar os eventos das renovações.
+func (s *StripeService) CreateRecurringCheckout(checkout models.RecurringCheckout) (string, string, error) {
+	params := &stripe.CheckoutSessionParams{
+		Mode: stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: stripe.String(checkout.Price.Currency.Code()),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name: stripe.String(checkout.Description),
+					},
+					UnitAmount: stripe.Int64(checkout.Price.Amount),
+					Recurring: &stripe.CheckoutSessionLineItemPriceDataRecurringParams{
+						Interval: stripe.String(checkout.Interval),
+					},
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		SuccessURL: stripe.String(checkout.SuccessURL),
+		CancelURL:  stripe.String(checkout.CancelURL),
+		Metadata:   checkout.Metadata,
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: checkout.Metadata,
+		},
+	}
+	if checkout.CustomerEmail != "" {
+		params.CustomerEmail = stripe.String(checkout.CustomerEmail)
+	}
+
+	session, err := checkoutsession.New(params)
+	if err != nil {
+		log.Printf("Error creating recurring checkout session: %v", err)
+		return "", "", err
+	}
+
+	return session.ID, session.URL, nil
+}
+
+func (s *StripeService) GetRecurringCheckout(sessionID string) (*models.RecurringCheckoutResult, error) {
+	params := &stripe.CheckoutSessionParams{}
+	params.AddExpand("subscription")
+
+	session, err := checkoutsession.Get(sessionID, params)
+	if err != nil {
+		log.Printf("Error fetching checkout session %s: %v", sessionID, err)
+		return nil, err
+	}
+
+	result := &models.RecurringCheckoutResult{
+		Paid: session.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid,
+	}
+	if session.Subscription != nil {
+		result.SubscriptionID = session.Subscription.ID
+		result.PeriodEnd = time.Unix(session.Subscription.CurrentPeriodEnd, 0)
+	}
+	return result, nil
+}
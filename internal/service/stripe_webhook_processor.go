@@ -0,0 +1,366 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	"github.com/anglesson/simple-web-server/pkg/mail"
+	"github.com/stripe/stripe-go/v76"
+)
+
+// StripeWebhookProcessor aplica as regras de negócio dos eventos enviados pelo Stripe
+type StripeWebhookProcessor struct {
+	subscriptionService SubscriptionService
+	dunningService      DunningService
+	orderService        OrderService
+	recoveryService     CheckoutRecoveryService
+	membershipService   MembershipService
+	paymentGateway      PaymentGateway
+	purchaseRepository  *repository.PurchaseRepository
+	emailService        *mail.EmailService
+}
+
+func NewStripeWebhookProcessor(
+	subscriptionService SubscriptionService,
+	dunningService DunningService,
+	orderService OrderService,
+	recoveryService CheckoutRecoveryService,
+	membershipService MembershipService,
+	paymentGateway PaymentGateway,
+	purchaseRepository *repository.PurchaseRepository,
+	emailService *mail.EmailService,
+) WebhookProcessor {
+	return &StripeWebhookProcessor{
+		subscriptionService: subscriptionService,
+		dunningService:      dunningService,
+		orderService:        orderService,
+		recoveryService:     recoveryService,
+		membershipService:   membershipService,
+		paymentGateway:      paymentGateway,
+		purchaseRepository:  purchaseRepository,
+		emailService:        emailService,
+	}
+}
+
+func (p *StripeWebhookProcessor) Process(webhookEvent *models.WebhookEvent) error {
+	var event stripe.Event
+	if err := json.Unmarshal([]byte(webhookEvent.Payload), &event); err != nil {
+		return fmt.Errorf("payload do evento inválido: %v", err)
+	}
+
+	actor := models.GatewayActor(webhookEvent.EventID)
+
+	switch event.Type {
+	case "checkout.session.completed":
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			return fmt.Errorf("error parsing checkout session: %v", err)
+		}
+
+		// Verificar se é um pagamento de ebook ou assinatura
+		if session.Mode == stripe.CheckoutSessionModePayment {
+			return p.handleEbookPayment(session)
+		}
+		if session.Mode == stripe.CheckoutSessionModeSubscription {
+			if memberID(session.Metadata) != 0 {
+				_, err := p.membershipService.ConfirmCheckout(session.ID)
+				return err
+			}
+			return p.handleSubscriptionPayment(session, actor)
+		}
+
+	case "checkout.session.expired":
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			return fmt.Errorf("error parsing checkout session: %v", err)
+		}
+		if session.Mode != stripe.CheckoutSessionModePayment {
+			return nil
+		}
+		return p.recoveryService.HandleSessionExpired(session.ID)
+
+	case "customer.subscription.updated":
+		var stripeSubscription stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &stripeSubscription); err != nil {
+			return fmt.Errorf("error parsing subscription: %v", err)
+		}
+		if id := memberID(stripeSubscription.Metadata); id != 0 {
+			return p.membershipService.HandleSubscriptionUpdated(id, stripeSubscription.ID, string(stripeSubscription.Status),
+				time.Unix(stripeSubscription.CurrentPeriodEnd, 0), subscriptionCancelAt(stripeSubscription))
+		}
+		return p.updateSubscriptionStatus(stripeSubscription, string(stripeSubscription.Status), actor)
+
+	case "customer.subscription.deleted":
+		var stripeSubscription stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &stripeSubscription); err != nil {
+			return fmt.Errorf("error parsing subscription: %v", err)
+		}
+		if id := memberID(stripeSubscription.Metadata); id != 0 {
+			return p.membershipService.HandleSubscriptionUpdated(id, stripeSubscription.ID, "canceled", time.Time{}, nil)
+		}
+		return p.updateSubscriptionStatus(stripeSubscription, "canceled", actor)
+
+	case "invoice.payment_failed":
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return fmt.Errorf("error parsing invoice: %v", err)
+		}
+		if id := invoiceMemberID(invoice); id != 0 {
+			return p.membershipService.HandlePaymentFailed(id)
+		}
+		if invoice.Customer == nil {
+			return nil
+		}
+		return p.dunningService.HandlePaymentFailed(invoice.Customer.ID, actor)
+
+	case "invoice.paid":
+		var invoice stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+			return fmt.Errorf("error parsing invoice: %v", err)
+		}
+		if id := invoiceMemberID(invoice); id != 0 {
+			member, err := p.membershipService.HandleInvoicePaid(id, invoiceSubscriptionID(invoice), invoicePeriodEnd(invoice))
+			if err != nil {
+				return err
+			}
+			return p.orderService.ConfirmMembershipPayment(member, invoicePayment(invoice))
+		}
+		if invoice.Customer == nil {
+			return nil
+		}
+		return p.dunningService.HandleInvoicePaid(invoice.Customer.ID, actor)
+
+	case "charge.succeeded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return fmt.Errorf("error parsing charge: %v", err)
+		}
+		return p.handleChargeFee(charge)
+
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return fmt.Errorf("error parsing charge: %v", err)
+		}
+		if charge.PaymentIntent == nil {
+			return nil
+		}
+		return p.orderService.RefundPayment(charge.PaymentIntent.ID, charge.AmountRefunded)
+
+	case "charge.dispute.created":
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("error parsing dispute: %v", err)
+		}
+		if dispute.PaymentIntent == nil {
+			return nil
+		}
+		return p.orderService.DisputePayment(dispute.PaymentIntent.ID)
+
+	case "charge.dispute.closed":
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("error parsing dispute: %v", err)
+		}
+		if dispute.PaymentIntent == nil {
+			return nil
+		}
+		return p.orderService.ResolveDispute(dispute.PaymentIntent.ID, dispute.Status == stripe.DisputeStatusWon)
+
+	default:
+		log.Printf("Evento de webhook ignorado: %s", event.Type)
+	}
+
+	return nil
+}
+
+// memberID identifica nos metadados os eventos das assinaturas do catálogo dos
+// criadores; as demais assinaturas são as da plataforma
+func memberID(metadata map[string]string) uint {
+	id, err := strconv.ParseUint(metadata[MembershipMemberMetadata], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+func invoiceMemberID(invoice stripe.Invoice) uint {
+	if invoice.SubscriptionDetails != nil {
+		if id := memberID(invoice.SubscriptionDetails.Metadata); id != 0 {
+			return id
+		}
+	}
+	if invoice.Subscription != nil {
+		return memberID(invoice.Subscription.Metadata)
+	}
+	return 0
+}
+
+// invoiceSubscriptionID é a assinatura do gateway cobrada pela fatura
+func invoiceSubscriptionID(invoice stripe.Invoice) string {
+	if invoice.Subscription == nil {
+		return ""
+	}
+	return invoice.Subscription.ID
+}
+
+// invoicePayment é o pagamento da fatura da assinatura, identificado pela fatura
+func invoicePayment(invoice stripe.Invoice) ConfirmPaymentInput {
+	input := ConfirmPaymentInput{
+		GatewayInvoiceID: invoice.ID,
+		AmountPaid:       invoice.AmountPaid,
+		Currency:         string(invoice.Currency),
+	}
+	if invoice.PaymentIntent != nil {
+		input.GatewayPaymentID = invoice.PaymentIntent.ID
+	}
+	return input
+}
+
+// invoicePeriodEnd é o fim do período cobrado pela fatura da assinatura
+func invoicePeriodEnd(invoice stripe.Invoice) time.Time {
+	if invoice.Lines != nil {
+		for _, line := range invoice.Lines.Data {
+			if line.Period != nil && line.Period.End > 0 {
+				return time.Unix(line.Period.End, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func subscriptionCancelAt(stripeSubscription stripe.Subscription) *time.Time {
+	if stripeSubscription.CancelAt == 0 {
+		return nil
+	}
+	cancelAt := time.Unix(stripeSubscription.CancelAt, 0)
+	return &cancelAt
+}
+
+func (p *StripeWebhookProcessor) updateSubscriptionStatus(stripeSubscription stripe.Subscription, status string, actor models.SubscriptionActor) error {
+	// Find subscription by Stripe customer ID
+	subscription, err := p.subscriptionService.FindByStripeCustomerID(stripeSubscription.Customer.ID)
+	if err != nil {
+		return fmt.Errorf("error finding subscription: %v", err)
+	}
+	if subscription == nil {
+		return fmt.Errorf("subscription not found for Stripe customer ID: %s", stripeSubscription.Customer.ID)
+	}
+
+	var endDate *time.Time
+	if stripeSubscription.CurrentPeriodEnd > 0 {
+		periodEnd := time.Unix(stripeSubscription.CurrentPeriodEnd, 0)
+		endDate = &periodEnd
+	}
+
+	if err := p.subscriptionService.UpdateSubscriptionStatus(subscription, status, endDate, actor); err != nil {
+		return fmt.Errorf("error updating subscription: %v", err)
+	}
+
+	// Cancelamentos e reativações feitos pelo portal do gateway
+	if subscription.State() == models.SubscriptionActive {
+		if err := p.subscriptionService.SyncCancellation(subscription, stripeSubscription.CancelAtPeriodEnd, actor); err != nil {
+			return fmt.Errorf("error updating subscription: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// handleEbookPayment confirma o pedido e envia o link de download das compras criadas
+func (p *StripeWebhookProcessor) handleEbookPayment(session stripe.CheckoutSession) error {
+	input := ConfirmPaymentInput{
+		GatewaySessionID: session.ID,
+		AmountPaid:       session.AmountTotal,
+		Currency:         string(session.Currency),
+	}
+
+	if orderIDStr := session.Metadata["order_id"]; orderIDStr != "" {
+		orderID, err := strconv.ParseUint(orderIDStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("order ID inválido: %v", err)
+		}
+		input.OrderID = uint(orderID)
+	}
+
+	if session.PaymentIntent != nil {
+		input.GatewayPaymentID = session.PaymentIntent.ID
+	}
+
+	purchases, err := p.orderService.ConfirmPayment(input)
+	if err != nil {
+		return fmt.Errorf("erro ao confirmar pedido: %v", err)
+	}
+
+	var purchasesWithRelations []*models.Purchase
+	for _, purchase := range purchases {
+		log.Printf("Purchase criado com sucesso: ID=%d, EbookID=%d, ClientID=%d", purchase.ID, purchase.EbookID, purchase.ClientID)
+
+		purchaseWithRelations, err := p.purchaseRepository.FindByID(purchase.ID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar dados da compra: %v", err)
+		}
+
+		if purchaseWithRelations.Client.Email == "" {
+			log.Printf("Cliente sem email: ClientID=%d", purchaseWithRelations.ClientID)
+			continue
+		}
+
+		purchasesWithRelations = append(purchasesWithRelations, purchaseWithRelations)
+	}
+
+	if len(purchasesWithRelations) > 0 {
+		go p.emailService.SendLinkToDownload(purchasesWithRelations)
+	}
+
+	return nil
+}
+
+// handleChargeFee registra a taxa cobrada pelo gateway no pagamento
+func (p *StripeWebhookProcessor) handleChargeFee(charge stripe.Charge) error {
+	if charge.PaymentIntent == nil || charge.BalanceTransaction == nil {
+		return nil
+	}
+
+	fee := charge.BalanceTransaction.Fee
+	if fee == 0 && charge.BalanceTransaction.ID != "" {
+		fetched, err := p.paymentGateway.BalanceTransactionFee(charge.BalanceTransaction.ID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar taxa do pagamento: %v", err)
+		}
+		fee = fetched
+	}
+
+	return p.orderService.RegisterFee(charge.PaymentIntent.ID, fee)
+}
+
+// handleSubscriptionPayment processa pagamento de assinatura
+func (p *StripeWebhookProcessor) handleSubscriptionPayment(session stripe.CheckoutSession, actor models.SubscriptionActor) error {
+	subscription, err := p.subscriptionService.FindByStripeCustomerID(session.Customer.ID)
+	if err != nil {
+		return fmt.Errorf("error finding subscription: %v", err)
+	}
+	if subscription == nil {
+		return fmt.Errorf("subscription not found for Stripe customer ID: %s", session.Customer.ID)
+	}
+
+	err = p.subscriptionService.ActivateSubscription(subscription, session.Customer.ID, session.Subscription.ID, actor)
+	if err != nil {
+		return fmt.Errorf("error updating subscription: %v", err)
+	}
+
+	// Plano escolhido no checkout, liberado só agora que foi pago
+	planID, interval := session.Metadata["plan"], session.Metadata["interval"]
+	if planID != "" && (planID != subscription.PlanID || interval != subscription.BillingInterval) {
+		if err := p.subscriptionService.ChangePlan(subscription, planID, interval, actor); err != nil {
+			return fmt.Errorf("error changing subscription plan: %v", err)
+		}
+	}
+
+	return nil
+}
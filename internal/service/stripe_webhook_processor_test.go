@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stripe/stripe-go/v76"
+)
+
+func TestStripeWebhookProcessor_ChargeFeeFetchedThroughGateway(t *testing.T) {
+	gateway := NewFakePaymentGateway()
+	gateway.Fees["txn_1"] = 349
+	orderRepo := new(repoMocks.MockOrderRepository)
+	payment := &models.Payment{GatewayPaymentID: "pi_1", Amount: 5000}
+	orderRepo.On("FindPaymentByGatewayPaymentID", "pi_1").Return(payment, nil)
+	orderRepo.On("UpdatePayment", payment, mock.Anything).Return(nil)
+
+	processor := &StripeWebhookProcessor{orderService: NewOrderService(orderRepo), paymentGateway: gateway}
+
+	// O evento nem sempre traz a movimentação expandida com a taxa
+	err := processor.handleChargeFee(stripe.Charge{
+		PaymentIntent:      &stripe.PaymentIntent{ID: "pi_1"},
+		BalanceTransaction: &stripe.BalanceTransaction{ID: "txn_1"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(349), payment.Fee)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/anglesson/simple-web-server/internal/models"
@@ -14,25 +16,45 @@ type SubscriptionService interface {
 	FindByUserID(userID uint) (*models.Subscription, error)
 	FindByStripeCustomerID(customerID string) (*models.Subscription, error)
 	FindByStripeSubscriptionID(subscriptionID string) (*models.Subscription, error)
-	ActivateSubscription(subscription *models.Subscription, stripeCustomerID, stripeSubscriptionID string) error
-	UpdateSubscriptionStatus(subscription *models.Subscription, status string, endDate *time.Time) error
-	CancelSubscription(subscription *models.Subscription) error
-	EndTrial(subscription *models.Subscription) error
+	FindPastDue() ([]*models.Subscription, error)
+	AttachCustomer(subscription *models.Subscription, stripeCustomerID string, actor models.SubscriptionActor) error
+	ActivateSubscription(subscription *models.Subscription, stripeCustomerID, stripeSubscriptionID string, actor models.SubscriptionActor) error
+	UpdateSubscriptionStatus(subscription *models.Subscription, status string, endDate *time.Time, actor models.SubscriptionActor) error
+	SyncCancellation(subscription *models.Subscription, cancelAtPeriodEnd bool, actor models.SubscriptionActor) error
+	CancelSubscription(subscription *models.Subscription, actor models.SubscriptionActor) error
+	EndTrial(subscription *models.Subscription, actor models.SubscriptionActor) error
 	GetUserSubscriptionStatus(userID uint) (string, int, error)
+	ScheduleCancellation(subscription *models.Subscription, actor models.SubscriptionActor) error
+	ResumeSubscription(subscription *models.Subscription, actor models.SubscriptionActor) error
+	ChangePlan(subscription *models.Subscription, planID, interval string, actor models.SubscriptionActor) error
+	ListInvoices(subscription *models.Subscription) ([]*models.Invoice, error)
+	CreatePortalSession(subscription *models.Subscription, returnURL string) (string, error)
+	MarkPaymentFailed(subscription *models.Subscription, gracePeriod time.Duration, actor models.SubscriptionActor) error
+	MarkPaymentRecovered(subscription *models.Subscription, actor models.SubscriptionActor) error
+	RecordDunningReminder(subscription *models.Subscription, remindersSent int) error
+	ListEvents(subscription *models.Subscription) ([]*models.SubscriptionEvent, error)
 }
 
+var ErrNoGatewaySubscription = errors.New("assinatura ainda não foi contratada")
+
+// Todas as alterações de assinatura passam por este serviço, que valida a
+// transição de estado e registra quem a provocou no histórico (SubscriptionEvent).
+
 type subscriptionServiceImpl struct {
 	subscriptionRepository repository.SubscriptionRepository
 	receitaFederalService  gov.ReceitaFederalService
+	paymentGateway         PaymentGateway
 }
 
 func NewSubscriptionService(
 	subscriptionRepository repository.SubscriptionRepository,
 	receitaFederalService gov.ReceitaFederalService,
+	paymentGateway PaymentGateway,
 ) SubscriptionService {
 	return &subscriptionServiceImpl{
 		subscriptionRepository: subscriptionRepository,
 		receitaFederalService:  receitaFederalService,
+		paymentGateway:         paymentGateway,
 	}
 }
 
@@ -46,8 +68,8 @@ func (ss *subscriptionServiceImpl) CreateSubscription(userID uint, planID string
 
 	subscription := models.NewSubscription(userID, planID)
 
-	err := ss.subscriptionRepository.Create(subscription)
-	if err != nil {
+	event := models.NewSubscriptionEvent(subscription, "", models.SubscriptionActionCreated, models.UserActor(userID), "plano "+planID)
+	if err := ss.subscriptionRepository.SaveWithEvent(subscription, event); err != nil {
 		return nil, err
 	}
 
@@ -62,6 +84,10 @@ func (ss *subscriptionServiceImpl) FindByUserID(userID uint) (*models.Subscripti
 	return ss.subscriptionRepository.FindByUserID(userID)
 }
 
+func (ss *subscriptionServiceImpl) FindPastDue() ([]*models.Subscription, error) {
+	return ss.subscriptionRepository.FindPastDue()
+}
+
 func (ss *subscriptionServiceImpl) FindByStripeCustomerID(customerID string) (*models.Subscription, error) {
 	if customerID == "" {
 		return nil, errors.New("ID do cliente é obrigatório")
@@ -78,10 +104,33 @@ func (ss *subscriptionServiceImpl) FindByStripeSubscriptionID(subscriptionID str
 	return ss.subscriptionRepository.FindByStripeSubscriptionID(subscriptionID)
 }
 
-func (ss *subscriptionServiceImpl) ActivateSubscription(subscription *models.Subscription, stripeCustomerID, stripeSubscriptionID string) error {
+// apply executa a alteração na assinatura e salva junto com o evento no histórico
+func (ss *subscriptionServiceImpl) apply(subscription *models.Subscription, action string, actor models.SubscriptionActor, details string, change func() error) error {
 	if subscription == nil {
 		return errors.New("assinatura é obrigatória")
 	}
+
+	from := subscription.State()
+	if err := change(); err != nil {
+		return err
+	}
+
+	event := models.NewSubscriptionEvent(subscription, from, action, actor, details)
+	return ss.subscriptionRepository.SaveWithEvent(subscription, event)
+}
+
+func (ss *subscriptionServiceImpl) AttachCustomer(subscription *models.Subscription, stripeCustomerID string, actor models.SubscriptionActor) error {
+	if stripeCustomerID == "" {
+		return errors.New("ID do cliente Stripe é obrigatório")
+	}
+
+	return ss.apply(subscription, models.SubscriptionActionCustomerAttached, actor, stripeCustomerID, func() error {
+		subscription.AttachCustomer(stripeCustomerID)
+		return nil
+	})
+}
+
+func (ss *subscriptionServiceImpl) ActivateSubscription(subscription *models.Subscription, stripeCustomerID, stripeSubscriptionID string, actor models.SubscriptionActor) error {
 	if stripeCustomerID == "" {
 		return errors.New("ID do cliente Stripe é obrigatório")
 	}
@@ -89,42 +138,54 @@ func (ss *subscriptionServiceImpl) ActivateSubscription(subscription *models.Sub
 		return errors.New("ID da assinatura Stripe é obrigatório")
 	}
 
-	subscription.ActivateSubscription(stripeCustomerID, stripeSubscriptionID)
-
-	return ss.subscriptionRepository.Save(subscription)
+	return ss.apply(subscription, models.SubscriptionActionActivated, actor, stripeSubscriptionID, func() error {
+		return subscription.Activate(stripeCustomerID, stripeSubscriptionID)
+	})
 }
 
-func (ss *subscriptionServiceImpl) UpdateSubscriptionStatus(subscription *models.Subscription, status string, endDate *time.Time) error {
-	if subscription == nil {
-		return errors.New("assinatura é obrigatória")
-	}
+func (ss *subscriptionServiceImpl) UpdateSubscriptionStatus(subscription *models.Subscription, status string, endDate *time.Time, actor models.SubscriptionActor) error {
 	if status == "" {
 		return errors.New("status é obrigatório")
 	}
+	// Status novos do gateway não devem travar o webhook: ficam só no log
+	if _, ok := models.SubscriptionStateFromGateway(status); !ok {
+		log.Printf("Status de assinatura %q desconhecido para a assinatura %d, ignorando", status, subscription.ID)
+		return nil
+	}
 
-	subscription.UpdateSubscriptionStatus(status, endDate)
-
-	return ss.subscriptionRepository.Save(subscription)
+	return ss.apply(subscription, models.SubscriptionActionGatewaySync, actor, "status "+status, func() error {
+		return subscription.ApplyGatewayStatus(status, endDate)
+	})
 }
 
-func (ss *subscriptionServiceImpl) CancelSubscription(subscription *models.Subscription) error {
+// SyncCancellation aplica cancelamentos e reativações feitos diretamente no gateway.
+// Nada é registrado quando a assinatura já está na situação informada.
+func (ss *subscriptionServiceImpl) SyncCancellation(subscription *models.Subscription, cancelAtPeriodEnd bool, actor models.SubscriptionActor) error {
 	if subscription == nil {
 		return errors.New("assinatura é obrigatória")
 	}
+	if subscription.CancelAtPeriodEnd == cancelAtPeriodEnd {
+		return nil
+	}
 
-	subscription.CancelSubscription()
-
-	return ss.subscriptionRepository.Save(subscription)
-}
-
-func (ss *subscriptionServiceImpl) EndTrial(subscription *models.Subscription) error {
-	if subscription == nil {
-		return errors.New("assinatura é obrigatória")
+	if cancelAtPeriodEnd {
+		return ss.apply(subscription, models.SubscriptionActionCancellationScheduled, actor, "", func() error {
+			return subscription.ScheduleCancellation(time.Time{})
+		})
 	}
+	return ss.apply(subscription, models.SubscriptionActionResumed, actor, "", subscription.Resume)
+}
 
-	subscription.EndTrial()
+func (ss *subscriptionServiceImpl) CancelSubscription(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	return ss.apply(subscription, models.SubscriptionActionCanceled, actor, "", func() error {
+		return subscription.Cancel()
+	})
+}
 
-	return ss.subscriptionRepository.Save(subscription)
+func (ss *subscriptionServiceImpl) EndTrial(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	return ss.apply(subscription, models.SubscriptionActionTrialEnded, actor, "", func() error {
+		return subscription.EndTrial()
+	})
 }
 
 func (ss *subscriptionServiceImpl) GetUserSubscriptionStatus(userID uint) (string, int, error) {
@@ -144,7 +205,9 @@ func (ss *subscriptionServiceImpl) GetUserSubscriptionStatus(userID uint) (strin
 	status := subscription.GetSubscriptionStatus()
 	daysLeft := 0
 
-	if subscription.IsInTrialPeriod() {
+	if subscription.IsInGracePeriod() {
+		daysLeft = subscription.DaysLeftInGracePeriod()
+	} else if subscription.IsInTrialPeriod() {
 		daysLeft = subscription.DaysLeftInTrial()
 	} else if subscription.IsSubscribed() {
 		daysLeft = subscription.DaysLeftInSubscription()
@@ -152,3 +215,112 @@ func (ss *subscriptionServiceImpl) GetUserSubscriptionStatus(userID uint) (strin
 
 	return status, daysLeft, nil
 }
+
+// ScheduleCancellation cancela a assinatura no gateway ao fim do período pago,
+// mantendo o acesso do criador até lá.
+func (ss *subscriptionServiceImpl) ScheduleCancellation(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	if subscription == nil {
+		return errors.New("assinatura é obrigatória")
+	}
+	if !subscription.HasGatewaySubscription() || !subscription.IsSubscribed() {
+		return ErrNoGatewaySubscription
+	}
+	if subscription.CancelAtPeriodEnd {
+		return nil
+	}
+
+	periodEnd, err := ss.paymentGateway.ScheduleSubscriptionCancellation(subscription.StripeSubscriptionID)
+	if err != nil {
+		return errors.New("erro ao cancelar assinatura")
+	}
+
+	return ss.apply(subscription, models.SubscriptionActionCancellationScheduled, actor, "", func() error {
+		return subscription.ScheduleCancellation(periodEnd)
+	})
+}
+
+func (ss *subscriptionServiceImpl) ResumeSubscription(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	if subscription == nil {
+		return errors.New("assinatura é obrigatória")
+	}
+	if !subscription.IsCancellationScheduled() {
+		return errors.New("assinatura não possui cancelamento agendado")
+	}
+
+	if err := ss.paymentGateway.ResumeSubscription(subscription.StripeSubscriptionID); err != nil {
+		return errors.New("erro ao reativar assinatura")
+	}
+
+	return ss.apply(subscription, models.SubscriptionActionResumed, actor, "", subscription.Resume)
+}
+
+func (ss *subscriptionServiceImpl) ChangePlan(subscription *models.Subscription, planID, interval string, actor models.SubscriptionActor) error {
+	if planID == "" {
+		return errors.New("ID do plano é obrigatório")
+	}
+
+	details := fmt.Sprintf("%s (%s) para %s (%s)", subscription.PlanID, subscription.BillingInterval, planID, interval)
+	return ss.apply(subscription, models.SubscriptionActionPlanChanged, actor, details, func() error {
+		subscription.ChangePlan(planID, interval)
+		return nil
+	})
+}
+
+func (ss *subscriptionServiceImpl) ListInvoices(subscription *models.Subscription) ([]*models.Invoice, error) {
+	if subscription == nil {
+		return nil, errors.New("assinatura é obrigatória")
+	}
+	if subscription.StripeCustomerID == "" {
+		return []*models.Invoice{}, nil
+	}
+
+	invoices, err := ss.paymentGateway.ListInvoices(subscription.StripeCustomerID)
+	if err != nil {
+		return nil, errors.New("erro ao buscar faturas")
+	}
+
+	return invoices, nil
+}
+
+func (ss *subscriptionServiceImpl) CreatePortalSession(subscription *models.Subscription, returnURL string) (string, error) {
+	if subscription == nil {
+		return "", errors.New("assinatura é obrigatória")
+	}
+	if subscription.StripeCustomerID == "" {
+		return "", ErrNoGatewaySubscription
+	}
+
+	url, err := ss.paymentGateway.CreatePortalSession(subscription.StripeCustomerID, returnURL)
+	if err != nil {
+		return "", errors.New("erro ao abrir portal de pagamento")
+	}
+
+	return url, nil
+}
+
+func (ss *subscriptionServiceImpl) MarkPaymentFailed(subscription *models.Subscription, gracePeriod time.Duration, actor models.SubscriptionActor) error {
+	return ss.apply(subscription, models.SubscriptionActionPaymentFailed, actor, "", func() error {
+		return subscription.MarkPaymentFailed(gracePeriod)
+	})
+}
+
+func (ss *subscriptionServiceImpl) MarkPaymentRecovered(subscription *models.Subscription, actor models.SubscriptionActor) error {
+	return ss.apply(subscription, models.SubscriptionActionPaymentRecovered, actor, "", subscription.MarkPaymentRecovered)
+}
+
+// RecordDunningReminder registra quantos lembretes de pagamento já foram enviados
+func (ss *subscriptionServiceImpl) RecordDunningReminder(subscription *models.Subscription, remindersSent int) error {
+	details := fmt.Sprintf("lembrete %d", remindersSent)
+	return ss.apply(subscription, models.SubscriptionActionDunningReminder, models.SystemActor, details, func() error {
+		subscription.DunningRemindersSent = remindersSent
+		return nil
+	})
+}
+
+func (ss *subscriptionServiceImpl) ListEvents(subscription *models.Subscription) ([]*models.SubscriptionEvent, error) {
+	if subscription == nil {
+		return nil, errors.New("assinatura é obrigatória")
+	}
+
+	return ss.subscriptionRepository.FindEvents(subscription.ID)
+}
@@ -8,10 +8,106 @@ import (
 	"github.com/anglesson/simple-web-server/internal/models"
 	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
 	govMocks "github.com/anglesson/simple-web-server/pkg/gov/mocks"
+	"github.com/anglesson/simple-web-server/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+type MockPaymentGateway struct {
+	mock.Mock
+}
+
+func (m *MockPaymentGateway) CreateCustomer(email, name string) (string, error) {
+	args := m.Called(email, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateSubscription(customerID, priceID string) (string, error) {
+	args := m.Called(customerID, priceID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CancelSubscription(subscriptionID string) error {
+	args := m.Called(subscriptionID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) UpdateSubscriptionPrice(subscriptionID, priceID string) error {
+	args := m.Called(subscriptionID, priceID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) ScheduleSubscriptionCancellation(subscriptionID string) (time.Time, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockPaymentGateway) ResumeSubscription(subscriptionID string) error {
+	args := m.Called(subscriptionID)
+	return args.Error(0)
+}
+
+func (m *MockPaymentGateway) ListInvoices(customerID string) ([]*models.Invoice, error) {
+	args := m.Called(customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Invoice), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreatePortalSession(customerID, returnURL string) (string, error) {
+	args := m.Called(customerID, returnURL)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) ChargeSavedPaymentMethod(paymentID string, amount int64, currency money.Currency, description, idempotencyKey string) (string, error) {
+	args := m.Called(paymentID, amount, currency, description, idempotencyKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateConnectedAccount(email string) (string, error) {
+	args := m.Called(email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateAccountLink(accountID, refreshURL, returnURL string) (string, error) {
+	args := m.Called(accountID, refreshURL, returnURL)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) PayoutsEnabled(accountID string) (bool, error) {
+	args := m.Called(accountID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) Transfer(accountID string, amount int64, currency money.Currency, reference string) (string, error) {
+	args := m.Called(accountID, amount, currency, reference)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) BalanceTransactionFee(balanceTransactionID string) (int64, error) {
+	args := m.Called(balanceTransactionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateCoupon(amountOff int64, currency money.Currency, name string) (string, error) {
+	args := m.Called(amountOff, currency, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentGateway) CreateRecurringCheckout(checkout models.RecurringCheckout) (string, string, error) {
+	args := m.Called(checkout)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockPaymentGateway) GetRecurringCheckout(sessionID string) (*models.RecurringCheckoutResult, error) {
+	args := m.Called(sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecurringCheckoutResult), args.Error(1)
+}
+
 func TestSubscriptionService_CreateSubscription(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -26,7 +122,7 @@ func TestSubscriptionService_CreateSubscription(t *testing.T) {
 			userID: 1,
 			planID: "test_plan",
 			setupMocks: func(mockRepo *repoMocks.MockSubscriptionRepository) {
-				mockRepo.On("Create", mock.AnythingOfType("*models.Subscription")).Return(nil)
+				mockRepo.On("SaveWithEvent", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
 			},
 			expectedError: false,
 		},
@@ -51,7 +147,7 @@ func TestSubscriptionService_CreateSubscription(t *testing.T) {
 			userID: 1,
 			planID: "test_plan",
 			setupMocks: func(mockRepo *repoMocks.MockSubscriptionRepository) {
-				mockRepo.On("Create", mock.AnythingOfType("*models.Subscription")).Return(errors.New("database error"))
+				mockRepo.On("SaveWithEvent", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.SubscriptionEvent")).Return(errors.New("database error"))
 			},
 			expectedError:  true,
 			expectedResult: nil,
@@ -64,7 +160,7 @@ func TestSubscriptionService_CreateSubscription(t *testing.T) {
 			mockRF := new(govMocks.MockRFService)
 			tt.setupMocks(mockRepo)
 
-			service := NewSubscriptionService(mockRepo, mockRF)
+			service := NewSubscriptionService(mockRepo, mockRF, nil)
 
 			result, err := service.CreateSubscription(tt.userID, tt.planID)
 
@@ -129,7 +225,7 @@ func TestSubscriptionService_FindByUserID(t *testing.T) {
 			mockRF := new(govMocks.MockRFService)
 			tt.setupMocks(mockRepo)
 
-			service := NewSubscriptionService(mockRepo, mockRF)
+			service := NewSubscriptionService(mockRepo, mockRF, nil)
 
 			result, err := service.FindByUserID(tt.userID)
 
@@ -163,7 +259,7 @@ func TestSubscriptionService_ActivateSubscription(t *testing.T) {
 			stripeCustomerID:     "cus_123",
 			stripeSubscriptionID: "sub_123",
 			setupMocks: func(mockRepo *repoMocks.MockSubscriptionRepository) {
-				mockRepo.On("Save", mock.AnythingOfType("*models.Subscription")).Return(nil)
+				mockRepo.On("SaveWithEvent", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
 			},
 			expectedError: false,
 		},
@@ -205,9 +301,9 @@ func TestSubscriptionService_ActivateSubscription(t *testing.T) {
 			mockRF := new(govMocks.MockRFService)
 			tt.setupMocks(mockRepo)
 
-			service := NewSubscriptionService(mockRepo, mockRF)
+			service := NewSubscriptionService(mockRepo, mockRF, nil)
 
-			err := service.ActivateSubscription(tt.subscription, tt.stripeCustomerID, tt.stripeSubscriptionID)
+			err := service.ActivateSubscription(tt.subscription, tt.stripeCustomerID, tt.stripeSubscriptionID, models.UserActor(1))
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -215,7 +311,7 @@ func TestSubscriptionService_ActivateSubscription(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.stripeCustomerID, tt.subscription.StripeCustomerID)
 				assert.Equal(t, tt.stripeSubscriptionID, tt.subscription.StripeSubscriptionID)
-				assert.Equal(t, "active", tt.subscription.SubscriptionStatus)
+				assert.Equal(t, models.SubscriptionActive, tt.subscription.State())
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -231,13 +327,10 @@ func TestSubscriptionService_CancelSubscription(t *testing.T) {
 		expectedError bool
 	}{
 		{
-			name: "should cancel subscription successfully",
-			subscription: &models.Subscription{
-				UserID: 1,
-				PlanID: "test_plan",
-			},
+			name:         "should cancel subscription successfully",
+			subscription: newActiveSubscription(),
 			setupMocks: func(mockRepo *repoMocks.MockSubscriptionRepository) {
-				mockRepo.On("Save", mock.AnythingOfType("*models.Subscription")).Return(nil)
+				mockRepo.On("SaveWithEvent", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
 			},
 			expectedError: false,
 		},
@@ -255,15 +348,15 @@ func TestSubscriptionService_CancelSubscription(t *testing.T) {
 			mockRF := new(govMocks.MockRFService)
 			tt.setupMocks(mockRepo)
 
-			service := NewSubscriptionService(mockRepo, mockRF)
+			service := NewSubscriptionService(mockRepo, mockRF, nil)
 
-			err := service.CancelSubscription(tt.subscription)
+			err := service.CancelSubscription(tt.subscription, models.UserActor(1))
 
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, "canceled", tt.subscription.SubscriptionStatus)
+				assert.Equal(t, models.SubscriptionCanceled, tt.subscription.State())
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -286,7 +379,7 @@ func TestSubscriptionService_EndTrial(t *testing.T) {
 				IsTrialActive: true,
 			},
 			setupMocks: func(mockRepo *repoMocks.MockSubscriptionRepository) {
-				mockRepo.On("Save", mock.AnythingOfType("*models.Subscription")).Return(nil)
+				mockRepo.On("SaveWithEvent", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
 			},
 			expectedError: false,
 		},
@@ -304,9 +397,9 @@ func TestSubscriptionService_EndTrial(t *testing.T) {
 			mockRF := new(govMocks.MockRFService)
 			tt.setupMocks(mockRepo)
 
-			service := NewSubscriptionService(mockRepo, mockRF)
+			service := NewSubscriptionService(mockRepo, mockRF, nil)
 
-			err := service.EndTrial(tt.subscription)
+			err := service.EndTrial(tt.subscription, models.SystemActor)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -353,7 +446,7 @@ func TestSubscriptionService_GetUserSubscriptionStatus(t *testing.T) {
 					UserID:              1,
 					PlanID:              "test_plan",
 					IsTrialActive:       false,
-					SubscriptionStatus:  "active",
+					SubscriptionStatus:  models.SubscriptionActive,
 					SubscriptionEndDate: &time.Time{}, // Will be set in test
 				}
 				endDate := time.Now().AddDate(0, 1, 0) // 1 month from now
@@ -372,7 +465,7 @@ func TestSubscriptionService_GetUserSubscriptionStatus(t *testing.T) {
 					UserID:              1,
 					PlanID:              "test_plan",
 					IsTrialActive:       false,
-					SubscriptionStatus:  "active",
+					SubscriptionStatus:  models.SubscriptionActive,
 					SubscriptionEndDate: &time.Time{}, // Will be set in test
 				}
 				endDate := time.Now().AddDate(0, 0, 8) // 8 days from now
@@ -419,7 +512,7 @@ func TestSubscriptionService_GetUserSubscriptionStatus(t *testing.T) {
 			mockRF := new(govMocks.MockRFService)
 			tt.setupMocks(mockRepo)
 
-			service := NewSubscriptionService(mockRepo, mockRF)
+			service := NewSubscriptionService(mockRepo, mockRF, nil)
 
 			status, days, err := service.GetUserSubscriptionStatus(tt.userID)
 
@@ -444,3 +537,129 @@ func TestSubscriptionService_GetUserSubscriptionStatus(t *testing.T) {
 		})
 	}
 }
+
+func newActiveSubscription() *models.Subscription {
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	subscription.Activate("cus_1", "sub_1")
+	return subscription
+}
+
+func TestSubscriptionService_ScheduleCancellation(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	mockGateway := new(MockPaymentGateway)
+	subscription := newActiveSubscription()
+	periodEnd := time.Now().AddDate(0, 0, 20).Truncate(time.Second)
+
+	mockGateway.On("ScheduleSubscriptionCancellation", "sub_1").Return(periodEnd, nil)
+	mockRepo.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+
+	service := NewSubscriptionService(mockRepo, nil, mockGateway)
+	err := service.ScheduleCancellation(subscription, models.UserActor(1))
+
+	assert.NoError(t, err)
+	assert.True(t, subscription.IsCancellationScheduled())
+	assert.Equal(t, periodEnd, *subscription.SubscriptionEndDate)
+	assert.NotNil(t, subscription.CanceledAt)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ScheduleCancellation_RequiresGatewaySubscription(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	mockGateway := new(MockPaymentGateway)
+
+	service := NewSubscriptionService(mockRepo, nil, mockGateway)
+	err := service.ScheduleCancellation(models.NewSubscription(1, models.DefaultPlanSlug), models.UserActor(1))
+
+	assert.ErrorIs(t, err, ErrNoGatewaySubscription)
+	mockGateway.AssertNotCalled(t, "ScheduleSubscriptionCancellation", mock.Anything)
+}
+
+func TestSubscriptionService_ResumeSubscription(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	mockGateway := new(MockPaymentGateway)
+	subscription := newActiveSubscription()
+	subscription.ScheduleCancellation(time.Now().AddDate(0, 0, 10))
+
+	mockGateway.On("ResumeSubscription", "sub_1").Return(nil)
+	mockRepo.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+
+	service := NewSubscriptionService(mockRepo, nil, mockGateway)
+	err := service.ResumeSubscription(subscription, models.UserActor(1))
+
+	assert.NoError(t, err)
+	assert.False(t, subscription.CancelAtPeriodEnd)
+	assert.Nil(t, subscription.CanceledAt)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ListInvoices_WithoutCustomer(t *testing.T) {
+	mockGateway := new(MockPaymentGateway)
+
+	service := NewSubscriptionService(new(repoMocks.MockSubscriptionRepository), nil, mockGateway)
+	invoices, err := service.ListInvoices(models.NewSubscription(1, models.DefaultPlanSlug))
+
+	assert.NoError(t, err)
+	assert.Empty(t, invoices)
+	mockGateway.AssertNotCalled(t, "ListInvoices", mock.Anything)
+}
+
+func TestSubscriptionService_RecordsEventWithActor(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+	subscription.ID = 10
+
+	var recorded *models.SubscriptionEvent
+	mockRepo.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).
+		Run(func(args mock.Arguments) { recorded = args.Get(1).(*models.SubscriptionEvent) }).
+		Return(nil)
+
+	service := NewSubscriptionService(mockRepo, nil, nil)
+	err := service.ActivateSubscription(subscription, "cus_1", "sub_1", models.GatewayActor("evt_1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), recorded.SubscriptionID)
+	assert.Equal(t, models.SubscriptionTrialing, recorded.FromState)
+	assert.Equal(t, models.SubscriptionActive, recorded.ToState)
+	assert.Equal(t, models.SubscriptionActionActivated, recorded.Action)
+	assert.Equal(t, models.ActorTypeGateway, recorded.ActorType)
+	assert.Equal(t, "evt_1", recorded.ActorID)
+}
+
+func TestSubscriptionService_RejectsInvalidTransition(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	subscription := models.NewSubscription(1, models.DefaultPlanSlug)
+
+	service := NewSubscriptionService(mockRepo, nil, nil)
+	err := service.MarkPaymentRecovered(subscription, models.SystemActor)
+
+	assert.ErrorIs(t, err, models.ErrInvalidSubscriptionTransition)
+	assert.Equal(t, models.SubscriptionTrialing, subscription.State())
+	mockRepo.AssertNotCalled(t, "SaveWithEvent", mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionService_UpdateSubscriptionStatus_IgnoresUnknownStatus(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	subscription := newActiveSubscription()
+
+	service := NewSubscriptionService(mockRepo, nil, nil)
+	err := service.UpdateSubscriptionStatus(subscription, "novo_status", nil, models.GatewayActor("evt_1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.SubscriptionActive, subscription.State())
+	mockRepo.AssertNotCalled(t, "SaveWithEvent", mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionService_UpdateSubscriptionStatus_WaitsForDunningOnPastDue(t *testing.T) {
+	mockRepo := new(repoMocks.MockSubscriptionRepository)
+	subscription := newActiveSubscription()
+	endDate := time.Now().AddDate(0, 1, 0)
+
+	mockRepo.On("SaveWithEvent", subscription, mock.AnythingOfType("*models.SubscriptionEvent")).Return(nil)
+
+	service := NewSubscriptionService(mockRepo, nil, nil)
+	err := service.UpdateSubscriptionStatus(subscription, "past_due", &endDate, models.GatewayActor("evt_1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.SubscriptionActive, subscription.State())
+	assert.Equal(t, endDate, *subscription.SubscriptionEndDate)
+}
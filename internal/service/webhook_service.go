@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+)
+
+// ErrWebhookAlreadyProcessed impede o reprocessamento acidental de eventos que já
+// produziram efeito (e-mails, liberações, repasses)
+var ErrWebhookAlreadyProcessed = errors.New("evento de webhook já processado, use --force para reprocessar")
+
+// WebhookProcessor executa a regra de negócio associada a um evento de webhook
+type WebhookProcessor interface {
+	Process(event *models.WebhookEvent) error
+}
+
+type WebhookService interface {
+	HandleEvent(eventID, eventType string, payload []byte) (*models.WebhookEvent, error)
+	RetryFailedEvents() (int, error)
+	ReplayEvent(eventID string, force bool) (*models.WebhookEvent, error)
+	ReplayPeriod(start, end time.Time, force bool) (int, error)
+	StartRetryWorker(interval time.Duration)
+}
+
+type webhookServiceImpl struct {
+	webhookEventRepository repository.WebhookEventRepository
+	processor              WebhookProcessor
+}
+
+func NewWebhookService(webhookEventRepository repository.WebhookEventRepository, processor WebhookProcessor) WebhookService {
+	return &webhookServiceImpl{
+		webhookEventRepository: webhookEventRepository,
+		processor:              processor,
+	}
+}
+
+// HandleEvent registra o evento recebido e o processa uma única vez.
+// Eventos já processados ou assumidos por outra entrega são ignorados. Falhas de processamento ficam
+// registradas no evento e são reprocessadas pelo RetryFailedEvents; o
+// erro retornado indica apenas falha ao persistir o evento.
+func (s *webhookServiceImpl) HandleEvent(eventID, eventType string, payload []byte) (*models.WebhookEvent, error) {
+	if eventID == "" {
+		return nil, errors.New("ID do evento é obrigatório")
+	}
+
+	event, err := s.webhookEventRepository.FindByEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event != nil && event.IsProcessed() {
+		log.Printf("Evento de webhook %s já processado, ignorando", eventID)
+		return event, nil
+	}
+
+	if event == nil {
+		event = models.NewWebhookEvent(eventID, eventType, payload)
+		if err := s.webhookEventRepository.Create(event); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.process(event, false); err != nil {
+		if errors.Is(err, models.ErrWebhookEventInProgress) {
+			log.Printf("Evento de webhook %s já está sendo processado, ignorando", eventID)
+			return event, nil
+		}
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// RetryFailedEvents reprocessa os eventos com falha cujo backoff já expirou
+func (s *webhookServiceImpl) RetryFailedEvents() (int, error) {
+	events, err := s.webhookEventRepository.FindDueForRetry(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, event := range events {
+		if err := s.process(event, false); err != nil {
+			log.Printf("Erro ao reprocessar evento %s: %v", event.EventID, err)
+			continue
+		}
+		if event.IsProcessed() {
+			processed++
+		}
+	}
+
+	return processed, nil
+}
+
+// ReplayEvent reprocessa um evento pendente ou com falha. Eventos já processados
+// só são reprocessados com force.
+func (s *webhookServiceImpl) ReplayEvent(eventID string, force bool) (*models.WebhookEvent, error) {
+	event, err := s.webhookEventRepository.FindByEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("evento de webhook %s não encontrado", eventID)
+	}
+	if event.IsProcessed() && !force {
+		return event, ErrWebhookAlreadyProcessed
+	}
+
+	if err := s.process(event, force); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// ReplayPeriod reprocessa os eventos pendentes ou com falha recebidos no intervalo
+// informado. Com force, os já processados também são reprocessados.
+func (s *webhookServiceImpl) ReplayPeriod(start, end time.Time, force bool) (int, error) {
+	if end.Before(start) {
+		return 0, errors.New("período inválido")
+	}
+
+	events, err := s.webhookEventRepository.FindByPeriod(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	processed, skipped := 0, 0
+	for _, event := range events {
+		if event.IsProcessed() && !force {
+			skipped++
+			continue
+		}
+		if err := s.process(event, force); err != nil {
+			log.Printf("Erro ao reprocessar evento %s: %v", event.EventID, err)
+			continue
+		}
+		if event.IsProcessed() {
+			processed++
+		}
+	}
+	if skipped > 0 {
+		log.Printf("%d evento(s) já processado(s) ignorado(s); use --force para reprocessá-los", skipped)
+	}
+
+	return processed, nil
+}
+
+func (s *webhookServiceImpl) StartRetryWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := s.RetryFailedEvents(); err != nil {
+				log.Printf("Erro ao reprocessar eventos de webhook: %v", err)
+			}
+		}
+	}()
+}
+
+// process assume o evento antes de executá-lo, para que entregas simultâneas
+// do gateway, o worker de retentativas e o replay não o processem em paralelo
+func (s *webhookServiceImpl) process(event *models.WebhookEvent, force bool) error {
+	if err := s.webhookEventRepository.Claim(event, force); err != nil {
+		return err
+	}
+
+	if err := s.processor.Process(event); err != nil {
+		log.Printf("Falha ao processar evento de webhook %s (%s), tentativa %d: %v", event.EventID, event.Type, event.Attempts+1, err)
+		event.MarkFailed(err)
+	} else {
+		event.MarkProcessed()
+	}
+
+	return s.webhookEventRepository.Update(event)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anglesson/simple-web-server/internal/models"
+	"github.com/anglesson/simple-web-server/internal/repository"
+	repoMocks "github.com/anglesson/simple-web-server/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type MockWebhookProcessor struct {
+	mock.Mock
+}
+
+func (m *MockWebhookProcessor) Process(event *models.WebhookEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func TestWebhookService_HandleEvent_NewEventIsStoredAndProcessed(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	mockRepo.On("FindByEventID", "evt_1").Return(nil, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+	mockRepo.On("Claim", mock.AnythingOfType("*models.WebhookEvent"), false).Return(nil)
+	mockProcessor.On("Process", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+	mockRepo.On("Update", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.HandleEvent("evt_1", "checkout.session.completed", []byte(`{"id":"evt_1"}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookEventProcessed, event.Status)
+	assert.Equal(t, `{"id":"evt_1"}`, event.Payload)
+	mockRepo.AssertExpectations(t)
+	mockProcessor.AssertExpectations(t)
+}
+
+func TestWebhookService_HandleEvent_SkipsAlreadyProcessedEvent(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	existing := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	existing.MarkProcessed()
+	mockRepo.On("FindByEventID", "evt_1").Return(existing, nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.HandleEvent("evt_1", "checkout.session.completed", []byte("{}"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, event)
+	mockProcessor.AssertNotCalled(t, "Process", mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWebhookService_HandleEvent_SkipsEventClaimedByAnotherDelivery(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	existing := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	mockRepo.On("FindByEventID", "evt_1").Return(existing, nil)
+	mockRepo.On("Claim", existing, false).Return(models.ErrWebhookEventInProgress)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.HandleEvent("evt_1", "checkout.session.completed", []byte("{}"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, event)
+	mockProcessor.AssertNotCalled(t, "Process", mock.Anything)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// countingProcessor conta as execuções e segura cada uma por um instante,
+// para que as entregas simultâneas se sobreponham
+type countingProcessor struct {
+	calls atomic.Int32
+}
+
+func (p *countingProcessor) Process(event *models.WebhookEvent) error {
+	p.calls.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+
+func TestWebhookService_HandleEvent_ConcurrentDeliveriesProcessOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "webhooks.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	db.AutoMigrate(&models.WebhookEvent{})
+
+	// Primeira entrega falhou; o gateway reenvia o evento várias vezes ao mesmo tempo
+	failed := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	failed.MarkFailed(errors.New("timeout"))
+	db.Create(failed)
+
+	processor := &countingProcessor{}
+	service := NewWebhookService(repository.NewGormWebhookEventRepository(db), processor)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.HandleEvent("evt_1", "checkout.session.completed", []byte("{}"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), processor.calls.Load())
+
+	var saved models.WebhookEvent
+	db.First(&saved, failed.ID)
+	assert.Equal(t, models.WebhookEventProcessed, saved.Status)
+	assert.Equal(t, 2, saved.Attempts)
+}
+
+func TestWebhookService_HandleEvent_RecordsProcessingFailure(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	mockRepo.On("FindByEventID", "evt_1").Return(nil, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+	mockRepo.On("Claim", mock.AnythingOfType("*models.WebhookEvent"), false).Return(nil)
+	mockProcessor.On("Process", mock.AnythingOfType("*models.WebhookEvent")).Return(errors.New("cliente sem email válido"))
+	mockRepo.On("Update", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.HandleEvent("evt_1", "checkout.session.completed", []byte("{}"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookEventFailed, event.Status)
+	assert.Equal(t, "cliente sem email válido", event.Error)
+	assert.NotNil(t, event.NextAttemptAt)
+}
+
+func TestWebhookService_HandleEvent_ReturnsErrorWhenEventCannotBeStored(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	mockRepo.On("FindByEventID", "evt_1").Return(nil, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.WebhookEvent")).Return(errors.New("database error"))
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.HandleEvent("evt_1", "checkout.session.completed", []byte("{}"))
+
+	assert.Error(t, err)
+	assert.Nil(t, event)
+	mockProcessor.AssertNotCalled(t, "Process", mock.Anything)
+}
+
+func TestWebhookService_RetryFailedEvents(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	recovered := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	recovered.MarkFailed(errors.New("timeout"))
+	stillFailing := models.NewWebhookEvent("evt_2", "checkout.session.completed", []byte("{}"))
+	stillFailing.MarkFailed(errors.New("timeout"))
+
+	mockRepo.On("FindDueForRetry", mock.AnythingOfType("time.Time")).Return([]*models.WebhookEvent{recovered, stillFailing}, nil)
+	mockRepo.On("Claim", mock.AnythingOfType("*models.WebhookEvent"), false).Return(nil)
+	mockProcessor.On("Process", recovered).Return(nil)
+	mockProcessor.On("Process", stillFailing).Return(errors.New("timeout"))
+	mockRepo.On("Update", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	processed, err := service.RetryFailedEvents()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.True(t, recovered.IsProcessed())
+	assert.Equal(t, 2, stillFailing.Attempts)
+}
+
+func TestWebhookService_ReplayEvent(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	existing := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	existing.MarkProcessed()
+
+	mockRepo.On("FindByEventID", "evt_1").Return(existing, nil)
+	mockRepo.On("Claim", existing, true).Return(nil)
+	mockProcessor.On("Process", existing).Return(nil)
+	mockRepo.On("Update", existing).Return(nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.ReplayEvent("evt_1", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, event.Attempts)
+	mockProcessor.AssertExpectations(t)
+}
+
+func TestWebhookService_ReplayEvent_ProcessedRequiresForce(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	existing := models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}"))
+	existing.MarkProcessed()
+	mockRepo.On("FindByEventID", "evt_1").Return(existing, nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.ReplayEvent("evt_1", false)
+
+	assert.ErrorIs(t, err, ErrWebhookAlreadyProcessed)
+	assert.Equal(t, 1, event.Attempts)
+	mockProcessor.AssertNotCalled(t, "Process", mock.Anything)
+}
+
+func TestWebhookService_ReplayEvent_NotFound(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	mockRepo.On("FindByEventID", "evt_404").Return(nil, nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	event, err := service.ReplayEvent("evt_404", false)
+
+	assert.Error(t, err)
+	assert.Nil(t, event)
+}
+
+func TestWebhookService_ReplayPeriod(t *testing.T) {
+	mockRepo := new(repoMocks.MockWebhookEventRepository)
+	mockProcessor := new(MockWebhookProcessor)
+
+	start := time.Now().Add(-time.Hour)
+	end := time.Now()
+	alreadyProcessed := models.NewWebhookEvent("evt_3", "invoice.paid", []byte("{}"))
+	alreadyProcessed.MarkProcessed()
+	events := []*models.WebhookEvent{
+		models.NewWebhookEvent("evt_1", "checkout.session.completed", []byte("{}")),
+		models.NewWebhookEvent("evt_2", "customer.subscription.updated", []byte("{}")),
+		alreadyProcessed,
+	}
+
+	mockRepo.On("FindByPeriod", start, end).Return(events, nil)
+	mockRepo.On("Claim", mock.AnythingOfType("*models.WebhookEvent"), mock.AnythingOfType("bool")).Return(nil)
+	mockProcessor.On("Process", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+	mockRepo.On("Update", mock.AnythingOfType("*models.WebhookEvent")).Return(nil)
+
+	service := NewWebhookService(mockRepo, mockProcessor)
+
+	processed, err := service.ReplayPeriod(start, end, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	mockProcessor.AssertNotCalled(t, "Process", alreadyProcessed)
+
+	processed, err = service.ReplayPeriod(start, end, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, processed)
+}
+
+func TestWebhookService_ReplayPeriod_InvalidRange(t *testing.T) {
+	service := NewWebhookService(new(repoMocks.MockWebhookEventRepository), new(MockWebhookProcessor))
+
+	_, err := service.ReplayPeriod(time.Now(), time.Now().Add(-time.Hour), false)
+
+	assert.Error(t, err)
+}
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"log"
+	"time"
 
 	"github.com/anglesson/simple-web-server/internal/config"
 	"github.com/anglesson/simple-web-server/internal/models"
@@ -44,9 +46,148 @@ func migrate() {
 	DB.AutoMigrate(&models.Client{})
 	DB.AutoMigrate(&models.Contact{})
 	DB.AutoMigrate(&models.Creator{})
+	DB.AutoMigrate(&models.CustomDomain{})
 	DB.AutoMigrate(&models.Ebook{})
+	DB.AutoMigrate(&models.EbookFile{})
+	DB.AutoMigrate(&models.EbookPricePeriod{})
+	DB.AutoMigrate(&models.Lead{})
+	DB.AutoMigrate(&models.Gift{})
+	DB.AutoMigrate(&models.License{})
+	DB.AutoMigrate(&models.LicenseSeat{})
+	DB.AutoMigrate(&models.Membership{})
+	DB.AutoMigrate(&models.Member{})
+	DB.AutoMigrate(&models.Bundle{})
+	DB.AutoMigrate(&models.Offer{})
+	DB.AutoMigrate(&models.Affiliate{})
+	DB.AutoMigrate(&models.CommissionRule{})
 	DB.AutoMigrate(&models.Purchase{})
 	DB.AutoMigrate(&models.DownloadLog{})
+	DB.AutoMigrate(&models.FileVersion{})
+	DB.AutoMigrate(&models.Order{})
+	DB.AutoMigrate(&models.OrderItem{})
+	DB.AutoMigrate(&models.Payment{})
+	DB.AutoMigrate(&models.Commission{})
+	DB.AutoMigrate(&models.BalanceEntry{})
+	DB.AutoMigrate(&models.PayoutAccount{})
+	DB.AutoMigrate(&models.Payout{})
+	DB.AutoMigrate(&models.CheckoutAttempt{})
+	DB.AutoMigrate(&models.WebhookEvent{})
+	DB.AutoMigrate(&models.Plan{})
+	DB.AutoMigrate(&models.SubscriptionEvent{})
+
+	migrateEbookPrices()
+	migrateSubscriptionStates()
+	migrateCustomDomainHosts()
+	seedPlans()
+}
+
+// seedPlans cria os planos padrão que ainda não existem e mantém os IDs de
+// preço do Stripe sincronizados com a configuração.
+func seedPlans() {
+	priceIDs := map[string][2]string{
+		models.DefaultPlanSlug: {config.AppConfig.StripePriceID, config.AppConfig.StripePriceIDYearly},
+		"pro":                  {config.AppConfig.StripeProPriceID, config.AppConfig.StripeProPriceIDYearly},
+		"business":             {config.AppConfig.StripeBusinessPriceID, config.AppConfig.StripeBusinessPriceIDYearly},
+	}
+
+	for _, plan := range models.DefaultPlans() {
+		var existing models.Plan
+		err := DB.Where("slug = ?", plan.Slug).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Erro ao buscar plano %s: %v", plan.Slug, err)
+			continue
+		}
+
+		ids := priceIDs[plan.Slug]
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			plan.MonthlyPriceID, plan.YearlyPriceID = ids[0], ids[1]
+			if err := DB.Create(plan).Error; err != nil {
+				log.Printf("Erro ao criar plano %s: %v", plan.Slug, err)
+			}
+			continue
+		}
+
+		updates := map[string]any{}
+		if ids[0] != "" && ids[0] != existing.MonthlyPriceID {
+			updates["monthly_price_id"] = ids[0]
+		}
+		if ids[1] != "" && ids[1] != existing.YearlyPriceID {
+			updates["yearly_price_id"] = ids[1]
+		}
+		if len(updates) > 0 {
+			if err := DB.Model(&existing).Updates(updates).Error; err != nil {
+				log.Printf("Erro ao atualizar preços do plano %s: %v", plan.Slug, err)
+			}
+		}
+	}
+}
+
+// migrateEbookPrices converte a antiga coluna value (float, em reais) para
+// price_amount em centavos e remove a coluna para evitar divergência.
+func migrateEbookPrices() {
+	if !DB.Migrator().HasColumn(&models.Ebook{}, "value") {
+		return
+	}
+
+	err := DB.Exec(`UPDATE ebooks
+		SET price_amount = CAST(ROUND(value * 100) AS BIGINT), price_currency = 'BRL'
+		WHERE value IS NOT NULL AND (price_amount IS NULL OR price_amount = 0)`).Error
+	if err != nil {
+		log.Printf("Erro ao migrar preços dos ebooks: %v", err)
+		return
+	}
+
+	if err := DB.Migrator().DropColumn(&models.Ebook{}, "value"); err != nil {
+		log.Printf("Erro ao remover coluna value dos ebooks: %v", err)
+	}
+}
+
+// migrateSubscriptionStates converte os status livres gravados antes da máquina
+// de estados ("inactive" e os status crus do gateway) para os estados válidos.
+// Pagamentos pendentes sem prazo de tolerância ganham um a partir de agora, como
+// se a falha tivesse acabado de acontecer.
+func migrateSubscriptionStates() {
+	updates := []struct {
+		state string
+		where string
+		args  []any
+	}{
+		{string(models.SubscriptionTrialing), "subscription_status IN ('inactive', '') AND is_trial_active = ? AND trial_end_date > ?", []any{true, time.Now()}},
+		{string(models.SubscriptionExpired), "subscription_status IN ('inactive', '')", nil},
+		{string(models.SubscriptionPastDue), "subscription_status IN ('unpaid', 'incomplete')", nil},
+		{string(models.SubscriptionCanceled), "subscription_status = 'incomplete_expired'", nil},
+	}
+
+	for _, update := range updates {
+		err := DB.Model(&models.Subscription{}).Where(update.where, update.args...).
+			Update("subscription_status", update.state).Error
+		if err != nil {
+			log.Printf("Erro ao migrar status das assinaturas para %s: %v", update.state, err)
+		}
+	}
+
+	now := time.Now()
+	err := DB.Model(&models.Subscription{}).
+		Where("subscription_status = ? AND grace_period_ends_at IS NULL", models.SubscriptionPastDue).
+		Updates(map[string]any{
+			"payment_failed_at":      gorm.Expr("COALESCE(payment_failed_at, ?)", now),
+			"grace_period_ends_at":   now.AddDate(0, 0, config.AppConfig.DunningGraceDays),
+			"dunning_reminders_sent": 0,
+		}).Error
+	if err != nil {
+		log.Printf("Erro ao definir tolerância das assinaturas com pagamento pendente: %v", err)
+	}
+}
+
+// migrateCustomDomainHosts remove o índice único antigo de host, que reservava o
+// domínio já no cadastro; agora só domínios verificados são únicos.
+func migrateCustomDomainHosts() {
+	if !DB.Migrator().HasIndex(&models.CustomDomain{}, "idx_custom_domains_host") {
+		return
+	}
+	if err := DB.Migrator().DropIndex(&models.CustomDomain{}, "idx_custom_domains_host"); err != nil {
+		log.Printf("Erro ao remover índice antigo dos domínios: %v", err)
+	}
 }
 
 func Close() {
@@ -48,13 +48,176 @@ func (s *EmailService) SendAccountConfirmation(name, email, token string) {
 	s.mailer.Send()
 }
 
+// SendDunningReminder avisa o criador sobre o pagamento pendente da assinatura.
+// Quando readOnly é verdadeiro, o período de tolerância já terminou.
+func (s *EmailService) SendDunningReminder(name, email string, daysLeft int, readOnly bool) {
+	subject := "Não conseguimos processar o pagamento da sua assinatura"
+	if readOnly {
+		subject = "Sua conta está em modo somente leitura"
+	}
+
+	data := map[string]interface{}{
+		"Name":       name,
+		"Title":      subject,
+		"AppName":    config.AppConfig.AppName,
+		"Contact":    config.AppConfig.MailFromAddress,
+		"DaysLeft":   daysLeft,
+		"ReadOnly":   readOnly,
+		"ManageLink": fmt.Sprintf("%s:%s/settings/subscription", config.AppConfig.Host, config.AppConfig.Port),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("dunning_reminder", data))
+	s.mailer.Send()
+}
+
+// SendCheckoutRecovery lembra o comprador do checkout não concluído, com o link
+// que retoma a compra e, quando houver, o desconto oferecido
+func (s *EmailService) SendCheckoutRecovery(attempt *models.CheckoutAttempt) {
+	subject := fmt.Sprintf("Você esqueceu algo: %s", attempt.Ebook.Title)
+	if attempt.DiscountPercent > 0 {
+		subject = fmt.Sprintf("%d%% de desconto para concluir sua compra", attempt.DiscountPercent)
+	}
+
+	data := map[string]interface{}{
+		"Name":            attempt.Name,
+		"Title":           subject,
+		"EbookTitle":      attempt.Ebook.Title,
+		"DiscountPercent": attempt.DiscountPercent,
+		"Contact":         config.AppConfig.MailFromAddress,
+		"CheckoutLink": fmt.Sprintf("%s/checkout/%d?recovery=%s",
+			siteURL(&attempt.Ebook.Creator), attempt.EbookID, attempt.Token),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(attempt.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("checkout_recovery", data))
+	s.mailer.Send()
+}
+
+// SendLeadConfirmation envia o link de confirmação do e-mail (double opt-in) que
+// libera o download do ebook gratuito
+func (s *EmailService) SendLeadConfirmation(lead *models.Lead) {
+	subject := fmt.Sprintf("Confirme seu e-mail para receber %s", lead.Ebook.Title)
+
+	data := map[string]interface{}{
+		"Name":       lead.Client.Name,
+		"Title":      subject,
+		"EbookTitle": lead.Ebook.Title,
+		"Contact":    config.AppConfig.MailFromAddress,
+		"ConfirmLink": fmt.Sprintf("%s/lead/confirm/%s",
+			siteURL(&lead.Ebook.Creator), lead.Token),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(lead.Client.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("lead_confirmation", data))
+	s.mailer.Send()
+}
+
+// SendGiftClaimLink envia a quem ganhou o presente o link para resgatar o ebook
+func (s *EmailService) SendGiftClaimLink(gift *models.Gift) {
+	subject := fmt.Sprintf("%s enviou um presente para você", gift.Sender.Name)
+
+	data := map[string]interface{}{
+		"Name":       gift.RecipientName,
+		"Title":      subject,
+		"SenderName": gift.Sender.Name,
+		"EbookTitle": gift.Ebook.Title,
+		"Message":    gift.Message,
+		"Contact":    config.AppConfig.MailFromAddress,
+		"ClaimLink": fmt.Sprintf("%s/gift/%s",
+			siteURL(&gift.Ebook.Creator), gift.Token),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(gift.RecipientEmail)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("gift_claim", data))
+	s.mailer.Send()
+}
+
+// SendLicenseManagerLink envia a quem comprou as licenças para equipe o link da
+// página onde as licenças são atribuídas
+func (s *EmailService) SendLicenseManagerLink(license *models.License) {
+	subject := fmt.Sprintf("Suas %d licenças de %s", license.Seats, license.Ebook.Title)
+
+	data := map[string]interface{}{
+		"Name":       license.Manager.Name,
+		"Title":      subject,
+		"EbookTitle": license.Ebook.Title,
+		"Seats":      license.Seats,
+		"Contact":    config.AppConfig.MailFromAddress,
+		"ManageLink": fmt.Sprintf("%s:%s/license/%s",
+			config.AppConfig.Host, config.AppConfig.Port, license.Token),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(license.Manager.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("license_manager", data))
+	s.mailer.Send()
+}
+
+// SendMembershipWelcome envia ao novo assinante o link da área do assinante, onde
+// ficam os ebooks do catálogo do criador
+func (s *EmailService) SendMembershipWelcome(member *models.Member) {
+	subject := fmt.Sprintf("Bem-vindo à assinatura %s", member.Membership.Name)
+
+	data := map[string]interface{}{
+		"Name":           member.Client.Name,
+		"Title":          subject,
+		"MembershipName": member.Membership.Name,
+		"CreatorName":    member.Membership.Creator.Name,
+		"Contact":        config.AppConfig.MailFromAddress,
+		"MemberLink": fmt.Sprintf("%s:%s/membership/%s",
+			config.AppConfig.Host, config.AppConfig.Port, member.Token),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(member.Client.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("membership_welcome", data))
+	s.mailer.Send()
+}
+
+// SendMembershipEnded avisa o assinante que a assinatura terminou e o acesso ao
+// catálogo foi encerrado
+func (s *EmailService) SendMembershipEnded(member *models.Member) {
+	subject := fmt.Sprintf("Sua assinatura %s foi encerrada", member.Membership.Name)
+
+	data := map[string]interface{}{
+		"Name":           member.Client.Name,
+		"Title":          subject,
+		"MembershipName": member.Membership.Name,
+		"CreatorName":    member.Membership.Creator.Name,
+		"Contact":        config.AppConfig.MailFromAddress,
+		"SubscribeLink": fmt.Sprintf("%s:%s/m/%d",
+			config.AppConfig.Host, config.AppConfig.Port, member.MembershipID),
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(member.Client.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("membership_ended", data))
+	s.mailer.Send()
+}
+
+// SendLinkToDownload envia os links de download das compras. Compras do mesmo
+// cliente (como as de um carrinho) são entregues em um único e-mail. Compras em
+// pré-venda recebem apenas a confirmação; o link vai no lançamento.
 func (s *EmailService) SendLinkToDownload(purchases []*models.Purchase) {
 	log.Printf("📧 SendLinkToDownload chamado com %d purchase(s)", len(purchases))
 
+	var emails []string
+	byEmail := map[string][]*models.Purchase{}
 	for i, purchase := range purchases {
 		log.Printf("📧 Processando purchase %d/%d", i+1, len(purchases))
 		log.Printf("📧 Purchase ID=%d, ClientID=%d", purchase.ID, purchase.ClientID)
-		log.Printf("📧 Client struct: %+v", purchase.Client)
 		log.Printf("📧 Client ID=%d, Name='%s', Email='%s'",
 			purchase.Client.ID, purchase.Client.Name, purchase.Client.Email)
 
@@ -70,22 +233,146 @@ func (s *EmailService) SendLinkToDownload(purchases []*models.Purchase) {
 			continue
 		}
 
-		data := map[string]interface{}{
-			"Name":              purchase.Client.Name,
-			"Title":             "Seu e-book chegou!",
-			"AppName":           config.AppConfig.AppName,
-			"Contact":           config.AppConfig.MailFromAddress,
-			"EbookDownloadLink": fmt.Sprintf("%s:%s/purchase/download/%d", config.AppConfig.Host, config.AppConfig.Port, purchase.ID),
-			"Ebook":             purchase.Ebook,
-			"Files":             purchase.Ebook.Files,
-			"FileCount":         len(purchase.Ebook.Files),
+		if purchase.AwaitingRelease {
+			s.sendPreSaleConfirmation(purchase)
+			continue
+		}
+
+		if _, ok := byEmail[purchase.Client.Email]; !ok {
+			emails = append(emails, purchase.Client.Email)
+		}
+		byEmail[purchase.Client.Email] = append(byEmail[purchase.Client.Email], purchase)
+	}
+
+	for _, email := range emails {
+		clientPurchases := byEmail[email]
+		if len(clientPurchases) == 1 {
+			s.sendSingleDownload(clientPurchases[0])
+			continue
 		}
+		s.sendOrderDownload(clientPurchases)
+	}
+}
+
+func (s *EmailService) sendPreSaleConfirmation(purchase *models.Purchase) {
+	subject := fmt.Sprintf("Pré-venda confirmada: %s", purchase.Ebook.Title)
+
+	data := map[string]interface{}{
+		"Name":    purchase.Client.Name,
+		"Title":   subject,
+		"AppName": config.AppConfig.AppName,
+		"Contact": config.AppConfig.MailFromAddress,
+		"Ebook":   purchase.Ebook,
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(purchase.Client.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("presale_confirmed", data))
+	s.mailer.Send()
+}
+
+func (s *EmailService) sendSingleDownload(purchase *models.Purchase) {
+	data := map[string]interface{}{
+		"Name":              purchase.Client.Name,
+		"Title":             "Seu e-book chegou!",
+		"AppName":           config.AppConfig.AppName,
+		"Contact":           config.AppConfig.MailFromAddress,
+		"EbookDownloadLink": downloadLink(purchase),
+		"Ebook":             purchase.Ebook,
+		"Files":             purchase.Ebook.Files,
+		"FileCount":         len(purchase.Ebook.Files),
+	}
 
-		log.Printf("Configurando email para: %s", purchase.Client.Email)
-		s.mailer.From(config.AppConfig.MailFromAddress)
-		s.mailer.To(purchase.Client.Email)
-		s.mailer.Subject("Seu e-book chegou!")
-		s.mailer.Body(NewEmail("ebook_download", data))
-		s.mailer.Send()
+	log.Printf("Configurando email para: %s", purchase.Client.Email)
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(purchase.Client.Email)
+	s.mailer.Subject("Seu e-book chegou!")
+	s.mailer.Body(NewEmail("ebook_download", data))
+	s.mailer.Send()
+}
+
+func (s *EmailService) sendOrderDownload(purchases []*models.Purchase) {
+	type downloadItem struct {
+		Ebook        models.Ebook
+		FileCount    int
+		DownloadLink string
 	}
+
+	items := make([]downloadItem, 0, len(purchases))
+	for _, purchase := range purchases {
+		items = append(items, downloadItem{
+			Ebook:        purchase.Ebook,
+			FileCount:    len(purchase.Ebook.Files),
+			DownloadLink: downloadLink(purchase),
+		})
+	}
+
+	client := purchases[0].Client
+	data := map[string]interface{}{
+		"Name":    client.Name,
+		"Title":   "Seus e-books chegaram!",
+		"AppName": config.AppConfig.AppName,
+		"Contact": config.AppConfig.MailFromAddress,
+		"Items":   items,
+	}
+
+	log.Printf("Configurando email com %d e-books para: %s", len(items), client.Email)
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(client.Email)
+	s.mailer.Subject("Seus e-books chegaram!")
+	s.mailer.Body(NewEmail("ebooks_download", data))
+	s.mailer.Send()
+}
+
+// SendFilesUnlocked avisa o comprador que novos arquivos do ebook foram liberados
+// pelo cronograma do criador
+func (s *EmailService) SendFilesUnlocked(purchase *models.Purchase, files []*models.ScheduledFile) {
+	subject := fmt.Sprintf("Novo conteúdo liberado: %s", purchase.Ebook.Title)
+
+	data := map[string]interface{}{
+		"Name":              purchase.Client.Name,
+		"Title":             subject,
+		"AppName":           config.AppConfig.AppName,
+		"Contact":           config.AppConfig.MailFromAddress,
+		"EbookDownloadLink": downloadLink(purchase),
+		"Ebook":             purchase.Ebook,
+		"Files":             files,
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(purchase.Client.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("files_unlocked", data))
+	s.mailer.Send()
+}
+
+func (s *EmailService) SendNewVersionAvailable(purchase *models.Purchase, files []*models.File) {
+	subject := fmt.Sprintf("Nova versão disponível: %s", purchase.Ebook.Title)
+
+	data := map[string]interface{}{
+		"Name":              purchase.Client.Name,
+		"Title":             subject,
+		"AppName":           config.AppConfig.AppName,
+		"Contact":           config.AppConfig.MailFromAddress,
+		"EbookDownloadLink": downloadLink(purchase),
+		"Ebook":             purchase.Ebook,
+		"Files":             files,
+	}
+
+	s.mailer.From(config.AppConfig.MailFromAddress)
+	s.mailer.To(purchase.Client.Email)
+	s.mailer.Subject(subject)
+	s.mailer.Body(NewEmail("new_version", data))
+	s.mailer.Send()
+}
+
+func downloadLink(purchase *models.Purchase) string {
+	return fmt.Sprintf("%s/purchase/download/%d", siteURL(&purchase.Ebook.Creator), purchase.ID)
+}
+
+// siteURL é a base dos links enviados ao comprador: o domínio próprio do
+// criador, quando verificado, ou o domínio da plataforma
+func siteURL(creator *models.Creator) string {
+	return creator.SiteURL(fmt.Sprintf("%s:%s", config.AppConfig.Host, config.AppConfig.Port))
 }
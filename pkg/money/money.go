@@ -0,0 +1,176 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const (
+	BRL Currency = "BRL"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+)
+
+var (
+	ErrInvalidAmount       = errors.New("valor inválido")
+	ErrUnsupportedCurrency = errors.New("moeda não suportada")
+)
+
+// locale define como os valores de cada moeda são exibidos
+type locale struct {
+	symbol      string
+	decimal     string
+	thousands   string
+	symbolAfter bool
+	spaced      bool
+}
+
+var locales = map[Currency]locale{
+	BRL: {symbol: "R$", decimal: ",", thousands: ".", spaced: true},
+	USD: {symbol: "$", decimal: ".", thousands: ","},
+	EUR: {symbol: "€", decimal: ",", thousands: ".", symbolAfter: true, spaced: true},
+}
+
+// SupportedCurrencies retorna as moedas aceitas para precificação
+func SupportedCurrencies() []Currency {
+	return []Currency{BRL, USD, EUR}
+}
+
+// ParseCurrency normaliza um código ISO 4217 (ex: "brl" -> BRL)
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := locales[currency]; !ok {
+		return "", ErrUnsupportedCurrency
+	}
+	return currency, nil
+}
+
+// Code retorna o código ISO da moeda em minúsculas, formato esperado pelo gateway
+func (c Currency) Code() string {
+	return strings.ToLower(string(c))
+}
+
+func (c Currency) Symbol() string {
+	return c.locale().symbol
+}
+
+func (c Currency) locale() locale {
+	if l, ok := locales[c]; ok {
+		return l
+	}
+	return locales[BRL]
+}
+
+// Money representa um valor monetário na menor unidade da moeda (centavos)
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency" gorm:"size:3;default:'BRL'"`
+}
+
+func New(amount int64, currency Currency) Money {
+	if currency == "" {
+		currency = BRL
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Parse converte um valor digitado (ex: "29,90", "1.234,56", "29.90") para Money
+// sem passar por ponto flutuante.
+func Parse(input string, currency Currency) (Money, error) {
+	value := strings.TrimSpace(input)
+	value = strings.TrimPrefix(value, currency.Symbol())
+	value = strings.TrimSuffix(value, currency.Symbol())
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if value == "" {
+		return Money{}, ErrInvalidAmount
+	}
+
+	integer, fraction := splitDecimal(value)
+	integer = strings.NewReplacer(".", "", ",", "").Replace(integer)
+	if integer == "" {
+		integer = "0"
+	}
+	if len(fraction) > 2 {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	units, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || units < 0 {
+		return Money{}, ErrInvalidAmount
+	}
+	cents, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+
+	return New(units*100+cents, currency), nil
+}
+
+// splitDecimal identifica o separador decimal: o último "," ou "." seguido de
+// até dois dígitos. Seguido de três dígitos, o separador é tratado como milhar.
+func splitDecimal(value string) (string, string) {
+	idx := strings.LastIndexAny(value, ".,")
+	if idx < 0 || len(value)-idx-1 == 3 {
+		return value, ""
+	}
+	return value[:idx], value[idx+1:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) Add(other Money) Money {
+	return New(m.Amount+other.Amount, m.Currency)
+}
+
+func (m Money) Sub(other Money) Money {
+	return New(m.Amount-other.Amount, m.Currency)
+}
+
+// Percent retorna a porcentagem informada do valor, arredondada para o centavo mais próximo
+func (m Money) Percent(percent int64) Money {
+	return New((m.Amount*percent+50)/100, m.Currency)
+}
+
+// Decimal retorna o valor sem símbolo no formato da moeda (ex: "1.234,56"),
+// usado para preencher campos de formulário.
+func (m Money) Decimal() string {
+	l := m.Currency.locale()
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	integer := strconv.FormatInt(amount/100, 10)
+	for i := len(integer) - 3; i > 0; i -= 3 {
+		integer = integer[:i] + l.thousands + integer[i:]
+	}
+
+	return fmt.Sprintf("%s%s%s%02d", sign, integer, l.decimal, amount%100)
+}
+
+// Format retorna o valor formatado conforme a moeda (ex: "R$ 29,90", "$29.90", "29,90 €")
+func (m Money) Format() string {
+	l := m.Currency.locale()
+	separator := ""
+	if l.spaced {
+		separator = " "
+	}
+	if l.symbolAfter {
+		return m.Decimal() + separator + l.symbol
+	}
+	return l.symbol + separator + m.Decimal()
+}
+
+func (m Money) String() string {
+	return m.Format()
+}
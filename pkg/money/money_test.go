@@ -0,0 +1,65 @@
+package money_test
+
+import (
+	"testing"
+
+	"github.com/anglesson/simple-web-server/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency money.Currency
+		expected int64
+	}{
+		{"comma decimal", "19,99", money.BRL, 1999},
+		{"dot decimal", "19.99", money.BRL, 1999},
+		{"thousands and decimal", "1.234,56", money.BRL, 123456},
+		{"usd thousands and decimal", "1,234.56", money.USD, 123456},
+		{"thousands only", "1.234", money.BRL, 123400},
+		{"single decimal digit", "29,9", money.BRL, 2990},
+		{"with symbol", "R$ 29,90", money.BRL, 2990},
+		{"integer", "30", money.EUR, 3000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := money.Parse(tt.input, tt.currency)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, value.Amount)
+			assert.Equal(t, tt.currency, value.Currency)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{"", "abc", "-10,00", "10,9999"} {
+		_, err := money.Parse(input, money.BRL)
+		assert.ErrorIs(t, err, money.ErrInvalidAmount, input)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "R$ 1.234,56", money.New(123456, money.BRL).Format())
+	assert.Equal(t, "$1,234.56", money.New(123456, money.USD).Format())
+	assert.Equal(t, "1.234,56 €", money.New(123456, money.EUR).Format())
+	assert.Equal(t, "R$ 0,05", money.New(5, money.BRL).Format())
+	assert.Equal(t, "29.90", money.New(2990, money.USD).Decimal())
+}
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := money.ParseCurrency("brl")
+	assert.NoError(t, err)
+	assert.Equal(t, money.BRL, currency)
+	assert.Equal(t, "brl", currency.Code())
+
+	_, err = money.ParseCurrency("jpy")
+	assert.ErrorIs(t, err, money.ErrUnsupportedCurrency)
+}
+
+func TestPercent(t *testing.T) {
+	assert.Equal(t, int64(2999), money.New(1999, money.BRL).Percent(150).Amount)
+}
@@ -51,6 +51,8 @@ func convertFieldName(fieldName string) string {
 		return "sales_page"
 	case "Value":
 		return "value"
+	case "Currency":
+		return "currency"
 	case "Status":
 		return "status"
 	default: